@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/archive"
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runArchive переносит холодные локации в архивный индекс и выводит отчет в JSON.
+// С флагом -dry-run только перечисляет кандидатов.
+func runArchive(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("archive", flag.ExitOnError)
+	months := fs.Int("months", cfg.ArchiveAfterMonths, "срок без обновлений и показов, после которого локация архивируется, месяцы")
+	dryRun := fs.Bool("dry-run", false, "только найти кандидатов, не перенося их")
+	fs.Parse(args)
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	archiver := archive.NewArchiver(esStorage, pgStorage, time.Duration(*months)*30*24*time.Hour)
+
+	report, err := archiver.Run(context.Background(), *dryRun)
+	if err != nil {
+		logging.Fatal("Error archiving locations", logging.Err(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logging.Fatal("Error encoding report", logging.Err(err))
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// errEnoughQueries останавливает обход индекса после сбора нужного числа векторов-запросов.
+var errEnoughQueries = errors.New("enough query vectors collected")
+
+// runBenchmarkKNN измеряет recall и задержку приближенного kNN поиска относительно точного
+// поиска на текущих данных индекса для нескольких значений num_candidates.
+// Результаты помогают подобрать параметры HNSW (m, ef_construction) и num_candidates.
+func runBenchmarkKNN(esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("benchmark-knn", flag.ExitOnError)
+	queries := fs.Int("queries", 50, "количество векторов-запросов, берутся из документов индекса")
+	k := fs.Int("k", 10, "количество ближайших соседей")
+	candidatesFlag := fs.String("num-candidates", "20,50,100,200", "значения num_candidates через запятую")
+	fs.Parse(args)
+
+	candidates, err := parseIntList(*candidatesFlag)
+	if err != nil {
+		logging.Fatal("Invalid -num-candidates", logging.Err(err))
+	}
+
+	ctx := context.Background()
+
+	current, err := esStorage.GetVectorIndexOptions(ctx)
+	if err != nil {
+		logging.Fatal("Error reading vector index options", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Index vector options", "similarity", current.Similarity, "m", current.M,
+		"ef_construction", current.EfConstruction, "dims", current.Dims)
+
+	vectors := make([][]float64, 0, *queries)
+	err = esStorage.ScanLocations(ctx, &models.LocationFilter{}, *queries, func(locations []*models.Location) error {
+		for _, location := range locations {
+			if len(location.Embedding) > 0 {
+				vectors = append(vectors, location.Embedding)
+			}
+			if len(vectors) >= *queries {
+				return errEnoughQueries
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughQueries) {
+		logging.Fatal("Error collecting query vectors", logging.Err(err))
+	}
+	if len(vectors) == 0 {
+		logging.Fatal("No documents with embeddings found")
+	}
+
+	// Эталонные результаты точного поиска
+	exact := make([]map[string]bool, len(vectors))
+	var exactLatencies []time.Duration
+	for i, vector := range vectors {
+		start := time.Now()
+		result, err := esStorage.ExactVectorSearch(ctx, vector, *k, current.Similarity)
+		if err != nil {
+			logging.Fatal("Error running exact search", logging.Err(err))
+		}
+		exactLatencies = append(exactLatencies, time.Since(start))
+
+		exact[i] = make(map[string]bool, len(result.IDs))
+		for _, id := range result.IDs {
+			exact[i][id] = true
+		}
+	}
+
+	fmt.Printf("queries=%d k=%d\n", len(vectors), *k)
+	fmt.Printf("%-16s %-8s %-10s %-10s\n", "num_candidates", "recall", "p50", "p95")
+	fmt.Printf("%-16s %-8s %-10s %-10s\n", "exact", "1.000", percentile(exactLatencies, 0.5), percentile(exactLatencies, 0.95))
+
+	for _, numCandidates := range candidates {
+		if numCandidates < *k {
+			slog.WarnContext(ctx, "Skipping num_candidates: must be >= k", "num_candidates", numCandidates, "k", *k)
+			continue
+		}
+
+		var latencies []time.Duration
+		var recallSum float64
+		for i, vector := range vectors {
+			start := time.Now()
+			result, err := esStorage.KNNSearch(ctx, vector, *k, numCandidates)
+			if err != nil {
+				logging.Fatal("Error running kNN search", logging.Err(err))
+			}
+			latencies = append(latencies, time.Since(start))
+
+			if len(exact[i]) == 0 {
+				recallSum++
+				continue
+			}
+			found := 0
+			for _, id := range result.IDs {
+				if exact[i][id] {
+					found++
+				}
+			}
+			recallSum += float64(found) / float64(len(exact[i]))
+		}
+
+		fmt.Printf("%-16d %-8.3f %-10s %-10s\n", numCandidates, recallSum/float64(len(vectors)),
+			percentile(latencies, 0.5), percentile(latencies, 0.95))
+	}
+}
+
+// percentile возвращает перцентиль p (0..1) для набора длительностей.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx].Round(time.Microsecond)
+}
+
+// parseIntList разбирает список целых чисел, разделенных запятыми.
+func parseIntList(value string) ([]int, error) {
+	var result []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/refresh"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runEducation загружает справочник учебных заведений из CSV файла в PostgreSQL, заменяя текущий.
+// Формат строки: name,kind,lat,lon, где kind — school или university. С флагом -recompute сразу
+// пересчитывает schools_nearby и universities_nearby локаций.
+func runEducation(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("education", flag.ExitOnError)
+	file := fs.String("file", "", "CSV файл с учебными заведениями (name,kind,lat,lon)")
+	recompute := fs.Bool("recompute", false, "пересчитать число учебных заведений рядом с локациями после загрузки")
+	walk := fs.Float64("walk", cfg.WalkingDistanceMeters, "пешая доступность, метры")
+	fs.Parse(args)
+
+	if *file == "" {
+		logging.Fatal("Flag -file is required")
+	}
+
+	institutions, err := loadEducationInstitutions(*file)
+	if err != nil {
+		logging.Fatal("Error reading education institutions", logging.Err(err))
+	}
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	ctx := context.Background()
+	if err := pgStorage.ReplaceEducationInstitutions(ctx, institutions); err != nil {
+		logging.Fatal("Error saving education institutions", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Loaded education institutions", "count", len(institutions))
+
+	if !*recompute {
+		return
+	}
+	updated, err := refresh.RecomputeEducation(ctx, esStorage, pgStorage, institutions, *walk)
+	if err != nil {
+		logging.Fatal("Error recomputing education proximity", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Education proximity updated", "values", updated)
+}
+
+// loadEducationInstitutions читает и проверяет учебные заведения из CSV файла.
+// Строка заголовка, если она есть, пропускается.
+func loadEducationInstitutions(filename string) ([]models.EducationInstitution, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 4
+
+	var institutions []models.EducationInstitution
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.TrimSpace(record[2]) == "lat" {
+			continue
+		}
+
+		kind := strings.TrimSpace(record[1])
+		if kind != "school" && kind != "university" {
+			return nil, fmt.Errorf("line %d: kind must be school or university", line)
+		}
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		lon, lonErr := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+
+		institutions = append(institutions, models.EducationInstitution{
+			Name:        strings.TrimSpace(record[0]),
+			Kind:        kind,
+			Coordinates: models.GeoPoint{Lat: lat, Lon: lon},
+		})
+	}
+
+	return institutions, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/evaluation"
+	"github.com/akozadaev/go_es_analytical_system/internal/footfall"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/routing"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// rankingConfig — конфигурация ранжирования для офлайн-оценки.
+type rankingConfig struct {
+	Index   string                `json:"index"`   // Индекс локаций (по умолчанию locations)
+	Request json.RawMessage       `json:"request"` // Поля, переопределяющие сохраненный запрос
+	Boosts  []models.ScoringBoost `json:"boosts"`  // Профиль ранжирования вместо профиля типа бизнеса
+}
+
+// runEvaluate повторяет сохраненные запросы из истории на двух конфигурациях ранжирования
+// (или двух индексах) и сравнивает выдачи: NDCG@k по отзывам пользователей (статусы кандидатов
+// в проектах и оценки в заметках), пересечение top-k и сдвиг оценок общих локаций.
+// Кеш и история запросов сервиса не затрагиваются.
+func runEvaluate(cfg *config.Config, esClient *elasticsearch.Client, args []string) {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	baselineFile := fs.String("baseline", "", "JSON файл базовой конфигурации (по умолчанию — текущая)")
+	candidateFile := fs.String("candidate", "", "JSON файл проверяемой конфигурации")
+	since := fs.Duration("since", 30*24*time.Hour, "период истории запросов")
+	queries := fs.Int("queries", 500, "максимальное количество запросов")
+	k := fs.Int("k", 10, "глубина выдачи для метрик")
+	fs.Parse(args)
+
+	if *candidateFile == "" {
+		logging.Fatal("Flag -candidate is required")
+	}
+	baseline, err := loadRankingConfig(*baselineFile)
+	if err != nil {
+		logging.Fatal("Error reading baseline config", logging.Err(err))
+	}
+	candidate, err := loadRankingConfig(*candidateFile)
+	if err != nil {
+		logging.Fatal("Error reading candidate config", logging.Err(err))
+	}
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	footfallModel, err := footfall.New(cfg.FootfallModel, cfg.FootfallModelPath, cfg.FootfallModelURL, cfg.FootfallModelVersion)
+	if err != nil {
+		logging.Fatal("Error loading footfall model", logging.Err(err))
+	}
+	router := routing.New(cfg.RoutingProvider, cfg.RoutingURL, cfg.RoutingBatchSize, cfg.RoutingSpeedKmh,
+		time.Duration(cfg.RoutingCacheTTLMinutes)*time.Minute)
+	newService := func(rc *rankingConfig) *service.RecommendationService {
+		es := storage.NewElasticsearchStorageWithURL(esClient, rc.Index, cfg.ElasticsearchURL)
+		return service.NewRecommendationService(es, pgStorage, 0, cfg.RecommendMaxLimit, router, footfallModel, service.RelaxationPolicy{})
+	}
+	baselineService, candidateService := newService(baseline), newService(candidate)
+
+	ctx := context.Background()
+
+	requests, err := pgStorage.RecentQueries(ctx, time.Now().Add(-*since), *queries)
+	if err != nil {
+		logging.Fatal("Error loading query history", logging.Err(err))
+	}
+	labels, err := pgStorage.RelevanceLabels(ctx)
+	if err != nil {
+		logging.Fatal("Error loading relevance labels", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Replaying queries", "queries", len(requests), "labeled_locations", len(labels))
+
+	var summary evaluation.Summary
+	for i := range requests {
+		baselineRanked, err := replay(ctx, baselineService, baseline, requests[i])
+		if err != nil {
+			slog.WarnContext(ctx, "Baseline query failed", "query", i+1, logging.Err(err))
+			summary.Failed++
+			continue
+		}
+		candidateRanked, err := replay(ctx, candidateService, candidate, requests[i])
+		if err != nil {
+			slog.WarnContext(ctx, "Candidate query failed", "query", i+1, logging.Err(err))
+			summary.Failed++
+			continue
+		}
+		summary.Add(evaluation.Compare(baselineRanked, candidateRanked, labels, *k))
+	}
+	summary.Finish()
+
+	fmt.Printf("queries=%d labeled=%d failed=%d k=%d\n", summary.Queries, summary.Labeled, summary.Failed, *k)
+	fmt.Printf("%-16s %-10s %-10s\n", "metric", "baseline", "candidate")
+	fmt.Printf("%-16s %-10.4f %-10.4f\n", "ndcg", summary.BaselineNDCG, summary.CandidateNDCG)
+	fmt.Printf("improved=%d degraded=%d\n", summary.Improved, summary.Degraded)
+	fmt.Printf("overlap=%.3f score_shift=%.4f rank_shift=%.2f\n", summary.Overlap, summary.ScoreShift, summary.RankShift)
+}
+
+// replay выполняет сохраненный запрос в конфигурации rc.
+func replay(ctx context.Context, svc *service.RecommendationService, rc *rankingConfig, saved models.RecommendRequest) ([]evaluation.Ranked, error) {
+	// Запрос копируется через JSON: переопределения не должны менять вложенные значения сохраненного запроса
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var req models.RecommendRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+	if len(rc.Request) > 0 {
+		if err := json.Unmarshal(rc.Request, &req); err != nil {
+			return nil, fmt.Errorf("failed to apply request overrides: %w", err)
+		}
+	}
+	if rc.Boosts != nil {
+		req.Boosts = rc.Boosts
+	}
+
+	locations, err := svc.Replay(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]evaluation.Ranked, len(locations))
+	for i, loc := range locations {
+		ranked[i] = evaluation.Ranked{ID: loc.ID, Score: loc.Score}
+	}
+	return ranked, nil
+}
+
+// loadRankingConfig читает конфигурацию ранжирования. Пустой путь — текущая конфигурация.
+func loadRankingConfig(path string) (*rankingConfig, error) {
+	rc := &rankingConfig{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, rc); err != nil {
+			return nil, err
+		}
+	}
+	if rc.Index == "" {
+		rc.Index = "locations"
+	}
+	return rc, nil
+}
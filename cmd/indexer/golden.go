@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/footfall"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/routing"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runGolden прогоняет эталонные запросы по текущему индексу и выводит отклонения.
+// С флагом -file предварительно загружает эталонные запросы из JSON фикстуры (массив объектов
+// с полями id, name, request, expected_ids, top_k), заменяя запросы с теми же ID.
+// Завершается с кодом 1, если хотя бы один запрос не прошел проверку, — для запуска после загрузки данных.
+func runGolden(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("golden", flag.ExitOnError)
+	file := fs.String("file", "", "JSON фикстура эталонных запросов для загрузки перед прогоном")
+	fs.Parse(args)
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	footfallModel, err := footfall.New(cfg.FootfallModel, cfg.FootfallModelPath, cfg.FootfallModelURL, cfg.FootfallModelVersion)
+	if err != nil {
+		logging.Fatal("Error loading footfall model", logging.Err(err))
+	}
+	router := routing.New(cfg.RoutingProvider, cfg.RoutingURL, cfg.RoutingBatchSize, cfg.RoutingSpeedKmh,
+		time.Duration(cfg.RoutingCacheTTLMinutes)*time.Minute)
+	recommendations := service.NewRecommendationService(esStorage, pgStorage, 0, cfg.RecommendMaxLimit, router, footfallModel, service.RelaxationPolicy{})
+	golden := service.NewGoldenQueryService(recommendations, pgStorage)
+
+	ctx := context.Background()
+
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			logging.Fatal("Error reading golden queries", logging.Err(err))
+		}
+		var queries []models.GoldenQuery
+		if err := json.Unmarshal(data, &queries); err != nil {
+			logging.Fatal("Error parsing golden queries", logging.Err(err))
+		}
+		for i := range queries {
+			if err := golden.Save(ctx, &queries[i]); err != nil {
+				logging.Fatal("Error saving golden query", slog.String("query_id", queries[i].ID), logging.Err(err))
+			}
+		}
+		slog.InfoContext(ctx, "Loaded golden queries", "count", len(queries))
+	}
+
+	report, err := golden.Run(ctx)
+	if err != nil {
+		logging.Fatal("Error running golden queries", logging.Err(err))
+	}
+
+	fmt.Printf("%-24s %-6s %s\n", "id", "status", "deviation")
+	for _, result := range report.Results {
+		status, deviation := "ok", ""
+		switch {
+		case result.Error != "":
+			status, deviation = "error", result.Error
+		case !result.Passed:
+			status, deviation = "fail", "missing "+strings.Join(result.Missing, ",")+"; actual "+strings.Join(result.Actual, ",")
+		}
+		fmt.Printf("%-24s %-6s %s\n", result.ID, status, deviation)
+	}
+	fmt.Printf("total=%d passed=%d failed=%d took=%dms\n", report.Total, report.Passed, report.Failed, report.DurationMs)
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
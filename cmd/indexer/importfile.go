@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/importer"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// loadLocationsFromFormat читает локации из файла filename функцией read (importer.ReadCSV или
+// importer.ReadGeoJSON) и проверяет их так же, как validate-import, в том числе по профилям
+// проверки profiles и справочнику городов cities (nil — без этих проверок). Записи с ошибками останавливают загрузку, а с
+// skipInvalid пропускаются; предупреждения только выводятся в лог.
+// Локации без id получают ID с префиксом idPrefix, вычисленный по региону, адресу и координатам, —
+// повторная загрузка файла обновляет их, а не создает дубликаты. Состояние уже проиндексированных
+// локаций переносит mergeExistingLocations.
+func loadLocationsFromFormat(filename string, read func(io.Reader) ([]importer.Record, error), idPrefix string, skipInvalid bool, profiles map[string]*models.ValidationProfile, cities models.KnownCities) []*models.Location {
+	f, err := os.Open(filename)
+	if err != nil {
+		logging.Fatal("Error opening import file", logging.Err(err))
+	}
+	records, err := read(f)
+	f.Close()
+	if err != nil {
+		logging.Fatal("Error reading import file", slog.String("file", filename), logging.Err(err))
+	}
+
+	report := importer.Validate(filename, records, nil, importer.Options{
+		DuplicateRadiusMeters: 10,
+		DuplicateThreshold:    1,
+		Profiles:              profiles,
+		Cities:                cities,
+	})
+	invalid := make(map[string]bool)
+	for _, issue := range report.Issues {
+		level := slog.LevelWarn
+		if issue.Severity == importer.SeverityError {
+			level = slog.LevelError
+		}
+		slog.Log(context.Background(), level, "Import issue", "ref", issue.Ref, "check", issue.Check, "message", issue.Message)
+		if issue.Severity == importer.SeverityError {
+			invalid[issue.Ref] = true
+		}
+	}
+	if len(invalid) > 0 && !skipInvalid {
+		logging.Fatal("Import file has invalid records; fix them (see validate-import) or use -skip-invalid",
+			slog.String("file", filename), slog.Int("invalid", len(invalid)))
+	}
+
+	now := time.Now()
+	locations := make([]*models.Location, 0, len(records))
+	for _, record := range records {
+		if invalid[record.Ref] {
+			continue
+		}
+		location := record.Location
+		if location.ID == "" {
+			location.ID = importedLocationID(idPrefix, location)
+		}
+		location.CreatedAt = now
+		location.UpdatedAt = now
+		locations = append(locations, location)
+	}
+	if len(invalid) > 0 {
+		slog.Warn("Skipped invalid records", "skipped", len(invalid), "records", len(records))
+	}
+
+	return locations
+}
+
+// mergeBatchSize — число локаций в одном запросе _mget при слиянии с проиндексированными.
+const mergeBatchSize = 1000
+
+// mergeExistingLocations переносит в загружаемые локации состояние уже проиндексированных
+// документов с теми же ID (в том числе из архива): время создания, историю демографии и,
+// если запись файла его не задает, состояние жизненного цикла со сроком показа. Демографические
+// данные записи добавляются в историю новой версией, если они изменились, — как при записи через API.
+func mergeExistingLocations(ctx context.Context, esStorage *storage.ElasticsearchStorage, locations []*models.Location) {
+	now := time.Now()
+	var merged int
+	for start := 0; start < len(locations); start += mergeBatchSize {
+		batch := locations[start:min(start+mergeBatchSize, len(locations))]
+		ids := make([]string, len(batch))
+		for i, location := range batch {
+			ids[i] = location.ID
+		}
+		existing, err := esStorage.MultiGetLocations(ctx, ids, true)
+		if err != nil {
+			logging.Fatal("Error loading indexed locations", logging.Err(err))
+		}
+
+		for _, location := range batch {
+			if current, ok := existing[location.ID]; ok {
+				location.CreatedAt = current.CreatedAt
+				location.DemographicsHistory = current.DemographicsHistory
+				if location.Status == "" {
+					location.Status = current.Status
+					location.PublishAt = current.PublishAt
+					location.ExpireAt = current.ExpireAt
+				}
+				merged++
+			}
+			location.RecordDemographics(now)
+		}
+	}
+	if merged > 0 {
+		slog.InfoContext(ctx, "Merged locations with already indexed documents", "merged", merged)
+	}
+}
+
+// importedLocationID вычисляет ID локации без id по региону, адресу и координатам.
+func importedLocationID(prefix string, location *models.Location) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%.6f|%.6f",
+		location.Region, location.Address, location.Coordinates.Lat, location.Coordinates.Lon)))
+	return prefix + hex.EncodeToString(sum[:8])
+}
@@ -3,14 +3,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/akozadaev/go_es_analytical_system/internal/anomaly"
+	"github.com/akozadaev/go_es_analytical_system/internal/bulkload"
 	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+	"github.com/akozadaev/go_es_analytical_system/internal/httpproxy"
+	"github.com/akozadaev/go_es_analytical_system/internal/importer"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
 	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
 	"github.com/akozadaev/go_es_analytical_system/internal/storage"
 	"github.com/elastic/go-elasticsearch/v8"
 )
@@ -18,6 +27,13 @@ import (
 func main() {
 	cfg := config.Load()
 
+	if err := logging.Setup(os.Stderr, cfg.LogLevel); err != nil {
+		logging.Fatal("Invalid LOG_LEVEL", logging.Err(err))
+	}
+	if err := httpproxy.Install(httpproxy.Config{URL: cfg.OutboundProxyURL, NoProxy: cfg.OutboundNoProxy}); err != nil {
+		logging.Fatal("Error configuring outbound proxy", logging.Err(err))
+	}
+
 	// Инициализация Elasticsearch клиента
 	esCfg := elasticsearch.Config{
 		Addresses:         []string{cfg.ElasticsearchURL},
@@ -26,22 +42,200 @@ func main() {
 
 	esClient, err := elasticsearch.NewClient(esCfg)
 	if err != nil {
-		log.Fatalf("Error creating Elasticsearch client: %v", err)
+		logging.Fatal("Error creating Elasticsearch client", logging.Err(err))
 	}
 
 	esStorage := storage.NewElasticsearchStorageWithURL(esClient, "locations", cfg.ElasticsearchURL)
 
-	// Генерация тестовых данных
-	locations := generateSampleLocations(100)
+	// Подкоманды; аргументы, начинающиеся с "-", относятся к загрузке тестовых данных
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		switch os.Args[1] {
+		case "benchmark-knn":
+			runBenchmarkKNN(esStorage, os.Args[2:])
+			return
+		case "reconcile":
+			runReconcile(cfg, esStorage, os.Args[2:])
+			return
+		case "archive":
+			runArchive(cfg, esStorage, os.Args[2:])
+			return
+		case "seasonality":
+			runSeasonality(cfg, os.Args[2:])
+			return
+		case "venues":
+			runVenues(cfg, esStorage, os.Args[2:])
+			return
+		case "education":
+			runEducation(cfg, esStorage, os.Args[2:])
+			return
+		case "safety":
+			runSafety(cfg, esStorage, os.Args[2:])
+			return
+		case "golden":
+			runGolden(cfg, esStorage, os.Args[2:])
+			return
+		case "evaluate":
+			runEvaluate(cfg, esClient, os.Args[2:])
+			return
+		case "validate-import":
+			runValidateImport(cfg, os.Args[2:])
+			return
+		case "export-clickhouse":
+			runExportClickHouse(cfg, esStorage, os.Args[2:])
+			return
+		case "advise-shards":
+			runAdviseShards(cfg, esStorage, os.Args[2:])
+			return
+		case "reference":
+			runReference(cfg, os.Args[2:])
+			return
+		default:
+			logging.Fatal("Unknown command", slog.String("command", os.Args[1]),
+				slog.String("available", "benchmark-knn, reconcile, archive, seasonality, venues, education, safety, evaluate, golden, validate-import, export-clickhouse, advise-shards, reference"))
+		}
+	}
+
+	fs := flag.NewFlagSet("indexer", flag.ExitOnError)
+	fastLoad := fs.Bool("fast-load", false, "отключить обновление и реплики индекса на время загрузки (для первичной загрузки)")
+	csvFile := fs.String("csv", "", "загрузить локации из CSV файла с заголовком вместо тестовых данных")
+	geojsonFile := fs.String("geojson", "", "загрузить локации из GeoJSON FeatureCollection с геометрией Point вместо тестовых данных")
+	skipInvalid := fs.Bool("skip-invalid", false, "с -csv или -geojson пропускать записи с ошибками проверки вместо остановки загрузки")
+	skipProfiles := fs.Bool("skip-profiles", false, "с -csv или -geojson не проверять записи по профилям проверки типов бизнеса из PostgreSQL")
+	skipCities := fs.Bool("skip-cities", false, "с -csv или -geojson не проверять города записей по справочнику городов из PostgreSQL")
+	workers := fs.Int("workers", 0, "индексировать запросами по -flush-bytes в заданное число потоков без подстройки под нагрузку кластера")
+	flushBytes := fs.Int("flush-bytes", storage.DefaultBulkFlushBytes, "размер тела Bulk запроса с -workers, байты")
+	fs.Parse(os.Args[1:])
+
+	if *csvFile != "" && *geojsonFile != "" {
+		logging.Fatal("-csv and -geojson are mutually exclusive")
+	}
+	if *workers > 0 && *fastLoad {
+		logging.Fatal("-fast-load is supported only by the adaptive loader, drop -workers")
+	}
+
+	// Локации из CSV или GeoJSON файла или тестовые данные
+	var locations []*models.Location
+	var profiles map[string]*models.ValidationProfile
+	var cities models.KnownCities
+	if (*csvFile != "" || *geojsonFile != "") && !*skipProfiles {
+		profiles = loadValidationProfiles(cfg)
+	}
+	if (*csvFile != "" || *geojsonFile != "") && !*skipCities {
+		cities = loadKnownCities(cfg)
+	}
+	switch {
+	case *csvFile != "":
+		locations = loadLocationsFromFormat(*csvFile, importer.ReadCSV, "csv_", *skipInvalid, profiles, cities)
+	case *geojsonFile != "":
+		locations = loadLocationsFromFormat(*geojsonFile, importer.ReadGeoJSON, "geojson_", *skipInvalid, profiles, cities)
+	default:
+		locations = generateSampleLocations(100)
+	}
+	ctx := context.Background()
+
+	if *csvFile != "" || *geojsonFile != "" {
+		mergeExistingLocations(ctx, esStorage, locations)
+	}
+	for _, location := range locations {
+		location.DemographicEmbedding = embedding.DemographicVector(location.Demographics)
+	}
+
+	// Статистика до загрузки — базовая линия для проверки аномалий
+	statsBefore, err := esStorage.GetCityScoreStats(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not collect score stats before load", logging.Err(err))
+	}
+
+	slog.InfoContext(ctx, "Indexing locations", "count", len(locations))
+
+	if *workers > 0 {
+		indexFixed(ctx, esStorage, locations, *workers, *flushBytes)
+	} else {
+		indexAdaptive(ctx, cfg, esStorage, locations, *fastLoad)
+	}
+
+	if statsBefore != nil {
+		checkAnomalies(ctx, cfg, esStorage, statsBefore)
+	}
+}
+
+// maxLoggedFailures ограничивает число неудачных документов, выводимых в лог при индексации.
+const maxLoggedFailures = 20
+
+// indexFixed индексирует локации Bulk запросами около flushBytes в workers параллельных потоков.
+// Неудачные документы выводятся в лог и не прерывают загрузку.
+func indexFixed(ctx context.Context, esStorage *storage.ElasticsearchStorage, locations []*models.Location, workers, flushBytes int) {
+	started := time.Now()
+	logged := 0
+	stats, err := esStorage.BulkIndexLocations(ctx, locations, storage.BulkIndexOptions{
+		FlushBytes: flushBytes,
+		Workers:    workers,
+		OnFailure: func(location *models.Location, err error) {
+			if logged < maxLoggedFailures {
+				slog.WarnContext(ctx, "Failed to index location", "location_id", location.ID, logging.Err(err))
+			}
+			logged++
+		},
+	})
+	if stats == nil {
+		logging.Fatal("Error indexing locations", logging.Err(err))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Indexing completed with errors", logging.Err(err))
+	}
+
+	slog.InfoContext(ctx, "Indexing completed", "indexed", stats.Indexed, "failed", stats.Failed,
+		"requests", stats.Requests, "workers", workers, "duration", time.Since(started).Round(time.Millisecond).String())
+}
+
+// indexAdaptive индексирует локации с подстройкой размера пачек и параллельности под нагрузку кластера.
+func indexAdaptive(ctx context.Context, cfg *config.Config, esStorage *storage.ElasticsearchStorage, locations []*models.Location, fastLoad bool) {
+	loader := bulkload.NewLoader(esStorage, bulkload.Options{
+		InitialBatch:     cfg.IndexBatchSize,
+		MinBatch:         cfg.IndexMinBatchSize,
+		MaxBatch:         cfg.IndexMaxBatchSize,
+		MaxConcurrency:   cfg.IndexMaxConcurrency,
+		TargetLatency:    time.Duration(cfg.IndexTargetLatencyMs) * time.Millisecond,
+		MaxDocsPerSecond: cfg.IndexMaxDocsPerSecond,
+		FastLoad:         fastLoad,
+	})
+	stats, err := loader.Load(ctx, locations)
+	if err != nil {
+		logging.Fatal("Error indexing locations", logging.Err(err))
+	}
+
+	slog.InfoContext(ctx, "Indexing completed", "indexed", stats.Indexed, "failed", stats.Failed,
+		"retried_after_429", stats.Rejected, "final_batch", stats.FinalBatch, "concurrency", stats.FinalConcurrency,
+		"duration", stats.Duration.Round(time.Millisecond).String())
+}
 
-	log.Printf("Indexing %d locations...", len(locations))
+// checkAnomalies сравнивает распределение оценок до и после загрузки
+// и отправляет алерт через подсистему уведомлений при обнаружении аномалий.
+func checkAnomalies(ctx context.Context, cfg *config.Config, esStorage *storage.ElasticsearchStorage, statsBefore map[string]*models.CityScoreStats) {
+	if err := esStorage.Refresh(ctx); err != nil {
+		slog.WarnContext(ctx, "Could not refresh index", logging.Err(err))
+		return
+	}
 
-	// Индексация данных
-	if err := esStorage.BulkIndexLocations(context.Background(), locations); err != nil {
-		log.Fatalf("Error indexing locations: %v", err)
+	statsAfter, err := esStorage.GetCityScoreStats(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not collect score stats after load", logging.Err(err))
+		return
 	}
 
-	log.Println("Indexing completed successfully!")
+	detector := anomaly.NewDetector(anomaly.Thresholds{
+		MeanShift: cfg.AnomalyMeanShiftThreshold,
+		ZeroShare: cfg.AnomalyZeroShareThreshold,
+		MinDocs:   cfg.AnomalyMinDocs,
+	}, notify.New(cfg.NotifyWebhookURL))
+
+	findings, err := detector.Check(ctx, "indexer", statsBefore, statsAfter)
+	if err != nil {
+		slog.WarnContext(ctx, "Score distribution check failed", logging.Err(err))
+	}
+	if len(findings) == 0 {
+		slog.InfoContext(ctx, "Score distribution check passed")
+	}
 }
 
 // generateSampleLocations генерирует тестовые данные локаций
@@ -108,9 +302,10 @@ func generateSampleLocations(count int) []*models.Location {
 			BusinessTypesSuitable: suitableTypes,
 			TrafficScore:          rand.Float64() * 10, // 0-10
 			CompetitionDensity:    rand.Float64() * 10, // 0-10
+			StreetParkingScore:    rand.Float64() * 10, // 0-10
+			PaidLotsNearby:        rand.Intn(4),
 			Demographics: models.Demographics{
-				AgeGroup:          ageGroups[rand.Intn(len(ageGroups))],
-				AverageIncome:     float64(rand.Intn(100000) + 20000), // 20k-120k
+				Segments:          sampleSegments(ageGroups),
 				Interests:         locationInterests,
 				PopulationDensity: rand.Float64() * 10000, // 0-10000 чел/км²
 			},
@@ -119,12 +314,36 @@ func generateSampleLocations(count int) []*models.Location {
 			UpdatedAt: time.Now(),
 		}
 
+		location.Demographics.Summarize()
+		location.RecordDemographics(location.CreatedAt)
+
 		locations = append(locations, location)
 	}
 
 	return locations
 }
 
+// sampleSegments генерирует 2-4 демографических сегмента района с долями, в сумме равными 1
+func sampleSegments(ageGroups []string) []models.DemographicSegment {
+	count := 2 + rand.Intn(3)
+	segments := make([]models.DemographicSegment, 0, count)
+	weights := make([]float64, 0, count)
+	total := 0.0
+	for _, j := range rand.Perm(len(ageGroups))[:count] {
+		weight := 0.1 + rand.Float64()
+		weights = append(weights, weight)
+		total += weight
+		segments = append(segments, models.DemographicSegment{
+			AgeGroup:      ageGroups[j],
+			AverageIncome: float64(rand.Intn(100000) + 20000), // 20k-120k
+		})
+	}
+	for j := range segments {
+		segments[j].Share = weights[j] / total
+	}
+	return segments
+}
+
 // loadLocationsFromFile загружает локации из JSON файла
 func loadLocationsFromFile(filename string) ([]*models.Location, error) {
 	data, err := os.ReadFile(filename)
@@ -136,6 +355,15 @@ func loadLocationsFromFile(filename string) ([]*models.Location, error) {
 	if err := json.Unmarshal(data, &locations); err != nil {
 		return nil, err
 	}
+	now := time.Now()
+	for _, location := range locations {
+		location.Demographics.Summarize()
+		effectiveFrom := location.CreatedAt
+		if effectiveFrom.IsZero() {
+			effectiveFrom = now
+		}
+		location.RecordDemographics(effectiveFrom)
+	}
 
 	return locations, nil
 }
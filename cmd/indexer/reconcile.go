@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
+	"github.com/akozadaev/go_es_analytical_system/internal/reconcile"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runReconcile сверяет локации в PostgreSQL и Elasticsearch и выводит отчет в JSON.
+// С флагом -repair исправляет расхождения старше grace-периода; сироты в Elasticsearch
+// удаляются только с -delete-orphans.
+func runReconcile(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "исправить расхождения старше grace-периода")
+	deleteOrphans := fs.Bool("delete-orphans", false, "с -repair удалить сироты из Elasticsearch (в том числе документы, загруженные indexer)")
+	grace := fs.Duration("grace", time.Duration(cfg.ReconcileGraceMinutes)*time.Minute, "минимальный возраст расхождения перед исправлением")
+	fs.Parse(args)
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	reconciler := reconcile.NewReconciler(pgStorage, esStorage, notify.New(cfg.NotifyWebhookURL), *grace)
+
+	report, err := reconciler.Run(context.Background(), reconcile.Options{Repair: *repair, DeleteOrphans: *deleteOrphans})
+	if err != nil {
+		logging.Fatal("Error reconciling locations", logging.Err(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logging.Fatal("Error encoding report", logging.Err(err))
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/refdata"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runReference выгружает справочники типов бизнеса, регионов и городов в файл (export) или загружает
+// их из файла (import), как GET и POST /admin/reference-data. Формат файла определяется
+// по расширению (.json или .csv) или флагом -format.
+func runReference(cfg *config.Config, args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		logging.Fatal("Usage: indexer reference export|import -file <file> [-format json|csv] [-dry-run] [-prune]")
+	}
+	command := args[0]
+
+	fs := flag.NewFlagSet("reference "+command, flag.ExitOnError)
+	file := fs.String("file", "", "JSON или CSV файл выгрузки справочников")
+	formatName := fs.String("format", "", "формат файла: json или csv (по умолчанию — по расширению)")
+	dryRun := fs.Bool("dry-run", false, "при загрузке только вывести изменения, не применяя их")
+	prune := fs.Bool("prune", false, "при загрузке удалить записи, отсутствующие в файле")
+	fs.Parse(args[1:])
+
+	if *file == "" {
+		logging.Fatal("Flag -file is required")
+	}
+	format := refdata.Format(*formatName)
+	if format == "" {
+		format = refdata.Format(strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), "."))
+	}
+	if !format.Valid() {
+		logging.Fatal("Unsupported reference data format", slog.String("format", string(format)),
+			slog.String("available", "json, csv"))
+	}
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	referenceData := service.NewReferenceDataService(pgStorage, nil)
+	ctx := context.Background()
+
+	if command == "export" {
+		data, err := referenceData.Export(ctx)
+		if err != nil {
+			logging.Fatal("Error exporting reference data", logging.Err(err))
+		}
+		f, err := os.Create(*file)
+		if err != nil {
+			logging.Fatal("Error creating file", logging.Err(err))
+		}
+		if err := refdata.Write(f, format, data); err != nil {
+			f.Close()
+			logging.Fatal("Error writing reference data", logging.Err(err))
+		}
+		if err := f.Close(); err != nil {
+			logging.Fatal("Error writing reference data", logging.Err(err))
+		}
+		slog.InfoContext(ctx, "Reference data exported", "business_types", len(data.BusinessTypes),
+			"regions", len(data.Regions), "cities", len(data.Cities), "file", *file)
+		return
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		logging.Fatal("Error opening file", logging.Err(err))
+	}
+	data, err := refdata.Read(f, format)
+	f.Close()
+	if err != nil {
+		logging.Fatal("Error reading reference data", logging.Err(err))
+	}
+
+	diff, err := referenceData.Import(ctx, data, *dryRun, *prune)
+	if err != nil {
+		logging.Fatal("Error importing reference data", logging.Err(err))
+	}
+
+	printReferenceChanges("business_types", &diff.BusinessTypes, diff.Prune)
+	printReferenceChanges("regions", &diff.Regions, diff.Prune)
+	printReferenceChanges("cities", &diff.Cities, diff.Prune)
+	if diff.DryRun {
+		slog.InfoContext(ctx, "Dry run: no changes applied")
+	} else {
+		slog.InfoContext(ctx, "Reference data imported")
+	}
+}
+
+// printReferenceChanges выводит изменения справочника: строку на каждую запись с признаком
+// + (добавляется), ~ (изменяется) или - (удаляется; без -prune — только отсутствует в файле).
+func printReferenceChanges(dictionary string, changes *models.ReferenceChanges, prune bool) {
+	for _, name := range changes.Added {
+		fmt.Printf("+ %s %s\n", dictionary, name)
+	}
+	for _, name := range changes.Updated {
+		fmt.Printf("~ %s %s\n", dictionary, name)
+	}
+	removed := "missing"
+	if prune {
+		removed = "removed"
+	}
+	for _, name := range changes.Removed {
+		fmt.Printf("- %s %s (%s)\n", dictionary, name, removed)
+	}
+	fmt.Printf("%s: added=%d updated=%d %s=%d unchanged=%d\n", dictionary,
+		len(changes.Added), len(changes.Updated), removed, len(changes.Removed), changes.Unchanged)
+}
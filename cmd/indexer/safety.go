@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/refresh"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runSafety загружает индексы безопасности районов из CSV файла в PostgreSQL, заменяя текущие.
+// Формат строки: city,district,lat,lon,safety_score, где lat и lon — центр района, safety_score — 0–10.
+// С флагом -recompute сразу пересчитывает safety_score локаций.
+func runSafety(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("safety", flag.ExitOnError)
+	file := fs.String("file", "", "CSV файл с индексами безопасности районов (city,district,lat,lon,safety_score)")
+	recompute := fs.Bool("recompute", false, "пересчитать safety_score локаций после загрузки")
+	fs.Parse(args)
+
+	if *file == "" {
+		logging.Fatal("Flag -file is required")
+	}
+
+	districts, err := loadDistrictSafety(*file)
+	if err != nil {
+		logging.Fatal("Error reading district safety", logging.Err(err))
+	}
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	ctx := context.Background()
+	if err := pgStorage.ReplaceDistrictSafety(ctx, districts); err != nil {
+		logging.Fatal("Error saving district safety", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Loaded safety scores", "districts", len(districts))
+
+	if !*recompute {
+		return
+	}
+	updated, err := refresh.RecomputeSafety(ctx, esStorage, pgStorage, districts)
+	if err != nil {
+		logging.Fatal("Error recomputing safety score", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Safety score updated", "locations", updated)
+}
+
+// loadDistrictSafety читает и проверяет индексы безопасности районов из CSV файла.
+// Строка заголовка, если она есть, пропускается.
+func loadDistrictSafety(filename string) ([]models.DistrictSafety, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 5
+
+	var districts []models.DistrictSafety
+	seen := make(map[string]bool)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.TrimSpace(record[4]) == "safety_score" {
+			continue
+		}
+
+		city := strings.TrimSpace(record[0])
+		district := strings.TrimSpace(record[1])
+		if city == "" || district == "" {
+			return nil, fmt.Errorf("line %d: city and district are required", line)
+		}
+		if seen[city+"\x00"+district] {
+			return nil, fmt.Errorf("line %d: duplicate district %s in %s", line, district, city)
+		}
+		seen[city+"\x00"+district] = true
+
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		lon, lonErr := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+		score, err := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+		if err != nil || score < 0 || score > 10 {
+			return nil, fmt.Errorf("line %d: safety_score must be between 0 and 10", line)
+		}
+
+		districts = append(districts, models.DistrictSafety{
+			City:        city,
+			District:    district,
+			Center:      models.GeoPoint{Lat: lat, Lon: lon},
+			SafetyScore: score,
+		})
+	}
+
+	return districts, nil
+}
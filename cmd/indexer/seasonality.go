@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runSeasonality загружает коэффициенты сезонности из CSV файла в PostgreSQL.
+// Формат строки: city,business_type,month,coefficient; пустой city задает коэффициент для всех городов.
+func runSeasonality(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("seasonality", flag.ExitOnError)
+	file := fs.String("file", "", "CSV файл с коэффициентами (city,business_type,month,coefficient)")
+	fs.Parse(args)
+
+	if *file == "" {
+		logging.Fatal("Flag -file is required")
+	}
+
+	coefficients, err := loadSeasonalCoefficients(*file)
+	if err != nil {
+		logging.Fatal("Error reading seasonal coefficients", logging.Err(err))
+	}
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	if err := pgStorage.UpsertSeasonalCoefficients(context.Background(), coefficients); err != nil {
+		logging.Fatal("Error saving seasonal coefficients", logging.Err(err))
+	}
+
+	slog.Info("Loaded seasonal coefficients", "count", len(coefficients))
+}
+
+// loadSeasonalCoefficients читает и проверяет коэффициенты из CSV файла.
+// Строка заголовка, если она есть, пропускается.
+func loadSeasonalCoefficients(filename string) ([]models.SeasonalCoefficient, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 4
+
+	var coefficients []models.SeasonalCoefficient
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.TrimSpace(record[2]) == "month" {
+			continue
+		}
+
+		month, err := strconv.Atoi(strings.TrimSpace(record[2]))
+		if err != nil || month < 1 || month > 12 {
+			return nil, fmt.Errorf("line %d: month must be between 1 and 12", line)
+		}
+		coefficient, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil || coefficient < 0 {
+			return nil, fmt.Errorf("line %d: coefficient must be a non-negative number", line)
+		}
+		businessType := strings.TrimSpace(record[1])
+		if businessType == "" {
+			return nil, fmt.Errorf("line %d: business_type is required", line)
+		}
+
+		coefficients = append(coefficients, models.SeasonalCoefficient{
+			City:         strings.TrimSpace(record[0]),
+			BusinessType: businessType,
+			Month:        month,
+			Coefficient:  coefficient,
+		})
+	}
+
+	return coefficients, nil
+}
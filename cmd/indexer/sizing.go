@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/sizing"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// sizingReport — отчет команды advise-shards.
+type sizingReport struct {
+	Stats          sizing.Stats          `json:"stats"`
+	Recommendation sizing.Recommendation `json:"recommendation"`
+	AppliedTo      string                `json:"applied_to,omitempty"` // Индекс, созданный с рекомендуемыми параметрами
+	Reindexed      int64                 `json:"reindexed,omitempty"`
+}
+
+// runAdviseShards рекомендует число шардов и реплик и refresh_interval следующей версии индекса
+// по текущему объему, среднему размеру документа и темпу роста за -growth-days, и выводит отчет
+// в JSON. С -target создает индекс с рекомендуемыми параметрами и переиндексирует в него
+// текущий индекс; на время переиндексации обновление и реплики отключаются.
+func runAdviseShards(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("advise-shards", flag.ExitOnError)
+	growthDays := fs.Int("growth-days", 30, "период оценки темпа роста по created_at, дни")
+	horizonDays := fs.Int("horizon-days", 365, "срок, на который рассчитывается индекс, дни")
+	targetShardGB := fs.Float64("target-shard-gb", 30, "целевой размер шарда, ГБ")
+	target := fs.String("target", "", "создать индекс с рекомендуемыми параметрами и переиндексировать в него (например, locations-v2)")
+	fs.Parse(args)
+
+	if *growthDays <= 0 || *horizonDays <= 0 || *targetShardGB <= 0 {
+		logging.Fatal("-growth-days, -horizon-days and -target-shard-gb must be positive")
+	}
+
+	ctx := context.Background()
+
+	indexStats, err := esStorage.GetIndexStats(ctx)
+	if err != nil {
+		logging.Fatal("Error reading index stats", logging.Err(err))
+	}
+	since := time.Now().AddDate(0, 0, -*growthDays)
+	recent, err := esStorage.CountLocations(ctx, &models.LocationFilter{CreatedSince: &since})
+	if err != nil {
+		logging.Fatal("Error counting recent locations", logging.Err(err))
+	}
+	dataNodes, err := esStorage.DataNodeCount(ctx)
+	if err != nil {
+		logging.Fatal("Error reading cluster health", logging.Err(err))
+	}
+
+	report := sizingReport{
+		Stats: sizing.Stats{
+			Docs:       indexStats.Docs,
+			StoreBytes: indexStats.StoreBytes,
+			DocsPerDay: float64(recent) / float64(*growthDays),
+			DataNodes:  dataNodes,
+		},
+	}
+	report.Recommendation = sizing.Advise(report.Stats, sizing.Options{
+		HorizonDays:      *horizonDays,
+		TargetShardBytes: int64(*targetShardGB * (1 << 30)),
+	})
+
+	if *target != "" {
+		report.Reindexed = applySizing(ctx, cfg, esStorage, *target, report.Recommendation)
+		report.AppliedTo = *target
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logging.Fatal("Error encoding report", logging.Err(err))
+	}
+}
+
+// applySizing создает индекс target с рекомендуемыми параметрами, переиндексирует в него
+// индекс локаций и возвращает число скопированных документов. Переключение приложения
+// на новый индекс выполняется отдельно.
+func applySizing(ctx context.Context, cfg *config.Config, esStorage *storage.ElasticsearchStorage, target string, rec sizing.Recommendation) int64 {
+	mapping, err := storage.BuildLocationsMapping(storage.VectorIndexOptions{
+		Dims:           cfg.EmbeddingDims,
+		Similarity:     cfg.KNNSimilarity,
+		M:              cfg.KNNM,
+		EfConstruction: cfg.KNNEfConstruction,
+	})
+	if err != nil {
+		logging.Fatal("Invalid vector index configuration", logging.Err(err))
+	}
+
+	// Без обновления и реплик переиндексация идет быстрее; реплики строятся один раз в конце
+	err = esStorage.CreateIndexWithSettings(ctx, target, mapping, map[string]interface{}{
+		"index.number_of_shards":   rec.Shards,
+		"index.number_of_replicas": 0,
+		"index.refresh_interval":   "-1",
+	})
+	if err != nil {
+		logging.Fatal("Error creating index", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Created index, reindexing", "index", target, "shards", rec.Shards)
+
+	reindexed, err := esStorage.ReindexTo(ctx, target)
+	if err != nil {
+		logging.Fatal("Error reindexing", slog.String("index", target), logging.Err(err))
+	}
+
+	err = esStorage.SetIndexSettings(ctx, target, map[string]interface{}{
+		"index.number_of_replicas": rec.Replicas,
+		"index.refresh_interval":   rec.RefreshInterval,
+	})
+	if err != nil {
+		logging.Fatal("Error applying index settings", slog.String("index", target), logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Reindexed documents", "documents", reindexed, "index", target, "replicas", rec.Replicas,
+		"refresh_interval", rec.RefreshInterval)
+
+	return reindexed
+}
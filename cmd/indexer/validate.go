@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/importer"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runValidateImport проверяет файл импорта локаций (CSV или GeoJSON) без загрузки в индекс:
+// координаты вне диапазонов и переставленные широта/долгота, точки вне границ заявленного
+// региона (при заданном -regions), дубликаты координат, с -profiles — соответствие профилям
+// проверки типов бизнеса, а с -cities — города по справочнику городов из PostgreSQL. Отчет в JSON записывается в -report.
+// Код выхода 1, если в файле есть ошибки.
+func runValidateImport(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("validate-import", flag.ExitOnError)
+	file := fs.String("file", "", "файл импорта локаций (.csv, .geojson или .json)")
+	regions := fs.String("regions", "", "GeoJSON с границами регионов (properties.name, Polygon/MultiPolygon)")
+	reportFile := fs.String("report", "", "файл для отчета в JSON (по умолчанию — только сводка в stdout)")
+	radius := fs.Float64("duplicate-radius", 10, "точки ближе этого расстояния считаются одной точкой, метры")
+	threshold := fs.Int("duplicate-threshold", 1, "допустимое число записей в одной точке")
+	profiles := fs.Bool("profiles", false, "проверить записи по профилям проверки типов бизнеса из PostgreSQL")
+	cities := fs.Bool("cities", false, "проверить города записей по справочнику городов из PostgreSQL")
+	fs.Parse(args)
+
+	if *file == "" {
+		logging.Fatal("Flag -file is required")
+	}
+
+	records, err := readImportFile(*file)
+	if err != nil {
+		logging.Fatal("Error reading import file", slog.String("file", *file), logging.Err(err))
+	}
+
+	var boundaries importer.Boundaries
+	if *regions != "" {
+		f, err := os.Open(*regions)
+		if err != nil {
+			logging.Fatal("Error opening region boundaries", logging.Err(err))
+		}
+		boundaries, err = importer.LoadBoundaries(f)
+		f.Close()
+		if err != nil {
+			logging.Fatal("Error reading region boundaries", logging.Err(err))
+		}
+	}
+
+	opts := importer.Options{
+		DuplicateRadiusMeters: *radius,
+		DuplicateThreshold:    *threshold,
+	}
+	if *profiles {
+		opts.Profiles = loadValidationProfiles(cfg)
+	}
+	if *cities {
+		opts.Cities = loadKnownCities(cfg)
+	}
+
+	report := importer.Validate(*file, records, boundaries, opts)
+
+	if *reportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			logging.Fatal("Error encoding report", logging.Err(err))
+		}
+		if err := os.WriteFile(*reportFile, data, 0o644); err != nil {
+			logging.Fatal("Error writing report", logging.Err(err))
+		}
+	}
+
+	fmt.Printf("%-24s %d\n", "records", report.Total)
+	fmt.Printf("%-24s %d\n", "valid", report.Valid)
+	fmt.Printf("%-24s %d\n", "errors", report.Errors)
+	fmt.Printf("%-24s %d\n", "warnings", report.Warnings)
+	checks := make([]string, 0, len(report.Counts))
+	for check := range report.Counts {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	for _, check := range checks {
+		fmt.Printf("  %-22s %d\n", check, report.Counts[check])
+	}
+	if *reportFile != "" {
+		fmt.Printf("report written to %s\n", *reportFile)
+	}
+
+	if report.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// loadValidationProfiles загружает профили проверки локаций по типам бизнеса из PostgreSQL.
+func loadValidationProfiles(cfg *config.Config) map[string]*models.ValidationProfile {
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	profiles, err := pgStorage.ListValidationProfiles(context.Background())
+	if err != nil {
+		logging.Fatal("Error loading validation profiles", logging.Err(err))
+	}
+	byType := make(map[string]*models.ValidationProfile, len(profiles))
+	for i := range profiles {
+		byType[profiles[i].BusinessType] = &profiles[i]
+	}
+	return byType
+}
+
+// loadKnownCities загружает справочник городов по регионам из PostgreSQL.
+func loadKnownCities(cfg *config.Config) models.KnownCities {
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	cities, err := pgStorage.GetCities(context.Background())
+	if err != nil {
+		logging.Fatal("Error loading cities", logging.Err(err))
+	}
+	known := make([]models.City, len(cities))
+	for i, city := range cities {
+		known[i] = *city
+	}
+	return models.NewKnownCities(known)
+}
+
+// readImportFile читает локации из CSV или GeoJSON файла по расширению.
+func readImportFile(filename string) ([]importer.Record, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return importer.ReadCSV(f)
+	case ".geojson", ".json":
+		return importer.ReadGeoJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q (expected .csv, .geojson or .json)", filepath.Ext(filename))
+	}
+}
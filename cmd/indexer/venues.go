@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/refresh"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runVenues загружает справочник площадок мероприятий из CSV файла в PostgreSQL, заменяя текущий.
+// Формат строки: name,kind,lat,lon,events_per_year,capacity. С флагом -recompute сразу
+// пересчитывает event_exposure локаций, не дожидаясь конвейера обновления.
+func runVenues(cfg *config.Config, esStorage *storage.ElasticsearchStorage, args []string) {
+	fs := flag.NewFlagSet("venues", flag.ExitOnError)
+	file := fs.String("file", "", "CSV файл с площадками (name,kind,lat,lon,events_per_year,capacity)")
+	recompute := fs.Bool("recompute", false, "пересчитать event_exposure локаций после загрузки")
+	radius := fs.Float64("radius", cfg.EventRadiusMeters, "радиус влияния площадок, метры")
+	fs.Parse(args)
+
+	if *file == "" {
+		logging.Fatal("Flag -file is required")
+	}
+
+	venues, err := loadEventVenues(*file)
+	if err != nil {
+		logging.Fatal("Error reading event venues", logging.Err(err))
+	}
+
+	pgStorage, err := storage.NewPostgresStorage(cfg.PostgresDSN())
+	if err != nil {
+		logging.Fatal("Error creating PostgreSQL client", logging.Err(err))
+	}
+	defer pgStorage.Close()
+
+	ctx := context.Background()
+	if err := pgStorage.ReplaceEventVenues(ctx, venues); err != nil {
+		logging.Fatal("Error saving event venues", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Loaded event venues", "count", len(venues))
+
+	if !*recompute {
+		return
+	}
+	updated, err := refresh.RecomputeEventExposure(ctx, esStorage, pgStorage, venues, *radius)
+	if err != nil {
+		logging.Fatal("Error recomputing event exposure", logging.Err(err))
+	}
+	slog.InfoContext(ctx, "Event exposure updated", "locations", updated)
+}
+
+// loadEventVenues читает и проверяет площадки из CSV файла.
+// Строка заголовка, если она есть, пропускается.
+func loadEventVenues(filename string) ([]models.EventVenue, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 6
+
+	var venues []models.EventVenue
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.TrimSpace(record[2]) == "lat" {
+			continue
+		}
+
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		lon, lonErr := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+		events, err := strconv.Atoi(strings.TrimSpace(record[4]))
+		if err != nil || events < 0 {
+			return nil, fmt.Errorf("line %d: events_per_year must be a non-negative integer", line)
+		}
+		capacity, err := strconv.Atoi(strings.TrimSpace(record[5]))
+		if err != nil || capacity < 0 {
+			return nil, fmt.Errorf("line %d: capacity must be a non-negative integer", line)
+		}
+
+		venues = append(venues, models.EventVenue{
+			Name:          strings.TrimSpace(record[0]),
+			Kind:          strings.TrimSpace(record[1]),
+			Coordinates:   models.GeoPoint{Lat: lat, Lon: lon},
+			EventsPerYear: events,
+			Capacity:      capacity,
+		})
+	}
+
+	return venues, nil
+}
@@ -0,0 +1,72 @@
+// Точка входа для запуска API в AWS Lambda (custom runtime provided.al2/provided.al2023,
+// исполняемый файл bootstrap) за API Gateway или Function URL.
+//
+// Приложение собирается лениво при первом вызове и переиспользуется последующими вызовами
+// экземпляра среды выполнения. Фоновые процессы (outbox relay, сверка, архивация, конвейеры)
+// не запускаются, поэтому по умолчанию обслуживаются только запросы чтения.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/app"
+	"github.com/akozadaev/go_es_analytical_system/internal/buildinfo"
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/serverless"
+)
+
+// readOnlyPOST перечисляет POST маршруты, которые только читают данные (поиск и прогноз),
+// по путям версии 1 и прежним путям без префикса.
+var readOnlyPOST = map[string]bool{
+	"/api/v1/locations/recommend":     true,
+	"/api/v1/locations/portfolio":     true,
+	"/api/v1/locations/_mget":         true,
+	"/api/v1/locations/validate-refs": true,
+	"/api/v1/predict/footfall":        true,
+	"/locations/recommend":            true,
+	"/locations/portfolio":            true,
+	"/locations/_mget":                true,
+	"/locations/validate-refs":        true,
+	"/predict/footfall":               true,
+}
+
+func main() {
+	cfg := config.Load()
+
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, slog.String("version", buildinfo.Get().Version)); err != nil {
+		logging.Fatal("Invalid LOG_LEVEL", logging.Err(err))
+	}
+
+	err := serverless.Run(context.Background(), func() (http.Handler, error) {
+		// Индекс создается сервером или индексером: функция не меняет кластер
+		application, err := app.BuildApp(cfg, app.WithoutIndexSetup())
+		if err != nil {
+			return nil, err
+		}
+		if cfg.LambdaReadOnly {
+			return readOnly(application.Handler), nil
+		}
+		return application.Handler, nil
+	})
+	if err != nil {
+		logging.Fatal("Lambda runtime stopped", logging.Err(err))
+	}
+}
+
+// readOnly отклоняет запросы, изменяющие данные.
+func readOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS":
+		case r.Method == "POST" && readOnlyPOST[r.URL.Path]:
+		default:
+			http.Error(w, "Method not allowed in read-only deployment", http.StatusMethodNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,189 @@
+// Команда promote переносит проверенные данные из одного окружения в другое (например, из staging
+// в production) через HTTP API: справочники, профили ранжирования, активные версии моделей
+// и выбранные локации. С -dry-run выводит изменения целевого окружения, не применяя их.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/promote"
+)
+
+func main() {
+	sourceURL := flag.String("source", "", "адрес API исходного окружения, например https://staging.example.com")
+	sourceKey := flag.String("source-key", os.Getenv("PROMOTE_SOURCE_API_KEY"), "API ключ исходного окружения (по умолчанию PROMOTE_SOURCE_API_KEY)")
+	targetURL := flag.String("target", "", "адрес API целевого окружения")
+	targetKey := flag.String("target-key", os.Getenv("PROMOTE_TARGET_API_KEY"), "API ключ целевого окружения (по умолчанию PROMOTE_TARGET_API_KEY)")
+	include := flag.String("include", "reference,ranking-profiles,models", "переносимые разделы через запятую: reference, ranking-profiles, models, locations")
+	dryRun := flag.Bool("dry-run", false, "только вывести изменения целевого окружения")
+	prune := flag.Bool("prune", false, "удалить из справочников целевого окружения записи, отсутствующие в исходном")
+	region := flag.String("region", "", "переносить локации региона")
+	city := flag.String("city", "", "переносить локации города")
+	businessType := flag.String("business-type", "", "переносить локации, подходящие для типа бизнеса")
+	ids := flag.String("ids", "", "переносить локации с ID через запятую (вместо -region, -city, -business-type)")
+	limit := flag.Int("limit", 1000, "максимум переносимых локаций (0 — без ограничения)")
+	idMapFile := flag.String("id-map", "", "CSV файл source_id,target_id: ID локаций в целевом окружении")
+	batchSize := flag.Int("batch-size", 100, "число ID в запросе нескольких локаций (не больше LOCATIONS_MAX_IDS)")
+	reportFile := flag.String("report", "", "записать отчет в JSON файл")
+	timeout := flag.Duration("timeout", 60*time.Second, "таймаут HTTP запроса")
+	flag.Parse()
+
+	if err := logging.Setup(os.Stderr, os.Getenv("LOG_LEVEL")); err != nil {
+		logging.Fatal("Invalid LOG_LEVEL", logging.Err(err))
+	}
+
+	if *sourceURL == "" || *targetURL == "" {
+		logging.Fatal("Flags -source and -target are required")
+	}
+
+	opts := promote.Options{
+		DryRun:    *dryRun,
+		Prune:     *prune,
+		BatchSize: *batchSize,
+		Locations: promote.LocationFilter{
+			Region:       *region,
+			City:         *city,
+			BusinessType: *businessType,
+			Limit:        *limit,
+		},
+	}
+	for _, name := range strings.Split(*include, ",") {
+		section := promote.Section(strings.TrimSpace(name))
+		if !knownSection(section) {
+			logging.Fatal("Unknown section", slog.String("section", string(section)),
+				slog.String("available", "reference, ranking-profiles, models, locations"))
+		}
+		opts.Sections = append(opts.Sections, section)
+	}
+	if *ids != "" {
+		opts.Locations.IDs = strings.Split(*ids, ",")
+	}
+	if *idMapFile != "" {
+		idMap, err := loadIDMap(*idMapFile)
+		if err != nil {
+			logging.Fatal("Error reading ID map", logging.Err(err))
+		}
+		opts.IDMap = idMap
+	}
+
+	source := promote.NewClient(*sourceURL, *sourceKey, *timeout)
+	target := promote.NewClient(*targetURL, *targetKey, *timeout)
+	report, runErr := promote.Run(context.Background(), source, target, opts)
+
+	// Отчет выводится и при ошибке: он показывает, что уже перенесено
+	printReport(report)
+	if *reportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*reportFile, data, 0o644)
+		}
+		if err != nil {
+			slog.Error("Error writing report", logging.Err(err))
+		}
+	}
+	if runErr != nil {
+		logging.Fatal("Promotion failed", logging.Err(runErr))
+	}
+	if report.DryRun {
+		slog.Info("Dry run: no changes applied")
+	}
+}
+
+func knownSection(section promote.Section) bool {
+	for _, known := range promote.Sections {
+		if section == known {
+			return true
+		}
+	}
+	return false
+}
+
+// loadIDMap читает соответствие ID локаций из CSV файла source_id,target_id.
+// Строка заголовка, если она есть, пропускается.
+func loadIDMap(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 2
+
+	idMap := make(map[string]string)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sourceID, targetID := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if line == 1 && sourceID == "source_id" {
+			continue
+		}
+		if sourceID == "" || targetID == "" {
+			return nil, fmt.Errorf("line %d: source_id and target_id are required", line)
+		}
+		idMap[sourceID] = targetID
+	}
+	return idMap, nil
+}
+
+// printReport выводит изменения целевого окружения: строку на каждую запись и итоги по разделам.
+func printReport(report *promote.Report) {
+	if diff := report.ReferenceData; diff != nil {
+		for _, changes := range []struct {
+			name    string
+			added   []string
+			updated []string
+			removed []string
+		}{
+			{"business_types", diff.BusinessTypes.Added, diff.BusinessTypes.Updated, diff.BusinessTypes.Removed},
+			{"regions", diff.Regions.Added, diff.Regions.Updated, diff.Regions.Removed},
+			{"cities", diff.Cities.Added, diff.Cities.Updated, diff.Cities.Removed},
+		} {
+			for _, name := range changes.added {
+				fmt.Printf("%-16s %-9s %s %s\n", promote.SectionReference, promote.ActionCreate, changes.name, name)
+			}
+			for _, name := range changes.updated {
+				fmt.Printf("%-16s %-9s %s %s\n", promote.SectionReference, promote.ActionUpdate, changes.name, name)
+			}
+			removed := "missing"
+			if diff.Prune {
+				removed = "delete"
+			}
+			for _, name := range changes.removed {
+				fmt.Printf("%-16s %-9s %s %s\n", promote.SectionReference, removed, changes.name, name)
+			}
+		}
+	}
+
+	counts := make(map[promote.Section]map[promote.Action]int)
+	for _, change := range report.Changes {
+		if change.Action != promote.ActionUnchanged {
+			fmt.Printf("%-16s %-9s %s %s\n", change.Section, change.Action, change.Key, change.Detail)
+		}
+		if counts[change.Section] == nil {
+			counts[change.Section] = make(map[promote.Action]int)
+		}
+		counts[change.Section][change.Action]++
+	}
+	for _, section := range promote.Sections {
+		if c, ok := counts[section]; ok {
+			fmt.Printf("%s: create=%d update=%d activate=%d unchanged=%d skip=%d\n", section,
+				c[promote.ActionCreate], c[promote.ActionUpdate], c[promote.ActionActivate], c[promote.ActionUnchanged], c[promote.ActionSkip])
+		}
+	}
+}
@@ -18,151 +18,142 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 
 	_ "github.com/akozadaev/go_es_analytical_system/docs" // swagger docs
+	"github.com/akozadaev/go_es_analytical_system/internal/acme"
+	"github.com/akozadaev/go_es_analytical_system/internal/app"
+	"github.com/akozadaev/go_es_analytical_system/internal/buildinfo"
 	"github.com/akozadaev/go_es_analytical_system/internal/config"
-	"github.com/akozadaev/go_es_analytical_system/internal/handlers"
-	"github.com/akozadaev/go_es_analytical_system/internal/storage"
-	"github.com/elastic/go-elasticsearch/v8"
-	"github.com/gorilla/mux"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/akozadaev/go_es_analytical_system/internal/listener"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
 )
 
 func main() {
 	cfg := config.Load()
 
-	// Инициализация Elasticsearch клиента
-	// Используем кастомный транспорт для обхода проверки типа сервера
-	esCfg := elasticsearch.Config{
-		Addresses:         []string{cfg.ElasticsearchURL},
-		DisableMetaHeader: true,
+	// Версия в каждой записи журнала — для сопоставления поведения с релизом
+	info := buildinfo.Get()
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, slog.String("version", info.Version)); err != nil {
+		logging.Fatal("Invalid LOG_LEVEL", logging.Err(err))
 	}
+	slog.Info("Starting", slog.String("commit", info.Commit), slog.String("build_date", info.BuildDate),
+		slog.String("go_version", info.GoVersion))
 
-	esClient, err := elasticsearch.NewClient(esCfg)
+	socketMode, err := strconv.ParseUint(cfg.UnixSocketMode, 8, 32)
 	if err != nil {
-		log.Fatalf("Error creating Elasticsearch client: %v", err)
+		logging.Fatal("Invalid UNIX_SOCKET_MODE", slog.String("value", cfg.UnixSocketMode), logging.Err(err))
 	}
-
-	// Простая проверка доступности через прямой HTTP запрос
-	// (клиент go-elasticsearch проверяет тип сервера, поэтому пропускаем стандартные методы)
-	log.Println("Elasticsearch/OpenSearch client initialized")
-
-	// Создание индекса с маппингом
-	esStorage := storage.NewElasticsearchStorageWithURL(esClient, "locations", cfg.ElasticsearchURL)
-
-	// Пытаемся найти файл маппинга в разных местах
-	mappingPaths := []string{
-		"migrations/elasticsearch_mapping.json",
-		"../migrations/elasticsearch_mapping.json",
-		filepath.Join(filepath.Dir(os.Args[0]), "../migrations/elasticsearch_mapping.json"),
+	listenOptions := listener.Options{UnixSocket: cfg.UnixSocket, UnixSocketMode: fs.FileMode(socketMode)}
+	if cfg.ListenTCP {
+		listenOptions.TCPAddr = ":" + cfg.AppPort
 	}
 
-	var mappingData []byte
-	for _, path := range mappingPaths {
-		var readErr error
-		mappingData, readErr = os.ReadFile(path)
-		if readErr == nil {
-			break
+	// При включенном autocert TCP порт отвечает на проверки ACME и перенаправляет на HTTPS
+	var certManager *acme.Manager
+	if len(cfg.AutocertDomains) > 0 {
+		certManager, err = acme.New(acme.Config{
+			Domains:      cfg.AutocertDomains,
+			CacheDir:     cfg.AutocertCacheDir,
+			Email:        cfg.AutocertEmail,
+			DirectoryURL: cfg.AutocertDirectoryURL,
+		})
+		if err != nil {
+			logging.Fatal("Error configuring autocert", logging.Err(err))
 		}
+		listenOptions.TLSAddr = ":" + cfg.HTTPSPort
+		listenOptions.TLSConfig = certManager.TLSConfig()
 	}
 
-	if len(mappingData) > 0 {
-		if err := esStorage.CreateIndex(context.Background(), string(mappingData)); err != nil {
-			log.Printf("Warning: could not create index: %v", err)
-		} else {
-			log.Println("Elasticsearch index created/verified")
-		}
-	} else {
-		log.Printf("Warning: could not read mapping file from any location")
+	application, err := app.BuildApp(cfg)
+	if err != nil {
+		logging.Fatal("Error building application", logging.Err(err))
 	}
+	defer application.Close()
 
-	// Инициализация PostgreSQL клиента
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.PostgresHost,
-		cfg.PostgresPort,
-		cfg.PostgresUser,
-		cfg.PostgresPassword,
-		cfg.PostgresDB,
-	)
+	application.Start(context.Background())
 
-	pgStorage, err := storage.NewPostgresStorage(dsn)
+	// Сокеты открываются после сборки приложения: при socket activation systemd
+	// держит соединения в очереди, пока сервис не готов их принимать
+	listeners, err := listener.Open(listenOptions)
+	if errors.Is(err, listener.ErrTCPWithSystemd) {
+		logging.Fatal("Set LISTEN_TCP=false when using systemd socket activation", logging.Err(err))
+	}
 	if err != nil {
-		log.Fatalf("Error creating PostgreSQL client: %v", err)
+		logging.Fatal("Error opening listeners", logging.Err(err))
 	}
-	defer pgStorage.Close()
-	log.Println("Connected to PostgreSQL")
-
-	// Инициализация handlers
-	h := handlers.NewHandlers(esStorage, pgStorage)
-
-	// Настройка роутера
-	router := mux.NewRouter()
-	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
-	router.HandleFunc("/locations/recommend", h.RecommendLocations).Methods("POST")
-	router.HandleFunc("/locations/{id}", h.GetLocation).Methods("GET")
-	router.HandleFunc("/business-types", h.GetBusinessTypes).Methods("GET")
-	router.HandleFunc("/regions", h.GetRegions).Methods("GET")
-
-	// Swagger UI
-	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
-		httpSwagger.DeepLinking(true),
-		httpSwagger.DocExpansion("none"),
-		httpSwagger.DomID("swagger-ui"),
-	))
-
-	// Настройка CORS
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
-	})
 
 	// Настройка сервера
 	srv := &http.Server{
-		Addr:         ":" + cfg.AppPort,
-		Handler:      router,
+		Handler:      application.Handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	servers := []*http.Server{srv}
+
+	var redirectSrv *http.Server
+	if certManager != nil {
+		redirectSrv = &http.Server{
+			Handler:      certManager.HTTPHandler(cfg.HTTPSPort),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+		servers = append(servers, redirectSrv)
+	}
 
-	// Graceful shutdown
-	go func() {
-		log.Printf("Server starting on port %s", cfg.AppPort)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+	// Ошибка любого сокета завершает работу через graceful shutdown, как и сигнал
+	serveErr := make(chan error, len(listeners))
+	for _, l := range listeners {
+		server := srv
+		if redirectSrv != nil && l.Kind == listener.KindTCP {
+			server = redirectSrv
 		}
-	}()
+		go func(l listener.Listener, server *http.Server) {
+			slog.Info("Server listening", slog.String("address", l.Name))
+			if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("serve %s: %w", l.Name, err)
+			}
+		}(l, server)
+	}
 
 	// Ожидание сигнала для graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down server...")
+	var failed bool
+	select {
+	case <-quit:
+		slog.Info("Shutting down server")
+	case err := <-serveErr:
+		slog.Error("Server failed, shutting down", logging.Err(err))
+		failed = true
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("Server forced to shutdown", logging.Err(err))
+			failed = true
+		}
 	}
 
-	log.Println("Server exited")
+	slog.Info("Server exited")
+	if failed {
+		// os.Exit не выполняет отложенные вызовы
+		cancel()
+		application.Close()
+		os.Exit(1)
+	}
 }
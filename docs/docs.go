@@ -24,26 +24,23 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/business-types": {
+        "/admin/api-keys": {
             "get": {
-                "description": "Возвращает все доступные типы бизнеса из справочника",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает API ключи внешних клиентов, включая отозванные, с дневной квотой и числом запросов за текущие сутки UTC. Сами ключи не возвращаются.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "business-types"
+                    "admin"
                 ],
-                "summary": "Получить список типов бизнеса",
+                "summary": "Выпущенные API ключи",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessType"
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.APIKey"
                             }
                         }
                     },
@@ -57,11 +54,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Возвращает статус сервиса. Используется для мониторинга и проверки доступности.",
+            },
+            "post": {
+                "description": "Выпускает API ключ внешнего клиента с ролями и дневной квотой запросов (0 — без ограничения). Ключ возвращается только в этом ответе. Без subject клиент определяется ID ключа.",
                 "consumes": [
                     "application/json"
                 ],
@@ -69,12 +64,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
+                ],
+                "summary": "Выпустить API ключ",
+                "parameters": [
+                    {
+                        "description": "Название, организация, клиент, роли и квота",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.APIKey"
+                        }
+                    }
                 ],
-                "summary": "Проверка работоспособности сервиса",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.APIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -85,9 +106,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/locations/recommend": {
-            "post": {
-                "description": "Возвращает список рекомендованных локаций для указанного типа бизнеса в регионе. Локации ранжируются по релевантности с учетом traffic_score, competition_density и демографии.",
+        "/admin/api-keys/{id}": {
+            "put": {
+                "description": "Изменяет название, роли или дневную квоту ключа; поля без значения не меняются",
                 "consumes": [
                     "application/json"
                 ],
@@ -95,17 +116,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "locations"
+                    "admin"
                 ],
-                "summary": "Получить рекомендации локаций",
+                "summary": "Изменить API ключ",
                 "parameters": [
                     {
-                        "description": "Запрос на рекомендации",
+                        "type": "string",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые параметры",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.APIKeyUpdate"
                         }
                     }
                 ],
@@ -113,7 +141,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendResponse"
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.APIKey"
                         }
                     },
                     "400": {
@@ -125,6 +153,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Ключ не найден или отозван",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -135,39 +172,27 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/locations/{id}": {
-            "get": {
-                "description": "Возвращает полную информацию о локации по её идентификатору",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+            },
+            "delete": {
                 "tags": [
-                    "locations"
+                    "admin"
                 ],
-                "summary": "Получить детали локации",
+                "summary": "Отозвать API ключ",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Идентификатор локации",
+                        "description": "ID ключа",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "404": {
-                        "description": "Локация не найдена",
+                        "description": "Ключ не найден или уже отозван",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -187,9 +212,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/regions": {
-            "get": {
-                "description": "Возвращает все доступные регионы из справочника с поддержкой иерархии",
+        "/admin/archive": {
+            "post": {
+                "description": "Запускает фоновый перенос локаций, которые не обновлялись и не попадали в рекомендации дольше ARCHIVE_AFTER_MONTHS, в архивный индекс. Архивные локации доступны в поиске с include_archived=true.",
                 "consumes": [
                     "application/json"
                 ],
@@ -197,16 +222,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "regions"
+                    "admin"
+                ],
+                "summary": "Архивировать холодные локации",
+                "parameters": [
+                    {
+                        "description": "Параметры архивации",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ArchiveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/business-type-substitutes": {
+            "get": {
+                "description": "Возвращает связи замещаемости: substitute конкурирует с business_type с долей weight",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Замещаемость типов бизнеса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Фильтр по типу бизнеса",
+                        "name": "business_type",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получить список регионов",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypeSubstitute"
                             }
                         }
                     },
@@ -221,170 +293,8394 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_akozadaev_go_es_analytical_system_internal_models.BusinessType": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
-                }
-            }
         },
-        "github_com_akozadaev_go_es_analytical_system_internal_models.Demographics": {
-            "type": "object",
-            "properties": {
-                "age_group": {
-                    "type": "string"
-                },
-                "average_income": {
-                    "type": "number"
-                },
-                "interests": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/admin/business-type-substitutes/{business_type}/{substitute}": {
+            "put": {
+                "description": "Создает связь замещаемости типов бизнеса или обновляет долю weight (0–1] существующей",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сохранить связь замещаемости",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Замещающий тип бизнеса",
+                        "name": "substitute",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Доля замещаемости",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypeSubstitute"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypeSubstitute"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
-                },
-                "population_density": {
-                    "type": "number"
                 }
-            }
-        },
-        "github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint": {
-            "type": "object",
-            "properties": {
-                "lat": {
-                    "description": "Широта (latitude)",
-                    "type": "number"
-                },
-                "lon": {
-                    "description": "Долгота (longitude)",
-                    "type": "number"
+            },
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Удалить связь замещаемости",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Замещающий тип бизнеса",
+                        "name": "substitute",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Связь не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
                 }
             }
         },
-        "github_com_akozadaev_go_es_analytical_system_internal_models.Location": {
-            "type": "object",
-            "properties": {
-                "address": {
-                    "type": "string"
-                },
-                "business_types_suitable": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "city": {
-                    "type": "string"
-                },
-                "competition_density": {
-                    "type": "number"
-                },
-                "coordinates": {
-                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
-                },
-                "created_at": {
-                    "type": "string"
+        "/admin/cities": {
+            "post": {
+                "description": "Добавляет город региона с координатами центра и населением. Центр города используется как точка отсчета фактора расстояния в рекомендациях без origin; город локаций региона, у которого есть города в справочнике, проверяется при записи и импорте.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Добавить город",
+                "parameters": [
+                    {
+                        "description": "Город",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Город с таким именем в регионе уже есть",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cities/{id}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Изменить город",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID города",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Город",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Город не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Город с таким именем в регионе уже есть",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Удалить город",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID города",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Город не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cluster": {
+            "get": {
+                "description": "Возвращает текущего лидера и роли экземпляров с недавним heartbeat. Без выбора лидера (LEADER_ELECTION=false) каждый экземпляр выполняет фоновые процессы и возвращает только себя.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Экземпляры кластера",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ClusterStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения состояния кластера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/embeddings/rebuild": {
+            "post": {
+                "description": "Запускает фоновую задачу пересчета embeddings через настроенный провайдер для документов, подходящих под фильтр. По умолчанию пересчитываются документы с embedding_version ниже текущей.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Пересчитать embeddings локаций",
+                "parameters": [
+                    {
+                        "description": "Фильтр документов",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RebuildEmbeddingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/golden-queries": {
+            "get": {
+                "description": "Возвращает эталонные запросы рекомендаций с ожидаемыми лучшими результатами",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Эталонные запросы",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GoldenQuery"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/golden-queries/run": {
+            "post": {
+                "description": "Выполняет эталонные запросы без кеша и записи в историю и возвращает отклонения: ожидаемые локации, не вошедшие в top_k",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Прогнать эталонные запросы",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GoldenRunReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/golden-queries/{id}": {
+            "put": {
+                "description": "Создает или заменяет эталонный запрос: локации expected_ids должны входить в первые top_k результатов выдачи по request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сохранить эталонный запрос",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор эталонного запроса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Эталонный запрос",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GoldenQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GoldenQuery"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Удалить эталонный запрос",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор эталонного запроса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Эталонный запрос не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/index/settings": {
+            "get": {
+                "description": "Возвращает число шардов и реплик и предпочтительные уровни узлов данных (_tier_preference) основного или архивного индекса локаций",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Настройки размещения индекса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Индекс: locations (по умолчанию) или archive",
+                        "name": "index",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.IndexSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Изменяет число реплик и предпочтительные уровни узлов данных индекса, например \"data_warm,data_hot\" для переноса устаревающих данных на warm узлы. Поля без значения не меняются, пустой tier_preference возвращает значение по умолчанию кластера. Шарды переносятся кластером в фоне.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Изменить настройки размещения индекса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Индекс: locations (по умолчанию) или archive",
+                        "name": "index",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Новые настройки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.IndexSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.IndexSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{id}": {
+            "get": {
+                "description": "Возвращает статус и прогресс фоновой задачи по её идентификатору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Получить состояние фоновой задачи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locks": {
+            "get": {
+                "description": "Возвращает блокировки заданий, выполняемых одним экземпляром кластера (архивация, сверка, выгрузки, конвейеры): удерживающий экземпляр и сведения этого экземпляра о последнем выполнении и пропуске.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Блокировки заданий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.LocksResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения блокировок",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/mapping": {
+            "get": {
+                "description": "Возвращает текущий маппинг индекса и сравнивает параметры kNN индекса (HNSW m/ef_construction, метрика сходства) с конфигурацией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Получить маппинг индекса локаций",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.MappingResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/metrics": {
+            "get": {
+                "description": "Возвращает количество и длительность запросов по маршрутам, методам и кодам ответа",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Получить метрики HTTP запросов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_metrics.RequestStats"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/models": {
+            "get": {
+                "description": "Возвращает зарегистрированные версии моделей (reranker, footfall, embedding) с метаданными и признаком активности",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Версии моделей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Вид модели",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ModelVersion"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет версию модели в реестр без активации. uri указывает на артефакт: путь к файлу, HTTP(S) URL объекта или адрес сервера моделей.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Зарегистрировать версию модели",
+                "parameters": [
+                    {
+                        "description": "Версия модели",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ModelVersion"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ModelVersion"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Версия уже зарегистрирована",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/models/{kind}/{version}/activate": {
+            "post": {
+                "description": "Загружает версию модели и переключает на нее сервис без перезапуска; другие экземпляры переключаются при проверке активных версий (ACTIVE_VERSION_POLL_SECONDS). Если загрузка не удалась, остается прежняя версия, а причина записывается в лог сервиса.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Активировать версию модели",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Вид модели",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия модели",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ModelVersion"
+                        }
+                    },
+                    "404": {
+                        "description": "Версия не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Не удалось загрузить модель",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pipelines": {
+            "get": {
+                "description": "Возвращает зарегистрированные конвейеры, их шаги и последний запуск",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Список конвейеров",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.PipelineInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pipelines/runs/{id}": {
+            "get": {
+                "description": "Возвращает статус, число попыток и ошибки каждого шага запуска",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Состояние запуска конвейера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор запуска",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Run"
+                        }
+                    },
+                    "404": {
+                        "description": "Запуск не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pipelines/{name}/runs": {
+            "get": {
+                "description": "Возвращает последние запуски конвейера, начиная с самого нового",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "История запусков конвейера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя конвейера",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Run"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Конвейер не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Запускает конвейер вне расписания. Состояние шагов доступно через GET /admin/pipelines/runs/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Запустить конвейер",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя конвейера",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Run"
+                        }
+                    },
+                    "404": {
+                        "description": "Конвейер не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Конвейер уже выполняется на этом или другом экземпляре",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/ranking-profiles": {
+            "get": {
+                "description": "Возвращает профили ранжирования типов бизнеса: бустинг числовых полей локации и веса векторного поиска",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Профили ранжирования",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RankingProfile"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/ranking-profiles/{business_type}": {
+            "put": {
+                "description": "Заменяет бусты числовых полей и веса векторного поиска типа бизнеса. Пустой список boosts удаляет бусты, отсутствие vector_weights — веса (используются равные).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сохранить профиль ранжирования",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Профиль ранжирования",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RankingProfile"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RankingProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reconcile": {
+            "post": {
+                "description": "Запускает фоновую сверку: находит документы, которые есть только в Elasticsearch или только в PostgreSQL. При repair=true расхождения, обнаруженные раньше grace-периода, исправляются: недоставленные локации повторно ставятся в outbox. Сироты в Elasticsearch удаляются, только если дополнительно задан delete_orphans=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сверить локации в PostgreSQL и Elasticsearch",
+                "parameters": [
+                    {
+                        "description": "Параметры сверки",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ReconcileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reference-data": {
+            "get": {
+                "description": "Возвращает справочники типов бизнеса, регионов и городов целиком в формате JSON или CSV для загрузки в другое окружение. Родители записей и регионы городов указываются по именам (для регионов — путям), а не по ID.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Выгрузить справочники",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат выгрузки: json (по умолчанию) или csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceData"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Загружает справочники из выгрузки GET /admin/reference-data (JSON или CSV) и возвращает изменения: добавленные, измененные и отсутствующие в выгрузке записи, а с prune=true и каскадные изменения (удаляемые связи замещаемости, подтипы без категории). С prune=true записи, отсутствующие в выгрузке, удаляются. Изменения рассчитываются в транзакции загрузки; с dry_run=true она откатывается.",
+                "consumes": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Загрузить справочники",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат тела: json или csv (по умолчанию определяется по Content-Type)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только рассчитать изменения",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Удалить записи, отсутствующие в выгрузке",
+                        "name": "prune",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверная выгрузка",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/runtime-fields": {
+            "get": {
+                "description": "Возвращает вычисляемые поля (runtime fields) маппинга индекса локаций",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Вычисляемые поля",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeField"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/runtime-fields/{name}": {
+            "get": {
+                "description": "Возвращает определение вычисляемого поля; для числовых полей — со статистикой значений по индексу (count, min, max, avg)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Вычисляемое поле",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя поля",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeField"
+                        }
+                    },
+                    "400": {
+                        "description": "Скрипт поля завершается ошибкой на документах индекса",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Поле не найдено",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Добавляет вычисляемое поле в маппинг основного и архивного индексов локаций или заменяет его определение. Поле сразу доступно в фильтре field_ranges запросов рекомендаций без переиндексации. OpenSearch вычисляемые поля не поддерживает (400).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сохранить вычисляемое поле",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя поля",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Определение поля (type, script)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeField"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeField"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное определение, ошибка компиляции скрипта или кластер OpenSearch",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Удалить вычисляемое поле",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя поля",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Поле не найдено",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/search-templates": {
+            "get": {
+                "description": "Возвращает версии шаблона поиска рекомендаций (mustache) с признаком активности, начиная с последней",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Версии шаблона поиска",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SearchTemplate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Проверяет шаблон mustache отрисовкой в кластере и сохраняет его новой версией без активации. Без source сохраняется встроенный запрос рекомендаций.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сохранить версию шаблона поиска",
+                "parameters": [
+                    {
+                        "description": "Шаблон (source, description)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SearchTemplate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SearchTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный шаблон",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/search-templates/active": {
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Вернуть встроенный запрос рекомендаций",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/admin/search-templates/{version}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Версия шаблона поиска",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Версия шаблона",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SearchTemplate"
+                        }
+                    },
+                    "404": {
+                        "description": "Версия не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/search-templates/{version}/activate": {
+            "post": {
+                "description": "Сохраняет версию шаблона в кластере и переключает на нее поиск рекомендаций без перезапуска. Векторный (use_embedding) и сезонный (target_month) режимы выполняются встроенным запросом.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Активировать версию шаблона поиска",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Версия шаблона",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SearchTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Кластер отклонил шаблон",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Версия не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/search-templates/{version}/render": {
+            "post": {
+                "description": "Подставляет в версию шаблона параметры запроса рекомендаций (с профилем ранжирования типа бизнеса) и возвращает получившийся запрос к Elasticsearch без его выполнения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Отрисовать шаблон поиска",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Версия шаблона",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Запрос рекомендаций",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос или шаблон",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Версия не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/selfcheck": {
+            "get": {
+                "description": "Возвращает последний отчет самопроверки, выполненной при запуске: конфигурация, доступность Elasticsearch и маппинг, версия схемы PostgreSQL, кеш, загрузка моделей. С run=true выполняет самопроверку заново.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Отчет самопроверки",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Выполнить самопроверку заново",
+                        "name": "run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_selfcheck.Report"
+                        }
+                    },
+                    "404": {
+                        "description": "Самопроверка еще не выполнялась",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/usage": {
+            "get": {
+                "description": "Возвращает дневную стоимость запросов к Elasticsearch по клиентам API: число запросов, время выполнения в кластере, найденные документы, бакеты агрегаций, стоимость в условных единицах и дневной бюджет клиента. Показатели записываются с задержкой до QUERY_COST_FLUSH_SECONDS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Стоимость запросов по клиентам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Клиент (subject API ключа или пользователя)",
+                        "name": "subject",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода YYYY-MM-DD (по умолчанию 6 дней назад)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода YYYY-MM-DD (по умолчанию сегодня)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.QueryCostUsage"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/validation-profiles": {
+            "get": {
+                "description": "Возвращает профили проверки локаций по типам бизнеса: обязательные поля и допустимые диапазоны числовых полей",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Профили проверки локаций",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ValidationProfile"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/validation-profiles/{business_type}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Профиль проверки типа бизнеса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ValidationProfile"
+                        }
+                    },
+                    "404": {
+                        "description": "Профиль не задан",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Задает обязательные поля (required) и диапазоны числовых полей (ranges) локаций типа бизнеса. Профиль применяется при создании, изменении, асинхронной индексации и импорте локаций.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Сохранить профиль проверки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Профиль проверки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ValidationProfile"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ValidationProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Удалить профиль проверки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Профиль не задан",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/segments": {
+            "get": {
+                "description": "Возвращает количество локаций, средние значения и перцентили traffic_score, среднюю конкуренцию и доход по парам регион и тип бизнеса. Агрегаты рассчитываются в Elasticsearch; если он перегружен или недоступен, ответ строится по данным, материализованным в PostgreSQL (source = postgres, refreshed_at — время расчета).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Аналитика по сегментам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Регион",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Источник: elasticsearch или postgres (по умолчанию — с переходом на postgres при сбое)",
+                        "name": "source",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SegmentAnalyticsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/changes": {
+            "get": {
+                "description": "Возвращает добавленные, удаленные и устаревшие эндпоинты и поля моделей по версиям — от новых к старым. Параметр since оставляет версии новее указанной.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Журнал изменений API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Версия, после которой нужны изменения",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_apichanges.Changelog"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/artifacts/{id}": {
+            "get": {
+                "description": "Возвращает файл, сформированный фоновой задачей, если он принадлежит организации клиента",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Скачать артефакт",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор артефакта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Артефакт не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/artifacts/{id}/sign": {
+            "post": {
+                "description": "Возвращает ссылку с ограниченным сроком действия (SIGNED_URL_TTL_MINUTES), по которой файл скачивается без API ключа — например, из браузера",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Создать подписанную ссылку на артефакт",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор артефакта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_service.SignedDownload"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Артефакт не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/business-types": {
+            "get": {
+                "description": "Возвращает страницу типов бизнеса из справочника с общим количеством подходящих записей. Записи отбираются по началу имени и категории и сортируются по имени или ID. Без limit и offset возвращается массив всех отобранных записей, как в прежних версиях API.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "business-types"
+                ],
+                "summary": "Получить список типов бизнеса",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы: по умолчанию 100, больше 1000 — ошибка 400",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число пропускаемых записей",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало имени без учета регистра",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID категории; 0 — категории верхнего уровня",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: name, -name, id, -id (по умолчанию name)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница, если передан limit или offset; без них — массив models.BusinessType всех отобранных записей (схема в x-unpaged-response)",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры выборки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "x-unpaged-response": {
+                    "description": "Ответ без limit и offset: массив всех отобранных записей",
+                    "schema": {
+                        "items": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessType"
+                        },
+                        "type": "array"
+                    }
+                }
+            }
+        },
+        "/cities": {
+            "get": {
+                "description": "Возвращает страницу городов справочника с координатами центров и общим количеством подходящих записей. Записи отбираются по началу имени и региону и сортируются по имени или ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cities"
+                ],
+                "summary": "Получить список городов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 100, не более 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число пропускаемых записей",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало имени без учета регистра",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID региона",
+                        "name": "region_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: name, -name, id, -id (по умолчанию name)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.CityPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры выборки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/cities/lookup": {
+            "get": {
+                "description": "Возвращает города с указанным именем без учета регистра; одноименные города разных регионов различаются параметром region.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cities"
+                ],
+                "summary": "Найти город по имени",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя города",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Регион города",
+                        "name": "region",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Не указано имя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/cities/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cities"
+                ],
+                "summary": "Получить город",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID города",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID города",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Город не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/downloads/artifacts/{id}": {
+            "get": {
+                "description": "Возвращает файл по ссылке из signed_url результата выгрузки или POST /artifacts/{id}/sign",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Скачать артефакт по подписанной ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор артефакта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Срок действия ссылки (Unix time)",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подпись ссылки",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "403": {
+                        "description": "Неверная подпись",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Артефакт не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Срок действия ссылки истек",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/export/flat": {
+            "get": {
+                "description": "Возвращает по строке на локацию с демографией и оценками, развернутыми в столбцы. Набор и порядок столбцов стабильны (schema_version); новые столбцы добавляются только в конец. Постраничный обход — по курсору next_cursor. С format=csv возвращает CSV с заголовком; курсор следующей страницы и общее количество передаются в заголовках X-Next-Cursor и X-Total-Count.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Плоское представление локаций",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Регион",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Город",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из next_cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 1000, не более 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (по умолчанию) или csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FlatViewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/export/sample": {
+            "get": {
+                "description": "Возвращает n случайных локаций, подходящих под фильтр. Каждой локации сопоставляется псевдослучайный ключ random_score из seed и id, в выборку входят локации с наибольшими ключами, поэтому повторный запрос с тем же seed возвращает ту же выборку, пока не изменились данные индекса. Без seed он выбирается случайно и возвращается в ответе. С stratify_by объем распределяется между стратами (регионами или типами бизнеса) пропорционально их численности методом наибольших остатков; страты типов бизнеса пересекаются, и локация может попасть в несколько из них. Поле embedding не возвращается.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Случайная выборка локаций",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Объем выборки (по умолчанию 100, не более 10000)",
+                        "name": "n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seed генератора",
+                        "name": "seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "region или business_type",
+                        "name": "stratify_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Регион",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Город",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SampleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Возвращает статус сервиса и статус каждой зависимости (ok, warning или failed) в поле checks: Elasticsearch (статус кластера и наличие индекса локаций) и PostgreSQL (доступность и примененные миграции). Если проверка не пройдена, возвращает 503 со статусом degraded; тексты ошибок не возвращаются, а пишутся в лог сервиса. Статус кластера yellow не считается сбоем. Проверки те же, что у /readyz, и выполняются не чаще раза в секунду; /readyz возвращает только общий статус.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка работоспособности сервиса",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Зависимость недоступна",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Возвращает 200, пока процесс обрабатывает запросы. Зависимости не проверяются, чтобы сбой Elasticsearch или PostgreSQL не приводил к перезапуску всех экземпляров.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка живости процесса",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "description": "Возвращает статус, прогресс и результат задачи, запущенной организацией клиента (например, POST /projects/{id}/export). Служебные задачи и задачи других организаций не возвращаются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Получить состояние задачи выгрузки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Job"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations": {
+            "post": {
+                "description": "Сохраняет локацию в PostgreSQL; в поиске она появляется после доставки в Elasticsearch через outbox. Без id локации присваивается сгенерированный ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Создать локацию",
+                "parameters": [
+                    {
+                        "description": "Локация",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли редактора локаций",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Локация с таким ID уже существует",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/_mget": {
+            "post": {
+                "description": "Возвращает локации по списку ID одним запросом: найденные в порядке запроса и ID отсутствующих",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Получить несколько локаций",
+                "parameters": [
+                    {
+                        "description": "Список ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.MultiGetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.MultiGetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/async": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Состояние пакета асинхронной индексации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пакета",
+                        "name": "batch_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Не указан batch_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пакет не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Принимает локации и сразу отвечает 202 с идентификатором пакета и идентификаторами отслеживания документов. Документы индексируются через очередь outbox; их состояние (pending, indexed, failed, rejected) доступно на GET /locations/async/{tracking_id}. Если указан callback_url, о завершении каждого документа отправляется POST уведомление. Локация с существующим ID заменяется с сохранением времени создания, истории демографии и состояния; status учитывается только для новых локаций. Доступно только редакторам локаций.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Асинхронно проиндексировать локации",
+                "parameters": [
+                    {
+                        "description": "Локации и адрес уведомлений",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли редактора локаций",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/async/{tracking_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Состояние документа асинхронной индексации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор отслеживания",
+                        "name": "tracking_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexItem"
+                        }
+                    },
+                    "404": {
+                        "description": "Документ не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/changes": {
+            "get": {
+                "description": "Возвращает созданные, обновленные и удаленные локации после курсора since в порядке изменения. Курсор next_cursor передается в следующий запрос. Если изменений нет, запрос с wait ждет их появления до указанного числа секунд (long polling).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Лента изменений локаций",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Курсор из next_cursor предыдущего ответа (пусто — с начала ленты)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество изменений (по умолчанию 100, не более 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сколько секунд ждать изменений, если их нет",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.LocationChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/portfolio": {
+            "post": {
+                "description": "Подбирает целевое количество точек в нескольких регионах, максимизируя суммарную оценку при ограничениях на общий бюджет, число точек в регионе и минимальное расстояние между точками. Отбор жадный, на основе обычного поиска рекомендаций; оценки — исходные оценки Elasticsearch без нормализации, сравнимые между регионами.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Подобрать портфель локаций",
+                "parameters": [
+                    {
+                        "description": "Параметры портфеля",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/recommend": {
+            "get": {
+                "description": "То же, что POST /locations/recommend, с параметрами в строке запроса. Точка отсчета задается lat и lon, радиус поиска от нее — radius (метры), область карты — bbox (запад,юг,восток,север).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Получить рекомендации локаций по параметрам запроса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Регион",
+                        "name": "region",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип бизнеса",
+                        "name": "business_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Город",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество результатов",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Широта точки отсчета",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Долгота точки отсчета",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Радиус от точки отсчета, метры",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Область карты: запад,юг,восток,север",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "false — не учитывать расстояние от центра города без lat и lon",
+                        "name": "distance_from_city_center",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимум результатов до ослабления ограничений",
+                        "name": "min_results",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Исправлять опечатки в регионе и городе",
+                        "name": "autocorrect",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Веса факторов: traffic:0.5,competition:0.3,demographics:0.2",
+                        "name": "weights",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Описание искомого места свободным текстом",
+                        "name": "intent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Доля сходства с intent в оценке (0–1], по умолчанию 0.5",
+                        "name": "intent_weight",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы (next_cursor предыдущего ответа)",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Возвращает список рекомендованных локаций для указанного типа бизнеса в регионе. Локации ранжируются по релевантности с учетом traffic_score, competition_density и демографии с весами из weights. С use_embedding и query_vector локации, подходящие под фильтры, ранжируются по сходству их embedding с вектором запроса в метрике similarity (cosine, dot_product, l2_norm; по умолчанию — метрика индекса), с target_demographics — по сходству вектора демографического профиля района; с обоими векторами сходства суммируются с весами vector_weights или профиля ранжирования типа бизнеса. С intent описание места свободным текстом переводится в embedding настроенным провайдером, и сходство с ним добавляется к оценке по показателям с долей intent_weight (по умолчанию 0.5). Если в ответе есть next_cursor, следующая страница запрашивается с тем же запросом и cursor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Получить рекомендации локаций",
+                "parameters": [
+                    {
+                        "description": "Запрос на рекомендации",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/validate-refs": {
+            "post": {
+                "description": "Сообщает, какие из переданных ID локаций существуют, без передачи документов",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Проверить ссылки на локации",
+                "parameters": [
+                    {
+                        "description": "Список ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.MultiGetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ValidateRefsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}": {
+            "get": {
+                "description": "Возвращает полную информацию о локации по её идентификатору. Для аутентифицированного клиента добавляются заметки и средняя оценка его организации.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Получить детали локации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Искать также в архивном индексе",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.LocationDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Локация не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет данные локации, сохраняя время создания. Изменение появляется в поиске после доставки в Elasticsearch через outbox.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Изменить локацию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Локация",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли редактора локаций",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Локация не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет локацию из PostgreSQL; из индекса она удаляется после доставки изменения через outbox",
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Удалить локацию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Нет роли редактора локаций",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Локация не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Проверить существование локации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Искать также в архиве",
+                        "name": "include_archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            }
+        },
+        "/locations/{id}/notes": {
+            "get": {
+                "description": "Возвращает заметки и оценки локации, оставленные пользователями организации клиента",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Получить заметки о локации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Note"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Клиент без организации",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет заметку и/или оценку от 1 до 5 от имени аутентифицированного пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Добавить заметку к локации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Заметка",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.NoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Клиент без организации",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Локация не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}/notes/{note_id}": {
+            "put": {
+                "description": "Изменяет текст и оценку заметки автора",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Изменить заметку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор заметки",
+                        "name": "note_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Заметка",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.NoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Заметка принадлежит другому пользователю или клиент без организации",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Удалить заметку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор заметки",
+                        "name": "note_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Заметка принадлежит другому пользователю или клиент без организации",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}/status": {
+            "post": {
+                "description": "Переводит локацию в состояние draft, published или retired и записывает переход в журнал. Доступно редакторам локаций; новое состояние применяется в поиске после доставки через outbox.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Изменить состояние локации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Целевое состояние",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.LocationStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.LocationStatusTransition"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос или недопустимый переход",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли редактора",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Локация не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}/status-history": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Журнал состояний локации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.LocationStatusTransition"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли редактора",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/predict/footfall": {
+            "post": {
+                "description": "Прогнозирует ожидаемое число посетителей в день для локации из индекса (location_id) или кандидата, описанного признаками (location), и типа бизнеса",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "predictions"
+                ],
+                "summary": "Прогноз посещаемости",
+                "parameters": [
+                    {
+                        "description": "Локация и тип бизнеса",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FootfallRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FootfallPrediction"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Локация не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Модель прогноза не настроена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects": {
+            "get": {
+                "description": "Возвращает проекты подбора локаций организации клиента",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Получить проекты",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Project"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает проект подбора локаций для типа бизнеса в регионе с необязательным бюджетом",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Создать проект",
+                "parameters": [
+                    {
+                        "description": "Параметры проекта",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ProjectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Project"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}": {
+            "get": {
+                "description": "Возвращает проект, его кандидатов с данными локаций и количество кандидатов по статусам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Получить сводку по проекту",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ProjectSummary"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Изменить проект",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры проекта",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ProjectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Project"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Удалить проект",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Проект принадлежит другому пользователю",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/candidates": {
+            "post": {
+                "description": "Добавляет локацию в шорт-лист проекта; статус по умолчанию — reviewing",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Добавить кандидата в проект",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Кандидат",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.CandidateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Candidate"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект или локация не найдены",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Локация уже добавлена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/candidates/{location_id}": {
+            "put": {
+                "description": "Изменяет статус (reviewing, visited, rejected, selected) и комментарий кандидата",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Изменить статус кандидата",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "location_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Статус кандидата",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.CandidateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Candidate"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект или кандидат не найдены",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Удалить кандидата из проекта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор локации",
+                        "name": "location_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект или кандидат не найдены",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{id}/export": {
+            "post": {
+                "description": "Запускает формирование клиентского отчета: XLSX (строка на кандидата с метриками), PPTX (слайд на кандидата с диаграммой оценок и схемой расположения) или Parquet (строка на кандидата со всеми полями локации и эмбеддингом для pandas/Spark). Готовый файл скачивается по download_url из результата задачи.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Выгрузить проект в XLSX, PPTX или Parquet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор проекта",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Формат отчета",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Проект не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/ranking-overrides": {
+            "get": {
+                "description": "Возвращает веса факторов и бусты полей, применяемые к запросам рекомендаций организации или API ключа клиента",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ranking-overrides"
+                ],
+                "summary": "Получить ранжирование по умолчанию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Область: organization (по умолчанию) или key",
+                        "name": "scope",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RankingOverride"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверная область",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Ранжирование по умолчанию не задано",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Сохраняет веса факторов (для запросов без weights) и бусты полей поверх профиля ранжирования типа бизнеса. Значения API ключа приоритетнее значений организации.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ranking-overrides"
+                ],
+                "summary": "Сохранить ранжирование по умолчанию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Область: organization (по умолчанию) или key",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Веса и бусты",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RankingOverride"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RankingOverride"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "ranking-overrides"
+                ],
+                "summary": "Удалить ранжирование по умолчанию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Область: organization (по умолчанию) или key",
+                        "name": "scope",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Неверная область",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Ранжирование по умолчанию не задано",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Выполняет те же проверки, что и /health (с общим кешем на 1 секунду): кластер Elasticsearch с индексом локаций и PostgreSQL со всеми примененными миграциями. Если экземпляр не готов, возвращает 503 со статусом not_ready: Kubernetes не направляет на него запросы, но не перезапускает. Подробности сбоев пишутся в лог сервиса.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности экземпляра",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Экземпляр не готов",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recommendations/{query_id}/share": {
+            "post": {
+                "description": "Фиксирует результат запроса рекомендаций (query_id из ответа /locations/recommend) в неизменяемый снимок и возвращает публичную ссылку с ограниченным сроком действия. Поделиться можно только запросом своей организации; черновики попадают в снимок только у редактора.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Поделиться выдачей рекомендаций",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор запроса рекомендаций",
+                        "name": "query_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры ссылки",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_handlers.ShareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Запрос не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/regions": {
+            "get": {
+                "description": "Возвращает страницу регионов из справочника с общим количеством подходящих записей. Записи отбираются по началу имени и родительскому региону (для обхода иерархии) и сортируются по имени или ID. Без limit и offset возвращается массив всех отобранных записей, как в прежних версиях API.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Получить список регионов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы: по умолчанию 100, больше 1000 — ошибка 400",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число пропускаемых записей",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало имени без учета регистра",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID родительского региона; 0 — регионы верхнего уровня",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: name, -name, id, -id (по умолчанию name)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница, если передан limit или offset; без них — массив models.Region всех отобранных записей (схема в x-unpaged-response)",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RegionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры выборки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "x-unpaged-response": {
+                    "description": "Ответ без limit и offset: массив всех отобранных записей",
+                    "schema": {
+                        "items": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"
+                        },
+                        "type": "array"
+                    }
+                }
+            }
+        },
+        "/regions/{id}/stats": {
+            "get": {
+                "description": "Возвращает положение региона в иерархии справочника (путь, родительские регионы, подрегионы) и агрегаты опубликованных локаций региона и всех его подрегионов, рассчитанные в Elasticsearch в момент запроса: количество, средние traffic_score и competition_density, конкуренцию по типам бизнеса и распределение дохода.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Сводка по региону",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID региона",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RegionStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID региона",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Регион не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-searches": {
+            "get": {
+                "description": "Возвращает сохраненные поиски организации клиента",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-searches"
+                ],
+                "summary": "Получить сохраненные поиски",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SavedSearch"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Сохраняет фильтры запроса рекомендаций (регион, город, тип бизнеса, гео- и прочие фильтры). Когда новая или измененная локация подходит под фильтры, уведомление отправляется POST запросом на webhook_url.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-searches"
+                ],
+                "summary": "Сохранить поиск",
+                "parameters": [
+                    {
+                        "description": "Сохраненный поиск",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SavedSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SavedSearch"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-searches/{id}": {
+            "delete": {
+                "tags": [
+                    "saved-searches"
+                ],
+                "summary": "Удалить сохраненный поиск",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор сохраненного поиска",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Поиск принадлежит другому пользователю",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Поиск не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/{token}": {
+            "get": {
+                "description": "Возвращает зафиксированную выдачу рекомендаций по токену публичной ссылки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Получить снимок выдачи по ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Snapshot"
+                        }
+                    },
+                    "404": {
+                        "description": "Снимок не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Срок действия ссылки истек",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Возвращает семантическую версию, git коммит, дату сборки и версию Go",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Версия сервиса",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_buildinfo.Info"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_akozadaev_go_es_analytical_system_internal_apichanges.Changelog": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_apichanges.Changes"
+                    }
+                },
+                "current": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_apichanges.Changes": {
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_apichanges.Diff"
+                },
+                "fields": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_apichanges.Diff"
+                },
+                "previous": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_apichanges.Diff": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deprecated": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_buildinfo.Info": {
+            "type": "object",
+            "properties": {
+                "build_date": {
+                    "description": "Дата сборки (RFC 3339)",
+                    "type": "string"
+                },
+                "commit": {
+                    "description": "Git коммит",
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "modified": {
+                    "description": "Сборка из рабочей копии с незакоммиченными изменениями",
+                    "type": "boolean"
+                },
+                "version": {
+                    "description": "Семантическая версия",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_jobs.Job": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "organization": {
+                    "description": "Organization — организация, запустившая задачу через SubmitFor (пусто — служебная задача)",
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "result": {},
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_jobs.Status"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_jobs.Status": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusRunning",
+                "StatusCompleted",
+                "StatusFailed"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_lock.Status": {
+            "type": "object",
+            "properties": {
+                "acquired_at": {
+                    "description": "Время захвата этим экземпляром",
+                    "type": "string"
+                },
+                "held_locally": {
+                    "description": "Блокировку удерживает этот экземпляр",
+                    "type": "boolean"
+                },
+                "holder": {
+                    "description": "Экземпляр, удерживающий блокировку",
+                    "type": "string"
+                },
+                "holder_address": {
+                    "description": "Адрес удерживающего клиента",
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "description": "Последнее выполнение задания этим экземпляром",
+                    "type": "string"
+                },
+                "last_skipped_at": {
+                    "type": "string"
+                },
+                "locked": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_metrics.RequestStats": {
+            "type": "object",
+            "properties": {
+                "avg_seconds": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "max_seconds": {
+                    "type": "number"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "route": {
+                    "type": "string"
+                },
+                "slow_count": {
+                    "description": "Запросы, превысившие порог slow-log маршрута",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "total_seconds": {
+                    "type": "number"
+                },
+                "version": {
+                    "description": "Версия приложения, обработавшего запросы",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "daily_quota": {
+                    "description": "Запросов за сутки UTC (0 — без ограничения)",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Ключ; заполняется только в ответе на выпуск",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Описание клиента, например название партнера",
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Начало ключа для опознания",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "Роли клиента в приложении",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subject": {
+                    "description": "Клиент, от имени которого выполняются запросы",
+                    "type": "string"
+                },
+                "used_today": {
+                    "description": "Запросов за текущие сутки UTC; только в списке ключей",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.APIKeyUpdate": {
+            "type": "object",
+            "properties": {
+                "daily_quota": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.AnalyticsSource": {
+            "type": "string",
+            "enum": [
+                "elasticsearch",
+                "postgres"
+            ],
+            "x-enum-comments": {
+                "AnalyticsLive": "Рассчитаны по индексу в момент запроса",
+                "AnalyticsMaterialized": "Материализованы в PostgreSQL фоновым обновлением"
+            },
+            "x-enum-descriptions": [
+                "Рассчитаны по индексу в момент запроса",
+                "Материализованы в PostgreSQL фоновым обновлением"
+            ],
+            "x-enum-varnames": [
+                "AnalyticsLive",
+                "AnalyticsMaterialized"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexItem": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Попытки индексации",
+                    "type": "integer"
+                },
+                "batch_id": {
+                    "type": "string"
+                },
+                "callback_attempts": {
+                    "description": "Отправки уведомления",
+                    "type": "integer"
+                },
+                "callback_delivered_at": {
+                    "type": "string"
+                },
+                "callback_error": {
+                    "description": "Ошибка последней отправки уведомления",
+                    "type": "string"
+                },
+                "callback_url": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexStatus"
+                },
+                "tracking_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexRequest": {
+            "type": "object",
+            "properties": {
+                "callback_url": {
+                    "description": "URL для POST уведомлений о каждом документе",
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "batch_id": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexItem"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.AsyncIndexStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "indexed",
+                "failed",
+                "rejected"
+            ],
+            "x-enum-comments": {
+                "AsyncIndexFailed": "Не проиндексирован за допустимое число попыток",
+                "AsyncIndexIndexed": "Проиндексирован",
+                "AsyncIndexPending": "Ожидает доставки в Elasticsearch",
+                "AsyncIndexRejected": "Не прошел проверку и не принят"
+            },
+            "x-enum-descriptions": [
+                "Ожидает доставки в Elasticsearch",
+                "Проиндексирован",
+                "Не проиндексирован за допустимое число попыток",
+                "Не прошел проверку и не принят"
+            ],
+            "x-enum-varnames": [
+                "AsyncIndexPending",
+                "AsyncIndexIndexed",
+                "AsyncIndexFailed",
+                "AsyncIndexRejected"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.BusinessType": {
+            "type": "object",
+            "properties": {
+                "benefits_from_events": {
+                    "description": "Бизнес выигрывает от потока посетителей мероприятий",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "Категория, к которой относится подтип",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypeCompetition": {
+            "type": "object",
+            "properties": {
+                "avg_competition_density": {
+                    "type": "number"
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "location_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypePage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessType"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Число записей, подходящих под фильтр",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypeSubstitute": {
+            "type": "object",
+            "properties": {
+                "business_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "substitute": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Candidate": {
+            "type": "object",
+            "properties": {
+                "added_by": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "location": {
+                    "description": "Данные локации; отсутствуют, если локация удалена",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                        }
+                    ]
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.CandidateStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.CandidateRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "description": "Обязательно при добавлении",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "По умолчанию reviewing",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.CandidateStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.CandidateStatus": {
+            "type": "string",
+            "enum": [
+                "reviewing",
+                "visited",
+                "rejected",
+                "selected"
+            ],
+            "x-enum-comments": {
+                "CandidateRejected": "Отклонена",
+                "CandidateReviewing": "На рассмотрении",
+                "CandidateSelected": "Выбрана",
+                "CandidateVisited": "Осмотрена на месте"
+            },
+            "x-enum-descriptions": [
+                "На рассмотрении",
+                "Осмотрена на месте",
+                "Отклонена",
+                "Выбрана"
+            ],
+            "x-enum-varnames": [
+                "CandidateReviewing",
+                "CandidateVisited",
+                "CandidateRejected",
+                "CandidateSelected"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.City": {
+            "type": "object",
+            "properties": {
+                "center": {
+                    "description": "Координаты центра города",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "region": {
+                    "description": "Название региона, заполняется при чтении",
+                    "type": "string"
+                },
+                "region_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.CityPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.City"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Число записей, подходящих под фильтр",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ClusterInstance": {
+            "type": "object",
+            "properties": {
+                "heartbeat_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ClusterRole"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ClusterRole": {
+            "type": "string",
+            "enum": [
+                "leader",
+                "follower"
+            ],
+            "x-enum-comments": {
+                "ClusterRoleFollower": "Только обслуживает HTTP",
+                "ClusterRoleLeader": "Выполняет фоновые процессы и обслуживает HTTP"
+            },
+            "x-enum-descriptions": [
+                "Выполняет фоновые процессы и обслуживает HTTP",
+                "Только обслуживает HTTP"
+            ],
+            "x-enum-varnames": [
+                "ClusterRoleLeader",
+                "ClusterRoleFollower"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ClusterStatus": {
+            "type": "object",
+            "properties": {
+                "instance": {
+                    "description": "Экземпляр, ответивший на запрос",
+                    "type": "string"
+                },
+                "instances": {
+                    "description": "Экземпляры с недавним heartbeat",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ClusterInstance"
+                    }
+                },
+                "leader": {
+                    "type": "string"
+                },
+                "leader_election": {
+                    "description": "false — каждый экземпляр выполняет фоновые процессы",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.DemographicSegment": {
+            "type": "object",
+            "properties": {
+                "age_group": {
+                    "type": "string"
+                },
+                "average_income": {
+                    "type": "number"
+                },
+                "share": {
+                    "description": "Доля населения района, 0–1",
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Demographics": {
+            "type": "object",
+            "properties": {
+                "age_group": {
+                    "description": "Преобладающая возрастная группа",
+                    "type": "string"
+                },
+                "average_income": {
+                    "description": "Средний доход, взвешенный по долям сегментов",
+                    "type": "number"
+                },
+                "interests": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "population_density": {
+                    "type": "number"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicSegment"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.DemographicsSnapshot": {
+            "type": "object",
+            "properties": {
+                "demographics": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                },
+                "effective_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.FieldRange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "gte": {
+                    "type": "number"
+                },
+                "lte": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.FlatColumn": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "string, number, integer или datetime",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.FlatLocation": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "business_types_suitable": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "competition_density": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "description": "RFC 3339, UTC",
+                    "type": "string"
+                },
+                "demographics_age_group": {
+                    "type": "string"
+                },
+                "demographics_average_income": {
+                    "type": "number"
+                },
+                "demographics_interests": {
+                    "type": "string"
+                },
+                "demographics_population_density": {
+                    "type": "number"
+                },
+                "event_exposure": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "paid_lots_nearby": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "safety_score": {
+                    "type": "number"
+                },
+                "schools_nearby": {
+                    "type": "integer"
+                },
+                "street_parking_score": {
+                    "type": "number"
+                },
+                "traffic_score": {
+                    "type": "number"
+                },
+                "universities_nearby": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "RFC 3339, UTC",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.FlatViewResponse": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FlatColumn"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FlatLocation"
+                    }
+                },
+                "schema_version": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Число локаций, подходящих под фильтр",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.FootfallPrediction": {
+            "type": "object",
+            "properties": {
+                "business_type": {
+                    "type": "string"
+                },
+                "expected_daily_visitors": {
+                    "type": "number"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "model_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.FootfallRequest": {
+            "type": "object",
+            "properties": {
+                "business_type": {
+                    "description": "Тип бизнеса (обязательно)",
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                },
+                "location_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.GeoBoundingBox": {
+            "type": "object",
+            "properties": {
+                "bottom_right": {
+                    "description": "Юго-восточный угол",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                        }
+                    ]
+                },
+                "top_left": {
+                    "description": "Северо-западный угол",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "description": "Широта (latitude)",
+                    "type": "number"
+                },
+                "lon": {
+                    "description": "Долгота (longitude)",
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.GoldenQuery": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expected_ids": {
+                    "description": "Ожидаемые локации",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "request": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                },
+                "top_k": {
+                    "description": "Глубина проверки (по умолчанию — число ожидаемых локаций)",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.GoldenQueryResult": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "description": "Фактические top_k локации",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "description": "Ошибка выполнения запроса",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "missing": {
+                    "description": "Ожидаемые локации, не вошедшие в top_k",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passed": {
+                    "type": "boolean"
+                },
+                "positions": {
+                    "description": "Позиции (с 1) найденных ожидаемых локаций",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.GoldenRunReport": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Запросы с отклонениями или ошибками",
+                    "type": "integer"
+                },
+                "passed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GoldenQueryResult"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.IncomeDistribution": {
+            "type": "object",
+            "properties": {
+                "avg": {
+                    "type": "number"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "p25": {
+                    "type": "number"
+                },
+                "p50": {
+                    "type": "number"
+                },
+                "p75": {
+                    "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.IndexSettings": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "description": "Имя индекса (только чтение)",
+                    "type": "string"
+                },
+                "number_of_replicas": {
+                    "description": "NumberOfReplicas — число реплик каждого шарда",
+                    "type": "integer"
+                },
+                "number_of_shards": {
+                    "description": "Число первичных шардов (только чтение)",
+                    "type": "integer"
+                },
+                "tier_preference": {
+                    "description": "TierPreference — предпочтительные уровни узлов данных через запятую, от более приоритетного:\n\"data_warm,data_hot\". Пустая строка при изменении возвращает значение по умолчанию кластера",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Location": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "archived": {
+                    "description": "Локация найдена в архивном индексе",
+                    "type": "boolean"
+                },
+                "business_types_suitable": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "competition_density": {
+                    "type": "number"
+                },
+                "coordinates": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "demographic_embedding": {
+                    "description": "Вектор демографического профиля, вычисляется при записи",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "demographics": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                },
+                "demographics_history": {
+                    "description": "DemographicsHistory — версии демографических данных по возрастанию EffectiveFrom;\nпоследняя совпадает с Demographics",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicsSnapshot"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "embedding_version": {
+                    "description": "Версия модели, построившей Embedding",
+                    "type": "integer"
+                },
+                "event_exposure": {
+                    "description": "EventExposure — близость к площадкам мероприятий с учетом их частоты и вместимости (0–10)",
+                    "type": "number"
+                },
+                "expected_daily_visitors": {
+                    "description": "ExpectedDailyVisitors — прогноз посещаемости для типа бизнеса запроса, если он учитывался в ранжировании",
+                    "type": "number"
+                },
+                "expire_at": {
+                    "description": "Окончание показа, после него локация снимается с публикации",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched_segments": {
+                    "description": "MatchedSegments — демографические сегменты, подошедшие под фильтр segment запроса",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicSegment"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "paid_lots_nearby": {
+                    "type": "integer"
+                },
+                "publish_at": {
+                    "description": "Начало показа опубликованной локации, nil — сразу",
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "safety_score": {
+                    "description": "SafetyScore — индекс безопасности района локации (0–10, выше — безопаснее)",
+                    "type": "number"
+                },
+                "schools_nearby": {
+                    "description": "SchoolsNearby и UniversitiesNearby — число учебных заведений в пешей доступности",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Для ранжирования",
+                    "type": "number"
+                },
+                "seasonality": {
+                    "description": "Seasonality — коэффициенты трафика по месяцам (ключ — номер месяца 1–12); переопределяют\nкоэффициенты города и типа бизнеса",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "status": {
+                    "description": "Состояние жизненного цикла, пусто — LocationPublished",
+                    "type": "string"
+                },
+                "street_parking_score": {
+                    "description": "StreetParkingScore — доступность уличной парковки (0–10), PaidLotsNearby — число платных парковок рядом",
+                    "type": "number"
+                },
+                "traffic_score": {
+                    "type": "number"
+                },
+                "travel_time_seconds": {
+                    "description": "Время в пути от точки отсчета запроса",
+                    "type": "number"
+                },
+                "universities_nearby": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.LocationChange": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                },
+                "operation": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.OutboxOperation"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.LocationChangesResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.LocationChange"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.LocationDetails": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "archived": {
+                    "description": "Локация найдена в архивном индексе",
+                    "type": "boolean"
+                },
+                "business_types_suitable": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "competition_density": {
+                    "type": "number"
+                },
+                "coordinates": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "demographic_embedding": {
+                    "description": "Вектор демографического профиля, вычисляется при записи",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "demographics": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                },
+                "demographics_history": {
+                    "description": "DemographicsHistory — версии демографических данных по возрастанию EffectiveFrom;\nпоследняя совпадает с Demographics",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicsSnapshot"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "embedding_version": {
+                    "description": "Версия модели, построившей Embedding",
+                    "type": "integer"
+                },
+                "event_exposure": {
+                    "description": "EventExposure — близость к площадкам мероприятий с учетом их частоты и вместимости (0–10)",
+                    "type": "number"
+                },
+                "expected_daily_visitors": {
+                    "description": "ExpectedDailyVisitors — прогноз посещаемости для типа бизнеса запроса, если он учитывался в ранжировании",
+                    "type": "number"
+                },
+                "expire_at": {
+                    "description": "Окончание показа, после него локация снимается с публикации",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched_segments": {
+                    "description": "MatchedSegments — демографические сегменты, подошедшие под фильтр segment запроса",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicSegment"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Note"
+                    }
+                },
+                "paid_lots_nearby": {
+                    "type": "integer"
+                },
+                "publish_at": {
+                    "description": "Начало показа опубликованной локации, nil — сразу",
+                    "type": "string"
+                },
+                "rating": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RatingSummary"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "safety_score": {
+                    "description": "SafetyScore — индекс безопасности района локации (0–10, выше — безопаснее)",
+                    "type": "number"
+                },
+                "schools_nearby": {
+                    "description": "SchoolsNearby и UniversitiesNearby — число учебных заведений в пешей доступности",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Для ранжирования",
+                    "type": "number"
+                },
+                "seasonality": {
+                    "description": "Seasonality — коэффициенты трафика по месяцам (ключ — номер месяца 1–12); переопределяют\nкоэффициенты города и типа бизнеса",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "status": {
+                    "description": "Состояние жизненного цикла, пусто — LocationPublished",
+                    "type": "string"
+                },
+                "street_parking_score": {
+                    "description": "StreetParkingScore — доступность уличной парковки (0–10), PaidLotsNearby — число платных парковок рядом",
+                    "type": "number"
+                },
+                "traffic_score": {
+                    "type": "number"
+                },
+                "travel_time_seconds": {
+                    "description": "Время в пути от точки отсчета запроса",
+                    "type": "number"
+                },
+                "universities_nearby": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.LocationStatusRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "Причина перехода (опционально)",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Целевое состояние: draft, published или retired",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.LocationStatusTransition": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Subject клиента, пусто без аутентификации",
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ModelVersion": {
+            "type": "object",
+            "properties": {
+                "activated_at": {
+                    "type": "string"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "reranker, footfall или embedding",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "uri": {
+                    "description": "Путь к файлу, URL объекта или адрес сервера моделей",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.MultiGetRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include_archived": {
+                    "description": "Искать не найденные локации в архиве",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.MultiGetResponse": {
+            "type": "object",
+            "properties": {
+                "found": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Note": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.NoteRequest": {
+            "type": "object",
+            "properties": {
+                "rating": {
+                    "description": "Оценка от 1 до 5 (опционально)",
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.OutboxOperation": {
+            "type": "string",
+            "enum": [
+                "upsert",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "OutboxUpsert",
+                "OutboxDelete"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.PlaceCorrection": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.PlaceSuggestions": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "region": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioItem": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "archived": {
+                    "description": "Локация найдена в архивном индексе",
+                    "type": "boolean"
+                },
+                "business_types_suitable": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "competition_density": {
+                    "type": "number"
+                },
+                "coordinates": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "demographic_embedding": {
+                    "description": "Вектор демографического профиля, вычисляется при записи",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "demographics": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                },
+                "demographics_history": {
+                    "description": "DemographicsHistory — версии демографических данных по возрастанию EffectiveFrom;\nпоследняя совпадает с Demographics",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicsSnapshot"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "embedding_version": {
+                    "description": "Версия модели, построившей Embedding",
+                    "type": "integer"
+                },
+                "event_exposure": {
+                    "description": "EventExposure — близость к площадкам мероприятий с учетом их частоты и вместимости (0–10)",
+                    "type": "number"
+                },
+                "expected_daily_visitors": {
+                    "description": "ExpectedDailyVisitors — прогноз посещаемости для типа бизнеса запроса, если он учитывался в ранжировании",
+                    "type": "number"
+                },
+                "expire_at": {
+                    "description": "Окончание показа, после него локация снимается с публикации",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched_segments": {
+                    "description": "MatchedSegments — демографические сегменты, подошедшие под фильтр segment запроса",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicSegment"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "paid_lots_nearby": {
+                    "type": "integer"
+                },
+                "publish_at": {
+                    "description": "Начало показа опубликованной локации, nil — сразу",
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "safety_score": {
+                    "description": "SafetyScore — индекс безопасности района локации (0–10, выше — безопаснее)",
+                    "type": "number"
+                },
+                "schools_nearby": {
+                    "description": "SchoolsNearby и UniversitiesNearby — число учебных заведений в пешей доступности",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Для ранжирования",
+                    "type": "number"
+                },
+                "seasonality": {
+                    "description": "Seasonality — коэффициенты трафика по месяцам (ключ — номер месяца 1–12); переопределяют\nкоэффициенты города и типа бизнеса",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "status": {
+                    "description": "Состояние жизненного цикла, пусто — LocationPublished",
+                    "type": "string"
+                },
+                "street_parking_score": {
+                    "description": "StreetParkingScore — доступность уличной парковки (0–10), PaidLotsNearby — число платных парковок рядом",
+                    "type": "number"
+                },
+                "traffic_score": {
+                    "type": "number"
+                },
+                "travel_time_seconds": {
+                    "description": "Время в пути от точки отсчета запроса",
+                    "type": "number"
+                },
+                "universities_nearby": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioRegion": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "max_outlets": {
+                    "description": "Максимум точек в регионе (0 — без ограничения)",
+                    "type": "integer"
+                },
+                "outlet_cost": {
+                    "description": "Ожидаемая стоимость открытия одной точки в регионе",
+                    "type": "number"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioRequest": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "description": "Общий бюджет (0 — без ограничения)",
+                    "type": "number"
+                },
+                "business_type": {
+                    "description": "Тип бизнеса (обязательно)",
+                    "type": "string"
+                },
+                "min_distance_meters": {
+                    "description": "Минимальное расстояние между точками, метры",
+                    "type": "number"
+                },
+                "outlets": {
+                    "description": "Целевое количество точек (обязательно)",
+                    "type": "integer"
+                },
+                "regions": {
+                    "description": "Регионы, в которых допускается открытие (обязательно)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioRegion"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioResponse": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "description": "Удалось ли подобрать целевое количество точек",
+                    "type": "boolean"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.PortfolioItem"
+                    }
+                },
+                "outlets": {
+                    "type": "integer"
+                },
+                "total_cost": {
+                    "type": "number"
+                },
+                "total_score": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Project": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "type": "number"
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ProjectRequest": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "type": "number"
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ProjectSummary": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "type": "number"
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Candidate"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "status_counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.QueryCostUsage": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "integer"
+                },
+                "budget": {
+                    "description": "Дневной бюджет клиента (0 — без ограничения)",
+                    "type": "number"
+                },
+                "budget_alerted_at": {
+                    "description": "Время алерта о превышении бюджета",
+                    "type": "string"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "day": {
+                    "description": "Дата в формате YYYY-MM-DD",
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "requests": {
+                    "description": "Запросы API, выполнившие поиск",
+                    "type": "integer"
+                },
+                "searches": {
+                    "type": "integer"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "took_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RankingOverride": {
+            "type": "object",
+            "properties": {
+                "boosts": {
+                    "description": "Boosts заменяют правила профиля с тем же полем; пустой business_type — для всех типов бизнеса",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ScoringBoost"
+                    }
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "subject": {
+                    "description": "Пусто — значения для всей организации",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weights": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ScoringWeights"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RankingProfile": {
+            "type": "object",
+            "properties": {
+                "boosts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ScoringBoost"
+                    }
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "vector_weights": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.VectorWeights"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RatingSummary": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RebuildEmbeddingsRequest": {
+            "type": "object",
+            "properties": {
+                "all": {
+                    "description": "Пересчитать все документы, подходящие под фильтр, независимо от версии",
+                    "type": "boolean"
+                },
+                "business_type": {
+                    "description": "Только локации, подходящие типу бизнеса",
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "created_since": {
+                    "description": "Только документы, созданные начиная с указанного момента",
+                    "type": "string"
+                },
+                "embedding_version_below": {
+                    "description": "Только документы с embedding_version ниже указанной",
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "updated_before": {
+                    "description": "Только документы, обновленные раньше указанного момента",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "description": "AsOf рассчитывает фактор демографии по версии демографических данных, действовавшей\nв указанный момент (для аудита прошлых рекомендаций); локации возвращаются с этой версией",
+                    "type": "string"
+                },
+                "autocorrect": {
+                    "description": "AutoCorrect при пустой выдаче заменяет регион и город с опечаткой ближайшим известным названием",
+                    "type": "boolean"
+                },
+                "business_type": {
+                    "description": "Тип бизнеса (обязательно)",
+                    "type": "string"
+                },
+                "city": {
+                    "description": "Город для фильтрации (опционально)",
+                    "type": "string"
+                },
+                "cursor": {
+                    "description": "Cursor — next_cursor предыдущей страницы для получения следующей; остальные поля запроса\nдолжны совпадать с запросом первой страницы",
+                    "type": "string"
+                },
+                "distance_from_city_center": {
+                    "description": "DistanceFromCityCenter: false отключает фактор расстояния от центра города City из справочника\nгородов, по умолчанию учитываемый в запросах без Origin",
+                    "type": "boolean"
+                },
+                "event_boost": {
+                    "description": "EventBoost включает бустинг локаций рядом с площадкам мероприятий;\nпо умолчанию определяется признаком benefits_from_events типа бизнеса",
+                    "type": "boolean"
+                },
+                "field_ranges": {
+                    "description": "FieldRanges оставляет только локации со значениями полей в заданных границах;\nдопускаются числовые поля индекса и вычисляемые поля (runtime fields)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FieldRange"
+                    }
+                },
+                "footfall_weight": {
+                    "description": "FootfallWeight — доля прогноза посещаемости в итоговой оценке (0–1, 0 — прогноз не учитывается)",
+                    "type": "number"
+                },
+                "geo_bounding_box": {
+                    "description": "BoundingBox оставляет только локации внутри прямоугольной области карты",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoBoundingBox"
+                        }
+                    ]
+                },
+                "geo_polygon": {
+                    "description": "Polygon оставляет только локации внутри многоугольника (например, границы района);\nвершины перечисляются по порядку, многоугольник замыкается автоматически",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                    }
+                },
+                "has_parking": {
+                    "description": "HasParking оставляет только локации с платной парковкой рядом или уличной парковкой не хуже среднего",
+                    "type": "boolean"
+                },
+                "include_archived": {
+                    "description": "IncludeArchived включает в поиск локации из архивного индекса",
+                    "type": "boolean"
+                },
+                "intent": {
+                    "description": "Intent — описание искомого места свободным текстом («тихий спальный район рядом с метро\nдля кофейни»): текст переводится в embedding настроенным провайдером, и сходство с ним\nembedding локаций добавляется к оценке по показателям; фильтры запроса сохраняются",
+                    "type": "string"
+                },
+                "intent_weight": {
+                    "description": "IntentWeight — доля сходства с Intent в оценке (0–1], по умолчанию 0.5",
+                    "type": "number"
+                },
+                "limit": {
+                    "description": "Максимальное количество результатов (по умолчанию 20)",
+                    "type": "integer"
+                },
+                "max_travel_minutes": {
+                    "description": "MaxTravelMinutes исключает локации дальше заданного времени в пути от Origin (0 — без ограничения)",
+                    "type": "number"
+                },
+                "min_distance_meters": {
+                    "description": "MinDistanceMeters — минимальное расстояние между локациями в выдаче, метры (0 — без ограничения).\nИспользуется сетями, планирующими несколько открытий одновременно.",
+                    "type": "number"
+                },
+                "min_parking_score": {
+                    "description": "MinParkingScore — минимальная оценка уличной парковки (0–10)",
+                    "type": "number"
+                },
+                "min_results": {
+                    "description": "MinResults — минимум результатов: при меньшем числе ограничения запроса ослабляются\nв порядке политики сервиса (0 — значение по умолчанию из конфигурации)",
+                    "type": "integer"
+                },
+                "min_safety": {
+                    "description": "MinSafety — минимальный индекс безопасности района (0–10)",
+                    "type": "number"
+                },
+                "origin": {
+                    "description": "Origin — точка отсчета: при указании выдача ранжируется по времени в пути от нее",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                        }
+                    ]
+                },
+                "query_vector": {
+                    "description": "QueryVector — вектор запроса размерности embeddings индекса (с UseEmbedding обязателен\nQueryVector или TargetDemographics)",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "radius_meters": {
+                    "description": "RadiusMeters оставляет только локации не дальше заданного расстояния от Origin по прямой (0 — без ограничения)",
+                    "type": "number"
+                },
+                "region": {
+                    "description": "Регион для поиска (обязательно)",
+                    "type": "string"
+                },
+                "safety_weight": {
+                    "description": "SafetyWeight — бустинг локаций в безопасных районах (0 — без бустинга)",
+                    "type": "number"
+                },
+                "segment": {
+                    "description": "Segment оставляет только локации, в районе которых есть подходящий демографический сегмент;\nподошедшие сегменты возвращаются в matched_segments",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SegmentFilter"
+                        }
+                    ]
+                },
+                "similarity": {
+                    "description": "Similarity — метрика сходства QueryVector: cosine, dot_product или l2_norm;\nне указана — метрика, с которой построен kNN индекс",
+                    "type": "string"
+                },
+                "target_demographics": {
+                    "description": "TargetDemographics — целевой демографический профиль района: с UseEmbedding локации\nранжируются по сходству с ним их вектора демографического профиля",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                        }
+                    ]
+                },
+                "target_month": {
+                    "description": "TargetMonth — месяц открытия (1–12): оценка корректируется сезонным коэффициентом трафика",
+                    "type": "integer"
+                },
+                "use_embedding": {
+                    "description": "UseEmbedding ранжирует локации по сходству их embedding с QueryVector и (или) вектора\nдемографического профиля с TargetDemographics вместо бустинга по показателям;\nфильтры запроса сохраняются",
+                    "type": "boolean"
+                },
+                "vector_weights": {
+                    "description": "VectorWeights — веса сходства по описанию и по демографии, если заданы оба вектора;\nне указаны — веса профиля ранжирования типа бизнеса",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.VectorWeights"
+                        }
+                    ]
+                },
+                "weights": {
+                    "description": "Weights — веса факторов ранжирования; не указаны — веса по умолчанию",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ScoringWeights"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RecommendResponse": {
+            "type": "object",
+            "properties": {
+                "corrected": {
+                    "description": "Corrected — исправленные регион и город, если запрос выполнен с autocorrect",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.PlaceCorrection"
+                        }
+                    ]
+                },
+                "did_you_mean": {
+                    "description": "DidYouMean — известные регионы и города, близкие к указанным в запросе, если по ним ничего не найдено",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.PlaceSuggestions"
+                        }
+                    ]
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor передается в cursor запроса следующей страницы; пусто — страница последняя",
+                    "type": "string"
+                },
+                "query_id": {
+                    "description": "Идентификатор запроса в истории",
+                    "type": "string"
+                },
+                "relaxations": {
+                    "description": "Relaxations — ограничения запроса, ослабленные из-за недостатка результатов, в порядке применения",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "substitution": {
+                    "description": "Substitution заполняется, если выдача дополнена локациями замещающего типа бизнеса",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Substitution"
+                        }
+                    ]
+                },
+                "total": {
+                    "description": "Total — всего локаций, подходящих под запрос; при ограничениях, применяемых после поиска\n(origin, min_distance_meters, footfall_weight), и ослаблении запроса — число локаций в выдаче",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceBusinessType": {
+            "type": "object",
+            "properties": {
+                "benefits_from_events": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "description": "Имя категории",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceCascade": {
+            "type": "object",
+            "properties": {
+                "detached_business_types": {
+                    "description": "DetachedBusinessTypes — оставшиеся типы бизнеса, которые теряют удаляемую категорию (parent_id = NULL)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "substitutes": {
+                    "description": "Substitutes — связи замещаемости \"тип/заменитель\", удаляемые вместе с типами бизнеса",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceChanges": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceCity": {
+            "type": "object",
+            "properties": {
+                "center": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "region": {
+                    "description": "Путь региона города",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceData": {
+            "type": "object",
+            "properties": {
+                "business_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceBusinessType"
+                    }
+                },
+                "cities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceCity"
+                    }
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceRegion"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceDiff": {
+            "type": "object",
+            "properties": {
+                "business_types": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceChanges"
+                },
+                "cascade": {
+                    "description": "Cascade — изменения связанных записей, которые вызовет удаление записей с Prune",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceCascade"
+                        }
+                    ]
+                },
+                "cities": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceChanges"
+                },
+                "dry_run": {
+                    "description": "Изменения только рассчитаны, но не применены",
+                    "type": "boolean"
+                },
+                "prune": {
+                    "description": "Записи, отсутствующие в выгрузке, удаляются",
+                    "type": "boolean"
+                },
+                "regions": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceChanges"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceRegion": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "description": "Путь родительского региона, например \"Россия/Ленинградская область\"",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Region": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_region_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RegionLocationStats": {
+            "type": "object",
+            "properties": {
+                "avg_competition_density": {
+                    "type": "number"
+                },
+                "avg_traffic_score": {
+                    "type": "number"
+                },
+                "competition": {
+                    "description": "По убыванию числа локаций",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessTypeCompetition"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "income": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.IncomeDistribution"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RegionPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Число записей, подходящих под фильтр",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RegionStats": {
+            "type": "object",
+            "properties": {
+                "ancestors": {
+                    "description": "Родительские регионы от корня",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"
+                    }
+                },
+                "children": {
+                    "description": "Непосредственные подрегионы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"
+                    }
+                },
+                "locations": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RegionLocationStats"
+                },
+                "path": {
+                    "description": "Путь от корня иерархии через \"/\"",
+                    "type": "string"
+                },
+                "region": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"
+                },
+                "regions": {
+                    "description": "Регионы локаций, вошедших в агрегаты: сам регион и подрегионы",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeField": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "script": {
+                    "description": "Значение передается через emit(...)",
+                    "type": "string"
+                },
+                "stats": {
+                    "description": "Статистика значений числового поля",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeFieldStats"
+                        }
+                    ]
+                },
+                "type": {
+                    "description": "double, long, keyword, boolean или date",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.RuntimeFieldStats": {
+            "type": "object",
+            "properties": {
+                "avg": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SampleResponse": {
+            "type": "object",
+            "properties": {
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SampledLocation"
+                    }
+                },
+                "method": {
+                    "description": "Описание метода отбора",
+                    "type": "string"
+                },
+                "n": {
+                    "type": "integer"
+                },
+                "population": {
+                    "description": "Число локаций, подходящих под фильтр",
+                    "type": "integer"
+                },
+                "seed": {
+                    "type": "integer"
+                },
+                "strata": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SampleStratum"
+                    }
+                },
+                "stratify_by": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SampleStratify"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SampleStratify": {
+            "type": "string",
+            "enum": [
+                "",
+                "region",
+                "business_type"
+            ],
+            "x-enum-comments": {
+                "StratifyBusinessType": "Страты — подходящие типы бизнеса (пересекаются)",
+                "StratifyNone": "Простая случайная выборка",
+                "StratifyRegion": "Страты — регионы"
+            },
+            "x-enum-descriptions": [
+                "Простая случайная выборка",
+                "Страты — регионы",
+                "Страты — подходящие типы бизнеса (пересекаются)"
+            ],
+            "x-enum-varnames": [
+                "StratifyNone",
+                "StratifyRegion",
+                "StratifyBusinessType"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SampleStratum": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SampledLocation": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "archived": {
+                    "description": "Локация найдена в архивном индексе",
+                    "type": "boolean"
+                },
+                "business_types_suitable": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "competition_density": {
+                    "type": "number"
+                },
+                "coordinates": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.GeoPoint"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "demographic_embedding": {
+                    "description": "Вектор демографического профиля, вычисляется при записи",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "demographics": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                },
+                "demographics_history": {
+                    "description": "DemographicsHistory — версии демографических данных по возрастанию EffectiveFrom;\nпоследняя совпадает с Demographics",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicsSnapshot"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "embedding_version": {
+                    "description": "Версия модели, построившей Embedding",
+                    "type": "integer"
+                },
+                "event_exposure": {
+                    "description": "EventExposure — близость к площадкам мероприятий с учетом их частоты и вместимости (0–10)",
+                    "type": "number"
+                },
+                "expected_daily_visitors": {
+                    "description": "ExpectedDailyVisitors — прогноз посещаемости для типа бизнеса запроса, если он учитывался в ранжировании",
+                    "type": "number"
+                },
+                "expire_at": {
+                    "description": "Окончание показа, после него локация снимается с публикации",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched_segments": {
+                    "description": "MatchedSegments — демографические сегменты, подошедшие под фильтр segment запроса",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.DemographicSegment"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "paid_lots_nearby": {
+                    "type": "integer"
+                },
+                "publish_at": {
+                    "description": "Начало показа опубликованной локации, nil — сразу",
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "safety_score": {
+                    "description": "SafetyScore — индекс безопасности района локации (0–10, выше — безопаснее)",
+                    "type": "number"
+                },
+                "schools_nearby": {
+                    "description": "SchoolsNearby и UniversitiesNearby — число учебных заведений в пешей доступности",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Для ранжирования",
+                    "type": "number"
+                },
+                "seasonality": {
+                    "description": "Seasonality — коэффициенты трафика по месяцам (ключ — номер месяца 1–12); переопределяют\nкоэффициенты города и типа бизнеса",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "status": {
+                    "description": "Состояние жизненного цикла, пусто — LocationPublished",
+                    "type": "string"
+                },
+                "stratum": {
+                    "type": "string"
+                },
+                "street_parking_score": {
+                    "description": "StreetParkingScore — доступность уличной парковки (0–10), PaidLotsNearby — число платных парковок рядом",
+                    "type": "number"
+                },
+                "traffic_score": {
+                    "type": "number"
+                },
+                "travel_time_seconds": {
+                    "description": "Время в пути от точки отсчета запроса",
+                    "type": "number"
+                },
+                "universities_nearby": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SavedSearch": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "request": {
+                    "description": "Учитываются только фильтры запроса",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                        }
+                    ]
+                },
+                "webhook_url": {
+                    "description": "Без адреса уведомления пишутся в лог",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SavedSearchRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "request": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ScoringBoost": {
+            "type": "object",
+            "properties": {
+                "boost": {
+                    "type": "number"
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "min_value": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ScoringWeights": {
+            "type": "object",
+            "properties": {
+                "competition": {
+                    "type": "number"
+                },
+                "demographics": {
+                    "type": "number"
+                },
+                "distance": {
+                    "description": "Учитывается только с origin",
+                    "type": "number"
+                },
+                "traffic": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SearchTemplate": {
+            "type": "object",
+            "properties": {
+                "activated_at": {
+                    "type": "string"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Текст шаблона mustache",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SegmentAnalyticsResponse": {
+            "type": "object",
+            "properties": {
+                "refreshed_at": {
+                    "description": "Время материализации (для source = postgres)",
+                    "type": "string"
                 },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.SegmentStats"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.AnalyticsSource"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SegmentFilter": {
+            "type": "object",
+            "properties": {
+                "age_group": {
+                    "type": "string"
+                },
+                "max_income": {
+                    "description": "Максимальный средний доход сегмента (0 — без ограничения)",
+                    "type": "number"
+                },
+                "min_income": {
+                    "description": "Минимальный средний доход сегмента",
+                    "type": "number"
+                },
+                "min_share": {
+                    "description": "Минимальная доля населения, 0–1",
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.SegmentStats": {
+            "type": "object",
+            "properties": {
+                "avg_competition_density": {
+                    "type": "number"
+                },
+                "avg_income": {
+                    "description": "Средний доход населения района",
+                    "type": "number"
+                },
+                "avg_traffic_score": {
+                    "type": "number"
+                },
+                "business_type": {
+                    "type": "string"
+                },
+                "location_count": {
+                    "type": "integer"
+                },
+                "p50_traffic_score": {
+                    "type": "number"
+                },
+                "p90_traffic_score": {
+                    "type": "number"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Snapshot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                    }
+                },
+                "query_id": {
+                    "type": "string"
+                },
+                "request": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.Substitution": {
+            "type": "object",
+            "properties": {
+                "business_type": {
+                    "description": "Запрошенный тип бизнеса",
+                    "type": "string"
+                },
+                "substitute": {
+                    "description": "Тип бизнеса, по которому построена выдача",
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Доля замещаемости",
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ValidateRefsResponse": {
+            "type": "object",
+            "properties": {
+                "existing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.ValidationProfile": {
+            "type": "object",
+            "properties": {
+                "business_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "ranges": {
+                    "description": "Допустимые диапазоны числовых полей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.FieldRange"
+                    }
+                },
+                "required": {
+                    "description": "Поля, которые должны быть заполнены",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_models.VectorWeights": {
+            "type": "object",
+            "properties": {
                 "demographics": {
-                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Demographics"
+                    "type": "number"
                 },
                 "description": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_orchestrator.PipelineInfo": {
+            "type": "object",
+            "properties": {
+                "last_run": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Run"
+                },
+                "name": {
                     "type": "string"
                 },
-                "embedding": {
+                "running": {
+                    "type": "boolean"
+                },
+                "steps": {
                     "type": "array",
                     "items": {
-                        "type": "number"
+                        "type": "string"
                     }
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Run": {
+            "type": "object",
+            "properties": {
+                "finished_at": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "pipeline": {
                     "type": "string"
                 },
-                "region": {
+                "started_at": {
                     "type": "string"
                 },
-                "score": {
-                    "description": "Для ранжирования",
-                    "type": "number"
+                "status": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Status"
                 },
-                "traffic_score": {
-                    "type": "number"
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.StepState"
+                    }
                 },
-                "updated_at": {
+                "trigger": {
+                    "description": "manual или schedule",
                     "type": "string"
                 }
             }
         },
-        "github_com_akozadaev_go_es_analytical_system_internal_models.RecommendRequest": {
+        "github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Status": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed",
+                "skipped"
+            ],
+            "x-enum-comments": {
+                "StatusSkipped": "Не запускался из-за неудачи зависимости"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "",
+                "Не запускался из-за неудачи зависимости"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusRunning",
+                "StatusCompleted",
+                "StatusFailed",
+                "StatusSkipped"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_orchestrator.StepState": {
             "type": "object",
             "properties": {
-                "business_type": {
-                    "description": "Тип бизнеса (обязательно)",
+                "attempts": {
+                    "type": "integer"
+                },
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
                     "type": "string"
                 },
-                "city": {
-                    "description": "Город для фильтрации (опционально)",
+                "finished_at": {
                     "type": "string"
                 },
-                "limit": {
-                    "description": "Максимальное количество результатов (по умолчанию 20)",
+                "name": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_orchestrator.Status"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_report.Format": {
+            "type": "string",
+            "enum": [
+                "xlsx",
+                "pptx",
+                "parquet"
+            ],
+            "x-enum-comments": {
+                "FormatPPTX": "Презентация: слайд на каждого кандидата",
+                "FormatParquet": "Столбцовый файл для pandas/Spark: строка на каждого кандидата",
+                "FormatXLSX": "Таблица: строка на каждого кандидата"
+            },
+            "x-enum-descriptions": [
+                "Таблица: строка на каждого кандидата",
+                "Презентация: слайд на каждого кандидата",
+                "Столбцовый файл для pandas/Spark: строка на каждого кандидата"
+            ],
+            "x-enum-varnames": [
+                "FormatXLSX",
+                "FormatPPTX",
+                "FormatParquet"
+            ]
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_selfcheck.Check": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
                     "type": "integer"
                 },
-                "region": {
-                    "description": "Регион для поиска (обязательно)",
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "github_com_akozadaev_go_es_analytical_system_internal_models.RecommendResponse": {
+        "github_com_akozadaev_go_es_analytical_system_internal_selfcheck.Report": {
             "type": "object",
             "properties": {
-                "locations": {
+                "checks": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Location"
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_selfcheck.Check"
                     }
                 },
-                "total": {
+                "duration_ms": {
                     "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
-        "github_com_akozadaev_go_es_analytical_system_internal_models.Region": {
+        "github_com_akozadaev_go_es_analytical_system_internal_service.SignedDownload": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "expires_at": {
                     "type": "string"
                 },
-                "id": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_akozadaev_go_es_analytical_system_internal_storage.VectorIndexOptions": {
+            "type": "object",
+            "properties": {
+                "dims": {
+                    "description": "Размерность векторов",
                     "type": "integer"
                 },
-                "name": {
+                "ef_construction": {
+                    "description": "HNSW: размер списка кандидатов при построении графа",
+                    "type": "integer"
+                },
+                "m": {
+                    "description": "HNSW: число связей узла графа",
+                    "type": "integer"
+                },
+                "similarity": {
+                    "description": "Метрика сходства: cosine, dot_product, l2_norm",
                     "type": "string"
+                }
+            }
+        },
+        "internal_handlers.ArchiveRequest": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "description": "Только найти кандидатов, не перенося их",
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_handlers.ExportRequest": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "description": "xlsx, pptx или parquet",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_report.Format"
+                        }
+                    ]
+                }
+            }
+        },
+        "internal_handlers.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Зависимость -\u003e ok, warning или failed",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "elasticsearch": "ok",
+                        "postgres": "ok"
+                    }
                 },
-                "parent_region_id": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_handlers.LocksResponse": {
+            "type": "object",
+            "properties": {
+                "instance": {
+                    "description": "Экземпляр, ответивший на запрос",
+                    "type": "string"
+                },
+                "locks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_lock.Status"
+                    }
+                }
+            }
+        },
+        "internal_handlers.MappingResponse": {
+            "type": "object",
+            "properties": {
+                "current_vector_options": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_storage.VectorIndexOptions"
+                },
+                "desired_vector_options": {
+                    "$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_storage.VectorIndexOptions"
+                },
+                "in_sync": {
+                    "description": "false — для применения настроек нужна переиндексация",
+                    "type": "boolean"
+                },
+                "mapping": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "internal_handlers.ReconcileRequest": {
+            "type": "object",
+            "properties": {
+                "delete_orphans": {
+                    "description": "С repair удалить сироты из Elasticsearch",
+                    "type": "boolean"
+                },
+                "repair": {
+                    "description": "Исправить расхождения старше grace-периода",
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_handlers.ShareRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in_hours": {
+                    "description": "Срок действия ссылки (по умолчанию SHARE_TTL_HOURS)",
                     "type": "integer"
+                }
+            }
+        },
+        "internal_handlers.ShareResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "token": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
@@ -0,0 +1,100 @@
+// Package acme автоматически получает и продлевает TLS сертификаты Let's Encrypt (или другого
+// ACME сервера, RFC 8555) для небольших автономных развертываний без внешнего reverse proxy.
+//
+// Протокол ACME реализует golang.org/x/crypto/acme/autocert; пакет задает политику доменов,
+// кеш и обработчик порта HTTP. Владение доменом подтверждается проверкой http-01 (HTTPHandler
+// отвечает на запросы /.well-known/acme-challenge/ на порту 80, а остальные запросы перенаправляет
+// на HTTPS) или tls-alpn-01 на порту HTTPS. Ключ аккаунта и сертификаты хранятся в каталоге кеша,
+// чтобы не выпускать их заново при каждом перезапуске (Let's Encrypt ограничивает число выпусков).
+package acme
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// LetsEncryptURL — адрес ACME directory Let's Encrypt.
+const LetsEncryptURL = autocert.DefaultACMEDirectory
+
+// renewBefore — за сколько до истечения сертификат продлевается.
+const renewBefore = 30 * 24 * time.Hour
+
+// Config задает параметры получения сертификатов.
+type Config struct {
+	Domains      []string // Разрешенные домены; сертификаты для других имен не запрашиваются
+	CacheDir     string   // Каталог для ключа аккаунта и сертификатов
+	Email        string   // Контактный адрес ACME аккаунта (уведомления об истечении)
+	DirectoryURL string   // Адрес ACME directory (пусто — Let's Encrypt)
+}
+
+// Manager выдает сертификаты для TLS рукопожатий, получая их у ACME сервера при первом
+// обращении к домену и продлевая в фоне.
+type Manager struct {
+	autocert *autocert.Manager
+}
+
+// New создает Manager. Каталог кеша создается при необходимости.
+func New(cfg Config) (*Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("acme: no domains configured")
+	}
+	if cfg.CacheDir == "" {
+		return nil, errors.New("acme: cache dir is required")
+	}
+	if cfg.DirectoryURL == "" {
+		cfg.DirectoryURL = LetsEncryptURL
+	}
+	if err := os.MkdirAll(cfg.CacheDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create acme cache dir: %w", err)
+	}
+
+	domains := make([]string, len(cfg.Domains))
+	for i, domain := range cfg.Domains {
+		domains[i] = strings.ToLower(strings.TrimSuffix(domain, "."))
+	}
+
+	return &Manager{autocert: &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       autocert.DirCache(cfg.CacheDir),
+		HostPolicy:  autocert.HostWhitelist(domains...),
+		RenewBefore: renewBefore,
+		Email:       cfg.Email,
+		Client:      &acme.Client{DirectoryURL: cfg.DirectoryURL},
+	}}, nil
+}
+
+// TLSConfig возвращает конфигурацию TLS, получающую сертификаты через Manager.
+func (m *Manager) TLSConfig() *tls.Config {
+	config := m.autocert.TLSConfig()
+	config.MinVersion = tls.VersionTLS12
+	return config
+}
+
+// HTTPHandler отвечает на проверки http-01 и перенаправляет остальные GET и HEAD запросы
+// на HTTPS; запросы с другими методами получают 400. httpsPort — порт HTTPS слушателя для
+// адреса перенаправления ("443" опускается).
+func (m *Manager) HTTPHandler(httpsPort string) http.Handler {
+	return m.autocert.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Use HTTPS", http.StatusBadRequest)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}))
+}
@@ -0,0 +1,120 @@
+// Package anomaly содержит статистические проверки распределения оценок локаций
+// после загрузки данных, позволяющие обнаружить сломанные источники данных.
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
+)
+
+// Kind определяет тип обнаруженной аномалии.
+type Kind string
+
+const (
+	KindMeanShift Kind = "mean_shift" // Резкое изменение среднего traffic_score
+	KindZeroSpike Kind = "zero_spike" // Всплеск нулевых значений traffic_score
+)
+
+// Finding описывает одну обнаруженную аномалию по городу.
+type Finding struct {
+	City   string  `json:"city"`
+	Kind   Kind    `json:"kind"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// Thresholds содержит пороги срабатывания проверок.
+type Thresholds struct {
+	MeanShift float64 // Допустимое относительное изменение среднего (0.3 = 30%)
+	ZeroShare float64 // Допустимый прирост доли нулевых значений (0.1 = 10 п.п.)
+	MinDocs   int     // Города с меньшим числом документов не проверяются
+}
+
+// Detector сравнивает статистику до и после загрузки и отправляет алерты через Notifier.
+type Detector struct {
+	thresholds Thresholds
+	notifier   notify.Notifier
+}
+
+// NewDetector создает новый экземпляр Detector.
+func NewDetector(thresholds Thresholds, notifier notify.Notifier) *Detector {
+	return &Detector{
+		thresholds: thresholds,
+		notifier:   notifier,
+	}
+}
+
+// Compare сравнивает статистику по городам до и после загрузки и возвращает найденные аномалии.
+// Города, отсутствовавшие до загрузки, не проверяются: для них нет базовой линии.
+func (d *Detector) Compare(before, after map[string]*models.CityScoreStats) []Finding {
+	var findings []Finding
+
+	for city, cur := range after {
+		prev, ok := before[city]
+		if !ok || prev.DocCount < d.thresholds.MinDocs || cur.DocCount < d.thresholds.MinDocs {
+			continue
+		}
+
+		if prev.AvgTrafficScore > 0 {
+			shift := math.Abs(cur.AvgTrafficScore-prev.AvgTrafficScore) / prev.AvgTrafficScore
+			if shift > d.thresholds.MeanShift {
+				findings = append(findings, Finding{
+					City:   city,
+					Kind:   KindMeanShift,
+					Before: prev.AvgTrafficScore,
+					After:  cur.AvgTrafficScore,
+				})
+			}
+		}
+
+		prevZeroShare := float64(prev.ZeroTrafficCount) / float64(prev.DocCount)
+		curZeroShare := float64(cur.ZeroTrafficCount) / float64(cur.DocCount)
+		if curZeroShare-prevZeroShare > d.thresholds.ZeroShare {
+			findings = append(findings, Finding{
+				City:   city,
+				Kind:   KindZeroSpike,
+				Before: prevZeroShare,
+				After:  curZeroShare,
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].City != findings[j].City {
+			return findings[i].City < findings[j].City
+		}
+		return findings[i].Kind < findings[j].Kind
+	})
+
+	return findings
+}
+
+// Check сравнивает статистику и, если найдены аномалии, отправляет один сводный алерт.
+// source указывает, какая загрузка проверяется (например, "indexer").
+func (d *Detector) Check(ctx context.Context, source string, before, after map[string]*models.CityScoreStats) ([]Finding, error) {
+	findings := d.Compare(before, after)
+	if len(findings) == 0 {
+		return nil, nil
+	}
+
+	alert := notify.Alert{
+		Source:    source,
+		Severity:  notify.SeverityWarning,
+		Title:     "Score distribution anomaly detected",
+		Message:   fmt.Sprintf("%d anomalies found after data load", len(findings)),
+		Details:   map[string]interface{}{"findings": findings},
+		CreatedAt: time.Now(),
+	}
+
+	if err := d.notifier.Notify(ctx, alert); err != nil {
+		return findings, fmt.Errorf("failed to send anomaly alert: %w", err)
+	}
+
+	return findings, nil
+}
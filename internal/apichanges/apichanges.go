@@ -0,0 +1,241 @@
+// Package apichanges формирует журнал изменений контракта API: сравнивает архивные Swagger
+// спецификации выпусков (archive/<версия>.json) между собой и с текущей спецификацией,
+// сгенерированной из аннотаций handlers, и перечисляет добавленные, удаленные и устаревшие
+// эндпоинты и поля моделей.
+package apichanges
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/swaggo/swag"
+)
+
+//go:embed archive/*.json
+var archive embed.FS
+
+// Changes — изменения контракта между версией Previous и Version.
+type Changes struct {
+	Version   string `json:"version"`
+	Previous  string `json:"previous,omitempty"`
+	Endpoints Diff   `json:"endpoints"`
+	Fields    Diff   `json:"fields"`
+}
+
+// Diff — добавленные, удаленные и ставшие устаревшими элементы: эндпоинты в виде "GET /path",
+// поля в виде "Модель.поле".
+type Diff struct {
+	Added      []string `json:"added"`
+	Removed    []string `json:"removed"`
+	Deprecated []string `json:"deprecated"`
+}
+
+// Changelog — журнал изменений от первой архивной версии до текущей, от новых к старым.
+type Changelog struct {
+	Current string    `json:"current"`
+	Changes []Changes `json:"changes"`
+}
+
+// changed сообщает, отличаются ли эндпоинты или поля спецификаций.
+func changed(previous, current *spec) bool {
+	for _, d := range []Diff{diffEndpoints(previous, current), diffFields(previous, current)} {
+		if len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Deprecated) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// spec — часть Swagger 2.0 спецификации, участвующая в сравнении.
+type spec struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths       map[string]map[string]operation `json:"paths"`
+	Definitions map[string]definition           `json:"definitions"`
+}
+
+type operation struct {
+	Deprecated bool `json:"deprecated"`
+}
+
+type definition struct {
+	Properties map[string]property `json:"properties"`
+}
+
+type property struct {
+	Deprecated bool `json:"x-deprecated"`
+}
+
+type version struct {
+	name string
+	spec *spec
+}
+
+// Build строит журнал изменений по архивным спецификациям и текущей спецификации,
+// зарегистрированной пакетом docs. currentVersion — версия текущей сборки, под которой
+// в журнал попадает текущая спецификация, если она отличается от последней архивной.
+func Build(currentVersion string) (*Changelog, error) {
+	versions, err := loadArchive()
+	if err != nil {
+		return nil, err
+	}
+
+	if doc, err := swag.ReadDoc(); err == nil {
+		var current spec
+		if err := json.Unmarshal([]byte(doc), &current); err != nil {
+			return nil, fmt.Errorf("failed to decode current swagger spec: %w", err)
+		}
+		// Текущая спецификация добавляется, только если отличается от последней архивной
+		if len(versions) == 0 || changed(versions[len(versions)-1].spec, &current) {
+			versions = append(versions, version{name: currentVersion, spec: &current})
+		}
+	}
+
+	changelog := &Changelog{Changes: []Changes{}}
+	for i := len(versions) - 1; i >= 0; i-- {
+		changes := Changes{Version: versions[i].name}
+		var previous *spec
+		if i > 0 {
+			changes.Previous = versions[i-1].name
+			previous = versions[i-1].spec
+		}
+		changes.Endpoints = diffEndpoints(previous, versions[i].spec)
+		changes.Fields = diffFields(previous, versions[i].spec)
+		changelog.Changes = append(changelog.Changes, changes)
+	}
+	if len(versions) > 0 {
+		changelog.Current = versions[len(versions)-1].name
+	}
+
+	return changelog, nil
+}
+
+// Since возвращает изменения версий новее since.
+func (c *Changelog) Since(since string) []Changes {
+	var changes []Changes
+	for _, ch := range c.Changes {
+		if compareVersions(ch.Version, since) <= 0 {
+			break
+		}
+		changes = append(changes, ch)
+	}
+	if changes == nil {
+		changes = []Changes{}
+	}
+	return changes
+}
+
+// loadArchive читает архивные спецификации в порядке возрастания версий.
+func loadArchive() ([]version, error) {
+	entries, err := archive.ReadDir("archive")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []version
+	for _, entry := range entries {
+		data, err := archive.ReadFile(path.Join("archive", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var s spec
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", entry.Name(), err)
+		}
+		versions = append(versions, version{name: strings.TrimSuffix(entry.Name(), ".json"), spec: &s})
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i].name, versions[j].name) < 0 })
+
+	return versions, nil
+}
+
+func diffEndpoints(previous, current *spec) Diff {
+	list := func(s *spec) map[string]bool {
+		endpoints := make(map[string]bool)
+		if s == nil {
+			return endpoints
+		}
+		for p, ops := range s.Paths {
+			for method, op := range ops {
+				endpoints[strings.ToUpper(method)+" "+p] = op.Deprecated
+			}
+		}
+		return endpoints
+	}
+	return diff(list(previous), list(current))
+}
+
+func diffFields(previous, current *spec) Diff {
+	list := func(s *spec) map[string]bool {
+		fields := make(map[string]bool)
+		if s == nil {
+			return fields
+		}
+		for name, def := range s.Definitions {
+			// swag с --parseDependency добавляет к имени модели путь пакета
+			name = name[strings.LastIndex(name, ".")+1:]
+			for field, prop := range def.Properties {
+				fields[name+"."+field] = prop.Deprecated
+			}
+		}
+		return fields
+	}
+	return diff(list(previous), list(current))
+}
+
+// diff сравнивает наборы элементов; значение — признак устаревания элемента.
+func diff(previous, current map[string]bool) Diff {
+	d := Diff{Added: []string{}, Removed: []string{}, Deprecated: []string{}}
+	for name, deprecated := range current {
+		wasDeprecated, existed := previous[name]
+		if !existed {
+			d.Added = append(d.Added, name)
+		}
+		if deprecated && !wasDeprecated {
+			d.Deprecated = append(d.Deprecated, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Deprecated)
+	return d
+}
+
+// compareVersions сравнивает версии вида 1.2.3 (префикс v допускается) по числовым компонентам;
+// нечисловые компоненты сравниваются как строки.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y string
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errX := strconv.Atoi(x)
+		ny, errY := strconv.Atoi(y)
+		switch {
+		case errX == nil && errY == nil && nx != ny:
+			if nx < ny {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,952 @@
+// Package app собирает приложение из конфигурации: хранилища, сервисы, HTTP роутер
+// и фоновые процессы. Используется всеми точками входа (cmd/server, тесты, будущие воркеры),
+// чтобы приложение конструировалось одинаково.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/archive"
+	"github.com/akozadaev/go_es_analytical_system/internal/artifact"
+	"github.com/akozadaev/go_es_analytical_system/internal/buildinfo"
+	"github.com/akozadaev/go_es_analytical_system/internal/chaos"
+	"github.com/akozadaev/go_es_analytical_system/internal/clickhouse"
+	"github.com/akozadaev/go_es_analytical_system/internal/cluster"
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+	"github.com/akozadaev/go_es_analytical_system/internal/footfall"
+	"github.com/akozadaev/go_es_analytical_system/internal/handlers"
+	"github.com/akozadaev/go_es_analytical_system/internal/httpproxy"
+	"github.com/akozadaev/go_es_analytical_system/internal/jobs"
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
+	"github.com/akozadaev/go_es_analytical_system/internal/middleware"
+	"github.com/akozadaev/go_es_analytical_system/internal/mlregistry"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
+	"github.com/akozadaev/go_es_analytical_system/internal/oidc"
+	"github.com/akozadaev/go_es_analytical_system/internal/orchestrator"
+	"github.com/akozadaev/go_es_analytical_system/internal/outbox"
+	"github.com/akozadaev/go_es_analytical_system/internal/querycost"
+	"github.com/akozadaev/go_es_analytical_system/internal/reconcile"
+	"github.com/akozadaev/go_es_analytical_system/internal/redis"
+	"github.com/akozadaev/go_es_analytical_system/internal/refresh"
+	"github.com/akozadaev/go_es_analytical_system/internal/routing"
+	"github.com/akozadaev/go_es_analytical_system/internal/selfcheck"
+	"github.com/akozadaev/go_es_analytical_system/internal/server"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/signedurl"
+	"github.com/akozadaev/go_es_analytical_system/internal/sqlhook"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/akozadaev/go_es_analytical_system/internal/tracing"
+	"github.com/akozadaev/go_es_analytical_system/internal/webhook"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/gorilla/mux"
+)
+
+// Runner — фоновый процесс приложения. Должен завершаться при отмене ctx.
+type Runner func(ctx context.Context) error
+
+// Closer освобождает ресурс приложения при остановке.
+type Closer func() error
+
+// App содержит собранное приложение.
+type App struct {
+	Config  *config.Config
+	Router  *mux.Router
+	Handler http.Handler // Router, обернутый цепочкой middleware
+	Metrics *metrics.Registry
+	// Recorder получает метрики запросов: реестр Metrics и, если настроены, Prometheus и агент StatsD
+	Recorder metrics.Recorder
+	// Prometheus отдает метрики запросов и хранилищ на GET /metrics (nil — отключено)
+	Prometheus *metrics.Prometheus
+
+	ESStorage *storage.ElasticsearchStorage
+	PGStorage *storage.PostgresStorage
+	Jobs      *jobs.Manager
+	Locks     *lock.Manager // Блокировки заданий, выполняемых одним экземпляром кластера
+	Cluster   *cluster.Node // Роль экземпляра в кластере
+	Pipelines *orchestrator.Orchestrator
+	Models    *mlregistry.Registry
+	SelfCheck *selfcheck.Checker
+	Health    *selfcheck.Checker // Проверки зависимостей для GET /health и GET /readyz
+
+	Recommendations    *service.RecommendationService
+	Locations          *service.LocationService
+	References         *service.ReferenceService
+	Cities             *service.CityService
+	Snapshots          *service.SnapshotService
+	Notes              *service.NoteService
+	Projects           *service.ProjectService
+	Exports            *service.ExportService
+	Footfall           *service.FootfallService
+	GoldenQueries      *service.GoldenQueryService
+	SearchTemplates    *service.SearchTemplateService
+	SavedSearches      *service.SavedSearchService
+	Rankings           *service.RankingOverrideService
+	Substitutes        *service.SubstituteService
+	ValidationProfiles *service.ValidationProfileService
+	ReferenceData      *service.ReferenceDataService
+	RankingProfiles    *service.RankingProfileService
+	Changes            *service.ChangeFeedService
+	Analytics          *service.AnalyticsService
+	Costs              *service.CostService
+	AsyncIndex         *service.AsyncIndexService
+	APIKeys            *service.APIKeyService
+
+	runners map[string]Runner
+	closers []Closer
+	chaos   *chaos.Injector     // Внедрение сбоев (nil — отключено)
+	tracer  *tracing.Tracer     // Трассировка запросов (nil — отключена)
+	oidc    *oidc.Authenticator // Проверка токенов OIDC провайдера (nil — отключена)
+	signer  *signedurl.Signer   // Подпись ссылок на скачивание
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// options содержит параметры сборки приложения.
+type options struct {
+	mappingPaths []string
+	skipIndex    bool
+	routerHooks  []func(*App, *mux.Router)
+	runners      map[string]Runner
+	closers      []Closer
+}
+
+// Option настраивает сборку приложения.
+type Option func(*options)
+
+// WithMappingPaths задает пути поиска файла маппинга Elasticsearch.
+func WithMappingPaths(paths ...string) Option {
+	return func(o *options) {
+		o.mappingPaths = paths
+	}
+}
+
+// WithoutIndexSetup отключает создание индекса при сборке (например, для read-only окружений).
+func WithoutIndexSetup() Option {
+	return func(o *options) {
+		o.skipIndex = true
+	}
+}
+
+// WithRouterHook регистрирует функцию, вызываемую после настройки стандартных маршрутов.
+// Позволяет точкам входа добавлять собственные маршруты и middleware.
+func WithRouterHook(hook func(*App, *mux.Router)) Option {
+	return func(o *options) {
+		o.routerHooks = append(o.routerHooks, hook)
+	}
+}
+
+// WithRunner добавляет именованный фоновый процесс, запускаемый в Start.
+func WithRunner(name string, runner Runner) Option {
+	return func(o *options) {
+		o.runners[name] = runner
+	}
+}
+
+// WithCloser добавляет функцию освобождения ресурса, вызываемую в Close.
+func WithCloser(closer Closer) Option {
+	return func(o *options) {
+		o.closers = append(o.closers, closer)
+	}
+}
+
+// BuildApp собирает приложение по конфигурации.
+// При ошибке уже открытые ресурсы закрываются.
+func BuildApp(cfg *config.Config, opts ...Option) (_ *App, err error) {
+	o := &options{
+		mappingPaths: []string{
+			"migrations/elasticsearch_mapping.json",
+			"../migrations/elasticsearch_mapping.json",
+			filepath.Join(filepath.Dir(os.Args[0]), "../migrations/elasticsearch_mapping.json"),
+		},
+		runners: make(map[string]Runner),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	a := &App{
+		Config:  cfg,
+		Metrics: metrics.NewRegistry(buildinfo.Get().Version),
+		runners: o.runners,
+	}
+	defer func() {
+		if err != nil {
+			a.Close()
+		}
+	}()
+
+	if err := httpproxy.Install(httpproxy.Config{URL: cfg.OutboundProxyURL, NoProxy: cfg.OutboundNoProxy}); err != nil {
+		return nil, err
+	}
+
+	recorders := []metrics.Recorder{a.Metrics}
+	if cfg.PrometheusEnabled {
+		a.Prometheus = metrics.NewPrometheus(buildinfo.Get().Version)
+		a.Prometheus.SetToken(cfg.PrometheusToken)
+		recorders = append(recorders, a.Prometheus)
+	}
+	if cfg.StatsDAddress != "" {
+		statsd, err := metrics.NewStatsD(metrics.StatsDConfig{
+			Address:   cfg.StatsDAddress,
+			Namespace: cfg.StatsDNamespace,
+			Tags:      append([]string{"version:" + buildinfo.Get().Version}, cfg.StatsDTags...),
+			DogStatsD: cfg.StatsDDogStatsD,
+		})
+		if err != nil {
+			return nil, err
+		}
+		a.closers = append(a.closers, statsd.Close)
+		recorders = append(recorders, statsd)
+		slog.Info("Sending metrics to StatsD", slog.String("address", cfg.StatsDAddress))
+	}
+	a.Recorder = metrics.Multi(recorders...)
+
+	// На тестовом стенде запросы к Elasticsearch проходят через транспорт, внедряющий сбои
+	var esTransport http.RoundTripper = http.DefaultTransport
+	if cfg.ChaosEnabled {
+		a.chaos = chaos.New(chaos.Config{
+			LatencyRate:   cfg.ChaosLatencyRate,
+			Latency:       time.Duration(cfg.ChaosLatencyMs) * time.Millisecond,
+			ErrorRate:     cfg.ChaosErrorRate,
+			ESErrorRate:   cfg.ChaosESErrorRate,
+			ESPartialRate: cfg.ChaosESPartialRate,
+		})
+		esTransport = a.chaos.Transport(esTransport)
+		slog.Warn("Fault injection is enabled, do not use in production")
+	}
+	if a.Prometheus != nil {
+		esTransport = metrics.Transport(esTransport, a.Prometheus)
+	}
+	if cfg.OTLPEndpoint != "" {
+		headers, err := tracing.ParseHeaders(cfg.OTLPHeaders)
+		if err != nil {
+			return nil, err
+		}
+		a.tracer, err = tracing.New(tracing.Config{
+			Endpoint:    cfg.OTLPEndpoint,
+			Headers:     headers,
+			ServiceName: cfg.OTelServiceName,
+			Version:     buildinfo.Get().Version,
+			SampleRatio: cfg.TraceSampleRatio,
+		})
+		if err != nil {
+			return nil, err
+		}
+		a.closers = append(a.closers, a.tracer.Close)
+		// Span запроса к Elasticsearch включает задержки и сбои, внедренные на тестовом стенде
+		esTransport = a.tracer.Transport(esTransport)
+		slog.Info("Exporting traces", slog.String("endpoint", cfg.OTLPEndpoint))
+	}
+	if cfg.OIDCIssuer != "" {
+		roleMapping, err := oidc.ParseRoleMapping(cfg.OIDCRoleMapping)
+		if err != nil {
+			return nil, err
+		}
+		a.oidc, err = oidc.New(oidc.Config{
+			Issuer:            cfg.OIDCIssuer,
+			Audience:          cfg.OIDCAudience,
+			RolesClaim:        cfg.OIDCRolesClaim,
+			RoleMapping:       roleMapping,
+			OrganizationClaim: cfg.OIDCOrganizationClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Заголовок с версией приложения вместо meta header клиента, отключенного для совместимости с OpenSearch
+	esTransport = buildinfo.Transport(esTransport)
+	// Стоимость поисковых запросов учитывается по клиентам API (middleware cost)
+	esTransport = querycost.Transport(esTransport)
+
+	// Инициализация Elasticsearch клиента
+	// Используем кастомный транспорт для обхода проверки типа сервера
+	esClient, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:         []string{cfg.ElasticsearchURL},
+		DisableMetaHeader: true,
+		Transport:         esTransport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+	slog.Info("Elasticsearch/OpenSearch client initialized")
+
+	a.ESStorage = storage.NewElasticsearchStorageWithURL(esClient, "locations", cfg.ElasticsearchURL)
+	a.ESStorage.SetTransport(esTransport)
+	if a.Prometheus != nil {
+		a.ESStorage.SetRecorder(a.Prometheus)
+	}
+
+	vectorOptions := storage.VectorIndexOptions{
+		Dims:           cfg.EmbeddingDims,
+		Similarity:     cfg.KNNSimilarity,
+		M:              cfg.KNNM,
+		EfConstruction: cfg.KNNEfConstruction,
+	}
+
+	if !o.skipIndex {
+		if err := setupIndex(a.ESStorage, vectorOptions, o.mappingPaths); err != nil {
+			return nil, err
+		}
+	}
+
+	// Инициализация PostgreSQL клиента
+	var pgHooks []sqlhook.Hook
+	if a.Prometheus != nil {
+		pgHooks = append(pgHooks, metrics.SQLHook(a.Prometheus))
+	}
+	var openDB storage.SQLOpener
+	if a.tracer != nil {
+		openDB = a.tracer.OpenDB
+	}
+	a.PGStorage, err = storage.NewPostgresStorageWithHooks(cfg.PostgresDSN(), openDB, pgHooks...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PostgreSQL client: %w", err)
+	}
+	a.closers = append(a.closers, a.PGStorage.Close)
+	slog.Info("Connected to PostgreSQL")
+
+	// Фоновые задачи останавливаются вместе с приложением
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.Jobs = jobs.NewManager(ctx)
+	a.Locks = lock.New(a.PGStorage.AdvisoryLocks(), instanceID(cfg.InstanceID))
+	a.Pipelines = orchestrator.New(ctx)
+	a.Pipelines.SetLocks(a.Locks)
+
+	// Модели из конфигурации используются, пока в реестре не активированы другие версии
+	footfallModel, err := footfall.New(cfg.FootfallModel, cfg.FootfallModelPath, cfg.FootfallModelURL, cfg.FootfallModelVersion)
+	if err != nil {
+		return nil, err
+	}
+	a.Models = mlregistry.New(a.PGStorage, cfg.ModelDir, footfallModel,
+		embedding.New(cfg.EmbeddingProvider, cfg.EmbeddingURL, cfg.EmbeddingVersion, cfg.EmbeddingDims))
+	if err := a.Models.LoadActive(ctx); err != nil {
+		slog.Warn("Could not load active model versions", logging.Err(err))
+	}
+
+	// Инициализация сервисного слоя
+	cacheTTL := time.Duration(cfg.CacheTTLSeconds) * time.Second
+	routingProvider := routing.New(cfg.RoutingProvider, cfg.RoutingURL, cfg.RoutingBatchSize, cfg.RoutingSpeedKmh,
+		time.Duration(cfg.RoutingCacheTTLMinutes)*time.Minute)
+	relaxation := service.RelaxationPolicy{
+		Steps:        cfg.RecommendRelaxations,
+		MinResults:   cfg.RecommendMinResults,
+		RadiusFactor: cfg.RecommendRadiusWidenFactor,
+	}
+	if err := relaxation.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid relaxation policy: %w", err)
+	}
+	a.Recommendations = service.NewRecommendationService(a.ESStorage, a.PGStorage, cacheTTL, cfg.RecommendMaxLimit, routingProvider, a.Models.Footfall(), relaxation)
+	a.Recommendations.SetEmbedder(a.Models.Embedder())
+	a.ESStorage.OnGenerationChange(a.Recommendations.PurgeGenerations)
+	if a.Prometheus != nil {
+		a.Recommendations.SetRecorder(a.Prometheus)
+	}
+	a.Locations = service.NewLocationService(a.ESStorage, a.PGStorage, cfg.LocationsMaxIDs)
+	a.Locations.SetEditorRole(cfg.LocationEditorRole)
+	a.Footfall = service.NewFootfallService(a.Models.Footfall(), a.Locations, a.PGStorage)
+	a.GoldenQueries = service.NewGoldenQueryService(a.Recommendations, a.PGStorage)
+	a.SearchTemplates = service.NewSearchTemplateService(a.ESStorage, a.PGStorage, a.Recommendations)
+	if err := a.SearchTemplates.LoadActive(ctx); err != nil {
+		slog.Warn("Could not load active search template, using built-in query", logging.Err(err))
+	}
+	a.Substitutes = service.NewSubstituteService(a.PGStorage)
+	a.ValidationProfiles = service.NewValidationProfileService(a.PGStorage, cacheTTL)
+	a.RankingProfiles = service.NewRankingProfileService(a.PGStorage)
+	a.Locations.SetValidationProfiles(a.ValidationProfiles)
+	a.Changes = service.NewChangeFeedService(a.PGStorage, time.Duration(cfg.ChangesMaxWaitSeconds)*time.Second,
+		time.Duration(cfg.ChangesPollIntervalMs)*time.Millisecond)
+	a.Changes.SetEditorRole(cfg.LocationEditorRole)
+	a.Analytics = service.NewAnalyticsService(a.ESStorage, a.PGStorage, time.Duration(cfg.AnalyticsLiveTimeoutMs)*time.Millisecond)
+	a.Analytics.SetLocks(a.Locks)
+	a.SelfCheck = a.newSelfCheck(vectorOptions)
+	a.Health = a.newHealthCheck()
+	a.References = service.NewReferenceService(a.PGStorage, cacheTTL)
+	a.Recommendations.SetReferences(a.References)
+	a.Locations.SetReferences(a.References)
+	a.Analytics.SetReferences(a.References)
+	a.ReferenceData = service.NewReferenceDataService(a.PGStorage, a.References)
+	a.Cities = service.NewCityService(a.PGStorage, a.References)
+	a.Notes = service.NewNoteService(a.Locations, a.PGStorage)
+	a.Projects = service.NewProjectService(a.ESStorage, a.PGStorage, a.Locations)
+	a.SavedSearches = service.NewSavedSearchService(a.ESStorage, a.PGStorage, a.Recommendations)
+	a.SavedSearches.SetWebhookPolicy(webhook.Policy{AllowPrivate: cfg.WebhookAllowPrivate})
+	if _, ok := a.runners["saved_search_notifications"]; !ok {
+		a.runners["saved_search_notifications"] = a.SavedSearches.Runner(
+			time.Duration(cfg.SavedSearchNotifyIntervalSeconds) * time.Second)
+	}
+	a.Rankings = service.NewRankingOverrideService(a.PGStorage, a.Recommendations)
+	a.APIKeys = service.NewAPIKeyService(a.PGStorage, time.Duration(cfg.APIKeyCacheSeconds)*time.Second)
+	if cfg.APIKeyStore && cfg.APIKeyCheckSeconds > 0 {
+		if _, ok := a.runners["api_keys"]; !ok {
+			a.runners["api_keys"] = a.APIKeys.Runner(time.Duration(cfg.APIKeyCheckSeconds) * time.Second)
+		}
+	}
+	if cfg.APIKeyQuotaRedisURL != "" {
+		client, err := redis.New(cfg.APIKeyQuotaRedisURL, 16)
+		if err != nil {
+			return nil, err
+		}
+		a.closers = append(a.closers, client.Close)
+		a.APIKeys.SetRedis(redis.NewCounters(client, "go_es_analytical_system:quota:"),
+			time.Duration(cfg.RateLimitTimeoutMs)*time.Millisecond)
+	}
+
+	artifacts, err := artifact.NewFileStore(cfg.ArtifactDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SignedURLSecret == "" {
+		slog.Warn("SIGNED_URL_SECRET is not set, signed download links are invalidated on restart")
+	}
+	a.signer, err = signedurl.New(cfg.SignedURLSecret, time.Duration(cfg.SignedURLTTLMinutes)*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create url signer: %w", err)
+	}
+	a.Exports = service.NewExportService(a.Projects, a.Jobs, artifacts, a.signer)
+	a.Snapshots = service.NewSnapshotService(a.ESStorage, a.PGStorage,
+		time.Duration(cfg.ShareTTLHours)*time.Hour, time.Duration(cfg.ShareMaxTTLHours)*time.Hour)
+	a.Snapshots.SetEditorRole(cfg.LocationEditorRole)
+
+	relay := outbox.NewRelay(a.PGStorage, a.ESStorage, time.Duration(cfg.OutboxPollIntervalMs)*time.Millisecond, cfg.OutboxBatchSize)
+	relay.SetMatcher(a.SavedSearches)
+	if _, ok := a.runners["outbox_relay"]; !ok {
+		a.runners["outbox_relay"] = relay.Run
+	}
+
+	a.AsyncIndex = service.NewAsyncIndexService(a.PGStorage, cfg.AsyncIndexMaxDocuments)
+	a.AsyncIndex.SetValidationProfiles(a.ValidationProfiles)
+	a.AsyncIndex.SetEditorRole(cfg.LocationEditorRole)
+	a.AsyncIndex.SetWebhookPolicy(webhook.Policy{AllowPrivate: cfg.WebhookAllowPrivate})
+	a.AsyncIndex.SetReferences(a.References)
+	if _, ok := a.runners["async_index"]; !ok {
+		a.runners["async_index"] = a.AsyncIndex.Runner(time.Duration(cfg.AsyncCallbackIntervalSeconds)*time.Second,
+			time.Duration(cfg.AsyncIndexRetentionHours)*time.Hour)
+	}
+
+	if cfg.CacheGenerationPollMs > 0 && cfg.CacheTTLSeconds > 0 {
+		if _, ok := a.runners["cache_generation"]; !ok {
+			interval := time.Duration(cfg.CacheGenerationPollMs) * time.Millisecond
+			a.runners["cache_generation"] = func(ctx context.Context) error {
+				return a.ESStorage.WatchGeneration(ctx, interval)
+			}
+		}
+	}
+
+	if cfg.ActiveVersionPollSeconds > 0 {
+		if _, ok := a.runners["search_template"]; !ok {
+			a.runners["search_template"] = a.SearchTemplates.Runner(time.Duration(cfg.ActiveVersionPollSeconds) * time.Second)
+		}
+		if _, ok := a.runners["model_registry"]; !ok {
+			a.runners["model_registry"] = a.Models.Runner(time.Duration(cfg.ActiveVersionPollSeconds) * time.Second)
+		}
+	}
+
+	if _, ok := a.runners["idempotency_cleanup"]; !ok {
+		a.runners["idempotency_cleanup"] = idempotencyCleanup(a.PGStorage, time.Hour)
+	}
+
+	if cfg.LocationScheduleIntervalSeconds > 0 {
+		if _, ok := a.runners["location_schedule"]; !ok {
+			a.runners["location_schedule"] = locationScheduler(a.PGStorage, time.Duration(cfg.LocationScheduleIntervalSeconds)*time.Second)
+		}
+	}
+
+	notifier := notify.New(cfg.NotifyWebhookURL)
+	costBudgets, err := service.ParseCostBudgets(cfg.QueryCostBudgets)
+	if err != nil {
+		return nil, err
+	}
+	a.Costs = service.NewCostService(a.PGStorage, notifier, costBudgets, cfg.QueryCostDefaultBudget)
+	if _, ok := a.runners["query_costs"]; !ok {
+		a.runners["query_costs"] = a.Costs.Runner(time.Duration(cfg.QueryCostFlushSeconds) * time.Second)
+	}
+
+	reconciler := reconcile.NewReconciler(a.PGStorage, a.ESStorage, notifier, time.Duration(cfg.ReconcileGraceMinutes)*time.Minute)
+	reconciler.SetLocks(a.Locks)
+	if cfg.ReconcileIntervalMinutes > 0 {
+		if _, ok := a.runners["reconcile"]; !ok {
+			a.runners["reconcile"] = reconciler.Runner(time.Duration(cfg.ReconcileIntervalMinutes)*time.Minute, reconcile.Options{
+				Repair:        cfg.ReconcileAutoRepair,
+				DeleteOrphans: cfg.ReconcileDeleteOrphans,
+			})
+		}
+	}
+
+	archiver := archive.NewArchiver(a.ESStorage, a.PGStorage, time.Duration(cfg.ArchiveAfterMonths)*30*24*time.Hour)
+	archiver.SetLocks(a.Locks)
+	if cfg.ArchiveIntervalHours > 0 {
+		if _, ok := a.runners["archive"]; !ok {
+			a.runners["archive"] = archiver.Runner(time.Duration(cfg.ArchiveIntervalHours) * time.Hour)
+		}
+	}
+
+	err = a.Pipelines.Register(refresh.NewPipeline(refresh.Deps{
+		ESStorage:         a.ESStorage,
+		PGStorage:         a.PGStorage,
+		Relay:             relay,
+		Recommendations:   a.Recommendations,
+		Analytics:         a.Analytics,
+		CompetitionRadius: cfg.CompetitionRadiusMeters,
+		EventRadius:       cfg.EventRadiusMeters,
+		WalkingDistance:   cfg.WalkingDistanceMeters,
+		WarmQueries:       cfg.CacheWarmQueries,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AnalyticsIntervalMinutes > 0 {
+		if _, ok := a.runners["analytics"]; !ok {
+			a.runners["analytics"] = a.Analytics.Runner(time.Duration(cfg.AnalyticsIntervalMinutes) * time.Minute)
+		}
+	}
+
+	if cfg.ClickHouseDSN != "" && cfg.ClickHouseExportIntervalMinutes > 0 {
+		client, err := clickhouse.New(cfg.ClickHouseDSN)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := a.runners["clickhouse_export"]; !ok {
+			exporter := clickhouse.NewExporter(client, a.ESStorage, a.PGStorage, cfg.ClickHouseBatchSize)
+			exporter.SetLocks(a.Locks)
+			a.Locks.Register(clickhouse.LockName)
+			a.runners["clickhouse_export"] = exporter.Runner(time.Duration(cfg.ClickHouseExportIntervalMinutes) * time.Minute)
+		}
+	}
+
+	a.Locks.Register(archive.LockName, reconcile.LockName, service.AnalyticsLockName, orchestrator.LockName(refresh.PipelineName))
+
+	if cfg.RefreshIntervalMinutes > 0 {
+		if _, ok := a.runners["refresh"]; !ok {
+			a.runners["refresh"] = a.Pipelines.Runner(refresh.PipelineName, time.Duration(cfg.RefreshIntervalMinutes)*time.Minute)
+		}
+	}
+
+	// При выборе лидера фоновые процессы из leaderRunners выполняет только лидер
+	if cfg.LeaderElection {
+		interval := time.Duration(cfg.LeaderCheckSeconds) * time.Second
+		elector := lock.NewElector(a.PGStorage.AdvisoryLocks(), a.Locks.Instance(), interval)
+		a.Cluster = cluster.New(a.PGStorage, elector, a.Locks.Instance(), buildinfo.Get().Version, interval)
+		a.runners["leader"] = leaderRunner(elector, a.takeLeaderRunners())
+		a.runners["cluster_heartbeat"] = a.Cluster.Heartbeat
+	} else {
+		a.Cluster = cluster.New(a.PGStorage, nil, a.Locks.Instance(), buildinfo.Get().Version, 0)
+	}
+
+	// Инициализация handlers
+	routes := server.Handlers{
+		API:       handlers.NewHandlers(a.Recommendations, a.Locations, a.References, a.Notes, a.Changes, a.Health),
+		Snapshots: handlers.NewSnapshotHandlers(a.Snapshots, cfg.PublicBaseURL),
+		Notes:     handlers.NewNoteHandlers(a.Notes),
+		Projects:  handlers.NewProjectHandlers(a.Projects),
+		Exports:   handlers.NewExportHandlers(a.Exports),
+		Saved:     handlers.NewSavedSearchHandlers(a.SavedSearches),
+		Async:     handlers.NewAsyncIndexHandlers(a.AsyncIndex),
+		Rankings:  handlers.NewRankingOverrideHandlers(a.Rankings),
+		Predict:   handlers.NewPredictionHandlers(a.Footfall),
+		Analytics: handlers.NewAnalyticsHandlers(a.Analytics),
+		Signer:    a.signer,
+	}
+	if a.Prometheus != nil {
+		routes.Metrics = a.Prometheus
+	}
+	routes.Admin = handlers.NewAdminHandlers(handlers.AdminDeps{
+		ESStorage:          a.ESStorage,
+		Jobs:               a.Jobs,
+		Embedder:           a.Models.Embedder(),
+		Models:             a.Models,
+		EmbeddingBatchSize: cfg.EmbeddingBatchSize,
+		EmbeddingRateLimit: cfg.EmbeddingRateLimit,
+		VectorOptions:      vectorOptions,
+		Metrics:            a.Metrics,
+		Reconciler:         reconciler,
+		Archiver:           archiver,
+		Pipelines:          a.Pipelines,
+		GoldenQueries:      a.GoldenQueries,
+		Substitutes:        a.Substitutes,
+		ValidationProfiles: a.ValidationProfiles,
+		Cities:             a.Cities,
+		ReferenceData:      a.ReferenceData,
+		RankingProfiles:    a.RankingProfiles,
+		SelfCheck:          a.SelfCheck,
+		Costs:              a.Costs,
+		APIKeys:            a.APIKeys,
+		IndexSettings:      service.NewIndexSettingsService(a.ESStorage),
+		SearchTemplates:    a.SearchTemplates,
+		RuntimeFields:      service.NewRuntimeFieldService(a.ESStorage, a.Recommendations),
+		Locks:              a.Locks,
+		Cluster:            a.Cluster,
+	})
+
+	a.Router = server.NewRouter(cfg, routes)
+
+	for _, hook := range o.routerHooks {
+		hook(a, a.Router)
+	}
+
+	chain, err := a.buildMiddlewareChain()
+	if err != nil {
+		return nil, err
+	}
+	a.Handler = chain.Then(a.Router)
+	slog.Info("Middleware chain", slog.String("chain", strings.Join(chain.Names(), " -> ")))
+
+	// Отчет самопроверки не прерывает запуск: он логируется и доступен через GET /admin/selfcheck
+	a.SelfCheck.Run(ctx)
+
+	a.closers = append(a.closers, o.closers...)
+
+	return a, nil
+}
+
+// leaderRunners — фоновые процессы, которые при выборе лидера выполняет только лидер: доставка outbox,
+// уведомлений о сохраненных поисках и асинхронной индексации и задания по расписанию. Остальные
+// процессы (сброс учета стоимости запросов, отслеживание записей для кеша) относятся к состоянию
+// экземпляра и выполняются всеми.
+var leaderRunners = []string{"outbox_relay", "async_index", "idempotency_cleanup", "location_schedule", "reconcile", "archive", "analytics", "clickhouse_export", "refresh"}
+
+// takeLeaderRunners извлекает из фоновых процессов приложения процессы лидера.
+func (a *App) takeLeaderRunners() map[string]Runner {
+	taken := make(map[string]Runner)
+	for _, name := range leaderRunners {
+		if runner, ok := a.runners[name]; ok {
+			taken[name] = runner
+			delete(a.runners, name)
+		}
+	}
+	return taken
+}
+
+// leaderRunner возвращает фоновый процесс, участвующий в выборах лидера и выполняющий runners,
+// пока экземпляр остается лидером. При потере лидерства runners останавливаются и запускаются
+// снова, когда экземпляр опять станет лидером.
+func leaderRunner(elector *lock.Elector, runners map[string]Runner) Runner {
+	return func(ctx context.Context) error {
+		return elector.Run(ctx, func(ctx context.Context) {
+			var wg sync.WaitGroup
+			for name, runner := range runners {
+				wg.Add(1)
+				go func(name string, runner Runner) {
+					defer wg.Done()
+					slog.Info("Leader runner started", slog.String("runner", name))
+					if err := runner(ctx); err != nil && ctx.Err() == nil {
+						slog.Error("Leader runner failed", slog.String("runner", name), logging.Err(err))
+					}
+				}(name, runner)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// locationScheduleBatch — максимум локаций каждого вида, обрабатываемых за один запуск
+// задания расписания публикации; остальные обрабатываются при следующих запусках.
+const locationScheduleBatch = 500
+
+// locationScheduler возвращает фоновый процесс расписания публикации локаций. Поиск скрывает
+// локации вне срока показа сам; процесс снимает с публикации локации с истекшим expire_at
+// (с записью в журнал переходов от имени scheduler) и снимает наступивший publish_at, чтобы
+// локация переиндексировалась и проверилась по сохраненным поискам.
+func locationScheduler(pg *storage.PostgresStorage, interval time.Duration) Runner {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				published, retired := applyLocationSchedules(ctx, pg, time.Now())
+				if published > 0 || retired > 0 {
+					slog.Info("Applied location schedules", slog.Int("published", published), slog.Int("retired", retired))
+				}
+			}
+		}
+	}
+}
+
+// applyLocationSchedules выполняет один запуск задания расписания публикации и возвращает
+// число опубликованных и снятых с публикации локаций. Ошибки логируются и не прерывают
+// обработку остальных локаций.
+func applyLocationSchedules(ctx context.Context, pg *storage.PostgresStorage, now time.Time) (published, retired int) {
+	ids, err := pg.ListDuePublications(ctx, now, locationScheduleBatch)
+	if err != nil {
+		slog.Error("Error listing scheduled publications", logging.Err(err))
+	}
+	for _, id := range ids {
+		ok, err := pg.CompletePublication(ctx, id, now)
+		if err != nil {
+			slog.Error("Error publishing scheduled location", slog.String("location_id", id), logging.Err(err))
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+
+	ids, err = pg.ListExpiredLocations(ctx, now, locationScheduleBatch)
+	if err != nil {
+		slog.Error("Error listing expired locations", logging.Err(err))
+	}
+	for _, id := range ids {
+		err := pg.TransitionLocationStatus(ctx, &models.LocationStatusTransition{
+			LocationID: id,
+			To:         models.LocationRetired,
+			Comment:    "expired",
+			Actor:      "scheduler",
+		})
+		if err != nil {
+			// Локацию могли снять с публикации или удалить после выборки
+			if !errors.Is(err, storage.ErrLocationNotFound) && !errors.Is(err, storage.ErrLocationStatusTransition) {
+				slog.Error("Error retiring expired location", slog.String("location_id", id), logging.Err(err))
+			}
+			continue
+		}
+		retired++
+	}
+
+	return published, retired
+}
+
+// idempotencyCleanup возвращает фоновый процесс, удаляющий ключи идемпотентности с истекшим сроком.
+func idempotencyCleanup(pg *storage.PostgresStorage, interval time.Duration) Runner {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				deleted, err := pg.DeleteExpiredIdempotencyKeys(ctx)
+				if err != nil {
+					slog.Error("Error cleaning up idempotency keys", logging.Err(err))
+					continue
+				}
+				if deleted > 0 {
+					slog.Info("Deleted expired idempotency keys", slog.Int64("count", deleted))
+				}
+			}
+		}
+	}
+}
+
+// instanceID возвращает идентификатор экземпляра из конфигурации или имя хоста и PID процесса.
+func instanceID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// setupIndex создает индекс локаций, если он не существует, и добавляет в существующий индекс
+// недостающие поля маппинга.
+// Файл маппинга, если он найден, имеет приоритет над маппингом, построенным из конфигурации.
+func setupIndex(esStorage *storage.ElasticsearchStorage, vectorOptions storage.VectorIndexOptions, mappingPaths []string) error {
+	var mappingData []byte
+	for _, path := range mappingPaths {
+		var readErr error
+		mappingData, readErr = os.ReadFile(path)
+		if readErr == nil {
+			slog.Info("Using Elasticsearch mapping", slog.String("path", path))
+			break
+		}
+	}
+
+	if len(mappingData) == 0 {
+		mapping, err := storage.BuildLocationsMapping(vectorOptions)
+		if err != nil {
+			return fmt.Errorf("invalid vector index configuration: %w", err)
+		}
+		mappingData = []byte(mapping)
+	}
+
+	if err := esStorage.CreateIndex(context.Background(), string(mappingData)); err != nil {
+		slog.Warn("Could not create index", logging.Err(err))
+		return nil
+	}
+	slog.Info("Elasticsearch index created/verified")
+
+	// Индексы, созданные прежними версиями, получают новые поля маппинга (например, nested
+	// demographics.segments): иначе кластер создал бы их динамически с неверными типами
+	added, err := esStorage.UpdateMapping(context.Background(), string(mappingData))
+	if err != nil {
+		slog.Warn("Could not update index mapping, reindex may be required", logging.Err(err))
+	} else if len(added) > 0 {
+		slog.Info("Elasticsearch mapping updated", slog.String("added_fields", strings.Join(added, ", ")))
+	}
+
+	return nil
+}
+
+// rateLimiter создает ограничитель частоты запросов по клиентам RATE_LIMIT_KEY.
+// С RATE_LIMIT_REDIS_URL лимиты общие для всех экземпляров и хранятся в Redis.
+func (a *App) rateLimiter() (*middleware.RateLimiter, error) {
+	limiter := middleware.NewRateLimiter(a.Config.RateLimitRPS, a.Config.RateLimitBurst)
+	if err := limiter.SetKeyBy(a.Config.RateLimitKey); err != nil {
+		return nil, err
+	}
+	if a.Config.RateLimitRedisURL == "" || a.Config.RateLimitRPS <= 0 {
+		return limiter, nil
+	}
+
+	client, err := redis.New(a.Config.RateLimitRedisURL, 16)
+	if err != nil {
+		return nil, err
+	}
+	a.closers = append(a.closers, client.Close)
+	limiter.SetShared(redis.NewTokenBuckets(client, "go_es_analytical_system:ratelimit:"),
+		time.Duration(a.Config.RateLimitTimeoutMs)*time.Millisecond)
+	slog.Info("Using cluster-wide rate limits in Redis")
+	return limiter, nil
+}
+
+// buildMiddlewareChain собирает цепочку middleware в порядке, заданном в конфигурации.
+func (a *App) buildMiddlewareChain() (*middleware.Chain, error) {
+	slowThresholds, err := middleware.ParseSlowLogThresholds(a.Config.SlowLogThresholds)
+	if err != nil {
+		return nil, err
+	}
+	slowLog := middleware.SlowLogConfig{
+		Thresholds:   slowThresholds,
+		Default:      time.Duration(a.Config.SlowLogDefaultMs) * time.Millisecond,
+		MaxBodyBytes: a.Config.SlowLogMaxBodyBytes,
+	}
+
+	rateLimiter, err := a.rateLimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	available := map[string]middleware.Middleware{
+		"recovery":    middleware.Recovery(),
+		"tracing":     middleware.Tracing(a.tracer, a.routeName),
+		"logging":     middleware.Logging(a.routeName),
+		"metrics":     middleware.Metrics(a.Recorder, a.routeName),
+		"slowlog":     middleware.SlowLog(slowLog, a.Recorder, a.routeName),
+		"cors":        middleware.CORS(a.Config.CORSAllowedOrigins),
+		"auth":        middleware.Auth(a.authConfig()),
+		"quota":       middleware.Quota(a.quotaCounter()),
+		"cost":        middleware.QueryCost(a.Costs),
+		"ratelimit":   middleware.RateLimit(rateLimiter),
+		"compression": middleware.Compression(),
+		"idempotency": middleware.Idempotency(a.PGStorage, time.Duration(a.Config.IdempotencyTTLHours)*time.Hour),
+	}
+
+	order := middleware.ParseOrder(a.Config.MiddlewareChain)
+	if a.chaos != nil {
+		// По умолчанию сбои внедряются последними в цепочке, чтобы их видели логи и метрики
+		available["chaos"] = a.chaos.Handler
+		listed := false
+		for _, name := range order {
+			listed = listed || name == "chaos"
+		}
+		if !listed {
+			order = append(order, "chaos")
+		}
+	}
+
+	skips, err := middleware.ParseSkipRules(a.Config.MiddlewareSkip)
+	if err != nil {
+		return nil, err
+	}
+
+	return middleware.Build(order, available, skips)
+}
+
+// authConfig возвращает настройки аутентификации: API ключи конфигурации, выпущенные API ключи
+// (с API_KEY_STORE_ENABLED) и, если задан OIDC провайдер, bearer токены.
+func (a *App) authConfig() middleware.AuthConfig {
+	cfg := middleware.AuthConfig{
+		APIKeys:     a.Config.APIKeys,
+		APIKeyRoles: a.Config.APIKeyRoles,
+		AdminRole:   a.Config.AdminRole,
+		// Администрирование доступно и по прежнему пути без префикса версии
+		AdminPrefixes: []string{models.APIV1Prefix + "/admin/", "/admin/"},
+	}
+	if a.Config.APIKeyStore {
+		cfg.KeyStore = a.APIKeys
+	}
+	if a.oidc != nil {
+		cfg.Tokens = a.oidc
+	}
+	return cfg
+}
+
+// quotaCounter возвращает счетчик дневных квот выпущенных API ключей (nil — ключи не принимаются).
+func (a *App) quotaCounter() middleware.QuotaCounter {
+	if !a.Config.APIKeyStore {
+		return nil
+	}
+	return a.APIKeys
+}
+
+// routeName возвращает шаблон пути маршрута, которому соответствует запрос.
+func (a *App) routeName(r *http.Request) string {
+	var match mux.RouteMatch
+	if a.Router.Match(r, &match) && match.Route != nil {
+		if template, err := match.Route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// Start запускает все зарегистрированные фоновые процессы.
+// Процессы останавливаются при отмене ctx или вызове Close.
+func (a *App) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	prevCancel := a.cancel
+	a.cancel = func() {
+		cancel()
+		prevCancel()
+	}
+
+	for name, runner := range a.runners {
+		a.wg.Add(1)
+		go func(name string, runner Runner) {
+			defer a.wg.Done()
+			slog.Info("Background runner started", slog.String("runner", name))
+			if err := runner(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("Background runner failed", slog.String("runner", name), logging.Err(err))
+			}
+		}(name, runner)
+	}
+}
+
+// Close останавливает фоновые процессы и освобождает ресурсы в обратном порядке их создания.
+func (a *App) Close() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+
+	var firstErr error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i](); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
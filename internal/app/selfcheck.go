@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/mlregistry"
+	"github.com/akozadaev/go_es_analytical_system/internal/selfcheck"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// selfCheckTimeout ограничивает время каждой проверки самопроверки.
+const selfCheckTimeout = 5 * time.Second
+
+// healthCheckTimeout ограничивает время каждой проверки GET /health: мониторинг опрашивает
+// его часто и должен получить ответ раньше собственного таймаута.
+const healthCheckTimeout = 2 * time.Second
+
+// healthCacheTTL — время, в течение которого GET /health и GET /readyz отвечают по прежнему
+// результату проверок: частый опрос мониторингом, балансировщиками и Kubernetes не нагружает хранилища.
+const healthCacheTTL = time.Second
+
+// newHealthCheck регистрирует проверки зависимостей для GET /health и GET /readyz: кластер
+// Elasticsearch с индексом локаций и PostgreSQL со всеми примененными миграциями. В отличие
+// от самопроверки они не сверяют маппинг и версии, а только подтверждают, что хранилища
+// обслуживают запросы.
+func (a *App) newHealthCheck() *selfcheck.Checker {
+	checker := selfcheck.New(healthCheckTimeout)
+	checker.SetCacheTTL(healthCacheTTL)
+	checker.Add("elasticsearch", func(ctx context.Context) (string, error) {
+		status, err := a.ESStorage.Health(ctx)
+		if err != nil {
+			return "", err
+		}
+		if status == storage.ClusterStatusYellow {
+			return "", selfcheck.Warn("cluster status is %s", status)
+		}
+		return "cluster status " + status, nil
+	})
+	checker.Add("postgres", a.checkPostgres)
+	return checker
+}
+
+// newSelfCheck регистрирует проверки самопроверки приложения.
+func (a *App) newSelfCheck(vectorOptions storage.VectorIndexOptions) *selfcheck.Checker {
+	checker := selfcheck.New(selfCheckTimeout)
+	checker.Add("config", func(ctx context.Context) (string, error) {
+		return a.checkConfig(vectorOptions)
+	})
+	checker.Add("elasticsearch", func(ctx context.Context) (string, error) {
+		return a.checkElasticsearch(ctx, vectorOptions)
+	})
+	checker.Add("postgres", a.checkPostgres)
+	checker.Add("cache", func(ctx context.Context) (string, error) {
+		if a.Config.CacheTTLSeconds <= 0 {
+			return "", selfcheck.Warn("result cache disabled (CACHE_TTL_SECONDS=0)")
+		}
+		return fmt.Sprintf("in-process cache, ttl %ds", a.Config.CacheTTLSeconds), nil
+	})
+	checker.Add("models", a.checkModels)
+	if a.oidc != nil {
+		checker.Add("oidc", func(ctx context.Context) (string, error) {
+			if err := a.oidc.Discover(ctx); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("provider %s reachable", a.Config.OIDCIssuer), nil
+		})
+	}
+	return checker
+}
+
+// checkConfig проверяет согласованность конфигурации и предупреждает о небезопасных настройках.
+func (a *App) checkConfig(vectorOptions storage.VectorIndexOptions) (string, error) {
+	cfg := a.Config
+	if err := vectorOptions.Validate(); err != nil {
+		return "", err
+	}
+	if cfg.RecommendMaxLimit <= 0 {
+		return "", fmt.Errorf("RECOMMEND_MAX_LIMIT must be positive, got %d", cfg.RecommendMaxLimit)
+	}
+
+	var warnings []string
+	if len(cfg.APIKeys) == 0 && !cfg.APIKeyStore && cfg.OIDCIssuer == "" {
+		warnings = append(warnings, "authentication disabled (API_KEYS, API_KEY_STORE_ENABLED and OIDC_ISSUER are not set), admin API rejects all requests")
+	}
+	if cfg.ChaosEnabled {
+		warnings = append(warnings, "fault injection enabled (CHAOS_ENABLED)")
+	}
+	if len(warnings) > 0 {
+		return "", selfcheck.Warn("%s", strings.Join(warnings, "; "))
+	}
+	return "configuration is valid", nil
+}
+
+// checkElasticsearch проверяет доступность кластера и соответствие маппинга индекса конфигурации.
+func (a *App) checkElasticsearch(ctx context.Context, vectorOptions storage.VectorIndexOptions) (string, error) {
+	mapping, err := a.ESStorage.GetMapping(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	missing, err := storage.MissingMappingFields(mapping, vectorOptions)
+	if err != nil {
+		return "", err
+	}
+	if len(missing) > 0 {
+		return "", selfcheck.Warn("mapping is outdated, missing fields: %s", strings.Join(missing, ", "))
+	}
+	mismatches, err := storage.MappingTypeMismatches(mapping, vectorOptions)
+	if err != nil {
+		return "", err
+	}
+	if len(mismatches) > 0 {
+		return "", fmt.Errorf("mapping field types differ, reindex required: %s", strings.Join(mismatches, "; "))
+	}
+
+	current, err := a.ESStorage.GetVectorIndexOptions(ctx)
+	if err != nil {
+		return "", err
+	}
+	if *current != vectorOptions {
+		return "", selfcheck.Warn("vector index options differ from configuration (see GET /admin/mapping)")
+	}
+	return "reachable, mapping up to date", nil
+}
+
+// checkPostgres проверяет доступность PostgreSQL и применение всех миграций схемы.
+func (a *App) checkPostgres(ctx context.Context) (string, error) {
+	version, err := a.PGStorage.SchemaVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	if expected := storage.ExpectedSchemaVersion(); version < expected {
+		return "", fmt.Errorf("schema version %d, expected %d: apply migrations after %03d", version, expected, version)
+	}
+	return fmt.Sprintf("reachable, schema version %d", version), nil
+}
+
+// checkModels проверяет, что модели загружены и совпадают с активными версиями реестра.
+func (a *App) checkModels(ctx context.Context) (string, error) {
+	footfallModel := a.Models.Footfall().Current()
+	if a.Config.FootfallModel != "" && footfallModel == nil {
+		return "", fmt.Errorf("footfall model %s is not loaded", a.Config.FootfallModel)
+	}
+	embeddingVersion := a.Models.Embedder().Version()
+
+	versions, err := a.Models.List(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	var stale []string
+	for _, mv := range versions {
+		if !mv.Active {
+			continue
+		}
+		switch mv.Kind {
+		case mlregistry.KindFootfall:
+			if footfallModel == nil || footfallModel.Version() != mv.Version {
+				stale = append(stale, "footfall "+mv.Version)
+			}
+		case mlregistry.KindEmbedding:
+			if strconv.Itoa(embeddingVersion) != mv.Version {
+				stale = append(stale, "embedding "+mv.Version)
+			}
+		}
+	}
+	if len(stale) > 0 {
+		return "", selfcheck.Warn("active model versions not loaded: %s", strings.Join(stale, ", "))
+	}
+
+	message := fmt.Sprintf("embedding version %d", embeddingVersion)
+	if footfallModel != nil {
+		message += ", footfall model " + footfallModel.Version()
+	} else {
+		message += ", footfall prediction disabled"
+	}
+	return message, nil
+}
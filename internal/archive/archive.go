@@ -0,0 +1,144 @@
+// Package archive содержит перенос холодных локаций в архивный индекс.
+// Холодной считается локация, которая не обновлялась и не попадала в выдачу рекомендаций
+// дольше заданного срока. Архивный индекс исключен из поиска по умолчанию.
+package archive
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// LockName — имя блокировки архивации: архивация выполняется одним экземпляром кластера.
+const LockName = "archive"
+
+// Report содержит результат архивации.
+type Report struct {
+	Cutoff    time.Time `json:"cutoff"`
+	Scanned   int       `json:"scanned"`  // Локации без обновлений с момента cutoff
+	Skipped   int       `json:"skipped"`  // Пропущены, так как попадали в выдачу после cutoff
+	Archived  []string  `json:"archived"` // Перенесены в архив (в режиме dry-run — кандидаты)
+	DryRun    bool      `json:"dry_run"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// Archiver переносит холодные локации из основного индекса в архивный.
+type Archiver struct {
+	esStorage *storage.ElasticsearchStorage
+	pgStorage *storage.PostgresStorage
+	after     time.Duration
+	batchSize int
+	locks     *lock.Manager // Блокировка архивации (nil — без блокировки)
+}
+
+// NewArchiver создает новый экземпляр Archiver.
+// Локации, не обновлявшиеся и не рекомендованные дольше after, считаются холодными.
+func NewArchiver(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, after time.Duration) *Archiver {
+	return &Archiver{
+		esStorage: esStorage,
+		pgStorage: pgStorage,
+		after:     after,
+		batchSize: 500,
+	}
+}
+
+// SetLocks задает менеджер блокировок, исключающий одновременную архивацию на нескольких экземплярах.
+func (a *Archiver) SetLocks(locks *lock.Manager) {
+	a.locks = locks
+}
+
+// Run выполняет архивацию. При dryRun = true только возвращает список кандидатов.
+// Возвращает lock.ErrHeld, если архивация уже выполняется другим экземпляром.
+func (a *Archiver) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	var report *Report
+	err := a.locks.Do(ctx, LockName, func(ctx context.Context) error {
+		var err error
+		report, err = a.run(ctx, dryRun)
+		return err
+	})
+	return report, err
+}
+
+func (a *Archiver) run(ctx context.Context, dryRun bool) (*Report, error) {
+	report := &Report{
+		StartedAt: time.Now(),
+		Cutoff:    time.Now().Add(-a.after),
+		DryRun:    dryRun,
+	}
+
+	recommended, err := a.pgStorage.RecommendedLocationIDsSince(ctx, report.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &models.LocationFilter{UpdatedBefore: &report.Cutoff}
+	err = a.esStorage.ScanLocations(ctx, filter, a.batchSize, func(locations []*models.Location) error {
+		report.Scanned += len(locations)
+
+		cold := make([]*models.Location, 0, len(locations))
+		for _, location := range locations {
+			if recommended[location.ID] {
+				report.Skipped++
+				continue
+			}
+			cold = append(cold, location)
+		}
+
+		if dryRun {
+			for _, location := range cold {
+				report.Archived = append(report.Archived, location.ID)
+			}
+			return nil
+		}
+
+		moved, err := a.esStorage.MoveToArchive(ctx, cold)
+		if err != nil {
+			return err
+		}
+		if len(moved) < len(cold) {
+			slog.WarnContext(ctx, "Archive: some locations were not moved", "not_moved", len(cold)-len(moved),
+				"selected", len(cold))
+		}
+		report.Archived = append(report.Archived, moved...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Duration = time.Since(report.StartedAt).String()
+	return report, nil
+}
+
+// Runner возвращает фоновый процесс, выполняющий архивацию каждые interval.
+func (a *Archiver) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				report, err := a.Run(ctx, false)
+				if errors.Is(err, lock.ErrHeld) {
+					continue
+				}
+				if err != nil {
+					slog.ErrorContext(ctx, "Error archiving locations", logging.Err(err))
+					continue
+				}
+				slog.InfoContext(ctx, "Archive completed", "scanned", report.Scanned, "skipped", report.Skipped,
+					"archived", len(report.Archived))
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package artifact содержит хранилище артефактов — файлов, сформированных фоновыми задачами
+// (отчеты, выгрузки), которые клиент скачивает после завершения задачи.
+package artifact
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotFound возвращается, если артефакт отсутствует.
+var ErrNotFound = errors.New("artifact not found")
+
+// Artifact описывает сохраненный файл.
+type Artifact struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"` // Имя файла для скачивания
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Organization string    `json:"organization"` // Организация, которой доступен артефакт
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Store — хранилище артефактов.
+type Store interface {
+	// Put сохраняет содержимое артефакта и заполняет его ID, размер и время создания.
+	Put(ctx context.Context, artifact *Artifact, data io.Reader) error
+	// Open возвращает описание артефакта и его содержимое. Вызывающий код закрывает reader.
+	Open(ctx context.Context, id string) (*Artifact, io.ReadCloser, error)
+}
+
+// FileStore хранит артефакты в каталоге локальной файловой системы:
+// содержимое в файле <id>, описание — в <id>.json.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore создает хранилище в каталоге dir, создавая его при необходимости.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put сохраняет артефакт. Описание записывается после содержимого,
+// поэтому незавершенная запись не видна через Open.
+func (s *FileStore) Put(ctx context.Context, artifact *Artifact, data io.Reader) error {
+	artifact.ID = newID()
+	artifact.CreatedAt = time.Now()
+
+	file, err := os.Create(s.dataPath(artifact.ID))
+	if err != nil {
+		return fmt.Errorf("failed to create artifact file: %w", err)
+	}
+	size, err := io.Copy(file, data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(s.dataPath(artifact.ID))
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	artifact.Size = size
+
+	meta, err := json.Marshal(artifact)
+	if err != nil {
+		return fmt.Errorf("failed to marshal artifact: %w", err)
+	}
+	if err := os.WriteFile(s.metaPath(artifact.ID), meta, 0o644); err != nil {
+		return fmt.Errorf("failed to write artifact metadata: %w", err)
+	}
+
+	return nil
+}
+
+// Open возвращает артефакт по ID или ErrNotFound.
+func (s *FileStore) Open(ctx context.Context, id string) (*Artifact, io.ReadCloser, error) {
+	// ID генерируется хранилищем; все остальное не может указывать на файл артефакта
+	if !validID(id) {
+		return nil, nil, ErrNotFound
+	}
+
+	meta, err := os.ReadFile(s.metaPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+
+	var artifact Artifact
+	if err := json.Unmarshal(meta, &artifact); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal artifact metadata: %w", err)
+	}
+
+	file, err := os.Open(s.dataPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+
+	return &artifact, file, nil
+}
+
+func (s *FileStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *FileStore) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// newID генерирует случайный идентификатор артефакта.
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+// Package auth содержит описание аутентифицированного клиента, которое middleware аутентификации
+// передает обработчикам и сервисному слою через контекст запроса.
+package auth
+
+import "context"
+
+// Principal описывает аутентифицированного клиента API.
+type Principal struct {
+	Subject      string   `json:"subject"`         // Идентификатор пользователя
+	Organization string   `json:"organization"`    // Организация пользователя; данные совместной работы видны только внутри нее
+	Roles        []string `json:"roles,omitempty"` // Роли клиента в приложении
+	// APIKeyID — ID ключа из таблицы api_keys, которым аутентифицирован клиент (пусто для остальных)
+	APIKeyID string `json:"api_key_id,omitempty"`
+	// DailyQuota — дневная квота запросов ключа APIKeyID (0 — без ограничения)
+	DailyQuota int `json:"daily_quota,omitempty"`
+}
+
+// HasRole сообщает, есть ли у клиента роль.
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithPrincipal возвращает контекст с информацией о клиенте.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext возвращает клиента, сохраненного middleware аутентификации.
+// Возвращает false, если запрос не аутентифицирован.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
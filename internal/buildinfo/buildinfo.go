@@ -0,0 +1,107 @@
+// Package buildinfo содержит сведения о сборке: версию, коммит и дату сборки.
+//
+// Значения задаются при сборке через ldflags:
+//
+//	go build -ldflags "-X github.com/akozadaev/go_es_analytical_system/internal/buildinfo.Version=1.4.0 \
+//	  -X github.com/akozadaev/go_es_analytical_system/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/akozadaev/go_es_analytical_system/internal/buildinfo.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Не заданные значения берутся из debug.ReadBuildInfo (версия модуля и VCS метаданные Go toolchain).
+package buildinfo
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Значения, задаваемые через ldflags.
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info описывает сборку приложения.
+type Info struct {
+	Version   string `json:"version"`    // Семантическая версия
+	Commit    string `json:"commit"`     // Git коммит
+	BuildDate string `json:"build_date"` // Дата сборки (RFC 3339)
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"` // Сборка из рабочей копии с незакоммиченными изменениями
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get возвращает сведения о сборке.
+func Get() Info {
+	once.Do(func() {
+		info = Info{
+			Version:   Version,
+			Commit:    Commit,
+			BuildDate: BuildDate,
+			GoVersion: runtime.Version(),
+		}
+
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			if info.Version == "" && bi.Main.Version != "(devel)" {
+				info.Version = bi.Main.Version
+			}
+			for _, setting := range bi.Settings {
+				switch setting.Key {
+				case "vcs.revision":
+					if info.Commit == "" {
+						info.Commit = setting.Value
+					}
+				case "vcs.time":
+					if info.BuildDate == "" {
+						info.BuildDate = setting.Value
+					}
+				case "vcs.modified":
+					info.Modified = setting.Value == "true"
+				}
+			}
+		}
+
+		if info.Version == "" {
+			info.Version = "dev"
+		}
+	})
+	return info
+}
+
+// UserAgent возвращает идентификатор приложения для заголовка User-Agent исходящих запросов.
+func UserAgent() string {
+	i := Get()
+	agent := "go_es_analytical_system/" + i.Version
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		agent += " (" + commit + ")"
+	}
+	return agent
+}
+
+// Transport добавляет к запросам заголовок User-Agent с версией приложения, чтобы запросы
+// в логах Elasticsearch/OpenSearch можно было сопоставить с релизом.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	agent := UserAgent()
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.Header.Set("User-Agent", agent)
+		return next.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,262 @@
+// Package bulkload реализует массовую загрузку локаций с учетом нагрузки на кластер Elasticsearch.
+//
+// Размер пачки и число параллельных запросов подстраиваются по схеме AIMD:
+// пока кластер отвечает быстрее целевой задержки, они растут на фиксированный шаг,
+// а при ответах 429 или превышении задержки уменьшаются вдвое. Дополнительно
+// поддерживается жесткий потолок пропускной способности в документах в секунду,
+// чтобы ночные загрузки не ухудшали задержку рабочих запросов.
+package bulkload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+	// maxOverloadedRounds — число подряд идущих перегруженных раундов, после которого загрузка прерывается.
+	maxOverloadedRounds = 10
+	// restoreTimeout ограничивает восстановление настроек индекса после быстрой загрузки.
+	restoreTimeout = time.Minute
+)
+
+// Options задает параметры адаптивной загрузки.
+type Options struct {
+	InitialBatch     int           // Начальный размер пачки
+	MinBatch         int           // Минимальный размер пачки, он же шаг увеличения
+	MaxBatch         int           // Максимальный размер пачки
+	MaxConcurrency   int           // Максимум параллельных Bulk запросов
+	TargetLatency    time.Duration // Задержка ответа, выше которой кластер считается перегруженным
+	MaxDocsPerSecond float64       // Потолок пропускной способности (0 — без ограничения)
+	// FastLoad отключает обновление и реплики индекса на время загрузки и восстанавливает их
+	// после нее с принудительным обновлением. Для первичной загрузки: документы не видны поиску
+	// до завершения, а при сбое узла до восстановления реплик данные могут быть потеряны.
+	FastLoad bool
+}
+
+// Stats — итог загрузки.
+type Stats struct {
+	Indexed          int           `json:"indexed"`
+	Failed           int           `json:"failed"`
+	Rejected         int           `json:"rejected"` // Отказов 429, документы повторены
+	Rounds           int           `json:"rounds"`
+	FinalBatch       int           `json:"final_batch"`
+	FinalConcurrency int           `json:"final_concurrency"`
+	Duration         time.Duration `json:"duration"`
+}
+
+// Loader загружает локации пачками, подстраиваясь под нагрузку кластера.
+type Loader struct {
+	es          *storage.ElasticsearchStorage
+	opts        Options
+	batch       int
+	concurrency int
+	next        time.Time // Время, раньше которого нельзя отправить следующую пачку
+}
+
+// NewLoader создает загрузчик. Некорректные параметры заменяются безопасными значениями.
+func NewLoader(es *storage.ElasticsearchStorage, opts Options) *Loader {
+	if opts.MinBatch <= 0 {
+		opts.MinBatch = 1
+	}
+	if opts.MaxBatch < opts.MinBatch {
+		opts.MaxBatch = opts.MinBatch
+	}
+	if opts.InitialBatch < opts.MinBatch || opts.InitialBatch > opts.MaxBatch {
+		opts.InitialBatch = opts.MinBatch
+	}
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = 1
+	}
+
+	return &Loader{
+		es:          es,
+		opts:        opts,
+		batch:       opts.InitialBatch,
+		concurrency: 1,
+	}
+}
+
+// roundResult — итог одного Bulk запроса в раунде.
+type roundResult struct {
+	result  *storage.BulkIndexResult
+	latency time.Duration
+	err     error
+}
+
+// Load индексирует локации. Документы, отклоненные кластером из-за перегрузки, повторяются
+// после паузы; загрузка прерывается, если кластер остается перегруженным слишком долго.
+// С FastLoad настройки индекса восстанавливаются и при ошибке или отмене загрузки.
+func (l *Loader) Load(ctx context.Context, locations []*models.Location) (stats *Stats, err error) {
+	if !l.opts.FastLoad {
+		return l.load(ctx, locations)
+	}
+
+	saved, err := l.es.PrepareBulkLoad(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare index for fast load: %w", err)
+	}
+	slog.InfoContext(ctx, "Fast load: index refresh and replicas disabled until load completes")
+
+	defer func() {
+		restoreCtx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+		defer cancel()
+		restoreErr := l.es.FinishBulkLoad(restoreCtx, saved)
+		switch {
+		case restoreErr == nil:
+			slog.InfoContext(ctx, "Fast load: index settings restored and index refreshed")
+		case err == nil:
+			err = fmt.Errorf("failed to restore index settings after fast load: %w", restoreErr)
+		default:
+			slog.ErrorContext(ctx, "Error restoring index settings after fast load", logging.Err(restoreErr))
+		}
+	}()
+
+	return l.load(ctx, locations)
+}
+
+func (l *Loader) load(ctx context.Context, locations []*models.Location) (*Stats, error) {
+	started := time.Now()
+	stats := &Stats{}
+	pending := locations
+	backoff := minBackoff
+	overloaded := 0
+
+	for len(pending) > 0 {
+		// Раунд: до concurrency параллельных пачек текущего размера
+		var batches [][]*models.Location
+		for i := 0; i < l.concurrency && len(pending) > 0; i++ {
+			n := l.batch
+			if n > len(pending) {
+				n = len(pending)
+			}
+			batches = append(batches, pending[:n])
+			pending = pending[n:]
+		}
+
+		results := make([]roundResult, len(batches))
+		var wg sync.WaitGroup
+		for i, batch := range batches {
+			if err := l.pace(ctx, len(batch)); err != nil {
+				wg.Wait()
+				return stats, err
+			}
+			wg.Add(1)
+			go func(i int, batch []*models.Location) {
+				defer wg.Done()
+				start := time.Now()
+				result, err := l.es.IndexBatch(ctx, batch)
+				results[i] = roundResult{result: result, latency: time.Since(start), err: err}
+			}(i, batch)
+		}
+		wg.Wait()
+		stats.Rounds++
+
+		slow := false
+		var retry []*models.Location
+		for _, r := range results {
+			if r.err != nil {
+				return stats, r.err
+			}
+			stats.Indexed += r.result.Indexed
+			stats.Failed += r.result.Failed
+			stats.Rejected += len(r.result.Rejected)
+			retry = append(retry, r.result.Rejected...)
+			if l.opts.TargetLatency > 0 && r.latency > l.opts.TargetLatency {
+				slow = true
+			}
+		}
+
+		if len(retry) == 0 && !slow {
+			l.increase()
+			backoff = minBackoff
+			overloaded = 0
+			continue
+		}
+
+		l.decrease()
+		slog.WarnContext(ctx, "Cluster under load", "rejected", len(retry), "slow", slow, "batch_size", l.batch,
+			"concurrency", l.concurrency)
+
+		if len(retry) == 0 {
+			// Медленный, но успешный раунд: только снижаем нагрузку
+			continue
+		}
+
+		overloaded++
+		if overloaded >= maxOverloadedRounds {
+			return stats, fmt.Errorf("cluster rejected indexing for %d consecutive rounds", overloaded)
+		}
+		pending = append(retry, pending...)
+
+		select {
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	stats.FinalBatch = l.batch
+	stats.FinalConcurrency = l.concurrency
+	stats.Duration = time.Since(started)
+	return stats, nil
+}
+
+// increase аддитивно увеличивает размер пачки и число параллельных запросов.
+func (l *Loader) increase() {
+	l.batch += l.opts.MinBatch
+	if l.batch > l.opts.MaxBatch {
+		l.batch = l.opts.MaxBatch
+	}
+	if l.concurrency < l.opts.MaxConcurrency {
+		l.concurrency++
+	}
+}
+
+// decrease вдвое уменьшает размер пачки и число параллельных запросов.
+func (l *Loader) decrease() {
+	l.batch /= 2
+	if l.batch < l.opts.MinBatch {
+		l.batch = l.opts.MinBatch
+	}
+	l.concurrency /= 2
+	if l.concurrency < 1 {
+		l.concurrency = 1
+	}
+}
+
+// pace выдерживает паузу перед отправкой n документов, чтобы не превысить MaxDocsPerSecond.
+func (l *Loader) pace(ctx context.Context, n int) error {
+	if l.opts.MaxDocsPerSecond <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.opts.MaxDocsPerSecond * float64(time.Second)))
+
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
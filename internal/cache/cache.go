@@ -0,0 +1,85 @@
+// Package cache предоставляет простой потокобезопасный in-memory кеш с ограничением времени жизни записей.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache хранит значения по строковому ключу заданное время (TTL).
+// Просроченные записи удаляются при обращении и периодической очистке в Set.
+type Cache[V any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]entry[V]
+	lastGC  time.Time
+}
+
+// New создает новый кеш с заданным TTL. При ttl <= 0 кеш ничего не хранит.
+func New[V any](ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		ttl:     ttl,
+		entries: make(map[string]entry[V]),
+		lastGC:  time.Now(),
+	}
+}
+
+// Get возвращает значение по ключу, если оно есть и не просрочено.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set сохраняет значение по ключу.
+func (c *Cache[V]) Set(key string, value V) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry[V]{value: value, expiresAt: now.Add(c.ttl)}
+
+	// Периодически удаляем просроченные записи, чтобы кеш не рос бесконечно
+	if now.Sub(c.lastGC) > c.ttl {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastGC = now
+	}
+}
+
+// DeleteFunc удаляет записи, для ключей которых remove возвращает true.
+func (c *Cache[V]) DeleteFunc(remove func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		if remove(k) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Clear удаляет все записи из кеша.
+func (c *Cache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]entry[V])
+}
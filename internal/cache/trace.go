@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Trace накапливает попадания и промахи кешей при обработке одного запроса API
+// (например, для записи в slow-log). Безопасен для параллельного использования.
+type Trace struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Статусы кеша запроса.
+const (
+	StatusNone    = "none"    // Кеши не использовались
+	StatusHit     = "hit"     // Все обращения — попадания
+	StatusMiss    = "miss"    // Все обращения — промахи
+	StatusPartial = "partial" // Есть и попадания, и промахи
+)
+
+// Status возвращает сводный статус кеша запроса.
+func (t *Trace) Status() string {
+	hits, misses := t.hits.Load(), t.misses.Load()
+	switch {
+	case hits > 0 && misses > 0:
+		return StatusPartial
+	case hits > 0:
+		return StatusHit
+	case misses > 0:
+		return StatusMiss
+	}
+	return StatusNone
+}
+
+type traceKey struct{}
+
+// WithTrace возвращает контекст с Trace. Если в ctx уже есть Trace, используется он.
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	if trace, ok := ctx.Value(traceKey{}).(*Trace); ok {
+		return ctx, trace
+	}
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// GetContext возвращает значение по ключу, как Get, и отмечает попадание или промах
+// в Trace из ctx, если он есть.
+func (c *Cache[V]) GetContext(ctx context.Context, key string) (V, bool) {
+	value, ok := c.Get(key)
+	if trace, traced := ctx.Value(traceKey{}).(*Trace); traced {
+		if ok {
+			trace.hits.Add(1)
+		} else {
+			trace.misses.Add(1)
+		}
+	}
+	return value, ok
+}
@@ -0,0 +1,156 @@
+// Package chaos внедряет сбои для проверки устойчивости сервиса на стенде: задержки и ошибки
+// HTTP запросов к API, отказы и частичные сбои запросов к Elasticsearch.
+//
+// Предназначен только для тестовых окружений и включается конфигурацией (CHAOS_ENABLED).
+package chaos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config задает вероятности внедряемых сбоев (0–1).
+type Config struct {
+	LatencyRate   float64       // Доля запросов с дополнительной задержкой
+	Latency       time.Duration // Величина задержки
+	ErrorRate     float64       // Доля запросов к API, завершаемых ответом 503
+	ESErrorRate   float64       // Доля запросов к Elasticsearch, завершаемых ответом 503
+	ESPartialRate float64       // Доля операций bulk запросов к Elasticsearch, отклоняемых с 429
+}
+
+// Injector внедряет сбои с заданными вероятностями.
+type Injector struct {
+	cfg Config
+}
+
+// New создает новый экземпляр Injector.
+func New(cfg Config) *Injector {
+	return &Injector{cfg: cfg}
+}
+
+// hit сообщает, нужно ли внедрить сбой с вероятностью rate.
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// delay выполняет задержку с вероятностью LatencyRate. Возвращает false, если запрос отменен.
+func (i *Injector) delay(r *http.Request) bool {
+	if !hit(i.cfg.LatencyRate) {
+		return true
+	}
+	timer := time.NewTimer(i.cfg.Latency)
+	defer timer.Stop()
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Handler оборачивает handler API: добавляет задержки и отвечает 503 вместо обработки запроса.
+func (i *Injector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !i.delay(r) {
+			return
+		}
+		if hit(i.cfg.ErrorRate) {
+			http.Error(w, "Injected fault", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Transport оборачивает транспорт запросов к Elasticsearch: добавляет задержки, отвечает 503
+// без обращения к кластеру и отклоняет часть операций bulk запросов, как перегруженный кластер.
+func (i *Injector) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if !i.delay(r) {
+			return nil, r.Context().Err()
+		}
+		if hit(i.cfg.ESErrorRate) {
+			return response(r, http.StatusServiceUnavailable,
+				`{"error":{"type":"unavailable_shards_exception","reason":"injected fault"},"status":503}`), nil
+		}
+
+		res, err := next.RoundTrip(r)
+		if err != nil || i.cfg.ESPartialRate <= 0 || res.StatusCode >= 300 || !strings.HasSuffix(r.URL.Path, "/_bulk") {
+			return res, err
+		}
+		return i.rejectBulkItems(res)
+	})
+}
+
+// rejectBulkItems заменяет результаты части операций bulk ответа отказом 429.
+// Сами операции кластером выполнены, поэтому повтор отклоненных операций безопасен.
+func (i *Injector) rejectBulkItems(res *http.Response) (*http.Response, error) {
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return withBody(res, data), nil
+	}
+	items, _ := body["items"].([]interface{})
+	for _, item := range items {
+		operations, _ := item.(map[string]interface{})
+		for _, op := range operations {
+			result, ok := op.(map[string]interface{})
+			if !ok || !hit(i.cfg.ESPartialRate) {
+				continue
+			}
+			result["status"] = http.StatusTooManyRequests
+			result["error"] = map[string]interface{}{
+				"type":   "es_rejected_execution_exception",
+				"reason": "injected fault",
+			}
+			body["errors"] = true
+		}
+	}
+
+	data, err = json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return withBody(res, data), nil
+}
+
+// withBody заменяет тело ответа.
+func withBody(res *http.Response, data []byte) *http.Response {
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	res.ContentLength = int64(len(data))
+	res.Header.Del("Content-Length")
+	return res
+}
+
+// response создает ответ Elasticsearch с JSON телом.
+func response(r *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,293 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// LockName — имя блокировки выгрузки: выгрузка выполняется одним экземпляром кластера.
+const LockName = "clickhouse_export"
+
+// Report содержит результат выгрузки.
+type Report struct {
+	Locations int       `json:"locations"` // Выгружено локаций
+	Queries   int       `json:"queries"`   // Выгружено новых записей истории запросов
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// Exporter выгружает локации из Elasticsearch и историю запросов из PostgreSQL в ClickHouse.
+type Exporter struct {
+	client    *Client
+	esStorage *storage.ElasticsearchStorage
+	pgStorage *storage.PostgresStorage
+	batchSize int
+	locks     *lock.Manager // Блокировка выгрузки (nil — без блокировки)
+}
+
+// NewExporter создает новый экземпляр Exporter. batchSize — число строк в одной вставке.
+func NewExporter(client *Client, esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, batchSize int) *Exporter {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &Exporter{
+		client:    client,
+		esStorage: esStorage,
+		pgStorage: pgStorage,
+		batchSize: batchSize,
+	}
+}
+
+// SetLocks задает менеджер блокировок, исключающий одновременную выгрузку на нескольких экземплярах.
+func (e *Exporter) SetLocks(locks *lock.Manager) {
+	e.locks = locks
+}
+
+// Run выполняет выгрузку. Локации выгружаются полностью: строки локаций, которых больше нет
+// в индексе, удаляются после выгрузки. История запросов выгружается инкрементально — записи
+// новее последней уже выгруженной. Возвращает lock.ErrHeld, если выгрузка уже выполняется
+// другим экземпляром.
+func (e *Exporter) Run(ctx context.Context) (*Report, error) {
+	var report *Report
+	err := e.locks.Do(ctx, LockName, func(ctx context.Context) error {
+		var err error
+		report, err = e.run(ctx)
+		return err
+	})
+	return report, err
+}
+
+func (e *Exporter) run(ctx context.Context) (*Report, error) {
+	report := &Report{StartedAt: time.Now()}
+
+	if err := e.client.EnsureSchema(ctx); err != nil {
+		return nil, err
+	}
+
+	exportedAt := report.StartedAt.UTC()
+	err := e.esStorage.ScanLocations(ctx, &models.LocationFilter{}, e.batchSize, func(locations []*models.Location) error {
+		rows := make([]interface{}, 0, len(locations))
+		for _, location := range locations {
+			rows = append(rows, newLocationRow(location, exportedAt))
+		}
+		if err := e.client.Insert(ctx, locationsTable.Name, rows); err != nil {
+			return err
+		}
+		report.Locations += len(rows)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export locations: %w", err)
+	}
+
+	// Строки предыдущих выгрузок остаются только у удаленных из индекса локаций
+	deleteStale := fmt.Sprintf("ALTER TABLE %s DELETE WHERE exported_at < fromUnixTimestamp64Milli(%d)",
+		locationsTable.Name, exportedAt.UnixMilli())
+	if _, err := e.client.Exec(ctx, deleteStale, nil); err != nil {
+		return nil, fmt.Errorf("failed to delete stale locations: %w", err)
+	}
+
+	report.Queries, err = e.exportHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export recommendation history: %w", err)
+	}
+
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// exportHistory выгружает записи истории запросов новее последней выгруженной.
+func (e *Exporter) exportHistory(ctx context.Context) (int, error) {
+	afterTime, err := e.lastExportedQuery(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// Записи с тем же временем, что и последняя выгруженная, выгружаются повторно
+	// и схлопываются ReplacingMergeTree по id
+	afterTime = afterTime.Add(-time.Millisecond)
+	afterID := ""
+
+	exported := 0
+	for {
+		entries, err := e.pgStorage.ListQueries(ctx, afterTime, afterID, e.batchSize)
+		if err != nil {
+			return exported, err
+		}
+		if len(entries) == 0 {
+			return exported, nil
+		}
+
+		rows := make([]interface{}, 0, len(entries))
+		for i := range entries {
+			row, err := newHistoryRow(&entries[i])
+			if err != nil {
+				return exported, err
+			}
+			rows = append(rows, row)
+		}
+		if err := e.client.Insert(ctx, historyTable.Name, rows); err != nil {
+			return exported, err
+		}
+		exported += len(rows)
+
+		last := entries[len(entries)-1]
+		afterTime, afterID = last.CreatedAt, last.ID
+	}
+}
+
+// lastExportedQuery возвращает время последней выгруженной записи истории (нулевое, если таблица пуста).
+func (e *Exporter) lastExportedQuery(ctx context.Context) (time.Time, error) {
+	query := fmt.Sprintf("SELECT toUnixTimestamp64Milli(max(created_at)) FROM %s FORMAT TabSeparated", historyTable.Name)
+	data, err := e.client.Exec(ctx, query, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var millis int64
+	if _, err := fmt.Sscan(strings.TrimSpace(string(data)), &millis); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse last exported time: %w", err)
+	}
+	if millis <= 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// Runner возвращает фоновый процесс, выполняющий выгрузку с интервалом interval.
+func (e *Exporter) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				report, err := e.Run(ctx)
+				if errors.Is(err, lock.ErrHeld) {
+					continue
+				}
+				if err != nil {
+					slog.ErrorContext(ctx, "Error exporting to ClickHouse", logging.Err(err))
+					continue
+				}
+				slog.InfoContext(ctx, "ClickHouse export completed", "locations", report.Locations,
+					"queries", report.Queries, "duration", report.Duration)
+			}
+		}
+	}
+}
+
+// locationRow — строка таблицы locations.
+type locationRow struct {
+	ID                    string    `json:"id"`
+	Name                  string    `json:"name"`
+	Address               string    `json:"address"`
+	Region                string    `json:"region"`
+	City                  string    `json:"city"`
+	Lat                   float64   `json:"lat"`
+	Lon                   float64   `json:"lon"`
+	BusinessTypesSuitable []string  `json:"business_types_suitable"`
+	TrafficScore          float64   `json:"traffic_score"`
+	CompetitionDensity    float64   `json:"competition_density"`
+	EventExposure         float64   `json:"event_exposure"`
+	SafetyScore           float64   `json:"safety_score"`
+	StreetParkingScore    float64   `json:"street_parking_score"`
+	PaidLotsNearby        int       `json:"paid_lots_nearby"`
+	SchoolsNearby         int       `json:"schools_nearby"`
+	UniversitiesNearby    int       `json:"universities_nearby"`
+	AgeGroup              string    `json:"age_group"`
+	AverageIncome         float64   `json:"average_income"`
+	Interests             []string  `json:"interests"`
+	PopulationDensity     float64   `json:"population_density"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	ExportedAt            time.Time `json:"exported_at"`
+}
+
+func newLocationRow(l *models.Location, exportedAt time.Time) locationRow {
+	row := locationRow{
+		ID:                    l.ID,
+		Name:                  l.Name,
+		Address:               l.Address,
+		Region:                l.Region,
+		City:                  l.City,
+		Lat:                   l.Coordinates.Lat,
+		Lon:                   l.Coordinates.Lon,
+		BusinessTypesSuitable: l.BusinessTypesSuitable,
+		TrafficScore:          l.TrafficScore,
+		CompetitionDensity:    l.CompetitionDensity,
+		EventExposure:         l.EventExposure,
+		SafetyScore:           l.SafetyScore,
+		StreetParkingScore:    l.StreetParkingScore,
+		PaidLotsNearby:        l.PaidLotsNearby,
+		SchoolsNearby:         l.SchoolsNearby,
+		UniversitiesNearby:    l.UniversitiesNearby,
+		AgeGroup:              l.Demographics.AgeGroup,
+		AverageIncome:         l.Demographics.AverageIncome,
+		Interests:             l.Demographics.Interests,
+		PopulationDensity:     l.Demographics.PopulationDensity,
+		CreatedAt:             l.CreatedAt.UTC(),
+		UpdatedAt:             l.UpdatedAt.UTC(),
+		ExportedAt:            exportedAt,
+	}
+	// Массивы ClickHouse не допускают NULL
+	if row.BusinessTypesSuitable == nil {
+		row.BusinessTypesSuitable = []string{}
+	}
+	if row.Interests == nil {
+		row.Interests = []string{}
+	}
+	return row
+}
+
+// historyRow — строка таблицы recommendation_history.
+type historyRow struct {
+	ID           string    `json:"id"`
+	Region       string    `json:"region"`
+	City         string    `json:"city"`
+	BusinessType string    `json:"business_type"`
+	Request      string    `json:"request"` // Исходный запрос в JSON, разбирается функциями JSONExtract*
+	ResultIDs    []string  `json:"result_ids"`
+	Scores       []float64 `json:"scores"`
+	ResultCount  int       `json:"result_count"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newHistoryRow(entry *models.QueryHistoryEntry) (historyRow, error) {
+	request, err := json.Marshal(entry.Request)
+	if err != nil {
+		return historyRow{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	row := historyRow{
+		ID:           entry.ID,
+		Region:       entry.Request.Region,
+		City:         entry.Request.City,
+		BusinessType: entry.Request.BusinessType,
+		Request:      string(request),
+		ResultIDs:    entry.ResultIDs,
+		Scores:       entry.Scores,
+		ResultCount:  entry.ResultCount,
+		DurationMs:   entry.DurationMs,
+		CreatedAt:    entry.CreatedAt.UTC(),
+	}
+	if row.ResultIDs == nil {
+		row.ResultIDs = []string{}
+	}
+	if row.Scores == nil {
+		row.Scores = []float64{}
+	}
+	return row, nil
+}
@@ -0,0 +1,101 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// column описывает столбец таблицы ClickHouse.
+type column struct {
+	Name string
+	Type string
+}
+
+// table описывает таблицу выгрузки. Новые столбцы добавляются в конец списка:
+// EnsureSchema добавит их в существующую таблицу.
+type table struct {
+	Name    string
+	Columns []column
+	Engine  string
+}
+
+// locationsTable — локации из Elasticsearch. При повторной выгрузке строка с тем же id
+// заменяется более новой (по exported_at).
+var locationsTable = table{
+	Name: "locations",
+	Columns: []column{
+		{"id", "String"},
+		{"name", "String"},
+		{"address", "String"},
+		{"region", "LowCardinality(String)"},
+		{"city", "LowCardinality(String)"},
+		{"lat", "Float64"},
+		{"lon", "Float64"},
+		{"business_types_suitable", "Array(LowCardinality(String))"},
+		{"traffic_score", "Float64"},
+		{"competition_density", "Float64"},
+		{"event_exposure", "Float64"},
+		{"safety_score", "Float64"},
+		{"street_parking_score", "Float64"},
+		{"paid_lots_nearby", "UInt32"},
+		{"schools_nearby", "UInt32"},
+		{"universities_nearby", "UInt32"},
+		{"age_group", "LowCardinality(String)"},
+		{"average_income", "Float64"},
+		{"interests", "Array(String)"},
+		{"population_density", "Float64"},
+		{"created_at", "DateTime64(3, 'UTC')"},
+		{"updated_at", "DateTime64(3, 'UTC')"},
+		{"exported_at", "DateTime64(3, 'UTC')"},
+	},
+	Engine: "ReplacingMergeTree(exported_at) ORDER BY id",
+}
+
+// historyTable — история запросов рекомендаций из PostgreSQL. Записи неизменяемы,
+// повторно выгруженная запись с тем же id схлопывается при слиянии частей.
+var historyTable = table{
+	Name: "recommendation_history",
+	Columns: []column{
+		{"id", "String"},
+		{"region", "LowCardinality(String)"},
+		{"city", "LowCardinality(String)"},
+		{"business_type", "LowCardinality(String)"},
+		{"request", "String"},
+		{"result_ids", "Array(String)"},
+		{"scores", "Array(Float64)"},
+		{"result_count", "UInt32"},
+		{"duration_ms", "UInt64"},
+		{"created_at", "DateTime64(3, 'UTC')"},
+	},
+	Engine: "ReplacingMergeTree PARTITION BY toYYYYMM(created_at) ORDER BY (created_at, id)",
+}
+
+// EnsureSchema создает базу данных и таблицы выгрузки, если их нет, и добавляет
+// в существующие таблицы недостающие столбцы.
+func (c *Client) EnsureSchema(ctx context.Context) error {
+	if _, err := c.Exec(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", c.database), nil); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+
+	for _, t := range []table{locationsTable, historyTable} {
+		definitions := make([]string, 0, len(t.Columns))
+		for _, col := range t.Columns {
+			definitions = append(definitions, col.Name+" "+col.Type)
+		}
+		create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) ENGINE = %s",
+			t.Name, strings.Join(definitions, ", "), t.Engine)
+		if _, err := c.Exec(ctx, create, nil); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", t.Name, err)
+		}
+
+		for _, definition := range definitions {
+			alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", t.Name, definition)
+			if _, err := c.Exec(ctx, alter, nil); err != nil {
+				return fmt.Errorf("failed to add column to %s: %w", t.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
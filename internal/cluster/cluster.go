@@ -0,0 +1,129 @@
+// Package cluster распределяет роли между экземплярами сервиса. Все экземпляры обслуживают HTTP,
+// а фоновые процессы (доставка outbox и уведомлений, задания по расписанию) при включенном выборе
+// лидера выполняет только лидер. Экземпляры публикуют свою роль в PostgreSQL, чтобы состояние
+// кластера было видно через любой из них.
+package cluster
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// staleAfter — число пропущенных heartbeat, после которого экземпляр считается остановленным.
+const staleAfter = 3
+
+// Store хранит роли экземпляров.
+type Store interface {
+	UpsertClusterInstance(ctx context.Context, instance *models.ClusterInstance) error
+	ListClusterInstances(ctx context.Context, since time.Time) ([]models.ClusterInstance, error)
+	DeleteClusterInstance(ctx context.Context, id string) error
+}
+
+// Node — экземпляр сервиса в кластере.
+type Node struct {
+	store    Store
+	elector  *lock.Elector // nil — выбор лидера отключен
+	self     models.ClusterInstance
+	interval time.Duration
+}
+
+// New создает экземпляр id версии version. С elector == nil выбор лидера отключен:
+// экземпляр выполняет все фоновые процессы и не публикует роль.
+// interval задает период heartbeat.
+func New(store Store, elector *lock.Elector, id, version string, interval time.Duration) *Node {
+	return &Node{
+		store:   store,
+		elector: elector,
+		self: models.ClusterInstance{
+			ID:        id,
+			Version:   version,
+			StartedAt: time.Now(),
+		},
+		interval: interval,
+	}
+}
+
+// Elector возвращает участника выборов лидера или nil, если выбор отключен.
+func (n *Node) Elector() *lock.Elector {
+	return n.elector
+}
+
+// Role возвращает роль экземпляра. Без выбора лидера каждый экземпляр — лидер.
+func (n *Node) Role() models.ClusterRole {
+	if n.elector == nil || n.elector.IsLeader() {
+		return models.ClusterRoleLeader
+	}
+	return models.ClusterRoleFollower
+}
+
+// Heartbeat публикует роль экземпляра каждые interval до отмены ctx, после чего удаляет его запись.
+func (n *Node) Heartbeat(ctx context.Context) error {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		n.publish(ctx)
+		select {
+		case <-ctx.Done():
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), n.interval)
+			defer cancel()
+			if err := n.store.DeleteClusterInstance(cleanupCtx, n.self.ID); err != nil {
+				slog.ErrorContext(cleanupCtx, "Error removing cluster instance", logging.Err(err))
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *Node) publish(ctx context.Context) {
+	instance := n.self
+	instance.Role = n.Role()
+	instance.HeartbeatAt = time.Now()
+	if err := n.store.UpsertClusterInstance(ctx, &instance); err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "Error publishing cluster instance", logging.Err(err))
+	}
+}
+
+// Status возвращает роли экземпляров с недавним heartbeat и текущего лидера.
+func (n *Node) Status(ctx context.Context) (*models.ClusterStatus, error) {
+	self := n.self
+	self.Role = n.Role()
+	self.HeartbeatAt = time.Now()
+
+	if n.elector == nil {
+		return &models.ClusterStatus{
+			Instance:  self.ID,
+			Instances: []models.ClusterInstance{self},
+		}, nil
+	}
+
+	leader, err := n.elector.Leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := n.store.ListClusterInstances(ctx, time.Now().Add(-staleAfter*n.interval))
+	if err != nil {
+		return nil, err
+	}
+	// Роль лидера определяется по блокировке: heartbeat мог не успеть после смены лидера
+	for i := range instances {
+		if instances[i].ID == leader {
+			instances[i].Role = models.ClusterRoleLeader
+		} else {
+			instances[i].Role = models.ClusterRoleFollower
+		}
+	}
+
+	return &models.ClusterStatus{
+		LeaderElection: true,
+		Instance:       self.ID,
+		Leader:         leader,
+		Instances:      instances,
+	}, nil
+}
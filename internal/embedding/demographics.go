@@ -0,0 +1,74 @@
+package embedding
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+const (
+	// ageGroupBuckets и interestBuckets — число измерений вектора демографического профиля
+	// под возрастные группы и интересы.
+	ageGroupBuckets = 12
+	interestBuckets = 16
+	// DemographicDims — размерность вектора демографического профиля: возрастные группы,
+	// интересы, средний доход и плотность населения.
+	DemographicDims = ageGroupBuckets + interestBuckets + 2
+
+	// incomePivot и densityPivot — средний доход и плотность населения (чел./км²), при которых
+	// соответствующее измерение равно 0.5.
+	incomePivot  = 60000.0
+	densityPivot = 5000.0
+)
+
+// DemographicVector строит вектор демографического профиля района фиксированной размерности
+// DemographicDims: распределение населения по возрастным группам (доли сегментов, группы
+// распределяются по измерениям хешированием), интересы, насыщение среднего дохода и плотности
+// населения. Вектор нормализован; для пустой демографии возвращается nil. Вектор не зависит
+// от модели embeddings, поэтому совпадает для локаций и для целевого профиля запроса.
+func DemographicVector(d models.Demographics) []float64 {
+	vector := make([]float64, DemographicDims)
+	empty := true
+
+	segments := d.Segments
+	if len(segments) == 0 && d.AgeGroup != "" {
+		segments = []models.DemographicSegment{{AgeGroup: d.AgeGroup, Share: 1}}
+	}
+	for _, segment := range segments {
+		if segment.Share <= 0 {
+			continue
+		}
+		vector[bucket(segment.AgeGroup, ageGroupBuckets)] += segment.Share
+		empty = false
+	}
+
+	for _, interest := range d.Interests {
+		if interest = strings.TrimSpace(interest); interest == "" {
+			continue
+		}
+		vector[ageGroupBuckets+bucket(interest, interestBuckets)] += 1 / float64(len(d.Interests))
+		empty = false
+	}
+
+	if d.AverageIncome > 0 {
+		vector[ageGroupBuckets+interestBuckets] = d.AverageIncome / (d.AverageIncome + incomePivot)
+		empty = false
+	}
+	if d.PopulationDensity > 0 {
+		vector[ageGroupBuckets+interestBuckets+1] = d.PopulationDensity / (d.PopulationDensity + densityPivot)
+		empty = false
+	}
+
+	if empty {
+		return nil
+	}
+	return normalize(vector)
+}
+
+// bucket возвращает измерение из n для значения value без учета регистра.
+func bucket(value string, n int) int {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(value)))
+	return int(h.Sum64() % uint64(n))
+}
@@ -0,0 +1,214 @@
+// Package embedding предоставляет провайдеры векторных представлений (embeddings) локаций.
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Provider генерирует векторные представления для набора текстов.
+type Provider interface {
+	// Embed возвращает по одному вектору на каждый входной текст.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+	// Version возвращает версию модели, которая записывается в embedding_version документа.
+	Version() int
+	// Dimensions возвращает размерность векторов.
+	Dimensions() int
+}
+
+// New создает провайдер по имени: "http" — внешний сервис, иначе — локальный HashProvider.
+func New(name, url string, version, dims int) Provider {
+	if name == "http" && url != "" {
+		return NewHTTPProvider(url, version, dims)
+	}
+	return NewHashProvider(version, dims)
+}
+
+// Swappable — провайдер, модель которого можно заменить без перезапуска (см. mlregistry).
+type Swappable struct {
+	current atomic.Pointer[Provider]
+}
+
+// NewSwappable создает переключаемый провайдер с начальной моделью initial.
+func NewSwappable(initial Provider) *Swappable {
+	s := &Swappable{}
+	s.Store(initial)
+	return s
+}
+
+// Store делает provider текущим.
+func (s *Swappable) Store(provider Provider) {
+	s.current.Store(&provider)
+}
+
+// Current возвращает текущий провайдер.
+func (s *Swappable) Current() Provider {
+	return *s.current.Load()
+}
+
+// Embed строит embeddings текущим провайдером.
+func (s *Swappable) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return s.Current().Embed(ctx, texts)
+}
+
+// Version возвращает версию текущей модели.
+func (s *Swappable) Version() int { return s.Current().Version() }
+
+// Dimensions возвращает размерность векторов текущей модели.
+func (s *Swappable) Dimensions() int { return s.Current().Dimensions() }
+
+// Current возвращает провайдер, который построит следующие embeddings: для Swappable — текущий.
+// Длительные операции фиксируют его в начале, чтобы векторы и их версия не разошлись при переключении.
+func Current(provider Provider) Provider {
+	if s, ok := provider.(*Swappable); ok {
+		return s.Current()
+	}
+	return provider
+}
+
+// LocationText формирует текст локации, по которому строится embedding.
+func LocationText(location *models.Location) string {
+	parts := []string{
+		location.Name,
+		location.Description,
+		location.City,
+		location.Region,
+		strings.Join(location.BusinessTypesSuitable, " "),
+		location.Demographics.AgeGroup,
+		strings.Join(location.Demographics.Interests, " "),
+	}
+	return strings.Join(parts, " ")
+}
+
+// HashProvider строит embeddings локально методом feature hashing по токенам текста.
+// Не требует внешних сервисов и детерминирован, поэтому подходит для разработки и тестовых стендов.
+type HashProvider struct {
+	version int
+	dims    int
+}
+
+// NewHashProvider создает новый экземпляр HashProvider.
+func NewHashProvider(version, dims int) *HashProvider {
+	return &HashProvider{version: version, dims: dims}
+}
+
+// Version возвращает версию модели.
+func (hp *HashProvider) Version() int { return hp.version }
+
+// Dimensions возвращает размерность векторов.
+func (hp *HashProvider) Dimensions() int { return hp.dims }
+
+// Embed возвращает нормализованные векторы feature hashing для каждого текста.
+func (hp *HashProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, hp.dims)
+		for _, token := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New64a()
+			h.Write([]byte(token))
+			sum := h.Sum64()
+			sign := 1.0
+			if sum&1 == 1 {
+				sign = -1.0
+			}
+			vector[(sum>>1)%uint64(hp.dims)] += sign
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+// HTTPProvider получает embeddings от внешнего сервиса.
+// Протокол: POST {"texts": [...]} -> {"embeddings": [[...], ...]}.
+type HTTPProvider struct {
+	url        string
+	version    int
+	dims       int
+	httpClient *http.Client
+}
+
+// NewHTTPProvider создает новый экземпляр HTTPProvider.
+func NewHTTPProvider(url string, version, dims int) *HTTPProvider {
+	return &HTTPProvider{
+		url:        url,
+		version:    version,
+		dims:       dims,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Version возвращает версию модели.
+func (hp *HTTPProvider) Version() int { return hp.version }
+
+// Dimensions возвращает размерность векторов.
+func (hp *HTTPProvider) Dimensions() int { return hp.dims }
+
+// Embed отправляет тексты во внешний сервис и возвращает полученные векторы.
+func (hp *HTTPProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]interface{}{"texts": texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", hp.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := hp.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request embeddings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error requesting embeddings: status %d, body: %s", res.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding count mismatch: got %d, expected %d", len(result.Embeddings), len(texts))
+	}
+	for _, vector := range result.Embeddings {
+		if len(vector) != hp.dims {
+			return nil, fmt.Errorf("embedding dimensions mismatch: got %d, expected %d", len(vector), hp.dims)
+		}
+	}
+
+	return result.Embeddings, nil
+}
+
+// normalize приводит вектор к единичной длине (нулевой вектор возвращается как есть).
+func normalize(vector []float64) []float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
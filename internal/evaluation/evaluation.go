@@ -0,0 +1,163 @@
+// Package evaluation содержит метрики офлайн-сравнения двух конфигураций ранжирования
+// на сохраненных запросах: NDCG по отзывам пользователей, пересечение выдач и сдвиг оценок.
+package evaluation
+
+import (
+	"math"
+	"sort"
+)
+
+// Result — сравнение выдач двух конфигураций на одном запросе.
+type Result struct {
+	BaselineNDCG  float64 // NDCG@k базовой конфигурации
+	CandidateNDCG float64 // NDCG@k проверяемой конфигурации
+	Labeled       bool    // Среди результатов есть релевантные по отзывам локации, NDCG имеет смысл
+	Overlap       float64 // Доля общих локаций в top-k (0–1)
+	ScoreShift    float64 // Средний модуль изменения оценки общих локаций
+	RankShift     float64 // Средний модуль изменения позиции общих локаций
+}
+
+// Summary — сводка сравнения по всем запросам.
+type Summary struct {
+	Queries       int     `json:"queries"`
+	Labeled       int     `json:"labeled"` // Запросы с отзывами, по которым считается NDCG
+	Failed        int     `json:"failed"`  // Запросы, которые не удалось выполнить
+	BaselineNDCG  float64 `json:"baseline_ndcg"`
+	CandidateNDCG float64 `json:"candidate_ndcg"`
+	Overlap       float64 `json:"overlap"`
+	ScoreShift    float64 `json:"score_shift"`
+	RankShift     float64 `json:"rank_shift"`
+	Improved      int     `json:"improved"` // Запросы, где NDCG проверяемой конфигурации выше
+	Degraded      int     `json:"degraded"` // Запросы, где NDCG проверяемой конфигурации ниже
+
+	sumBaseline, sumCandidate, sumOverlap, sumScoreShift, sumRankShift float64
+}
+
+// Ranked — локация в выдаче: идентификатор и нормализованная оценка.
+type Ranked struct {
+	ID    string
+	Score float64
+}
+
+// Compare сравнивает top-k выдач базовой и проверяемой конфигураций.
+// labels — оценки релевантности локаций; локации без оценки считаются нерелевантными.
+// Идеальная выдача для NDCG строится из локаций, найденных хотя бы одной конфигурацией
+// (пул оценок), поэтому конфигурации сравниваются на общем наборе релевантных локаций.
+func Compare(baseline, candidate []Ranked, labels map[string]float64, k int) Result {
+	pool := make(map[string]float64)
+	for _, r := range append(append([]Ranked{}, baseline...), candidate...) {
+		if label, ok := labels[r.ID]; ok {
+			pool[r.ID] = label
+		}
+	}
+	baseline, candidate = top(baseline, k), top(candidate, k)
+
+	result := Result{
+		BaselineNDCG:  NDCG(baseline, pool, k),
+		CandidateNDCG: NDCG(candidate, pool, k),
+	}
+	for _, label := range pool {
+		if label > 0 {
+			result.Labeled = true
+			break
+		}
+	}
+
+	positions := make(map[string]int, len(baseline))
+	for i, r := range baseline {
+		positions[r.ID] = i
+	}
+	common := 0
+	for i, r := range candidate {
+		j, ok := positions[r.ID]
+		if !ok {
+			continue
+		}
+		common++
+		result.ScoreShift += math.Abs(r.Score - baseline[j].Score)
+		result.RankShift += math.Abs(float64(i - j))
+	}
+	if common > 0 {
+		result.ScoreShift /= float64(common)
+		result.RankShift /= float64(common)
+	}
+	if size := max(len(baseline), len(candidate)); size > 0 {
+		result.Overlap = float64(common) / float64(size)
+	} else {
+		result.Overlap = 1
+	}
+
+	return result
+}
+
+// NDCG вычисляет NDCG@k выдачи с экспоненциальным усилением (2^label - 1).
+// Идеальная выдача строится из всех локаций labels, поэтому NDCG штрафует и за
+// релевантные локации, не попавшие в top-k. При отсутствии релевантных локаций возвращает 0.
+func NDCG(ranked []Ranked, labels map[string]float64, k int) float64 {
+	var dcg float64
+	for i, r := range top(ranked, k) {
+		dcg += gain(labels[r.ID]) / math.Log2(float64(i+2))
+	}
+
+	ideal := make([]float64, 0, len(labels))
+	for _, label := range labels {
+		if label > 0 {
+			ideal = append(ideal, label)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(ideal)))
+	var idcg float64
+	for i, label := range ideal {
+		if i == k {
+			break
+		}
+		idcg += gain(label) / math.Log2(float64(i+2))
+	}
+	if idcg == 0 {
+		return 0
+	}
+	return dcg / idcg
+}
+
+// Add учитывает результат запроса в сводке.
+func (s *Summary) Add(r Result) {
+	s.Queries++
+	s.sumOverlap += r.Overlap
+	s.sumScoreShift += r.ScoreShift
+	s.sumRankShift += r.RankShift
+	if r.Labeled {
+		s.Labeled++
+		s.sumBaseline += r.BaselineNDCG
+		s.sumCandidate += r.CandidateNDCG
+		switch {
+		case r.CandidateNDCG > r.BaselineNDCG:
+			s.Improved++
+		case r.CandidateNDCG < r.BaselineNDCG:
+			s.Degraded++
+		}
+	}
+}
+
+// Finish вычисляет средние значения метрик.
+func (s *Summary) Finish() {
+	if s.Queries > 0 {
+		s.Overlap = s.sumOverlap / float64(s.Queries)
+		s.ScoreShift = s.sumScoreShift / float64(s.Queries)
+		s.RankShift = s.sumRankShift / float64(s.Queries)
+	}
+	if s.Labeled > 0 {
+		s.BaselineNDCG = s.sumBaseline / float64(s.Labeled)
+		s.CandidateNDCG = s.sumCandidate / float64(s.Labeled)
+	}
+}
+
+func top(ranked []Ranked, k int) []Ranked {
+	if k > 0 && len(ranked) > k {
+		return ranked[:k]
+	}
+	return ranked
+}
+
+func gain(label float64) float64 {
+	return math.Pow(2, label) - 1
+}
@@ -0,0 +1,297 @@
+// Package footfall предоставляет модели прогноза посещаемости (ожидаемого числа посетителей в день)
+// локации для заданного типа бизнеса.
+//
+// Поддерживаются регрессионные модели в формате PMML (RegressionModel), загружаемые из файла,
+// и внешний сервер моделей с протоколом KServe v1 / TensorFlow Serving, через который
+// обслуживаются модели остальных форматов, в том числе ONNX.
+package footfall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ErrNoModel возвращается переключаемой моделью, пока не загружена ни одна версия.
+var ErrNoModel = errors.New("no footfall model loaded")
+
+// Features — признаки одной локации: числа для числовых признаков, строки для категориальных.
+type Features map[string]interface{}
+
+// Model прогнозирует посещаемость по признакам локаций.
+type Model interface {
+	// Predict возвращает ожидаемое число посетителей в день для каждого набора признаков.
+	Predict(ctx context.Context, features []Features) ([]float64, error)
+	// Version возвращает версию модели, которая возвращается вместе с прогнозом.
+	Version() string
+}
+
+// New создает модель по имени: "pmml" — из файла path, "remote" — на сервере моделей url.
+// Для пустого имени возвращает nil: прогноз посещаемости отключен.
+func New(name, path, url, version string) (Model, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "pmml":
+		return LoadPMML(path)
+	case "remote":
+		if url == "" {
+			return nil, fmt.Errorf("footfall model url is required for remote model")
+		}
+		return NewRemoteModel(url, version), nil
+	default:
+		return nil, fmt.Errorf("unknown footfall model type: %s", name)
+	}
+}
+
+// Swappable — модель, версию которой можно заменить без перезапуска (см. mlregistry).
+type Swappable struct {
+	current atomic.Pointer[Model]
+}
+
+// NewSwappable создает переключаемую модель с начальной версией initial (может быть nil).
+func NewSwappable(initial Model) *Swappable {
+	s := &Swappable{}
+	if initial != nil {
+		s.Store(initial)
+	}
+	return s
+}
+
+// Store делает model текущей версией.
+func (s *Swappable) Store(model Model) {
+	s.current.Store(&model)
+}
+
+// Current возвращает текущую версию или nil. Прогноз и его версию следует брать у одного значения
+// Current, чтобы переключение между вызовами не приписало прогноз другой версии.
+func (s *Swappable) Current() Model {
+	if m := s.current.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// Predict выполняет прогноз текущей версией.
+func (s *Swappable) Predict(ctx context.Context, features []Features) ([]float64, error) {
+	m := s.Current()
+	if m == nil {
+		return nil, ErrNoModel
+	}
+	return m.Predict(ctx, features)
+}
+
+// Version возвращает версию текущей модели (пусто, если модель не загружена).
+func (s *Swappable) Version() string {
+	if m := s.Current(); m != nil {
+		return m.Version()
+	}
+	return ""
+}
+
+// Current возвращает модель, которая выполнит следующий прогноз: для Swappable — текущую версию.
+func Current(model Model) Model {
+	if s, ok := model.(*Swappable); ok {
+		return s.Current()
+	}
+	return model
+}
+
+// LocationFeatures формирует признаки локации для типа бизнеса.
+func LocationFeatures(location *models.Location, businessType string) Features {
+	return Features{
+		"business_type":        businessType,
+		"region":               location.Region,
+		"city":                 location.City,
+		"age_group":            location.Demographics.AgeGroup,
+		"traffic_score":        location.TrafficScore,
+		"competition_density":  location.CompetitionDensity,
+		"average_income":       location.Demographics.AverageIncome,
+		"population_density":   location.Demographics.PopulationDensity,
+		"event_exposure":       location.EventExposure,
+		"schools_nearby":       float64(location.SchoolsNearby),
+		"universities_nearby":  float64(location.UniversitiesNearby),
+		"street_parking_score": location.StreetParkingScore,
+		"paid_lots_nearby":     float64(location.PaidLotsNearby),
+		"safety_score":         location.SafetyScore,
+	}
+}
+
+// PMMLModel — линейная регрессионная модель PMML.
+// Отсутствующие числовые признаки считаются равными нулю, несовпадающие категориальные не дают вклада.
+type PMMLModel struct {
+	version     string
+	intercept   float64
+	numeric     []pmmlNumericPredictor
+	categorical []pmmlCategoricalPredictor
+	exp         bool // Прогноз — экспонента линейной части (normalizationMethod="exp")
+}
+
+type pmmlDocument struct {
+	Header struct {
+		Application struct {
+			Version string `xml:"version,attr"`
+		} `xml:"Application"`
+	} `xml:"Header"`
+	Models []struct {
+		Name                string `xml:"modelName,attr"`
+		FunctionName        string `xml:"functionName,attr"`
+		NormalizationMethod string `xml:"normalizationMethod,attr"`
+		Tables              []struct {
+			Intercept   float64                    `xml:"intercept,attr"`
+			Numeric     []pmmlNumericPredictor     `xml:"NumericPredictor"`
+			Categorical []pmmlCategoricalPredictor `xml:"CategoricalPredictor"`
+		} `xml:"RegressionTable"`
+	} `xml:"RegressionModel"`
+}
+
+type pmmlNumericPredictor struct {
+	Name        string  `xml:"name,attr"`
+	Coefficient float64 `xml:"coefficient,attr"`
+	Exponent    *int    `xml:"exponent,attr"`
+}
+
+type pmmlCategoricalPredictor struct {
+	Name        string  `xml:"name,attr"`
+	Value       string  `xml:"value,attr"`
+	Coefficient float64 `xml:"coefficient,attr"`
+}
+
+// LoadPMML загружает регрессионную модель из PMML файла. Версия модели — modelName,
+// при его отсутствии — имя файла, дополненное версией приложения из заголовка.
+func LoadPMML(path string) (*PMMLModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PMML file: %w", err)
+	}
+
+	var doc pmmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse PMML file: %w", err)
+	}
+	if len(doc.Models) != 1 {
+		return nil, fmt.Errorf("PMML file must contain exactly one RegressionModel, found %d", len(doc.Models))
+	}
+	m := doc.Models[0]
+	if m.FunctionName != "regression" || len(m.Tables) != 1 {
+		return nil, fmt.Errorf("PMML model must be a regression with one RegressionTable")
+	}
+	if m.NormalizationMethod != "" && m.NormalizationMethod != "none" && m.NormalizationMethod != "exp" {
+		return nil, fmt.Errorf("unsupported PMML normalization method: %s", m.NormalizationMethod)
+	}
+
+	version := m.Name
+	if version == "" {
+		version = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if v := doc.Header.Application.Version; v != "" {
+		version += "@" + v
+	}
+
+	return &PMMLModel{
+		version:     version,
+		intercept:   m.Tables[0].Intercept,
+		numeric:     m.Tables[0].Numeric,
+		categorical: m.Tables[0].Categorical,
+		exp:         m.NormalizationMethod == "exp",
+	}, nil
+}
+
+// Version возвращает версию модели.
+func (pm *PMMLModel) Version() string { return pm.version }
+
+// Predict вычисляет прогноз для каждого набора признаков. Отрицательные прогнозы приводятся к нулю.
+func (pm *PMMLModel) Predict(ctx context.Context, features []Features) ([]float64, error) {
+	predictions := make([]float64, len(features))
+	for i, f := range features {
+		y := pm.intercept
+		for _, p := range pm.numeric {
+			x, _ := f[p.Name].(float64)
+			exponent := 1
+			if p.Exponent != nil {
+				exponent = *p.Exponent
+			}
+			y += p.Coefficient * math.Pow(x, float64(exponent))
+		}
+		for _, p := range pm.categorical {
+			if value, _ := f[p.Name].(string); value == p.Value {
+				y += p.Coefficient
+			}
+		}
+		if pm.exp {
+			y = math.Exp(y)
+		}
+		predictions[i] = math.Max(y, 0)
+	}
+	return predictions, nil
+}
+
+// RemoteModel получает прогнозы от внешнего сервера моделей.
+// Протокол: POST {"instances": [{...}, ...]} -> {"predictions": [...]}.
+type RemoteModel struct {
+	url        string
+	version    string
+	httpClient *http.Client
+}
+
+// NewRemoteModel создает новый экземпляр RemoteModel.
+func NewRemoteModel(url, version string) *RemoteModel {
+	return &RemoteModel{
+		url:        url,
+		version:    version,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Version возвращает версию модели.
+func (rm *RemoteModel) Version() string { return rm.version }
+
+// Predict отправляет признаки на сервер моделей и возвращает полученные прогнозы.
+func (rm *RemoteModel) Predict(ctx context.Context, features []Features) ([]float64, error) {
+	body, err := json.Marshal(map[string]interface{}{"instances": features})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", rm.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := rm.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request predictions: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error requesting predictions: status %d, body: %s", res.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Predictions []float64 `json:"predictions"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Predictions) != len(features) {
+		return nil, fmt.Errorf("prediction count mismatch: got %d, expected %d", len(result.Predictions), len(features))
+	}
+
+	return result.Predictions, nil
+}
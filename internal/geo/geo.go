@@ -0,0 +1,21 @@
+// Package geo содержит геометрические расчеты на поверхности Земли.
+package geo
+
+import (
+	"math"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// EarthRadiusMeters — средний радиус Земли.
+const EarthRadiusMeters = 6371000.0
+
+// DistanceMeters возвращает расстояние между точками по дуге большого круга (формула гаверсинусов).
+func DistanceMeters(a, b models.GeoPoint) float64 {
+	toRad := math.Pi / 180
+	dLat := (b.Lat - a.Lat) * toRad
+	dLon := (b.Lon - a.Lon) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Lat*toRad)*math.Cos(b.Lat*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,492 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/archive"
+	"github.com/akozadaev/go_es_analytical_system/internal/cluster"
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+	"github.com/akozadaev/go_es_analytical_system/internal/jobs"
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
+	"github.com/akozadaev/go_es_analytical_system/internal/mlregistry"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/orchestrator"
+	"github.com/akozadaev/go_es_analytical_system/internal/reconcile"
+	"github.com/akozadaev/go_es_analytical_system/internal/selfcheck"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// AdminDeps содержит зависимости административных handlers.
+type AdminDeps struct {
+	ESStorage          storage.LocationIndex             // Индекс локаций Elasticsearch/OpenSearch
+	Jobs               *jobs.Manager                     // Менеджер фоновых задач
+	Embedder           embedding.Provider                // Провайдер embeddings
+	EmbeddingBatchSize int                               // Размер пачки документов для провайдера
+	EmbeddingRateLimit float64                           // Максимум запросов к провайдеру в секунду (0 — без ограничения)
+	VectorOptions      storage.VectorIndexOptions        // Целевые параметры kNN индекса из конфигурации
+	Metrics            *metrics.Registry                 // Реестр метрик HTTP запросов
+	Reconciler         *reconcile.Reconciler             // Сверка PostgreSQL и Elasticsearch
+	Archiver           *archive.Archiver                 // Перенос холодных локаций в архивный индекс
+	Pipelines          *orchestrator.Orchestrator        // Конвейеры обновления данных
+	Models             *mlregistry.Registry              // Реестр версий моделей
+	GoldenQueries      *service.GoldenQueryService       // Эталонные запросы для проверки релевантности
+	Substitutes        *service.SubstituteService        // Замещаемость типов бизнеса
+	ValidationProfiles *service.ValidationProfileService // Профили проверки локаций по типам бизнеса
+	Cities             *service.CityService              // Справочник городов
+	ReferenceData      *service.ReferenceDataService     // Выгрузка и загрузка справочников
+	RankingProfiles    *service.RankingProfileService    // Профили ранжирования типов бизнеса
+	SelfCheck          *selfcheck.Checker                // Самопроверка сервиса
+	Costs              *service.CostService              // Учет стоимости запросов по клиентам
+	APIKeys            *service.APIKeyService            // Выпущенные API ключи внешних клиентов
+	IndexSettings      *service.IndexSettingsService     // Настройки размещения индексов
+	SearchTemplates    *service.SearchTemplateService    // Версии шаблона поиска рекомендаций
+	RuntimeFields      *service.RuntimeFieldService      // Вычисляемые поля индекса локаций
+	Locks              *lock.Manager                     // Блокировки заданий, выполняемых одним экземпляром
+	Cluster            *cluster.Node                     // Роль экземпляра в кластере
+}
+
+// AdminHandlers содержит зависимости для административных HTTP запросов.
+type AdminHandlers struct {
+	esStorage       storage.LocationIndex
+	jobs            *jobs.Manager
+	embedder        embedding.Provider
+	batchSize       int
+	rateLimit       float64
+	vector          storage.VectorIndexOptions
+	metrics         *metrics.Registry
+	reconciler      *reconcile.Reconciler
+	archiver        *archive.Archiver
+	pipelines       *orchestrator.Orchestrator
+	models          *mlregistry.Registry
+	golden          *service.GoldenQueryService
+	substitutes     *service.SubstituteService
+	profiles        *service.ValidationProfileService
+	cities          *service.CityService
+	referenceData   *service.ReferenceDataService
+	rankingProfiles *service.RankingProfileService
+	selfCheck       *selfcheck.Checker
+	costs           *service.CostService
+	apiKeys         *service.APIKeyService
+	indexSettings   *service.IndexSettingsService
+	searchTemplates *service.SearchTemplateService
+	runtimeFields   *service.RuntimeFieldService
+	locks           *lock.Manager
+	cluster         *cluster.Node
+}
+
+// NewAdminHandlers создает новый экземпляр AdminHandlers.
+func NewAdminHandlers(deps AdminDeps) *AdminHandlers {
+	return &AdminHandlers{
+		esStorage:       deps.ESStorage,
+		jobs:            deps.Jobs,
+		embedder:        deps.Embedder,
+		batchSize:       deps.EmbeddingBatchSize,
+		rateLimit:       deps.EmbeddingRateLimit,
+		vector:          deps.VectorOptions,
+		metrics:         deps.Metrics,
+		reconciler:      deps.Reconciler,
+		archiver:        deps.Archiver,
+		pipelines:       deps.Pipelines,
+		models:          deps.Models,
+		golden:          deps.GoldenQueries,
+		substitutes:     deps.Substitutes,
+		profiles:        deps.ValidationProfiles,
+		cities:          deps.Cities,
+		referenceData:   deps.ReferenceData,
+		rankingProfiles: deps.RankingProfiles,
+		selfCheck:       deps.SelfCheck,
+		costs:           deps.Costs,
+		apiKeys:         deps.APIKeys,
+		indexSettings:   deps.IndexSettings,
+		searchTemplates: deps.SearchTemplates,
+		runtimeFields:   deps.RuntimeFields,
+		locks:           deps.Locks,
+		cluster:         deps.Cluster,
+	}
+}
+
+// RebuildEmbeddings обрабатывает POST запрос на асинхронный пересчет embeddings локаций.
+// Возвращает 202 и описание запущенной задачи; прогресс доступен через GET /admin/jobs/{id}.
+// Эндпоинт: POST /admin/embeddings/rebuild
+//
+// @Summary      Пересчитать embeddings локаций
+// @Description  Запускает фоновую задачу пересчета embeddings через настроенный провайдер для документов, подходящих под фильтр. По умолчанию пересчитываются документы с embedding_version ниже текущей.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.RebuildEmbeddingsRequest  false  "Фильтр документов"
+// @Success      202      {object}  jobs.Job
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/embeddings/rebuild [post]
+func (h *AdminHandlers) RebuildEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req models.RebuildEmbeddingsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Задача выполняется версией модели, активной на момент запуска
+	embedder := embedding.Current(h.embedder)
+	filter := req.LocationFilter
+	if !req.All && filter.EmbeddingVersionBelow == 0 {
+		filter.EmbeddingVersionBelow = embedder.Version()
+	}
+
+	job := h.jobs.Submit("rebuild_embeddings", func(ctx context.Context, progress *jobs.Progress) error {
+		// Пересчет на нескольких экземплярах одновременно повторял бы запросы к провайдеру
+		return h.locks.Do(ctx, "rebuild_embeddings", func(ctx context.Context) error {
+			return h.rebuildEmbeddings(ctx, embedder, &filter, progress)
+		})
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+	}
+}
+
+// rebuildEmbeddings обходит документы по фильтру и обновляет их embeddings,
+// ограничивая частоту запросов к провайдеру значением rateLimit.
+func (h *AdminHandlers) rebuildEmbeddings(ctx context.Context, embedder embedding.Provider, filter *models.LocationFilter, progress *jobs.Progress) error {
+	total, err := h.esStorage.CountLocations(ctx, filter)
+	if err != nil {
+		return err
+	}
+	progress.SetTotal(total)
+
+	var throttle <-chan time.Time
+	if h.rateLimit > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / h.rateLimit))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	return h.esStorage.ScanLocations(ctx, filter, h.batchSize, func(locations []*models.Location) error {
+		if throttle != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-throttle:
+			}
+		}
+
+		texts := make([]string, len(locations))
+		for i, location := range locations {
+			texts[i] = embedding.LocationText(location)
+		}
+
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed locations: %w", err)
+		}
+
+		embeddings := make(map[string]storage.LocationVectors, len(locations))
+		for i, location := range locations {
+			embeddings[location.ID] = storage.LocationVectors{
+				Text:        vectors[i],
+				Demographic: embedding.DemographicVector(location.Demographics),
+			}
+		}
+
+		failed, err := h.esStorage.BulkUpdateEmbeddings(ctx, embeddings, embedder.Version())
+		if err != nil {
+			return err
+		}
+		progress.Add(len(locations)-failed, failed)
+		return nil
+	})
+}
+
+// GetJob обрабатывает GET запрос на получение состояния фоновой задачи.
+// Эндпоинт: GET /admin/jobs/{id}
+//
+// @Summary      Получить состояние фоновой задачи
+// @Description  Возвращает статус и прогресс фоновой задачи по её идентификатору
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор задачи"
+// @Success      200  {object}  jobs.Job
+// @Failure      404  {object}  map[string]string  "Задача не найдена"
+// @Router       /admin/jobs/{id} [get]
+func (h *AdminHandlers) GetJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	job, ok := h.jobs.Get(id)
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// MappingResponse представляет текущий маппинг индекса и сравнение параметров kNN индекса с конфигурацией.
+type MappingResponse struct {
+	Mapping       map[string]interface{}      `json:"mapping"`
+	CurrentVector *storage.VectorIndexOptions `json:"current_vector_options"`
+	DesiredVector storage.VectorIndexOptions  `json:"desired_vector_options"`
+	InSync        bool                        `json:"in_sync"` // false — для применения настроек нужна переиндексация
+}
+
+// GetMapping обрабатывает GET запрос на получение маппинга индекса локаций.
+// Показывает текущие параметры kNN индекса и целевые параметры из конфигурации.
+// Эндпоинт: GET /admin/mapping
+//
+// @Summary      Получить маппинг индекса локаций
+// @Description  Возвращает текущий маппинг индекса и сравнивает параметры kNN индекса (HNSW m/ef_construction, метрика сходства) с конфигурацией
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  MappingResponse
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/mapping [get]
+func (h *AdminHandlers) GetMapping(w http.ResponseWriter, r *http.Request) {
+	mapping, err := h.esStorage.GetMapping(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting mapping", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	current, err := h.esStorage.GetVectorIndexOptions(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting vector index options", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := MappingResponse{
+		Mapping:       mapping,
+		CurrentVector: current,
+		DesiredVector: h.vector,
+		InSync:        *current == h.vector,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetMetrics обрабатывает GET запрос на получение накопленных метрик HTTP запросов.
+// Эндпоинт: GET /admin/metrics
+//
+// @Summary      Получить метрики HTTP запросов
+// @Description  Возвращает количество и длительность запросов по маршрутам, методам и кодам ответа
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}  metrics.RequestStats
+// @Router       /admin/metrics [get]
+func (h *AdminHandlers) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.metrics.Requests()); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetQueryCosts обрабатывает GET запрос на получение отчета о стоимости запросов по клиентам API.
+// Эндпоинт: GET /admin/usage
+//
+// @Summary      Стоимость запросов по клиентам
+// @Description  Возвращает дневную стоимость запросов к Elasticsearch по клиентам API: число запросов, время выполнения в кластере, найденные документы, бакеты агрегаций, стоимость в условных единицах и дневной бюджет клиента. Показатели записываются с задержкой до QUERY_COST_FLUSH_SECONDS.
+// @Tags         admin
+// @Produce      json
+// @Param        subject  query     string  false  "Клиент (subject API ключа или пользователя)"
+// @Param        from     query     string  false  "Начало периода YYYY-MM-DD (по умолчанию 6 дней назад)"
+// @Param        to       query     string  false  "Конец периода YYYY-MM-DD (по умолчанию сегодня)"
+// @Success      200      {array}   models.QueryCostUsage
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/usage [get]
+func (h *AdminHandlers) GetQueryCosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	usages, err := h.costs.Usage(r.Context(), query.Get("subject"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, usages)
+}
+
+// LocksResponse представляет состояние блокировок заданий.
+type LocksResponse struct {
+	Instance string        `json:"instance"` // Экземпляр, ответивший на запрос
+	Locks    []lock.Status `json:"locks"`
+}
+
+// GetLocks обрабатывает GET запрос на получение состояния блокировок заданий.
+// Эндпоинт: GET /admin/locks
+//
+// @Summary      Блокировки заданий
+// @Description  Возвращает блокировки заданий, выполняемых одним экземпляром кластера (архивация, сверка, выгрузки, конвейеры): удерживающий экземпляр и сведения этого экземпляра о последнем выполнении и пропуске.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  LocksResponse
+// @Failure      500  {object}  map[string]string  "Ошибка чтения блокировок"
+// @Router       /admin/locks [get]
+func (h *AdminHandlers) GetLocks(w http.ResponseWriter, r *http.Request) {
+	locks, err := h.locks.Status(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading locks", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, LocksResponse{Instance: h.locks.Instance(), Locks: locks})
+}
+
+// GetCluster обрабатывает GET запрос на получение ролей экземпляров сервиса.
+// Эндпоинт: GET /admin/cluster
+//
+// @Summary      Экземпляры кластера
+// @Description  Возвращает текущего лидера и роли экземпляров с недавним heartbeat. Без выбора лидера (LEADER_ELECTION=false) каждый экземпляр выполняет фоновые процессы и возвращает только себя.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  models.ClusterStatus
+// @Failure      500  {object}  map[string]string  "Ошибка чтения состояния кластера"
+// @Router       /admin/cluster [get]
+func (h *AdminHandlers) GetCluster(w http.ResponseWriter, r *http.Request) {
+	status, err := h.cluster.Status(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading cluster status", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// GetSelfCheck обрабатывает GET запрос на получение отчета самопроверки сервиса.
+// Эндпоинт: GET /admin/selfcheck
+//
+// @Summary      Отчет самопроверки
+// @Description  Возвращает последний отчет самопроверки, выполненной при запуске: конфигурация, доступность Elasticsearch и маппинг, версия схемы PostgreSQL, кеш, загрузка моделей. С run=true выполняет самопроверку заново.
+// @Tags         admin
+// @Produce      json
+// @Param        run  query     bool  false  "Выполнить самопроверку заново"
+// @Success      200  {object}  selfcheck.Report
+// @Failure      404  {object}  map[string]string  "Самопроверка еще не выполнялась"
+// @Router       /admin/selfcheck [get]
+func (h *AdminHandlers) GetSelfCheck(w http.ResponseWriter, r *http.Request) {
+	report := h.selfCheck.Last()
+	if r.URL.Query().Get("run") == "true" {
+		report = h.selfCheck.Run(r.Context())
+	}
+	if report == nil {
+		http.Error(w, "Self-check has not run yet", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// ReconcileRequest представляет запрос на сверку PostgreSQL и Elasticsearch.
+type ReconcileRequest struct {
+	Repair        bool `json:"repair"`         // Исправить расхождения старше grace-периода
+	DeleteOrphans bool `json:"delete_orphans"` // С repair удалить сироты из Elasticsearch
+}
+
+// Reconcile обрабатывает POST запрос на асинхронную сверку локаций между PostgreSQL и Elasticsearch.
+// Отчет о расхождениях доступен в поле result задачи через GET /admin/jobs/{id}.
+// Эндпоинт: POST /admin/reconcile
+//
+// @Summary      Сверить локации в PostgreSQL и Elasticsearch
+// @Description  Запускает фоновую сверку: находит документы, которые есть только в Elasticsearch или только в PostgreSQL. При repair=true расхождения, обнаруженные раньше grace-периода, исправляются: недоставленные локации повторно ставятся в outbox. Сироты в Elasticsearch удаляются, только если дополнительно задан delete_orphans=true.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      ReconcileRequest  false  "Параметры сверки"
+// @Success      202      {object}  jobs.Job
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/reconcile [post]
+func (h *AdminHandlers) Reconcile(w http.ResponseWriter, r *http.Request) {
+	var req ReconcileRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	job := h.jobs.Submit("reconcile", func(ctx context.Context, progress *jobs.Progress) error {
+		report, err := h.reconciler.Run(ctx, reconcile.Options{Repair: req.Repair, DeleteOrphans: req.DeleteOrphans})
+		if err != nil {
+			return err
+		}
+		progress.SetTotal(report.ESCount + report.PGCount)
+		progress.Add(report.ESCount+report.PGCount, 0)
+		progress.SetResult(report)
+		return nil
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+	}
+}
+
+// ArchiveRequest представляет запрос на архивацию холодных локаций.
+type ArchiveRequest struct {
+	DryRun bool `json:"dry_run"` // Только найти кандидатов, не перенося их
+}
+
+// Archive обрабатывает POST запрос на асинхронный перенос холодных локаций в архивный индекс.
+// Отчет доступен в поле result задачи через GET /admin/jobs/{id}.
+// Эндпоинт: POST /admin/archive
+//
+// @Summary      Архивировать холодные локации
+// @Description  Запускает фоновый перенос локаций, которые не обновлялись и не попадали в рекомендации дольше ARCHIVE_AFTER_MONTHS, в архивный индекс. Архивные локации доступны в поиске с include_archived=true.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      ArchiveRequest  false  "Параметры архивации"
+// @Success      202      {object}  jobs.Job
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/archive [post]
+func (h *AdminHandlers) Archive(w http.ResponseWriter, r *http.Request) {
+	var req ArchiveRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	job := h.jobs.Submit("archive", func(ctx context.Context, progress *jobs.Progress) error {
+		report, err := h.archiver.Run(ctx, req.DryRun)
+		if err != nil {
+			return err
+		}
+		progress.SetTotal(report.Scanned)
+		progress.Add(report.Scanned, 0)
+		progress.SetResult(report)
+		return nil
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// AnalyticsHandlers содержит зависимости для HTTP запросов аналитики.
+type AnalyticsHandlers struct {
+	analytics *service.AnalyticsService
+}
+
+// NewAnalyticsHandlers создает новый экземпляр AnalyticsHandlers.
+func NewAnalyticsHandlers(analytics *service.AnalyticsService) *AnalyticsHandlers {
+	return &AnalyticsHandlers{analytics: analytics}
+}
+
+// GetSegments обрабатывает GET запрос на получение агрегатов локаций по региону и типу бизнеса.
+// Эндпоинт: GET /analytics/segments
+//
+// @Summary      Аналитика по сегментам
+// @Description  Возвращает количество локаций, средние значения и перцентили traffic_score, среднюю конкуренцию и доход по парам регион и тип бизнеса. Агрегаты рассчитываются в Elasticsearch; если он перегружен или недоступен, ответ строится по данным, материализованным в PostgreSQL (source = postgres, refreshed_at — время расчета).
+// @Tags         analytics
+// @Produce      json
+// @Param        region         query     string  false  "Регион"
+// @Param        business_type  query     string  false  "Тип бизнеса"
+// @Param        source         query     string  false  "Источник: elasticsearch или postgres (по умолчанию — с переходом на postgres при сбое)"
+// @Success      200            {object}  models.SegmentAnalyticsResponse
+// @Failure      400            {object}  map[string]string  "Неверный запрос"
+// @Failure      500            {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /analytics/segments [get]
+func (h *AnalyticsHandlers) GetSegments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	response, err := h.analytics.Segments(r.Context(), query.Get("region"), query.Get("business_type"),
+		models.AnalyticsSource(query.Get("source")))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetRegionStats обрабатывает GET запрос на получение сводки по региону.
+// Эндпоинт: GET /regions/{id}/stats
+//
+// @Summary      Сводка по региону
+// @Description  Возвращает положение региона в иерархии справочника (путь, родительские регионы, подрегионы) и агрегаты опубликованных локаций региона и всех его подрегионов, рассчитанные в Elasticsearch в момент запроса: количество, средние traffic_score и competition_density, конкуренцию по типам бизнеса и распределение дохода.
+// @Tags         regions
+// @Produce      json
+// @Param        id   path      integer  true  "ID региона"
+// @Success      200  {object}  models.RegionStats
+// @Failure      400  {object}  map[string]string  "Неверный ID региона"
+// @Failure      404  {object}  map[string]string  "Регион не найден"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /regions/{id}/stats [get]
+func (h *AnalyticsHandlers) GetRegionStats(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid region ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.analytics.RegionStats(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListAPIKeys обрабатывает GET запрос на получение выпущенных API ключей.
+// Эндпоинт: GET /admin/api-keys
+//
+// @Summary      Выпущенные API ключи
+// @Description  Возвращает API ключи внешних клиентов, включая отозванные, с дневной квотой и числом запросов за текущие сутки UTC. Сами ключи не возвращаются.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.APIKey
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/api-keys [get]
+func (h *AdminHandlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeys.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey обрабатывает POST запрос на выпуск API ключа.
+// Эндпоинт: POST /admin/api-keys
+//
+// @Summary      Выпустить API ключ
+// @Description  Выпускает API ключ внешнего клиента с ролями и дневной квотой запросов (0 — без ограничения). Ключ возвращается только в этом ответе. Без subject клиент определяется ID ключа.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.APIKey  true  "Название, организация, клиент, роли и квота"
+// @Success      201      {object}  models.APIKey
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/api-keys [post]
+func (h *AdminHandlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var key models.APIKey
+	if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeys.Create(r.Context(), &key); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, key)
+}
+
+// UpdateAPIKey обрабатывает PUT запрос на изменение API ключа.
+// Эндпоинт: PUT /admin/api-keys/{id}
+//
+// @Summary      Изменить API ключ
+// @Description  Изменяет название, роли или дневную квоту ключа; поля без значения не меняются
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string               true  "ID ключа"
+// @Param        request  body      models.APIKeyUpdate  true  "Изменяемые параметры"
+// @Success      200      {object}  models.APIKey
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      404      {object}  map[string]string  "Ключ не найден или отозван"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/api-keys/{id} [put]
+func (h *AdminHandlers) UpdateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var update models.APIKeyUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeys.Update(r.Context(), mux.Vars(r)["id"], &update)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// RevokeAPIKey обрабатывает DELETE запрос на отзыв API ключа.
+// Эндпоинт: DELETE /admin/api-keys/{id}
+//
+// @Summary      Отозвать API ключ
+// @Tags         admin
+// @Param        id  path  string  true  "ID ключа"
+// @Success      204
+// @Failure      404  {object}  map[string]string  "Ключ не найден или уже отозван"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/api-keys/{id} [delete]
+func (h *AdminHandlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.apiKeys.Revoke(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// AsyncIndexHandlers содержит зависимости для HTTP запросов асинхронной индексации.
+type AsyncIndexHandlers struct {
+	asyncIndex *service.AsyncIndexService
+}
+
+// NewAsyncIndexHandlers создает новый экземпляр AsyncIndexHandlers.
+func NewAsyncIndexHandlers(asyncIndex *service.AsyncIndexService) *AsyncIndexHandlers {
+	return &AsyncIndexHandlers{asyncIndex: asyncIndex}
+}
+
+// SubmitLocations обрабатывает POST запрос на асинхронную индексацию локаций.
+// Эндпоинт: POST /locations/async
+//
+// @Summary      Асинхронно проиндексировать локации
+// @Description  Принимает локации и сразу отвечает 202 с идентификатором пакета и идентификаторами отслеживания документов. Документы индексируются через очередь outbox; их состояние (pending, indexed, failed, rejected) доступно на GET /locations/async/{tracking_id}. Если указан callback_url, о завершении каждого документа отправляется POST уведомление. Локация с существующим ID заменяется с сохранением времени создания, истории демографии и состояния; status учитывается только для новых локаций. Доступно только редакторам локаций.
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.AsyncIndexRequest  true  "Локации и адрес уведомлений"
+// @Success      202      {object}  models.AsyncIndexResponse
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      403      {object}  map[string]string  "Нет роли редактора локаций"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/async [post]
+func (h *AsyncIndexHandlers) SubmitLocations(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var req models.AsyncIndexRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	// Числовые поля, заданные в теле, нужны для проверки обязательных полей профилей
+	var raw struct {
+		Locations []json.RawMessage `json:"locations"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	for i := range req.Locations {
+		if err := req.Locations[i].MarkPresentJSON(raw.Locations[i]); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	response, err := h.asyncIndex.Submit(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+// GetAsyncIndexItem обрабатывает GET запрос на получение состояния документа асинхронной индексации.
+// Эндпоинт: GET /locations/async/{tracking_id}
+//
+// @Summary      Состояние документа асинхронной индексации
+// @Tags         locations
+// @Produce      json
+// @Param        tracking_id  path      string  true  "Идентификатор отслеживания"
+// @Success      200          {object}  models.AsyncIndexItem
+// @Failure      404          {object}  map[string]string  "Документ не найден"
+// @Failure      500          {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/async/{tracking_id} [get]
+func (h *AsyncIndexHandlers) GetAsyncIndexItem(w http.ResponseWriter, r *http.Request) {
+	item, err := h.asyncIndex.Get(r.Context(), mux.Vars(r)["tracking_id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// ListAsyncIndexItems обрабатывает GET запрос на получение состояния документов пакета.
+// Эндпоинт: GET /locations/async?batch_id=...
+//
+// @Summary      Состояние пакета асинхронной индексации
+// @Tags         locations
+// @Produce      json
+// @Param        batch_id  query     string  true  "Идентификатор пакета"
+// @Success      200       {array}   models.AsyncIndexItem
+// @Failure      400       {object}  map[string]string  "Не указан batch_id"
+// @Failure      404       {object}  map[string]string  "Пакет не найден"
+// @Failure      500       {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/async [get]
+func (h *AsyncIndexHandlers) ListAsyncIndexItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.asyncIndex.ListBatch(r.Context(), r.URL.Query().Get("batch_id"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
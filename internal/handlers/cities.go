@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// GetCities обрабатывает GET запрос на получение страницы городов справочника.
+// Эндпоинт: GET /cities
+//
+// @Summary      Получить список городов
+// @Description  Возвращает страницу городов справочника с координатами центров и общим количеством подходящих записей. Записи отбираются по началу имени и региону и сортируются по имени или ID.
+// @Tags         cities
+// @Produce      json
+// @Param        limit      query     integer  false  "Размер страницы (по умолчанию 100, не более 1000)"
+// @Param        offset     query     integer  false  "Число пропускаемых записей"
+// @Param        prefix     query     string   false  "Начало имени без учета регистра"
+// @Param        region_id  query     integer  false  "ID региона"
+// @Param        sort       query     string   false  "Сортировка: name, -name, id, -id (по умолчанию name)"
+// @Success      200  {object}  models.CityPage
+// @Failure      400  {object}  map[string]string  "Неверные параметры выборки"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /cities [get]
+func (h *Handlers) GetCities(w http.ResponseWriter, r *http.Request) {
+	query, err := parseReferenceQuery(r, "region_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.references.ListCities(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// LookupCities обрабатывает GET запрос на поиск городов по имени.
+// Эндпоинт: GET /cities/lookup
+//
+// @Summary      Найти город по имени
+// @Description  Возвращает города с указанным именем без учета регистра; одноименные города разных регионов различаются параметром region.
+// @Tags         cities
+// @Produce      json
+// @Param        name    query     string  true   "Имя города"
+// @Param        region  query     string  false  "Регион города"
+// @Success      200  {array}   models.City
+// @Failure      400  {object}  map[string]string  "Не указано имя"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /cities/lookup [get]
+func (h *Handlers) LookupCities(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cities, err := h.references.LookupCities(r.Context(), query.Get("name"), query.Get("region"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cities)
+}
+
+// GetCity обрабатывает GET запрос на получение города справочника.
+// Эндпоинт: GET /cities/{id}
+//
+// @Summary      Получить город
+// @Tags         cities
+// @Produce      json
+// @Param        id   path      integer  true  "ID города"
+// @Success      200  {object}  models.City
+// @Failure      400  {object}  map[string]string  "Неверный ID города"
+// @Failure      404  {object}  map[string]string  "Город не найден"
+// @Router       /cities/{id} [get]
+func (h *Handlers) GetCity(w http.ResponseWriter, r *http.Request) {
+	id, ok := cityID(w, r)
+	if !ok {
+		return
+	}
+
+	city, err := h.references.City(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, city)
+}
+
+// CreateCity обрабатывает POST запрос на добавление города в справочник.
+// Эндпоинт: POST /admin/cities
+//
+// @Summary      Добавить город
+// @Description  Добавляет город региона с координатами центра и населением. Центр города используется как точка отсчета фактора расстояния в рекомендациях без origin; город локаций региона, у которого есть города в справочнике, проверяется при записи и импорте.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.City  true  "Город"
+// @Success      201      {object}  models.City
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      409      {object}  map[string]string  "Город с таким именем в регионе уже есть"
+// @Router       /admin/cities [post]
+func (h *AdminHandlers) CreateCity(w http.ResponseWriter, r *http.Request) {
+	var city models.City
+	if err := json.NewDecoder(r.Body).Decode(&city); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cities.Create(r.Context(), &city); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, city)
+}
+
+// UpdateCity обрабатывает PUT запрос на изменение города справочника.
+// Эндпоинт: PUT /admin/cities/{id}
+//
+// @Summary      Изменить город
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      integer      true  "ID города"
+// @Param        request  body      models.City  true  "Город"
+// @Success      200      {object}  models.City
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      404      {object}  map[string]string  "Город не найден"
+// @Failure      409      {object}  map[string]string  "Город с таким именем в регионе уже есть"
+// @Router       /admin/cities/{id} [put]
+func (h *AdminHandlers) UpdateCity(w http.ResponseWriter, r *http.Request) {
+	id, ok := cityID(w, r)
+	if !ok {
+		return
+	}
+	var city models.City
+	if err := json.NewDecoder(r.Body).Decode(&city); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cities.Update(r.Context(), id, &city); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, city)
+}
+
+// DeleteCity обрабатывает DELETE запрос на удаление города из справочника.
+// Эндпоинт: DELETE /admin/cities/{id}
+//
+// @Summary      Удалить город
+// @Tags         admin
+// @Param        id  path  integer  true  "ID города"
+// @Success      204
+// @Failure      404  {object}  map[string]string  "Город не найден"
+// @Router       /admin/cities/{id} [delete]
+func (h *AdminHandlers) DeleteCity(w http.ResponseWriter, r *http.Request) {
+	id, ok := cityID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.cities.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cityID разбирает ID города из пути и при ошибке отвечает 400.
+func cityID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid city ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+)
+
+// writeServiceError преобразует ошибку сервисного слоя в HTTP ответ.
+// Неизвестные ошибки логируются с полями запроса r и возвращаются клиенту как 500 без подробностей.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case service.IsValidationError(err):
+		// Нарушения по полям возвращаются JSON, чтобы клиент мог сопоставить их с полями формы
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) && len(validationErr.Violations) > 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":      validationErr.Message,
+				"violations": validationErr.Violations,
+			})
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+	case errors.Is(err, service.ErrForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrConflict):
+		http.Error(w, "Already exists", http.StatusConflict)
+	case errors.Is(err, service.ErrExpired):
+		http.Error(w, "Expired", http.StatusGone)
+	case errors.Is(err, service.ErrUnavailable):
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+	default:
+		slog.ErrorContext(r.Context(), "Error processing request", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// writeJSON кодирует value в JSON ответ с указанным статусом.
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	writeEncoded(w, status, value)
+}
+
+// writeEncoded кодирует value в JSON ответ с указанным статусом и заданным ранее типом содержимого.
+func writeEncoded(w http.ResponseWriter, status int, value interface{}) {
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Error encoding response", logging.Err(err))
+	}
+}
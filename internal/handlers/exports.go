@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/artifact"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/report"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// ExportHandlers содержит зависимости для HTTP запросов выгрузки отчетов.
+type ExportHandlers struct {
+	exports *service.ExportService
+}
+
+// NewExportHandlers создает новый экземпляр ExportHandlers.
+func NewExportHandlers(exports *service.ExportService) *ExportHandlers {
+	return &ExportHandlers{exports: exports}
+}
+
+// ExportRequest представляет запрос на выгрузку проекта.
+type ExportRequest struct {
+	Format report.Format `json:"format"` // xlsx, pptx или parquet
+}
+
+// ExportProject обрабатывает POST запрос на асинхронное формирование отчета по проекту.
+// Ссылка на файл появляется в поле result задачи (GET /jobs/{id}).
+// Эндпоинт: POST /projects/{id}/export
+//
+// @Summary      Выгрузить проект в XLSX, PPTX или Parquet
+// @Description  Запускает формирование клиентского отчета: XLSX (строка на кандидата с метриками), PPTX (слайд на кандидата с диаграммой оценок и схемой расположения) или Parquet (строка на кандидата со всеми полями локации и эмбеддингом для pandas/Spark). Готовый файл скачивается по download_url из результата задачи.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string         true  "Идентификатор проекта"
+// @Param        request  body      ExportRequest  true  "Формат отчета"
+// @Success      202      {object}  github_com_akozadaev_go_es_analytical_system_internal_jobs.Job
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404      {object}  map[string]string  "Проект не найден"
+// @Router       /projects/{id}/export [post]
+func (h *ExportHandlers) ExportProject(w http.ResponseWriter, r *http.Request) {
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.exports.ExportProject(r.Context(), mux.Vars(r)["id"], req.Format)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetJob обрабатывает GET запрос на получение состояния задачи выгрузки.
+// Эндпоинт: GET /jobs/{id}
+//
+// @Summary      Получить состояние задачи выгрузки
+// @Description  Возвращает статус, прогресс и результат задачи, запущенной организацией клиента (например, POST /projects/{id}/export). Служебные задачи и задачи других организаций не возвращаются.
+// @Tags         projects
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор задачи"
+// @Success      200  {object}  github_com_akozadaev_go_es_analytical_system_internal_jobs.Job
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404  {object}  map[string]string  "Задача не найдена"
+// @Router       /jobs/{id} [get]
+func (h *ExportHandlers) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.exports.Job(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// DownloadArtifact обрабатывает GET запрос на скачивание сформированного файла.
+// Эндпоинт: GET /artifacts/{id}
+//
+// @Summary      Скачать артефакт
+// @Description  Возвращает файл, сформированный фоновой задачей, если он принадлежит организации клиента
+// @Tags         projects
+// @Produce      octet-stream
+// @Param        id   path  string  true  "Идентификатор артефакта"
+// @Success      200
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404  {object}  map[string]string  "Артефакт не найден"
+// @Router       /artifacts/{id} [get]
+func (h *ExportHandlers) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	meta, content, err := h.exports.OpenArtifact(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	defer content.Close()
+	writeArtifact(w, r, meta, content)
+}
+
+// SignArtifact обрабатывает POST запрос на создание подписанной ссылки на скачивание.
+// Эндпоинт: POST /artifacts/{id}/sign
+//
+// @Summary      Создать подписанную ссылку на артефакт
+// @Description  Возвращает ссылку с ограниченным сроком действия (SIGNED_URL_TTL_MINUTES), по которой файл скачивается без API ключа — например, из браузера
+// @Tags         projects
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор артефакта"
+// @Success      200  {object}  service.SignedDownload
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404  {object}  map[string]string  "Артефакт не найден"
+// @Router       /artifacts/{id}/sign [post]
+func (h *ExportHandlers) SignArtifact(w http.ResponseWriter, r *http.Request) {
+	signed, err := h.exports.SignArtifact(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, signed)
+}
+
+// DownloadSignedArtifact обрабатывает GET запрос на скачивание файла по подписанной ссылке.
+// Эндпоинт не требует аутентификации: подпись и срок действия проверяет middleware маршрута.
+// Эндпоинт: GET /downloads/artifacts/{id}
+//
+// @Summary      Скачать артефакт по подписанной ссылке
+// @Description  Возвращает файл по ссылке из signed_url результата выгрузки или POST /artifacts/{id}/sign
+// @Tags         projects
+// @Produce      octet-stream
+// @Param        id         path   string  true  "Идентификатор артефакта"
+// @Param        expires    query  int     true  "Срок действия ссылки (Unix time)"
+// @Param        signature  query  string  true  "Подпись ссылки"
+// @Success      200
+// @Failure      403  {object}  map[string]string  "Неверная подпись"
+// @Failure      404  {object}  map[string]string  "Артефакт не найден"
+// @Failure      410  {object}  map[string]string  "Срок действия ссылки истек"
+// @Router       /downloads/artifacts/{id} [get]
+func (h *ExportHandlers) DownloadSignedArtifact(w http.ResponseWriter, r *http.Request) {
+	meta, content, err := h.exports.OpenSignedArtifact(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	defer content.Close()
+	writeArtifact(w, r, meta, content)
+}
+
+// writeArtifact отправляет содержимое артефакта как вложение.
+func writeArtifact(w http.ResponseWriter, r *http.Request, meta *artifact.Artifact, content io.Reader) {
+	w.Header().Set("Content-Type", meta.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(meta.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(meta.Name)))
+	if _, err := io.Copy(w, content); err != nil {
+		slog.ErrorContext(r.Context(), "Error sending artifact", slog.String("artifact_id", meta.ID), logging.Err(err))
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+)
+
+// GetFlatView обрабатывает GET запрос на получение плоского представления локаций для BI
+// инструментов (Power BI, Tableau Web Data Connector).
+// Эндпоинт: GET /export/flat
+//
+// @Summary      Плоское представление локаций
+// @Description  Возвращает по строке на локацию с демографией и оценками, развернутыми в столбцы. Набор и порядок столбцов стабильны (schema_version); новые столбцы добавляются только в конец. Постраничный обход — по курсору next_cursor. С format=csv возвращает CSV с заголовком; курсор следующей страницы и общее количество передаются в заголовках X-Next-Cursor и X-Total-Count.
+// @Tags         export
+// @Produce      json
+// @Produce      text/csv
+// @Param        region  query     string   false  "Регион"
+// @Param        city    query     string   false  "Город"
+// @Param        cursor  query     string   false  "Курсор из next_cursor предыдущей страницы"
+// @Param        limit   query     integer  false  "Размер страницы (по умолчанию 1000, не более 10000)"
+// @Param        format  query     string   false  "json (по умолчанию) или csv"
+// @Success      200     {object}  models.FlatViewResponse
+// @Failure      400     {object}  map[string]string  "Неверный запрос"
+// @Failure      500     {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /export/flat [get]
+func (h *Handlers) GetFlatView(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.locations.FlatView(r.Context(), query.Get("region"), query.Get("city"), query.Get("cursor"), limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if format == "csv" {
+		writeFlatCSV(w, r, response)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeFlatCSV записывает страницу плоского представления в CSV с заголовком.
+func writeFlatCSV(w http.ResponseWriter, r *http.Request, response *models.FlatViewResponse) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("X-Schema-Version", strconv.Itoa(response.SchemaVersion))
+	w.Header().Set("X-Total-Count", strconv.Itoa(response.Total))
+	if response.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", response.NextCursor)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	header := make([]string, 0, len(response.Columns))
+	for _, column := range response.Columns {
+		header = append(header, column.Name)
+	}
+	records := [][]string{header}
+	for i := range response.Rows {
+		records = append(records, service.FlatRecord(&response.Rows[i]))
+	}
+	if err := writer.WriteAll(records); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing CSV", logging.Err(err))
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListGoldenQueries обрабатывает GET запрос на получение эталонных запросов.
+// Эндпоинт: GET /admin/golden-queries
+//
+// @Summary      Эталонные запросы
+// @Description  Возвращает эталонные запросы рекомендаций с ожидаемыми лучшими результатами
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.GoldenQuery
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/golden-queries [get]
+func (h *AdminHandlers) ListGoldenQueries(w http.ResponseWriter, r *http.Request) {
+	queries, err := h.golden.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if queries == nil {
+		queries = []models.GoldenQuery{}
+	}
+
+	writeJSON(w, http.StatusOK, queries)
+}
+
+// SaveGoldenQuery обрабатывает PUT запрос на создание или замену эталонного запроса.
+// Эндпоинт: PUT /admin/golden-queries/{id}
+//
+// @Summary      Сохранить эталонный запрос
+// @Description  Создает или заменяет эталонный запрос: локации expected_ids должны входить в первые top_k результатов выдачи по request
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string              true  "Идентификатор эталонного запроса"
+// @Param        request  body      models.GoldenQuery  true  "Эталонный запрос"
+// @Success      200      {object}  models.GoldenQuery
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/golden-queries/{id} [put]
+func (h *AdminHandlers) SaveGoldenQuery(w http.ResponseWriter, r *http.Request) {
+	var gq models.GoldenQuery
+	if err := json.NewDecoder(r.Body).Decode(&gq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	gq.ID = mux.Vars(r)["id"]
+
+	if err := h.golden.Save(r.Context(), &gq); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gq)
+}
+
+// DeleteGoldenQuery обрабатывает DELETE запрос на удаление эталонного запроса.
+// Эндпоинт: DELETE /admin/golden-queries/{id}
+//
+// @Summary      Удалить эталонный запрос
+// @Tags         admin
+// @Param        id   path  string  true  "Идентификатор эталонного запроса"
+// @Success      204
+// @Failure      404  {object}  map[string]string  "Эталонный запрос не найден"
+// @Router       /admin/golden-queries/{id} [delete]
+func (h *AdminHandlers) DeleteGoldenQuery(w http.ResponseWriter, r *http.Request) {
+	if err := h.golden.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunGoldenQueries обрабатывает POST запрос на прогон эталонных запросов по текущему индексу.
+// Эндпоинт: POST /admin/golden-queries/run
+//
+// @Summary      Прогнать эталонные запросы
+// @Description  Выполняет эталонные запросы без кеша и записи в историю и возвращает отклонения: ожидаемые локации, не вошедшие в top_k
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  models.GoldenRunReport
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/golden-queries/run [post]
+func (h *AdminHandlers) RunGoldenQueries(w http.ResponseWriter, r *http.Request) {
+	report, err := h.golden.Run(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
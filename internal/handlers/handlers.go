@@ -2,36 +2,78 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/akozadaev/go_es_analytical_system/internal/apichanges"
+	"github.com/akozadaev/go_es_analytical_system/internal/buildinfo"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
 	"github.com/akozadaev/go_es_analytical_system/internal/models"
-	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/akozadaev/go_es_analytical_system/internal/selfcheck"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
 	"github.com/gorilla/mux"
 )
 
 // Handlers содержит зависимости для обработки HTTP запросов.
-// Использует Elasticsearch для поиска локаций и PostgreSQL для справочников.
+// Бизнес-логика вынесена в сервисный слой; handlers отвечают только за HTTP.
 type Handlers struct {
-	esStorage *storage.ElasticsearchStorage // Хранилище для Elasticsearch/OpenSearch
-	pgStorage *storage.PostgresStorage      // Хранилище для PostgreSQL
+	recommendations *service.RecommendationService // Рекомендации локаций
+	locations       *service.LocationService       // Операции с локациями
+	references      *service.ReferenceService      // Справочники
+	notes           *service.NoteService           // Заметки пользователей о локациях
+	changes         *service.ChangeFeedService     // Лента изменений локаций
+	health          *selfcheck.Checker             // Проверки зависимостей для /health и /readyz (nil — без проверок)
 }
 
-// NewHandlers создает новый экземпляр Handlers с заданными хранилищами.
-func NewHandlers(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage) *Handlers {
+// NewHandlers создает новый экземпляр Handlers с заданными сервисами.
+func NewHandlers(recommendations *service.RecommendationService, locations *service.LocationService, references *service.ReferenceService, notes *service.NoteService, changes *service.ChangeFeedService, health *selfcheck.Checker) *Handlers {
 	return &Handlers{
-		esStorage: esStorage,
-		pgStorage: pgStorage,
+		recommendations: recommendations,
+		locations:       locations,
+		references:      references,
+		notes:           notes,
+		changes:         changes,
+		health:          health,
 	}
 }
 
+// APIChanges обрабатывает GET запрос на получение журнала изменений контракта API.
+// Эндпоинт: GET /api/changes
+//
+// @Summary      Журнал изменений API
+// @Description  Возвращает добавленные, удаленные и устаревшие эндпоинты и поля моделей по версиям — от новых к старым. Параметр since оставляет версии новее указанной.
+// @Tags         health
+// @Produce      json
+// @Param        since  query     string  false  "Версия, после которой нужны изменения"
+// @Success      200    {object}  apichanges.Changelog
+// @Failure      500    {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /api/changes [get]
+func (h *Handlers) APIChanges(w http.ResponseWriter, r *http.Request) {
+	changelog, err := apichanges.Build(buildinfo.Get().Version)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error building API changelog", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if since := r.URL.Query().Get("since"); since != "" {
+		changelog.Changes = changelog.Since(since)
+	}
+
+	writeJSON(w, http.StatusOK, changelog)
+}
+
 // RecommendLocations обрабатывает POST запрос на получение рекомендаций локаций.
 // Принимает RecommendRequest в теле запроса и возвращает отсортированный список локаций.
 // Эндпоинт: POST /locations/recommend
 //
 // @Summary      Получить рекомендации локаций
-// @Description  Возвращает список рекомендованных локаций для указанного типа бизнеса в регионе. Локации ранжируются по релевантности с учетом traffic_score, competition_density и демографии.
+// @Description  Возвращает список рекомендованных локаций для указанного типа бизнеса в регионе. Локации ранжируются по релевантности с учетом traffic_score, competition_density и демографии с весами из weights. С use_embedding и query_vector локации, подходящие под фильтры, ранжируются по сходству их embedding с вектором запроса в метрике similarity (cosine, dot_product, l2_norm; по умолчанию — метрика индекса), с target_demographics — по сходству вектора демографического профиля района; с обоими векторами сходства суммируются с весами vector_weights или профиля ранжирования типа бизнеса. С intent описание места свободным текстом переводится в embedding настроенным провайдером, и сходство с ним добавляется к оценке по показателям с долей intent_weight (по умолчанию 0.5). Если в ответе есть next_cursor, следующая страница запрашивается с тем же запросом и cursor.
 // @Tags         locations
 // @Accept       json
 // @Produce      json
@@ -52,51 +94,252 @@ func (h *Handlers) RecommendLocations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Region == "" || req.BusinessType == "" {
-		http.Error(w, "Region and business_type are required", http.StatusBadRequest)
+	h.recommend(w, r, &req)
+}
+
+// RecommendLocationsQuery обрабатывает GET запрос на получение рекомендаций локаций.
+// Параметры строки запроса соответствуют полям тела POST /locations/recommend, поэтому выдачу
+// можно получить по ссылке, закешировать прокси или запросить из BI инструмента.
+// Эндпоинт: GET /locations/recommend
+//
+// @Summary      Получить рекомендации локаций по параметрам запроса
+// @Description  То же, что POST /locations/recommend, с параметрами в строке запроса. Точка отсчета задается lat и lon, радиус поиска от нее — radius (метры), область карты — bbox (запад,юг,восток,север).
+// @Tags         locations
+// @Produce      json
+// @Param        region         query     string   true   "Регион"
+// @Param        business_type  query     string   true   "Тип бизнеса"
+// @Param        city           query     string   false  "Город"
+// @Param        limit          query     int      false  "Максимальное количество результатов"
+// @Param        lat            query     number   false  "Широта точки отсчета"
+// @Param        lon            query     number   false  "Долгота точки отсчета"
+// @Param        radius         query     number   false  "Радиус от точки отсчета, метры"
+// @Param        bbox           query     string   false  "Область карты: запад,юг,восток,север"
+// @Param        distance_from_city_center  query  boolean  false  "false — не учитывать расстояние от центра города без lat и lon"
+// @Param        min_results    query     int      false  "Минимум результатов до ослабления ограничений"
+// @Param        autocorrect    query     boolean  false  "Исправлять опечатки в регионе и городе"
+// @Param        weights        query     string   false  "Веса факторов: traffic:0.5,competition:0.3,demographics:0.2"
+// @Param        intent         query     string   false  "Описание искомого места свободным текстом"
+// @Param        intent_weight  query     number   false  "Доля сходства с intent в оценке (0–1], по умолчанию 0.5"
+// @Param        cursor         query     string   false  "Курсор следующей страницы (next_cursor предыдущего ответа)"
+// @Success      200            {object}  models.RecommendResponse
+// @Failure      400            {object}  map[string]string  "Неверный запрос"
+// @Failure      500            {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/recommend [get]
+func (h *Handlers) RecommendLocationsQuery(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRecommendQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if req.Limit == 0 {
-		req.Limit = 20
-	}
+	h.recommend(w, r, req)
+}
 
-	locations, err := h.esStorage.RecommendLocations(r.Context(), &req)
+// recommend выполняет запрос рекомендаций и записывает ответ.
+func (h *Handlers) recommend(w http.ResponseWriter, r *http.Request, req *models.RecommendRequest) {
+	response, err := h.recommendations.Recommend(r.Context(), req)
 	if err != nil {
-		log.Printf("Error recommending locations: %v", err)
+		if service.IsValidationError(err) {
+			// Нарушения по полям возвращаются JSON (см. writeServiceError)
+			writeServiceError(w, r, err)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error recommending locations", logging.Err(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Преобразуем указатели в значения для JSON
-	locationValues := make([]models.Location, len(locations))
-	for i, loc := range locations {
-		locationValues[i] = *loc
-	}
-
-	response := models.RecommendResponse{
-		Locations: locationValues,
-		Total:     len(locationValues),
+	if hypermediaRequested(r) {
+		linked := newHALRecommendResponse(response, req)
+		if r.Method == http.MethodGet {
+			linked.Links["self"] = halLink{Href: r.URL.RequestURI()}
+		}
+		writeHAL(w, http.StatusOK, linked)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// GetLocations обрабатывает POST запрос на получение нескольких локаций по ID.
+// Заменяет последовательные GET /locations/{id} после получения списка кандидатов.
+// Эндпоинт: POST /locations/_mget
+//
+// @Summary      Получить несколько локаций
+// @Description  Возвращает локации по списку ID одним запросом: найденные в порядке запроса и ID отсутствующих
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.MultiGetRequest  true  "Список ID"
+// @Success      200      {object}  models.MultiGetResponse
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/_mget [post]
+func (h *Handlers) GetLocations(w http.ResponseWriter, r *http.Request) {
+	var req models.MultiGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.locations.GetMany(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// LocationExists обрабатывает HEAD запрос на проверку существования локации без передачи документа.
+// Эндпоинт: HEAD /locations/{id}
+//
+// @Summary      Проверить существование локации
+// @Tags         locations
+// @Param        id                path   string   true   "Идентификатор локации"
+// @Param        include_archived  query  boolean  false  "Искать также в архиве"
+// @Success      200
+// @Failure      404
+// @Router       /locations/{id} [head]
+func (h *Handlers) LocationExists(w http.ResponseWriter, r *http.Request) {
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("include_archived"))
+
+	exists, err := h.locations.Exists(r.Context(), mux.Vars(r)["id"], includeArchived)
+	switch {
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error checking location", logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+	case exists:
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// ValidateLocationRefs обрабатывает POST запрос на проверку ссылок на локации из внешних систем.
+// Эндпоинт: POST /locations/validate-refs
+//
+// @Summary      Проверить ссылки на локации
+// @Description  Сообщает, какие из переданных ID локаций существуют, без передачи документов
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.MultiGetRequest  true  "Список ID"
+// @Success      200      {object}  models.ValidateRefsResponse
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/validate-refs [post]
+func (h *Handlers) ValidateLocationRefs(w http.ResponseWriter, r *http.Request) {
+	var req models.MultiGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.locations.ValidateRefs(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ListLocationChanges обрабатывает GET запрос на получение ленты изменений локаций.
+// Эндпоинт: GET /locations/changes
+//
+// @Summary      Лента изменений локаций
+// @Description  Возвращает созданные, обновленные и удаленные локации после курсора since в порядке изменения. Курсор next_cursor передается в следующий запрос. Если изменений нет, запрос с wait ждет их появления до указанного числа секунд (long polling).
+// @Tags         locations
+// @Produce      json
+// @Param        since  query     string   false  "Курсор из next_cursor предыдущего ответа (пусто — с начала ленты)"
+// @Param        limit  query     integer  false  "Максимальное количество изменений (по умолчанию 100, не более 1000)"
+// @Param        wait   query     integer  false  "Сколько секунд ждать изменений, если их нет"
+// @Success      200    {object}  models.LocationChangesResponse
+// @Failure      400    {object}  map[string]string  "Неверный запрос"
+// @Failure      500    {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/changes [get]
+func (h *Handlers) ListLocationChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	waitSeconds, err := queryInt(query.Get("wait"))
+	if err != nil {
+		http.Error(w, "Invalid wait", http.StatusBadRequest)
+		return
+	}
+
+	wait := time.Duration(waitSeconds) * time.Second
+	if wait > 0 {
+		// Ожидание может превысить WriteTimeout сервера — продлеваем его для этого запроса
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(h.changes.MaxWait() + 5*time.Second))
+	}
+
+	response, err := h.changes.Changes(r.Context(), query.Get("since"), limit, wait)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// queryInt разбирает необязательный целочисленный параметр запроса; пустое значение — 0.
+func queryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// PortfolioLocations обрабатывает POST запрос на подбор набора локаций в нескольких регионах.
+// Эндпоинт: POST /locations/portfolio
+//
+// @Summary      Подобрать портфель локаций
+// @Description  Подбирает целевое количество точек в нескольких регионах, максимизируя суммарную оценку при ограничениях на общий бюджет, число точек в регионе и минимальное расстояние между точками. Отбор жадный, на основе обычного поиска рекомендаций; оценки — исходные оценки Elasticsearch без нормализации, сравнимые между регионами.
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.PortfolioRequest  true  "Параметры портфеля"
+// @Success      200      {object}  models.PortfolioResponse
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/portfolio [post]
+func (h *Handlers) PortfolioLocations(w http.ResponseWriter, r *http.Request) {
+	var req models.PortfolioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.recommendations.Portfolio(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
 // GetLocation обрабатывает GET запрос на получение детальной информации о локации по ID.
 // Эндпоинт: GET /locations/{id}
 //
 // @Summary      Получить детали локации
-// @Description  Возвращает полную информацию о локации по её идентификатору
+// @Description  Возвращает полную информацию о локации по её идентификатору. Для аутентифицированного клиента добавляются заметки и средняя оценка его организации.
 // @Tags         locations
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "Идентификатор локации"
-// @Success      200  {object}  models.Location
+// @Param        id                path      string  true   "Идентификатор локации"
+// @Param        include_archived  query     bool    false  "Искать также в архивном индексе"
+// @Success      200  {object}  models.LocationDetails
 // @Failure      404  {object}  map[string]string  "Локация не найдена"
 // @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
 // @Router       /locations/{id} [get]
@@ -109,41 +352,157 @@ func (h *Handlers) GetLocation(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	if id == "" {
-		http.Error(w, "Location ID is required", http.StatusBadRequest)
-		return
-	}
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("include_archived"))
 
-	location, err := h.esStorage.GetLocation(r.Context(), id)
+	location, err := h.locations.Get(r.Context(), id, includeArchived)
 	if err != nil {
-		if err.Error() == "location not found" {
+		if service.IsValidationError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrNotFound) {
 			http.Error(w, "Location not found", http.StatusNotFound)
 			return
 		}
-		log.Printf("Error getting location: %v", err)
+		slog.ErrorContext(r.Context(), "Error getting location", logging.Err(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	details, err := h.notes.Details(r.Context(), location)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting location notes", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if hypermediaRequested(r) {
+		writeHAL(w, http.StatusOK, &halLocationDetails{LocationDetails: details, Links: locationLinks(location)})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(location); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	if err := json.NewEncoder(w).Encode(details); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// CreateLocation обрабатывает POST запрос на создание локации.
+// Эндпоинт: POST /locations
+//
+// @Summary      Создать локацию
+// @Description  Сохраняет локацию в PostgreSQL; в поиске она появляется после доставки в Elasticsearch через outbox. Без id локации присваивается сгенерированный ID.
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.Location    true  "Локация"
+// @Success      201      {object}  models.Location
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      403      {object}  map[string]string  "Нет роли редактора локаций"
+// @Failure      409      {object}  map[string]string  "Локация с таким ID уже существует"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations [post]
+func (h *Handlers) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	var location models.Location
+	if err := decodeLocation(r, &location); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.locations.Create(r.Context(), &location); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", models.APIV1Prefix+"/locations/"+location.ID)
+	writeJSON(w, http.StatusCreated, &location)
+}
+
+// UpdateLocation обрабатывает PUT запрос на замену локации.
+// Эндпоинт: PUT /locations/{id}
+//
+// @Summary      Изменить локацию
+// @Description  Заменяет данные локации, сохраняя время создания. Изменение появляется в поиске после доставки в Elasticsearch через outbox.
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "Идентификатор локации"
+// @Param        request  body      models.Location    true  "Локация"
+// @Success      200      {object}  models.Location
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      403      {object}  map[string]string  "Нет роли редактора локаций"
+// @Failure      404      {object}  map[string]string  "Локация не найдена"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/{id} [put]
+func (h *Handlers) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	var location models.Location
+	if err := decodeLocation(r, &location); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.locations.Update(r.Context(), mux.Vars(r)["id"], &location); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &location)
+}
+
+// decodeLocation декодирует локацию из тела запроса и отмечает числовые поля, заданные в нем,
+// для проверки обязательных полей профилей.
+func decodeLocation(r *http.Request, location *models.Location) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, location); err != nil {
+		return err
+	}
+	return location.MarkPresentJSON(body)
+}
+
+// DeleteLocation обрабатывает DELETE запрос на удаление локации.
+// Эндпоинт: DELETE /locations/{id}
+//
+// @Summary      Удалить локацию
+// @Description  Удаляет локацию из PostgreSQL; из индекса она удаляется после доставки изменения через outbox
+// @Tags         locations
+// @Param        id   path  string  true  "Идентификатор локации"
+// @Success      204
+// @Failure      403  {object}  map[string]string  "Нет роли редактора локаций"
+// @Failure      404  {object}  map[string]string  "Локация не найдена"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/{id} [delete]
+func (h *Handlers) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	if err := h.locations.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetBusinessTypes обрабатывает GET запрос на получение списка всех типов бизнеса.
 // Возвращает данные из справочника PostgreSQL.
 // Эндпоинт: GET /business-types
 //
 // @Summary      Получить список типов бизнеса
-// @Description  Возвращает все доступные типы бизнеса из справочника
+// @Description  Возвращает страницу типов бизнеса из справочника с общим количеством подходящих записей. Записи отбираются по началу имени и категории и сортируются по имени или ID. Без limit и offset возвращается массив всех отобранных записей, как в прежних версиях API.
 // @Tags         business-types
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.BusinessType
+// @Param        limit      query     integer  false  "Размер страницы: по умолчанию 100, больше 1000 — ошибка 400"
+// @Param        offset     query     integer  false  "Число пропускаемых записей"
+// @Param        prefix     query     string   false  "Начало имени без учета регистра"
+// @Param        parent_id  query     integer  false  "ID категории; 0 — категории верхнего уровня"
+// @Param        sort       query     string   false  "Сортировка: name, -name, id, -id (по умолчанию name)"
+// @Success      200  {object}  models.BusinessTypePage  "Страница, если передан limit или offset; без них — массив models.BusinessType всех отобранных записей (схема в x-unpaged-response)"
+// @Failure      400  {object}  map[string]string  "Неверные параметры выборки"
 // @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @x-unpaged-response {"description": "Ответ без limit и offset: массив всех отобранных записей", "schema": {"type": "array", "items": {"$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.BusinessType"}}}
 // @Router       /business-types [get]
 func (h *Handlers) GetBusinessTypes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -151,22 +510,32 @@ func (h *Handlers) GetBusinessTypes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	businessTypes, err := h.pgStorage.GetBusinessTypes(r.Context())
+	query, err := parseReferenceQuery(r, "parent_id")
 	if err != nil {
-		log.Printf("Error getting business types: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Клиенты прежних версий API запрашивают справочник без limit и offset и ожидают массив
+	query.All = !r.URL.Query().Has("limit") && !r.URL.Query().Has("offset")
 
-	// Преобразуем указатели в значения для JSON
-	btValues := make([]models.BusinessType, len(businessTypes))
-	for i, bt := range businessTypes {
-		btValues[i] = *bt
+	page, err := h.references.ListBusinessTypes(r.Context(), query)
+	if err != nil {
+		if service.IsValidationError(err) {
+			writeServiceError(w, r, err)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error getting business types", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	var response interface{} = page
+	if query.All {
+		response = page.Items
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(btValues); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -177,12 +546,19 @@ func (h *Handlers) GetBusinessTypes(w http.ResponseWriter, r *http.Request) {
 // Эндпоинт: GET /regions
 //
 // @Summary      Получить список регионов
-// @Description  Возвращает все доступные регионы из справочника с поддержкой иерархии
+// @Description  Возвращает страницу регионов из справочника с общим количеством подходящих записей. Записи отбираются по началу имени и родительскому региону (для обхода иерархии) и сортируются по имени или ID. Без limit и offset возвращается массив всех отобранных записей, как в прежних версиях API.
 // @Tags         regions
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.Region
+// @Param        limit      query     integer  false  "Размер страницы: по умолчанию 100, больше 1000 — ошибка 400"
+// @Param        offset     query     integer  false  "Число пропускаемых записей"
+// @Param        prefix     query     string   false  "Начало имени без учета регистра"
+// @Param        parent_id  query     integer  false  "ID родительского региона; 0 — регионы верхнего уровня"
+// @Param        sort       query     string   false  "Сортировка: name, -name, id, -id (по умолчанию name)"
+// @Success      200  {object}  models.RegionPage  "Страница, если передан limit или offset; без них — массив models.Region всех отобранных записей (схема в x-unpaged-response)"
+// @Failure      400  {object}  map[string]string  "Неверные параметры выборки"
 // @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @x-unpaged-response {"description": "Ответ без limit и offset: массив всех отобранных записей", "schema": {"type": "array", "items": {"$ref": "#/definitions/github_com_akozadaev_go_es_analytical_system_internal_models.Region"}}}
 // @Router       /regions [get]
 func (h *Handlers) GetRegions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -190,41 +566,172 @@ func (h *Handlers) GetRegions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	regions, err := h.pgStorage.GetRegions(r.Context())
+	query, err := parseReferenceQuery(r, "parent_id")
 	if err != nil {
-		log.Printf("Error getting regions: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Клиенты прежних версий API запрашивают справочник без limit и offset и ожидают массив
+	query.All = !r.URL.Query().Has("limit") && !r.URL.Query().Has("offset")
 
-	// Преобразуем указатели в значения для JSON
-	regionValues := make([]models.Region, len(regions))
-	for i, r := range regions {
-		regionValues[i] = *r
+	page, err := h.references.ListRegions(r.Context(), query)
+	if err != nil {
+		if service.IsValidationError(err) {
+			writeServiceError(w, r, err)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error getting regions", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	var response interface{} = page
+	if query.All {
+		response = page.Items
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(regionValues); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// parseReferenceQuery разбирает параметры постраничной выборки справочника; ID родителя
+// передается параметром parentParam.
+func parseReferenceQuery(r *http.Request, parentParam string) (models.ReferenceQuery, error) {
+	values := r.URL.Query()
+	query := models.ReferenceQuery{
+		Prefix: values.Get("prefix"),
+		Sort:   values.Get("sort"),
+	}
+	var err error
+	if query.Limit, err = queryInt(values.Get("limit")); err != nil {
+		return query, errors.New("Invalid limit")
+	}
+	if query.Offset, err = queryInt(values.Get("offset")); err != nil {
+		return query, errors.New("Invalid offset")
+	}
+	if value := values.Get(parentParam); value != "" {
+		parentID, err := strconv.Atoi(value)
+		if err != nil {
+			return query, errors.New("Invalid " + parentParam)
+		}
+		query.ParentID = &parentID
+	}
+	return query, nil
+}
+
+// Статусы ответа проверки здоровья.
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"  // Недоступна хотя бы одна зависимость
+	HealthStatusNotReady = "not_ready" // Экземпляр не готов принимать запросы
+)
+
+// HealthResponse представляет ответ проверки здоровья. Для зависимостей возвращаются только
+// статусы проверок: сообщения проверок (адреса и ошибки хранилищ) пишутся в лог.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty" example:"elasticsearch:ok,postgres:ok"` // Зависимость -> ok, warning или failed
+}
+
 // HealthCheck обрабатывает GET запрос на проверку работоспособности сервиса.
+// Проверяет Elasticsearch (статус кластера и наличие индекса локаций) и PostgreSQL (доступность
+// и примененные миграции) и возвращает статус каждой зависимости. Проверки и их кеш общие
+// с GET /readyz, подробности сбоев пишутся в лог.
 // Используется для мониторинга и проверки доступности API.
 // Эндпоинт: GET /health
 //
 // @Summary      Проверка работоспособности сервиса
-// @Description  Возвращает статус сервиса. Используется для мониторинга и проверки доступности.
+// @Description  Возвращает статус сервиса и статус каждой зависимости (ok, warning или failed) в поле checks: Elasticsearch (статус кластера и наличие индекса локаций) и PostgreSQL (доступность и примененные миграции). Если проверка не пройдена, возвращает 503 со статусом degraded; тексты ошибок не возвращаются, а пишутся в лог сервиса. Статус кластера yellow не считается сбоем. Проверки те же, что у /readyz, и выполняются не чаще раза в секунду; /readyz возвращает только общий статус.
 // @Tags         health
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]string
+// @Success      200  {object}  HealthResponse
+// @Failure      503  {object}  HealthResponse  "Зависимость недоступна"
 // @Router       /health [get]
 func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
-	})
+	response := HealthResponse{Status: HealthStatusOK}
+	if h.health == nil {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	report := h.checkHealth(r.Context())
+	response.Checks = make(map[string]string, len(report.Checks))
+	for _, check := range report.Checks {
+		response.Checks[check.Name] = check.Status
+	}
+	status := http.StatusOK
+	if report.Status == selfcheck.StatusFailed {
+		response.Status = HealthStatusDegraded
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+// Liveness обрабатывает GET запрос проверки живости процесса (liveness probe Kubernetes).
+// Зависимости не проверяются: их недоступность не исправить перезапуском экземпляра.
+// Эндпоинт: GET /healthz
+//
+// @Summary      Проверка живости процесса
+// @Description  Возвращает 200, пока процесс обрабатывает запросы. Зависимости не проверяются, чтобы сбой Elasticsearch или PostgreSQL не приводил к перезапуску всех экземпляров.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  HealthResponse
+// @Router       /healthz [get]
+func (h *Handlers) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: HealthStatusOK})
+}
+
+// Readiness обрабатывает GET запрос проверки готовности экземпляра (readiness probe Kubernetes).
+// Использует те же кешируемые проверки, что и GET /health, но возвращает только общий статус:
+// not_ready вместо degraded.
+// Эндпоинт: GET /readyz
+//
+// @Summary      Проверка готовности экземпляра
+// @Description  Выполняет те же проверки, что и /health (с общим кешем на 1 секунду): кластер Elasticsearch с индексом локаций и PostgreSQL со всеми примененными миграциями. Если экземпляр не готов, возвращает 503 со статусом not_ready: Kubernetes не направляет на него запросы, но не перезапускает. Подробности сбоев пишутся в лог сервиса.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  HealthResponse
+// @Failure      503  {object}  HealthResponse  "Экземпляр не готов"
+// @Router       /readyz [get]
+func (h *Handlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: HealthStatusOK}
+	if h.health == nil {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	status := http.StatusOK
+	if h.checkHealth(r.Context()).Status == selfcheck.StatusFailed {
+		response.Status = HealthStatusNotReady
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+// checkHealth возвращает отчет проверок зависимостей из кеша и пишет в лог подробности
+// неуспешного результата, когда проверки выполнены заново.
+func (h *Handlers) checkHealth(ctx context.Context) *selfcheck.Report {
+	report, fresh := h.health.Cached(ctx)
+	if fresh && report.Status != selfcheck.StatusOK {
+		slog.WarnContext(ctx, "Health check "+report.Status, slog.Any("checks", report.Checks))
+	}
+	return report
+}
+
+// Version обрабатывает GET запрос на получение сведений о сборке сервиса.
+// Используется для сопоставления поведения сервиса с релизом.
+// Эндпоинт: GET /version
+//
+// @Summary      Версия сервиса
+// @Description  Возвращает семантическую версию, git коммит, дату сборки и версию Go
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  buildinfo.Info
+// @Router       /version [get]
+func (h *Handlers) Version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, buildinfo.Get())
 }
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/selfcheck"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage/memory"
+	"github.com/gorilla/mux"
+)
+
+const testEditorRole = "location-editor"
+
+// testAPI — handlers API на хранилищах в памяти.
+type testAPI struct {
+	router    *mux.Router
+	locations *memory.Locations
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	locations := memory.NewLocations(storage.VectorIndexOptions{})
+	references := memory.NewReferences(
+		[]models.BusinessType{{ID: 1, Name: "cafe"}, {ID: 2, Name: "bakery"}},
+		[]models.Region{{ID: 1, Name: "Moscow"}},
+		[]models.City{{ID: 1, Name: "Moscow", RegionID: 1, Region: "Moscow", Center: models.GeoPoint{Lat: 55.7558, Lon: 37.6173}}},
+	)
+	referenceService := service.NewReferenceService(references, time.Minute)
+
+	locationService := service.NewLocationService(locations, locations, 100)
+	locationService.SetEditorRole(testEditorRole)
+	locationService.SetReferences(referenceService)
+
+	recommendations := service.NewRecommendationService(locations, references, time.Minute, 100, nil, nil, service.RelaxationPolicy{})
+	recommendations.SetReferences(referenceService)
+
+	h := NewHandlers(recommendations, locationService, referenceService, nil, nil, nil)
+	admin := NewAdminHandlers(AdminDeps{Cities: service.NewCityService(references, referenceService)})
+	router := mux.NewRouter()
+	router.HandleFunc("/locations", h.CreateLocation).Methods("POST")
+	router.HandleFunc("/locations/recommend", h.RecommendLocations).Methods("POST")
+	router.HandleFunc("/locations/_mget", h.GetLocations).Methods("POST")
+	router.HandleFunc("/locations/{id}", h.GetLocation).Methods("GET")
+	router.HandleFunc("/admin/cities/{id}", admin.UpdateCity).Methods("PUT")
+
+	return &testAPI{router: router, locations: locations}
+}
+
+// do выполняет запрос от имени principal (nil — без аутентификации) и декодирует ответ 2xx в out.
+func (a *testAPI) do(t *testing.T, principal *auth.Principal, method, path string, body, out interface{}) int {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+
+	if out != nil && rec.Code < 300 {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestGetLocation(t *testing.T) {
+	api := newTestAPI(t)
+	api.locations.Add(false, &models.Location{ID: "loc-1", Name: "Tverskaya 1", Region: "Moscow"})
+
+	var details models.LocationDetails
+	if code := api.do(t, nil, "GET", "/locations/loc-1", nil, &details); code != http.StatusOK {
+		t.Fatalf("GET existing location: status %d, want %d", code, http.StatusOK)
+	}
+	if details.ID != "loc-1" || details.Name != "Tverskaya 1" {
+		t.Errorf("GET existing location: got %+v", details.Location)
+	}
+
+	if code := api.do(t, nil, "GET", "/locations/missing", nil, nil); code != http.StatusNotFound {
+		t.Errorf("GET missing location: status %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestCreateLocation(t *testing.T) {
+	api := newTestAPI(t)
+	viewer := &auth.Principal{Subject: "viewer", Organization: "acme"}
+	editor := &auth.Principal{Subject: "editor", Organization: "acme", Roles: []string{testEditorRole}}
+	location := &models.Location{ID: "loc-1", Name: "Tverskaya 1", Region: "Moscow", BusinessTypesSuitable: []string{"cafe"}}
+
+	if code := api.do(t, viewer, "POST", "/locations", location, nil); code != http.StatusForbidden {
+		t.Errorf("create without editor role: status %d, want %d", code, http.StatusForbidden)
+	}
+
+	var created models.Location
+	if code := api.do(t, editor, "POST", "/locations", location, &created); code != http.StatusCreated {
+		t.Fatalf("create: status %d, want %d", code, http.StatusCreated)
+	}
+	if created.Status != models.LocationPublished || created.CreatedAt.IsZero() {
+		t.Errorf("create: status %q, created_at %v; want published with created_at", created.Status, created.CreatedAt)
+	}
+	if code := api.do(t, editor, "POST", "/locations", location, nil); code != http.StatusConflict {
+		t.Errorf("create duplicate: status %d, want %d", code, http.StatusConflict)
+	}
+
+	unknown := &models.Location{Name: "Arbat 2", Region: "Moscow", BusinessTypesSuitable: []string{"casino"}}
+	if code := api.do(t, editor, "POST", "/locations", unknown, nil); code != http.StatusBadRequest {
+		t.Errorf("create with unknown business type: status %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestGetLocationsHidesDrafts(t *testing.T) {
+	api := newTestAPI(t)
+	api.locations.Add(false,
+		&models.Location{ID: "loc-1", Name: "Tverskaya 1", Region: "Moscow"},
+		&models.Location{ID: "loc-2", Name: "Arbat 2", Region: "Moscow", Status: models.LocationDraft},
+	)
+	api.locations.Add(true, &models.Location{ID: "loc-3", Name: "Pokrovka 3", Region: "Moscow"})
+	viewer := &auth.Principal{Subject: "viewer", Organization: "acme"}
+
+	var response models.MultiGetResponse
+	request := models.MultiGetRequest{IDs: []string{"loc-3", "loc-2", "loc-1"}, IncludeArchived: true}
+	if code := api.do(t, viewer, "POST", "/locations/_mget", request, &response); code != http.StatusOK {
+		t.Fatalf("_mget: status %d, want %d", code, http.StatusOK)
+	}
+
+	var found []string
+	for _, location := range response.Found {
+		found = append(found, location.ID)
+	}
+	if len(found) != 2 || found[0] != "loc-3" || found[1] != "loc-1" {
+		t.Errorf("_mget found %v, want [loc-3 loc-1]", found)
+	}
+	if len(response.Missing) != 1 || response.Missing[0] != "loc-2" {
+		t.Errorf("_mget missing %v, want [loc-2]", response.Missing)
+	}
+}
+
+func TestRecommendLocations(t *testing.T) {
+	api := newTestAPI(t)
+	api.locations.Add(false,
+		&models.Location{ID: "loc-1", Name: "Tverskaya 1", Region: "Moscow", BusinessTypesSuitable: []string{"cafe"}, TrafficScore: 5},
+		&models.Location{ID: "loc-2", Name: "Arbat 2", Region: "Moscow", BusinessTypesSuitable: []string{"cafe"}, TrafficScore: 9},
+		&models.Location{ID: "loc-3", Name: "Pokrovka 3", Region: "Moscow", BusinessTypesSuitable: []string{"cafe"}, TrafficScore: 7},
+		&models.Location{ID: "loc-4", Name: "Nevsky 4", Region: "Moscow", BusinessTypesSuitable: []string{"bakery"}, TrafficScore: 10},
+		&models.Location{ID: "loc-5", Name: "Draft 5", Region: "Moscow", BusinessTypesSuitable: []string{"cafe"}, TrafficScore: 10, Status: models.LocationDraft},
+	)
+
+	var first models.RecommendResponse
+	request := &models.RecommendRequest{Region: "Moscow", BusinessType: "cafe", Limit: 2}
+	if code := api.do(t, nil, "POST", "/locations/recommend", request, &first); code != http.StatusOK {
+		t.Fatalf("recommend: status %d, want %d", code, http.StatusOK)
+	}
+	if got := ids(first.Locations); len(got) != 2 || got[0] != "loc-2" || got[1] != "loc-3" || first.Total != 3 {
+		t.Fatalf("recommend first page: %v of %d, want [loc-2 loc-3] of 3", got, first.Total)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("recommend first page: no next_cursor")
+	}
+
+	var second models.RecommendResponse
+	request = &models.RecommendRequest{Region: "Moscow", BusinessType: "cafe", Limit: 2, Cursor: first.NextCursor}
+	if code := api.do(t, nil, "POST", "/locations/recommend", request, &second); code != http.StatusOK {
+		t.Fatalf("recommend second page: status %d, want %d", code, http.StatusOK)
+	}
+	if got := ids(second.Locations); len(got) != 1 || got[0] != "loc-1" || second.NextCursor != "" {
+		t.Errorf("recommend second page: %v, next_cursor %q; want [loc-1] without next_cursor", got, second.NextCursor)
+	}
+}
+
+func TestRecommendLocationsFromCityCenter(t *testing.T) {
+	api := newTestAPI(t)
+	api.locations.Add(false,
+		&models.Location{ID: "loc-1", Name: "Tverskaya 1", Region: "Moscow", City: "Moscow", BusinessTypesSuitable: []string{"cafe"},
+			TrafficScore: 5, Coordinates: models.GeoPoint{Lat: 55.7558, Lon: 37.6173}},
+		&models.Location{ID: "loc-2", Name: "Krylatskoe 2", Region: "Moscow", City: "Moscow", BusinessTypesSuitable: []string{"cafe"},
+			TrafficScore: 6, Coordinates: models.GeoPoint{Lat: 55.7570, Lon: 37.4080}},
+	)
+	recommend := func(request *models.RecommendRequest) []string {
+		t.Helper()
+		var response models.RecommendResponse
+		if code := api.do(t, nil, "POST", "/locations/recommend", request, &response); code != http.StatusOK {
+			t.Fatalf("recommend: status %d, want %d", code, http.StatusOK)
+		}
+		return ids(response.Locations)
+	}
+	request := &models.RecommendRequest{Region: "Moscow", City: "Moscow", BusinessType: "cafe", Limit: 10}
+
+	// Без origin расстояние по умолчанию считается от центра города из справочника
+	if got := recommend(request); len(got) != 2 || got[0] != "loc-1" {
+		t.Fatalf("recommend from city center: %v, want loc-1 first", got)
+	}
+	disabled := false
+	optOut := &models.RecommendRequest{Region: "Moscow", City: "Moscow", BusinessType: "cafe", Limit: 10, DistanceFromCityCenter: &disabled}
+	if got := recommend(optOut); len(got) != 2 || got[0] != "loc-2" {
+		t.Errorf("recommend without city center: %v, want loc-2 first", got)
+	}
+
+	// Перенос центра города меняет выдачу сразу, а не после истечения кеша
+	city := models.City{Name: "Moscow", RegionID: 1, Center: models.GeoPoint{Lat: 55.7570, Lon: 37.4080}}
+	if code := api.do(t, nil, "PUT", "/admin/cities/1", city, nil); code != http.StatusOK {
+		t.Fatalf("update city: status %d, want %d", code, http.StatusOK)
+	}
+	if got := recommend(request); len(got) != 2 || got[0] != "loc-2" {
+		t.Errorf("recommend after moving city center: %v, want loc-2 first", got)
+	}
+}
+
+func TestRecommendLocationsValidation(t *testing.T) {
+	api := newTestAPI(t)
+
+	var response struct {
+		Violations []struct {
+			Field string `json:"field"`
+		} `json:"violations"`
+	}
+	request := &models.RecommendRequest{Limit: 1000}
+	rec := httptest.NewRecorder()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(request); err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	api.router.ServeHTTP(rec, httptest.NewRequest("POST", "/locations/recommend", &buf))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("recommend invalid request: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode violations: %v", err)
+	}
+	fields := map[string]bool{}
+	for _, violation := range response.Violations {
+		fields[violation.Field] = true
+	}
+	for _, field := range []string{"region", "business_type", "limit"} {
+		if !fields[field] {
+			t.Errorf("recommend invalid request: no violation for %s in %+v", field, response.Violations)
+		}
+	}
+}
+
+func ids(locations []models.Location) []string {
+	result := make([]string, len(locations))
+	for i, location := range locations {
+		result[i] = location.ID
+	}
+	return result
+}
+
+func TestHealthCheck(t *testing.T) {
+	health := selfcheck.New(time.Second)
+	health.Add("elasticsearch", func(ctx context.Context) (string, error) {
+		return "", selfcheck.Warn("cluster status is yellow")
+	})
+	health.Add("postgres", func(ctx context.Context) (string, error) {
+		return "", errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	})
+	h := NewHandlers(nil, nil, nil, nil, nil, health)
+
+	rec := httptest.NewRecorder()
+	h.HealthCheck(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if strings.Contains(rec.Body.String(), "10.0.0.5") {
+		t.Errorf("response exposes check message: %s", rec.Body.String())
+	}
+
+	var response HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := map[string]string{"elasticsearch": selfcheck.StatusWarning, "postgres": selfcheck.StatusFailed}
+	if response.Status != HealthStatusDegraded || !reflect.DeepEqual(response.Checks, want) {
+		t.Errorf("got %+v, want status %s and checks %v", response, HealthStatusDegraded, want)
+	}
+}
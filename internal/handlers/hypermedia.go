@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// halMediaType — тип содержимого ответов с навигационными ссылками (HAL).
+const halMediaType = "application/hal+json"
+
+// halLink — ссылка HAL.
+type halLink struct {
+	Href string `json:"href"`
+}
+
+// halLinks — ссылки ресурса по отношениям (self, notes, similar и т. д.).
+type halLinks map[string]halLink
+
+// hypermediaRequested сообщает, запросил ли клиент ответ со ссылками: параметром
+// ?hypermedia=true или типом application/hal+json в заголовке Accept.
+func hypermediaRequested(r *http.Request) bool {
+	if enabled, _ := strconv.ParseBool(r.URL.Query().Get("hypermedia")); enabled {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), halMediaType)
+}
+
+// writeHAL записывает ответ со ссылками с типом содержимого application/hal+json.
+func writeHAL(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", halMediaType)
+	writeEncoded(w, status, value)
+}
+
+// recommendURL возвращает ссылку GET /api/v1/locations/recommend с параметрами поиска.
+func recommendURL(region, city, businessType string) string {
+	query := url.Values{}
+	query.Set("region", region)
+	if city != "" {
+		query.Set("city", city)
+	}
+	query.Set("business_type", businessType)
+	return models.APIV1Prefix + "/locations/recommend?" + query.Encode()
+}
+
+// requestURL возвращает ссылку на запрос рекомендаций req в виде GET /api/v1/locations/recommend.
+func requestURL(req *models.RecommendRequest) string {
+	query := url.Values{}
+	query.Set("region", req.Region)
+	if req.City != "" {
+		query.Set("city", req.City)
+	}
+	query.Set("business_type", req.BusinessType)
+	if req.Limit > 0 {
+		query.Set("limit", strconv.Itoa(req.Limit))
+	}
+	if req.Origin != nil {
+		query.Set("lat", strconv.FormatFloat(req.Origin.Lat, 'f', -1, 64))
+		query.Set("lon", strconv.FormatFloat(req.Origin.Lon, 'f', -1, 64))
+	}
+	if req.RadiusMeters > 0 {
+		query.Set("radius", strconv.FormatFloat(req.RadiusMeters, 'f', -1, 64))
+	}
+	if req.IncludeArchived {
+		query.Set("include_archived", "true")
+	}
+	if w := req.Weights; w != nil {
+		query.Set("weights", fmt.Sprintf("traffic:%g,competition:%g,demographics:%g,distance:%g",
+			w.Traffic, w.Competition, w.Demographics, w.Distance))
+	}
+	if req.Cursor != "" {
+		query.Set("cursor", req.Cursor)
+	}
+	return models.APIV1Prefix + "/locations/recommend?" + query.Encode()
+}
+
+// locationLinks возвращает ссылки локации: карточку, заметки и поиск похожих локаций —
+// в том же городе для первого подходящего типа бизнеса.
+func locationLinks(loc *models.Location) halLinks {
+	self := models.APIV1Prefix + "/locations/" + url.PathEscape(loc.ID)
+	links := halLinks{
+		"self":  {Href: self},
+		"notes": {Href: self + "/notes"},
+	}
+	if len(loc.BusinessTypesSuitable) > 0 {
+		links["similar"] = halLink{Href: recommendURL(loc.Region, loc.City, loc.BusinessTypesSuitable[0])}
+	}
+	return links
+}
+
+// halLocation — локация со ссылками.
+type halLocation struct {
+	models.Location
+	Links halLinks `json:"_links"`
+}
+
+// halRecommendResponse — выдача рекомендаций со ссылками на запрос и каждую локацию.
+type halRecommendResponse struct {
+	*models.RecommendResponse
+	Locations []halLocation `json:"locations"`
+	Links     halLinks      `json:"_links"`
+}
+
+func newHALRecommendResponse(response *models.RecommendResponse, req *models.RecommendRequest) *halRecommendResponse {
+	locations := make([]halLocation, len(response.Locations))
+	for i := range response.Locations {
+		locations[i] = halLocation{Location: response.Locations[i], Links: locationLinks(&response.Locations[i])}
+	}
+	links := halLinks{"self": {Href: requestURL(req)}}
+	if response.QueryID != "" {
+		links["share"] = halLink{Href: models.APIV1Prefix + "/recommendations/" + response.QueryID + "/share"}
+	}
+	if response.NextCursor != "" {
+		next := *req
+		next.Cursor = response.NextCursor
+		links["next"] = halLink{Href: requestURL(&next)}
+	}
+	return &halRecommendResponse{RecommendResponse: response, Locations: locations, Links: links}
+}
+
+// halLocationDetails — карточка локации со ссылками.
+type halLocationDetails struct {
+	*models.LocationDetails
+	Links halLinks `json:"_links"`
+}
+
+// projectLinks возвращает ссылки проекта: сам проект и поиск локаций по его параметрам.
+func projectLinks(project *models.Project) halLinks {
+	return halLinks{
+		"self":            {Href: models.APIV1Prefix + "/projects/" + url.PathEscape(project.ID)},
+		"recommendations": {Href: recommendURL(project.Region, "", project.BusinessType)},
+	}
+}
+
+// halProject — проект со ссылками.
+type halProject struct {
+	models.Project
+	Links halLinks `json:"_links"`
+}
+
+// halCandidate — кандидат проекта со ссылкой на локацию.
+type halCandidate struct {
+	models.Candidate
+	Links halLinks `json:"_links"`
+}
+
+// halProjectSummary — сводка по проекту со ссылками на проект и локации кандидатов.
+type halProjectSummary struct {
+	*models.ProjectSummary
+	Candidates []halCandidate `json:"candidates"`
+	Links      halLinks       `json:"_links"`
+}
+
+func newHALProjectSummary(summary *models.ProjectSummary) *halProjectSummary {
+	candidates := make([]halCandidate, len(summary.Candidates))
+	for i, c := range summary.Candidates {
+		candidates[i] = halCandidate{Candidate: c, Links: halLinks{
+			"location": {Href: models.APIV1Prefix + "/locations/" + url.PathEscape(c.LocationID)},
+		}}
+	}
+	return &halProjectSummary{ProjectSummary: summary, Candidates: candidates, Links: projectLinks(&summary.Project)}
+}
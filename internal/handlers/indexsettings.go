@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// GetIndexSettings обрабатывает GET запрос на получение настроек размещения индекса.
+// Эндпоинт: GET /admin/index/settings
+//
+// @Summary      Настройки размещения индекса
+// @Description  Возвращает число шардов и реплик и предпочтительные уровни узлов данных (_tier_preference) основного или архивного индекса локаций
+// @Tags         admin
+// @Produce      json
+// @Param        index  query     string  false  "Индекс: locations (по умолчанию) или archive"
+// @Success      200    {object}  models.IndexSettings
+// @Failure      400    {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/index/settings [get]
+func (h *AdminHandlers) GetIndexSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.indexSettings.Get(r.Context(), r.URL.Query().Get("index"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// UpdateIndexSettings обрабатывает PUT запрос на изменение настроек размещения индекса.
+// Эндпоинт: PUT /admin/index/settings
+//
+// @Summary      Изменить настройки размещения индекса
+// @Description  Изменяет число реплик и предпочтительные уровни узлов данных индекса, например "data_warm,data_hot" для переноса устаревающих данных на warm узлы. Поля без значения не меняются, пустой tier_preference возвращает значение по умолчанию кластера. Шарды переносятся кластером в фоне.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        index    query     string                false "Индекс: locations (по умолчанию) или archive"
+// @Param        request  body      models.IndexSettings  true  "Новые настройки"
+// @Success      200      {object}  models.IndexSettings
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/index/settings [put]
+func (h *AdminHandlers) UpdateIndexSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.IndexSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.indexSettings.Update(r.Context(), r.URL.Query().Get("index"), &settings)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
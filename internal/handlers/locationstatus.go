@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// TransitionLocationStatus обрабатывает POST запрос на перевод локации в другое состояние
+// жизненного цикла (draft, published, retired).
+// Эндпоинт: POST /locations/{id}/status
+//
+// @Summary      Изменить состояние локации
+// @Description  Переводит локацию в состояние draft, published или retired и записывает переход в журнал. Доступно редакторам локаций; новое состояние применяется в поиске после доставки через outbox.
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "Идентификатор локации"
+// @Param        request  body      models.LocationStatusRequest   true  "Целевое состояние"
+// @Success      200      {object}  models.LocationStatusTransition
+// @Failure      400      {object}  map[string]string  "Неверный запрос или недопустимый переход"
+// @Failure      403      {object}  map[string]string  "Нет роли редактора"
+// @Failure      404      {object}  map[string]string  "Локация не найдена"
+// @Router       /locations/{id}/status [post]
+func (h *Handlers) TransitionLocationStatus(w http.ResponseWriter, r *http.Request) {
+	var req models.LocationStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	transition, err := h.locations.Transition(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transition)
+}
+
+// ListLocationStatusHistory обрабатывает GET запрос на получение журнала переходов локации
+// между состояниями.
+// Эндпоинт: GET /locations/{id}/status-history
+//
+// @Summary      Журнал состояний локации
+// @Tags         locations
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор локации"
+// @Success      200  {array}   models.LocationStatusTransition
+// @Failure      403  {object}  map[string]string  "Нет роли редактора"
+// @Router       /locations/{id}/status-history [get]
+func (h *Handlers) ListLocationStatusHistory(w http.ResponseWriter, r *http.Request) {
+	transitions, err := h.locations.StatusHistory(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transitions)
+}
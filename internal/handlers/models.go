@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/mlregistry"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListModels обрабатывает GET запрос на получение версий моделей из реестра.
+// Эндпоинт: GET /admin/models
+//
+// @Summary      Версии моделей
+// @Description  Возвращает зарегистрированные версии моделей (reranker, footfall, embedding) с метаданными и признаком активности
+// @Tags         admin
+// @Produce      json
+// @Param        kind  query     string  false  "Вид модели"
+// @Success      200   {array}   models.ModelVersion
+// @Failure      500   {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/models [get]
+func (h *AdminHandlers) ListModels(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.models.List(r.Context(), r.URL.Query().Get("kind"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing model versions", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, versions)
+}
+
+// RegisterModel обрабатывает POST запрос на регистрацию версии модели.
+// Эндпоинт: POST /admin/models
+//
+// @Summary      Зарегистрировать версию модели
+// @Description  Добавляет версию модели в реестр без активации. uri указывает на артефакт: путь к файлу, HTTP(S) URL объекта или адрес сервера моделей.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ModelVersion  true  "Версия модели"
+// @Success      201      {object}  models.ModelVersion
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      409      {object}  map[string]string  "Версия уже зарегистрирована"
+// @Router       /admin/models [post]
+func (h *AdminHandlers) RegisterModel(w http.ResponseWriter, r *http.Request) {
+	var mv models.ModelVersion
+	if err := json.NewDecoder(r.Body).Decode(&mv); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	mv.Active = false
+	mv.ActivatedAt = nil
+
+	err := h.models.Register(r.Context(), &mv)
+	switch {
+	case errors.Is(err, mlregistry.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, mlregistry.ErrExists):
+		http.Error(w, "Model version already exists", http.StatusConflict)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error registering model version", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, mv)
+}
+
+// ActivateModel обрабатывает POST запрос на переключение активной версии модели.
+// Эндпоинт: POST /admin/models/{kind}/{version}/activate
+//
+// @Summary      Активировать версию модели
+// @Description  Загружает версию модели и переключает на нее сервис без перезапуска; другие экземпляры переключаются при проверке активных версий (ACTIVE_VERSION_POLL_SECONDS). Если загрузка не удалась, остается прежняя версия, а причина записывается в лог сервиса.
+// @Tags         admin
+// @Produce      json
+// @Param        kind     path      string  true  "Вид модели"
+// @Param        version  path      string  true  "Версия модели"
+// @Success      200      {object}  models.ModelVersion
+// @Failure      404      {object}  map[string]string  "Версия не найдена"
+// @Failure      422      {object}  map[string]string  "Не удалось загрузить модель"
+// @Router       /admin/models/{kind}/{version}/activate [post]
+func (h *AdminHandlers) ActivateModel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	mv, err := h.models.Activate(r.Context(), vars["kind"], vars["version"])
+	switch {
+	case errors.Is(err, mlregistry.ErrNotFound):
+		http.Error(w, "Model version not found", http.StatusNotFound)
+		return
+	case errors.Is(err, mlregistry.ErrLoad):
+		http.Error(w, "Failed to load model version, see server logs", http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error activating model version", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mv)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// NoteHandlers содержит зависимости для HTTP запросов заметок о локациях.
+type NoteHandlers struct {
+	notes *service.NoteService
+}
+
+// NewNoteHandlers создает новый экземпляр NoteHandlers.
+func NewNoteHandlers(notes *service.NoteService) *NoteHandlers {
+	return &NoteHandlers{notes: notes}
+}
+
+// ListNotes обрабатывает GET запрос на получение заметок организации о локации.
+// Эндпоинт: GET /locations/{id}/notes
+//
+// @Summary      Получить заметки о локации
+// @Description  Возвращает заметки и оценки локации, оставленные пользователями организации клиента
+// @Tags         notes
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор локации"
+// @Success      200  {array}   models.Note
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      403  {object}  map[string]string  "Клиент без организации"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/{id}/notes [get]
+func (h *NoteHandlers) ListNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.notes.List(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(notes); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateNote обрабатывает POST запрос на добавление заметки к локации.
+// Эндпоинт: POST /locations/{id}/notes
+//
+// @Summary      Добавить заметку к локации
+// @Description  Добавляет заметку и/или оценку от 1 до 5 от имени аутентифицированного пользователя
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string              true  "Идентификатор локации"
+// @Param        request  body      models.NoteRequest  true  "Заметка"
+// @Success      201      {object}  models.Note
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      403      {object}  map[string]string  "Клиент без организации"
+// @Failure      404      {object}  map[string]string  "Локация не найдена"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/{id}/notes [post]
+func (h *NoteHandlers) CreateNote(w http.ResponseWriter, r *http.Request) {
+	var req models.NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.notes.Create(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(note); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+	}
+}
+
+// UpdateNote обрабатывает PUT запрос на изменение заметки. Изменять заметку может только автор.
+// Эндпоинт: PUT /locations/{id}/notes/{note_id}
+//
+// @Summary      Изменить заметку
+// @Description  Изменяет текст и оценку заметки автора
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string              true  "Идентификатор локации"
+// @Param        note_id  path      int                 true  "Идентификатор заметки"
+// @Param        request  body      models.NoteRequest  true  "Заметка"
+// @Success      200      {object}  models.Note
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      403      {object}  map[string]string  "Заметка принадлежит другому пользователю или клиент без организации"
+// @Failure      404      {object}  map[string]string  "Заметка не найдена"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/{id}/notes/{note_id} [put]
+func (h *NoteHandlers) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	noteID, err := strconv.ParseInt(vars["note_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.notes.Update(r.Context(), vars["id"], noteID, &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(note); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteNote обрабатывает DELETE запрос на удаление заметки. Удалять заметку может только автор.
+// Эндпоинт: DELETE /locations/{id}/notes/{note_id}
+//
+// @Summary      Удалить заметку
+// @Tags         notes
+// @Param        id       path  string  true  "Идентификатор локации"
+// @Param        note_id  path  int     true  "Идентификатор заметки"
+// @Success      204
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      403  {object}  map[string]string  "Заметка принадлежит другому пользователю или клиент без организации"
+// @Failure      404  {object}  map[string]string  "Заметка не найдена"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /locations/{id}/notes/{note_id} [delete]
+func (h *NoteHandlers) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	noteID, err := strconv.ParseInt(vars["note_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notes.Delete(r.Context(), vars["id"], noteID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/orchestrator"
+	"github.com/gorilla/mux"
+)
+
+// ListPipelines обрабатывает GET запрос на получение списка конвейеров обновления данных.
+// Эндпоинт: GET /admin/pipelines
+//
+// @Summary      Список конвейеров
+// @Description  Возвращает зарегистрированные конвейеры, их шаги и последний запуск
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   orchestrator.PipelineInfo
+// @Router       /admin/pipelines [get]
+func (h *AdminHandlers) ListPipelines(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.pipelines.Pipelines())
+}
+
+// RunPipeline обрабатывает POST запрос на ручной запуск конвейера.
+// Эндпоинт: POST /admin/pipelines/{name}/runs
+//
+// @Summary      Запустить конвейер
+// @Description  Запускает конвейер вне расписания. Состояние шагов доступно через GET /admin/pipelines/runs/{id}.
+// @Tags         admin
+// @Produce      json
+// @Param        name  path      string  true  "Имя конвейера"
+// @Success      202   {object}  orchestrator.Run
+// @Failure      404   {object}  map[string]string  "Конвейер не найден"
+// @Failure      409   {object}  map[string]string  "Конвейер уже выполняется на этом или другом экземпляре"
+// @Router       /admin/pipelines/{name}/runs [post]
+func (h *AdminHandlers) RunPipeline(w http.ResponseWriter, r *http.Request) {
+	run, err := h.pipelines.Trigger(mux.Vars(r)["name"], "manual")
+	switch {
+	case errors.Is(err, orchestrator.ErrUnknownPipeline):
+		http.Error(w, "Pipeline not found", http.StatusNotFound)
+		return
+	case errors.Is(err, orchestrator.ErrAlreadyRunning):
+		http.Error(w, "Pipeline is already running", http.StatusConflict)
+		return
+	case errors.Is(err, lock.ErrHeld):
+		http.Error(w, "Pipeline is running on another instance", http.StatusConflict)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error starting pipeline", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, run)
+}
+
+// ListPipelineRuns обрабатывает GET запрос на получение истории запусков конвейера.
+// Эндпоинт: GET /admin/pipelines/{name}/runs
+//
+// @Summary      История запусков конвейера
+// @Description  Возвращает последние запуски конвейера, начиная с самого нового
+// @Tags         admin
+// @Produce      json
+// @Param        name  path      string  true  "Имя конвейера"
+// @Success      200   {array}   orchestrator.Run
+// @Failure      404   {object}  map[string]string  "Конвейер не найден"
+// @Router       /admin/pipelines/{name}/runs [get]
+func (h *AdminHandlers) ListPipelineRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.pipelines.Runs(mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, "Pipeline not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// GetPipelineRun обрабатывает GET запрос на получение состояния запуска конвейера.
+// Эндпоинт: GET /admin/pipelines/runs/{id}
+//
+// @Summary      Состояние запуска конвейера
+// @Description  Возвращает статус, число попыток и ошибки каждого шага запуска
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор запуска"
+// @Success      200  {object}  orchestrator.Run
+// @Failure      404  {object}  map[string]string  "Запуск не найден"
+// @Router       /admin/pipelines/runs/{id} [get]
+func (h *AdminHandlers) GetPipelineRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.pipelines.GetRun(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
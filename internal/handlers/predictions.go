@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+)
+
+// PredictionHandlers содержит зависимости для HTTP запросов прогнозных моделей.
+type PredictionHandlers struct {
+	footfall *service.FootfallService
+}
+
+// NewPredictionHandlers создает новый экземпляр PredictionHandlers.
+func NewPredictionHandlers(footfall *service.FootfallService) *PredictionHandlers {
+	return &PredictionHandlers{footfall: footfall}
+}
+
+// PredictFootfall обрабатывает POST запрос на прогноз посещаемости локации.
+// Эндпоинт: POST /predict/footfall
+//
+// @Summary      Прогноз посещаемости
+// @Description  Прогнозирует ожидаемое число посетителей в день для локации из индекса (location_id) или кандидата, описанного признаками (location), и типа бизнеса
+// @Tags         predictions
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.FootfallRequest  true  "Локация и тип бизнеса"
+// @Success      200      {object}  models.FootfallPrediction
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      404      {object}  map[string]string  "Локация не найдена"
+// @Failure      503      {object}  map[string]string  "Модель прогноза не настроена"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /predict/footfall [post]
+func (h *PredictionHandlers) PredictFootfall(w http.ResponseWriter, r *http.Request) {
+	var req models.FootfallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	prediction, err := h.footfall.Predict(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prediction)
+}
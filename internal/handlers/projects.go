@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// ProjectHandlers содержит зависимости для HTTP запросов проектов подбора локаций.
+type ProjectHandlers struct {
+	projects *service.ProjectService
+}
+
+// NewProjectHandlers создает новый экземпляр ProjectHandlers.
+func NewProjectHandlers(projects *service.ProjectService) *ProjectHandlers {
+	return &ProjectHandlers{projects: projects}
+}
+
+// ListProjects обрабатывает GET запрос на получение проектов организации.
+// Эндпоинт: GET /projects
+//
+// @Summary      Получить проекты
+// @Description  Возвращает проекты подбора локаций организации клиента
+// @Tags         projects
+// @Produce      json
+// @Success      200  {array}   models.Project
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects [get]
+func (h *ProjectHandlers) ListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.projects.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if hypermediaRequested(r) {
+		linked := make([]halProject, len(projects))
+		for i := range projects {
+			linked[i] = halProject{Project: projects[i], Links: projectLinks(&projects[i])}
+		}
+		writeHAL(w, http.StatusOK, linked)
+		return
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// CreateProject обрабатывает POST запрос на создание проекта.
+// Эндпоинт: POST /projects
+//
+// @Summary      Создать проект
+// @Description  Создает проект подбора локаций для типа бизнеса в регионе с необязательным бюджетом
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ProjectRequest  true  "Параметры проекта"
+// @Success      201      {object}  models.Project
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects [post]
+func (h *ProjectHandlers) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var req models.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.projects.Create(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, project)
+}
+
+// GetProject обрабатывает GET запрос на получение сводки по проекту.
+// Эндпоинт: GET /projects/{id}
+//
+// @Summary      Получить сводку по проекту
+// @Description  Возвращает проект, его кандидатов с данными локаций и количество кандидатов по статусам
+// @Tags         projects
+// @Produce      json
+// @Param        id   path      string  true  "Идентификатор проекта"
+// @Success      200  {object}  models.ProjectSummary
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404  {object}  map[string]string  "Проект не найден"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects/{id} [get]
+func (h *ProjectHandlers) GetProject(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.projects.Summary(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if hypermediaRequested(r) {
+		writeHAL(w, http.StatusOK, newHALProjectSummary(summary))
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// UpdateProject обрабатывает PUT запрос на изменение параметров проекта.
+// Эндпоинт: PUT /projects/{id}
+//
+// @Summary      Изменить проект
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true  "Идентификатор проекта"
+// @Param        request  body      models.ProjectRequest  true  "Параметры проекта"
+// @Success      200      {object}  models.Project
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404      {object}  map[string]string  "Проект не найден"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects/{id} [put]
+func (h *ProjectHandlers) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	var req models.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.projects.Update(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, project)
+}
+
+// DeleteProject обрабатывает DELETE запрос на удаление проекта. Удалить проект может только владелец.
+// Эндпоинт: DELETE /projects/{id}
+//
+// @Summary      Удалить проект
+// @Tags         projects
+// @Param        id   path  string  true  "Идентификатор проекта"
+// @Success      204
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      403  {object}  map[string]string  "Проект принадлежит другому пользователю"
+// @Failure      404  {object}  map[string]string  "Проект не найден"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects/{id} [delete]
+func (h *ProjectHandlers) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	if err := h.projects.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddCandidate обрабатывает POST запрос на добавление локации в проект.
+// Эндпоинт: POST /projects/{id}/candidates
+//
+// @Summary      Добавить кандидата в проект
+// @Description  Добавляет локацию в шорт-лист проекта; статус по умолчанию — reviewing
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "Идентификатор проекта"
+// @Param        request  body      models.CandidateRequest  true  "Кандидат"
+// @Success      201      {object}  models.Candidate
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404      {object}  map[string]string  "Проект или локация не найдены"
+// @Failure      409      {object}  map[string]string  "Локация уже добавлена"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects/{id}/candidates [post]
+func (h *ProjectHandlers) AddCandidate(w http.ResponseWriter, r *http.Request) {
+	var req models.CandidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	candidate, err := h.projects.AddCandidate(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, candidate)
+}
+
+// UpdateCandidate обрабатывает PUT запрос на изменение статуса кандидата.
+// Эндпоинт: PUT /projects/{id}/candidates/{location_id}
+//
+// @Summary      Изменить статус кандидата
+// @Description  Изменяет статус (reviewing, visited, rejected, selected) и комментарий кандидата
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                   true  "Идентификатор проекта"
+// @Param        location_id  path      string                   true  "Идентификатор локации"
+// @Param        request      body      models.CandidateRequest  true  "Статус кандидата"
+// @Success      200          {object}  models.Candidate
+// @Failure      400          {object}  map[string]string  "Неверный запрос"
+// @Failure      401          {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404          {object}  map[string]string  "Проект или кандидат не найдены"
+// @Failure      500          {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects/{id}/candidates/{location_id} [put]
+func (h *ProjectHandlers) UpdateCandidate(w http.ResponseWriter, r *http.Request) {
+	var req models.CandidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	candidate, err := h.projects.UpdateCandidate(r.Context(), vars["id"], vars["location_id"], &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, candidate)
+}
+
+// RemoveCandidate обрабатывает DELETE запрос на удаление локации из проекта.
+// Эндпоинт: DELETE /projects/{id}/candidates/{location_id}
+//
+// @Summary      Удалить кандидата из проекта
+// @Tags         projects
+// @Param        id           path  string  true  "Идентификатор проекта"
+// @Param        location_id  path  string  true  "Идентификатор локации"
+// @Success      204
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404  {object}  map[string]string  "Проект или кандидат не найдены"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /projects/{id}/candidates/{location_id} [delete]
+func (h *ProjectHandlers) RemoveCandidate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.projects.RemoveCandidate(r.Context(), vars["id"], vars["location_id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+)
+
+// RankingOverrideHandlers содержит зависимости для HTTP запросов ранжирования по умолчанию.
+type RankingOverrideHandlers struct {
+	overrides *service.RankingOverrideService
+}
+
+// NewRankingOverrideHandlers создает новый экземпляр RankingOverrideHandlers.
+func NewRankingOverrideHandlers(overrides *service.RankingOverrideService) *RankingOverrideHandlers {
+	return &RankingOverrideHandlers{overrides: overrides}
+}
+
+// GetRankingOverride обрабатывает GET запрос на получение ранжирования по умолчанию.
+// Эндпоинт: GET /ranking-overrides
+//
+// @Summary      Получить ранжирование по умолчанию
+// @Description  Возвращает веса факторов и бусты полей, применяемые к запросам рекомендаций организации или API ключа клиента
+// @Tags         ranking-overrides
+// @Produce      json
+// @Param        scope  query     string  false  "Область: organization (по умолчанию) или key"
+// @Success      200    {object}  models.RankingOverride
+// @Failure      400    {object}  map[string]string  "Неверная область"
+// @Failure      401    {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404    {object}  map[string]string  "Ранжирование по умолчанию не задано"
+// @Failure      500    {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /ranking-overrides [get]
+func (h *RankingOverrideHandlers) GetRankingOverride(w http.ResponseWriter, r *http.Request) {
+	override, err := h.overrides.Get(r.Context(), r.URL.Query().Get("scope"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, override)
+}
+
+// PutRankingOverride обрабатывает PUT запрос на сохранение ранжирования по умолчанию.
+// Эндпоинт: PUT /ranking-overrides
+//
+// @Summary      Сохранить ранжирование по умолчанию
+// @Description  Сохраняет веса факторов (для запросов без weights) и бусты полей поверх профиля ранжирования типа бизнеса. Значения API ключа приоритетнее значений организации.
+// @Tags         ranking-overrides
+// @Accept       json
+// @Produce      json
+// @Param        scope    query     string                  false  "Область: organization (по умолчанию) или key"
+// @Param        request  body      models.RankingOverride  true   "Веса и бусты"
+// @Success      200      {object}  models.RankingOverride
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /ranking-overrides [put]
+func (h *RankingOverrideHandlers) PutRankingOverride(w http.ResponseWriter, r *http.Request) {
+	var override models.RankingOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.overrides.Put(r.Context(), r.URL.Query().Get("scope"), &override); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, override)
+}
+
+// DeleteRankingOverride обрабатывает DELETE запрос на удаление ранжирования по умолчанию.
+// Эндпоинт: DELETE /ranking-overrides
+//
+// @Summary      Удалить ранжирование по умолчанию
+// @Tags         ranking-overrides
+// @Param        scope  query  string  false  "Область: organization (по умолчанию) или key"
+// @Success      204
+// @Failure      400  {object}  map[string]string  "Неверная область"
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      404  {object}  map[string]string  "Ранжирование по умолчанию не задано"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /ranking-overrides [delete]
+func (h *RankingOverrideHandlers) DeleteRankingOverride(w http.ResponseWriter, r *http.Request) {
+	if err := h.overrides.Delete(r.Context(), r.URL.Query().Get("scope")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
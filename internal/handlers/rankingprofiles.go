@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListRankingProfiles обрабатывает GET запрос на получение профилей ранжирования типов бизнеса.
+// Эндпоинт: GET /admin/ranking-profiles
+//
+// @Summary      Профили ранжирования
+// @Description  Возвращает профили ранжирования типов бизнеса: бустинг числовых полей локации и веса векторного поиска
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.RankingProfile
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/ranking-profiles [get]
+func (h *AdminHandlers) ListRankingProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.rankingProfiles.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+// PutRankingProfile обрабатывает PUT запрос на замену профиля ранжирования типа бизнеса.
+// Эндпоинт: PUT /admin/ranking-profiles/{business_type}
+//
+// @Summary      Сохранить профиль ранжирования
+// @Description  Заменяет бусты числовых полей и веса векторного поиска типа бизнеса. Пустой список boosts удаляет бусты, отсутствие vector_weights — веса (используются равные).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        business_type  path      string                 true  "Тип бизнеса"
+// @Param        request        body      models.RankingProfile  true  "Профиль ранжирования"
+// @Success      200            {object}  models.RankingProfile
+// @Failure      400            {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/ranking-profiles/{business_type} [put]
+func (h *AdminHandlers) PutRankingProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.RankingProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	profile.BusinessType = mux.Vars(r)["business_type"]
+
+	if err := h.rankingProfiles.Save(r.Context(), &profile); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
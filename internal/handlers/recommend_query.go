@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// parseRecommendQuery собирает запрос рекомендаций из параметров строки запроса.
+// Имена параметров совпадают с полями JSON тела POST /locations/recommend; точка отсчета
+// задается параметрами lat и lon, радиус — параметром radius (или radius_meters), область карты —
+// параметром bbox, веса факторов — параметром weights, следующая страница — параметром cursor.
+func parseRecommendQuery(query url.Values) (*models.RecommendRequest, error) {
+	req := &models.RecommendRequest{
+		Region:       query.Get("region"),
+		City:         query.Get("city"),
+		BusinessType: query.Get("business_type"),
+		Cursor:       query.Get("cursor"),
+		Intent:       query.Get("intent"),
+	}
+
+	ints := []struct {
+		name   string
+		target *int
+	}{
+		{"limit", &req.Limit},
+		{"target_month", &req.TargetMonth},
+		{"min_results", &req.MinResults},
+	}
+	for _, p := range ints {
+		if value := query.Get(p.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer", p.name)
+			}
+			*p.target = parsed
+		}
+	}
+
+	floats := []struct {
+		name   string
+		target *float64
+	}{
+		{"radius", &req.RadiusMeters},
+		{"radius_meters", &req.RadiusMeters},
+		{"min_distance_meters", &req.MinDistanceMeters},
+		{"max_travel_minutes", &req.MaxTravelMinutes},
+		{"min_parking_score", &req.MinParkingScore},
+		{"min_safety", &req.MinSafety},
+		{"safety_weight", &req.SafetyWeight},
+		{"footfall_weight", &req.FootfallWeight},
+		{"intent_weight", &req.IntentWeight},
+	}
+	for _, p := range floats {
+		if value := query.Get(p.name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", p.name)
+			}
+			*p.target = parsed
+		}
+	}
+
+	bools := []struct {
+		name   string
+		target *bool
+	}{
+		{"include_archived", &req.IncludeArchived},
+		{"has_parking", &req.HasParking},
+		{"autocorrect", &req.AutoCorrect},
+	}
+	for _, p := range bools {
+		if value := query.Get(p.name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a boolean", p.name)
+			}
+			*p.target = parsed
+		}
+	}
+	// Флаги, у которых отсутствие значения отличается от false
+	optionalBools := []struct {
+		name   string
+		target **bool
+	}{
+		{"event_boost", &req.EventBoost},
+		{"distance_from_city_center", &req.DistanceFromCityCenter},
+	}
+	for _, p := range optionalBools {
+		if value := query.Get(p.name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a boolean", p.name)
+			}
+			*p.target = &parsed
+		}
+	}
+
+	// Момент расчета демографии: RFC 3339 или дата (начало суток UTC)
+	if value := query.Get("as_of"); value != "" {
+		asOf, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			asOf, err = time.Parse(time.DateOnly, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("as_of must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		}
+		req.AsOf = &asOf
+	}
+
+	// Веса факторов ранжирования: weights=traffic:0.5,competition:0.3,demographics:0.2,distance:0.3
+	if weights := query.Get("weights"); weights != "" {
+		req.Weights = &models.ScoringWeights{}
+		targets := map[string]*float64{
+			"traffic":      &req.Weights.Traffic,
+			"competition":  &req.Weights.Competition,
+			"demographics": &req.Weights.Demographics,
+			"distance":     &req.Weights.Distance,
+		}
+		for _, part := range strings.Split(weights, ",") {
+			name, value, _ := strings.Cut(part, ":")
+			target, ok := targets[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("weights must be traffic, competition, demographics and distance, e.g. traffic:0.5,competition:0.3")
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("weight %s must be a number", strings.TrimSpace(name))
+			}
+			*target = parsed
+		}
+	}
+
+	lat, lon := query.Get("lat"), query.Get("lon")
+	if lat != "" || lon != "" {
+		latValue, latErr := strconv.ParseFloat(lat, 64)
+		lonValue, lonErr := strconv.ParseFloat(lon, 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("lat and lon must both be numbers")
+		}
+		req.Origin = &models.GeoPoint{Lat: latValue, Lon: lonValue}
+	}
+
+	// Область карты в порядке GeoJSON: запад, юг, восток, север
+	if bbox := query.Get("bbox"); bbox != "" {
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("bbox must be west,south,east,north")
+		}
+		var edges [4]float64
+		for i, part := range parts {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("bbox must be west,south,east,north")
+			}
+			edges[i] = parsed
+		}
+		req.BoundingBox = &models.GeoBoundingBox{
+			TopLeft:     models.GeoPoint{Lat: edges[3], Lon: edges[0]},
+			BottomRight: models.GeoPoint{Lat: edges[1], Lon: edges[2]},
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/refdata"
+)
+
+// ExportReferenceData обрабатывает GET запрос на выгрузку справочников типов бизнеса, регионов и городов.
+// Эндпоинт: GET /admin/reference-data
+//
+// @Summary      Выгрузить справочники
+// @Description  Возвращает справочники типов бизнеса, регионов и городов целиком в формате JSON или CSV для загрузки в другое окружение. Родители записей и регионы городов указываются по именам (для регионов — путям), а не по ID.
+// @Tags         admin
+// @Produce      json
+// @Produce      text/csv
+// @Param        format  query     string  false  "Формат выгрузки: json (по умолчанию) или csv"
+// @Success      200     {object}  github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceData
+// @Failure      400     {object}  map[string]string  "Неверный формат"
+// @Failure      500     {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/reference-data [get]
+func (h *AdminHandlers) ExportReferenceData(w http.ResponseWriter, r *http.Request) {
+	format := refdata.FormatJSON
+	if value := r.URL.Query().Get("format"); value != "" {
+		format = refdata.Format(value)
+	}
+	if !format.Valid() {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.referenceData.Export(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// Выгрузка формируется целиком, чтобы ошибка кодирования не обрывала ответ со статусом 200
+	var buf bytes.Buffer
+	if err := refdata.Write(&buf, format, data); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding reference data", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=reference-data.%s", format))
+	w.Write(buf.Bytes())
+}
+
+// ImportReferenceData обрабатывает POST запрос на загрузку справочников из выгрузки.
+// Эндпоинт: POST /admin/reference-data
+//
+// @Summary      Загрузить справочники
+// @Description  Загружает справочники из выгрузки GET /admin/reference-data (JSON или CSV) и возвращает изменения: добавленные, измененные и отсутствующие в выгрузке записи, а с prune=true и каскадные изменения (удаляемые связи замещаемости, подтипы без категории). С prune=true записи, отсутствующие в выгрузке, удаляются. Изменения рассчитываются в транзакции загрузки; с dry_run=true она откатывается.
+// @Tags         admin
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Param        format   query     string  false  "Формат тела: json или csv (по умолчанию определяется по Content-Type)"
+// @Param        dry_run  query     bool    false  "Только рассчитать изменения"
+// @Param        prune    query     bool    false  "Удалить записи, отсутствующие в выгрузке"
+// @Success      200      {object}  github_com_akozadaev_go_es_analytical_system_internal_models.ReferenceDiff
+// @Failure      400      {object}  map[string]string  "Неверная выгрузка"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/reference-data [post]
+func (h *AdminHandlers) ImportReferenceData(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := refdata.FormatJSON
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		format = refdata.FormatCSV
+	}
+	if value := query.Get("format"); value != "" {
+		format = refdata.Format(value)
+	}
+	if !format.Valid() {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(query.Get("dry_run"))
+	prune, _ := strconv.ParseBool(query.Get("prune"))
+
+	data, err := refdata.Read(r.Body, format)
+	if err != nil {
+		http.Error(w, "Invalid reference data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.referenceData.Import(r.Context(), data, dryRun, prune)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListRuntimeFields обрабатывает GET запрос на получение вычисляемых полей индекса локаций.
+// Эндпоинт: GET /admin/runtime-fields
+//
+// @Summary      Вычисляемые поля
+// @Description  Возвращает вычисляемые поля (runtime fields) маппинга индекса локаций
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.RuntimeField
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/runtime-fields [get]
+func (h *AdminHandlers) ListRuntimeFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := h.runtimeFields.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fields)
+}
+
+// GetRuntimeField обрабатывает GET запрос на получение вычисляемого поля.
+// Эндпоинт: GET /admin/runtime-fields/{name}
+//
+// @Summary      Вычисляемое поле
+// @Description  Возвращает определение вычисляемого поля; для числовых полей — со статистикой значений по индексу (count, min, max, avg)
+// @Tags         admin
+// @Produce      json
+// @Param        name  path      string  true  "Имя поля"
+// @Success      200   {object}  models.RuntimeField
+// @Failure      400   {object}  map[string]string  "Скрипт поля завершается ошибкой на документах индекса"
+// @Failure      404   {object}  map[string]string  "Поле не найдено"
+// @Router       /admin/runtime-fields/{name} [get]
+func (h *AdminHandlers) GetRuntimeField(w http.ResponseWriter, r *http.Request) {
+	field, err := h.runtimeFields.Get(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, field)
+}
+
+// PutRuntimeField обрабатывает PUT запрос на создание или изменение вычисляемого поля.
+// Эндпоинт: PUT /admin/runtime-fields/{name}
+//
+// @Summary      Сохранить вычисляемое поле
+// @Description  Добавляет вычисляемое поле в маппинг основного и архивного индексов локаций или заменяет его определение. Поле сразу доступно в фильтре field_ranges запросов рекомендаций без переиндексации. OpenSearch вычисляемые поля не поддерживает (400).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        name     path      string               true  "Имя поля"
+// @Param        request  body      models.RuntimeField  true  "Определение поля (type, script)"
+// @Success      200      {object}  models.RuntimeField
+// @Failure      400      {object}  map[string]string  "Некорректное определение, ошибка компиляции скрипта или кластер OpenSearch"
+// @Router       /admin/runtime-fields/{name} [put]
+func (h *AdminHandlers) PutRuntimeField(w http.ResponseWriter, r *http.Request) {
+	var field models.RuntimeField
+	if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	field.Name = mux.Vars(r)["name"]
+	field.Stats = nil
+
+	saved, err := h.runtimeFields.Put(r.Context(), &field)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DeleteRuntimeField обрабатывает DELETE запрос на удаление вычисляемого поля.
+// Эндпоинт: DELETE /admin/runtime-fields/{name}
+//
+// @Summary      Удалить вычисляемое поле
+// @Tags         admin
+// @Param        name  path  string  true  "Имя поля"
+// @Success      204
+// @Failure      404  {object}  map[string]string  "Поле не найдено"
+// @Router       /admin/runtime-fields/{name} [delete]
+func (h *AdminHandlers) DeleteRuntimeField(w http.ResponseWriter, r *http.Request) {
+	if err := h.runtimeFields.Delete(r.Context(), mux.Vars(r)["name"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
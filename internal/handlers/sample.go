@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// GetSample обрабатывает GET запрос на получение воспроизводимой случайной выборки локаций
+// для экспериментов в ноутбуках (Jupyter).
+// Эндпоинт: GET /export/sample
+//
+// @Summary      Случайная выборка локаций
+// @Description  Возвращает n случайных локаций, подходящих под фильтр. Каждой локации сопоставляется псевдослучайный ключ random_score из seed и id, в выборку входят локации с наибольшими ключами, поэтому повторный запрос с тем же seed возвращает ту же выборку, пока не изменились данные индекса. Без seed он выбирается случайно и возвращается в ответе. С stratify_by объем распределяется между стратами (регионами или типами бизнеса) пропорционально их численности методом наибольших остатков; страты типов бизнеса пересекаются, и локация может попасть в несколько из них. Поле embedding не возвращается.
+// @Tags         export
+// @Produce      json
+// @Param        n              query     integer  false  "Объем выборки (по умолчанию 100, не более 10000)"
+// @Param        seed           query     integer  false  "Seed генератора"
+// @Param        stratify_by    query     string   false  "region или business_type"
+// @Param        region         query     string   false  "Регион"
+// @Param        city           query     string   false  "Город"
+// @Param        business_type  query     string   false  "Тип бизнеса"
+// @Success      200            {object}  models.SampleResponse
+// @Failure      400            {object}  map[string]string  "Неверный запрос"
+// @Failure      500            {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /export/sample [get]
+func (h *Handlers) GetSample(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	n, err := queryInt(query.Get("n"))
+	if err != nil {
+		http.Error(w, "Invalid n", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.SampleRequest{
+		Region:       query.Get("region"),
+		City:         query.Get("city"),
+		BusinessType: query.Get("business_type"),
+		N:            n,
+		StratifyBy:   models.SampleStratify(query.Get("stratify_by")),
+	}
+	if value := query.Get("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid seed", http.StatusBadRequest)
+			return
+		}
+		req.Seed = &seed
+	}
+
+	response, err := h.locations.Sample(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
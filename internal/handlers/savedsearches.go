@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// SavedSearchHandlers содержит зависимости для HTTP запросов сохраненных поисков.
+type SavedSearchHandlers struct {
+	savedSearches *service.SavedSearchService
+}
+
+// NewSavedSearchHandlers создает новый экземпляр SavedSearchHandlers.
+func NewSavedSearchHandlers(savedSearches *service.SavedSearchService) *SavedSearchHandlers {
+	return &SavedSearchHandlers{savedSearches: savedSearches}
+}
+
+// ListSavedSearches обрабатывает GET запрос на получение сохраненных поисков организации.
+// Эндпоинт: GET /saved-searches
+//
+// @Summary      Получить сохраненные поиски
+// @Description  Возвращает сохраненные поиски организации клиента
+// @Tags         saved-searches
+// @Produce      json
+// @Success      200  {array}   models.SavedSearch
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /saved-searches [get]
+func (h *SavedSearchHandlers) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.savedSearches.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, searches)
+}
+
+// CreateSavedSearch обрабатывает POST запрос на создание сохраненного поиска.
+// Эндпоинт: POST /saved-searches
+//
+// @Summary      Сохранить поиск
+// @Description  Сохраняет фильтры запроса рекомендаций (регион, город, тип бизнеса, гео- и прочие фильтры). Когда новая или измененная локация подходит под фильтры, уведомление отправляется POST запросом на webhook_url.
+// @Tags         saved-searches
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.SavedSearchRequest  true  "Сохраненный поиск"
+// @Success      201      {object}  models.SavedSearch
+// @Failure      400      {object}  map[string]string  "Неверный запрос"
+// @Failure      401      {object}  map[string]string  "Требуется аутентификация"
+// @Failure      500      {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /saved-searches [post]
+func (h *SavedSearchHandlers) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req models.SavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	search, err := h.savedSearches.Create(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, search)
+}
+
+// DeleteSavedSearch обрабатывает DELETE запрос на удаление сохраненного поиска.
+// Удалить поиск может только владелец.
+// Эндпоинт: DELETE /saved-searches/{id}
+//
+// @Summary      Удалить сохраненный поиск
+// @Tags         saved-searches
+// @Param        id   path  string  true  "Идентификатор сохраненного поиска"
+// @Success      204
+// @Failure      401  {object}  map[string]string  "Требуется аутентификация"
+// @Failure      403  {object}  map[string]string  "Поиск принадлежит другому пользователю"
+// @Failure      404  {object}  map[string]string  "Поиск не найден"
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /saved-searches/{id} [delete]
+func (h *SavedSearchHandlers) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	if err := h.savedSearches.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListSearchTemplates обрабатывает GET запрос на получение версий шаблона поиска рекомендаций.
+// Эндпоинт: GET /admin/search-templates
+//
+// @Summary      Версии шаблона поиска
+// @Description  Возвращает версии шаблона поиска рекомендаций (mustache) с признаком активности, начиная с последней
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.SearchTemplate
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/search-templates [get]
+func (h *AdminHandlers) ListSearchTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.searchTemplates.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, templates)
+}
+
+// CreateSearchTemplate обрабатывает POST запрос на сохранение новой версии шаблона поиска.
+// Эндпоинт: POST /admin/search-templates
+//
+// @Summary      Сохранить версию шаблона поиска
+// @Description  Проверяет шаблон mustache отрисовкой в кластере и сохраняет его новой версией без активации. Без source сохраняется встроенный запрос рекомендаций.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.SearchTemplate  true  "Шаблон (source, description)"
+// @Success      201      {object}  models.SearchTemplate
+// @Failure      400      {object}  map[string]string  "Некорректный шаблон"
+// @Router       /admin/search-templates [post]
+func (h *AdminHandlers) CreateSearchTemplate(w http.ResponseWriter, r *http.Request) {
+	var t models.SearchTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	t.Active = false
+	t.ActivatedAt = nil
+
+	if err := h.searchTemplates.Create(r.Context(), &t); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// GetSearchTemplate обрабатывает GET запрос на получение версии шаблона поиска.
+// Эндпоинт: GET /admin/search-templates/{version}
+//
+// @Summary      Версия шаблона поиска
+// @Tags         admin
+// @Produce      json
+// @Param        version  path      int  true  "Версия шаблона"
+// @Success      200      {object}  models.SearchTemplate
+// @Failure      404      {object}  map[string]string  "Версия не найдена"
+// @Router       /admin/search-templates/{version} [get]
+func (h *AdminHandlers) GetSearchTemplate(w http.ResponseWriter, r *http.Request) {
+	version, ok := templateVersion(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := h.searchTemplates.Get(r.Context(), version)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+// RenderSearchTemplate обрабатывает POST запрос на отрисовку версии шаблона поиска.
+// Эндпоинт: POST /admin/search-templates/{version}/render
+//
+// @Summary      Отрисовать шаблон поиска
+// @Description  Подставляет в версию шаблона параметры запроса рекомендаций (с профилем ранжирования типа бизнеса) и возвращает получившийся запрос к Elasticsearch без его выполнения
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        version  path      int                      true  "Версия шаблона"
+// @Param        request  body      models.RecommendRequest  true  "Запрос рекомендаций"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]string  "Неверный запрос или шаблон"
+// @Failure      404      {object}  map[string]string  "Версия не найдена"
+// @Router       /admin/search-templates/{version}/render [post]
+func (h *AdminHandlers) RenderSearchTemplate(w http.ResponseWriter, r *http.Request) {
+	version, ok := templateVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RecommendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rendered, err := h.searchTemplates.Render(r.Context(), version, &req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rendered)
+}
+
+// ActivateSearchTemplate обрабатывает POST запрос на переключение поиска на версию шаблона.
+// Эндпоинт: POST /admin/search-templates/{version}/activate
+//
+// @Summary      Активировать версию шаблона поиска
+// @Description  Сохраняет версию шаблона в кластере и переключает на нее поиск рекомендаций без перезапуска. Векторный (use_embedding) и сезонный (target_month) режимы выполняются встроенным запросом.
+// @Tags         admin
+// @Produce      json
+// @Param        version  path      int  true  "Версия шаблона"
+// @Success      200      {object}  models.SearchTemplate
+// @Failure      400      {object}  map[string]string  "Кластер отклонил шаблон"
+// @Failure      404      {object}  map[string]string  "Версия не найдена"
+// @Router       /admin/search-templates/{version}/activate [post]
+func (h *AdminHandlers) ActivateSearchTemplate(w http.ResponseWriter, r *http.Request) {
+	version, ok := templateVersion(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := h.searchTemplates.Activate(r.Context(), version)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+// DeactivateSearchTemplate обрабатывает DELETE запрос на возврат к встроенному запросу.
+// Эндпоинт: DELETE /admin/search-templates/active
+//
+// @Summary      Вернуть встроенный запрос рекомендаций
+// @Tags         admin
+// @Success      204
+// @Router       /admin/search-templates/active [delete]
+func (h *AdminHandlers) DeactivateSearchTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.searchTemplates.Deactivate(r.Context()); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// templateVersion разбирает номер версии шаблона из пути и при ошибке отвечает 400.
+func templateVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil || version <= 0 {
+		http.Error(w, "version must be a positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// SnapshotHandlers содержит зависимости для HTTP запросов снимков выдачи.
+type SnapshotHandlers struct {
+	snapshots     *service.SnapshotService
+	publicBaseURL string
+}
+
+// NewSnapshotHandlers создает новый экземпляр SnapshotHandlers.
+// publicBaseURL используется для построения публичных ссылок; если он пуст, ссылка строится по заголовкам запроса.
+func NewSnapshotHandlers(snapshots *service.SnapshotService, publicBaseURL string) *SnapshotHandlers {
+	return &SnapshotHandlers{
+		snapshots:     snapshots,
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+	}
+}
+
+// ShareRequest представляет запрос на создание публичной ссылки на выдачу.
+type ShareRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty"` // Срок действия ссылки (по умолчанию SHARE_TTL_HOURS)
+}
+
+// ShareResponse представляет созданную публичную ссылку на снимок выдачи.
+type ShareResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ShareRecommendation обрабатывает POST запрос на создание неизменяемого снимка выдачи рекомендаций.
+// Эндпоинт: POST /recommendations/{query_id}/share
+//
+// @Summary      Поделиться выдачей рекомендаций
+// @Description  Фиксирует результат запроса рекомендаций (query_id из ответа /locations/recommend) в неизменяемый снимок и возвращает публичную ссылку с ограниченным сроком действия. Поделиться можно только запросом своей организации; черновики попадают в снимок только у редактора.
+// @Tags         recommendations
+// @Accept       json
+// @Produce      json
+// @Param        query_id  path      string        true   "Идентификатор запроса рекомендаций"
+// @Param        request   body      ShareRequest  false  "Параметры ссылки"
+// @Success      201       {object}  ShareResponse
+// @Failure      400       {object}  map[string]string  "Неверный запрос"
+// @Failure      404       {object}  map[string]string  "Запрос не найден"
+// @Failure      500       {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /recommendations/{query_id}/share [post]
+func (h *SnapshotHandlers) ShareRecommendation(w http.ResponseWriter, r *http.Request) {
+	var req ShareRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	queryID := mux.Vars(r)["query_id"]
+	snapshot, err := h.snapshots.Share(r.Context(), queryID, time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		if service.IsValidationError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrNotFound) {
+			http.Error(w, "Query not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error sharing recommendation", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := ShareResponse{
+		Token:     snapshot.Token,
+		URL:       h.baseURL(r) + models.APIV1Prefix + "/shared/" + snapshot.Token,
+		ExpiresAt: snapshot.ExpiresAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+	}
+}
+
+// GetSharedRecommendation обрабатывает GET запрос на получение снимка выдачи по публичной ссылке.
+// Эндпоинт не требует аутентификации: доступ определяется знанием токена.
+// Эндпоинт: GET /shared/{token}
+//
+// @Summary      Получить снимок выдачи по ссылке
+// @Description  Возвращает зафиксированную выдачу рекомендаций по токену публичной ссылки
+// @Tags         recommendations
+// @Produce      json
+// @Param        token  path      string  true  "Токен ссылки"
+// @Success      200    {object}  models.Snapshot
+// @Failure      404    {object}  map[string]string  "Снимок не найден"
+// @Failure      410    {object}  map[string]string  "Срок действия ссылки истек"
+// @Failure      500    {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /shared/{token} [get]
+func (h *SnapshotHandlers) GetSharedRecommendation(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.snapshots.Get(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			http.Error(w, "Snapshot not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, service.ErrExpired) {
+			http.Error(w, "Link expired", http.StatusGone)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error getting snapshot", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding response", logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// baseURL возвращает базовый URL для публичных ссылок.
+func (h *SnapshotHandlers) baseURL(r *http.Request) string {
+	if h.publicBaseURL != "" {
+		return h.publicBaseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
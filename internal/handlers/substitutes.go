@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListSubstitutes обрабатывает GET запрос на получение связей замещаемости типов бизнеса.
+// Эндпоинт: GET /admin/business-type-substitutes
+//
+// @Summary      Замещаемость типов бизнеса
+// @Description  Возвращает связи замещаемости: substitute конкурирует с business_type с долей weight
+// @Tags         admin
+// @Produce      json
+// @Param        business_type  query     string  false  "Фильтр по типу бизнеса"
+// @Success      200            {array}   models.BusinessTypeSubstitute
+// @Failure      500            {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/business-type-substitutes [get]
+func (h *AdminHandlers) ListSubstitutes(w http.ResponseWriter, r *http.Request) {
+	substitutes, err := h.substitutes.List(r.Context(), r.URL.Query().Get("business_type"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if substitutes == nil {
+		substitutes = []models.BusinessTypeSubstitute{}
+	}
+
+	writeJSON(w, http.StatusOK, substitutes)
+}
+
+// SaveSubstitute обрабатывает PUT запрос на создание или изменение связи замещаемости.
+// Эндпоинт: PUT /admin/business-type-substitutes/{business_type}/{substitute}
+//
+// @Summary      Сохранить связь замещаемости
+// @Description  Создает связь замещаемости типов бизнеса или обновляет долю weight (0–1] существующей
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        business_type  path      string                         true  "Тип бизнеса"
+// @Param        substitute     path      string                         true  "Замещающий тип бизнеса"
+// @Param        request        body      models.BusinessTypeSubstitute  true  "Доля замещаемости"
+// @Success      200            {object}  models.BusinessTypeSubstitute
+// @Failure      400            {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/business-type-substitutes/{business_type}/{substitute} [put]
+func (h *AdminHandlers) SaveSubstitute(w http.ResponseWriter, r *http.Request) {
+	var sub models.BusinessTypeSubstitute
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	vars := mux.Vars(r)
+	sub.BusinessType, sub.Substitute = vars["business_type"], vars["substitute"]
+
+	if err := h.substitutes.Save(r.Context(), &sub); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// DeleteSubstitute обрабатывает DELETE запрос на удаление связи замещаемости.
+// Эндпоинт: DELETE /admin/business-type-substitutes/{business_type}/{substitute}
+//
+// @Summary      Удалить связь замещаемости
+// @Tags         admin
+// @Param        business_type  path  string  true  "Тип бизнеса"
+// @Param        substitute     path  string  true  "Замещающий тип бизнеса"
+// @Success      204
+// @Failure      404  {object}  map[string]string  "Связь не найдена"
+// @Router       /admin/business-type-substitutes/{business_type}/{substitute} [delete]
+func (h *AdminHandlers) DeleteSubstitute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.substitutes.Delete(r.Context(), vars["business_type"], vars["substitute"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
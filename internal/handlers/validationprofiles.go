@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// ListValidationProfiles обрабатывает GET запрос на получение профилей проверки локаций.
+// Эндпоинт: GET /admin/validation-profiles
+//
+// @Summary      Профили проверки локаций
+// @Description  Возвращает профили проверки локаций по типам бизнеса: обязательные поля и допустимые диапазоны числовых полей
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.ValidationProfile
+// @Failure      500  {object}  map[string]string  "Внутренняя ошибка сервера"
+// @Router       /admin/validation-profiles [get]
+func (h *AdminHandlers) ListValidationProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.profiles.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if profiles == nil {
+		profiles = []models.ValidationProfile{}
+	}
+
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+// GetValidationProfile обрабатывает GET запрос на получение профиля проверки типа бизнеса.
+// Эндпоинт: GET /admin/validation-profiles/{business_type}
+//
+// @Summary      Профиль проверки типа бизнеса
+// @Tags         admin
+// @Produce      json
+// @Param        business_type  path      string  true  "Тип бизнеса"
+// @Success      200            {object}  models.ValidationProfile
+// @Failure      404            {object}  map[string]string  "Профиль не задан"
+// @Router       /admin/validation-profiles/{business_type} [get]
+func (h *AdminHandlers) GetValidationProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.profiles.Get(r.Context(), mux.Vars(r)["business_type"])
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// PutValidationProfile обрабатывает PUT запрос на создание или замену профиля проверки.
+// Эндпоинт: PUT /admin/validation-profiles/{business_type}
+//
+// @Summary      Сохранить профиль проверки
+// @Description  Задает обязательные поля (required) и диапазоны числовых полей (ranges) локаций типа бизнеса. Профиль применяется при создании, изменении, асинхронной индексации и импорте локаций.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        business_type  path      string                    true  "Тип бизнеса"
+// @Param        request        body      models.ValidationProfile  true  "Профиль проверки"
+// @Success      200            {object}  models.ValidationProfile
+// @Failure      400            {object}  map[string]string  "Неверный запрос"
+// @Router       /admin/validation-profiles/{business_type} [put]
+func (h *AdminHandlers) PutValidationProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.ValidationProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	profile.BusinessType = mux.Vars(r)["business_type"]
+
+	if err := h.profiles.Save(r.Context(), &profile); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// DeleteValidationProfile обрабатывает DELETE запрос на удаление профиля проверки.
+// Эндпоинт: DELETE /admin/validation-profiles/{business_type}
+//
+// @Summary      Удалить профиль проверки
+// @Tags         admin
+// @Param        business_type  path  string  true  "Тип бизнеса"
+// @Success      204
+// @Failure      404  {object}  map[string]string  "Профиль не задан"
+// @Router       /admin/validation-profiles/{business_type} [delete]
+func (h *AdminHandlers) DeleteValidationProfile(w http.ResponseWriter, r *http.Request) {
+	if err := h.profiles.Delete(r.Context(), mux.Vars(r)["business_type"]); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,343 @@
+// Package importer читает наборы локаций из CSV и GeoJSON файлов и проверяет их перед загрузкой:
+// корректность и перестановку координат, попадание точек в границы заявленного региона,
+// дубликаты координат. Результат проверки — машиночитаемый отчет.
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Record — локация из файла импорта со ссылкой на ее место в файле.
+type Record struct {
+	Ref      string // "line 12" для CSV, "feature 3" для GeoJSON
+	Location *models.Location
+}
+
+// ReadCSV читает локации из CSV файла с заголовком. Столбцы сопоставляются полям локации
+// по имени (регистр не важен): id, name, address, lat, lon, region, city, description,
+// business_types (через точку с запятой), traffic_score, competition_density, event_exposure,
+// schools_nearby, universities_nearby, street_parking_score, paid_lots_nearby, safety_score,
+// age_group, average_income, interests (через точку с запятой), population_density, segments
+// (сегменты "возрастная_группа:доля:доход" через точку с запятой). Вместо lat и lon допускаются
+// latitude и longitude, вместо business_types — business_types_suitable. Неизвестные
+// столбцы игнорируются; lat и lon обязательны.
+//
+// Разделитель определяется по заголовку: Excel в русской локали сохраняет CSV с точкой
+// с запятой, тогда списки в ячейках заключаются в кавычки.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	buffered := bufio.NewReader(r)
+	firstLine, err := buffered.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(firstLine), buffered))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Excel добавляет BOM в начало файла в кодировке UTF-8
+		name = strings.TrimPrefix(name, "\ufeff")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"lat", "lon"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv header has no %q column", required)
+		}
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		props := make(map[string]interface{}, len(columns))
+		for name, i := range columns {
+			if i < len(row) && strings.TrimSpace(row[i]) != "" {
+				props[name] = strings.TrimSpace(row[i])
+			}
+		}
+
+		location, err := locationFromProperties(props)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		lat, latErr := parseFloat(props["lat"])
+		lon, lonErr := parseFloat(props["lon"])
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("line %d: lat and lon must be numbers", line)
+		}
+		location.Coordinates = models.GeoPoint{Lat: lat, Lon: lon}
+		records = append(records, Record{Ref: fmt.Sprintf("line %d", line), Location: location})
+	}
+	return records, nil
+}
+
+// columnAliases сопоставляет альтернативные имена столбцов CSV и свойств GeoJSON основным.
+var columnAliases = map[string]string{
+	"latitude":                "lat",
+	"longitude":               "lon",
+	"business_types_suitable": "business_types",
+}
+
+// featureCollection — GeoJSON FeatureCollection (RFC 7946).
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	ID         interface{}            `json:"id"`
+	Geometry   *geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ReadGeoJSON читает локации из GeoJSON FeatureCollection: геометрия Point задает координаты,
+// properties — поля локации (имена как в ReadCSV; списки — массивами или строкой через точку
+// с запятой, segments — также массивом объектов {age_group, share, average_income}).
+// Идентификатор берется из properties.id или id объекта.
+func ReadGeoJSON(r io.Reader) ([]Record, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("failed to decode geojson: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected FeatureCollection, got %q", collection.Type)
+	}
+
+	records := make([]Record, 0, len(collection.Features))
+	for i, f := range collection.Features {
+		ref := fmt.Sprintf("feature %d", i)
+		if f.Geometry == nil || f.Geometry.Type != "Point" {
+			return nil, fmt.Errorf("%s: geometry must be a Point", ref)
+		}
+		var position []float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &position); err != nil || len(position) < 2 {
+			return nil, fmt.Errorf("%s: invalid point coordinates", ref)
+		}
+
+		props := make(map[string]interface{}, len(f.Properties))
+		for name, value := range f.Properties {
+			name = strings.ToLower(name)
+			if alias, ok := columnAliases[name]; ok {
+				name = alias
+			}
+			props[name] = value
+		}
+		if _, ok := props["id"]; !ok && f.ID != nil {
+			props["id"] = fmt.Sprint(f.ID)
+		}
+
+		location, err := locationFromProperties(props)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref, err)
+		}
+		// В GeoJSON порядок координат — долгота, широта
+		location.Coordinates = models.GeoPoint{Lat: position[1], Lon: position[0]}
+		records = append(records, Record{Ref: ref, Location: location})
+	}
+	return records, nil
+}
+
+// locationFromProperties заполняет поля локации из свойств записи. Координаты не заполняются.
+func locationFromProperties(props map[string]interface{}) (*models.Location, error) {
+	location := &models.Location{
+		ID:                    toString(props["id"]),
+		Name:                  toString(props["name"]),
+		Address:               toString(props["address"]),
+		Region:                toString(props["region"]),
+		City:                  toString(props["city"]),
+		Description:           toString(props["description"]),
+		BusinessTypesSuitable: toList(props["business_types"]),
+	}
+	location.Demographics.AgeGroup = toString(props["age_group"])
+	location.Demographics.Interests = toList(props["interests"])
+
+	numbers := []struct {
+		name   string
+		target *float64
+	}{
+		{"traffic_score", &location.TrafficScore},
+		{"competition_density", &location.CompetitionDensity},
+		{"event_exposure", &location.EventExposure},
+		{"street_parking_score", &location.StreetParkingScore},
+		{"safety_score", &location.SafetyScore},
+		{"average_income", &location.Demographics.AverageIncome},
+		{"population_density", &location.Demographics.PopulationDensity},
+	}
+	for _, n := range numbers {
+		value, ok := props[n.name]
+		if !ok || value == nil {
+			continue
+		}
+		parsed, err := parseFloat(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", n.name)
+		}
+		*n.target = parsed
+		location.MarkPresent(profileField(n.name))
+	}
+
+	counts := []struct {
+		name   string
+		target *int
+	}{
+		{"schools_nearby", &location.SchoolsNearby},
+		{"universities_nearby", &location.UniversitiesNearby},
+		{"paid_lots_nearby", &location.PaidLotsNearby},
+	}
+	for _, c := range counts {
+		value, ok := props[c.name]
+		if !ok || value == nil {
+			continue
+		}
+		parsed, err := parseFloat(value)
+		if err != nil || parsed != float64(int(parsed)) || parsed < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", c.name)
+		}
+		*c.target = int(parsed)
+		location.MarkPresent(c.name)
+	}
+
+	segments, err := toSegments(props["segments"])
+	if err != nil {
+		return nil, err
+	}
+	location.Demographics.Segments = segments
+	location.Demographics.Summarize()
+	if len(segments) > 0 {
+		// Средний доход рассчитывается по сегментам
+		location.MarkPresent("demographics.average_income")
+	}
+	return location, nil
+}
+
+// profileField возвращает имя поля профиля проверки для столбца name: демографические
+// столбцы в профилях указываются с префиксом "demographics.".
+func profileField(name string) string {
+	switch name {
+	case "average_income", "population_density":
+		return "demographics." + name
+	}
+	return name
+}
+
+// toSegments разбирает демографические сегменты: строку "18-25:0.3:45000;26-35:0.7:60000"
+// или массив объектов GeoJSON с полями age_group, share и average_income.
+func toSegments(value interface{}) ([]models.DemographicSegment, error) {
+	var segments []models.DemographicSegment
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		for _, item := range toList(v) {
+			parts := strings.Split(item, ":")
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("segments must be age_group:share:income, got %q", item)
+			}
+			share, shareErr := parseFloat(strings.TrimSpace(parts[1]))
+			income, incomeErr := parseFloat(strings.TrimSpace(parts[2]))
+			if shareErr != nil || incomeErr != nil {
+				return nil, fmt.Errorf("segment %q: share and income must be numbers", item)
+			}
+			segments = append(segments, models.DemographicSegment{
+				AgeGroup:      strings.TrimSpace(parts[0]),
+				Share:         share,
+				AverageIncome: income,
+			})
+		}
+	case []interface{}:
+		for _, item := range v {
+			fields, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("segments must be objects")
+			}
+			segment := models.DemographicSegment{AgeGroup: toString(fields["age_group"])}
+			for name, target := range map[string]*float64{"share": &segment.Share, "average_income": &segment.AverageIncome} {
+				if fields[name] == nil {
+					continue
+				}
+				parsed, err := parseFloat(fields[name])
+				if err != nil {
+					return nil, fmt.Errorf("segment %s must be a number", name)
+				}
+				*target = parsed
+			}
+			segments = append(segments, segment)
+		}
+	default:
+		return nil, fmt.Errorf("segments must be a string or an array")
+	}
+	return segments, nil
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toList(value interface{}) []string {
+	var items []string
+	switch v := value.(type) {
+	case string:
+		for _, item := range strings.Split(v, ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s := toString(item); s != "" {
+				items = append(items, s)
+			}
+		}
+	}
+	return items
+}
+
+func parseFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		// Excel в русской локали выгружает дробные числа с запятой
+		return strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+	default:
+		return 0, fmt.Errorf("not a number: %v", value)
+	}
+}
@@ -0,0 +1,370 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Проверки отчета.
+const (
+	CheckInvalidCoordinates = "invalid_coordinates"   // Координаты вне допустимых диапазонов или (0, 0)
+	CheckSwapped            = "lat_lon_swapped"       // Широта и долгота, по-видимому, переставлены
+	CheckOutsideRegion      = "outside_region"        // Точка вне границ заявленного региона
+	CheckUnknownRegion      = "unknown_region"        // Для региона нет границ
+	CheckDuplicate          = "duplicate_coordinates" // Слишком много записей в одной точке
+	CheckValidationProfile  = "validation_profile"    // Нарушен профиль проверки типа бизнеса
+	CheckUnknownCity        = "unknown_city"          // Города нет в справочнике городов региона
+)
+
+// Серьезность замечаний: ошибки препятствуют загрузке записи, предупреждения требуют проверки.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// swapDistanceMeters — минимальное удаление точки от остальных точек региона, начиная с
+// которого проверяется перестановка координат при отсутствии границ региона.
+const swapDistanceMeters = 100000.0
+
+// Options задает пороги проверок.
+type Options struct {
+	DuplicateRadiusMeters float64 // Точки ближе этого расстояния считаются одной точкой
+	DuplicateThreshold    int     // Допустимое число записей в одной точке
+	// Profiles — профили проверки по типам бизнеса; nil — профили не проверяются
+	Profiles map[string]*models.ValidationProfile
+	// Cities — справочник городов по регионам; nil — города не проверяются
+	Cities models.KnownCities
+}
+
+// Issue — замечание к записи импорта.
+type Issue struct {
+	Ref         string           `json:"ref"`
+	LocationID  string           `json:"location_id,omitempty"`
+	Region      string           `json:"region,omitempty"`
+	Check       string           `json:"check"`
+	Severity    string           `json:"severity"`
+	Field       string           `json:"field,omitempty"` // Поле, нарушающее профиль проверки
+	Message     string           `json:"message"`
+	Coordinates models.GeoPoint  `json:"coordinates"`
+	Suggested   *models.GeoPoint `json:"suggested,omitempty"`    // Исправленные координаты, если исправление очевидно
+	DuplicateOf string           `json:"duplicate_of,omitempty"` // Первая запись в той же точке
+}
+
+// Report — отчет проверки файла импорта.
+type Report struct {
+	Source      string         `json:"source"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Total       int            `json:"total"`
+	Valid       int            `json:"valid"` // Записи без ошибок
+	Errors      int            `json:"errors"`
+	Warnings    int            `json:"warnings"`
+	Counts      map[string]int `json:"counts"` // Число замечаний по проверкам
+	Issues      []Issue        `json:"issues"`
+}
+
+// Polygon — многоугольник GeoJSON: внешний контур и контуры отверстий.
+type Polygon [][]models.GeoPoint
+
+// Boundaries — границы регионов по названию региона.
+type Boundaries map[string][]Polygon
+
+// LoadBoundaries читает границы регионов из GeoJSON FeatureCollection с геометрией Polygon
+// или MultiPolygon. Название региона берется из properties.name или properties.region.
+func LoadBoundaries(r io.Reader) (Boundaries, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("failed to decode region boundaries: %w", err)
+	}
+
+	boundaries := make(Boundaries)
+	for i, f := range collection.Features {
+		name := toString(f.Properties["name"])
+		if name == "" {
+			name = toString(f.Properties["region"])
+		}
+		if name == "" || f.Geometry == nil {
+			return nil, fmt.Errorf("feature %d: region name and geometry are required", i)
+		}
+
+		var polygons [][][][]float64
+		switch f.Geometry.Type {
+		case "Polygon":
+			var polygon [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &polygon); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid polygon: %w", i, err)
+			}
+			polygons = append(polygons, polygon)
+		case "MultiPolygon":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &polygons); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid multipolygon: %w", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("feature %d: unsupported geometry %q", i, f.Geometry.Type)
+		}
+
+		for _, polygon := range polygons {
+			rings := make(Polygon, 0, len(polygon))
+			for _, ring := range polygon {
+				points := make([]models.GeoPoint, 0, len(ring))
+				for _, position := range ring {
+					if len(position) < 2 {
+						return nil, fmt.Errorf("feature %d: invalid position", i)
+					}
+					points = append(points, models.GeoPoint{Lat: position[1], Lon: position[0]})
+				}
+				rings = append(rings, points)
+			}
+			boundaries[normalizeRegion(name)] = append(boundaries[normalizeRegion(name)], rings)
+		}
+	}
+	return boundaries, nil
+}
+
+// Contains сообщает, лежит ли точка в границах региона. known равно false, если границ региона нет.
+func (b Boundaries) Contains(region string, point models.GeoPoint) (inside, known bool) {
+	polygons, ok := b[normalizeRegion(region)]
+	if !ok {
+		return false, false
+	}
+	for _, polygon := range polygons {
+		if polygon.contains(point) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// contains проверяет попадание точки во внешний контур и отсутствие попадания в отверстия.
+func (p Polygon) contains(point models.GeoPoint) bool {
+	if len(p) == 0 || !inRing(p[0], point) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if inRing(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRing — проверка попадания точки в контур методом трассировки луча.
+func inRing(ring []models.GeoPoint, point models.GeoPoint) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lon < (b.Lon-a.Lon)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func normalizeRegion(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Validate проверяет записи импорта. boundaries может быть nil: тогда попадание в регион
+// не проверяется, а перестановка координат определяется по удаленности от остальных точек региона.
+func Validate(source string, records []Record, boundaries Boundaries, opts Options) *Report {
+	report := &Report{
+		Source:      source,
+		GeneratedAt: time.Now(),
+		Total:       len(records),
+		Counts:      make(map[string]int),
+		Issues:      []Issue{},
+	}
+	failed := make(map[int]bool)
+	add := func(i int, issue Issue) {
+		location := records[i].Location
+		issue.Ref = records[i].Ref
+		issue.LocationID = location.ID
+		issue.Region = location.Region
+		issue.Coordinates = location.Coordinates
+		report.Issues = append(report.Issues, issue)
+		report.Counts[issue.Check]++
+		if issue.Severity == SeverityError {
+			report.Errors++
+			failed[i] = true
+		} else {
+			report.Warnings++
+		}
+	}
+
+	centers := regionCenters(records)
+	unknownRegions := make(map[string]bool)
+	for i, record := range records {
+		point := record.Location.Coordinates
+		swapped := models.GeoPoint{Lat: point.Lon, Lon: point.Lat}
+
+		if !validPoint(point) {
+			if validPoint(swapped) {
+				add(i, Issue{Check: CheckSwapped, Severity: SeverityError, Suggested: &swapped,
+					Message: "latitude out of range, coordinates are likely swapped"})
+			} else {
+				add(i, Issue{Check: CheckInvalidCoordinates, Severity: SeverityError,
+					Message: "coordinates out of range or (0, 0)"})
+			}
+			continue
+		}
+
+		if boundaries != nil && record.Location.Region != "" {
+			inside, known := boundaries.Contains(record.Location.Region, point)
+			if !known {
+				if !unknownRegions[normalizeRegion(record.Location.Region)] {
+					unknownRegions[normalizeRegion(record.Location.Region)] = true
+					add(i, Issue{Check: CheckUnknownRegion, Severity: SeverityWarning,
+						Message: fmt.Sprintf("no boundaries for region %q, region checks skipped", record.Location.Region)})
+				}
+			} else if !inside {
+				if swappedInside, _ := boundaries.Contains(record.Location.Region, swapped); swappedInside {
+					add(i, Issue{Check: CheckSwapped, Severity: SeverityError, Suggested: &swapped,
+						Message: "point is outside the region, swapped coordinates are inside"})
+				} else {
+					add(i, Issue{Check: CheckOutsideRegion, Severity: SeverityError,
+						Message: fmt.Sprintf("point is outside region %q", record.Location.Region)})
+				}
+			}
+			if known {
+				continue
+			}
+		}
+
+		// Без границ региона: точка далеко от остальных точек региона, а с переставленными
+		// координатами оказывается рядом с ними
+		if center, ok := centers[normalizeRegion(record.Location.Region)]; ok {
+			distance := geo.DistanceMeters(point, center)
+			if distance > swapDistanceMeters && geo.DistanceMeters(swapped, center) < distance/10 {
+				add(i, Issue{Check: CheckSwapped, Severity: SeverityWarning, Suggested: &swapped,
+					Message: fmt.Sprintf("point is %.0f km from other points of the region, swapped coordinates are much closer", distance/1000)})
+			}
+		}
+	}
+
+	for i, record := range records {
+		for _, v := range models.CheckProfiles(record.Location, opts.Profiles) {
+			add(i, Issue{Check: CheckValidationProfile, Severity: SeverityError, Field: v.Field,
+				Message: fmt.Sprintf("%s %s (business type %q)", v.Field, v.Message, v.BusinessType)})
+		}
+		if location := record.Location; opts.Cities != nil && !opts.Cities.Allows(location.Region, location.City) {
+			add(i, Issue{Check: CheckUnknownCity, Severity: SeverityError, Field: "city",
+				Message: fmt.Sprintf("city %q is not in the cities of region %q", location.City, location.Region)})
+		}
+	}
+
+	for _, group := range duplicateGroups(records, failed, opts.DuplicateRadiusMeters) {
+		if len(group) <= opts.DuplicateThreshold {
+			continue
+		}
+		first := records[group[0]]
+		for _, i := range group[1:] {
+			add(i, Issue{Check: CheckDuplicate, Severity: SeverityWarning, DuplicateOf: first.Ref,
+				Message: fmt.Sprintf("%d records within %.0f m of %s (threshold %d)", len(group), opts.DuplicateRadiusMeters, first.Ref, opts.DuplicateThreshold)})
+		}
+	}
+
+	report.Valid = report.Total - len(failed)
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Severity == SeverityError && report.Issues[j].Severity != SeverityError
+	})
+	return report
+}
+
+// validPoint проверяет диапазоны координат и отбрасывает (0, 0) — типичное значение
+// по умолчанию при ошибке геокодирования.
+func validPoint(p models.GeoPoint) bool {
+	if p.Lat == 0 && p.Lon == 0 {
+		return false
+	}
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+// regionCenters возвращает для регионов с тремя и более точками покоординатную медиану
+// точек — устойчивую к отдельным ошибочным записям оценку положения региона.
+func regionCenters(records []Record) map[string]models.GeoPoint {
+	lats := make(map[string][]float64)
+	lons := make(map[string][]float64)
+	for _, record := range records {
+		point := record.Location.Coordinates
+		if record.Location.Region == "" || !validPoint(point) {
+			continue
+		}
+		region := normalizeRegion(record.Location.Region)
+		lats[region] = append(lats[region], point.Lat)
+		lons[region] = append(lons[region], point.Lon)
+	}
+
+	centers := make(map[string]models.GeoPoint, len(lats))
+	for region := range lats {
+		if len(lats[region]) < 3 {
+			continue
+		}
+		centers[region] = models.GeoPoint{Lat: median(lats[region]), Lon: median(lons[region])}
+	}
+	return centers
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	return values[len(values)/2]
+}
+
+// duplicateGroups группирует записи, координаты которых ближе radius к первой записи группы.
+// Для поиска соседей первые записи групп раскладываются по ячейкам сетки размером не меньше radius.
+func duplicateGroups(records []Record, skip map[int]bool, radius float64) [][]int {
+	if radius <= 0 {
+		radius = 1
+	}
+	cellDegrees := radius / 111000
+	// Градус долготы короче к полюсам: ширина ячеек по долготе задается для всей полосы широт
+	// по ее ближнему к полюсу краю, чтобы ячейки полосы были выровнены и не уже radius
+	lonDegrees := func(row int64) float64 {
+		edge := math.Max(math.Abs(float64(row)*cellDegrees), math.Abs(float64(row+1)*cellDegrees))
+		return cellDegrees / math.Max(math.Cos(math.Min(edge, 90)*math.Pi/180), 0.01)
+	}
+	cell := func(p models.GeoPoint, row int64) [2]int64 {
+		return [2]int64{row, int64(math.Floor(p.Lon / lonDegrees(row)))}
+	}
+
+	grid := make(map[[2]int64][]int) // ячейка -> номера групп
+	var groups [][]int
+	for i, record := range records {
+		if skip[i] {
+			continue
+		}
+		point := record.Location.Coordinates
+		row := int64(math.Floor(point.Lat / cellDegrees))
+
+		group := -1
+	search:
+		for dLat := int64(-1); dLat <= 1; dLat++ {
+			// Соседняя полоса делится на ячейки своей ширины
+			c := cell(point, row+dLat)
+			for dLon := int64(-1); dLon <= 1; dLon++ {
+				for _, g := range grid[[2]int64{c[0], c[1] + dLon}] {
+					if geo.DistanceMeters(point, records[groups[g][0]].Location.Coordinates) <= radius {
+						group = g
+						break search
+					}
+				}
+			}
+		}
+
+		if group < 0 {
+			c := cell(point, row)
+			grid[c] = append(grid[c], len(groups))
+			groups = append(groups, []int{i})
+		} else {
+			groups[group] = append(groups[group], i)
+		}
+	}
+	return groups
+}
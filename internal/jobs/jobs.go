@@ -0,0 +1,169 @@
+// Package jobs предоставляет менеджер фоновых асинхронных задач с отслеживанием прогресса.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// Status определяет состояние задачи.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Job представляет снимок состояния фоновой задачи.
+type Job struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Status     Status      `json:"status"`
+	Total      int         `json:"total"`
+	Processed  int         `json:"processed"`
+	Failed     int         `json:"failed"`
+	Error      string      `json:"error,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	// Organization — организация, запустившая задачу через SubmitFor (пусто — служебная задача)
+	Organization string `json:"organization,omitempty"`
+
+	tenant bool // Задача запущена клиентом через SubmitFor и видна его организации
+}
+
+// Progress позволяет функции задачи сообщать о ходе выполнения.
+type Progress struct {
+	mu  *sync.RWMutex
+	job *Job
+}
+
+// SetTotal устанавливает общее количество элементов для обработки.
+func (p *Progress) SetTotal(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Total = total
+}
+
+// Add увеличивает счетчики обработанных и неудачных элементов.
+func (p *Progress) Add(processed, failed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Processed += processed
+	p.job.Failed += failed
+}
+
+// SetResult сохраняет результат задачи, возвращаемый вместе с её состоянием.
+func (p *Progress) SetResult(result interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Result = result
+}
+
+// Func — функция, выполняющая работу задачи.
+type Func func(ctx context.Context, progress *Progress) error
+
+// Manager запускает задачи в отдельных горутинах и хранит их состояние в памяти.
+type Manager struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+	ctx  context.Context
+}
+
+// NewManager создает новый экземпляр Manager.
+// Контекст ctx передается во все задачи; его отмена останавливает выполняющиеся задачи.
+func NewManager(ctx context.Context) *Manager {
+	return &Manager{
+		jobs: make(map[string]*Job),
+		ctx:  ctx,
+	}
+}
+
+// Submit регистрирует и асинхронно запускает служебную задачу, возвращая её начальное состояние.
+func (m *Manager) Submit(jobType string, fn Func) *Job {
+	return m.submit(&Job{Type: jobType}, fn)
+}
+
+// SubmitFor запускает задачу клиента организации organization: ее состояние доступно
+// этой организации через GetFor.
+func (m *Manager) SubmitFor(organization, jobType string, fn Func) *Job {
+	return m.submit(&Job{Type: jobType, Organization: organization, tenant: true}, fn)
+}
+
+func (m *Manager) submit(job *Job, fn Func) *Job {
+	job.ID = newID()
+	job.Status = StatusPending
+	job.CreatedAt = time.Now()
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	snapshot := *job
+	m.mu.Unlock()
+
+	go m.run(job, fn)
+
+	return &snapshot
+}
+
+// Get возвращает снимок состояния задачи по ID.
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// GetFor возвращает снимок состояния задачи, запущенной клиентом организации organization.
+// Служебные задачи и задачи других организаций не возвращаются.
+func (m *Manager) GetFor(organization, id string) (*Job, bool) {
+	job, ok := m.Get(id)
+	if !ok || !job.tenant || job.Organization != organization {
+		return nil, false
+	}
+	return job, true
+}
+
+func (m *Manager) run(job *Job, fn Func) {
+	m.mu.Lock()
+	now := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &now
+	m.mu.Unlock()
+
+	err := fn(m.ctx, &Progress{mu: &m.mu, job: job})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+		slog.Error("Job failed", "job_id", job.ID, "type", job.Type, logging.Err(err))
+		return
+	}
+	job.Status = StatusCompleted
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,161 @@
+// Package listener открывает сокеты HTTP сервера: TCP порт, HTTPS порт, Unix domain socket
+// и сокеты, переданные systemd при socket activation (LISTEN_FDS).
+package listener
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart — первый дескриптор, передаваемый systemd (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// Виды сокетов.
+const (
+	KindTCP     = "tcp"
+	KindTLS     = "tls"
+	KindUnix    = "unix"
+	KindSystemd = "systemd"
+)
+
+// Options задает сокеты, которые нужно открыть.
+type Options struct {
+	TCPAddr        string      // Адрес TCP порта, например ":8080" (пусто — не открывать)
+	TLSAddr        string      // Адрес HTTPS порта, например ":443" (пусто — не открывать)
+	TLSConfig      *tls.Config // Конфигурация TLS для TLSAddr
+	UnixSocket     string      // Путь к Unix domain socket (пусто — не открывать)
+	UnixSocketMode fs.FileMode // Права на файл сокета
+}
+
+// Listener — открытый сокет с видом и описанием для логов.
+type Listener struct {
+	net.Listener
+	Kind string
+	Name string
+}
+
+// ErrTCPWithSystemd возвращается, если TCP порт задан при socket activation: порт открывает
+// systemd, и повторное открытие того же порта завершится ошибкой или займет его дважды.
+var ErrTCPWithSystemd = errors.New("tcp listener and systemd socket activation are mutually exclusive")
+
+// Open открывает сокеты systemd (если процесс запущен через socket activation), TCP и HTTPS
+// порты и Unix domain socket. TCP порт и socket activation взаимоисключающие (ErrTCPWithSystemd).
+// При ошибке уже открытые сокеты закрываются.
+func Open(opts Options) ([]Listener, error) {
+	listeners, err := systemd()
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(err error) ([]Listener, error) {
+		for _, l := range listeners {
+			l.Close()
+		}
+		return nil, err
+	}
+
+	if opts.TCPAddr != "" && len(listeners) > 0 {
+		return fail(ErrTCPWithSystemd)
+	}
+
+	if opts.TCPAddr != "" {
+		l, err := net.Listen("tcp", opts.TCPAddr)
+		if err != nil {
+			return fail(fmt.Errorf("failed to listen on %s: %w", opts.TCPAddr, err))
+		}
+		listeners = append(listeners, Listener{Listener: l, Kind: KindTCP, Name: "tcp " + l.Addr().String()})
+	}
+
+	if opts.TLSAddr != "" {
+		if opts.TLSConfig == nil {
+			return fail(errors.New("tls config is required for tls listener"))
+		}
+		l, err := net.Listen("tcp", opts.TLSAddr)
+		if err != nil {
+			return fail(fmt.Errorf("failed to listen on %s: %w", opts.TLSAddr, err))
+		}
+		listeners = append(listeners, Listener{Listener: tls.NewListener(l, opts.TLSConfig), Kind: KindTLS, Name: "tls " + l.Addr().String()})
+	}
+
+	if opts.UnixSocket != "" {
+		l, err := listenUnix(opts.UnixSocket, opts.UnixSocketMode)
+		if err != nil {
+			return fail(err)
+		}
+		listeners = append(listeners, Listener{Listener: l, Kind: KindUnix, Name: "unix " + opts.UnixSocket})
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("no listeners configured")
+	}
+	return listeners, nil
+}
+
+// listenUnix создает Unix domain socket, удаляя файл, оставшийся от предыдущего запуска.
+func listenUnix(path string, mode fs.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket path %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %s: %w", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to set unix socket mode: %w", err)
+	}
+	return l, nil
+}
+
+// systemd возвращает сокеты, переданные systemd (протокол sd_listen_fds): LISTEN_PID совпадает
+// с PID процесса, LISTEN_FDS — число дескрипторов начиная с 3, LISTEN_FDNAMES — их имена.
+// Переменные удаляются из окружения, чтобы их не унаследовали дочерние процессы.
+func systemd() ([]Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]Listener, 0, count)
+	for i := 0; i < count; i++ {
+		fd := listenFDsStart + i
+		name := fmt.Sprintf("systemd fd %d", fd)
+		if i < len(names) && names[i] != "" {
+			name = "systemd " + names[i]
+		}
+
+		file := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(file)
+		// FileListener дублирует дескриптор, исходный больше не нужен
+		file.Close()
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to use %s: %w", name, err)
+		}
+		listeners = append(listeners, Listener{Listener: l, Kind: KindSystemd, Name: name})
+	}
+	return listeners, nil
+}
@@ -0,0 +1,119 @@
+package lock
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// LeaderLockName — имя блокировки, удерживаемой лидером кластера.
+const LeaderLockName = "leader"
+
+// Elector выбирает лидера среди экземпляров сервиса: лидером становится экземпляр, захвативший
+// блокировку LeaderLockName. Остальные экземпляры периодически пытаются ее захватить, поэтому
+// при остановке лидера или потере его соединения с хранилищем лидерство переходит к другому.
+type Elector struct {
+	backend  Backend
+	instance string
+	interval time.Duration
+	leader   atomic.Bool
+}
+
+// NewElector создает участника выборов instance. interval задает период попыток захвата
+// блокировки и проверки, что лидер ее не потерял.
+func NewElector(backend Backend, instance string, interval time.Duration) *Elector {
+	return &Elector{
+		backend:  backend,
+		instance: instance,
+		interval: interval,
+	}
+}
+
+// Instance возвращает идентификатор экземпляра.
+func (e *Elector) Instance() string {
+	return e.instance
+}
+
+// IsLeader сообщает, является ли экземпляр лидером.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Leader возвращает идентификатор текущего лидера (пусто — лидер не выбран).
+func (e *Elector) Leader(ctx context.Context) (string, error) {
+	holders, err := e.backend.Holders(ctx, []string{LeaderLockName})
+	if err != nil {
+		return "", err
+	}
+	return holders[LeaderLockName].Instance, nil
+}
+
+// Run участвует в выборах до отмены ctx. Пока экземпляр лидер, выполняется lead; его контекст
+// отменяется при потере лидерства, и блокировка освобождается только после возврата из lead,
+// чтобы работа лидера не выполнялась двумя экземплярами одновременно.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		release, ok, err := e.backend.TryLock(ctx, LeaderLockName, e.instance)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "Leader election failed", logging.Err(err))
+		case ok:
+			e.lead(ctx, release, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead выполняет lead, пока экземпляр остается лидером.
+func (e *Elector) lead(ctx context.Context, release func() error, lead func(ctx context.Context)) {
+	e.leader.Store(true)
+	slog.InfoContext(ctx, "Instance became leader", "instance", e.instance)
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for leading := true; leading; {
+		select {
+		case <-ctx.Done():
+			leading = false
+		case <-done:
+			leading = false
+		case <-ticker.C:
+			// Хранилище освобождает блокировку при разрыве соединения: лидерство проверяется по нему
+			holder, err := e.Leader(ctx)
+			switch {
+			case err != nil:
+				slog.WarnContext(ctx, "Leader check failed, stepping down", logging.Err(err))
+				leading = false
+			case holder != e.instance:
+				slog.WarnContext(ctx, "Instance lost leadership", "instance", e.instance, "leader", holder)
+				leading = false
+			}
+		}
+	}
+
+	cancel()
+	<-done
+	if err := release(); err != nil {
+		slog.ErrorContext(ctx, "Error releasing leader lock", logging.Err(err))
+	}
+	e.leader.Store(false)
+	slog.InfoContext(ctx, "Instance stepped down", "instance", e.instance)
+}
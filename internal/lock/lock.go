@@ -0,0 +1,268 @@
+// Package lock предоставляет распределенные блокировки заданий, которые должны выполняться
+// в единственном экземпляре на весь кластер сервиса: архивации, сверки, выгрузок, конвейеров
+// обновления. Блокировки хранятся во внешнем хранилище (Backend), поэтому задание, запущенное
+// по расписанию на нескольких экземплярах одновременно, выполняет только один из них.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+var (
+	// ErrHeld возвращается, если блокировку удерживает другой процесс.
+	ErrHeld = errors.New("lock is held by another process")
+	// ErrLost — причина отмены контекста задания, блокировку которого экземпляр потерял.
+	ErrLost = errors.New("lock lost")
+)
+
+// checkInterval — период проверки, что захваченная блокировка по-прежнему удерживается
+// экземпляром: хранилище снимает ее при разрыве соединения, и задание может начать другой экземпляр.
+const checkInterval = 30 * time.Second
+
+// Holder описывает процесс, удерживающий блокировку.
+type Holder struct {
+	Instance string // Идентификатор экземпляра сервиса
+	Address  string // Адрес клиента в хранилище блокировок
+}
+
+// Backend — хранилище блокировок.
+type Backend interface {
+	// TryLock пытается захватить блокировку name от имени instance без ожидания.
+	// Возвращает ok = false, если блокировку удерживает другой процесс.
+	// Блокировка освобождается вызовом release или при потере соединения с хранилищем.
+	TryLock(ctx context.Context, name, instance string) (release func() error, ok bool, err error)
+	// Holders возвращает удерживающие процессы для захваченных блокировок из names.
+	Holders(ctx context.Context, names []string) (map[string]Holder, error)
+}
+
+// Status — состояние блокировки задания.
+type Status struct {
+	Name          string     `json:"name"`
+	Locked        bool       `json:"locked"`
+	Holder        string     `json:"holder,omitempty"`         // Экземпляр, удерживающий блокировку
+	HolderAddress string     `json:"holder_address,omitempty"` // Адрес удерживающего клиента
+	HeldLocally   bool       `json:"held_locally"`             // Блокировку удерживает этот экземпляр
+	AcquiredAt    *time.Time `json:"acquired_at,omitempty"`    // Время захвата этим экземпляром
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`    // Последнее выполнение задания этим экземпляром
+	LastSkippedAt *time.Time `json:"last_skipped_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// state — сведения экземпляра о блокировке.
+type state struct {
+	acquiredAt  *time.Time
+	lastRunAt   *time.Time
+	lastSkipped *time.Time
+	lastError   string
+}
+
+// Manager захватывает блокировки заданий и хранит сведения экземпляра о них.
+// Методы nil Manager выполняют задания без блокировок (один экземпляр, утилиты командной строки).
+type Manager struct {
+	backend  Backend
+	instance string
+
+	mu     sync.Mutex
+	states map[string]*state
+}
+
+// New создает менеджер блокировок экземпляра instance.
+func New(backend Backend, instance string) *Manager {
+	return &Manager{
+		backend:  backend,
+		instance: instance,
+		states:   make(map[string]*state),
+	}
+}
+
+// Instance возвращает идентификатор экземпляра.
+func (m *Manager) Instance() string {
+	if m == nil {
+		return ""
+	}
+	return m.instance
+}
+
+// Register добавляет блокировки в отчет Status до их первого захвата.
+func (m *Manager) Register(names ...string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range names {
+		m.state(name)
+	}
+}
+
+// Acquire захватывает блокировку name без ожидания. Возвращает ErrHeld, если блокировку
+// удерживает другой процесс, в том числе другое задание этого экземпляра.
+//
+// Задание выполняется с возвращенным контекстом: до вызова release экземпляр каждые checkInterval
+// проверяет по хранилищу, что блокировка осталась за ним, и при ее потере отменяет контекст
+// с причиной ErrLost.
+func (m *Manager) Acquire(ctx context.Context, name string) (held context.Context, release func(), err error) {
+	if m == nil {
+		return ctx, func() {}, nil
+	}
+
+	unlock, ok, err := m.backend.TryLock(ctx, name, m.instance)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	st := m.state(name)
+	if !ok {
+		st.lastSkipped = &now
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("%w: %s", ErrHeld, name)
+	}
+	st.acquiredAt = &now
+	m.mu.Unlock()
+
+	held, cancel := context.WithCancelCause(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		m.watch(held, name, cancel)
+	}()
+
+	var once sync.Once
+	return held, func() {
+		once.Do(func() {
+			// Проверка не должна обращаться к хранилищу после освобождения блокировки
+			cancel(nil)
+			<-stopped
+			if err := unlock(); err != nil {
+				slog.Error("Error releasing lock", "lock", name, logging.Err(err))
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.state(name).acquiredAt = nil
+		})
+	}, nil
+}
+
+// watch до отмены ctx проверяет, что блокировку name удерживает этот экземпляр, и отменяет
+// ctx с причиной ErrLost, если ее удерживает другой процесс или никто. Ошибка обращения
+// к хранилищу не отменяет задание: проверка повторяется через checkInterval.
+func (m *Manager) watch(ctx context.Context, name string, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		holders, err := m.backend.Holders(ctx, []string{name})
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "Lock check failed", "lock", name, logging.Err(err))
+			}
+			continue
+		}
+		if holder := holders[name].Instance; holder != m.instance {
+			slog.ErrorContext(ctx, "Lock lost, cancelling job", "lock", name, "holder", holder)
+			cancel(fmt.Errorf("%w: %s", ErrLost, name))
+			return
+		}
+	}
+}
+
+// Do выполняет fn под блокировкой name. Если блокировку удерживает другой процесс,
+// fn не выполняется и возвращается ErrHeld. Если экземпляр потерял блокировку во время
+// выполнения, контекст fn отменяется, а ошибка fn оборачивается в ErrLost.
+func (m *Manager) Do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	held, release, err := m.Acquire(ctx, name)
+	if err != nil {
+		if errors.Is(err, ErrHeld) {
+			slog.InfoContext(ctx, "Skipping: lock is held by another process", "lock", name)
+		}
+		return err
+	}
+	defer release()
+
+	err = fn(held)
+	if cause := context.Cause(held); err != nil && errors.Is(cause, ErrLost) {
+		err = fmt.Errorf("%w: %w", cause, err)
+	}
+
+	if m != nil {
+		now := time.Now()
+		m.mu.Lock()
+		st := m.state(name)
+		st.lastRunAt = &now
+		st.lastError = ""
+		if err != nil {
+			st.lastError = err.Error()
+		}
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// Status возвращает состояние зарегистрированных и захватывавшихся блокировок, упорядоченное
+// по имени: удерживающий экземпляр по данным хранилища и сведения этого экземпляра.
+func (m *Manager) Status(ctx context.Context) ([]Status, error) {
+	if m == nil {
+		return []Status{}, nil
+	}
+
+	m.mu.Lock()
+	names := make([]string, 0, len(m.states))
+	for name := range m.states {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+	sort.Strings(names)
+
+	holders, err := m.backend.Holders(ctx, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock holders: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]Status, 0, len(names))
+	for _, name := range names {
+		st := m.states[name]
+		status := Status{
+			Name:          name,
+			HeldLocally:   st.acquiredAt != nil,
+			AcquiredAt:    st.acquiredAt,
+			LastRunAt:     st.lastRunAt,
+			LastSkippedAt: st.lastSkipped,
+			LastError:     st.lastError,
+		}
+		if holder, ok := holders[name]; ok {
+			status.Locked = true
+			status.Holder = holder.Instance
+			status.HolderAddress = holder.Address
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// state возвращает сведения о блокировке name, создавая их при необходимости. Вызывается под m.mu.
+func (m *Manager) state(name string) *state {
+	st, ok := m.states[name]
+	if !ok {
+		st = &state{}
+		m.states[name] = st
+	}
+	return st
+}
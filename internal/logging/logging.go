@@ -0,0 +1,147 @@
+// Package logging настраивает структурированный журнал сервиса на log/slog: записи выводятся
+// в формате JSON, а поля запроса API (request_id, маршрут, метод), добавленные в контекст
+// With, и идентификатор записываемой трассы попадают в каждую запись, сделанную с этим контекстом
+// (slog.InfoContext, slog.ErrorContext и т.д.).
+//
+// Сообщения, которые остальные пакеты пишут через стандартный log, после Setup также выводятся
+// в JSON с уровнем INFO.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/tracing"
+	"github.com/lib/pq"
+)
+
+// ParseLevel разбирает уровень журнала: debug, info, warn (warning) или error.
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", value)
+}
+
+// Setup делает журналом по умолчанию JSON журнал в w с уровнем level. attrs добавляются
+// к каждой записи (например, версия сервиса).
+func Setup(w io.Writer, level string, attrs ...slog.Attr) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	var handler slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})
+	if len(attrs) > 0 {
+		handler = handler.WithAttrs(attrs)
+	}
+	slog.SetDefault(slog.New(contextHandler{Handler: handler}))
+	return nil
+}
+
+type contextKey struct{}
+
+// With возвращает контекст, записи журнала с которым содержат attrs в дополнение к полям,
+// уже добавленным в ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	existing, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// contextHandler добавляет к записи поля контекста и идентификатор записываемой трассы.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String("trace_id", traceID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// DetailedError — ошибка с дополнительными полями для журнала (например, статус и тип ошибки
+// ответа Elasticsearch).
+type DetailedError interface {
+	error
+	LogAttrs() []slog.Attr
+}
+
+// Err возвращает поле error с текстом ошибки. Если в цепочке err есть DetailedError или ошибка
+// PostgreSQL, поле содержит группу с текстом и подробностями: для PostgreSQL — код SQLSTATE,
+// уровень, подробность, таблицу и ограничение.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String("error", "")
+	}
+	attrs := []slog.Attr{slog.String("message", err.Error())}
+
+	var detailed DetailedError
+	if errors.As(err, &detailed) {
+		attrs = append(attrs, detailed.LogAttrs()...)
+	}
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		attrs = append(attrs, slog.String("source", "postgres"), slog.String("sqlstate", string(pgErr.Code)),
+			slog.String("severity", pgErr.Severity))
+		if pgErr.Detail != "" {
+			attrs = append(attrs, slog.String("detail", pgErr.Detail))
+		}
+		if pgErr.Table != "" {
+			attrs = append(attrs, slog.String("table", pgErr.Table))
+		}
+		if pgErr.Constraint != "" {
+			attrs = append(attrs, slog.String("constraint", pgErr.Constraint))
+		}
+	}
+
+	if len(attrs) == 1 {
+		return slog.String("error", err.Error())
+	}
+	return slog.Attr{Key: "error", Value: slog.GroupValue(attrs...)}
+}
+
+// Fatal записывает сообщение с уровнем ERROR и завершает процесс с кодом 1.
+func Fatal(msg string, attrs ...slog.Attr) {
+	slog.LogAttrs(context.Background(), slog.LevelError, msg, attrs...)
+	os.Exit(1)
+}
+
+// NewRequestID возвращает случайный идентификатор запроса.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/sqlhook"
+)
+
+// Transport возвращает http.RoundTripper, регистрирующий в recorder длительность запросов
+// к Elasticsearch. Операция определяется по первому сегменту пути API (_search, _bulk, _doc...);
+// запросы к индексу без такого сегмента (создание, удаление) относятся к операции index.
+// Ответы 429 и 5xx считаются ошибками.
+func Transport(next http.RoundTripper, recorder StorageRecorder) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &esTransport{next: next, recorder: recorder}
+}
+
+type esTransport struct {
+	next     http.RoundTripper
+	recorder StorageRecorder
+}
+
+func (t *esTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+
+	observed := err
+	if err == nil && (res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500) {
+		observed = fmt.Errorf("status %d", res.StatusCode)
+	}
+	t.recorder.ObserveStorageQuery("elasticsearch", ESOperation(req.URL.Path), time.Since(start), observed)
+	return res, err
+}
+
+// ESOperation возвращает имя операции API Elasticsearch по пути запроса: "/locations/_search" → "_search".
+func ESOperation(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "_") {
+			return segment
+		}
+	}
+	if strings.Trim(path, "/") == "" {
+		return "root"
+	}
+	return "index"
+}
+
+// SQLHook возвращает хук sqlhook, регистрирующий в recorder длительность запросов к PostgreSQL.
+// Операция — первое ключевое слово запроса (select, insert, update, delete...).
+func SQLHook(recorder StorageRecorder) sqlhook.Hook {
+	return func(ctx context.Context, query string) func(error) {
+		start := time.Now()
+		return func(err error) {
+			recorder.ObserveStorageQuery("postgres", sqlhook.Operation(query), time.Since(start), err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package metrics содержит in-process реестр метрик HTTP запросов, метрики HTTP запросов
+// и запросов к хранилищам в формате Prometheus и отправку метрик во внешние системы
+// мониторинга (StatsD/DogStatsD).
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder принимает метрики выполненных HTTP запросов.
+type Recorder interface {
+	ObserveRequest(route, method string, status int, duration time.Duration)
+	// ObserveSlowRequest регистрирует запрос, превысивший порог slow-log маршрута
+	ObserveSlowRequest(route, method string, status int)
+}
+
+// multiRecorder передает метрики нескольким получателям.
+type multiRecorder []Recorder
+
+// Multi возвращает Recorder, передающий метрики каждому из recorders.
+func Multi(recorders ...Recorder) Recorder {
+	if len(recorders) == 1 {
+		return recorders[0]
+	}
+	return multiRecorder(recorders)
+}
+
+func (m multiRecorder) ObserveRequest(route, method string, status int, duration time.Duration) {
+	for _, recorder := range m {
+		recorder.ObserveRequest(route, method, status, duration)
+	}
+}
+
+func (m multiRecorder) ObserveSlowRequest(route, method string, status int) {
+	for _, recorder := range m {
+		recorder.ObserveSlowRequest(route, method, status)
+	}
+}
+
+// RequestKey идентифицирует группу HTTP запросов в реестре.
+type RequestKey struct {
+	Route  string `json:"route"`
+	Method string `json:"method"`
+	Status int    `json:"status"`
+}
+
+// RequestStats содержит накопленную статистику по группе запросов.
+type RequestStats struct {
+	RequestKey
+	Version      string  `json:"version"` // Версия приложения, обработавшего запросы
+	Count        int64   `json:"count"`
+	TotalSeconds float64 `json:"total_seconds"`
+	MaxSeconds   float64 `json:"max_seconds"`
+	AvgSeconds   float64 `json:"avg_seconds"`
+	SlowCount    int64   `json:"slow_count"` // Запросы, превысившие порог slow-log маршрута
+}
+
+// Registry накапливает метрики HTTP запросов.
+type Registry struct {
+	mu       sync.RWMutex
+	version  string
+	requests map[RequestKey]*RequestStats
+}
+
+// NewRegistry создает новый пустой реестр метрик. version добавляется меткой ко всем метрикам,
+// чтобы при сборе с нескольких экземпляров метрики можно было сопоставить с релизом.
+func NewRegistry(version string) *Registry {
+	return &Registry{
+		version:  version,
+		requests: make(map[RequestKey]*RequestStats),
+	}
+}
+
+// ObserveRequest регистрирует выполненный HTTP запрос.
+func (r *Registry) ObserveRequest(route, method string, status int, duration time.Duration) {
+	key := RequestKey{Route: route, Method: method, Status: status}
+	seconds := duration.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats(key)
+	stats.Count++
+	stats.TotalSeconds += seconds
+	if seconds > stats.MaxSeconds {
+		stats.MaxSeconds = seconds
+	}
+}
+
+// ObserveSlowRequest регистрирует запрос, превысивший порог slow-log маршрута.
+func (r *Registry) ObserveSlowRequest(route, method string, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats(RequestKey{Route: route, Method: method, Status: status}).SlowCount++
+}
+
+// stats возвращает статистику группы запросов, создавая ее при первом обращении.
+// Вызывается под блокировкой.
+func (r *Registry) stats(key RequestKey) *RequestStats {
+	stats, ok := r.requests[key]
+	if !ok {
+		stats = &RequestStats{RequestKey: key, Version: r.version}
+		r.requests[key] = stats
+	}
+	return stats
+}
+
+// Requests возвращает снимок статистики запросов, отсортированный по маршруту, методу и статусу.
+func (r *Registry) Requests() []RequestStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]RequestStats, 0, len(r.requests))
+	for _, stats := range r.requests {
+		snapshot := *stats
+		if snapshot.Count > 0 {
+			snapshot.AvgSeconds = snapshot.TotalSeconds / float64(snapshot.Count)
+		}
+		result = append(result, snapshot)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Route != result[j].Route {
+			return result[i].Route < result[j].Route
+		}
+		if result[i].Method != result[j].Method {
+			return result[i].Method < result[j].Method
+		}
+		return result[i].Status < result[j].Status
+	})
+
+	return result
+}
@@ -0,0 +1,285 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// durationBuckets — верхние границы бакетов гистограмм длительности, секунды
+// (как DefBuckets клиента Prometheus).
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// StorageRecorder принимает метрики запросов к хранилищам.
+type StorageRecorder interface {
+	// ObserveStorageQuery регистрирует запрос к хранилищу system (elasticsearch, postgres)
+	ObserveStorageQuery(system, operation string, duration time.Duration, err error)
+	// ObserveBulkIndex регистрирует результат Bulk запроса: число проиндексированных и неудачных документов
+	ObserveBulkIndex(indexed, failed int)
+}
+
+// RecommendRecorder принимает метрики запросов рекомендаций.
+type RecommendRecorder interface {
+	// ObserveRecommend регистрирует запрос рекомендаций по профилю ранжирования profile
+	// с метрикой сходства similarity (none — без векторного поиска)
+	ObserveRecommend(profile, similarity string, duration time.Duration, err error)
+}
+
+// histogram — гистограмма длительностей одной серии.
+type histogram struct {
+	counts []uint64 // Число наблюдений не больше границы соответствующего бакета
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(seconds float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets))
+	}
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// recommendKey идентифицирует серию запросов рекомендаций.
+type recommendKey struct {
+	profile    string
+	similarity string
+	status     string
+}
+
+// storageKey идентифицирует серию запросов к хранилищу.
+type storageKey struct {
+	system    string
+	operation string
+	status    string
+}
+
+// Prometheus накапливает метрики HTTP запросов и запросов к хранилищам и отдает их
+// в текстовом формате Prometheus (ServeHTTP).
+type Prometheus struct {
+	mu       sync.Mutex
+	version  string
+	started  time.Time
+	requests map[RequestKey]*histogram
+	slow     map[RequestKey]uint64
+	storage  map[storageKey]*histogram
+	// recommend — длительность рекомендаций по профилю ранжирования и метрике сходства
+	recommend map[recommendKey]*histogram
+	bulkDocs  map[string]uint64 // Документы Bulk запросов по результату: indexed, failed
+	token     string            // Токен Bearer для получения метрик (пусто — без проверки)
+}
+
+// NewPrometheus создает пустой набор метрик. version отдается меткой метрики app_info.
+func NewPrometheus(version string) *Prometheus {
+	return &Prometheus{
+		version:   version,
+		started:   time.Now(),
+		requests:  make(map[RequestKey]*histogram),
+		slow:      make(map[RequestKey]uint64),
+		storage:   make(map[storageKey]*histogram),
+		recommend: make(map[recommendKey]*histogram),
+		bulkDocs:  make(map[string]uint64),
+	}
+}
+
+// ObserveRequest регистрирует выполненный HTTP запрос.
+func (p *Prometheus) ObserveRequest(route, method string, status int, duration time.Duration) {
+	key := RequestKey{Route: route, Method: method, Status: status}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.requests[key]
+	if !ok {
+		h = &histogram{}
+		p.requests[key] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// ObserveSlowRequest регистрирует запрос, превысивший порог slow-log маршрута.
+func (p *Prometheus) ObserveSlowRequest(route, method string, status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.slow[RequestKey{Route: route, Method: method, Status: status}]++
+}
+
+// ObserveStorageQuery регистрирует запрос к хранилищу.
+func (p *Prometheus) ObserveStorageQuery(system, operation string, duration time.Duration, err error) {
+	key := storageKey{system: system, operation: operation, status: "ok"}
+	if err != nil {
+		key.status = "error"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.storage[key]
+	if !ok {
+		h = &histogram{}
+		p.storage[key] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// ObserveRecommend регистрирует запрос рекомендаций.
+func (p *Prometheus) ObserveRecommend(profile, similarity string, duration time.Duration, err error) {
+	key := recommendKey{profile: profile, similarity: similarity, status: "ok"}
+	if err != nil {
+		key.status = "error"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.recommend[key]
+	if !ok {
+		h = &histogram{}
+		p.recommend[key] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// ObserveBulkIndex регистрирует результат Bulk запроса.
+func (p *Prometheus) ObserveBulkIndex(indexed, failed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.bulkDocs["indexed"] += uint64(indexed)
+	p.bulkDocs["failed"] += uint64(failed)
+}
+
+// SetToken задает токен, который должен передаваться в заголовке Authorization: Bearer
+// при получении метрик. Вызывается до регистрации маршрута.
+func (p *Prometheus) SetToken(token string) {
+	p.token = token
+}
+
+// ServeHTTP отдает метрики в текстовом формате Prometheus. Если задан токен, запрос без него
+// отклоняется с кодом 401.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(p.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.write(w)
+}
+
+// write выводит все метрики, серии каждой метрики отсортированы по меткам.
+func (p *Prometheus) write(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	writeHeader(w, "app_info", "gauge", "Application version")
+	fmt.Fprintf(w, "app_info{%s} 1\n", labels("version", p.version))
+	writeHeader(w, "process_uptime_seconds", "gauge", "Time since the instance started, seconds")
+	fmt.Fprintf(w, "process_uptime_seconds %s\n", formatFloat(time.Since(p.started).Seconds()))
+	writeHeader(w, "go_goroutines", "gauge", "Number of goroutines")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	requestSeries := make(map[string]*histogram, len(p.requests))
+	for key, h := range p.requests {
+		requestSeries[requestLabels(key)] = h
+	}
+	writeHeader(w, "http_request_duration_seconds", "histogram", "HTTP request duration by route, seconds")
+	writeHistograms(w, "http_request_duration_seconds", requestSeries)
+
+	writeHeader(w, "http_slow_requests_total", "counter", "HTTP requests exceeding the slow-log threshold of their route")
+	slowSeries := make(map[string]uint64, len(p.slow))
+	for key, count := range p.slow {
+		slowSeries[requestLabels(key)] = count
+	}
+	for _, series := range sortedKeys(slowSeries) {
+		fmt.Fprintf(w, "http_slow_requests_total{%s} %d\n", series, slowSeries[series])
+	}
+
+	storageSeries := make(map[string]*histogram, len(p.storage))
+	for key, h := range p.storage {
+		storageSeries[labels("system", key.system, "operation", key.operation, "status", key.status)] = h
+	}
+	writeHeader(w, "storage_query_duration_seconds", "histogram", "Elasticsearch and PostgreSQL query duration, seconds")
+	writeHistograms(w, "storage_query_duration_seconds", storageSeries)
+
+	recommendSeries := make(map[string]*histogram, len(p.recommend))
+	for key, h := range p.recommend {
+		recommendSeries[labels("profile", key.profile, "similarity", key.similarity, "status", key.status)] = h
+	}
+	writeHeader(w, "recommend_duration_seconds", "histogram", "Recommendation duration by ranking profile and similarity metric, seconds")
+	writeHistograms(w, "recommend_duration_seconds", recommendSeries)
+
+	writeHeader(w, "es_bulk_documents_total", "counter", "Documents sent in Elasticsearch bulk requests by result")
+	for _, result := range []string{"indexed", "failed"} {
+		fmt.Fprintf(w, "es_bulk_documents_total{%s} %d\n", labels("result", result), p.bulkDocs[result])
+	}
+}
+
+// requestLabels возвращает метки серии HTTP запросов.
+func requestLabels(key RequestKey) string {
+	return labels("route", key.Route, "method", key.Method, "status", strconv.Itoa(key.Status))
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeHistograms выводит серии гистограммы name; ключи series — метки серии.
+func writeHistograms(w io.Writer, name string, series map[string]*histogram) {
+	for _, key := range sortedKeys(series) {
+		h := series[key]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, key, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, key, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, key, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, key, h.count)
+	}
+}
+
+// labels форматирует пары имя-значение меток без фигурных скобок: name="value",...
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// labelEscaper экранирует значение метки по правилам текстового формата Prometheus.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsDConfig содержит параметры отправки метрик в StatsD.
+type StatsDConfig struct {
+	Address   string   // Адрес агента host:port (UDP)
+	Namespace string   // Префикс имен метрик
+	Tags      []string // Теги всех метрик "ключ:значение"
+	DogStatsD bool     // Расширение DogStatsD: метки передаются тегами, а не в имени метрики
+}
+
+// StatsD отправляет метрики HTTP запросов агенту StatsD или DogStatsD (Datadog) по UDP.
+// Для каждого запроса отправляются счетчик http.requests и время ответа http.request.duration,
+// для запросов, превысивших порог slow-log, — счетчик http.slow_requests.
+// Отправка не блокирует обработку запроса: при недоступности агента метрики теряются.
+type StatsD struct {
+	conn      net.Conn
+	namespace string
+	tags      string // Готовый суффикс тегов всех метрик без "|#"
+	dogStatsD bool
+}
+
+// NewStatsD создает отправитель метрик в StatsD по адресу cfg.Address.
+func NewStatsD(cfg StatsDConfig) (*StatsD, error) {
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", cfg.Address, err)
+	}
+
+	namespace := cfg.Namespace
+	if namespace != "" && !strings.HasSuffix(namespace, ".") {
+		namespace += "."
+	}
+
+	return &StatsD{
+		conn:      conn,
+		namespace: namespace,
+		tags:      strings.Join(cfg.Tags, ","),
+		dogStatsD: cfg.DogStatsD,
+	}, nil
+}
+
+// ObserveRequest отправляет метрики выполненного HTTP запроса.
+// В формате StatsD без тегов маршрут, метод и статус входят в имя метрики.
+func (s *StatsD) ObserveRequest(route, method string, status int, duration time.Duration) {
+	ms := strconv.FormatFloat(float64(duration.Microseconds())/1000, 'f', -1, 64)
+
+	var packet string
+	if s.dogStatsD {
+		tags := fmt.Sprintf("|#route:%s,method:%s,status:%d", route, method, status)
+		if s.tags != "" {
+			tags += "," + s.tags
+		}
+		packet = s.namespace + "http.requests:1|c" + tags + "\n" +
+			s.namespace + "http.request.duration:" + ms + "|ms" + tags
+	} else {
+		suffix := fmt.Sprintf(".%s.%s.%d", metricSegment(route), method, status)
+		packet = s.namespace + "http.requests" + suffix + ":1|c\n" +
+			s.namespace + "http.request.duration" + suffix + ":" + ms + "|ms"
+	}
+
+	// Ошибка отправки по UDP не должна влиять на обработку запроса
+	_, _ = s.conn.Write([]byte(packet))
+}
+
+// ObserveSlowRequest отправляет счетчик http.slow_requests запросов, превысивших порог slow-log.
+func (s *StatsD) ObserveSlowRequest(route, method string, status int) {
+	var packet string
+	if s.dogStatsD {
+		packet = fmt.Sprintf("%shttp.slow_requests:1|c|#route:%s,method:%s,status:%d", s.namespace, route, method, status)
+		if s.tags != "" {
+			packet += "," + s.tags
+		}
+	} else {
+		packet = fmt.Sprintf("%shttp.slow_requests.%s.%s.%d:1|c", s.namespace, metricSegment(route), method, status)
+	}
+
+	_, _ = s.conn.Write([]byte(packet))
+}
+
+// Close закрывает соединение с агентом.
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+// metricSegment приводит шаблон пути к сегменту имени метрики: "/locations/{id}" → "locations_id".
+func metricSegment(route string) string {
+	segment := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, route)
+	for strings.Contains(segment, "__") {
+		segment = strings.ReplaceAll(segment, "__", "_")
+	}
+	segment = strings.Trim(segment, "_")
+	if segment == "" {
+		return "root"
+	}
+	return segment
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// APIKeyHeader — заголовок, в котором клиент передает API ключ.
+const APIKeyHeader = "X-API-Key"
+
+// apiKey — разрешенный API ключ и клиент, от имени которого выполняются запросы с ним.
+type apiKey struct {
+	key       string
+	principal *auth.Principal
+}
+
+// parseAPIKey разбирает запись вида "key" или "key:organization:subject".
+// Для ключа без явного клиента subject вычисляется из хеша ключа.
+func parseAPIKey(entry string) apiKey {
+	parts := strings.SplitN(entry, ":", 3)
+	principal := &auth.Principal{}
+	if len(parts) > 1 {
+		principal.Organization = parts[1]
+	}
+	if len(parts) > 2 {
+		principal.Subject = parts[2]
+	}
+	if principal.Subject == "" {
+		sum := sha256.Sum256([]byte(parts[0]))
+		principal.Subject = "key-" + hex.EncodeToString(sum[:4])
+	}
+	return apiKey{key: parts[0], principal: principal}
+}
+
+// TokenAuthenticator проверяет bearer токен внешнего провайдера и возвращает клиента.
+type TokenAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// APIKeyAuthenticator находит клиента по API ключу, выпущенному во время работы сервиса.
+type APIKeyAuthenticator interface {
+	// AuthenticateAPIKey возвращает клиента ключа или nil, если ключ неизвестен или отозван
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// AuthConfig задает способы аутентификации.
+type AuthConfig struct {
+	APIKeys     []string            // Разрешенные API ключи ("key" или "key:organization:subject")
+	APIKeyRoles []string            // Роли клиентов, аутентифицированных API ключом
+	KeyStore    APIKeyAuthenticator // Выпущенные API ключи (nil — только APIKeys)
+	Tokens      TokenAuthenticator  // Проверка bearer токенов (nil — токены не принимаются)
+	AdminRole   string              // Роль, необходимая для путей администрирования (пусто — без проверки)
+	// AdminPrefixes — префиксы путей администрирования, требующих AdminRole (пусто — только /admin/)
+	AdminPrefixes []string
+}
+
+// StaticAPIKeyAuth проверяет, что запрос содержит один из разрешенных API ключей,
+// и сохраняет в контексте запроса клиента, которому принадлежит ключ.
+// Ключ задается как "key" или "key:organization:subject". Если список ключей пуст, аутентификация отключена,
+// а пути администрирования недоступны.
+func StaticAPIKeyAuth(keys []string) Middleware {
+	return Auth(AuthConfig{APIKeys: keys})
+}
+
+// Auth аутентифицирует запрос по API ключу в заголовке X-API-Key (машинные клиенты: ключи
+// конфигурации, затем выпущенные ключи KeyStore) или по токену OIDC провайдера в заголовке
+// Authorization: Bearer (пользователи) и сохраняет клиента в контексте запроса. Запросы к путям администрирования без роли AdminRole отклоняются с 403.
+// Если не задан ни один способ аутентификации, запросы пропускаются без клиента, кроме путей
+// администрирования: они отклоняются с 403, так как проверить роль AdminRole некому.
+func Auth(cfg AuthConfig) Middleware {
+	parsed := make([]apiKey, 0, len(cfg.APIKeys))
+	for _, entry := range cfg.APIKeys {
+		key := parseAPIKey(entry)
+		key.principal.Roles = cfg.APIKeyRoles
+		parsed = append(parsed, key)
+	}
+
+	adminPrefixes := cfg.AdminPrefixes
+	if len(adminPrefixes) == 0 {
+		adminPrefixes = []string{"/admin/"}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(parsed) == 0 && cfg.KeyStore == nil && cfg.Tokens == nil {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if hasAnyPrefix(r.URL.Path, adminPrefixes) {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := authenticate(r, parsed, cfg.KeyStore, cfg.Tokens)
+			if principal == nil {
+				if cfg.Tokens != nil {
+					w.Header().Set("WWW-Authenticate", `Bearer`)
+				}
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if cfg.AdminRole != "" && hasAnyPrefix(r.URL.Path, adminPrefixes) && !principal.HasRole(cfg.AdminRole) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// hasAnyPrefix сообщает, начинается ли path с одного из префиксов.
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate возвращает клиента по API ключу или bearer токену, nil — если запрос
+// не аутентифицирован.
+func authenticate(r *http.Request, keys []apiKey, store APIKeyAuthenticator, tokens TokenAuthenticator) *auth.Principal {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		for _, allowed := range keys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(allowed.key)) == 1 {
+				return allowed.principal
+			}
+		}
+		if store == nil {
+			return nil
+		}
+		principal, err := store.AuthenticateAPIKey(r.Context(), key)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error checking API key", logging.Err(err))
+			return nil
+		}
+		return principal
+	}
+
+	header := r.Header.Get("Authorization")
+	if tokens == nil || len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return nil
+	}
+	principal, err := tokens.Authenticate(r.Context(), strings.TrimSpace(header[7:]))
+	if err != nil {
+		slog.WarnContext(r.Context(), "Bearer token rejected", logging.Err(err))
+		return nil
+	}
+	return principal
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+)
+
+func TestAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	configured := AuthConfig{APIKeys: []string{"user-key:acme:etl"}, AdminRole: "admin"}
+
+	tests := []struct {
+		name string
+		cfg  AuthConfig
+		path string
+		key  string
+		want int
+	}{
+		// Без способов аутентификации пути администрирования закрыты, остальные открыты
+		{"unconfigured public", AuthConfig{}, "/api/v1/locations", "", http.StatusOK},
+		{"unconfigured admin", AuthConfig{}, "/admin/api-keys", "", http.StatusForbidden},
+		{"unconfigured custom admin prefix", AuthConfig{AdminPrefixes: []string{"/api/v1/admin/"}}, "/api/v1/admin/cities", "", http.StatusForbidden},
+		{"missing key", configured, "/api/v1/locations", "", http.StatusUnauthorized},
+		{"unknown key", configured, "/api/v1/locations", "other", http.StatusUnauthorized},
+		{"valid key", configured, "/api/v1/locations", "user-key", http.StatusOK},
+		{"admin without role", configured, "/admin/api-keys", "user-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			Auth(tt.cfg)(ok).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET %s: status %d, want %d", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+
+	admin := Auth(AuthConfig{APIKeys: []string{"admin-key:acme:ops"}, APIKeyRoles: []string{"admin"}, AdminRole: "admin"})
+	handler := admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := auth.FromContext(r.Context()); !ok || principal.Subject != "ops" {
+			t.Errorf("principal %+v, want subject ops", principal)
+		}
+	}))
+	req := httptest.NewRequest("GET", "/admin/api-keys", nil)
+	req.Header.Set(APIKeyHeader, "admin-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("admin with role: status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
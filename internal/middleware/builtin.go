@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
+	"github.com/akozadaev/go_es_analytical_system/internal/querycost"
+	"github.com/akozadaev/go_es_analytical_system/internal/tracing"
+)
+
+// CORS добавляет заголовки CORS и отвечает на preflight запросы.
+func CORS(allowedOrigins string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", allowedOrigins)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Idempotency-Key, X-Request-Id")
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Recovery перехватывает панику в обработчике, логирует её и возвращает 500.
+func Recovery() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					slog.ErrorContext(r.Context(), "Panic handling request", slog.String("method", r.Method),
+						slog.String("path", r.URL.Path), slog.Any("panic", rec), slog.String("stack", string(debug.Stack())))
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxRequestIDLength ограничивает длину идентификатора запроса, переданного клиентом.
+const maxRequestIDLength = 128
+
+// Logging присваивает запросу идентификатор — из заголовка X-Request-Id клиента или случайный —
+// и возвращает его в X-Request-Id. Поля request_id, method и route добавляются в контекст
+// запроса и попадают во все записи журнала обработчиков (см. пакет logging). По завершении
+// запроса записываются путь, код ответа и длительность; ответы 5xx — с уровнем ERROR.
+func Logging(routeName func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestID := r.Header.Get("X-Request-Id")
+			if !validRequestID(requestID) {
+				requestID = logging.NewRequestID()
+			}
+			w.Header().Set("X-Request-Id", requestID)
+			ctx := logging.With(r.Context(),
+				slog.String("request_id", requestID),
+				slog.String("method", r.Method),
+				slog.String("route", routeName(r)),
+			)
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			level := slog.LevelInfo
+			if rec.Status() >= 500 {
+				level = slog.LevelError
+			}
+			slog.LogAttrs(ctx, level, "Request completed",
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.Status()),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			)
+		})
+	}
+}
+
+// validRequestID сообщает, можно ли использовать идентификатор запроса клиента: непустой,
+// не длиннее maxRequestIDLength и из печатных ASCII символов.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Metrics передает метрики запросов получателю recorder (реестру метрик, StatsD).
+// routeName возвращает имя маршрута (шаблон пути), чтобы не плодить метрики по каждому ID.
+func Metrics(recorder metrics.Recorder, routeName func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			recorder.ObserveRequest(routeName(r), r.Method, rec.Status(), time.Since(start))
+		})
+	}
+}
+
+// Tracing создает span каждого запроса с именем "метод шаблон_пути" и передает его
+// в контексте обработчикам, запросам к Elasticsearch и PostgreSQL. Идентификатор записываемой
+// трассы возвращается в заголовке X-Trace-Id. При tracer == nil трассировка отключена.
+func Tracing(tracer *tracing.Tracer, routeName func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if tracer == nil {
+			return next
+		}
+		return tracer.Handler(next, routeName)
+	}
+}
+
+// CostRecorder учитывает стоимость запросов к Elasticsearch, выполненных при обработке запроса API.
+type CostRecorder interface {
+	Record(principal *auth.Principal, usage querycost.Usage)
+}
+
+// QueryCost учитывает стоимость запросов к Elasticsearch по клиентам API: в контекст запроса
+// добавляется querycost.Meter, который заполняет транспорт Elasticsearch. Должен стоять
+// в цепочке после auth, чтобы стоимость относилась к аутентифицированному клиенту.
+func QueryCost(recorder CostRecorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, meter := querycost.WithMeter(r.Context())
+			next.ServeHTTP(w, r.WithContext(ctx))
+			principal, _ := auth.FromContext(ctx)
+			recorder.Record(principal, meter.Usage())
+		})
+	}
+}
+
+// gzipResponseWriter сжимает тело ответа. Content-Length, заданный обработчиком для несжатого
+// тела, удаляется перед отправкой заголовков, в том числе при неявном WriteHeader из Write.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer      *gzip.Writer
+	wroteHeader bool
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if gw.wroteHeader {
+		return
+	}
+	gw.wroteHeader = true
+	gw.Header().Del("Content-Length")
+	gw.ResponseWriter.WriteHeader(status)
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+	return gw.writer.Write(b)
+}
+
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+	gw.writer.Flush()
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Compression сжимает ответы gzip, если клиент поддерживает это (Accept-Encoding).
+func Compression() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "HEAD" || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+
+			gw := &gzipResponseWriter{ResponseWriter: w, writer: gz}
+			next.ServeHTTP(gw, r)
+			// Без тела Close запишет пустой поток gzip: заголовки отправляются до этого
+			if !gw.wroteHeader {
+				gw.WriteHeader(http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+const (
+	// IdempotencyKeyHeader — заголовок, в котором клиент передает ключ идемпотентности.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader добавляется к ответу, восстановленному из сохраненного результата.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodySize   = 10 << 20
+)
+
+// IdempotencyStore хранит ключи идемпотентности и сохраненные ответы.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+}
+
+// Idempotency обеспечивает однократное выполнение POST, PUT и PATCH запросов с заголовком Idempotency-Key.
+// Первый запрос с ключом выполняется, а его ответ сохраняется на ttl; повтор с тем же телом получает
+// сохраненный ответ, повтор с другим телом — 422, повтор во время выполнения исходного — 409.
+// Ответы 5xx не сохраняются, чтобы запрос можно было повторить. Ключи разделены по клиентам.
+func Idempotency(store IdempotencyStore, ttl time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if store == nil || ttl <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			// Лишний байт отличает тело ровно на пределе от более длинного: обрезанное тело
+			// попало бы в обработчик и в хеш запроса
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if len(body) > maxIdempotentBodySize {
+				http.Error(w, "Request body is too large for an idempotent request", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := ""
+			if principal, ok := auth.FromContext(r.Context()); ok {
+				scope = principal.Organization + ":" + principal.Subject
+			}
+			hash := requestHash(r, body)
+
+			record, err := store.ReserveIdempotencyKey(r.Context(), scope, key, hash, ttl)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Error reserving idempotency key", logging.Err(err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if record != nil {
+				replayIdempotent(w, record, hash)
+				return
+			}
+
+			// Результат сохраняется и при отключении клиента, ради которого ключ и нужен
+			ctx := context.WithoutCancel(r.Context())
+			rec := &bodyRecorder{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.ReleaseIdempotencyKey(ctx, scope, key); err != nil {
+						slog.ErrorContext(ctx, "Error releasing idempotency key", logging.Err(err))
+					}
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.Status() >= 500 {
+				return
+			}
+			if err := store.CompleteIdempotencyKey(ctx, scope, key, rec.Status(), rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+				slog.ErrorContext(ctx, "Error saving idempotent response", logging.Err(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replayIdempotent отвечает на повтор запроса по сохраненной записи.
+func replayIdempotent(w http.ResponseWriter, record *models.IdempotencyRecord, hash string) {
+	switch {
+	case record.RequestHash != hash:
+		http.Error(w, "Idempotency-Key is already used with a different request", http.StatusUnprocessableEntity)
+	case !record.Completed:
+		http.Error(w, "Request with this Idempotency-Key is still in progress", http.StatusConflict)
+	default:
+		if record.ContentType != "" {
+			w.Header().Set("Content-Type", record.ContentType)
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		if _, err := w.Write(record.Body); err != nil {
+			slog.Error("Error writing idempotent response", logging.Err(err))
+		}
+	}
+}
+
+// requestHash вычисляет хеш метода, пути, параметров и тела запроса.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder запоминает код и тело ответа, передавая их клиенту.
+type bodyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (br *bodyRecorder) WriteHeader(status int) {
+	if br.status == 0 {
+		br.status = status
+	}
+	br.ResponseWriter.WriteHeader(status)
+}
+
+func (br *bodyRecorder) Write(b []byte) (int, error) {
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	br.body.Write(b)
+	return br.ResponseWriter.Write(b)
+}
+
+func (br *bodyRecorder) Status() int {
+	if br.status == 0 {
+		return http.StatusOK
+	}
+	return br.status
+}
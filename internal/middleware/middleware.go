@@ -0,0 +1,158 @@
+// Package middleware содержит HTTP middleware (CORS, аутентификация, ограничение частоты запросов,
+// логирование, метрики, восстановление после паники, сжатие) и сборку их в цепочку по конфигурации.
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Middleware оборачивает http.Handler дополнительной логикой.
+type Middleware func(http.Handler) http.Handler
+
+// Chain — упорядоченная цепочка именованных middleware.
+// Первый middleware в цепочке является внешним (выполняется первым).
+type Chain struct {
+	names       []string
+	middlewares map[string]Middleware
+	skips       map[string][]string // имя middleware -> шаблоны путей, для которых он пропускается
+}
+
+// Build собирает цепочку из middleware, перечисленных в order, используя реестр available.
+// skips задает для шаблона пути список middleware, которые к нему не применяются.
+// Шаблон, оканчивающийся на "/", сравнивается как префикс, иначе — как точный путь.
+func Build(order []string, available map[string]Middleware, skips map[string][]string) (*Chain, error) {
+	chain := &Chain{
+		middlewares: make(map[string]Middleware, len(order)),
+		skips:       make(map[string][]string),
+	}
+
+	for _, name := range order {
+		mw, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware: %q", name)
+		}
+		if _, dup := chain.middlewares[name]; dup {
+			return nil, fmt.Errorf("duplicate middleware: %q", name)
+		}
+		chain.names = append(chain.names, name)
+		chain.middlewares[name] = mw
+	}
+
+	for pattern, names := range skips {
+		for _, name := range names {
+			if _, ok := available[name]; !ok {
+				return nil, fmt.Errorf("unknown middleware in skip rule for %q: %q", pattern, name)
+			}
+			chain.skips[name] = append(chain.skips[name], pattern)
+		}
+	}
+
+	return chain, nil
+}
+
+// Names возвращает имена middleware в порядке применения.
+func (c *Chain) Names() []string {
+	return append([]string(nil), c.names...)
+}
+
+// Then оборачивает handler всеми middleware цепочки.
+func (c *Chain) Then(handler http.Handler) http.Handler {
+	for i := len(c.names) - 1; i >= 0; i-- {
+		name := c.names[i]
+		handler = skippable(c.middlewares[name], c.skips[name], handler)
+	}
+	return handler
+}
+
+// skippable применяет middleware ко всем запросам, кроме путей, совпадающих с patterns.
+func skippable(mw Middleware, patterns []string, next http.Handler) http.Handler {
+	wrapped := mw(next)
+	if len(patterns) == 0 {
+		return wrapped
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if matchesAny(r.URL.Path, patterns) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		wrapped.ServeHTTP(w, r)
+	})
+}
+
+func matchesAny(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(path, pattern) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOrder разбирает список имен middleware, разделенных запятыми.
+func ParseOrder(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseSkipRules разбирает правила исключений вида "/health:auth,logging;/swagger/:auth".
+func ParseSkipRules(value string) (map[string][]string, error) {
+	rules := make(map[string][]string)
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		pattern, names, ok := strings.Cut(rule, ":")
+		if !ok || strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("invalid skip rule: %q", rule)
+		}
+		pattern = strings.TrimSpace(pattern)
+		rules[pattern] = append(rules[pattern], ParseOrder(names)...)
+	}
+	return rules, nil
+}
+
+// statusRecorder запоминает код ответа для логирования и метрик.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+func (sr *statusRecorder) Status() int {
+	if sr.status == 0 {
+		return http.StatusOK
+	}
+	return sr.status
+}
+
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// QuotaCounter считает запросы API ключей по суткам UTC.
+type QuotaCounter interface {
+	// Consume учитывает запрос ключа keyID за сутки day (YYYY-MM-DD) и возвращает число его
+	// запросов за эти сутки с учетом добавленного
+	Consume(ctx context.Context, keyID, day string) (int64, error)
+}
+
+// Quota ограничивает число запросов за сутки UTC клиентов, аутентифицированных выпущенным
+// API ключом с дневной квотой (стоит в цепочке после auth). Ответ содержит заголовки
+// X-Quota-Limit, X-Quota-Remaining и X-Quota-Reset (Unix время начала следующих суток);
+// при исчерпании квоты запрос отклоняется с 429 и Retry-After. Отклоненные запросы тоже
+// учитываются. Если счетчик недоступен, запрос выполняется.
+func Quota(counter QuotaCounter) Middleware {
+	return func(next http.Handler) http.Handler {
+		if counter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok || principal.APIKeyID == "" || principal.DailyQuota <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := time.Now().UTC()
+			reset := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+			used, err := counter.Consume(r.Context(), principal.APIKeyID, now.Format("2006-01-02"))
+			if err != nil {
+				if r.Context().Err() != nil {
+					return
+				}
+				slog.WarnContext(r.Context(), "Could not count API key request, quota not enforced",
+					slog.String("api_key_id", principal.APIKeyID), logging.Err(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := int64(principal.DailyQuota)
+			remaining := limit - used
+			if remaining < 0 {
+				remaining = 0
+			}
+			w.Header().Set("X-Quota-Limit", strconv.FormatInt(limit, 10))
+			w.Header().Set("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+			w.Header().Set("X-Quota-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+			if used > limit {
+				retryAfter := int64(reset.Sub(now)/time.Second) + 1
+				w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+				http.Error(w, "Daily quota exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
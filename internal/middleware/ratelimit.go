@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// sharedRetryDelay — пауза перед повторным обращением к общему хранилищу после его ошибки.
+const sharedRetryDelay = 5 * time.Second
+
+const (
+	// RateLimitByIP — лимит на IP адрес клиента.
+	RateLimitByIP = "ip"
+	// RateLimitByClient — лимит на аутентифицированного клиента: выпущенный API ключ или subject;
+	// запросы без аутентификации ограничиваются по IP.
+	RateLimitByClient = "client"
+)
+
+// SharedRateLimitStore — общее для экземпляров сервиса хранилище token bucket клиентов.
+type SharedRateLimitStore interface {
+	// Take пополняет bucket клиента key на rate токенов в секунду с емкостью burst и расходует
+	// токен. Возвращает false, если токенов нет
+	Take(ctx context.Context, key string, rate float64, burst int) (bool, error)
+}
+
+// bucket — token bucket одного клиента.
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter ограничивает частоту запросов каждого клиента алгоритмом token bucket.
+// С общим хранилищем (SetShared) лимит действует на весь кластер: добавление экземпляров
+// не увеличивает допустимую частоту. Пока общее хранилище недоступно, запросы ограничиваются
+// bucket экземпляра.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Пополнение токенов в секунду
+	burst   float64 // Емкость bucket
+	buckets map[string]*bucket
+	lastGC  time.Time
+	keyBy   string // RateLimitByIP или RateLimitByClient
+
+	shared        SharedRateLimitStore
+	sharedTimeout time.Duration
+	sharedRetryAt atomic.Int64 // Время (UnixNano), до которого общее хранилище не используется
+	sharedDown    atomic.Bool
+}
+
+// NewRateLimiter создает ограничитель с rate запросов в секунду и допустимым всплеском burst.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		lastGC:  time.Now(),
+		keyBy:   RateLimitByIP,
+	}
+}
+
+// SetKeyBy задает, по чему считается лимит: RateLimitByIP (по умолчанию) или RateLimitByClient.
+func (rl *RateLimiter) SetKeyBy(by string) error {
+	if by != RateLimitByIP && by != RateLimitByClient {
+		return fmt.Errorf("invalid rate limit key %q: expected %s or %s", by, RateLimitByIP, RateLimitByClient)
+	}
+	rl.keyBy = by
+	return nil
+}
+
+// SetShared включает общее для экземпляров хранилище bucket. timeout ограничивает ожидание
+// его ответа: при ошибке или превышении запросы в течение sharedRetryDelay ограничиваются локально.
+func (rl *RateLimiter) SetShared(store SharedRateLimitStore, timeout time.Duration) {
+	rl.shared = store
+	rl.sharedTimeout = timeout
+}
+
+// Allow сообщает, можно ли выполнить запрос клиента key, и расходует токен.
+func (rl *RateLimiter) Allow(ctx context.Context, key string) bool {
+	if rl.shared != nil && time.Now().UnixNano() >= rl.sharedRetryAt.Load() {
+		sharedCtx, cancel := context.WithTimeout(ctx, rl.sharedTimeout)
+		allowed, err := rl.shared.Take(sharedCtx, key, rl.rate, int(rl.burst))
+		cancel()
+		if err == nil {
+			if rl.sharedDown.Swap(false) {
+				slog.InfoContext(ctx, "Shared rate limit store recovered, using cluster-wide limits")
+			}
+			return allowed
+		}
+		if ctx.Err() != nil {
+			// Клиент отключился: ошибка не говорит о недоступности хранилища
+			return false
+		}
+		rl.sharedRetryAt.Store(time.Now().Add(sharedRetryDelay).UnixNano())
+		if !rl.sharedDown.Swap(true) {
+			slog.WarnContext(ctx, "Shared rate limit store unavailable, falling back to per-instance limits", logging.Err(err))
+		}
+	}
+	return rl.allowLocal(key)
+}
+
+// allowLocal расходует токен bucket клиента key в памяти экземпляра.
+func (rl *RateLimiter) allowLocal(key string) bool {
+	now := time.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, lastSeen: now}
+		rl.buckets[key] = b
+	} else {
+		b.tokens += now.Sub(b.lastSeen).Seconds() * rl.rate
+		if b.tokens > rl.burst {
+			b.tokens = rl.burst
+		}
+		b.lastSeen = now
+	}
+
+	// Удаляем давно неактивных клиентов, чтобы карта не росла бесконечно
+	if now.Sub(rl.lastGC) > time.Minute {
+		for k, v := range rl.buckets {
+			if now.Sub(v.lastSeen) > time.Minute {
+				delete(rl.buckets, k)
+			}
+		}
+		rl.lastGC = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// key возвращает клиента запроса, для которого считается лимит. С RateLimitByClient
+// аутентифицированный клиент определяется по auth, поэтому middleware должен стоять в цепочке после него.
+func (rl *RateLimiter) key(r *http.Request) string {
+	if rl.keyBy == RateLimitByClient {
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			if principal.APIKeyID != "" {
+				return "key:" + principal.APIKeyID
+			}
+			if principal.Subject != "" {
+				return "client:" + principal.Subject
+			}
+		}
+	}
+	return clientIP(r)
+}
+
+// retryAfter возвращает время в секундах, за которое в bucket появляется токен.
+func (rl *RateLimiter) retryAfter() int64 {
+	return int64(math.Max(1, math.Ceil(1/rl.rate)))
+}
+
+// RateLimit ограничивает частоту запросов клиента (по IP или, с RateLimitByClient, по API ключу)
+// и при превышении возвращает 429 с Retry-After. При rate <= 0 ограничение отключено.
+func RateLimit(limiter *RateLimiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil || limiter.rate <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Allow(r.Context(), limiter.key(r)) {
+				w.Header().Set("Retry-After", strconv.FormatInt(limiter.retryAfter(), 10))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP возвращает IP адрес клиента без порта.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
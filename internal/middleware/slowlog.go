@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/cache"
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
+	"github.com/akozadaev/go_es_analytical_system/internal/querycost"
+)
+
+// redacted заменяет значения секретов в записях slow-log.
+const redacted = "***"
+
+// sensitiveHeaders — заголовки, значения которых не попадают в slow-log, помимо заголовков
+// с секретами в имени (X-API-Key, Authorization).
+var sensitiveHeaders = map[string]bool{
+	"Cookie":     true,
+	"Set-Cookie": true,
+}
+
+// SlowLogConfig задает пороги slow-log.
+type SlowLogConfig struct {
+	Thresholds   map[string]time.Duration // Пороги по шаблонам путей маршрутов
+	Default      time.Duration            // Порог остальных маршрутов (0 — не логируются)
+	MaxBodyBytes int                      // Максимальный размер тела запроса в записи
+}
+
+// ParseSlowLogThresholds разбирает пороги маршрутов из записей вида "шаблон_пути:мс".
+func ParseSlowLogThresholds(entries []string) (map[string]time.Duration, error) {
+	thresholds := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		// Шаблон пути может содержать ":" в регулярном выражении переменной, порог — после последнего
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid slow log threshold %q, expected route:ms", entry)
+		}
+		ms, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid slow log threshold %q, expected route:ms", entry)
+		}
+		thresholds[strings.TrimSpace(entry[:i])] = time.Duration(ms) * time.Millisecond
+	}
+	return thresholds, nil
+}
+
+// slowLogEntry — запись slow-log.
+type slowLogEntry struct {
+	Route       string              `json:"route"`
+	Method      string              `json:"method"`
+	Path        string              `json:"path"`
+	Query       map[string][]string `json:"query,omitempty"`
+	Headers     map[string]string   `json:"headers,omitempty"`
+	Body        interface{}         `json:"body,omitempty"`
+	Status      int                 `json:"status"`
+	DurationMs  int64               `json:"duration_ms"`
+	ThresholdMs int64               `json:"threshold_ms"`
+	Cache       string              `json:"cache"`         // Статус кешей: none, hit, miss, partial
+	ES          querycost.Usage     `json:"elasticsearch"` // Сводка запросов к Elasticsearch
+}
+
+// SlowLog записывает в лог подробности запросов, выполнявшихся дольше порога их маршрута:
+// запрос с замаскированными секретами, сводку запросов к Elasticsearch (число, took, найденные
+// документы, бакеты агрегаций) и статус кеша, — и регистрирует их в метрике медленных запросов.
+// routeName возвращает шаблон пути маршрута, по которому выбирается порог.
+func SlowLog(cfg SlowLogConfig, recorder metrics.Recorder, routeName func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.Thresholds) == 0 && cfg.Default <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeName(r)
+			threshold, ok := cfg.Thresholds[route]
+			if !ok {
+				threshold = cfg.Default
+			}
+			if threshold <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Начало тела сохраняется для записи, обработчик читает тело полностью
+			var body []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				body, _ = io.ReadAll(io.LimitReader(r.Body, int64(cfg.MaxBodyBytes)+1))
+				r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+			}
+
+			ctx, meter := querycost.WithMeter(r.Context())
+			ctx, trace := cache.WithTrace(ctx)
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			duration := time.Since(start)
+			if duration < threshold {
+				return
+			}
+
+			recorder.ObserveSlowRequest(route, r.Method, rec.Status())
+			entry := slowLogEntry{
+				Route:       route,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				Query:       sanitizeQuery(r.URL.Query()),
+				Headers:     sanitizeHeaders(r.Header),
+				Body:        sanitizeBody(body, cfg.MaxBodyBytes, r.Header.Get("Content-Type")),
+				Status:      rec.Status(),
+				DurationMs:  duration.Milliseconds(),
+				ThresholdMs: threshold.Milliseconds(),
+				Cache:       trace.Status(),
+				ES:          meter.Usage(),
+			}
+			slog.WarnContext(ctx, "Slow request", slog.Any("details", entry))
+		})
+	}
+}
+
+// readCloser объединяет Reader с Closer исходного тела запроса.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// isSensitive сообщает, может ли параметр или поле с таким именем содержать секрет.
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range []string{"key", "token", "secret", "password", "signature", "authorization"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeQuery(query map[string][]string) map[string][]string {
+	if len(query) == 0 {
+		return nil
+	}
+	result := make(map[string][]string, len(query))
+	for name, values := range query {
+		if isSensitive(name) {
+			values = []string{redacted}
+		}
+		result[name] = values
+	}
+	return result
+}
+
+func sanitizeHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[name] || isSensitive(name) {
+			value = redacted
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// sanitizeBody возвращает тело запроса для записи: JSON с замаскированными секретами,
+// иначе — только размер и тип содержимого. Тело длиннее maxBytes не разбирается.
+func sanitizeBody(body []byte, maxBytes int, contentType string) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) > maxBytes {
+		return fmt.Sprintf("<more than %d bytes of %s>", maxBytes, contentType)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("<%d bytes of %s>", len(body), contentType)
+	}
+	return redactJSON(value)
+}
+
+// redactJSON маскирует значения полей, которые могут содержать секреты.
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if isSensitive(name) {
+				v[name] = redacted
+			} else {
+				v[name] = redactJSON(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSON(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,331 @@
+// Package mlregistry реализует реестр моделей: учет версий артефактов моделей (reranker, footfall,
+// embedding) с метаданными в PostgreSQL и переключение активной версии без перезапуска сервиса.
+//
+// Артефакт версии задается uri: путь к локальному файлу, HTTP(S) URL объекта в хранилище
+// (например, presigned URL S3/MinIO) или адрес сервера моделей. Загруженная версия подменяет
+// модель в переключаемых обертках footfall.Swappable и embedding.Swappable, через которые
+// модели используются сервисами, поэтому запросы в процессе выполнения завершаются прежней версией.
+package mlregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+	"github.com/akozadaev/go_es_analytical_system/internal/footfall"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// Виды моделей реестра.
+const (
+	KindReranker  = "reranker"
+	KindFootfall  = "footfall"
+	KindEmbedding = "embedding"
+)
+
+var (
+	// ErrInvalid возвращается при некорректном описании версии модели.
+	ErrInvalid = errors.New("invalid model version")
+	// ErrNotFound возвращается, если версия модели отсутствует в реестре.
+	ErrNotFound = errors.New("model version not found")
+	// ErrExists возвращается при повторной регистрации версии модели.
+	ErrExists = errors.New("model version already exists")
+	// ErrLoad возвращается, если артефакт версии не удалось загрузить.
+	ErrLoad = errors.New("failed to load model version")
+)
+
+// versionPattern ограничивает версии символами, безопасными в имени файла артефакта.
+var versionPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// formats перечисляет допустимые форматы артефактов для каждого вида модели.
+var formats = map[string][]string{
+	KindReranker:  {"pmml", "onnx", "remote"},
+	KindFootfall:  {"pmml", "remote"},
+	KindEmbedding: {"hash", "http"},
+}
+
+// Registry хранит версии моделей и держит загруженными активные версии.
+type Registry struct {
+	store      *storage.PostgresStorage
+	dir        string
+	footfall   *footfall.Swappable
+	embedder   *embedding.Swappable
+	httpClient *http.Client
+
+	// mu упорядочивает загрузку версий при активации и проверке активных версий
+	mu     sync.Mutex
+	loaded map[string]string // Загруженная версия по виду модели
+}
+
+// New создает реестр. dir — каталог для артефактов, скачиваемых по HTTP(S);
+// footfallModel и embedder — модели из конфигурации, используемые до активации версий в реестре.
+func New(store *storage.PostgresStorage, dir string, footfallModel footfall.Model, embedder embedding.Provider) *Registry {
+	return &Registry{
+		store:      store,
+		dir:        dir,
+		footfall:   footfall.NewSwappable(footfallModel),
+		embedder:   embedding.NewSwappable(embedder),
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		loaded:     make(map[string]string),
+	}
+}
+
+// Footfall возвращает модель прогноза посещаемости, переключаемую реестром.
+func (r *Registry) Footfall() *footfall.Swappable { return r.footfall }
+
+// Embedder возвращает провайдер embeddings, переключаемый реестром.
+func (r *Registry) Embedder() embedding.Provider { return r.embedder }
+
+// LoadActive загружает активные версии из реестра, еще не загруженные экземпляром. Версия,
+// которую не удалось загрузить, пропускается с записью в лог, и остается прежняя модель.
+func (r *Registry) LoadActive(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, err := r.store.ActiveModelVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		if r.loaded[versions[i].Kind] == versions[i].Version {
+			continue
+		}
+		if err := r.load(ctx, &versions[i]); err != nil {
+			slog.ErrorContext(ctx, "Error loading model version", "kind", versions[i].Kind,
+				"version", versions[i].Version, logging.Err(err))
+			continue
+		}
+		slog.InfoContext(ctx, "Loaded model version", "kind", versions[i].Kind, "version", versions[i].Version)
+	}
+	return nil
+}
+
+// Runner возвращает фоновый процесс, который с интервалом interval загружает активные версии
+// (LoadActive): так активация на одном экземпляре доходит до остальных.
+func (r *Registry) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				if err := r.LoadActive(ctx); err != nil {
+					slog.ErrorContext(ctx, "Error loading active model versions", logging.Err(err))
+				}
+			}
+		}
+	}
+}
+
+// List возвращает версии моделей вида kind (всех видов при пустом kind).
+func (r *Registry) List(ctx context.Context, kind string) ([]models.ModelVersion, error) {
+	return r.store.ListModelVersions(ctx, kind)
+}
+
+// Register регистрирует новую версию модели без активации.
+func (r *Registry) Register(ctx context.Context, mv *models.ModelVersion) error {
+	if err := validate(mv); err != nil {
+		return err
+	}
+	err := r.store.CreateModelVersion(ctx, mv)
+	if errors.Is(err, storage.ErrModelVersionExists) {
+		return ErrExists
+	}
+	return err
+}
+
+// Activate загружает версию модели и делает ее активной. Если загрузка не удалась,
+// активной остается прежняя версия, а причина записывается в лог: ErrLoad ее не содержит,
+// чтобы не раскрывать клиенту пути и адреса артефактов. Другие экземпляры загружают версию
+// при следующей проверке (Runner).
+func (r *Registry) Activate(ctx context.Context, kind, version string) (*models.ModelVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mv, err := r.store.GetModelVersion(ctx, kind, version)
+	if errors.Is(err, storage.ErrModelVersionNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	swap, err := r.prepare(ctx, mv)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading model version", "kind", kind, "version", version, logging.Err(err))
+		return nil, ErrLoad
+	}
+	if err := r.store.ActivateModelVersion(ctx, kind, version); err != nil {
+		return nil, err
+	}
+	swap()
+	r.loaded[kind] = version
+
+	return r.store.GetModelVersion(ctx, kind, version)
+}
+
+// load загружает версию и сразу подменяет ей текущую модель.
+func (r *Registry) load(ctx context.Context, mv *models.ModelVersion) error {
+	swap, err := r.prepare(ctx, mv)
+	if err != nil {
+		return err
+	}
+	swap()
+	r.loaded[mv.Kind] = mv.Version
+	return nil
+}
+
+// prepare загружает артефакт версии и возвращает функцию, подменяющую им текущую модель.
+// Для reranker загрузка не выполняется: в сервисе нет переранжирования, и версия только учитывается.
+func (r *Registry) prepare(ctx context.Context, mv *models.ModelVersion) (func(), error) {
+	switch mv.Kind {
+	case KindFootfall:
+		model, err := r.loadFootfall(ctx, mv)
+		if err != nil {
+			return nil, err
+		}
+		return func() { r.footfall.Store(model) }, nil
+	case KindEmbedding:
+		provider, err := r.loadEmbedding(mv)
+		if err != nil {
+			return nil, err
+		}
+		return func() { r.embedder.Store(provider) }, nil
+	default:
+		return func() {}, nil
+	}
+}
+
+// loadFootfall загружает модель прогноза посещаемости. Прогнозы модели помечаются версией реестра.
+func (r *Registry) loadFootfall(ctx context.Context, mv *models.ModelVersion) (footfall.Model, error) {
+	if mv.Format == "remote" {
+		return footfall.NewRemoteModel(mv.URI, mv.Version), nil
+	}
+
+	path, err := r.fetch(ctx, mv)
+	if err != nil {
+		return nil, err
+	}
+	model, err := footfall.LoadPMML(path)
+	if err != nil {
+		return nil, err
+	}
+	return versionedModel{Model: model, version: mv.Version}, nil
+}
+
+// loadEmbedding создает провайдер embeddings. Версия реестра должна быть целым числом:
+// она записывается в embedding_version документов. Размерность задается metadata.dims,
+// по умолчанию сохраняется текущая — иначе векторы не поместятся в индекс.
+func (r *Registry) loadEmbedding(mv *models.ModelVersion) (embedding.Provider, error) {
+	version, err := strconv.Atoi(mv.Version)
+	if err != nil || version <= 0 {
+		return nil, fmt.Errorf("%w: embedding version must be a positive integer", ErrInvalid)
+	}
+	dims := r.embedder.Dimensions()
+	if d, ok := mv.Metadata["dims"].(float64); ok && d > 0 {
+		dims = int(d)
+	}
+	if mv.Format == "http" {
+		return embedding.NewHTTPProvider(mv.URI, version, dims), nil
+	}
+	return embedding.NewHashProvider(version, dims), nil
+}
+
+// fetch возвращает локальный путь к артефакту, скачивая его в dir, если uri — HTTP(S) URL.
+func (r *Registry) fetch(ctx context.Context, mv *models.ModelVersion) (string, error) {
+	if !strings.HasPrefix(mv.URI, "http://") && !strings.HasPrefix(mv.URI, "https://") {
+		return mv.URI, nil
+	}
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%s-%s.%s", mv.Kind, mv.Version, mv.Format))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create model directory: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mv.URI, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download model artifact: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return "", fmt.Errorf("error downloading model artifact: status %d", res.StatusCode)
+	}
+
+	// Запись во временный файл: незавершенная загрузка не должна попасть в кеш артефактов
+	tmp, err := os.CreateTemp(r.dir, "download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create model file: %w", err)
+	}
+	_, err = io.Copy(tmp, res.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write model artifact: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to save model artifact: %w", err)
+	}
+
+	return path, nil
+}
+
+// validate проверяет описание регистрируемой версии.
+func validate(mv *models.ModelVersion) error {
+	allowed, ok := formats[mv.Kind]
+	if !ok {
+		return fmt.Errorf("%w: kind must be one of reranker, footfall, embedding", ErrInvalid)
+	}
+	if !versionPattern.MatchString(mv.Version) {
+		return fmt.Errorf("%w: version must be 1-64 letters, digits, dots, dashes or underscores", ErrInvalid)
+	}
+	supported := false
+	for _, format := range allowed {
+		supported = supported || format == mv.Format
+	}
+	if !supported {
+		return fmt.Errorf("%w: format of %s model must be one of %s", ErrInvalid, mv.Kind, strings.Join(allowed, ", "))
+	}
+	if mv.URI == "" && mv.Format != "hash" {
+		return fmt.Errorf("%w: uri is required", ErrInvalid)
+	}
+	if mv.Kind == KindEmbedding {
+		if v, err := strconv.Atoi(mv.Version); err != nil || v <= 0 {
+			return fmt.Errorf("%w: embedding version must be a positive integer", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// versionedModel помечает прогнозы модели версией из реестра.
+type versionedModel struct {
+	footfall.Model
+	version string
+}
+
+func (vm versionedModel) Version() string { return vm.version }
@@ -1,7 +1,16 @@
 // Package models содержит модели данных для рекомендательной системы локаций.
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// APIV1Prefix — префикс путей версии 1 HTTP API. Ссылки в ответах API строятся с этим префиксом.
+const APIV1Prefix = "/api/v1"
 
 // Location представляет локацию в Elasticsearch.
 // Содержит информацию о географическом положении, подходящих типах бизнеса,
@@ -19,9 +28,180 @@ type Location struct {
 	CompetitionDensity    float64      `json:"competition_density"`
 	Demographics          Demographics `json:"demographics"`
 	Embedding             []float64    `json:"embedding,omitempty"`
+	EmbeddingVersion      int          `json:"embedding_version,omitempty"`     // Версия модели, построившей Embedding
+	DemographicEmbedding  []float64    `json:"demographic_embedding,omitempty"` // Вектор демографического профиля, вычисляется при записи
 	CreatedAt             time.Time    `json:"created_at"`
 	UpdatedAt             time.Time    `json:"updated_at"`
-	Score                 float64      `json:"score,omitempty"` // Для ранжирования
+	Status                string       `json:"status,omitempty"`              // Состояние жизненного цикла, пусто — LocationPublished
+	PublishAt             *time.Time   `json:"publish_at,omitempty"`          // Начало показа опубликованной локации, nil — сразу
+	ExpireAt              *time.Time   `json:"expire_at,omitempty"`           // Окончание показа, после него локация снимается с публикации
+	Score                 float64      `json:"score,omitempty"`               // Для ранжирования
+	Archived              bool         `json:"archived,omitempty"`            // Локация найдена в архивном индексе
+	TravelTimeSeconds     float64      `json:"travel_time_seconds,omitempty"` // Время в пути от точки отсчета запроса
+	// Seasonality — коэффициенты трафика по месяцам (ключ — номер месяца 1–12); переопределяют
+	// коэффициенты города и типа бизнеса
+	Seasonality map[int]float64 `json:"seasonality,omitempty"`
+	// EventExposure — близость к площадкам мероприятий с учетом их частоты и вместимости (0–10)
+	EventExposure float64 `json:"event_exposure,omitempty"`
+	// SchoolsNearby и UniversitiesNearby — число учебных заведений в пешей доступности
+	SchoolsNearby      int `json:"schools_nearby,omitempty"`
+	UniversitiesNearby int `json:"universities_nearby,omitempty"`
+	// StreetParkingScore — доступность уличной парковки (0–10), PaidLotsNearby — число платных парковок рядом
+	StreetParkingScore float64 `json:"street_parking_score,omitempty"`
+	PaidLotsNearby     int     `json:"paid_lots_nearby,omitempty"`
+	// SafetyScore — индекс безопасности района локации (0–10, выше — безопаснее)
+	SafetyScore float64 `json:"safety_score,omitempty"`
+	// ExpectedDailyVisitors — прогноз посещаемости для типа бизнеса запроса, если он учитывался в ранжировании
+	ExpectedDailyVisitors float64 `json:"expected_daily_visitors,omitempty"`
+	// MatchedSegments — демографические сегменты, подошедшие под фильтр segment запроса
+	MatchedSegments []DemographicSegment `json:"matched_segments,omitempty"`
+	// DemographicsHistory — версии демографических данных по возрастанию EffectiveFrom;
+	// последняя совпадает с Demographics
+	DemographicsHistory []DemographicsSnapshot `json:"demographics_history,omitempty"`
+
+	// present — числовые поля профилей проверки, заданные во входных данных (nil — неизвестно)
+	present map[string]bool
+}
+
+// MarkPresentJSON отмечает заданными числовые поля профилей проверки, присутствующие в JSON data,
+// из которого декодирована локация: обязательное поле со значением 0 заполнено, а отсутствующее — нет.
+func (l *Location) MarkPresentJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var demographics map[string]json.RawMessage
+	if raw, ok := fields["demographics"]; ok {
+		if err := json.Unmarshal(raw, &demographics); err != nil {
+			return err
+		}
+	}
+	l.present = make(map[string]bool)
+	for field := range profileNumericFields {
+		raw, ok := fields[field]
+		if name, nested := strings.CutPrefix(field, "demographics."); nested {
+			raw, ok = demographics[name]
+		}
+		if ok && string(raw) != "null" {
+			l.present[field] = true
+		}
+	}
+	// Средний доход рассчитывается по демографическим сегментам (см. Demographics.Summarize)
+	if raw, ok := demographics["segments"]; ok && string(raw) != "null" && string(raw) != "[]" {
+		l.present["demographics.average_income"] = true
+	}
+	return nil
+}
+
+// MarkPresent отмечает числовые поля профилей проверки (имена как в профиле) заданными
+// во входных данных.
+func (l *Location) MarkPresent(fields ...string) {
+	if l.present == nil {
+		l.present = make(map[string]bool)
+	}
+	for _, field := range fields {
+		l.present[field] = true
+	}
+}
+
+// DemographicsSnapshot — версия демографических данных района, действующая с EffectiveFrom
+// до начала следующей версии.
+type DemographicsSnapshot struct {
+	EffectiveFrom time.Time    `json:"effective_from"`
+	Demographics  Demographics `json:"demographics"`
+}
+
+// RecordDemographics добавляет текущие демографические данные в историю версий с датой
+// effectiveFrom, если они отличаются от последней версии. Пустые данные без истории не записываются.
+func (l *Location) RecordDemographics(effectiveFrom time.Time) {
+	n := len(l.DemographicsHistory)
+	if n == 0 && reflect.DeepEqual(l.Demographics, Demographics{}) {
+		return
+	}
+	if n > 0 && reflect.DeepEqual(l.DemographicsHistory[n-1].Demographics, l.Demographics) {
+		return
+	}
+	l.DemographicsHistory = append(l.DemographicsHistory, DemographicsSnapshot{
+		EffectiveFrom: effectiveFrom,
+		Demographics:  l.Demographics,
+	})
+}
+
+// Состояния жизненного цикла локации. Черновик виден только редакторам, опубликованная
+// локация участвует в поиске, снятая с публикации исключается из всех выдач.
+const (
+	LocationDraft     = "draft"
+	LocationPublished = "published"
+	LocationRetired   = "retired"
+)
+
+// locationStatusTransitions перечисляет допустимые переходы между состояниями локации.
+var locationStatusTransitions = map[string][]string{
+	LocationDraft:     {LocationPublished, LocationRetired},
+	LocationPublished: {LocationRetired},
+	LocationRetired:   {LocationDraft},
+}
+
+// IsLocationStatus сообщает, является ли status состоянием жизненного цикла локации.
+func IsLocationStatus(status string) bool {
+	_, ok := locationStatusTransitions[status]
+	return ok
+}
+
+// LocationStatusTransitionAllowed сообщает, допустим ли переход локации из состояния from в to.
+func LocationStatusTransitionAllowed(from, to string) bool {
+	for _, allowed := range locationStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentStatus возвращает состояние жизненного цикла локации; документы без состояния,
+// записанные до появления жизненного цикла, считаются опубликованными.
+func (l *Location) CurrentStatus() string {
+	if l.Status == "" {
+		return LocationPublished
+	}
+	return l.Status
+}
+
+// Published сообщает, участвует ли локация в поиске сейчас.
+func (l *Location) Published() bool {
+	return l.PublishedAt(time.Now())
+}
+
+// PublishedAt сообщает, участвует ли локация в поиске в момент t: она опубликована,
+// срок показа уже начался (PublishAt) и еще не закончился (ExpireAt).
+func (l *Location) PublishedAt(t time.Time) bool {
+	if l.CurrentStatus() != LocationPublished {
+		return false
+	}
+	if l.PublishAt != nil && l.PublishAt.After(t) {
+		return false
+	}
+	return l.ExpireAt == nil || l.ExpireAt.After(t)
+}
+
+// DemographicsAt возвращает демографические данные, действовавшие в момент t, — последнюю версию
+// истории с EffectiveFrom не позже t. Без истории возвращаются текущие данные;
+// ok == false, если t раньше первой версии.
+func (l *Location) DemographicsAt(t time.Time) (Demographics, bool) {
+	if len(l.DemographicsHistory) == 0 {
+		return l.Demographics, true
+	}
+	var found *DemographicsSnapshot
+	for i := range l.DemographicsHistory {
+		snapshot := &l.DemographicsHistory[i]
+		if !snapshot.EffectiveFrom.After(t) && (found == nil || snapshot.EffectiveFrom.After(found.EffectiveFrom)) {
+			found = snapshot
+		}
+	}
+	if found == nil {
+		return Demographics{}, false
+	}
+	return found.Demographics, true
 }
 
 // GeoPoint представляет географические координаты точки на карте.
@@ -31,23 +211,221 @@ type GeoPoint struct {
 	Lon float64 `json:"lon"` // Долгота (longitude)
 }
 
+// GeoBoundingBox представляет прямоугольную область карты, например видимую часть.
+// Если левая граница восточнее правой, область пересекает 180-й меридиан.
+type GeoBoundingBox struct {
+	TopLeft     GeoPoint `json:"top_left"`     // Северо-западный угол
+	BottomRight GeoPoint `json:"bottom_right"` // Юго-восточный угол
+}
+
 // Demographics представляет демографические данные района локации.
 // Используется для анализа целевой аудитории и соответствия типу бизнеса.
+// Профиль района задается сегментами; AgeGroup и AverageIncome — их сводка (см. Summarize).
 type Demographics struct {
-	AgeGroup          string   `json:"age_group"`
-	AverageIncome     float64  `json:"average_income"`
-	Interests         []string `json:"interests"`
-	PopulationDensity float64  `json:"population_density"`
+	AgeGroup          string               `json:"age_group"`      // Преобладающая возрастная группа
+	AverageIncome     float64              `json:"average_income"` // Средний доход, взвешенный по долям сегментов
+	Interests         []string             `json:"interests"`
+	PopulationDensity float64              `json:"population_density"`
+	Segments          []DemographicSegment `json:"segments,omitempty"`
+}
+
+// DemographicSegment — демографический сегмент населения района: возрастная группа,
+// ее доля в населении и средний доход группы.
+type DemographicSegment struct {
+	AgeGroup      string  `json:"age_group"`
+	Share         float64 `json:"share"` // Доля населения района, 0–1
+	AverageIncome float64 `json:"average_income"`
+}
+
+// Summarize согласует сегменты и сводку профиля района. Без сегментов из AgeGroup и
+// AverageIncome строится единственный сегмент с долей 1, чтобы локация находилась запросами
+// по сегментам; с сегментами AgeGroup — группа с наибольшей долей, AverageIncome —
+// средний доход, взвешенный по долям.
+func (d *Demographics) Summarize() {
+	if len(d.Segments) == 0 {
+		if d.AgeGroup != "" {
+			d.Segments = []DemographicSegment{{AgeGroup: d.AgeGroup, Share: 1, AverageIncome: d.AverageIncome}}
+		}
+		return
+	}
+
+	var top DemographicSegment
+	var shares, income float64
+	for _, segment := range d.Segments {
+		if segment.Share > top.Share {
+			top = segment
+		}
+		shares += segment.Share
+		income += segment.Share * segment.AverageIncome
+	}
+	d.AgeGroup = top.AgeGroup
+	if shares > 0 {
+		d.AverageIncome = income / shares
+	}
 }
 
 // BusinessType представляет тип бизнеса из справочника PostgreSQL.
 // Используется для фильтрации и рекомендаций локаций.
 type BusinessType struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 int       `json:"id"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"`
+	BenefitsFromEvents bool      `json:"benefits_from_events"` // Бизнес выигрывает от потока посетителей мероприятий
+	ParentID           *int      `json:"parent_id,omitempty"`  // Категория, к которой относится подтип
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// BusinessTypeSubstitute описывает частичную замещаемость типов бизнеса: Substitute конкурирует
+// с BusinessType с долей Weight (0–1]. Связь направленная.
+type BusinessTypeSubstitute struct {
+	BusinessType string    `json:"business_type"`
+	Substitute   string    `json:"substitute"`
+	Weight       float64   `json:"weight"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ValidationProfile — профиль проверки локаций типа бизнеса: обязательные поля и допустимые
+// диапазоны числовых полей. Профиль применяется к локациям, у которых BusinessType входит
+// в business_types_suitable.
+type ValidationProfile struct {
+	BusinessType string       `json:"business_type"`
+	Required     []string     `json:"required,omitempty"` // Поля, которые должны быть заполнены
+	Ranges       []FieldRange `json:"ranges,omitempty"`   // Допустимые диапазоны числовых полей
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// FieldViolation — нарушение профиля проверки в поле локации.
+type FieldViolation struct {
+	Field        string `json:"field"`
+	BusinessType string `json:"business_type,omitempty"` // Тип бизнеса, профиль которого нарушен
+	Message      string `json:"message"`
+}
+
+// profileNumericFields перечисляет числовые поля локации, для которых задаются диапазоны.
+// Числовое поле считается заполненным, если оно присутствовало во входных данных (см. MarkPresentJSON
+// и MarkPresent); если они неизвестны — если его значение отлично от нуля.
+var profileNumericFields = map[string]func(*Location) float64{
+	"traffic_score":                   func(l *Location) float64 { return l.TrafficScore },
+	"competition_density":             func(l *Location) float64 { return l.CompetitionDensity },
+	"event_exposure":                  func(l *Location) float64 { return l.EventExposure },
+	"schools_nearby":                  func(l *Location) float64 { return float64(l.SchoolsNearby) },
+	"universities_nearby":             func(l *Location) float64 { return float64(l.UniversitiesNearby) },
+	"street_parking_score":            func(l *Location) float64 { return l.StreetParkingScore },
+	"paid_lots_nearby":                func(l *Location) float64 { return float64(l.PaidLotsNearby) },
+	"safety_score":                    func(l *Location) float64 { return l.SafetyScore },
+	"demographics.average_income":     func(l *Location) float64 { return l.Demographics.AverageIncome },
+	"demographics.population_density": func(l *Location) float64 { return l.Demographics.PopulationDensity },
+}
+
+// profileTextFields перечисляет остальные поля локации, которые можно сделать обязательными.
+var profileTextFields = map[string]func(*Location) bool{
+	"address":                func(l *Location) bool { return strings.TrimSpace(l.Address) != "" },
+	"city":                   func(l *Location) bool { return strings.TrimSpace(l.City) != "" },
+	"description":            func(l *Location) bool { return strings.TrimSpace(l.Description) != "" },
+	"demographics.age_group": func(l *Location) bool { return l.Demographics.AgeGroup != "" },
+	"demographics.interests": func(l *Location) bool { return len(l.Demographics.Interests) > 0 },
+	"demographics.segments":  func(l *Location) bool { return len(l.Demographics.Segments) > 0 },
+	"seasonality":            func(l *Location) bool { return len(l.Seasonality) > 0 },
+}
+
+// IsProfileField сообщает, можно ли сделать поле field обязательным в профиле проверки.
+func IsProfileField(field string) bool {
+	_, numeric := profileNumericFields[field]
+	_, text := profileTextFields[field]
+	return numeric || text
+}
+
+// IsProfileRangeField сообщает, можно ли задать диапазон поля field в профиле проверки.
+func IsProfileRangeField(field string) bool {
+	_, ok := profileNumericFields[field]
+	return ok
+}
+
+// Check проверяет локацию по профилю и возвращает нарушения в порядке полей профиля:
+// сначала обязательные поля, затем диапазоны.
+func (p *ValidationProfile) Check(location *Location) []FieldViolation {
+	var violations []FieldViolation
+	for _, field := range p.Required {
+		filled := false
+		if value, ok := profileNumericFields[field]; ok {
+			if location.present != nil {
+				filled = location.present[field]
+			} else {
+				filled = value(location) != 0
+			}
+		} else if present, ok := profileTextFields[field]; ok {
+			filled = present(location)
+		}
+		if !filled {
+			violations = append(violations, FieldViolation{Field: field, BusinessType: p.BusinessType, Message: "is required"})
+		}
+	}
+
+	for _, r := range p.Ranges {
+		value, ok := profileNumericFields[r.Field]
+		if !ok {
+			continue
+		}
+		v := value(location)
+		switch {
+		case r.Gte != nil && v < *r.Gte:
+			violations = append(violations, FieldViolation{Field: r.Field, BusinessType: p.BusinessType,
+				Message: fmt.Sprintf("must be at least %g, got %g", *r.Gte, v)})
+		case r.Lte != nil && v > *r.Lte:
+			violations = append(violations, FieldViolation{Field: r.Field, BusinessType: p.BusinessType,
+				Message: fmt.Sprintf("must be at most %g, got %g", *r.Lte, v)})
+		}
+	}
+	return violations
+}
+
+// CheckProfiles проверяет локацию по профилям ее типов бизнеса из profiles (ключ — тип бизнеса).
+func CheckProfiles(location *Location, profiles map[string]*ValidationProfile) []FieldViolation {
+	var violations []FieldViolation
+	for _, businessType := range location.BusinessTypesSuitable {
+		if profile, ok := profiles[businessType]; ok {
+			violations = append(violations, profile.Check(location)...)
+		}
+	}
+	return violations
+}
+
+// Substitution сообщает, что для запрошенного типа бизнеса подходящих локаций не нашлось
+// и выдача построена по замещающему типу.
+type Substitution struct {
+	BusinessType string  `json:"business_type"` // Запрошенный тип бизнеса
+	Substitute   string  `json:"substitute"`    // Тип бизнеса, по которому построена выдача
+	Weight       float64 `json:"weight"`        // Доля замещаемости
+}
+
+// EducationInstitution представляет учебное заведение: школу или университет (включая колледжи).
+type EducationInstitution struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"` // school или university
+	Coordinates GeoPoint `json:"coordinates"`
+}
+
+// DistrictSafety представляет индекс безопасности района города по данным внешнего датасета.
+// Локации относятся к району с ближайшим центром в том же городе.
+type DistrictSafety struct {
+	City        string   `json:"city"`
+	District    string   `json:"district"`
+	Center      GeoPoint `json:"center"`
+	SafetyScore float64  `json:"safety_score"` // 0–10, выше — безопаснее
+}
+
+// EventVenue представляет площадку мероприятий (стадион, выставочный центр, концертный зал).
+type EventVenue struct {
+	ID            int64    `json:"id"`
+	Name          string   `json:"name"`
+	Kind          string   `json:"kind"`
+	Coordinates   GeoPoint `json:"coordinates"`
+	EventsPerYear int      `json:"events_per_year"`
+	Capacity      int      `json:"capacity"`
 }
 
 // Region представляет регион из справочника PostgreSQL.
@@ -60,18 +438,1084 @@ type Region struct {
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// Порядок сортировки справочников в ReferenceQuery.Sort; префикс "-" — по убыванию.
+const (
+	ReferenceSortName = "name"
+	ReferenceSortID   = "id"
+)
+
+// ReferenceQuery — параметры постраничной выборки справочника типов бизнеса или регионов.
+type ReferenceQuery struct {
+	Limit    int    // Размер страницы (0 — по умолчанию)
+	Offset   int    // Число пропускаемых записей
+	Prefix   string // Начало имени без учета регистра
+	ParentID *int   // Родитель записи; 0 — записи верхнего уровня
+	Sort     string // name, -name, id или -id (по умолчанию name)
+	All      bool   // Все отобранные записи без постраничной выборки (Limit и Offset не заданы)
+}
+
+// BusinessTypePage — страница справочника типов бизнеса.
+type BusinessTypePage struct {
+	Items  []BusinessType `json:"items"`
+	Total  int            `json:"total"` // Число записей, подходящих под фильтр
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// RegionPage — страница справочника регионов.
+type RegionPage struct {
+	Items  []Region `json:"items"`
+	Total  int      `json:"total"` // Число записей, подходящих под фильтр
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
+// City представляет город из справочника PostgreSQL. Имена городов уникальны в пределах региона.
+type City struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	RegionID   int       `json:"region_id"`
+	Region     string    `json:"region,omitempty"` // Название региона, заполняется при чтении
+	Center     GeoPoint  `json:"center"`           // Координаты центра города
+	Population int       `json:"population"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CityPage — страница справочника городов.
+type CityPage struct {
+	Items  []City `json:"items"`
+	Total  int    `json:"total"` // Число записей, подходящих под фильтр
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// KnownCities — города справочника по названиям регионов для проверки города локации.
+// Названия хранятся нормализованными (см. NormalizeCityName).
+type KnownCities map[string]map[string]bool
+
+// NewKnownCities строит KnownCities по городам справочника.
+func NewKnownCities(cities []City) KnownCities {
+	known := make(KnownCities)
+	for _, city := range cities {
+		region := NormalizeCityName(city.Region)
+		if known[region] == nil {
+			known[region] = make(map[string]bool)
+		}
+		known[region][NormalizeCityName(city.Name)] = true
+	}
+	return known
+}
+
+// Allows сообщает, допустим ли город city для локации региона region. Пустой город и города
+// регионов, для которых справочник городов не заполнен, не проверяются. Названия сравниваются
+// без учета регистра и окружающих пробелов, как при поиске города по имени.
+func (k KnownCities) Allows(region, city string) bool {
+	cities, ok := k[NormalizeCityName(region)]
+	return city == "" || !ok || cities[NormalizeCityName(city)]
+}
+
+// NormalizeCityName приводит название города или региона к виду для сравнения: без окружающих
+// пробелов и в нижнем регистре.
+func NormalizeCityName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// RegionPathSeparator разделяет имена регионов в пути региона от корня иерархии.
+const RegionPathSeparator = "/"
+
+// ReferenceData — переносимая выгрузка справочников типов бизнеса и регионов для синхронизации
+// окружений. Записи ссылаются на родителей по именам, а не по ID, которые в окружениях различаются.
+type ReferenceData struct {
+	BusinessTypes []ReferenceBusinessType `json:"business_types"`
+	Regions       []ReferenceRegion       `json:"regions"`
+	Cities        []ReferenceCity         `json:"cities"`
+}
+
+// ReferenceBusinessType — тип бизнеса в выгрузке справочников.
+type ReferenceBusinessType struct {
+	Name               string `json:"name"`
+	Description        string `json:"description,omitempty"`
+	BenefitsFromEvents bool   `json:"benefits_from_events,omitempty"`
+	Parent             string `json:"parent,omitempty"` // Имя категории
+}
+
+// ReferenceRegion — регион в выгрузке справочников. Регион идентифицируется путем (Path),
+// так как имена регионов уникальны только в пределах родителя.
+type ReferenceRegion struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent,omitempty"` // Путь родительского региона, например "Россия/Ленинградская область"
+}
+
+// ReferenceCity — город в выгрузке справочников. Город идентифицируется путем региона и именем.
+type ReferenceCity struct {
+	Name       string   `json:"name"`
+	Region     string   `json:"region"` // Путь региона города
+	Center     GeoPoint `json:"center"`
+	Population int      `json:"population"`
+}
+
+// Key возвращает путь города: путь региона и имя города.
+func (c ReferenceCity) Key() string {
+	return c.Region + RegionPathSeparator + c.Name
+}
+
+// Path возвращает путь региона от корня иерархии.
+func (r ReferenceRegion) Path() string {
+	if r.Parent == "" {
+		return r.Name
+	}
+	return r.Parent + RegionPathSeparator + r.Name
+}
+
+// RegionPaths возвращает пути регионов по их ID. Регионы, родитель которых отсутствует
+// в списке, считаются корневыми; ссылки по кругу обрываются на повторном регионе.
+func RegionPaths(regions []Region) map[int]string {
+	byID := make(map[int]Region, len(regions))
+	for _, region := range regions {
+		byID[region.ID] = region
+	}
+	paths := make(map[int]string, len(regions))
+	var path func(id int, seen map[int]bool) string
+	path = func(id int, seen map[int]bool) string {
+		if p, ok := paths[id]; ok {
+			return p
+		}
+		region := byID[id]
+		p := region.Name
+		if parentID := region.ParentRegionID; parentID != nil && !seen[*parentID] {
+			if _, ok := byID[*parentID]; ok {
+				seen[id] = true
+				p = path(*parentID, seen) + RegionPathSeparator + region.Name
+			}
+		}
+		paths[id] = p
+		return p
+	}
+	for _, region := range regions {
+		path(region.ID, map[int]bool{})
+	}
+	return paths
+}
+
+// ReferenceDiff — различия между справочниками окружения и импортируемой выгрузкой.
+type ReferenceDiff struct {
+	DryRun        bool             `json:"dry_run"` // Изменения только рассчитаны, но не применены
+	Prune         bool             `json:"prune"`   // Записи, отсутствующие в выгрузке, удаляются
+	BusinessTypes ReferenceChanges `json:"business_types"`
+	Regions       ReferenceChanges `json:"regions"`
+	Cities        ReferenceChanges `json:"cities"`
+	// Cascade — изменения связанных записей, которые вызовет удаление записей с Prune
+	Cascade ReferenceCascade `json:"cascade"`
+}
+
+// ReferenceCascade — записи, которые PostgreSQL изменяет каскадно при удалении типов бизнеса.
+type ReferenceCascade struct {
+	// Substitutes — связи замещаемости "тип/заменитель", удаляемые вместе с типами бизнеса
+	Substitutes []string `json:"substitutes"`
+	// DetachedBusinessTypes — оставшиеся типы бизнеса, которые теряют удаляемую категорию (parent_id = NULL)
+	DetachedBusinessTypes []string `json:"detached_business_types"`
+}
+
+// ReferenceChanges — изменения одного справочника: имена (для регионов и городов — пути) добавляемых,
+// изменяемых и отсутствующих в выгрузке записей. Отсутствующие записи удаляются только с Prune.
+type ReferenceChanges struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
 // RecommendRequest представляет запрос на получение рекомендаций локаций.
 // Все поля, кроме City, являются обязательными.
 type RecommendRequest struct {
-	Region       string `json:"region"`        // Регион для поиска (обязательно)
-	City         string `json:"city,omitempty"` // Город для фильтрации (опционально)
-	BusinessType string `json:"business_type"`  // Тип бизнеса (обязательно)
+	Region       string `json:"region"`          // Регион для поиска (обязательно)
+	City         string `json:"city,omitempty"`  // Город для фильтрации (опционально)
+	BusinessType string `json:"business_type"`   // Тип бизнеса (обязательно)
 	Limit        int    `json:"limit,omitempty"` // Максимальное количество результатов (по умолчанию 20)
+	// Cursor — next_cursor предыдущей страницы для получения следующей; остальные поля запроса
+	// должны совпадать с запросом первой страницы
+	Cursor string `json:"cursor,omitempty"`
+	// IncludeArchived включает в поиск локации из архивного индекса
+	IncludeArchived bool `json:"include_archived,omitempty"`
+	// MinDistanceMeters — минимальное расстояние между локациями в выдаче, метры (0 — без ограничения).
+	// Используется сетями, планирующими несколько открытий одновременно.
+	MinDistanceMeters float64 `json:"min_distance_meters,omitempty"`
+	// Origin — точка отсчета: при указании выдача ранжируется по времени в пути от нее
+	Origin *GeoPoint `json:"origin,omitempty"`
+	// DistanceFromCityCenter: false отключает фактор расстояния от центра города City из справочника
+	// городов, по умолчанию учитываемый в запросах без Origin
+	DistanceFromCityCenter *bool `json:"distance_from_city_center,omitempty"`
+	// RadiusMeters оставляет только локации не дальше заданного расстояния от Origin по прямой (0 — без ограничения)
+	RadiusMeters float64 `json:"radius_meters,omitempty"`
+	// BoundingBox оставляет только локации внутри прямоугольной области карты
+	BoundingBox *GeoBoundingBox `json:"geo_bounding_box,omitempty"`
+	// Polygon оставляет только локации внутри многоугольника (например, границы района);
+	// вершины перечисляются по порядку, многоугольник замыкается автоматически
+	Polygon []GeoPoint `json:"geo_polygon,omitempty"`
+	// MaxTravelMinutes исключает локации дальше заданного времени в пути от Origin (0 — без ограничения)
+	MaxTravelMinutes float64 `json:"max_travel_minutes,omitempty"`
+	// TargetMonth — месяц открытия (1–12): оценка корректируется сезонным коэффициентом трафика
+	TargetMonth int `json:"target_month,omitempty"`
+	// HasParking оставляет только локации с платной парковкой рядом или уличной парковкой не хуже среднего
+	HasParking bool `json:"has_parking,omitempty"`
+	// MinParkingScore — минимальная оценка уличной парковки (0–10)
+	MinParkingScore float64 `json:"min_parking_score,omitempty"`
+	// MinSafety — минимальный индекс безопасности района (0–10)
+	MinSafety float64 `json:"min_safety,omitempty"`
+	// SafetyWeight — бустинг локаций в безопасных районах (0 — без бустинга)
+	SafetyWeight float64 `json:"safety_weight,omitempty"`
+	// FootfallWeight — доля прогноза посещаемости в итоговой оценке (0–1, 0 — прогноз не учитывается)
+	FootfallWeight float64 `json:"footfall_weight,omitempty"`
+	// AutoCorrect при пустой выдаче заменяет регион и город с опечаткой ближайшим известным названием
+	AutoCorrect bool `json:"autocorrect,omitempty"`
+	// MinResults — минимум результатов: при меньшем числе ограничения запроса ослабляются
+	// в порядке политики сервиса (0 — значение по умолчанию из конфигурации)
+	MinResults int `json:"min_results,omitempty"`
+	// EventBoost включает бустинг локаций рядом с площадкам мероприятий;
+	// по умолчанию определяется признаком benefits_from_events типа бизнеса
+	EventBoost *bool `json:"event_boost,omitempty"`
+	// UseEmbedding ранжирует локации по сходству их embedding с QueryVector и (или) вектора
+	// демографического профиля с TargetDemographics вместо бустинга по показателям;
+	// фильтры запроса сохраняются
+	UseEmbedding bool `json:"use_embedding,omitempty"`
+	// QueryVector — вектор запроса размерности embeddings индекса (с UseEmbedding обязателен
+	// QueryVector или TargetDemographics)
+	QueryVector []float64 `json:"query_vector,omitempty"`
+	// Similarity — метрика сходства QueryVector: cosine, dot_product или l2_norm;
+	// не указана — метрика, с которой построен kNN индекс
+	Similarity string `json:"similarity,omitempty"`
+	// TargetDemographics — целевой демографический профиль района: с UseEmbedding локации
+	// ранжируются по сходству с ним их вектора демографического профиля
+	TargetDemographics *Demographics `json:"target_demographics,omitempty"`
+	// VectorWeights — веса сходства по описанию и по демографии, если заданы оба вектора;
+	// не указаны — веса профиля ранжирования типа бизнеса
+	VectorWeights *VectorWeights `json:"vector_weights,omitempty"`
+	// Intent — описание искомого места свободным текстом («тихий спальный район рядом с метро
+	// для кофейни»): текст переводится в embedding настроенным провайдером, и сходство с ним
+	// embedding локаций добавляется к оценке по показателям; фильтры запроса сохраняются
+	Intent string `json:"intent,omitempty"`
+	// IntentWeight — доля сходства с Intent в оценке (0–1], по умолчанию 0.5
+	IntentWeight float64 `json:"intent_weight,omitempty"`
+	// Weights — веса факторов ранжирования; не указаны — веса по умолчанию
+	Weights *ScoringWeights `json:"weights,omitempty"`
+	// FieldRanges оставляет только локации со значениями полей в заданных границах;
+	// допускаются числовые поля индекса и вычисляемые поля (runtime fields)
+	FieldRanges []FieldRange `json:"field_ranges,omitempty"`
+	// Segment оставляет только локации, в районе которых есть подходящий демографический сегмент;
+	// подошедшие сегменты возвращаются в matched_segments
+	Segment *SegmentFilter `json:"segment,omitempty"`
+	// AsOf рассчитывает фактор демографии по версии демографических данных, действовавшей
+	// в указанный момент (для аудита прошлых рекомендаций); локации возвращаются с этой версией
+	AsOf *time.Time `json:"as_of,omitempty"`
+	// DemographicVector — вектор профиля TargetDemographics, заполняется сервисом
+	DemographicVector []float64 `json:"-"`
+	// IntentVector — embedding Intent, заполняется сервисом
+	IntentVector []float64 `json:"-"`
+	// IntentEmbeddingVersion — версия модели IntentVector: сходство считается только с embeddings
+	// локаций этой версии, заполняется сервисом
+	IntentEmbeddingVersion int `json:"-"`
+	// CityCenter — центр города City из справочника городов, заполняется сервисом
+	// без Origin, если DistanceFromCityCenter не выключен: служит точкой отсчета фактора расстояния
+	CityCenter *GeoPoint `json:"-"`
+	// Seasonal — коэффициенты города и типа бизнеса для TargetMonth, заполняются сервисом
+	Seasonal *SeasonalCoefficients `json:"-"`
+	// Boosts — профиль ранжирования типа бизнеса, заполняется сервисом
+	Boosts []ScoringBoost `json:"-"`
+	// BoostOverrides — бусты полей организации и клиента поверх профиля ранжирования
+	// в порядке возрастания приоритета, заполняются сервисом
+	BoostOverrides []ScoringBoost `json:"-"`
+	// BusinessTypes — BusinessType вместе с его подтипами и категориями по таксономии,
+	// заполняется сервисом; пусто — фильтр только по BusinessType
+	BusinessTypes []string `json:"-"`
+	// SearchAfter — значения сортировки последней локации предыдущей страницы, заполняется сервисом из Cursor
+	SearchAfter []interface{} `json:"-"`
+	// MaxScore — оценка лучшей локации первой страницы, заполняется сервисом из Cursor:
+	// оценки всех страниц нормализуются относительно нее
+	MaxScore float64 `json:"-"`
+}
+
+// ScoringWeights задает веса факторов ранжирования: трафика, низкой конкуренции,
+// плотности населения и близости к точке отсчета. Веса относительные: важно их соотношение,
+// а не сумма.
+type ScoringWeights struct {
+	Traffic      float64 `json:"traffic"`
+	Competition  float64 `json:"competition"`
+	Demographics float64 `json:"demographics"`
+	Distance     float64 `json:"distance,omitempty"` // Учитывается только с origin
+}
+
+// VectorWeights — веса сходства векторов локации в векторном поиске: embedding описания
+// и вектора демографического профиля.
+type VectorWeights struct {
+	Description  float64 `json:"description"`
+	Demographics float64 `json:"demographics"`
+}
+
+// ScoringBoost — правило профиля ранжирования: локации типа бизнеса BusinessType
+// со значением числового поля Field не меньше Min получают бустинг Boost.
+type ScoringBoost struct {
+	BusinessType string  `json:"business_type"`
+	Field        string  `json:"field"`
+	Min          float64 `json:"min_value"`
+	Boost        float64 `json:"boost"`
+}
+
+// RankingProfile — профиль ранжирования типа бизнеса целиком: бустинг числовых полей
+// (scoring_boosts) и веса векторного поиска (scoring_vector_weights; nil — равные веса).
+type RankingProfile struct {
+	BusinessType  string         `json:"business_type"`
+	Boosts        []ScoringBoost `json:"boosts"`
+	VectorWeights *VectorWeights `json:"vector_weights,omitempty"`
+}
+
+// RankingOverride — ранжирование по умолчанию организации или отдельного клиента (API ключа):
+// веса факторов для запросов без weights и бусты полей поверх профиля ранжирования типа бизнеса.
+// Значения клиента имеют приоритет над значениями организации.
+type RankingOverride struct {
+	Organization string          `json:"organization"`
+	Subject      string          `json:"subject,omitempty"` // Пусто — значения для всей организации
+	Weights      *ScoringWeights `json:"weights,omitempty"`
+	// Boosts заменяют правила профиля с тем же полем; пустой business_type — для всех типов бизнеса
+	Boosts    []ScoringBoost `json:"boosts"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ClusterRole — роль экземпляра сервиса в кластере.
+type ClusterRole string
+
+const (
+	ClusterRoleLeader   ClusterRole = "leader"   // Выполняет фоновые процессы и обслуживает HTTP
+	ClusterRoleFollower ClusterRole = "follower" // Только обслуживает HTTP
+)
+
+// ClusterInstance — экземпляр сервиса и его роль.
+type ClusterInstance struct {
+	ID          string      `json:"id"`
+	Role        ClusterRole `json:"role"`
+	Version     string      `json:"version"`
+	StartedAt   time.Time   `json:"started_at"`
+	HeartbeatAt time.Time   `json:"heartbeat_at"`
+}
+
+// ClusterStatus — состояние кластера с точки зрения ответившего экземпляра.
+type ClusterStatus struct {
+	LeaderElection bool              `json:"leader_election"` // false — каждый экземпляр выполняет фоновые процессы
+	Instance       string            `json:"instance"`        // Экземпляр, ответивший на запрос
+	Leader         string            `json:"leader,omitempty"`
+	Instances      []ClusterInstance `json:"instances"` // Экземпляры с недавним heartbeat
+}
+
+// SeasonalCoefficient представляет коэффициент сезонности трафика для города и типа бизнеса в месяце.
+// Пустой City задает коэффициент для всех городов без отдельной записи.
+type SeasonalCoefficient struct {
+	City         string  `json:"city"`
+	BusinessType string  `json:"business_type"`
+	Month        int     `json:"month"`
+	Coefficient  float64 `json:"coefficient"`
+}
+
+// SeasonalCoefficients содержит коэффициенты сезонности одного месяца для типа бизнеса.
+type SeasonalCoefficients struct {
+	Default float64            // Коэффициент для городов без отдельной записи
+	ByCity  map[string]float64 // Коэффициенты по городам
 }
 
 // RecommendResponse представляет ответ с рекомендованными локациями.
 // Содержит отсортированный список локаций и общее количество найденных результатов.
 type RecommendResponse struct {
+	QueryID   string     `json:"query_id,omitempty"` // Идентификатор запроса в истории
 	Locations []Location `json:"locations"`
-	Total     int        `json:"total"`
+	// Total — всего локаций, подходящих под запрос; при ограничениях, применяемых после поиска
+	// (origin, min_distance_meters, footfall_weight), и ослаблении запроса — число локаций в выдаче
+	Total int `json:"total"`
+	// NextCursor передается в cursor запроса следующей страницы; пусто — страница последняя
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Substitution заполняется, если выдача дополнена локациями замещающего типа бизнеса
+	Substitution *Substitution `json:"substitution,omitempty"`
+	// Relaxations — ограничения запроса, ослабленные из-за недостатка результатов, в порядке применения
+	Relaxations []string `json:"relaxations,omitempty"`
+	// DidYouMean — известные регионы и города, близкие к указанным в запросе, если по ним ничего не найдено
+	DidYouMean *PlaceSuggestions `json:"did_you_mean,omitempty"`
+	// Corrected — исправленные регион и город, если запрос выполнен с autocorrect
+	Corrected *PlaceCorrection `json:"corrected,omitempty"`
+}
+
+// PlaceSuggestions содержит варианты названий региона и города, ближайшие к указанным в запросе.
+type PlaceSuggestions struct {
+	Region []string `json:"region,omitempty"`
+	City   []string `json:"city,omitempty"`
+}
+
+// PlaceCorrection содержит регион и город, которыми заменены указанные в запросе.
+type PlaceCorrection struct {
+	Region string `json:"region,omitempty"`
+	City   string `json:"city,omitempty"`
+}
+
+// FootfallRequest представляет запрос прогноза посещаемости: локация из индекса по LocationID
+// или кандидат, описанный признаками в Location.
+type FootfallRequest struct {
+	LocationID   string    `json:"location_id,omitempty"`
+	Location     *Location `json:"location,omitempty"`
+	BusinessType string    `json:"business_type"` // Тип бизнеса (обязательно)
+}
+
+// FootfallPrediction представляет прогноз посещаемости локации.
+type FootfallPrediction struct {
+	LocationID            string  `json:"location_id,omitempty"`
+	BusinessType          string  `json:"business_type"`
+	ExpectedDailyVisitors float64 `json:"expected_daily_visitors"`
+	ModelVersion          string  `json:"model_version"`
+}
+
+// ModelVersion представляет версию модели в реестре моделей.
+// Format определяет способ загрузки: для footfall — pmml или remote, для embedding — hash или http.
+type ModelVersion struct {
+	Kind        string                 `json:"kind"` // reranker, footfall или embedding
+	Version     string                 `json:"version"`
+	Format      string                 `json:"format"`
+	URI         string                 `json:"uri,omitempty"` // Путь к файлу, URL объекта или адрес сервера моделей
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Active      bool                   `json:"active"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ActivatedAt *time.Time             `json:"activated_at,omitempty"`
+}
+
+// SavedSearch представляет сохраненный поиск: при появлении или изменении локации,
+// подходящей под фильтры запроса Request, владелец получает уведомление на WebhookURL.
+type SavedSearch struct {
+	ID           string           `json:"id"`
+	Organization string           `json:"organization"`
+	Owner        string           `json:"owner"`
+	Name         string           `json:"name"`
+	Request      RecommendRequest `json:"request"`               // Учитываются только фильтры запроса
+	WebhookURL   string           `json:"webhook_url,omitempty"` // Без адреса уведомления пишутся в лог
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// SavedSearchNotification — уведомление владельца сохраненного поиска о совпавшей локации
+// в очереди отправки.
+type SavedSearchNotification struct {
+	ID       int64       `json:"id"`
+	Search   SavedSearch `json:"search"`   // Поиск без запроса (Request не заполняется)
+	Location Location    `json:"location"` // Локация в момент индексации
+	Attempts int         `json:"attempts"` // Неудачных попыток отправки
+}
+
+// SavedSearchRequest представляет запрос на создание сохраненного поиска.
+type SavedSearchRequest struct {
+	Name       string           `json:"name"`
+	Request    RecommendRequest `json:"request"`
+	WebhookURL string           `json:"webhook_url,omitempty"`
+}
+
+// SegmentFilter задает условия на демографический сегмент района; все условия относятся
+// к одному сегменту. Незаданные условия не проверяются.
+type SegmentFilter struct {
+	AgeGroup  string  `json:"age_group,omitempty"`
+	MinShare  float64 `json:"min_share,omitempty"`  // Минимальная доля населения, 0–1
+	MinIncome float64 `json:"min_income,omitempty"` // Минимальный средний доход сегмента
+	MaxIncome float64 `json:"max_income,omitempty"` // Максимальный средний доход сегмента (0 — без ограничения)
+}
+
+// FieldRange задает границы значения поля локации; не заданная граница не проверяется.
+type FieldRange struct {
+	Field string   `json:"field"`
+	Gte   *float64 `json:"gte,omitempty"`
+	Lte   *float64 `json:"lte,omitempty"`
+}
+
+// RuntimeField представляет вычисляемое поле индекса локаций (runtime field Elasticsearch).
+// Значение рассчитывается скриптом Painless при выполнении запроса, поэтому поле доступно
+// в фильтрах, сортировке и агрегациях сразу после определения, без переиндексации.
+type RuntimeField struct {
+	Name   string             `json:"name"`
+	Type   string             `json:"type"`            // double, long, keyword, boolean или date
+	Script string             `json:"script"`          // Значение передается через emit(...)
+	Stats  *RuntimeFieldStats `json:"stats,omitempty"` // Статистика значений числового поля
+}
+
+// RuntimeFieldStats содержит статистику значений числового вычисляемого поля по индексу.
+type RuntimeFieldStats struct {
+	Count int64    `json:"count"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Avg   *float64 `json:"avg"`
+}
+
+// SearchTemplate представляет версию шаблона поиска (mustache), хранимого в кластере.
+// Активная версия задает структуру запроса рекомендаций, сервис передает в нее только параметры.
+type SearchTemplate struct {
+	Name        string     `json:"name"`
+	Version     int        `json:"version"`
+	Source      string     `json:"source"` // Текст шаблона mustache
+	Description string     `json:"description,omitempty"`
+	Active      bool       `json:"active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+}
+
+// ModelPrediction представляет прогноз модели вместе с версией, которая его построила.
+type ModelPrediction struct {
+	Kind         string
+	Version      string
+	LocationID   string // Пусто для кандидатов вне индекса
+	BusinessType string
+	Value        float64
+}
+
+// GoldenQuery представляет эталонный запрос рекомендаций: ожидаемые локации должны входить
+// в первые TopK результатов выдачи. Используется для регрессионной проверки релевантности.
+type GoldenQuery struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name,omitempty"`
+	Request     RecommendRequest `json:"request"`
+	ExpectedIDs []string         `json:"expected_ids"`    // Ожидаемые локации
+	TopK        int              `json:"top_k,omitempty"` // Глубина проверки (по умолчанию — число ожидаемых локаций)
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// GoldenQueryResult представляет результат проверки эталонного запроса.
+type GoldenQueryResult struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name,omitempty"`
+	Passed    bool           `json:"passed"`
+	Missing   []string       `json:"missing,omitempty"`   // Ожидаемые локации, не вошедшие в top_k
+	Positions map[string]int `json:"positions,omitempty"` // Позиции (с 1) найденных ожидаемых локаций
+	Actual    []string       `json:"actual"`              // Фактические top_k локации
+	Error     string         `json:"error,omitempty"`     // Ошибка выполнения запроса
+}
+
+// GoldenRunReport представляет отчет о прогоне эталонных запросов.
+type GoldenRunReport struct {
+	Total      int                 `json:"total"`
+	Passed     int                 `json:"passed"`
+	Failed     int                 `json:"failed"` // Запросы с отклонениями или ошибками
+	StartedAt  time.Time           `json:"started_at"`
+	DurationMs int64               `json:"duration_ms"`
+	Results    []GoldenQueryResult `json:"results"`
+}
+
+// PortfolioRequest представляет запрос на подбор набора локаций для одновременного открытия
+// нескольких точек в разных регионах.
+type PortfolioRequest struct {
+	BusinessType      string            `json:"business_type"`                 // Тип бизнеса (обязательно)
+	Regions           []PortfolioRegion `json:"regions"`                       // Регионы, в которых допускается открытие (обязательно)
+	Outlets           int               `json:"outlets"`                       // Целевое количество точек (обязательно)
+	Budget            float64           `json:"budget,omitempty"`              // Общий бюджет (0 — без ограничения)
+	MinDistanceMeters float64           `json:"min_distance_meters,omitempty"` // Минимальное расстояние между точками, метры
+}
+
+// PortfolioRegion задает регион портфеля и ограничения для него.
+type PortfolioRegion struct {
+	Region     string  `json:"region"`
+	City       string  `json:"city,omitempty"`
+	MaxOutlets int     `json:"max_outlets,omitempty"` // Максимум точек в регионе (0 — без ограничения)
+	OutletCost float64 `json:"outlet_cost,omitempty"` // Ожидаемая стоимость открытия одной точки в регионе
+}
+
+// PortfolioResponse представляет подобранный набор локаций.
+type PortfolioResponse struct {
+	Locations  []PortfolioItem `json:"locations"`
+	Outlets    int             `json:"outlets"`
+	TotalScore float64         `json:"total_score"`
+	TotalCost  float64         `json:"total_cost"`
+	Complete   bool            `json:"complete"` // Удалось ли подобрать целевое количество точек
+}
+
+// PortfolioItem представляет локацию в портфеле со стоимостью открытия.
+type PortfolioItem struct {
+	Location
+	Cost float64 `json:"cost"`
+}
+
+// CityScoreStats представляет агрегированную статистику оценок по городу.
+// Используется для обнаружения аномалий в распределении оценок после загрузки данных.
+type CityScoreStats struct {
+	City             string  `json:"city"`
+	DocCount         int     `json:"doc_count"`
+	AvgTrafficScore  float64 `json:"avg_traffic_score"`
+	ZeroTrafficCount int     `json:"zero_traffic_count"`
+}
+
+// SegmentStats представляет агрегаты локаций сегмента — региона и подходящего типа бизнеса.
+type SegmentStats struct {
+	Region                string  `json:"region"`
+	BusinessType          string  `json:"business_type"`
+	LocationCount         int     `json:"location_count"`
+	AvgTrafficScore       float64 `json:"avg_traffic_score"`
+	P50TrafficScore       float64 `json:"p50_traffic_score"`
+	P90TrafficScore       float64 `json:"p90_traffic_score"`
+	AvgCompetitionDensity float64 `json:"avg_competition_density"`
+	AvgIncome             float64 `json:"avg_income"` // Средний доход населения района
+}
+
+// AnalyticsSource определяет, откуда получены агрегаты аналитики.
+type AnalyticsSource string
+
+const (
+	AnalyticsLive         AnalyticsSource = "elasticsearch" // Рассчитаны по индексу в момент запроса
+	AnalyticsMaterialized AnalyticsSource = "postgres"      // Материализованы в PostgreSQL фоновым обновлением
+)
+
+// SegmentAnalyticsResponse представляет ответ GET /analytics/segments.
+type SegmentAnalyticsResponse struct {
+	Source      AnalyticsSource `json:"source"`
+	RefreshedAt *time.Time      `json:"refreshed_at,omitempty"` // Время материализации (для source = postgres)
+	Segments    []SegmentStats  `json:"segments"`
+}
+
+// RegionStats — сводка по региону для дашбордов: положение региона в иерархии справочника
+// PostgreSQL и агрегаты опубликованных локаций региона и всех его подрегионов из Elasticsearch.
+type RegionStats struct {
+	Region    Region              `json:"region"`
+	Path      string              `json:"path"`      // Путь от корня иерархии через "/"
+	Ancestors []Region            `json:"ancestors"` // Родительские регионы от корня
+	Children  []Region            `json:"children"`  // Непосредственные подрегионы
+	Regions   []string            `json:"regions"`   // Регионы локаций, вошедших в агрегаты: сам регион и подрегионы
+	Locations RegionLocationStats `json:"locations"`
+}
+
+// RegionLocationStats — агрегаты локаций региона, рассчитанные в Elasticsearch.
+type RegionLocationStats struct {
+	Count                 int                       `json:"count"`
+	AvgTrafficScore       float64                   `json:"avg_traffic_score"`
+	AvgCompetitionDensity float64                   `json:"avg_competition_density"`
+	Competition           []BusinessTypeCompetition `json:"competition"` // По убыванию числа локаций
+	Income                IncomeDistribution        `json:"income"`
+}
+
+// BusinessTypeCompetition — конкуренция в регионе среди локаций, подходящих типу бизнеса.
+type BusinessTypeCompetition struct {
+	BusinessType          string  `json:"business_type"`
+	LocationCount         int     `json:"location_count"`
+	AvgCompetitionDensity float64 `json:"avg_competition_density"`
+}
+
+// IncomeDistribution — распределение среднего дохода населения районов локаций.
+type IncomeDistribution struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Avg float64 `json:"avg"`
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P90 float64 `json:"p90"`
+}
+
+// LocationFilter задает критерии отбора локаций для массовых операций.
+// Пустые поля не участвуют в фильтрации.
+type LocationFilter struct {
+	Region                string     `json:"region,omitempty"`
+	City                  string     `json:"city,omitempty"`
+	BusinessType          string     `json:"business_type,omitempty"`           // Только локации, подходящие типу бизнеса
+	EmbeddingVersionBelow int        `json:"embedding_version_below,omitempty"` // Только документы с embedding_version ниже указанной
+	UpdatedBefore         *time.Time `json:"updated_before,omitempty"`          // Только документы, обновленные раньше указанного момента
+	CreatedSince          *time.Time `json:"created_since,omitempty"`           // Только документы, созданные начиная с указанного момента
+	PublishedOnly         bool       `json:"-"`                                 // Только опубликованные локации (публичные выдачи)
+}
+
+// RebuildEmbeddingsRequest представляет запрос на пересчет embeddings локаций.
+// Если All = false и EmbeddingVersionBelow не задан, пересчитываются документы с версией ниже текущей.
+type RebuildEmbeddingsRequest struct {
+	LocationFilter
+	All bool `json:"all,omitempty"` // Пересчитать все документы, подходящие под фильтр, независимо от версии
+}
+
+// QueryHistoryEntry представляет запись истории запросов рекомендаций в PostgreSQL.
+// Используется для аналитики и офлайн-оценки изменений ранжирования.
+type QueryHistoryEntry struct {
+	ID          string           `json:"id"`
+	Request     RecommendRequest `json:"request"`
+	ResultIDs   []string         `json:"result_ids"`
+	Scores      []float64        `json:"scores"` // Нормализованные оценки в порядке ResultIDs
+	ResultCount int              `json:"result_count"`
+	DurationMs  int64            `json:"duration_ms"`
+	// ModelVersions — версии моделей, участвовавших в ранжировании (вид модели → версия)
+	ModelVersions map[string]string `json:"model_versions,omitempty"`
+	// Organization — организация клиента, выполнившего запрос (пусто — без аутентификации)
+	Organization string    `json:"organization,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// QueryCostUsage содержит суммарную стоимость запросов клиента API к Elasticsearch за день (UTC).
+// Стоимость — условные единицы, рассчитанные по времени выполнения в кластере, числу найденных
+// документов и бакетов агрегаций.
+type QueryCostUsage struct {
+	Subject         string     `json:"subject"`
+	Organization    string     `json:"organization,omitempty"`
+	Day             string     `json:"day"`      // Дата в формате YYYY-MM-DD
+	Requests        int64      `json:"requests"` // Запросы API, выполнившие поиск
+	Searches        int64      `json:"searches"`
+	TookMs          int64      `json:"took_ms"`
+	Hits            int64      `json:"hits"`
+	Buckets         int64      `json:"buckets"`
+	Cost            float64    `json:"cost"`
+	Budget          float64    `json:"budget,omitempty"`            // Дневной бюджет клиента (0 — без ограничения)
+	BudgetAlertedAt *time.Time `json:"budget_alerted_at,omitempty"` // Время алерта о превышении бюджета
+}
+
+// IndexSettings содержит изменяемые настройки размещения индекса локаций в кластере.
+// При изменении поля без значения не меняются.
+type IndexSettings struct {
+	Index          string `json:"index"`                      // Имя индекса (только чтение)
+	NumberOfShards int    `json:"number_of_shards,omitempty"` // Число первичных шардов (только чтение)
+	// NumberOfReplicas — число реплик каждого шарда
+	NumberOfReplicas *int `json:"number_of_replicas,omitempty"`
+	// TierPreference — предпочтительные уровни узлов данных через запятую, от более приоритетного:
+	// "data_warm,data_hot". Пустая строка при изменении возвращает значение по умолчанию кластера
+	TierPreference *string `json:"tier_preference,omitempty"`
+}
+
+// Snapshot представляет неизменяемый снимок выдачи рекомендаций, доступный по публичной ссылке.
+type Snapshot struct {
+	Token     string           `json:"token"`
+	QueryID   string           `json:"query_id"`
+	Request   RecommendRequest `json:"request"`
+	Locations []Location       `json:"locations"`
+	CreatedAt time.Time        `json:"created_at"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// IdempotencyRecord представляет сохраненный результат запроса с заголовком Idempotency-Key.
+// Completed равен false, пока исходный запрос еще выполняется.
+type IdempotencyRecord struct {
+	RequestHash string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Note представляет заметку пользователя о локации с необязательной оценкой от 1 до 5.
+type Note struct {
+	ID           int64     `json:"id"`
+	LocationID   string    `json:"location_id"`
+	Organization string    `json:"organization"`
+	Author       string    `json:"author"`
+	Text         string    `json:"text"`
+	Rating       *int      `json:"rating,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// NoteRequest представляет запрос на создание или изменение заметки.
+type NoteRequest struct {
+	Text   string `json:"text"`
+	Rating *int   `json:"rating,omitempty"` // Оценка от 1 до 5 (опционально)
+}
+
+// LocationStatusTransition — запись журнала переходов локации между состояниями жизненного цикла.
+type LocationStatusTransition struct {
+	ID           int64     `json:"id"`
+	LocationID   string    `json:"location_id"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Comment      string    `json:"comment,omitempty"`
+	Actor        string    `json:"actor,omitempty"` // Subject клиента, пусто без аутентификации
+	Organization string    `json:"organization,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LocationStatusRequest представляет запрос на перевод локации в другое состояние.
+type LocationStatusRequest struct {
+	Status  string `json:"status"`            // Целевое состояние: draft, published или retired
+	Comment string `json:"comment,omitempty"` // Причина перехода (опционально)
+}
+
+// RatingSummary содержит агрегированную оценку локации внутри организации.
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// LocationDetails представляет локацию вместе с заметками организации запрашивающего пользователя.
+type LocationDetails struct {
+	Location
+	Notes  []Note         `json:"notes,omitempty"`
+	Rating *RatingSummary `json:"rating,omitempty"`
+}
+
+// MultiGetRequest представляет запрос локаций по списку ID.
+type MultiGetRequest struct {
+	IDs             []string `json:"ids"`
+	IncludeArchived bool     `json:"include_archived,omitempty"` // Искать не найденные локации в архиве
+}
+
+// MultiGetResponse содержит найденные локации в порядке запроса и ID отсутствующих.
+type MultiGetResponse struct {
+	Found   []Location `json:"found"`
+	Missing []string   `json:"missing"`
+}
+
+// ValidateRefsResponse сообщает, какие из проверенных ID локаций существуют.
+type ValidateRefsResponse struct {
+	Existing []string `json:"existing"`
+	Missing  []string `json:"missing"`
+}
+
+// LocationChange — изменение локации в ленте изменений: сохранение (upsert) с данными локации
+// или удаление (delete).
+type LocationChange struct {
+	ID        string          `json:"id"`
+	Operation OutboxOperation `json:"operation"`
+	ChangedAt time.Time       `json:"changed_at"`
+	Location  *Location       `json:"location,omitempty"`
+}
+
+// LocationChangesResponse — страница ленты изменений локаций. NextCursor передается в since
+// следующего запроса; при пустой странице он равен исходному курсору.
+type LocationChangesResponse struct {
+	Changes    []LocationChange `json:"changes"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// FlatViewSchemaVersion — версия набора столбцов плоского представления локаций. Столбцы
+// не удаляются и не переименовываются; при несовместимом изменении версия увеличивается.
+const FlatViewSchemaVersion = 1
+
+// FlatLocation — строка плоского представления локации для BI инструментов: демография и
+// оценки развернуты в столбцы, списки объединены через ";". Все столбцы присутствуют всегда.
+type FlatLocation struct {
+	ID                            string  `json:"id"`
+	Name                          string  `json:"name"`
+	Address                       string  `json:"address"`
+	Region                        string  `json:"region"`
+	City                          string  `json:"city"`
+	Lat                           float64 `json:"lat"`
+	Lon                           float64 `json:"lon"`
+	BusinessTypesSuitable         string  `json:"business_types_suitable"`
+	TrafficScore                  float64 `json:"traffic_score"`
+	CompetitionDensity            float64 `json:"competition_density"`
+	EventExposure                 float64 `json:"event_exposure"`
+	SafetyScore                   float64 `json:"safety_score"`
+	StreetParkingScore            float64 `json:"street_parking_score"`
+	PaidLotsNearby                int     `json:"paid_lots_nearby"`
+	SchoolsNearby                 int     `json:"schools_nearby"`
+	UniversitiesNearby            int     `json:"universities_nearby"`
+	DemographicsAgeGroup          string  `json:"demographics_age_group"`
+	DemographicsAverageIncome     float64 `json:"demographics_average_income"`
+	DemographicsInterests         string  `json:"demographics_interests"`
+	DemographicsPopulationDensity float64 `json:"demographics_population_density"`
+	CreatedAt                     string  `json:"created_at"` // RFC 3339, UTC
+	UpdatedAt                     string  `json:"updated_at"` // RFC 3339, UTC
+}
+
+// FlatColumn описывает столбец плоского представления локаций.
+type FlatColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // string, number, integer или datetime
+}
+
+// FlatViewResponse — страница плоского представления локаций. NextCursor передается в cursor
+// следующего запроса; на последней странице он пуст.
+type FlatViewResponse struct {
+	SchemaVersion int            `json:"schema_version"`
+	Columns       []FlatColumn   `json:"columns"`
+	Rows          []FlatLocation `json:"rows"`
+	Total         int            `json:"total"` // Число локаций, подходящих под фильтр
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// SampleStratify определяет признак стратификации выборки.
+type SampleStratify string
+
+const (
+	StratifyNone         SampleStratify = ""              // Простая случайная выборка
+	StratifyRegion       SampleStratify = "region"        // Страты — регионы
+	StratifyBusinessType SampleStratify = "business_type" // Страты — подходящие типы бизнеса (пересекаются)
+)
+
+// SampleRequest описывает параметры выборки локаций.
+type SampleRequest struct {
+	Region       string
+	City         string
+	BusinessType string
+	N            int
+	Seed         *int64 // Не задан — выбирается случайно и возвращается в ответе
+	StratifyBy   SampleStratify
+}
+
+// SampleStratum описывает страту выборки: число локаций в генеральной совокупности и в выборке.
+type SampleStratum struct {
+	Key        string `json:"key"`
+	Population int    `json:"population"`
+	Size       int    `json:"size"`
+}
+
+// SampledLocation — локация выборки с ключом ее страты.
+type SampledLocation struct {
+	Location
+	Stratum string `json:"stratum,omitempty"`
+}
+
+// SampleResponse — воспроизводимая случайная выборка локаций. Повторный запрос с тем же seed
+// и параметрами возвращает ту же выборку, пока не изменились данные индекса.
+type SampleResponse struct {
+	Seed       int64             `json:"seed"`
+	N          int               `json:"n"`
+	StratifyBy SampleStratify    `json:"stratify_by,omitempty"`
+	Population int               `json:"population"` // Число локаций, подходящих под фильтр
+	Strata     []SampleStratum   `json:"strata,omitempty"`
+	Method     string            `json:"method"` // Описание метода отбора
+	Locations  []SampledLocation `json:"locations"`
+}
+
+// CandidateStatus определяет этап рассмотрения локации-кандидата в проекте.
+type CandidateStatus string
+
+const (
+	CandidateReviewing CandidateStatus = "reviewing" // На рассмотрении
+	CandidateVisited   CandidateStatus = "visited"   // Осмотрена на месте
+	CandidateRejected  CandidateStatus = "rejected"  // Отклонена
+	CandidateSelected  CandidateStatus = "selected"  // Выбрана
+)
+
+// Valid сообщает, является ли статус допустимым.
+func (s CandidateStatus) Valid() bool {
+	switch s {
+	case CandidateReviewing, CandidateVisited, CandidateRejected, CandidateSelected:
+		return true
+	}
+	return false
+}
+
+// Project представляет проект подбора локаций для бизнеса в регионе.
+type Project struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"organization"`
+	Owner        string    `json:"owner"`
+	Name         string    `json:"name"`
+	BusinessType string    `json:"business_type"`
+	Region       string    `json:"region"`
+	Budget       *float64  `json:"budget,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ProjectRequest представляет запрос на создание или изменение проекта.
+type ProjectRequest struct {
+	Name         string   `json:"name"`
+	BusinessType string   `json:"business_type"`
+	Region       string   `json:"region"`
+	Budget       *float64 `json:"budget,omitempty"`
+}
+
+// Candidate представляет локацию-кандидата в проекте.
+type Candidate struct {
+	ProjectID  string          `json:"project_id"`
+	LocationID string          `json:"location_id"`
+	Status     CandidateStatus `json:"status"`
+	Comment    string          `json:"comment,omitempty"`
+	AddedBy    string          `json:"added_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Location   *Location       `json:"location,omitempty"` // Данные локации; отсутствуют, если локация удалена
+}
+
+// CandidateRequest представляет запрос на добавление или изменение кандидата.
+type CandidateRequest struct {
+	LocationID string          `json:"location_id,omitempty"` // Обязательно при добавлении
+	Status     CandidateStatus `json:"status,omitempty"`      // По умолчанию reviewing
+	Comment    string          `json:"comment,omitempty"`
+}
+
+// ProjectSummary представляет проект со списком кандидатов и их количеством по статусам.
+type ProjectSummary struct {
+	Project
+	Candidates   []Candidate             `json:"candidates"`
+	StatusCounts map[CandidateStatus]int `json:"status_counts"`
+}
+
+// OutboxOperation определяет тип изменения локации в outbox.
+type OutboxOperation string
+
+const (
+	OutboxUpsert OutboxOperation = "upsert"
+	OutboxDelete OutboxOperation = "delete"
+)
+
+// OutboxEntry представляет запись outbox — изменение локации, которое нужно применить к Elasticsearch.
+type OutboxEntry struct {
+	ID         int64           `json:"id"`
+	LocationID string          `json:"location_id"`
+	Operation  OutboxOperation `json:"operation"`
+	Payload    *Location       `json:"payload,omitempty"` // Для delete отсутствует
+	Attempts   int             `json:"attempts"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AsyncIndexStatus определяет состояние документа, принятого на асинхронную индексацию.
+type AsyncIndexStatus string
+
+const (
+	AsyncIndexPending  AsyncIndexStatus = "pending"  // Ожидает доставки в Elasticsearch
+	AsyncIndexIndexed  AsyncIndexStatus = "indexed"  // Проиндексирован
+	AsyncIndexFailed   AsyncIndexStatus = "failed"   // Не проиндексирован за допустимое число попыток
+	AsyncIndexRejected AsyncIndexStatus = "rejected" // Не прошел проверку и не принят
+)
+
+// AsyncIndexRequest представляет запрос на асинхронную индексацию локаций.
+type AsyncIndexRequest struct {
+	Locations   []Location `json:"locations"`
+	CallbackURL string     `json:"callback_url,omitempty"` // URL для POST уведомлений о каждом документе
+}
+
+// AsyncIndexItem представляет документ, принятый на асинхронную индексацию, и его состояние.
+type AsyncIndexItem struct {
+	TrackingID          string           `json:"tracking_id"`
+	BatchID             string           `json:"batch_id"`
+	LocationID          string           `json:"location_id"`
+	Status              AsyncIndexStatus `json:"status"`
+	Error               string           `json:"error,omitempty"`
+	Attempts            int              `json:"attempts"` // Попытки индексации
+	CallbackURL         string           `json:"callback_url,omitempty"`
+	CallbackAttempts    int              `json:"callback_attempts,omitempty"` // Отправки уведомления
+	CallbackDeliveredAt *time.Time       `json:"callback_delivered_at,omitempty"`
+	CallbackError       string           `json:"callback_error,omitempty"` // Ошибка последней отправки уведомления
+	CreatedAt           time.Time        `json:"created_at"`
+	CompletedAt         *time.Time       `json:"completed_at,omitempty"`
+}
+
+// AsyncIndexResponse представляет ответ на запрос асинхронной индексации.
+type AsyncIndexResponse struct {
+	BatchID  string           `json:"batch_id"`
+	Accepted int              `json:"accepted"`
+	Rejected int              `json:"rejected"`
+	Items    []AsyncIndexItem `json:"items"`
+}
+
+// AsyncIndexCallback — тело уведомления о документе асинхронной индексации.
+type AsyncIndexCallback struct {
+	TrackingID  string           `json:"tracking_id"`
+	BatchID     string           `json:"batch_id"`
+	LocationID  string           `json:"location_id"`
+	Status      AsyncIndexStatus `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
+
+// APIKey — API ключ внешнего клиента из таблицы api_keys. Сам ключ не хранится: он возвращается
+// только при выпуске (поле Key).
+type APIKey struct {
+	ID           string     `json:"id"`
+	Key          string     `json:"key,omitempty"` // Ключ; заполняется только в ответе на выпуск
+	Prefix       string     `json:"prefix"`        // Начало ключа для опознания
+	Name         string     `json:"name"`          // Описание клиента, например название партнера
+	Organization string     `json:"organization,omitempty"`
+	Subject      string     `json:"subject"`              // Клиент, от имени которого выполняются запросы
+	Roles        []string   `json:"roles"`                // Роли клиента в приложении
+	DailyQuota   int        `json:"daily_quota"`          // Запросов за сутки UTC (0 — без ограничения)
+	UsedToday    *int64     `json:"used_today,omitempty"` // Запросов за текущие сутки UTC; только в списке ключей
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyUpdate содержит изменяемые параметры API ключа. Поля без значения не меняются.
+type APIKeyUpdate struct {
+	Name       *string  `json:"name,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	DailyQuota *int     `json:"daily_quota,omitempty"`
 }
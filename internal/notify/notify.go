@@ -0,0 +1,121 @@
+// Package notify предоставляет подсистему уведомлений для отправки алертов
+// (в лог и во внешние системы через вебхуки).
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Severity определяет уровень важности алерта.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Alert представляет одно уведомление, отправляемое через Notifier.
+type Alert struct {
+	Source    string                 `json:"source"`            // Подсистема-источник алерта
+	Severity  Severity               `json:"severity"`          // Уровень важности
+	Title     string                 `json:"title"`             // Краткое описание
+	Message   string                 `json:"message"`           // Подробное описание
+	Details   map[string]interface{} `json:"details,omitempty"` // Дополнительные данные
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// Notifier отправляет алерты во внешние системы.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// LogNotifier пишет алерты в журнал сервиса: critical с уровнем ERROR, warning — WARN, остальные — INFO.
+type LogNotifier struct{}
+
+// Notify пишет алерт в лог.
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	level := slog.LevelInfo
+	switch alert.Severity {
+	case SeverityCritical:
+		level = slog.LevelError
+	case SeverityWarning:
+		level = slog.LevelWarn
+	}
+	slog.Log(ctx, level, "Alert: "+alert.Title, "source", alert.Source, "severity", alert.Severity,
+		"message", alert.Message, "details", alert.Details)
+	return nil
+}
+
+// WebhookNotifier отправляет алерты POST запросом с JSON телом на заданный URL.
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier создает новый экземпляр WebhookNotifier.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify отправляет алерт на вебхук.
+func (wn *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", wn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := wn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error sending alert: status %d, body: %s", res.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// MultiNotifier рассылает алерт во все вложенные Notifier.
+// Ошибка одного получателя не прерывает отправку остальным.
+type MultiNotifier []Notifier
+
+// Notify отправляет алерт всем получателям и возвращает первую возникшую ошибку.
+func (mn MultiNotifier) Notify(ctx context.Context, alert Alert) error {
+	var firstErr error
+	for _, n := range mn {
+		if err := n.Notify(ctx, alert); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// New создает Notifier по конфигурации: алерты всегда пишутся в лог,
+// а при заданном webhookURL дополнительно отправляются на вебхук.
+func New(webhookURL string) Notifier {
+	notifiers := MultiNotifier{LogNotifier{}}
+	if webhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(webhookURL))
+	}
+	return notifiers
+}
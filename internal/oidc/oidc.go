@@ -0,0 +1,189 @@
+// Package oidc проверяет ID/access токены внешнего OIDC провайдера (Keycloak, Auth0 и др.)
+// библиотекой github.com/coreos/go-oidc: находит JWKS через OpenID discovery, проверяет подпись
+// и стандартные claims и преобразует claims в клиента API с ролями по настраиваемому сопоставлению.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+)
+
+// signingAlgorithms — алгоритмы подписи, принимаемые от провайдера.
+var signingAlgorithms = []string{gooidc.RS256, gooidc.RS384, gooidc.RS512, gooidc.ES256, gooidc.ES384}
+
+// ErrInvalidToken возвращается для токенов, не прошедших проверку.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config задает провайдера и сопоставление claims.
+type Config struct {
+	Issuer            string            // Адрес провайдера (значение claim iss и issuer discovery)
+	Audience          string            // Ожидаемое значение claim aud (обязательно)
+	RolesClaim        string            // Путь к claim с ролями через точку, например "realm_access.roles"
+	RoleMapping       map[string]string // Роль провайдера -> роль приложения (пусто — роли без изменений)
+	OrganizationClaim string            // Claim с организацией пользователя (обязательно)
+}
+
+// ParseRoleMapping разбирает записи сопоставления ролей вида "роль_провайдера:роль_приложения".
+func ParseRoleMapping(entries []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		provider, app, ok := strings.Cut(entry, ":")
+		if !ok || provider == "" || app == "" {
+			return nil, fmt.Errorf("invalid role mapping %q: expected provider_role:app_role", entry)
+		}
+		mapping[provider] = app
+	}
+	return mapping, nil
+}
+
+// Authenticator проверяет токены провайдера.
+type Authenticator struct {
+	cfg        Config
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	verifier *gooidc.IDTokenVerifier // nil — провайдер еще не найден через discovery
+}
+
+// New создает Authenticator. Провайдер находится при первой проверке токена. Без audience
+// принимались бы токены, выпущенные провайдером для других приложений, а без claim
+// организации — токены, не относящиеся ни к одной организации.
+func New(cfg Config) (*Authenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("oidc issuer is required")
+	}
+	if cfg.Audience == "" {
+		return nil, errors.New("oidc audience is required")
+	}
+	if cfg.OrganizationClaim == "" {
+		return nil, errors.New("oidc organization claim is required")
+	}
+	return &Authenticator{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Authenticate проверяет токен и возвращает клиента: subject из claim sub, организацию
+// и роли из настроенных claims. Токен без организации отклоняется: данные API разделены
+// по организациям.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	claims, err := a.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	organization, _ := lookup(claims, a.cfg.OrganizationClaim).(string)
+	if organization == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, a.cfg.OrganizationClaim)
+	}
+	return &auth.Principal{Subject: subject, Organization: organization, Roles: a.roles(claims)}, nil
+}
+
+// roles извлекает роли провайдера и сопоставляет их ролям приложения. Роли без
+// сопоставления отбрасываются, если сопоставление задано.
+func (a *Authenticator) roles(claims map[string]interface{}) []string {
+	var provider []string
+	switch value := lookup(claims, a.cfg.RolesClaim).(type) {
+	case []interface{}:
+		for _, v := range value {
+			if role, ok := v.(string); ok {
+				provider = append(provider, role)
+			}
+		}
+	case string:
+		// Некоторые провайдеры передают роли строкой через пробел, как scope
+		provider = strings.Fields(value)
+	}
+
+	if len(a.cfg.RoleMapping) == 0 {
+		return provider
+	}
+	var roles []string
+	seen := make(map[string]bool)
+	for _, role := range provider {
+		if mapped, ok := a.cfg.RoleMapping[role]; ok && !seen[mapped] {
+			seen[mapped] = true
+			roles = append(roles, mapped)
+		}
+	}
+	return roles
+}
+
+// lookup возвращает значение claim по пути через точку.
+func lookup(claims map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var value interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+	return value
+}
+
+// Verify проверяет подпись и claims iss, aud, exp, nbf токена и возвращает его claims.
+// Ключи подписи загружаются из JWKS провайдера и перезагружаются, когда токен подписан
+// неизвестным ключом (ротация на стороне провайдера).
+func (a *Authenticator) Verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	verifier, err := a.tokenVerifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verified, err := verifier.Verify(gooidc.ClientContext(ctx, a.httpClient), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims map[string]interface{}
+	if err := verified.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+// Discover проверяет доступность провайдера: загружает документ OpenID discovery.
+func (a *Authenticator) Discover(ctx context.Context) error {
+	_, err := gooidc.NewProvider(gooidc.ClientContext(ctx, a.httpClient), a.cfg.Issuer)
+	if err != nil {
+		return fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+	return nil
+}
+
+// tokenVerifier возвращает проверку токенов, при первом вызове находя провайдера через
+// OpenID discovery. Если провайдер недоступен, discovery повторяется при следующей проверке.
+// Набор ключей провайдера не зависит от ctx вызова: go-oidc берет из него только HTTP-клиент.
+func (a *Authenticator) tokenVerifier(ctx context.Context) (*gooidc.IDTokenVerifier, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.verifier != nil {
+		return a.verifier, nil
+	}
+	provider, err := gooidc.NewProvider(gooidc.ClientContext(ctx, a.httpClient), a.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+	a.verifier = provider.Verifier(&gooidc.Config{
+		ClientID:             a.cfg.Audience,
+		SupportedSigningAlgs: signingAlgorithms,
+	})
+	return a.verifier, nil
+}
@@ -0,0 +1,439 @@
+// Package orchestrator выполняет конвейеры обновления данных, заданные как граф зависимостей шагов (DAG).
+// Шаг запускается, когда успешно завершены все шаги, от которых он зависит; независимые шаги
+// выполняются параллельно. Неудачный шаг повторяется заданное число раз, а зависящие от него шаги
+// пропускаются. Состояние запусков хранится в памяти и доступно через API статуса.
+package orchestrator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+var (
+	// ErrUnknownPipeline возвращается при запуске незарегистрированного конвейера.
+	ErrUnknownPipeline = errors.New("unknown pipeline")
+	// ErrAlreadyRunning возвращается, если предыдущий запуск конвейера еще не завершен.
+	ErrAlreadyRunning = errors.New("pipeline is already running")
+)
+
+// Status определяет состояние запуска или шага.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped" // Не запускался из-за неудачи зависимости
+)
+
+// LockName возвращает имя блокировки конвейера: запуск выполняется одним экземпляром кластера.
+func LockName(pipeline string) string {
+	return "pipeline:" + pipeline
+}
+
+// historyLimit ограничивает количество хранимых запусков каждого конвейера.
+const historyLimit = 50
+
+// Step — шаг конвейера.
+type Step struct {
+	Name       string
+	DependsOn  []string
+	Retries    int           // Количество повторов после первой неудачной попытки
+	RetryDelay time.Duration // Пауза перед повтором
+	Run        func(ctx context.Context) error
+}
+
+// Pipeline — именованный граф шагов.
+type Pipeline struct {
+	Name  string
+	Steps []Step
+}
+
+// Validate проверяет, что имена шагов уникальны, зависимости существуют и граф не содержит циклов.
+func (p *Pipeline) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("pipeline name is required")
+	}
+
+	steps := make(map[string]*Step, len(p.Steps))
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Name == "" || step.Run == nil {
+			return fmt.Errorf("pipeline %s: step name and Run are required", p.Name)
+		}
+		if _, ok := steps[step.Name]; ok {
+			return fmt.Errorf("pipeline %s: duplicate step %q", p.Name, step.Name)
+		}
+		steps[step.Name] = step
+	}
+
+	// Поиск цикла обходом в глубину: 1 — в стеке обхода, 2 — обработан
+	state := make(map[string]int, len(steps))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("pipeline %s: dependency cycle at step %q", p.Name, name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dep := range steps[name].DependsOn {
+			if _, ok := steps[dep]; !ok {
+				return fmt.Errorf("pipeline %s: step %q depends on unknown step %q", p.Name, name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+	for _, step := range p.Steps {
+		if err := visit(step.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StepState — состояние шага в запуске.
+type StepState struct {
+	Name       string     `json:"name"`
+	DependsOn  []string   `json:"depends_on,omitempty"`
+	Status     Status     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Run — запуск конвейера.
+type Run struct {
+	ID         string       `json:"id"`
+	Pipeline   string       `json:"pipeline"`
+	Trigger    string       `json:"trigger"` // manual или schedule
+	Status     Status       `json:"status"`
+	Steps      []*StepState `json:"steps"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// PipelineInfo — описание зарегистрированного конвейера и его последнего запуска.
+type PipelineInfo struct {
+	Name    string   `json:"name"`
+	Steps   []string `json:"steps"`
+	Running bool     `json:"running"`
+	LastRun *Run     `json:"last_run,omitempty"`
+}
+
+// Orchestrator регистрирует конвейеры, запускает их и хранит историю запусков.
+type Orchestrator struct {
+	mu        sync.RWMutex
+	ctx       context.Context
+	pipelines map[string]*Pipeline
+	runs      map[string][]*Run // Запуски по конвейерам, последние в конце
+	running   map[string]bool
+	locks     *lock.Manager // Блокировки запусков (nil — без блокировки)
+}
+
+// New создает новый экземпляр Orchestrator.
+// Отмена ctx прерывает выполняющиеся запуски.
+func New(ctx context.Context) *Orchestrator {
+	return &Orchestrator{
+		ctx:       ctx,
+		pipelines: make(map[string]*Pipeline),
+		runs:      make(map[string][]*Run),
+		running:   make(map[string]bool),
+	}
+}
+
+// Register регистрирует конвейер после проверки графа.
+func (o *Orchestrator) Register(pipeline Pipeline) error {
+	if err := pipeline.Validate(); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pipelines[pipeline.Name] = &pipeline
+	return nil
+}
+
+// SetLocks задает менеджер блокировок, исключающий одновременный запуск конвейера на нескольких экземплярах.
+func (o *Orchestrator) SetLocks(locks *lock.Manager) {
+	o.locks = locks
+}
+
+// Trigger асинхронно запускает конвейер и возвращает снимок созданного запуска.
+// Возвращает lock.ErrHeld, если конвейер выполняется другим экземпляром.
+func (o *Orchestrator) Trigger(name, trigger string) (*Run, error) {
+	o.mu.Lock()
+	pipeline, ok := o.pipelines[name]
+	if !ok {
+		o.mu.Unlock()
+		return nil, ErrUnknownPipeline
+	}
+	if o.running[name] {
+		o.mu.Unlock()
+		return nil, ErrAlreadyRunning
+	}
+	o.running[name] = true
+	o.mu.Unlock()
+
+	// Блокировка удерживается до завершения запуска; при ее потере шаги отменяются
+	ctx, release, err := o.locks.Acquire(o.ctx, LockName(name))
+	if err != nil {
+		o.mu.Lock()
+		o.running[name] = false
+		o.mu.Unlock()
+		return nil, err
+	}
+
+	o.mu.Lock()
+	run := &Run{
+		ID:        newID(),
+		Pipeline:  name,
+		Trigger:   trigger,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+	}
+	for _, step := range pipeline.Steps {
+		run.Steps = append(run.Steps, &StepState{
+			Name:      step.Name,
+			DependsOn: step.DependsOn,
+			Status:    StatusPending,
+		})
+	}
+
+	history := append(o.runs[name], run)
+	if len(history) > historyLimit {
+		history = history[len(history)-historyLimit:]
+	}
+	o.runs[name] = history
+	snapshot := o.snapshot(run)
+	o.mu.Unlock()
+
+	go func() {
+		defer release()
+		o.execute(ctx, pipeline, run)
+	}()
+
+	return snapshot, nil
+}
+
+// GetRun возвращает снимок запуска по ID.
+func (o *Orchestrator) GetRun(id string) (*Run, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, runs := range o.runs {
+		for _, run := range runs {
+			if run.ID == id {
+				return o.snapshot(run), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Runs возвращает снимки запусков конвейера, начиная с последнего.
+func (o *Orchestrator) Runs(name string) ([]*Run, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if _, ok := o.pipelines[name]; !ok {
+		return nil, ErrUnknownPipeline
+	}
+
+	runs := o.runs[name]
+	result := make([]*Run, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		result = append(result, o.snapshot(runs[i]))
+	}
+	return result, nil
+}
+
+// Pipelines возвращает описания зарегистрированных конвейеров, отсортированные по имени.
+func (o *Orchestrator) Pipelines() []PipelineInfo {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	infos := make([]PipelineInfo, 0, len(o.pipelines))
+	for name, pipeline := range o.pipelines {
+		info := PipelineInfo{Name: name, Running: o.running[name]}
+		for _, step := range pipeline.Steps {
+			info.Steps = append(info.Steps, step.Name)
+		}
+		if runs := o.runs[name]; len(runs) > 0 {
+			info.LastRun = o.snapshot(runs[len(runs)-1])
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Runner возвращает фоновый процесс, запускающий конвейер каждые interval.
+// Запуск пропускается, если предыдущий еще выполняется.
+func (o *Orchestrator) Runner(name string, interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				if _, err := o.Trigger(name, "schedule"); err != nil {
+					slog.WarnContext(ctx, "Pipeline not started", "pipeline", name, logging.Err(err))
+				}
+			}
+		}
+	}
+}
+
+// execute выполняет шаги запуска с учетом зависимостей.
+func (o *Orchestrator) execute(ctx context.Context, pipeline *Pipeline, run *Run) {
+	done := make(map[string]chan struct{}, len(pipeline.Steps))
+	for _, step := range pipeline.Steps {
+		done[step.Name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i := range pipeline.Steps {
+		step := &pipeline.Steps[i]
+		state := run.Steps[i]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[step.Name])
+
+			for _, dep := range step.DependsOn {
+				<-done[dep]
+			}
+			if failedDep := o.failedDependency(run, step); failedDep != "" {
+				o.update(func() {
+					state.Status = StatusSkipped
+					state.Error = fmt.Sprintf("dependency %s did not complete", failedDep)
+				})
+				return
+			}
+
+			o.runStep(ctx, step, state)
+		}()
+	}
+	wg.Wait()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = StatusCompleted
+	for _, state := range run.Steps {
+		if state.Status != StatusCompleted {
+			run.Status = StatusFailed
+		}
+	}
+	o.running[pipeline.Name] = false
+
+	slog.Info("Pipeline run "+string(run.Status), "pipeline", pipeline.Name, "run_id", run.ID,
+		"duration", now.Sub(run.StartedAt).String())
+}
+
+// runStep выполняет шаг с повторами.
+func (o *Orchestrator) runStep(ctx context.Context, step *Step, state *StepState) {
+	started := time.Now()
+	o.update(func() {
+		state.Status = StatusRunning
+		state.StartedAt = &started
+	})
+
+	var err error
+	for attempt := 0; attempt <= step.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(step.RetryDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+			break
+		}
+
+		o.update(func() { state.Attempts++ })
+		if err = step.Run(ctx); err == nil {
+			break
+		}
+		slog.Warn("Pipeline step failed", "step", step.Name, "attempt", attempt+1, "attempts", step.Retries+1,
+			logging.Err(err))
+	}
+
+	finished := time.Now()
+	o.update(func() {
+		state.FinishedAt = &finished
+		if err != nil {
+			state.Status = StatusFailed
+			state.Error = err.Error()
+			return
+		}
+		state.Status = StatusCompleted
+	})
+}
+
+// failedDependency возвращает имя зависимости шага, завершившейся неуспешно, или пустую строку.
+func (o *Orchestrator) failedDependency(run *Run, step *Step) string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, dep := range step.DependsOn {
+		for _, state := range run.Steps {
+			if state.Name == dep && state.Status != StatusCompleted {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+func (o *Orchestrator) update(fn func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fn()
+}
+
+// snapshot возвращает копию запуска; вызывается под блокировкой.
+func (o *Orchestrator) snapshot(run *Run) *Run {
+	copied := *run
+	copied.Steps = make([]*StepState, len(run.Steps))
+	for i, state := range run.Steps {
+		stateCopy := *state
+		copied.Steps[i] = &stateCopy
+	}
+	return &copied
+}
+
+// newID генерирует случайный идентификатор запуска.
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,130 @@
+// Package outbox содержит relay-воркер, доставляющий изменения локаций из outbox PostgreSQL
+// в Elasticsearch. Вместе с транзакционной записью в outbox это гарантирует, что хранилища сойдутся,
+// даже если Elasticsearch был недоступен в момент записи.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// Matcher сопоставляет проиндексированную локацию с подписками, например сохраненными поисками.
+type Matcher interface {
+	Match(ctx context.Context, location *models.Location) error
+}
+
+// Relay периодически применяет необработанные записи outbox к Elasticsearch.
+type Relay struct {
+	pgStorage    *storage.PostgresStorage
+	esStorage    *storage.ElasticsearchStorage
+	pollInterval time.Duration
+	batchSize    int
+	matcher      Matcher
+}
+
+// NewRelay создает новый экземпляр Relay.
+func NewRelay(pgStorage *storage.PostgresStorage, esStorage *storage.ElasticsearchStorage, pollInterval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		pgStorage:    pgStorage,
+		esStorage:    esStorage,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// SetMatcher задает сопоставление новых и измененных локаций с подписками после индексации.
+func (r *Relay) SetMatcher(matcher Matcher) {
+	r.matcher = matcher
+}
+
+// Run обрабатывает outbox до отмены ctx. Пока в outbox есть записи, пачки обрабатываются
+// без паузы; при пустом outbox или ошибке relay ждет pollInterval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		processed, failed, err := r.pgStorage.ProcessOutbox(ctx, r.batchSize, r.apply)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Error processing outbox", logging.Err(err))
+		}
+		if failed > 0 {
+			slog.WarnContext(ctx, "Outbox entries failed, will retry", "failed", failed)
+		}
+
+		if err == nil && failed == 0 && processed == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// Drain однократно обрабатывает все накопившиеся записи outbox.
+// Возвращает ошибку, если часть записей применить не удалось.
+func (r *Relay) Drain(ctx context.Context) error {
+	for {
+		processed, failed, err := r.pgStorage.ProcessOutbox(ctx, r.batchSize, r.apply)
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d outbox entries failed", failed)
+		}
+		if processed < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// apply применяет одну запись outbox к Elasticsearch.
+func (r *Relay) apply(entry *models.OutboxEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch entry.Operation {
+	case models.OutboxUpsert:
+		if entry.Payload == nil {
+			return fmt.Errorf("outbox entry %d has no payload", entry.ID)
+		}
+		if err := r.esStorage.IndexLocation(ctx, entry.Payload); err != nil {
+			return err
+		}
+		// Обновленная локация перестает быть холодной: убираем ее копию из архива
+		if err := ignoreNotFound(r.esStorage.DeleteArchivedLocation(ctx, entry.LocationID)); err != nil {
+			return err
+		}
+		// Сопоставление только ставит уведомления в очередь. Его ошибка не возвращает запись
+		// в outbox: повторная доставка продублировала бы уже поставленные уведомления
+		if r.matcher != nil {
+			if err := r.matcher.Match(ctx, entry.Payload); err != nil {
+				slog.ErrorContext(ctx, "Error matching location with subscriptions", "location_id", entry.LocationID,
+					logging.Err(err))
+			}
+		}
+		return nil
+	case models.OutboxDelete:
+		if err := ignoreNotFound(r.esStorage.DeleteLocation(ctx, entry.LocationID)); err != nil {
+			return err
+		}
+		return ignoreNotFound(r.esStorage.DeleteArchivedLocation(ctx, entry.LocationID))
+	default:
+		return fmt.Errorf("unknown outbox operation: %q", entry.Operation)
+	}
+}
+
+// ignoreNotFound делает удаление идемпотентным: отсутствие документа не считается ошибкой.
+func ignoreNotFound(err error) error {
+	if errors.Is(err, storage.ErrLocationNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,219 @@
+// Package parquet записывает таблицы в формате Apache Parquet библиотекой
+// github.com/xitongsys/parquet-go: столбцы — строки, целые, числа с плавающей точкой,
+// время и списки из них. Такие файлы читаются pandas (pyarrow), Spark и DuckDB.
+package parquet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Type определяет тип значений столбца.
+type Type int
+
+const (
+	String    Type = iota // UTF-8 строка
+	Int64                 // Целое число
+	Double                // Число с плавающей точкой
+	Timestamp             // Время в UTC с точностью до миллисекунд
+)
+
+// Column описывает столбец таблицы.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool // Значение может отсутствовать (nil)
+	List     bool // Значение — список элементов типа Type; nil — отсутствующий список
+}
+
+// Writer накапливает строки таблицы в памяти и формирует файл.
+type Writer struct {
+	columns []Column
+	rows    []string // Строки в JSON, который parquet-go раскладывает по столбцам схемы
+}
+
+// schemaItem — элемент JSON схемы parquet-go.
+type schemaItem struct {
+	Tag    string
+	Fields []schemaItem `json:",omitempty"`
+}
+
+// NewWriter создает Writer для таблицы с указанными столбцами.
+func NewWriter(columns []Column) *Writer {
+	return &Writer{columns: columns}
+}
+
+// Write добавляет строку. Значения перечисляются в порядке столбцов: string, int/int64,
+// float64, time.Time или срез этих типов для столбцов-списков; nil — отсутствующее значение.
+func (w *Writer) Write(row []interface{}) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+	record := make(map[string]interface{}, len(w.columns))
+	for i, column := range w.columns {
+		value, err := column.value(row[i])
+		if err != nil {
+			return fmt.Errorf("parquet: column %s: %w", column.Name, err)
+		}
+		record[column.Name] = value
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("parquet: %w", err)
+	}
+	w.rows = append(w.rows, string(data))
+	return nil
+}
+
+// Bytes возвращает содержимое файла со всеми записанными строками.
+func (w *Writer) Bytes() ([]byte, error) {
+	schema, err := w.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	pw, err := writer.NewJSONWriterFromWriter(schema, &buf, 1)
+	if err != nil {
+		return nil, fmt.Errorf("parquet: failed to create writer: %w", err)
+	}
+	for _, row := range w.rows {
+		if err := pw.Write(row); err != nil {
+			return nil, fmt.Errorf("parquet: failed to write row: %w", err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		return nil, fmt.Errorf("parquet: failed to write footer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// schema формирует JSON схему таблицы. Список записывается трехуровневой структурой LIST:
+// <name> (optional group) → list (repeated group) → element.
+func (w *Writer) schema() (string, error) {
+	root := schemaItem{Tag: "name=schema, repetitiontype=REQUIRED"}
+	for _, column := range w.columns {
+		if !column.List {
+			repetition := "REQUIRED"
+			if column.Optional {
+				repetition = "OPTIONAL"
+			}
+			root.Fields = append(root.Fields, schemaItem{Tag: column.leafTag(column.Name, repetition)})
+			continue
+		}
+		root.Fields = append(root.Fields, schemaItem{
+			Tag:    fmt.Sprintf("name=%s, type=LIST, repetitiontype=OPTIONAL", column.Name),
+			Fields: []schemaItem{{Tag: column.leafTag("element", "REQUIRED")}},
+		})
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		return "", fmt.Errorf("parquet: %w", err)
+	}
+	return string(data), nil
+}
+
+// leafTag возвращает описание элемента схемы со значениями столбца.
+func (c Column) leafTag(name, repetition string) string {
+	var kind string
+	switch c.Type {
+	case String:
+		kind = "type=BYTE_ARRAY, convertedtype=UTF8"
+	case Int64:
+		kind = "type=INT64"
+	case Double:
+		kind = "type=DOUBLE"
+	case Timestamp:
+		kind = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	}
+	return fmt.Sprintf("name=%s, %s, repetitiontype=%s", name, kind, repetition)
+}
+
+// value проверяет значение строки и приводит его к виду, который parquet-go принимает в JSON.
+// Срез, равный nil, записывается пустым списком, отсутствующий список — только значением nil.
+func (c Column) value(value interface{}) (interface{}, error) {
+	if value == nil {
+		if !c.Optional && !c.List {
+			return nil, fmt.Errorf("value is required")
+		}
+		return nil, nil
+	}
+	if !c.List {
+		return c.item(value)
+	}
+
+	items, err := listItems(value)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		if items[i], err = c.item(item); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// item проверяет тип значения; время записывается в миллисекундах с начала эпохи Unix.
+func (c Column) item(value interface{}) (interface{}, error) {
+	switch c.Type {
+	case String:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unexpected value %T, want string", value)
+	case Int64:
+		switch n := value.(type) {
+		case int:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		}
+		return nil, fmt.Errorf("unexpected value %T, want integer", value)
+	case Double:
+		if v, ok := value.(float64); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("unexpected value %T, want float64", value)
+	case Timestamp:
+		if t, ok := value.(time.Time); ok {
+			return t.UnixMilli(), nil
+		}
+		return nil, fmt.Errorf("unexpected value %T, want time.Time", value)
+	}
+	return nil, fmt.Errorf("unsupported column type %d", c.Type)
+}
+
+// listItems приводит срез поддерживаемого типа к []interface{}.
+func listItems(value interface{}) ([]interface{}, error) {
+	items := []interface{}{}
+	switch v := value.(type) {
+	case []string:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []float64:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []int64:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []int:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []time.Time:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected value %T, want slice", value)
+	}
+	return items, nil
+}
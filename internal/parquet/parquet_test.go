@@ -0,0 +1,88 @@
+package parquet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// TestWriterReadBack записывает таблицу со всеми типами столбцов и читает ее по столбцам:
+// значения и уровни повторения/определения должны совпасть.
+func TestWriterReadBack(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	w := NewWriter([]Column{
+		{Name: "id", Type: String},
+		{Name: "count", Type: Int64},
+		{Name: "score", Type: Double, Optional: true},
+		{Name: "created_at", Type: Timestamp},
+		{Name: "tags", Type: String, List: true},
+		{Name: "weights", Type: Double, List: true},
+	})
+	rows := [][]interface{}{
+		{"loc-1", 3, 7.5, at, []string{"cafe", "bakery"}, []float64{0.25}},
+		{"loc-2", int64(-1), nil, at.Add(time.Hour), []string{}, nil},
+		{"Тверская", 0, 0.0, at.Add(-time.Millisecond), nil, []float64{1, 2, 3}},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("write %v: %v", row, err)
+		}
+	}
+
+	data, err := w.Bytes()
+	if err != nil {
+		t.Fatalf("bytes: %v", err)
+	}
+	pr, err := reader.NewParquetColumnReader(buffer.NewBufferFileFromBytes(data), 1)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer pr.ReadStop()
+	if got := pr.GetNumRows(); got != int64(len(rows)) {
+		t.Fatalf("rows: got %d, want %d", got, len(rows))
+	}
+
+	tests := []struct {
+		name   string
+		values []interface{}
+		rep    []int32
+		def    []int32
+	}{
+		{"id", []interface{}{"loc-1", "loc-2", "Тверская"}, []int32{0, 0, 0}, []int32{0, 0, 0}},
+		{"count", []interface{}{int64(3), int64(-1), int64(0)}, []int32{0, 0, 0}, []int32{0, 0, 0}},
+		{"score", []interface{}{7.5, nil, 0.0}, []int32{0, 0, 0}, []int32{1, 0, 1}},
+		{"created_at", []interface{}{at.UnixMilli(), at.Add(time.Hour).UnixMilli(), at.Add(-time.Millisecond).UnixMilli()},
+			[]int32{0, 0, 0}, []int32{0, 0, 0}},
+		// Пустой список — определенный на уровне 1, отсутствующий — на уровне 0
+		{"tags", []interface{}{"cafe", "bakery", nil, nil}, []int32{0, 1, 0, 0}, []int32{2, 2, 1, 0}},
+		{"weights", []interface{}{0.25, nil, 1.0, 2.0, 3.0}, []int32{0, 0, 0, 1, 1}, []int32{2, 0, 2, 2, 2}},
+	}
+	for i, tt := range tests {
+		values, rep, def, err := pr.ReadColumnByIndex(int64(i), int64(len(tt.values)))
+		if err != nil {
+			t.Fatalf("%s: read: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("%s: values %v, want %v", tt.name, values, tt.values)
+		}
+		if !reflect.DeepEqual(rep, tt.rep) || !reflect.DeepEqual(def, tt.def) {
+			t.Errorf("%s: levels rep=%v def=%v, want rep=%v def=%v", tt.name, rep, def, tt.rep, tt.def)
+		}
+	}
+}
+
+func TestWriterRejectsInvalidValues(t *testing.T) {
+	w := NewWriter([]Column{{Name: "id", Type: String}, {Name: "count", Type: Int64}})
+	for _, row := range [][]interface{}{
+		{"loc-1"},
+		{nil, 1},
+		{"loc-1", "one"},
+	} {
+		if err := w.Write(row); err == nil {
+			t.Errorf("write %v: expected error", row)
+		}
+	}
+}
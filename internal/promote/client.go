@@ -0,0 +1,95 @@
+package promote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/middleware"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// maxErrorBody ограничивает фрагмент тела ответа с ошибкой, сохраняемый в APIError.
+const maxErrorBody = 1024
+
+// Client — клиент HTTP API одного окружения. Запросы аутентифицируются API ключом;
+// для путей /admin ключ должен иметь роль администратора.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient создает клиент API окружения с адресом baseURL (например, https://staging.example.com).
+func NewClient(baseURL, apiKey string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// APIError — ответ API с кодом ошибки.
+type APIError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// do выполняет запрос к пути path версии API /api/v1. Тело in кодируется в JSON, ответ
+// декодируется в out (если out не nil). Ответ с кодом не из 2xx возвращается как *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	target := c.baseURL + models.APIV1Prefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &APIError{Method: method, URL: target, Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %w", method, target, err)
+	}
+	return nil
+}
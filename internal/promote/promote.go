@@ -0,0 +1,387 @@
+// Package promote переносит проверенные данные из одного окружения в другое (например, из staging
+// в production) через HTTP API обоих окружений: справочники, профили ранжирования, активные версии
+// моделей и выбранные локации. В режиме dry-run изменения целевого окружения только рассчитываются.
+package promote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Section — раздел переносимых данных.
+type Section string
+
+const (
+	SectionReference       Section = "reference"        // Справочники типов бизнеса, регионов и городов
+	SectionRankingProfiles Section = "ranking-profiles" // Профили ранжирования типов бизнеса
+	SectionModels          Section = "models"           // Активные версии моделей
+	SectionLocations       Section = "locations"        // Локации, выбранные фильтром
+)
+
+// Sections — разделы в порядке переноса: справочники переносятся первыми, так как профили
+// ранжирования и локации ссылаются на типы бизнеса и регионы.
+var Sections = []Section{SectionReference, SectionRankingProfiles, SectionModels, SectionLocations}
+
+// Action — действие с записью целевого окружения.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionActivate  Action = "activate"
+	ActionUnchanged Action = "unchanged"
+	ActionSkip      Action = "skip"
+)
+
+// Change — изменение записи целевого окружения. Key — имя типа бизнеса, kind/version модели
+// или ID локации в целевом окружении.
+type Change struct {
+	Section Section `json:"section"`
+	Key     string  `json:"key"`
+	Action  Action  `json:"action"`
+	Detail  string  `json:"detail,omitempty"`
+}
+
+// Report — отчет о переносе.
+type Report struct {
+	DryRun        bool                  `json:"dry_run"`
+	ReferenceData *models.ReferenceDiff `json:"reference_data,omitempty"`
+	Changes       []Change              `json:"changes"`
+}
+
+// LocationFilter выбирает переносимые локации: по списку ID или по региону, городу и типу бизнеса.
+type LocationFilter struct {
+	IDs          []string
+	Region       string
+	City         string
+	BusinessType string
+	Limit        int // Максимум локаций (0 — без ограничения)
+}
+
+// Options — параметры переноса.
+type Options struct {
+	Sections  []Section
+	DryRun    bool
+	Prune     bool              // Удалить из справочников целевого окружения записи, отсутствующие в исходном
+	Locations LocationFilter    // Выбор локаций для SectionLocations
+	IDMap     map[string]string // ID локаций в целевом окружении по ID в исходном (без записи ID не меняется)
+	BatchSize int               // Число ID в запросе POST /locations/_mget (не больше LOCATIONS_MAX_IDS окружений)
+}
+
+// flatPageSize — размер страницы выгрузки /export/flat при выборе локаций по фильтру.
+const flatPageSize = 1000
+
+// Run переносит разделы opts.Sections из окружения source в target и возвращает отчет.
+// При ошибке перенос останавливается; отчет содержит изменения, выполненные до нее.
+func Run(ctx context.Context, source, target *Client, opts Options) (*Report, error) {
+	p := &promoter{source: source, target: target, opts: opts, report: &Report{DryRun: opts.DryRun, Changes: []Change{}}}
+	if p.opts.BatchSize <= 0 {
+		p.opts.BatchSize = 100
+	}
+
+	include := make(map[Section]bool, len(opts.Sections))
+	for _, section := range opts.Sections {
+		include[section] = true
+	}
+	steps := map[Section]func(context.Context) error{
+		SectionReference:       p.referenceData,
+		SectionRankingProfiles: p.rankingProfiles,
+		SectionModels:          p.models,
+		SectionLocations:       p.locations,
+	}
+	for _, section := range Sections {
+		if !include[section] {
+			continue
+		}
+		if err := steps[section](ctx); err != nil {
+			return p.report, fmt.Errorf("%s: %w", section, err)
+		}
+	}
+	return p.report, nil
+}
+
+type promoter struct {
+	source *Client
+	target *Client
+	opts   Options
+	report *Report
+}
+
+func (p *promoter) add(section Section, key string, action Action, detail string) {
+	p.report.Changes = append(p.report.Changes, Change{Section: section, Key: key, Action: action, Detail: detail})
+}
+
+// referenceData загружает выгрузку справочников исходного окружения в целевое. Целевое
+// окружение само рассчитывает изменения, в том числе в режиме dry-run.
+func (p *promoter) referenceData(ctx context.Context) error {
+	var data models.ReferenceData
+	if err := p.source.do(ctx, http.MethodGet, "/admin/reference-data", nil, nil, &data); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("dry_run", strconv.FormatBool(p.opts.DryRun))
+	query.Set("prune", strconv.FormatBool(p.opts.Prune))
+	var diff models.ReferenceDiff
+	if err := p.target.do(ctx, http.MethodPost, "/admin/reference-data", query, &data, &diff); err != nil {
+		return err
+	}
+	p.report.ReferenceData = &diff
+	return nil
+}
+
+// rankingProfiles заменяет профили ранжирования целевого окружения профилями исходного.
+// Профили типов бизнеса, которых нет в исходном окружении, не изменяются.
+func (p *promoter) rankingProfiles(ctx context.Context) error {
+	var sourceProfiles, targetProfiles []models.RankingProfile
+	if err := p.source.do(ctx, http.MethodGet, "/admin/ranking-profiles", nil, nil, &sourceProfiles); err != nil {
+		return err
+	}
+	if err := p.target.do(ctx, http.MethodGet, "/admin/ranking-profiles", nil, nil, &targetProfiles); err != nil {
+		return err
+	}
+	existing := make(map[string]models.RankingProfile, len(targetProfiles))
+	for _, profile := range targetProfiles {
+		existing[profile.BusinessType] = profile
+	}
+
+	for i := range sourceProfiles {
+		profile := &sourceProfiles[i]
+		action := ActionCreate
+		if previous, ok := existing[profile.BusinessType]; ok {
+			if reflect.DeepEqual(previous, *profile) {
+				p.add(SectionRankingProfiles, profile.BusinessType, ActionUnchanged, "")
+				continue
+			}
+			action = ActionUpdate
+		}
+		p.add(SectionRankingProfiles, profile.BusinessType, action, fmt.Sprintf("%d boosts", len(profile.Boosts)))
+		if p.opts.DryRun {
+			continue
+		}
+		path := "/admin/ranking-profiles/" + url.PathEscape(profile.BusinessType)
+		if err := p.target.do(ctx, http.MethodPut, path, nil, profile, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// models регистрирует в целевом окружении активные версии моделей исходного и активирует их.
+// Артефакты моделей (uri) должны быть доступны из целевого окружения: при активации версия
+// загружается, и ошибка загрузки останавливает перенос.
+func (p *promoter) models(ctx context.Context) error {
+	var sourceVersions, targetVersions []models.ModelVersion
+	if err := p.source.do(ctx, http.MethodGet, "/admin/models", nil, nil, &sourceVersions); err != nil {
+		return err
+	}
+	if err := p.target.do(ctx, http.MethodGet, "/admin/models", nil, nil, &targetVersions); err != nil {
+		return err
+	}
+	existing := make(map[string]models.ModelVersion, len(targetVersions))
+	for _, mv := range targetVersions {
+		existing[mv.Kind+"/"+mv.Version] = mv
+	}
+
+	for _, mv := range sourceVersions {
+		if !mv.Active {
+			continue
+		}
+		key := mv.Kind + "/" + mv.Version
+		previous, registered := existing[key]
+		if registered && previous.Active {
+			p.add(SectionModels, key, ActionUnchanged, "")
+			continue
+		}
+		if !registered {
+			p.add(SectionModels, key, ActionCreate, mv.URI)
+		}
+		p.add(SectionModels, key, ActionActivate, "")
+		if p.opts.DryRun {
+			continue
+		}
+
+		if !registered {
+			register := models.ModelVersion{Kind: mv.Kind, Version: mv.Version, Format: mv.Format, URI: mv.URI, Metadata: mv.Metadata}
+			if err := p.target.do(ctx, http.MethodPost, "/admin/models", nil, &register, nil); err != nil {
+				return err
+			}
+		}
+		path := "/admin/models/" + url.PathEscape(mv.Kind) + "/" + url.PathEscape(mv.Version) + "/activate"
+		if err := p.target.do(ctx, http.MethodPost, path, nil, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// locations копирует выбранные локации в целевое окружение: отсутствующие создаются,
+// отличающиеся заменяются. Состояние жизненного цикла существующих локаций не меняется,
+// а снятые с публикации локации не создаются.
+func (p *promoter) locations(ctx context.Context) error {
+	ids, err := p.locationIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(ids); start += p.opts.BatchSize {
+		end := start + p.opts.BatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := p.locationBatch(ctx, ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// locationIDs возвращает ID переносимых локаций исходного окружения: заданные списком или
+// выбранные по региону, городу и типу бизнеса из плоской выгрузки /export/flat.
+func (p *promoter) locationIDs(ctx context.Context) ([]string, error) {
+	filter := p.opts.Locations
+	if len(filter.IDs) > 0 {
+		return filter.IDs, nil
+	}
+
+	var ids []string
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(flatPageSize))
+	if filter.Region != "" {
+		query.Set("region", filter.Region)
+	}
+	if filter.City != "" {
+		query.Set("city", filter.City)
+	}
+	for {
+		var page models.FlatViewResponse
+		if err := p.source.do(ctx, http.MethodGet, "/export/flat", query, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, row := range page.Rows {
+			if filter.BusinessType != "" && !containsBusinessType(row.BusinessTypesSuitable, filter.BusinessType) {
+				continue
+			}
+			ids = append(ids, row.ID)
+			if filter.Limit > 0 && len(ids) >= filter.Limit {
+				return ids, nil
+			}
+		}
+		if page.NextCursor == "" {
+			return ids, nil
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+// containsBusinessType сообщает, есть ли businessType в списке типов плоской выгрузки (через ";").
+func containsBusinessType(list, businessType string) bool {
+	for _, name := range strings.Split(list, ";") {
+		if name == businessType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *promoter) locationBatch(ctx context.Context, ids []string) error {
+	var source models.MultiGetResponse
+	if err := p.source.do(ctx, http.MethodPost, "/locations/_mget", nil, &models.MultiGetRequest{IDs: ids}, &source); err != nil {
+		return err
+	}
+	for _, id := range source.Missing {
+		p.add(SectionLocations, p.targetID(id), ActionSkip, "not found in source")
+	}
+
+	targetIDs := make([]string, len(source.Found))
+	for i, location := range source.Found {
+		targetIDs[i] = p.targetID(location.ID)
+	}
+	existing := make(map[string]models.Location)
+	if len(targetIDs) > 0 {
+		var target models.MultiGetResponse
+		// Архивные локации цели тоже существуют: без них создание завершилось бы конфликтом ID
+		request := &models.MultiGetRequest{IDs: targetIDs, IncludeArchived: true}
+		if err := p.target.do(ctx, http.MethodPost, "/locations/_mget", nil, request, &target); err != nil {
+			return err
+		}
+		for _, location := range target.Found {
+			existing[location.ID] = location
+		}
+	}
+
+	for i := range source.Found {
+		location := source.Found[i]
+		location.ID = targetIDs[i]
+		detail := ""
+		if location.ID != source.Found[i].ID {
+			detail = "source id " + source.Found[i].ID
+		}
+
+		previous, ok := existing[location.ID]
+		switch {
+		case ok && reflect.DeepEqual(portable(previous), portable(location)):
+			p.add(SectionLocations, location.ID, ActionUnchanged, detail)
+			continue
+		case ok:
+			p.add(SectionLocations, location.ID, ActionUpdate, detail)
+		case location.CurrentStatus() == models.LocationRetired:
+			p.add(SectionLocations, location.ID, ActionSkip, "retired locations are not created")
+			continue
+		default:
+			p.add(SectionLocations, location.ID, ActionCreate, detail)
+		}
+		if p.opts.DryRun {
+			continue
+		}
+
+		payload := portable(location)
+		payload.ID = location.ID
+		if ok {
+			err := p.target.do(ctx, http.MethodPut, "/locations/"+url.PathEscape(location.ID), nil, &payload, nil)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		payload.Status = location.Status
+		if err := p.target.do(ctx, http.MethodPost, "/locations", nil, &payload, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetID возвращает ID локации в целевом окружении.
+func (p *promoter) targetID(id string) string {
+	if mapped, ok := p.opts.IDMap[id]; ok {
+		return mapped
+	}
+	return id
+}
+
+// portable возвращает копию локации без полей, которые назначаются окружением или вычисляются
+// при поиске и записи: по ним локации окружений не сравниваются и они не передаются при записи.
+// История демографии ведется каждым окружением при записи локации.
+func portable(location models.Location) models.Location {
+	location.ID = ""
+	location.CreatedAt = time.Time{}
+	location.UpdatedAt = time.Time{}
+	location.Status = ""
+	location.Score = 0
+	location.Archived = false
+	location.TravelTimeSeconds = 0
+	location.ExpectedDailyVisitors = 0
+	location.MatchedSegments = nil
+	location.DemographicEmbedding = nil
+	location.DemographicsHistory = nil
+	return location
+}
@@ -0,0 +1,179 @@
+// Package querycost оценивает стоимость поисковых запросов к Elasticsearch, выполненных
+// при обработке запроса API: время выполнения в кластере (took), число найденных документов
+// и число бакетов агрегаций. Стоимость накапливается в Meter из контекста запроса.
+package querycost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Веса составляющих стоимости в условных единицах.
+const (
+	costPerTookMs   = 1.0   // Миллисекунда выполнения в кластере
+	costPerKiloHits = 1.0   // Тысяча найденных документов
+	costPerBucket   = 0.001 // Бакет агрегации
+)
+
+// Usage содержит накопленные показатели поисковых запросов.
+type Usage struct {
+	Searches int64 `json:"searches"` // Число запросов _search и _count
+	TookMs   int64 `json:"took_ms"`  // Суммарное время выполнения в кластере, мс
+	Hits     int64 `json:"hits"`     // Суммарное число найденных документов
+	Buckets  int64 `json:"buckets"`  // Суммарное число бакетов агрегаций
+}
+
+// Cost возвращает стоимость в условных единицах.
+func (u Usage) Cost() float64 {
+	return float64(u.TookMs)*costPerTookMs + float64(u.Hits)/1000*costPerKiloHits + float64(u.Buckets)*costPerBucket
+}
+
+// Meter накапливает стоимость запросов к Elasticsearch одного запроса API.
+// Безопасен для параллельного использования.
+type Meter struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// Usage возвращает накопленные показатели.
+func (m *Meter) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+func (m *Meter) add(u Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Searches += u.Searches
+	m.usage.TookMs += u.TookMs
+	m.usage.Hits += u.Hits
+	m.usage.Buckets += u.Buckets
+}
+
+type contextKey struct{}
+
+// WithMeter возвращает контекст с Meter. Если в ctx уже есть Meter (например, добавленный
+// middleware slow-log), используется он.
+func WithMeter(ctx context.Context) (context.Context, *Meter) {
+	if meter := FromContext(ctx); meter != nil {
+		return ctx, meter
+	}
+	meter := &Meter{}
+	return context.WithValue(ctx, contextKey{}, meter), meter
+}
+
+// FromContext возвращает Meter запроса или nil, если стоимость не учитывается.
+func FromContext(ctx context.Context) *Meter {
+	meter, _ := ctx.Value(contextKey{}).(*Meter)
+	return meter
+}
+
+// Transport возвращает http.RoundTripper, учитывающий стоимость запросов _search и _count
+// в Meter из контекста запроса. Запросы без Meter передаются next без изменений.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper{next: next}
+}
+
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	meter := FromContext(req.Context())
+	res, err := t.next.RoundTrip(req)
+	if err != nil || meter == nil || res.StatusCode >= 400 || !isSearch(req.URL.Path) {
+		return res, err
+	}
+
+	// Тело ответа читается целиком для разбора и передается вызывающему коду заново
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if usage, ok := parseUsage(body); ok {
+		meter.add(usage)
+	}
+	return res, nil
+}
+
+// isSearch сообщает, является ли путь запросом поиска или подсчета документов.
+func isSearch(path string) bool {
+	return strings.HasSuffix(path, "/_search") || strings.HasSuffix(path, "/_count")
+}
+
+// parseUsage извлекает показатели из ответа _search или _count.
+func parseUsage(body []byte) (Usage, bool) {
+	var response struct {
+		Took  int64  `json:"took"`
+		Count *int64 `json:"count"`
+		Hits  struct {
+			Total json.RawMessage `json:"total"`
+		} `json:"hits"`
+		Aggregations json.RawMessage `json:"aggregations"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Usage{}, false
+	}
+
+	usage := Usage{Searches: 1, TookMs: response.Took}
+	if response.Count != nil {
+		usage.Hits = *response.Count
+	} else {
+		usage.Hits = parseTotal(response.Hits.Total)
+	}
+	if len(response.Aggregations) > 0 {
+		var aggregations interface{}
+		if err := json.Unmarshal(response.Aggregations, &aggregations); err == nil {
+			usage.Buckets = countBuckets(aggregations)
+		}
+	}
+	return usage, true
+}
+
+// parseTotal разбирает hits.total: объект {"value": N} (Elasticsearch 7+) или число.
+func parseTotal(raw json.RawMessage) int64 {
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &total); err == nil {
+		return total.Value
+	}
+	var value int64
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+	return 0
+}
+
+// countBuckets считает бакеты во всех агрегациях, включая вложенные.
+func countBuckets(value interface{}) int64 {
+	var count int64
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "buckets" {
+				switch buckets := child.(type) {
+				case []interface{}:
+					count += int64(len(buckets))
+				case map[string]interface{}:
+					count += int64(len(buckets))
+				}
+			}
+			count += countBuckets(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			count += countBuckets(child)
+		}
+	}
+	return count
+}
@@ -0,0 +1,246 @@
+// Package reconcile содержит сверку локаций между PostgreSQL (система-источник истины)
+// и Elasticsearch: обнаружение документов-сирот с обеих сторон и их двухфазное исправление.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+const (
+	sideES = "es" // Документ есть только в Elasticsearch
+	sidePG = "pg" // Локация есть только в PostgreSQL
+)
+
+// LockName — имя блокировки сверки: сверка выполняется одним экземпляром кластера.
+const LockName = "reconcile"
+
+// Report содержит результат сверки.
+type Report struct {
+	ESCount   int       `json:"es_count"`
+	PGCount   int       `json:"pg_count"`
+	OnlyInES  []string  `json:"only_in_es"` // Сироты в Elasticsearch (нет в PostgreSQL)
+	OnlyInPG  []string  `json:"only_in_pg"` // Не доставлены в Elasticsearch
+	Pending   int       `json:"pending"`    // Пропущены из-за необработанных записей outbox
+	Deleted   []string  `json:"deleted"`    // Удалены из Elasticsearch (только с DeleteOrphans)
+	Requeued  []string  `json:"requeued"`   // Повторно поставлены в outbox
+	Deferred  int       `json:"deferred"`   // Ожидают истечения grace-периода
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// Options задает режим сверки.
+type Options struct {
+	// Repair исправляет расхождения старше grace-периода: недоставленные локации повторно
+	// ставятся в outbox. Сироты в Elasticsearch только перечисляются в отчете.
+	Repair bool
+	// DeleteOrphans вместе с Repair удаляет сироты из Elasticsearch. Документы, загруженные
+	// indexer напрямую в Elasticsearch, в PostgreSQL отсутствуют и тоже считаются сиротами.
+	DeleteOrphans bool
+}
+
+// Reconciler сверяет PostgreSQL и Elasticsearch.
+type Reconciler struct {
+	pgStorage *storage.PostgresStorage
+	esStorage *storage.ElasticsearchStorage
+	notifier  notify.Notifier
+	grace     time.Duration
+	locks     *lock.Manager // Блокировка сверки (nil — без блокировки)
+}
+
+// NewReconciler создает новый экземпляр Reconciler.
+// Расхождения исправляются, только если впервые обнаружены раньше, чем grace назад.
+func NewReconciler(pgStorage *storage.PostgresStorage, esStorage *storage.ElasticsearchStorage, notifier notify.Notifier, grace time.Duration) *Reconciler {
+	return &Reconciler{
+		pgStorage: pgStorage,
+		esStorage: esStorage,
+		notifier:  notifier,
+		grace:     grace,
+	}
+}
+
+// SetLocks задает менеджер блокировок, исключающий одновременную сверку на нескольких экземплярах.
+func (r *Reconciler) SetLocks(locks *lock.Manager) {
+	r.locks = locks
+}
+
+// Run выполняет сверку. С opts.Repair исправляет расхождения старше grace-периода:
+// недоставленные локации повторно ставятся в outbox, а сироты в Elasticsearch удаляются
+// только с opts.DeleteOrphans. Возвращает lock.ErrHeld, если сверка уже выполняется
+// другим экземпляром.
+func (r *Reconciler) Run(ctx context.Context, opts Options) (*Report, error) {
+	var report *Report
+	err := r.locks.Do(ctx, LockName, func(ctx context.Context) error {
+		var err error
+		report, err = r.run(ctx, opts)
+		return err
+	})
+	return report, err
+}
+
+func (r *Reconciler) run(ctx context.Context, opts Options) (*Report, error) {
+	report := &Report{StartedAt: time.Now()}
+
+	pgIDs, err := r.pgStorage.ListLocationIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.PGCount = len(pgIDs)
+
+	inPG := make(map[string]bool, len(pgIDs))
+	for _, id := range pgIDs {
+		inPG[id] = true
+	}
+
+	// Читаем outbox после списка PostgreSQL: локации, записанные между чтениями, будут учтены как pending
+	pending, err := r.pgStorage.PendingOutboxLocationIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inES := make(map[string]bool)
+	err = r.esStorage.ScanLocationIDs(ctx, 1000, func(ids []string) error {
+		for _, id := range ids {
+			inES[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.ESCount = len(inES)
+
+	for id := range inES {
+		if inPG[id] {
+			continue
+		}
+		if pending[id] {
+			report.Pending++
+			continue
+		}
+		report.OnlyInES = append(report.OnlyInES, id)
+	}
+	for id := range inPG {
+		if inES[id] {
+			continue
+		}
+		if pending[id] {
+			report.Pending++
+			continue
+		}
+		report.OnlyInPG = append(report.OnlyInPG, id)
+	}
+	sort.Strings(report.OnlyInES)
+	sort.Strings(report.OnlyInPG)
+
+	// Фаза 1: фиксируем расхождения и время их первого обнаружения
+	esFirstSeen, err := r.pgStorage.RecordOrphans(ctx, sideES, report.OnlyInES)
+	if err != nil {
+		return nil, err
+	}
+	pgFirstSeen, err := r.pgStorage.RecordOrphans(ctx, sidePG, report.OnlyInPG)
+	if err != nil {
+		return nil, err
+	}
+
+	// Фаза 2: исправляем только устойчивые расхождения
+	if opts.Repair {
+		cutoff := time.Now().Add(-r.grace)
+
+		for _, id := range report.OnlyInES {
+			if !opts.DeleteOrphans {
+				break
+			}
+			if esFirstSeen[id].After(cutoff) {
+				report.Deferred++
+				continue
+			}
+			if err := r.esStorage.DeleteLocation(ctx, id); err != nil && !errors.Is(err, storage.ErrLocationNotFound) {
+				slog.ErrorContext(ctx, "Error deleting orphan from Elasticsearch", "location_id", id, logging.Err(err))
+				continue
+			}
+			if err := r.esStorage.DeleteArchivedLocation(ctx, id); err != nil && !errors.Is(err, storage.ErrLocationNotFound) {
+				slog.ErrorContext(ctx, "Error deleting orphan from archive", "location_id", id, logging.Err(err))
+				continue
+			}
+			if err := r.pgStorage.ResolveOrphan(ctx, sideES, id); err != nil {
+				slog.ErrorContext(ctx, "Error resolving orphan", "location_id", id, logging.Err(err))
+			}
+			report.Deleted = append(report.Deleted, id)
+		}
+
+		for _, id := range report.OnlyInPG {
+			if pgFirstSeen[id].After(cutoff) {
+				report.Deferred++
+				continue
+			}
+			if err := r.pgStorage.EnqueueLocationUpsert(ctx, id); err != nil {
+				slog.ErrorContext(ctx, "Error requeueing location", "location_id", id, logging.Err(err))
+				continue
+			}
+			if err := r.pgStorage.ResolveOrphan(ctx, sidePG, id); err != nil {
+				slog.ErrorContext(ctx, "Error resolving orphan", "location_id", id, logging.Err(err))
+			}
+			report.Requeued = append(report.Requeued, id)
+		}
+	}
+
+	report.Duration = time.Since(report.StartedAt).String()
+
+	if len(report.OnlyInES) > 0 || len(report.OnlyInPG) > 0 {
+		alert := notify.Alert{
+			Source:   "reconcile",
+			Severity: notify.SeverityWarning,
+			Title:    "Location stores diverged",
+			Message: fmt.Sprintf("%d documents only in Elasticsearch, %d locations only in PostgreSQL",
+				len(report.OnlyInES), len(report.OnlyInPG)),
+			Details: map[string]interface{}{
+				"deleted":  len(report.Deleted),
+				"requeued": len(report.Requeued),
+				"deferred": report.Deferred,
+			},
+			CreatedAt: time.Now(),
+		}
+		if err := r.notifier.Notify(ctx, alert); err != nil {
+			slog.ErrorContext(ctx, "Error sending reconcile alert", logging.Err(err))
+		}
+	}
+
+	return report, nil
+}
+
+// Runner возвращает фоновый процесс, выполняющий сверку в режиме opts каждые interval.
+func (r *Reconciler) Runner(interval time.Duration, opts Options) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				report, err := r.Run(ctx, opts)
+				if errors.Is(err, lock.ErrHeld) {
+					continue
+				}
+				if err != nil {
+					slog.ErrorContext(ctx, "Error reconciling locations", logging.Err(err))
+					continue
+				}
+				slog.InfoContext(ctx, "Reconcile completed", "es", report.ESCount, "pg", report.PGCount,
+					"only_in_es", len(report.OnlyInES), "only_in_pg", len(report.OnlyInPG),
+					"deleted", len(report.Deleted), "requeued", len(report.Requeued))
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// counterScript увеличивает счетчик KEYS[1] и при создании задает ему время жизни ARGV[1] секунд.
+// Возвращает значение после увеличения.
+var counterScript = goredis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+return n`)
+
+// Counters хранит в Redis счетчики, общие для всех экземпляров сервиса (например, запросы
+// API ключей за сутки). Счетчик удаляется по TTL, заданному при его создании.
+type Counters struct {
+	client *Client
+	prefix string // Префикс ключей
+}
+
+// NewCounters создает хранилище счетчиков с ключами prefix + ключ счетчика.
+func NewCounters(client *Client, prefix string) *Counters {
+	return &Counters{client: client, prefix: prefix}
+}
+
+// Incr увеличивает счетчик key на 1 и возвращает новое значение. Новый счетчик удаляется через ttl.
+func (c *Counters) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := counterScript.Run(ctx, c.client, []string{c.prefix + key}, int64(ttl/time.Second)).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w", err)
+	}
+	return n, nil
+}
+
+// Get возвращает значение счетчика key или 0, если его нет.
+func (c *Counters) Get(ctx context.Context, key string) (int64, error) {
+	n, err := c.client.Get(ctx, c.prefix+key).Int64()
+	if errors.Is(err, goredis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counter: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,27 @@
+// Package redis хранит в Redis счетчики, общие для экземпляров сервиса (ограничение частоты
+// запросов, квоты API ключей). Соединениями и протоколом управляет github.com/redis/go-redis;
+// Lua скрипты выполняются через EVALSHA и загружаются командой EVAL, только если их еще нет
+// в кеше скриптов Redis.
+package redis
+
+import (
+	"fmt"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Client — клиент Redis с пулом соединений.
+type Client = goredis.Client
+
+// New создает клиент по URL вида redis://:password@host:6379/db (rediss:// — с TLS). poolSize
+// ограничивает число соединений пула.
+func New(rawURL string, poolSize int) (*Client, error) {
+	opts, err := goredis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if poolSize > 0 {
+		opts.PoolSize = poolSize
+	}
+	return goredis.NewClient(opts), nil
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript пополняет bucket KEYS[1] на ARGV[1] токенов в секунду с емкостью ARGV[2]
+// к моменту ARGV[3] (секунды Unix) и расходует токен, если он есть. Время передает экземпляр:
+// скрипт без TIME детерминирован и реплицируется как есть, а расхождение часов экземпляров
+// (при синхронизации по NTP — миллисекунды) лишь немного сдвигает пополнение. Время bucket
+// не уходит назад, поэтому отстающие часы одного экземпляра не пополняют bucket повторно.
+// Возвращает 1, если токен израсходован.
+var tokenBucketScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('EXPIRE', KEYS[1], math.ceil(burst / rate) + 1)
+return allowed`)
+
+// TokenBuckets хранит token bucket клиентов в Redis, общие для всех экземпляров сервиса.
+// Bucket удаляется по TTL после полного пополнения.
+type TokenBuckets struct {
+	client *Client
+	prefix string // Префикс ключей
+}
+
+// NewTokenBuckets создает хранилище bucket с ключами prefix + ключ клиента.
+func NewTokenBuckets(client *Client, prefix string) *TokenBuckets {
+	return &TokenBuckets{client: client, prefix: prefix}
+}
+
+// Take пополняет bucket клиента key на rate токенов в секунду с емкостью burst и расходует токен.
+// Возвращает false, если токенов нет.
+func (tb *TokenBuckets) Take(ctx context.Context, key string, rate float64, burst int) (bool, error) {
+	now := float64(time.Now().UnixMicro()) / 1e6
+	allowed, err := tokenBucketScript.Run(ctx, tb.client, []string{tb.prefix + key}, rate, burst, now).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to take token: %w", err)
+	}
+	return allowed == 1, nil
+}
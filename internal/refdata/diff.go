@@ -0,0 +1,221 @@
+package refdata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// FromModels строит переносимую выгрузку из справочников окружения: ссылки на родителей по ID
+// заменяются именами категорий и путями регионов. Типы бизнеса упорядочены по имени, регионы —
+// по пути, поэтому родительский регион предшествует дочерним, города — по пути региона и имени.
+func FromModels(businessTypes []models.BusinessType, regions []models.Region, cities []models.City) *models.ReferenceData {
+	names := make(map[int]string, len(businessTypes))
+	for _, bt := range businessTypes {
+		names[bt.ID] = bt.Name
+	}
+	data := &models.ReferenceData{
+		BusinessTypes: make([]models.ReferenceBusinessType, 0, len(businessTypes)),
+		Regions:       make([]models.ReferenceRegion, 0, len(regions)),
+		Cities:        make([]models.ReferenceCity, 0, len(cities)),
+	}
+	for _, bt := range businessTypes {
+		item := models.ReferenceBusinessType{Name: bt.Name, Description: bt.Description, BenefitsFromEvents: bt.BenefitsFromEvents}
+		if bt.ParentID != nil {
+			item.Parent = names[*bt.ParentID]
+		}
+		data.BusinessTypes = append(data.BusinessTypes, item)
+	}
+	sort.Slice(data.BusinessTypes, func(i, j int) bool { return data.BusinessTypes[i].Name < data.BusinessTypes[j].Name })
+
+	paths := models.RegionPaths(regions)
+	for _, region := range regions {
+		item := models.ReferenceRegion{Name: region.Name}
+		if path := paths[region.ID]; path != region.Name {
+			item.Parent = strings.TrimSuffix(path, models.RegionPathSeparator+region.Name)
+		}
+		data.Regions = append(data.Regions, item)
+	}
+	sort.Slice(data.Regions, func(i, j int) bool { return data.Regions[i].Path() < data.Regions[j].Path() })
+
+	for _, city := range cities {
+		data.Cities = append(data.Cities, models.ReferenceCity{
+			Name:       city.Name,
+			Region:     paths[city.RegionID],
+			Center:     city.Center,
+			Population: city.Population,
+		})
+	}
+	sort.Slice(data.Cities, func(i, j int) bool { return data.Cities[i].Key() < data.Cities[j].Key() })
+	return data
+}
+
+// Validate проверяет выгрузку перед импортом: имена заданы и уникальны, родители и регионы городов
+// есть в самой выгрузке (она содержит справочники целиком), категории типов бизнеса не образуют цикл,
+// координаты центров городов корректны.
+func Validate(data *models.ReferenceData) error {
+	parents := make(map[string]string, len(data.BusinessTypes))
+	for i, bt := range data.BusinessTypes {
+		if bt.Name == "" {
+			return fmt.Errorf("business_types[%d]: name is required", i)
+		}
+		if _, ok := parents[bt.Name]; ok {
+			return fmt.Errorf("business_types[%d]: duplicate name %q", i, bt.Name)
+		}
+		parents[bt.Name] = bt.Parent
+	}
+	for _, bt := range data.BusinessTypes {
+		if bt.Parent == "" {
+			continue
+		}
+		if _, ok := parents[bt.Parent]; !ok {
+			return fmt.Errorf("business type %q: parent %q is not in the reference data", bt.Name, bt.Parent)
+		}
+		// Путь вверх по категориям не длиннее числа типов, иначе он зациклен
+		name := bt.Parent
+		for steps := 0; name != ""; steps++ {
+			if name == bt.Name || steps > len(parents) {
+				return fmt.Errorf("business type %q: parent chain forms a cycle", bt.Name)
+			}
+			name = parents[name]
+		}
+	}
+
+	paths := make(map[string]bool, len(data.Regions))
+	for i, region := range data.Regions {
+		if region.Name == "" {
+			return fmt.Errorf("regions[%d]: name is required", i)
+		}
+		if strings.Contains(region.Name, models.RegionPathSeparator) {
+			return fmt.Errorf("regions[%d]: name must not contain %q", i, models.RegionPathSeparator)
+		}
+		if paths[region.Path()] {
+			return fmt.Errorf("regions[%d]: duplicate region %q", i, region.Path())
+		}
+		paths[region.Path()] = true
+	}
+	for _, region := range data.Regions {
+		if region.Parent != "" && !paths[region.Parent] {
+			return fmt.Errorf("region %q: parent %q is not in the reference data", region.Path(), region.Parent)
+		}
+	}
+
+	cities := make(map[string]bool, len(data.Cities))
+	for i, city := range data.Cities {
+		if city.Name == "" {
+			return fmt.Errorf("cities[%d]: name is required", i)
+		}
+		if !paths[city.Region] {
+			return fmt.Errorf("city %q: region %q is not in the reference data", city.Name, city.Region)
+		}
+		if cities[city.Key()] {
+			return fmt.Errorf("cities[%d]: duplicate city %q", i, city.Key())
+		}
+		cities[city.Key()] = true
+		if city.Center.Lat < -90 || city.Center.Lat > 90 || city.Center.Lon < -180 || city.Center.Lon > 180 ||
+			(city.Center.Lat == 0 && city.Center.Lon == 0) {
+			return fmt.Errorf("city %q: center must be a valid point", city.Key())
+		}
+		if city.Population < 0 {
+			return fmt.Errorf("city %q: population must not be negative", city.Key())
+		}
+	}
+	return nil
+}
+
+// Diff рассчитывает изменения, которые импорт incoming внесет в справочники current.
+// Записи current, отсутствующие в incoming, перечисляются в Removed.
+func Diff(current, incoming *models.ReferenceData) *models.ReferenceDiff {
+	diff := &models.ReferenceDiff{
+		BusinessTypes: newChanges(),
+		Regions:       newChanges(),
+		Cities:        newChanges(),
+		Cascade:       models.ReferenceCascade{Substitutes: []string{}, DetachedBusinessTypes: []string{}},
+	}
+
+	existing := make(map[string]models.ReferenceBusinessType, len(current.BusinessTypes))
+	for _, bt := range current.BusinessTypes {
+		existing[bt.Name] = bt
+	}
+	imported := make(map[string]bool, len(incoming.BusinessTypes))
+	for _, bt := range incoming.BusinessTypes {
+		imported[bt.Name] = true
+		previous, ok := existing[bt.Name]
+		switch {
+		case !ok:
+			diff.BusinessTypes.Added = append(diff.BusinessTypes.Added, bt.Name)
+		case previous != bt:
+			diff.BusinessTypes.Updated = append(diff.BusinessTypes.Updated, bt.Name)
+		default:
+			diff.BusinessTypes.Unchanged++
+		}
+	}
+	for _, bt := range current.BusinessTypes {
+		if !imported[bt.Name] {
+			diff.BusinessTypes.Removed = append(diff.BusinessTypes.Removed, bt.Name)
+		}
+	}
+
+	// Регионы идентифицируются путем и не имеют других атрибутов: перенос региона к другому
+	// родителю — это удаление по старому пути и добавление по новому
+	regions := make(map[string]bool, len(current.Regions))
+	for _, region := range current.Regions {
+		regions[region.Path()] = true
+	}
+	importedRegions := make(map[string]bool, len(incoming.Regions))
+	for _, region := range incoming.Regions {
+		path := region.Path()
+		importedRegions[path] = true
+		if regions[path] {
+			diff.Regions.Unchanged++
+		} else {
+			diff.Regions.Added = append(diff.Regions.Added, path)
+		}
+	}
+	for _, region := range current.Regions {
+		if !importedRegions[region.Path()] {
+			diff.Regions.Removed = append(diff.Regions.Removed, region.Path())
+		}
+	}
+
+	cities := make(map[string]models.ReferenceCity, len(current.Cities))
+	for _, city := range current.Cities {
+		cities[city.Key()] = city
+	}
+	importedCities := make(map[string]bool, len(incoming.Cities))
+	for _, city := range incoming.Cities {
+		key := city.Key()
+		importedCities[key] = true
+		previous, ok := cities[key]
+		switch {
+		case !ok:
+			diff.Cities.Added = append(diff.Cities.Added, key)
+		case previous != city:
+			diff.Cities.Updated = append(diff.Cities.Updated, key)
+		default:
+			diff.Cities.Unchanged++
+		}
+	}
+	for _, city := range current.Cities {
+		if !importedCities[city.Key()] {
+			diff.Cities.Removed = append(diff.Cities.Removed, city.Key())
+		}
+	}
+
+	sort.Strings(diff.BusinessTypes.Added)
+	sort.Strings(diff.BusinessTypes.Updated)
+	sort.Strings(diff.BusinessTypes.Removed)
+	sort.Strings(diff.Regions.Added)
+	sort.Strings(diff.Regions.Removed)
+	sort.Strings(diff.Cities.Added)
+	sort.Strings(diff.Cities.Updated)
+	sort.Strings(diff.Cities.Removed)
+	return diff
+}
+
+// newChanges возвращает пустые изменения: списки без записей кодируются в JSON как [], а не null.
+func newChanges() models.ReferenceChanges {
+	return models.ReferenceChanges{Added: []string{}, Updated: []string{}, Removed: []string{}}
+}
@@ -0,0 +1,175 @@
+// Package refdata выгружает и загружает справочники типов бизнеса, регионов и городов в переносимом
+// формате (JSON или CSV) и рассчитывает различия между справочниками окружений, чтобы окружения
+// синхронизировались без SQL дампов.
+package refdata
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Format определяет формат выгрузки справочников.
+type Format string
+
+const (
+	FormatJSON Format = "json" // Объект с массивами business_types, regions и cities
+	FormatCSV  Format = "csv"  // Строка на каждую запись всех справочников (см. csvHeader)
+)
+
+// ContentType возвращает MIME тип выгрузки.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Valid сообщает, поддерживается ли формат.
+func (f Format) Valid() bool {
+	return f == FormatJSON || f == FormatCSV
+}
+
+// Значения столбца dictionary в CSV выгрузке.
+const (
+	dictionaryBusinessType = "business_type"
+	dictionaryRegion       = "region"
+	dictionaryCity         = "city"
+)
+
+// csvHeader — столбцы CSV выгрузки. parent — имя категории для типа бизнеса, путь
+// родительского региона для региона и путь региона для города; description и
+// benefits_from_events заполняются только у типов бизнеса, center_lat, center_lon
+// и population — только у городов.
+var csvHeader = []string{"dictionary", "name", "parent", "description", "benefits_from_events", "center_lat", "center_lon", "population"}
+
+// Write записывает справочники в w в формате format.
+func Write(w io.Writer, format Format, data *models.ReferenceData) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, bt := range data.BusinessTypes {
+			record := []string{dictionaryBusinessType, bt.Name, bt.Parent, bt.Description, strconv.FormatBool(bt.BenefitsFromEvents), "", "", ""}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		for _, region := range data.Regions {
+			if err := cw.Write([]string{dictionaryRegion, region.Name, region.Parent, "", "", "", "", ""}); err != nil {
+				return err
+			}
+		}
+		for _, city := range data.Cities {
+			record := []string{dictionaryCity, city.Name, city.Region, "", "",
+				strconv.FormatFloat(city.Center.Lat, 'f', -1, 64), strconv.FormatFloat(city.Center.Lon, 'f', -1, 64),
+				strconv.Itoa(city.Population)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unsupported reference data format: %q", format)
+}
+
+// Read читает справочники из r в формате format. Содержимое не проверяется (см. Validate).
+func Read(r io.Reader, format Format) (*models.ReferenceData, error) {
+	switch format {
+	case FormatJSON:
+		var data models.ReferenceData
+		if err := json.NewDecoder(r).Decode(&data); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return &data, nil
+	case FormatCSV:
+		return readCSV(r)
+	}
+	return nil, fmt.Errorf("unsupported reference data format: %q", format)
+}
+
+// readCSV читает CSV выгрузку. Столбцы сопоставляются по заголовку (регистр не важен),
+// обязательны dictionary и name; остальные столбцы могут отсутствовать.
+func readCSV(r io.Reader) (*models.ReferenceData, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Excel добавляет BOM в начало файла в кодировке UTF-8
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"dictionary", "name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header must contain column %q", required)
+		}
+	}
+
+	data := &models.ReferenceData{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch dictionary := field("dictionary"); dictionary {
+		case dictionaryBusinessType:
+			bt := models.ReferenceBusinessType{Name: field("name"), Parent: field("parent"), Description: field("description")}
+			if value := field("benefits_from_events"); value != "" {
+				if bt.BenefitsFromEvents, err = strconv.ParseBool(value); err != nil {
+					return nil, fmt.Errorf("line %d: benefits_from_events must be true or false", line)
+				}
+			}
+			data.BusinessTypes = append(data.BusinessTypes, bt)
+		case dictionaryRegion:
+			data.Regions = append(data.Regions, models.ReferenceRegion{Name: field("name"), Parent: field("parent")})
+		case dictionaryCity:
+			city := models.ReferenceCity{Name: field("name"), Region: field("parent")}
+			if city.Center.Lat, err = strconv.ParseFloat(field("center_lat"), 64); err != nil {
+				return nil, fmt.Errorf("line %d: center_lat must be a number", line)
+			}
+			if city.Center.Lon, err = strconv.ParseFloat(field("center_lon"), 64); err != nil {
+				return nil, fmt.Errorf("line %d: center_lon must be a number", line)
+			}
+			if value := field("population"); value != "" {
+				if city.Population, err = strconv.Atoi(value); err != nil {
+					return nil, fmt.Errorf("line %d: population must be an integer", line)
+				}
+			}
+			data.Cities = append(data.Cities, city)
+		default:
+			return nil, fmt.Errorf("line %d: dictionary must be %q, %q or %q, got %q", line,
+				dictionaryBusinessType, dictionaryRegion, dictionaryCity, dictionary)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,168 @@
+package refresh
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+const (
+	metersPerDegree = 111320.0
+	// maxCompetitionDensity — верхняя граница шкалы competition_density.
+	maxCompetitionDensity = 10.0
+	updateBatchSize       = 500
+)
+
+// point — локация, участвующая в расчете конкуренции.
+type point struct {
+	id    string
+	pos   models.GeoPoint
+	types map[string]bool
+}
+
+// RecomputeCompetition пересчитывает competition_density всех локаций основного индекса как количество
+// других локаций в радиусе radiusMeters, подходящих хотя бы для одного общего типа бизнеса
+// (с ограничением шкалой 0–10). Локация, подходящая только для замещающего типа из substitutes,
+// учитывается с долей замещаемости. Обновляются только изменившиеся значения (см. updateField).
+// Возвращает количество обновленных документов.
+func RecomputeCompetition(ctx context.Context, esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, radiusMeters float64, substitutes []models.BusinessTypeSubstitute) (int, error) {
+	var points []point
+	current := make(map[string]float64)
+
+	err := esStorage.ScanLocations(ctx, &models.LocationFilter{}, 1000, func(locations []*models.Location) error {
+		for _, loc := range locations {
+			types := make(map[string]bool, len(loc.BusinessTypesSuitable))
+			for _, t := range loc.BusinessTypesSuitable {
+				types[t] = true
+			}
+			points = append(points, point{id: loc.ID, pos: loc.Coordinates, types: types})
+			current[loc.ID] = loc.CompetitionDensity
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	density := competitionDensity(points, radiusMeters, newSubstituteWeights(substitutes))
+
+	return updateField(ctx, esStorage, pgStorage, "competition_density", density, current)
+}
+
+// updateField записывает в поле field локаций значения values. Локации, сохраненные в PostgreSQL,
+// обновляются в нем и доставляются в Elasticsearch через outbox, иначе повторная доставка
+// вернула бы прежнее значение; остальные локации (загруженные индексатором напрямую)
+// обновляются в Elasticsearch, если значение отличается от current.
+// Возвращает количество обновленных документов.
+func updateField(ctx context.Context, esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, field string, values, current map[string]float64) (int, error) {
+	updated := 0
+	batch := make(map[string]map[string]interface{})
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		stored, changed, err := pgStorage.UpdateLocationFields(ctx, batch)
+		if err != nil {
+			return err
+		}
+		updated += changed
+
+		direct := make(map[string]map[string]interface{})
+		for id, fields := range batch {
+			if !stored[id] && current[id] != values[id] {
+				direct[id] = fields
+			}
+		}
+		batch = make(map[string]map[string]interface{})
+		if len(direct) == 0 {
+			return nil
+		}
+		failed, err := esStorage.BulkUpdateFields(ctx, direct)
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to update %s for %d locations", field, failed)
+		}
+		updated += len(direct)
+		return nil
+	}
+
+	for id, value := range values {
+		batch[id] = map[string]interface{}{field: value}
+		if len(batch) >= updateBatchSize {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return updated, err
+	}
+
+	return updated, nil
+}
+
+// substituteWeights — доли замещаемости: weights[businessType][substitute].
+type substituteWeights map[string]map[string]float64
+
+func newSubstituteWeights(substitutes []models.BusinessTypeSubstitute) substituteWeights {
+	weights := make(substituteWeights)
+	for _, sub := range substitutes {
+		if weights[sub.BusinessType] == nil {
+			weights[sub.BusinessType] = make(map[string]float64)
+		}
+		weights[sub.BusinessType][sub.Substitute] = sub.Weight
+	}
+	return weights
+}
+
+// competitionDensity считает конкурентов каждой точки. Точки сортируются по широте,
+// и для каждой проверяются только соседи в полосе широт шириной radius.
+func competitionDensity(points []point, radius float64, weights substituteWeights) map[string]float64 {
+	sort.Slice(points, func(i, j int) bool { return points[i].pos.Lat < points[j].pos.Lat })
+
+	counts := make([]float64, len(points))
+	latWindow := radius / metersPerDegree
+	for i := range points {
+		for j := i + 1; j < len(points) && points[j].pos.Lat-points[i].pos.Lat <= latWindow; j++ {
+			wi := weights.competition(points[i].types, points[j].types)
+			wj := weights.competition(points[j].types, points[i].types)
+			if wi == 0 && wj == 0 {
+				continue
+			}
+			if geo.DistanceMeters(points[i].pos, points[j].pos) <= radius {
+				counts[i] += wi
+				counts[j] += wj
+			}
+		}
+	}
+
+	density := make(map[string]float64, len(points))
+	for i, p := range points {
+		density[p.id] = math.Min(math.Round(counts[i]*10)/10, maxCompetitionDensity)
+	}
+	return density
+}
+
+// competition возвращает, в какой доле локация с типами other конкурирует с локацией с типами own:
+// 1 при общем типе, иначе наибольшую долю замещаемости типа own типом other.
+func (w substituteWeights) competition(own, other map[string]bool) float64 {
+	best := 0.0
+	for t := range own {
+		if other[t] {
+			return 1
+		}
+		for sub, weight := range w[t] {
+			if other[sub] && weight > best {
+				best = weight
+			}
+		}
+	}
+	return best
+}
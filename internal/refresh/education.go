@@ -0,0 +1,49 @@
+package refresh
+
+import (
+	"context"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// RecomputeEducation пересчитывает schools_nearby и universities_nearby всех локаций основного индекса —
+// число учебных заведений каждого вида в пешей доступности walkMeters по прямой.
+// Возвращает количество обновленных значений.
+func RecomputeEducation(ctx context.Context, esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, institutions []models.EducationInstitution, walkMeters float64) (int, error) {
+	schools := make(map[string]float64)
+	universities := make(map[string]float64)
+	currentSchools := make(map[string]float64)
+	currentUniversities := make(map[string]float64)
+
+	err := esStorage.ScanLocations(ctx, &models.LocationFilter{}, 1000, func(locations []*models.Location) error {
+		for _, loc := range locations {
+			schools[loc.ID] = 0
+			universities[loc.ID] = 0
+			for _, inst := range institutions {
+				if geo.DistanceMeters(loc.Coordinates, inst.Coordinates) > walkMeters {
+					continue
+				}
+				if inst.Kind == "university" {
+					universities[loc.ID]++
+				} else {
+					schools[loc.ID]++
+				}
+			}
+			currentSchools[loc.ID] = float64(loc.SchoolsNearby)
+			currentUniversities[loc.ID] = float64(loc.UniversitiesNearby)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := updateField(ctx, esStorage, pgStorage, "schools_nearby", schools, currentSchools)
+	if err != nil {
+		return updated, err
+	}
+	n, err := updateField(ctx, esStorage, pgStorage, "universities_nearby", universities, currentUniversities)
+	return updated + n, err
+}
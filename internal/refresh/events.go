@@ -0,0 +1,52 @@
+package refresh
+
+import (
+	"context"
+	"math"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// maxEventExposure — верхняя граница шкалы event_exposure.
+const maxEventExposure = 10.0
+
+// RecomputeEventExposure пересчитывает event_exposure всех локаций основного индекса по площадкам
+// мероприятий в радиусе radiusMeters и возвращает количество обновленных документов.
+//
+// Вклад площадки — число мероприятий в год, умноженное на вместимость в тысячах зрителей
+// и линейно убывающее до нуля на границе радиуса; сумма вкладов переводится в шкалу 0–10
+// логарифмически, чтобы один крупный стадион не обесценивал остальные площадки.
+func RecomputeEventExposure(ctx context.Context, esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, venues []models.EventVenue, radiusMeters float64) (int, error) {
+	exposure := make(map[string]float64)
+	current := make(map[string]float64)
+
+	err := esStorage.ScanLocations(ctx, &models.LocationFilter{}, 1000, func(locations []*models.Location) error {
+		for _, loc := range locations {
+			exposure[loc.ID] = eventExposure(loc.Coordinates, venues, radiusMeters)
+			current[loc.ID] = loc.EventExposure
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updateField(ctx, esStorage, pgStorage, "event_exposure", exposure, current)
+}
+
+// eventExposure рассчитывает event_exposure точки.
+func eventExposure(pos models.GeoPoint, venues []models.EventVenue, radius float64) float64 {
+	var raw float64
+	for _, venue := range venues {
+		d := geo.DistanceMeters(pos, venue.Coordinates)
+		if d >= radius {
+			continue
+		}
+		capacity := math.Max(float64(venue.Capacity), 1) / 1000
+		raw += float64(venue.EventsPerYear) * capacity * (1 - d/radius)
+	}
+	// Округление убирает шум float, чтобы неизменившиеся значения не перезаписывались
+	return math.Round(math.Min(math.Log2(1+raw), maxEventExposure)*100) / 100
+}
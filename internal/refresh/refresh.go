@@ -0,0 +1,146 @@
+// Package refresh описывает конвейер регулярного обновления данных локаций для orchestrator:
+// доставка накопленных изменений → пересчет конкуренции, близости к мероприятиям и учебным заведениям,
+// безопасности районов → пересчет score → прогрев кеша и материализация аналитики.
+package refresh
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/orchestrator"
+	"github.com/akozadaev/go_es_analytical_system/internal/outbox"
+	"github.com/akozadaev/go_es_analytical_system/internal/service"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// PipelineName — имя конвейера обновления локаций.
+const PipelineName = "locations_refresh"
+
+// Deps содержит зависимости шагов конвейера.
+type Deps struct {
+	ESStorage         *storage.ElasticsearchStorage
+	PGStorage         *storage.PostgresStorage
+	Relay             *outbox.Relay
+	Recommendations   *service.RecommendationService
+	Analytics         *service.AnalyticsService
+	CompetitionRadius float64 // Радиус поиска конкурентов, метры
+	EventRadius       float64 // Радиус влияния площадок мероприятий, метры
+	WalkingDistance   float64 // Пешая доступность учебных заведений, метры
+	WarmQueries       int     // Количество частых запросов для прогрева кеша
+}
+
+// NewPipeline создает конвейер обновления локаций.
+func NewPipeline(deps Deps) orchestrator.Pipeline {
+	return orchestrator.Pipeline{
+		Name: PipelineName,
+		Steps: []orchestrator.Step{
+			{
+				Name:       "ingest",
+				Retries:    2,
+				RetryDelay: 10 * time.Second,
+				Run:        deps.Relay.Drain,
+			},
+			{
+				Name:       "recompute_competition",
+				DependsOn:  []string{"ingest"},
+				Retries:    1,
+				RetryDelay: 30 * time.Second,
+				Run: func(ctx context.Context) error {
+					substitutes, err := deps.PGStorage.ListBusinessTypeSubstitutes(ctx, "")
+					if err != nil {
+						return err
+					}
+					updated, err := RecomputeCompetition(ctx, deps.ESStorage, deps.PGStorage, deps.CompetitionRadius, substitutes)
+					slog.InfoContext(ctx, "Competition density updated", "locations", updated)
+					return err
+				},
+			},
+			{
+				Name:       "recompute_event_exposure",
+				DependsOn:  []string{"ingest"},
+				Retries:    1,
+				RetryDelay: 30 * time.Second,
+				Run: func(ctx context.Context) error {
+					venues, err := deps.PGStorage.ListEventVenues(ctx)
+					if err != nil {
+						return err
+					}
+					updated, err := RecomputeEventExposure(ctx, deps.ESStorage, deps.PGStorage, venues, deps.EventRadius)
+					slog.InfoContext(ctx, "Event exposure updated", "locations", updated)
+					return err
+				},
+			},
+			{
+				Name:       "recompute_education",
+				DependsOn:  []string{"ingest"},
+				Retries:    1,
+				RetryDelay: 30 * time.Second,
+				Run: func(ctx context.Context) error {
+					institutions, err := deps.PGStorage.ListEducationInstitutions(ctx)
+					if err != nil {
+						return err
+					}
+					updated, err := RecomputeEducation(ctx, deps.ESStorage, deps.PGStorage, institutions, deps.WalkingDistance)
+					slog.InfoContext(ctx, "Education proximity updated", "values", updated)
+					return err
+				},
+			},
+			{
+				Name:       "recompute_safety",
+				DependsOn:  []string{"ingest"},
+				Retries:    1,
+				RetryDelay: 30 * time.Second,
+				Run: func(ctx context.Context) error {
+					districts, err := deps.PGStorage.ListDistrictSafety(ctx)
+					if err != nil {
+						return err
+					}
+					updated, err := RecomputeSafety(ctx, deps.ESStorage, deps.PGStorage, districts)
+					slog.InfoContext(ctx, "Safety score updated", "locations", updated)
+					return err
+				},
+			},
+			// Итоговый score рассчитывается при поиске из полей документа, поэтому достаточно
+			// доставить из outbox пересчитанные поля локаций PostgreSQL и сделать поля видимыми для поиска.
+			{
+				Name:       "recompute_scores",
+				DependsOn:  []string{"recompute_competition", "recompute_event_exposure", "recompute_education", "recompute_safety"},
+				Retries:    2,
+				RetryDelay: 5 * time.Second,
+				Run: func(ctx context.Context) error {
+					if err := deps.Relay.Drain(ctx); err != nil {
+						return err
+					}
+					return deps.ESStorage.Refresh(ctx)
+				},
+			},
+			{
+				Name:       "warm_caches",
+				DependsOn:  []string{"recompute_scores"},
+				Retries:    1,
+				RetryDelay: 5 * time.Second,
+				Run: func(ctx context.Context) error {
+					requests, err := deps.PGStorage.FrequentQueries(ctx, time.Now().AddDate(0, 0, -7), deps.WarmQueries)
+					if err != nil {
+						return err
+					}
+					warmed, err := deps.Recommendations.Warm(ctx, requests)
+					slog.InfoContext(ctx, "Recommendation cache warmed", "queries", warmed)
+					return err
+				},
+			},
+			{
+				Name:       "materialize_analytics",
+				DependsOn:  []string{"recompute_scores"},
+				Retries:    1,
+				RetryDelay: 30 * time.Second,
+				Run: func(ctx context.Context) error {
+					count, err := deps.Analytics.Materialize(ctx)
+					slog.InfoContext(ctx, "Analytics materialized", "segments", count)
+					return err
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package refresh
+
+import (
+	"context"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// RecomputeSafety присваивает локациям основного индекса safety_score района с ближайшим центром
+// в том же городе. Локации городов без данных о районах не изменяются.
+// Возвращает количество обновленных локаций.
+func RecomputeSafety(ctx context.Context, esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, districts []models.DistrictSafety) (int, error) {
+	byCity := make(map[string][]models.DistrictSafety)
+	for _, d := range districts {
+		byCity[d.City] = append(byCity[d.City], d)
+	}
+
+	scores := make(map[string]float64)
+	current := make(map[string]float64)
+
+	err := esStorage.ScanLocations(ctx, &models.LocationFilter{}, 1000, func(locations []*models.Location) error {
+		for _, loc := range locations {
+			cityDistricts := byCity[loc.City]
+			if len(cityDistricts) == 0 {
+				continue
+			}
+			nearest := cityDistricts[0]
+			nearestDistance := geo.DistanceMeters(loc.Coordinates, nearest.Center)
+			for _, d := range cityDistricts[1:] {
+				if distance := geo.DistanceMeters(loc.Coordinates, d.Center); distance < nearestDistance {
+					nearest, nearestDistance = d, distance
+				}
+			}
+			scores[loc.ID] = nearest.SafetyScore
+			current[loc.ID] = loc.SafetyScore
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updateField(ctx, esStorage, pgStorage, "safety_score", scores, current)
+}
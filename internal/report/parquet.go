@@ -0,0 +1,71 @@
+package report
+
+import (
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/parquet"
+)
+
+// parquetColumns — столбцы выгрузки кандидатов в Parquet. Поля локации необязательны:
+// у удаленной локации остаются только поля кандидата.
+var parquetColumns = []parquet.Column{
+	{Name: "project_id", Type: parquet.String},
+	{Name: "location_id", Type: parquet.String},
+	{Name: "status", Type: parquet.String},
+	{Name: "comment", Type: parquet.String},
+	{Name: "added_at", Type: parquet.Timestamp},
+	{Name: "name", Type: parquet.String, Optional: true},
+	{Name: "address", Type: parquet.String, Optional: true},
+	{Name: "region", Type: parquet.String, Optional: true},
+	{Name: "city", Type: parquet.String, Optional: true},
+	{Name: "lat", Type: parquet.Double, Optional: true},
+	{Name: "lon", Type: parquet.Double, Optional: true},
+	{Name: "business_types_suitable", Type: parquet.String, List: true},
+	{Name: "traffic_score", Type: parquet.Double, Optional: true},
+	{Name: "competition_density", Type: parquet.Double, Optional: true},
+	{Name: "event_exposure", Type: parquet.Double, Optional: true},
+	{Name: "safety_score", Type: parquet.Double, Optional: true},
+	{Name: "street_parking_score", Type: parquet.Double, Optional: true},
+	{Name: "paid_lots_nearby", Type: parquet.Int64, Optional: true},
+	{Name: "schools_nearby", Type: parquet.Int64, Optional: true},
+	{Name: "universities_nearby", Type: parquet.Int64, Optional: true},
+	{Name: "age_group", Type: parquet.String, Optional: true},
+	{Name: "average_income", Type: parquet.Double, Optional: true},
+	{Name: "interests", Type: parquet.String, List: true},
+	{Name: "population_density", Type: parquet.Double, Optional: true},
+	{Name: "embedding", Type: parquet.Double, List: true},
+	{Name: "embedding_version", Type: parquet.Int64, Optional: true},
+	{Name: "created_at", Type: parquet.Timestamp, Optional: true},
+	{Name: "updated_at", Type: parquet.Timestamp, Optional: true},
+}
+
+// renderParquet формирует Parquet файл: строка на каждого кандидата, эмбеддинг — столбец-список.
+func renderParquet(summary *models.ProjectSummary) ([]byte, error) {
+	w := parquet.NewWriter(parquetColumns)
+
+	for _, candidate := range summary.Candidates {
+		row := make([]interface{}, len(parquetColumns))
+		row[0] = summary.ID
+		row[1] = candidate.LocationID
+		row[2] = string(candidate.Status)
+		row[3] = candidate.Comment
+		row[4] = candidate.CreatedAt
+		if loc := candidate.Location; loc != nil {
+			copy(row[5:], []interface{}{
+				loc.Name, loc.Address, loc.Region, loc.City,
+				loc.Coordinates.Lat, loc.Coordinates.Lon,
+				loc.BusinessTypesSuitable,
+				loc.TrafficScore, loc.CompetitionDensity, loc.EventExposure, loc.SafetyScore, loc.StreetParkingScore,
+				loc.PaidLotsNearby, loc.SchoolsNearby, loc.UniversitiesNearby,
+				loc.Demographics.AgeGroup, loc.Demographics.AverageIncome, loc.Demographics.Interests,
+				loc.Demographics.PopulationDensity,
+				loc.Embedding, loc.EmbeddingVersion,
+				loc.CreatedAt, loc.UpdatedAt,
+			})
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.Bytes()
+}
@@ -0,0 +1,321 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Размеры слайда 16:9 в EMU (English Metric Units, 914400 на дюйм).
+const (
+	slideWidth  = 12192000
+	slideHeight = 6858000
+	emuPerCm    = 360000
+)
+
+const (
+	nsA = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	nsR = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsP = "http://schemas.openxmlformats.org/presentationml/2006/main"
+
+	relSlide       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide"
+	relSlideLayout = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideLayout"
+	relSlideMaster = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideMaster"
+	relTheme       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"
+)
+
+// renderPPTX формирует презентацию: титульный слайд со сводкой проекта и слайд на каждого кандидата
+// с ключевыми метриками, диаграммой оценок и схемой расположения среди остальных кандидатов.
+func renderPPTX(summary *models.ProjectSummary) []file {
+	slides := []string{titleSlide(summary)}
+	bounds := candidateBounds(summary.Candidates)
+	for i := range summary.Candidates {
+		slides = append(slides, candidateSlide(summary, i, bounds))
+	}
+
+	var contentTypes, presRels, sldIDs strings.Builder
+	contentTypes.WriteString(xmlHeader +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/ppt/presentation.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/>` +
+		`<Override PartName="/ppt/slideMasters/slideMaster1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideMaster+xml"/>` +
+		`<Override PartName="/ppt/slideLayouts/slideLayout1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"/>` +
+		`<Override PartName="/ppt/theme/theme1.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>`)
+	presRels.WriteString(xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relSlideMaster + `" Target="slideMasters/slideMaster1.xml"/>` +
+		`<Relationship Id="rId2" Type="` + relTheme + `" Target="theme/theme1.xml"/>`)
+
+	files := []file{}
+	for i, body := range slides {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/ppt/slides/slide%d.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slide+xml"/>`, n)
+		fmt.Fprintf(&presRels, `<Relationship Id="rId%d" Type="%s" Target="slides/slide%d.xml"/>`, n+2, relSlide, n)
+		fmt.Fprintf(&sldIDs, `<p:sldId id="%d" r:id="rId%d"/>`, 255+n, n+2)
+
+		files = append(files,
+			file{fmt.Sprintf("ppt/slides/slide%d.xml", n), body},
+			file{fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", n), xmlHeader +
+				`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+				`<Relationship Id="rId1" Type="` + relSlideLayout + `" Target="../slideLayouts/slideLayout1.xml"/>` +
+				`</Relationships>`},
+		)
+	}
+	contentTypes.WriteString(`</Types>`)
+	presRels.WriteString(`</Relationships>`)
+
+	return append([]file{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xmlHeader +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/>` +
+			`</Relationships>`},
+		{"ppt/presentation.xml", xmlHeader +
+			`<p:presentation xmlns:a="` + nsA + `" xmlns:r="` + nsR + `" xmlns:p="` + nsP + `">` +
+			`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>` +
+			`<p:sldIdLst>` + sldIDs.String() + `</p:sldIdLst>` +
+			fmt.Sprintf(`<p:sldSz cx="%d" cy="%d"/><p:notesSz cx="%d" cy="%d"/>`, slideWidth, slideHeight, slideHeight, slideWidth) +
+			`</p:presentation>`},
+		{"ppt/_rels/presentation.xml.rels", presRels.String()},
+		{"ppt/slideMasters/slideMaster1.xml", xmlHeader +
+			`<p:sldMaster xmlns:a="` + nsA + `" xmlns:r="` + nsR + `" xmlns:p="` + nsP + `">` +
+			`<p:cSld><p:spTree>` + groupHeader + `</p:spTree></p:cSld>` +
+			`<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>` +
+			`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/></p:sldLayoutIdLst>` +
+			`</p:sldMaster>`},
+		{"ppt/slideMasters/_rels/slideMaster1.xml.rels", xmlHeader +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + relSlideLayout + `" Target="../slideLayouts/slideLayout1.xml"/>` +
+			`<Relationship Id="rId2" Type="` + relTheme + `" Target="../theme/theme1.xml"/>` +
+			`</Relationships>`},
+		{"ppt/slideLayouts/slideLayout1.xml", xmlHeader +
+			`<p:sldLayout xmlns:a="` + nsA + `" xmlns:r="` + nsR + `" xmlns:p="` + nsP + `" type="blank">` +
+			`<p:cSld name="Blank"><p:spTree>` + groupHeader + `</p:spTree></p:cSld>` +
+			`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>` +
+			`</p:sldLayout>`},
+		{"ppt/slideLayouts/_rels/slideLayout1.xml.rels", xmlHeader +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + relSlideMaster + `" Target="../slideMasters/slideMaster1.xml"/>` +
+			`</Relationships>`},
+		{"ppt/theme/theme1.xml", themeXML},
+	}, files...)
+}
+
+// titleSlide формирует титульный слайд со сводкой проекта.
+func titleSlide(summary *models.ProjectSummary) string {
+	s := newSlide()
+	s.text(cm(2), cm(2), cm(30), cm(3), 4000, true, summary.Name)
+
+	lines := []string{
+		"Тип бизнеса: " + summary.BusinessType,
+		"Регион: " + summary.Region,
+	}
+	if summary.Budget != nil {
+		lines = append(lines, fmt.Sprintf("Бюджет: %.0f", *summary.Budget))
+	}
+	lines = append(lines, fmt.Sprintf("Кандидатов: %d", len(summary.Candidates)))
+	for _, status := range []models.CandidateStatus{models.CandidateSelected, models.CandidateVisited, models.CandidateReviewing, models.CandidateRejected} {
+		if count := summary.StatusCounts[status]; count > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %d", statusTitle(status), count))
+		}
+	}
+	s.text(cm(2), cm(6), cm(30), cm(10), 2000, false, lines...)
+
+	return s.xml()
+}
+
+// candidateSlide формирует слайд кандидата с индексом index.
+func candidateSlide(summary *models.ProjectSummary, index int, bounds geoBounds) string {
+	candidate := summary.Candidates[index]
+	loc := candidate.Location
+
+	s := newSlide()
+	if loc == nil {
+		s.text(cm(2), cm(1), cm(30), cm(2), 3200, true, candidate.LocationID)
+		s.text(cm(2), cm(4), cm(30), cm(4), 1800, false,
+			"Статус: "+statusTitle(candidate.Status),
+			"Локация больше не доступна в каталоге")
+		return s.xml()
+	}
+
+	s.text(cm(2), cm(1), cm(30), cm(2), 3200, true, loc.Name)
+
+	lines := []string{
+		loc.Address,
+		"Статус: " + statusTitle(candidate.Status),
+		fmt.Sprintf("Средний доход: %.0f", loc.Demographics.AverageIncome),
+		fmt.Sprintf("Плотность населения: %.0f", loc.Demographics.PopulationDensity),
+		"Возрастная группа: " + loc.Demographics.AgeGroup,
+	}
+	if candidate.Comment != "" {
+		lines = append(lines, "Комментарий: "+candidate.Comment)
+	}
+	s.text(cm(2), cm(3.5), cm(14), cm(7), 1600, false, lines...)
+
+	// Диаграмма оценок по шкале 0–10
+	s.bar(cm(2), cm(11), cm(14), "Traffic score", loc.TrafficScore, 10, "4472C4")
+	s.bar(cm(2), cm(13.5), cm(14), "Competition density", loc.CompetitionDensity, 10, "ED7D31")
+
+	// Схема расположения кандидата среди остальных кандидатов проекта
+	mapX, mapY, mapSize := cm(18), cm(3.5), cm(13)
+	s.rect(mapX, mapY, mapSize, mapSize, "F2F2F2", false)
+	for i, other := range summary.Candidates {
+		if other.Location == nil || i == index {
+			continue
+		}
+		x, y := bounds.project(other.Location.Coordinates, mapX, mapY, mapSize)
+		s.dot(x, y, cm(0.4), "A5A5A5")
+	}
+	x, y := bounds.project(loc.Coordinates, mapX, mapY, mapSize)
+	s.dot(x, y, cm(0.7), "C00000")
+	s.text(mapX, mapY+mapSize+cm(0.2), mapSize, cm(1), 1000, false,
+		fmt.Sprintf("%.5f, %.5f — расположение среди кандидатов проекта", loc.Coordinates.Lat, loc.Coordinates.Lon))
+
+	return s.xml()
+}
+
+// slide накапливает фигуры слайда.
+type slide struct {
+	shapes strings.Builder
+	nextID int
+}
+
+// groupHeader — обязательные свойства корневой группы фигур.
+const groupHeader = `<p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr><p:grpSpPr/>`
+
+func newSlide() *slide {
+	return &slide{nextID: 2}
+}
+
+func (s *slide) id() int {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// text добавляет текстовый блок; каждая строка — отдельный абзац.
+func (s *slide) text(x, y, cx, cy int, size int, bold bool, lines ...string) {
+	b := "0"
+	if bold {
+		b = "1"
+	}
+	id := s.id()
+	fmt.Fprintf(&s.shapes, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Text %d"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>`, id, id)
+	fmt.Fprintf(&s.shapes, `<p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>`, x, y, cx, cy)
+	s.shapes.WriteString(`<p:txBody><a:bodyPr wrap="square"/><a:lstStyle/>`)
+	for _, line := range lines {
+		fmt.Fprintf(&s.shapes, `<a:p><a:r><a:rPr lang="ru-RU" sz="%d" b="%s"/><a:t>%s</a:t></a:r></a:p>`, size, b, esc(line))
+	}
+	s.shapes.WriteString(`</p:txBody></p:sp>`)
+}
+
+// rect добавляет залитый прямоугольник (или эллипс).
+func (s *slide) rect(x, y, cx, cy int, color string, ellipse bool) {
+	geom := "rect"
+	if ellipse {
+		geom = "ellipse"
+	}
+	id := s.id()
+	fmt.Fprintf(&s.shapes, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Shape %d"/><p:cNvSpPr/><p:nvPr/></p:nvSpPr>`, id, id)
+	fmt.Fprintf(&s.shapes, `<p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="%s"><a:avLst/></a:prstGeom>`, x, y, cx, cy, geom)
+	fmt.Fprintf(&s.shapes, `<a:solidFill><a:srgbClr val="%s"/></a:solidFill><a:ln><a:noFill/></a:ln></p:spPr></p:sp>`, color)
+}
+
+// dot добавляет точку диаметром size с центром в (x, y).
+func (s *slide) dot(x, y, size int, color string) {
+	s.rect(x-size/2, y-size/2, size, size, color, true)
+}
+
+// bar добавляет горизонтальную полосу диаграммы с подписью.
+func (s *slide) bar(x, y, width int, label string, value, max float64, color string) {
+	s.text(x, y, width, cm(0.9), 1200, false, fmt.Sprintf("%s: %.1f", label, value))
+	s.rect(x, y+cm(1), width, cm(0.8), "E7E6E6", false)
+	if value > max {
+		value = max
+	}
+	if value > 0 {
+		s.rect(x, y+cm(1), int(float64(width)*value/max), cm(0.8), color, false)
+	}
+}
+
+func (s *slide) xml() string {
+	return xmlHeader +
+		`<p:sld xmlns:a="` + nsA + `" xmlns:r="` + nsR + `" xmlns:p="` + nsP + `">` +
+		`<p:cSld><p:spTree>` + groupHeader + s.shapes.String() + `</p:spTree></p:cSld>` +
+		`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>` +
+		`</p:sld>`
+}
+
+func cm(v float64) int {
+	return int(v * emuPerCm)
+}
+
+// geoBounds — охватывающий прямоугольник координат кандидатов.
+type geoBounds struct {
+	minLat, maxLat, minLon, maxLon float64
+}
+
+func candidateBounds(candidates []models.Candidate) geoBounds {
+	b := geoBounds{minLat: 90, maxLat: -90, minLon: 180, maxLon: -180}
+	for _, candidate := range candidates {
+		if candidate.Location == nil {
+			continue
+		}
+		c := candidate.Location.Coordinates
+		b.minLat, b.maxLat = minFloat(b.minLat, c.Lat), maxFloat(b.maxLat, c.Lat)
+		b.minLon, b.maxLon = minFloat(b.minLon, c.Lon), maxFloat(b.maxLon, c.Lon)
+	}
+	return b
+}
+
+// project переводит координаты в точку внутри квадрата схемы с отступом 10%.
+// Север сверху; при единственном кандидате точка в центре.
+func (b geoBounds) project(p models.GeoPoint, x, y, size int) (int, int) {
+	span := maxFloat(b.maxLat-b.minLat, b.maxLon-b.minLon)
+	if span == 0 {
+		return x + size/2, y + size/2
+	}
+	inner := float64(size) * 0.8
+	offset := float64(size) * 0.1
+	px := offset + (p.Lon-b.minLon)/span*inner
+	py := offset + (b.maxLat-p.Lat)/span*inner
+	return x + int(px), y + int(py)
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// themeXML — минимальная тема оформления, обязательная для презентации.
+var themeXML = xmlHeader +
+	`<a:theme xmlns:a="` + nsA + `" name="Office"><a:themeElements>` +
+	`<a:clrScheme name="Office">` +
+	`<a:dk1><a:srgbClr val="000000"/></a:dk1><a:lt1><a:srgbClr val="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="44546A"/></a:dk2><a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2>` +
+	`<a:accent3><a:srgbClr val="A5A5A5"/></a:accent3><a:accent4><a:srgbClr val="FFC000"/></a:accent4>` +
+	`<a:accent5><a:srgbClr val="5B9BD5"/></a:accent5><a:accent6><a:srgbClr val="70AD47"/></a:accent6>` +
+	`<a:hlink><a:srgbClr val="0563C1"/></a:hlink><a:folHlink><a:srgbClr val="954F72"/></a:folHlink>` +
+	`</a:clrScheme>` +
+	`<a:fontScheme name="Office">` +
+	`<a:majorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>` +
+	`</a:fontScheme>` +
+	`<a:fmtScheme name="Office">` +
+	`<a:fillStyleLst>` + strings.Repeat(`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>`, 3) + `</a:fillStyleLst>` +
+	`<a:lnStyleLst>` + strings.Repeat(`<a:ln w="9525"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>`, 3) + `</a:lnStyleLst>` +
+	`<a:effectStyleLst>` + strings.Repeat(`<a:effectStyle><a:effectLst/></a:effectStyle>`, 3) + `</a:effectStyleLst>` +
+	`<a:bgFillStyleLst>` + strings.Repeat(`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>`, 3) + `</a:bgFillStyleLst>` +
+	`</a:fmtScheme>` +
+	`</a:themeElements></a:theme>`
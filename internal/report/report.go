@@ -0,0 +1,102 @@
+// Package report формирует клиентские отчеты по проектам подбора локаций
+// в форматах Office Open XML (XLSX и PPTX) и выгрузки для анализа данных (Parquet).
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Format определяет формат отчета.
+type Format string
+
+const (
+	FormatXLSX    Format = "xlsx"    // Таблица: строка на каждого кандидата
+	FormatPPTX    Format = "pptx"    // Презентация: слайд на каждого кандидата
+	FormatParquet Format = "parquet" // Столбцовый файл для pandas/Spark: строка на каждого кандидата
+)
+
+// ContentType возвращает MIME тип файла отчета.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPPTX:
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "application/octet-stream"
+}
+
+// Valid сообщает, поддерживается ли формат.
+func (f Format) Valid() bool {
+	return f == FormatXLSX || f == FormatPPTX || f == FormatParquet
+}
+
+// Render формирует отчет по проекту в указанном формате.
+func Render(format Format, summary *models.ProjectSummary) ([]byte, error) {
+	var files []file
+	switch format {
+	case FormatXLSX:
+		files = renderXLSX(summary)
+	case FormatPPTX:
+		files = renderPPTX(summary)
+	case FormatParquet:
+		return renderParquet(summary)
+	default:
+		return nil, fmt.Errorf("unsupported report format: %q", format)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+		if _, err := w.Write([]byte(f.body)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// file — часть пакета Office Open XML.
+type file struct {
+	name string
+	body string
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// esc экранирует текст для вставки в XML.
+func esc(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// statusTitle возвращает название статуса кандидата для клиентского отчета.
+func statusTitle(status models.CandidateStatus) string {
+	switch status {
+	case models.CandidateReviewing:
+		return "На рассмотрении"
+	case models.CandidateVisited:
+		return "Осмотрена"
+	case models.CandidateRejected:
+		return "Отклонена"
+	case models.CandidateSelected:
+		return "Выбрана"
+	}
+	return string(status)
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// xlsxColumns — заголовки столбцов таблицы кандидатов.
+var xlsxColumns = []string{
+	"ID", "Название", "Адрес", "Город", "Статус", "Комментарий",
+	"Traffic score", "Competition density", "Средний доход", "Плотность населения",
+	"Широта", "Долгота",
+}
+
+// renderXLSX формирует книгу с одним листом: строка на каждого кандидата.
+// Строки записываются как inline strings, поэтому таблица общих строк не нужна.
+func renderXLSX(summary *models.ProjectSummary) []file {
+	var rows strings.Builder
+
+	writeRow := func(index int, cells []interface{}) {
+		fmt.Fprintf(&rows, `<row r="%d">`, index)
+		for col, value := range cells {
+			ref := fmt.Sprintf("%s%d", columnName(col), index)
+			switch v := value.(type) {
+			case float64:
+				fmt.Fprintf(&rows, `<c r="%s"><v>%g</v></c>`, ref, v)
+			case int:
+				fmt.Fprintf(&rows, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case nil:
+			default:
+				fmt.Fprintf(&rows, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, esc(fmt.Sprint(v)))
+			}
+		}
+		rows.WriteString(`</row>`)
+	}
+
+	header := make([]interface{}, len(xlsxColumns))
+	for i, title := range xlsxColumns {
+		header[i] = title
+	}
+	writeRow(1, header)
+
+	for i, candidate := range summary.Candidates {
+		cells := []interface{}{candidate.LocationID, nil, nil, nil, statusTitle(candidate.Status), candidate.Comment}
+		if loc := candidate.Location; loc != nil {
+			cells = []interface{}{
+				loc.ID, loc.Name, loc.Address, loc.City, statusTitle(candidate.Status), candidate.Comment,
+				loc.TrafficScore, loc.CompetitionDensity, loc.Demographics.AverageIncome, loc.Demographics.PopulationDensity,
+				loc.Coordinates.Lat, loc.Coordinates.Lon,
+			}
+		}
+		writeRow(i+2, cells)
+	}
+
+	sheetName := summary.Name
+	if len([]rune(sheetName)) > 31 {
+		sheetName = string([]rune(sheetName)[:31])
+	}
+	sheetName = strings.NewReplacer("/", " ", "\\", " ", "?", " ", "*", " ", "[", " ", "]", " ", ":", " ").Replace(sheetName)
+	if strings.TrimSpace(sheetName) == "" {
+		sheetName = "Candidates"
+	}
+
+	return []file{
+		{"[Content_Types].xml", xmlHeader +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xmlHeader +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xmlHeader +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + esc(sheetName) + `" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", xmlHeader +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", xmlHeader +
+			`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<sheetData>` + rows.String() + `</sheetData>` +
+			`</worksheet>`},
+	}
+}
+
+// columnName возвращает буквенное имя столбца по индексу с нуля (0 -> A, 26 -> AA).
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,191 @@
+// Package routing предоставляет провайдеры времени в пути между точками для ранжирования локаций
+// по зоне доступности (drive-time catchment), а не по расстоянию по прямой.
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/cache"
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Unreachable — время в пути до точки, до которой нет маршрута.
+const Unreachable = -1.0
+
+// Provider рассчитывает время в пути от одной точки до нескольких.
+type Provider interface {
+	// Durations возвращает время в пути в секундах от origin до каждой точки destinations
+	// (Unreachable, если маршрута нет).
+	Durations(ctx context.Context, origin models.GeoPoint, destinations []models.GeoPoint) ([]float64, error)
+}
+
+// New создает провайдер по имени: "osrm" — внешний сервис OSRM, иначе — оценка по прямой со скоростью speedKmh.
+// Результаты кешируются на cacheTTL.
+func New(name, url string, batchSize int, speedKmh float64, cacheTTL time.Duration) Provider {
+	var provider Provider = NewStraightLineProvider(speedKmh)
+	if name == "osrm" && url != "" {
+		provider = NewOSRMProvider(url, batchSize)
+	}
+	return NewCachedProvider(provider, cacheTTL)
+}
+
+// StraightLineProvider оценивает время в пути по расстоянию по прямой и средней скорости.
+// Не требует внешних сервисов, поэтому подходит для разработки и тестовых стендов.
+type StraightLineProvider struct {
+	metersPerSecond float64
+}
+
+// NewStraightLineProvider создает новый экземпляр StraightLineProvider.
+func NewStraightLineProvider(speedKmh float64) *StraightLineProvider {
+	if speedKmh <= 0 {
+		speedKmh = 30
+	}
+	return &StraightLineProvider{metersPerSecond: speedKmh * 1000 / 3600}
+}
+
+// Durations возвращает оценку времени в пути до каждой точки.
+func (sp *StraightLineProvider) Durations(ctx context.Context, origin models.GeoPoint, destinations []models.GeoPoint) ([]float64, error) {
+	durations := make([]float64, len(destinations))
+	for i, dest := range destinations {
+		durations[i] = geo.DistanceMeters(origin, dest) / sp.metersPerSecond
+	}
+	return durations, nil
+}
+
+// OSRMProvider получает время в пути из table service OSRM.
+// Точки отправляются пачками по batchSize, чтобы не превышать ограничения сервиса на размер матрицы.
+type OSRMProvider struct {
+	url        string
+	batchSize  int
+	httpClient *http.Client
+}
+
+// NewOSRMProvider создает новый экземпляр OSRMProvider. url — базовый адрес с профилем,
+// например http://osrm:5000/table/v1/driving.
+func NewOSRMProvider(url string, batchSize int) *OSRMProvider {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &OSRMProvider{
+		url:        strings.TrimRight(url, "/"),
+		batchSize:  batchSize,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Durations запрашивает строку матрицы времени в пути от origin пачками точек.
+func (op *OSRMProvider) Durations(ctx context.Context, origin models.GeoPoint, destinations []models.GeoPoint) ([]float64, error) {
+	durations := make([]float64, 0, len(destinations))
+	for start := 0; start < len(destinations); start += op.batchSize {
+		end := start + op.batchSize
+		if end > len(destinations) {
+			end = len(destinations)
+		}
+		batch, err := op.table(ctx, origin, destinations[start:end])
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, batch...)
+	}
+	return durations, nil
+}
+
+// table выполняет один запрос к table service: источник — первая координата, назначения — остальные.
+func (op *OSRMProvider) table(ctx context.Context, origin models.GeoPoint, destinations []models.GeoPoint) ([]float64, error) {
+	coords := make([]string, 0, len(destinations)+1)
+	indexes := make([]string, 0, len(destinations))
+	coords = append(coords, fmt.Sprintf("%f,%f", origin.Lon, origin.Lat))
+	for i, dest := range destinations {
+		coords = append(coords, fmt.Sprintf("%f,%f", dest.Lon, dest.Lat))
+		indexes = append(indexes, fmt.Sprint(i+1))
+	}
+
+	url := fmt.Sprintf("%s/%s?sources=0&destinations=%s&annotations=duration",
+		op.url, strings.Join(coords, ";"), strings.Join(indexes, ";"))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := op.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request travel times: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("error requesting travel times: status %d, body: %s", res.StatusCode, string(body))
+	}
+
+	var result struct {
+		Code      string       `json:"code"`
+		Durations [][]*float64 `json:"durations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Code != "Ok" || len(result.Durations) != 1 || len(result.Durations[0]) != len(destinations) {
+		return nil, fmt.Errorf("unexpected routing response: code %s", result.Code)
+	}
+
+	durations := make([]float64, len(destinations))
+	for i, d := range result.Durations[0] {
+		durations[i] = Unreachable
+		if d != nil {
+			durations[i] = *d
+		}
+	}
+	return durations, nil
+}
+
+// CachedProvider кеширует время в пути по паре точек и запрашивает у провайдера только недостающие.
+type CachedProvider struct {
+	provider Provider
+	cache    *cache.Cache[float64]
+}
+
+// NewCachedProvider создает новый экземпляр CachedProvider.
+func NewCachedProvider(provider Provider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{provider: provider, cache: cache.New[float64](ttl)}
+}
+
+// Durations возвращает время в пути из кеша, запрашивая отсутствующие значения одним вызовом провайдера.
+func (cp *CachedProvider) Durations(ctx context.Context, origin models.GeoPoint, destinations []models.GeoPoint) ([]float64, error) {
+	durations := make([]float64, len(destinations))
+	var missing []models.GeoPoint
+	var missingIdx []int
+	for i, dest := range destinations {
+		if d, ok := cp.cache.Get(pairKey(origin, dest)); ok {
+			durations[i] = d
+			continue
+		}
+		missing = append(missing, dest)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return durations, nil
+	}
+
+	fetched, err := cp.provider.Durations(ctx, origin, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, d := range fetched {
+		durations[missingIdx[j]] = d
+		cp.cache.Set(pairKey(origin, missing[j]), d)
+	}
+	return durations, nil
+}
+
+// pairKey строит ключ кеша по паре точек, округленных до ~10 м.
+func pairKey(origin, dest models.GeoPoint) string {
+	return fmt.Sprintf("%.4f,%.4f;%.4f,%.4f", origin.Lat, origin.Lon, dest.Lat, dest.Lon)
+}
@@ -0,0 +1,187 @@
+// Package selfcheck выполняет самопроверку сервиса при запуске (конфигурация, доступность
+// хранилищ, версии схемы и маппинга, загрузка моделей) и хранит последний отчет для быстрой
+// проверки после развертывания.
+package selfcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Статусы проверок и отчета.
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning" // Сервис работает, но требует внимания
+	StatusFailed  = "failed"
+)
+
+// Func выполняет проверку и возвращает ее краткий результат. Ошибка, созданная Warn,
+// помечает проверку предупреждением, остальные ошибки — сбоем.
+type Func func(ctx context.Context) (string, error)
+
+// warning — результат проверки, требующий внимания.
+type warning struct {
+	message string
+}
+
+func (w *warning) Error() string { return w.message }
+
+// Warn создает ошибку-предупреждение для Func.
+func Warn(format string, args ...interface{}) error {
+	return &warning{message: fmt.Sprintf(format, args...)}
+}
+
+// Check — результат одной проверки.
+type Check struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report — отчет самопроверки. Status — худший из статусов проверок.
+type Report struct {
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Checks     []Check   `json:"checks"`
+}
+
+type namedCheck struct {
+	name string
+	fn   Func
+}
+
+// Checker выполняет зарегистрированные проверки и хранит последний отчет.
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+
+	mu   sync.RWMutex
+	last *Report
+
+	// Кеш Cached: повторные проверки в пределах cacheTTL получают тот же отчет
+	cacheTTL time.Duration
+	cacheMu  sync.Mutex
+	cached   *Report
+	cachedAt time.Time
+}
+
+// New создает Checker; timeout ограничивает время каждой проверки.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку. Проверки выполняются в порядке регистрации.
+func (c *Checker) Add(name string, fn Func) {
+	c.checks = append(c.checks, namedCheck{name: name, fn: fn})
+}
+
+// Run выполняет проверки, логирует результат каждой и сохраняет отчет как последний.
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := c.Check(ctx)
+	for _, result := range report.Checks {
+		slog.Log(ctx, statusLevel(result.Status), "Self-check "+result.Name, "status", result.Status,
+			"duration_ms", result.DurationMs, "message", result.Message)
+	}
+	slog.Log(ctx, statusLevel(report.Status), "Self-check completed", "status", report.Status,
+		"checks", len(report.Checks), "duration_ms", report.DurationMs)
+
+	c.mu.Lock()
+	c.last = report
+	c.mu.Unlock()
+
+	return report
+}
+
+// statusLevel возвращает уровень записи журнала для статуса проверки.
+func statusLevel(status string) slog.Level {
+	switch status {
+	case StatusFailed:
+		return slog.LevelError
+	case StatusWarning:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// Check выполняет проверки и возвращает отчет, не логируя его и не сохраняя как последний.
+// Подходит для частых проверок, например проверки здоровья по запросу мониторинга.
+func (c *Checker) Check(ctx context.Context) *Report {
+	report := &Report{
+		Status:    StatusOK,
+		StartedAt: time.Now(),
+		Checks:    make([]Check, 0, len(c.checks)),
+	}
+	for _, check := range c.checks {
+		result := c.run(ctx, check)
+		report.Checks = append(report.Checks, result)
+		if severity(result.Status) > severity(report.Status) {
+			report.Status = result.Status
+		}
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	return report
+}
+
+// SetCacheTTL задает время, в течение которого Cached возвращает прежний отчет.
+func (c *Checker) SetCacheTTL(ttl time.Duration) {
+	c.cacheTTL = ttl
+}
+
+// Cached возвращает отчет, полученный не раньше cacheTTL назад, или выполняет проверки заново;
+// fresh сообщает, что проверки выполнены этим вызовом. Одновременные вызовы ждут одну проверку,
+// поэтому частый опрос не умножает запросы к зависимостям. Отмена запроса, выполняющего
+// проверку, не прерывает ее: отчет получат и остальные ожидающие.
+func (c *Checker) Cached(ctx context.Context) (report *Report, fresh bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.cached != nil && time.Since(c.cachedAt) < c.cacheTTL {
+		return c.cached, false
+	}
+	report = c.Check(context.WithoutCancel(ctx))
+	c.cached, c.cachedAt = report, time.Now()
+	return report, true
+}
+
+// Last возвращает последний отчет или nil, если самопроверка еще не выполнялась.
+func (c *Checker) Last() *Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.last
+}
+
+// run выполняет одну проверку с таймаутом.
+func (c *Checker) run(ctx context.Context, check namedCheck) Check {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	message, err := check.fn(ctx)
+	result := Check{Name: check.name, Status: StatusOK, Message: message, DurationMs: time.Since(start).Milliseconds()}
+
+	var warn *warning
+	switch {
+	case errors.As(err, &warn):
+		result.Status, result.Message = StatusWarning, err.Error()
+	case err != nil:
+		result.Status, result.Message = StatusFailed, err.Error()
+	}
+	return result
+}
+
+func severity(status string) int {
+	switch status {
+	case StatusWarning:
+		return 1
+	case StatusFailed:
+		return 2
+	default:
+		return 0
+	}
+}
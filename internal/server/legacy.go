@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// registerLegacy регистрирует маршруты версии 1 по прежним путям без префикса — для клиентов,
+// еще не перешедших на /api/v1. Обработчики те же, что у версии 1; ответы помечаются
+// как устаревшие заголовком Deprecation и ссылкой Link на путь версии 1.
+func registerLegacy(router *mux.Router, h Handlers) {
+	legacy := router.NewRoute().Subrouter()
+	legacy.Use(deprecated)
+	registerV1(legacy, h)
+}
+
+// deprecated добавляет к ответу заголовки Deprecation и Link с rel="successor-version".
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+models.APIV1Prefix+r.URL.EscapedPath()+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
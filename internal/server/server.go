@@ -0,0 +1,67 @@
+// Package server настраивает маршруты HTTP API. Маршруты API регистрируются по версиям под
+// префиксами /api/v<N>: новая версия (например, с другим контрактом ранжирования) добавляется
+// отдельной функцией регистрации рядом с registerV1, не меняя маршрутов существующих клиентов.
+// Служебные маршруты (проверка здоровья, версия, метрики, Swagger, журнал изменений API)
+// не версионируются.
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/config"
+	"github.com/akozadaev/go_es_analytical_system/internal/handlers"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/signedurl"
+	"github.com/gorilla/mux"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// Handlers группирует handlers, маршруты которых регистрирует NewRouter.
+type Handlers struct {
+	API       *handlers.Handlers
+	Admin     *handlers.AdminHandlers
+	Snapshots *handlers.SnapshotHandlers
+	Notes     *handlers.NoteHandlers
+	Projects  *handlers.ProjectHandlers
+	Exports   *handlers.ExportHandlers
+	Saved     *handlers.SavedSearchHandlers
+	Async     *handlers.AsyncIndexHandlers
+	Rankings  *handlers.RankingOverrideHandlers
+	Signer    *signedurl.Signer
+	Predict   *handlers.PredictionHandlers
+	Analytics *handlers.AnalyticsHandlers
+	Metrics   http.Handler // Метрики Prometheus (nil — отключены)
+}
+
+// NewRouter настраивает маршруты HTTP API: служебные маршруты, маршруты версии 1 под
+// префиксом /api/v1 и, если включены API_LEGACY_ROUTES, те же маршруты по прежним путям без префикса.
+func NewRouter(cfg *config.Config, h Handlers) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/health", h.API.HealthCheck).Methods("GET")
+	router.HandleFunc("/healthz", h.API.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", h.API.Readiness).Methods("GET")
+	router.HandleFunc("/version", h.API.Version).Methods("GET")
+	if h.Metrics != nil {
+		router.Handle("/metrics", h.Metrics).Methods("GET")
+	}
+	// Журнал изменений описывает все версии API
+	router.HandleFunc("/api/changes", h.API.APIChanges).Methods("GET")
+
+	registerV1(router.PathPrefix(models.APIV1Prefix).Subrouter(), h)
+
+	// Swagger UI
+	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
+		httpSwagger.URL(fmt.Sprintf("http://localhost:%s/swagger/doc.json", cfg.AppPort)),
+		httpSwagger.DeepLinking(true),
+		httpSwagger.DocExpansion("none"),
+		httpSwagger.DomID("swagger-ui"),
+	))
+
+	// Прежние пути регистрируются последними: пути версий и служебные маршруты имеют приоритет
+	if cfg.APILegacyRoutes {
+		registerLegacy(router, h)
+	}
+
+	return router
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// registerV1 регистрирует маршруты версии 1 API на r; пути указываются без префикса версии.
+func registerV1(r *mux.Router, h Handlers) {
+	r.HandleFunc("/locations", h.API.CreateLocation).Methods("POST")
+	r.HandleFunc("/locations/recommend", h.API.RecommendLocations).Methods("POST")
+	r.HandleFunc("/locations/recommend", h.API.RecommendLocationsQuery).Methods("GET")
+	r.HandleFunc("/locations/portfolio", h.API.PortfolioLocations).Methods("POST")
+	r.HandleFunc("/predict/footfall", h.Predict.PredictFootfall).Methods("POST")
+	r.HandleFunc("/locations/_mget", h.API.GetLocations).Methods("POST")
+	r.HandleFunc("/locations/validate-refs", h.API.ValidateLocationRefs).Methods("POST")
+	r.HandleFunc("/locations/changes", h.API.ListLocationChanges).Methods("GET")
+	r.HandleFunc("/locations/async", h.Async.SubmitLocations).Methods("POST")
+	r.HandleFunc("/locations/async", h.Async.ListAsyncIndexItems).Methods("GET")
+	r.HandleFunc("/locations/async/{tracking_id}", h.Async.GetAsyncIndexItem).Methods("GET")
+	r.HandleFunc("/locations/{id}", h.API.GetLocation).Methods("GET")
+	r.HandleFunc("/locations/{id}", h.API.LocationExists).Methods("HEAD")
+	r.HandleFunc("/locations/{id}", h.API.UpdateLocation).Methods("PUT")
+	r.HandleFunc("/locations/{id}", h.API.DeleteLocation).Methods("DELETE")
+	r.HandleFunc("/locations/{id}/status", h.API.TransitionLocationStatus).Methods("POST")
+	r.HandleFunc("/locations/{id}/status-history", h.API.ListLocationStatusHistory).Methods("GET")
+	r.HandleFunc("/locations/{id}/notes", h.Notes.ListNotes).Methods("GET")
+	r.HandleFunc("/locations/{id}/notes", h.Notes.CreateNote).Methods("POST")
+	r.HandleFunc("/locations/{id}/notes/{note_id}", h.Notes.UpdateNote).Methods("PUT")
+	r.HandleFunc("/locations/{id}/notes/{note_id}", h.Notes.DeleteNote).Methods("DELETE")
+	r.HandleFunc("/business-types", h.API.GetBusinessTypes).Methods("GET")
+	r.HandleFunc("/regions", h.API.GetRegions).Methods("GET")
+	r.HandleFunc("/regions/{id}/stats", h.Analytics.GetRegionStats).Methods("GET")
+	r.HandleFunc("/cities", h.API.GetCities).Methods("GET")
+	r.HandleFunc("/cities/lookup", h.API.LookupCities).Methods("GET")
+	r.HandleFunc("/cities/{id}", h.API.GetCity).Methods("GET")
+	r.HandleFunc("/analytics/segments", h.Analytics.GetSegments).Methods("GET")
+	r.HandleFunc("/export/flat", h.API.GetFlatView).Methods("GET")
+	r.HandleFunc("/export/sample", h.API.GetSample).Methods("GET")
+	r.HandleFunc("/saved-searches", h.Saved.ListSavedSearches).Methods("GET")
+	r.HandleFunc("/saved-searches", h.Saved.CreateSavedSearch).Methods("POST")
+	r.HandleFunc("/saved-searches/{id}", h.Saved.DeleteSavedSearch).Methods("DELETE")
+	r.HandleFunc("/ranking-overrides", h.Rankings.GetRankingOverride).Methods("GET")
+	r.HandleFunc("/ranking-overrides", h.Rankings.PutRankingOverride).Methods("PUT")
+	r.HandleFunc("/ranking-overrides", h.Rankings.DeleteRankingOverride).Methods("DELETE")
+	r.HandleFunc("/projects", h.Projects.ListProjects).Methods("GET")
+	r.HandleFunc("/projects", h.Projects.CreateProject).Methods("POST")
+	r.HandleFunc("/projects/{id}", h.Projects.GetProject).Methods("GET")
+	r.HandleFunc("/projects/{id}", h.Projects.UpdateProject).Methods("PUT")
+	r.HandleFunc("/projects/{id}", h.Projects.DeleteProject).Methods("DELETE")
+	r.HandleFunc("/projects/{id}/export", h.Exports.ExportProject).Methods("POST")
+	r.HandleFunc("/jobs/{id}", h.Exports.GetJob).Methods("GET")
+	r.HandleFunc("/artifacts/{id}", h.Exports.DownloadArtifact).Methods("GET")
+	r.HandleFunc("/artifacts/{id}/sign", h.Exports.SignArtifact).Methods("POST")
+	r.Handle("/downloads/artifacts/{id}", h.Signer.Middleware(http.HandlerFunc(h.Exports.DownloadSignedArtifact))).Methods("GET")
+	r.HandleFunc("/projects/{id}/candidates", h.Projects.AddCandidate).Methods("POST")
+	r.HandleFunc("/projects/{id}/candidates/{location_id}", h.Projects.UpdateCandidate).Methods("PUT")
+	r.HandleFunc("/projects/{id}/candidates/{location_id}", h.Projects.RemoveCandidate).Methods("DELETE")
+	r.HandleFunc("/recommendations/{query_id}/share", h.Snapshots.ShareRecommendation).Methods("POST")
+	r.HandleFunc("/shared/{token}", h.Snapshots.GetSharedRecommendation).Methods("GET")
+	r.HandleFunc("/admin/embeddings/rebuild", h.Admin.RebuildEmbeddings).Methods("POST")
+	r.HandleFunc("/admin/jobs/{id}", h.Admin.GetJob).Methods("GET")
+	r.HandleFunc("/admin/mapping", h.Admin.GetMapping).Methods("GET")
+	r.HandleFunc("/admin/index/settings", h.Admin.GetIndexSettings).Methods("GET")
+	r.HandleFunc("/admin/index/settings", h.Admin.UpdateIndexSettings).Methods("PUT")
+	r.HandleFunc("/admin/metrics", h.Admin.GetMetrics).Methods("GET")
+	r.HandleFunc("/admin/usage", h.Admin.GetQueryCosts).Methods("GET")
+	r.HandleFunc("/admin/api-keys", h.Admin.ListAPIKeys).Methods("GET")
+	r.HandleFunc("/admin/api-keys", h.Admin.CreateAPIKey).Methods("POST")
+	r.HandleFunc("/admin/api-keys/{id}", h.Admin.UpdateAPIKey).Methods("PUT")
+	r.HandleFunc("/admin/api-keys/{id}", h.Admin.RevokeAPIKey).Methods("DELETE")
+	r.HandleFunc("/admin/reconcile", h.Admin.Reconcile).Methods("POST")
+	r.HandleFunc("/admin/archive", h.Admin.Archive).Methods("POST")
+	r.HandleFunc("/admin/models", h.Admin.ListModels).Methods("GET")
+	r.HandleFunc("/admin/models", h.Admin.RegisterModel).Methods("POST")
+	r.HandleFunc("/admin/models/{kind}/{version}/activate", h.Admin.ActivateModel).Methods("POST")
+	r.HandleFunc("/admin/search-templates", h.Admin.ListSearchTemplates).Methods("GET")
+	r.HandleFunc("/admin/search-templates", h.Admin.CreateSearchTemplate).Methods("POST")
+	r.HandleFunc("/admin/search-templates/active", h.Admin.DeactivateSearchTemplate).Methods("DELETE")
+	r.HandleFunc("/admin/search-templates/{version:[0-9]+}", h.Admin.GetSearchTemplate).Methods("GET")
+	r.HandleFunc("/admin/search-templates/{version:[0-9]+}/render", h.Admin.RenderSearchTemplate).Methods("POST")
+	r.HandleFunc("/admin/search-templates/{version:[0-9]+}/activate", h.Admin.ActivateSearchTemplate).Methods("POST")
+	r.HandleFunc("/admin/runtime-fields", h.Admin.ListRuntimeFields).Methods("GET")
+	r.HandleFunc("/admin/runtime-fields/{name}", h.Admin.GetRuntimeField).Methods("GET")
+	r.HandleFunc("/admin/runtime-fields/{name}", h.Admin.PutRuntimeField).Methods("PUT")
+	r.HandleFunc("/admin/runtime-fields/{name}", h.Admin.DeleteRuntimeField).Methods("DELETE")
+	r.HandleFunc("/admin/golden-queries", h.Admin.ListGoldenQueries).Methods("GET")
+	r.HandleFunc("/admin/golden-queries/run", h.Admin.RunGoldenQueries).Methods("POST")
+	r.HandleFunc("/admin/golden-queries/{id}", h.Admin.SaveGoldenQuery).Methods("PUT")
+	r.HandleFunc("/admin/golden-queries/{id}", h.Admin.DeleteGoldenQuery).Methods("DELETE")
+	r.HandleFunc("/admin/business-type-substitutes", h.Admin.ListSubstitutes).Methods("GET")
+	r.HandleFunc("/admin/business-type-substitutes/{business_type}/{substitute}", h.Admin.SaveSubstitute).Methods("PUT")
+	r.HandleFunc("/admin/business-type-substitutes/{business_type}/{substitute}", h.Admin.DeleteSubstitute).Methods("DELETE")
+	r.HandleFunc("/admin/cities", h.Admin.CreateCity).Methods("POST")
+	r.HandleFunc("/admin/cities/{id}", h.Admin.UpdateCity).Methods("PUT")
+	r.HandleFunc("/admin/cities/{id}", h.Admin.DeleteCity).Methods("DELETE")
+	r.HandleFunc("/admin/validation-profiles", h.Admin.ListValidationProfiles).Methods("GET")
+	r.HandleFunc("/admin/validation-profiles/{business_type}", h.Admin.GetValidationProfile).Methods("GET")
+	r.HandleFunc("/admin/validation-profiles/{business_type}", h.Admin.PutValidationProfile).Methods("PUT")
+	r.HandleFunc("/admin/validation-profiles/{business_type}", h.Admin.DeleteValidationProfile).Methods("DELETE")
+	r.HandleFunc("/admin/ranking-profiles", h.Admin.ListRankingProfiles).Methods("GET")
+	r.HandleFunc("/admin/ranking-profiles/{business_type}", h.Admin.PutRankingProfile).Methods("PUT")
+	r.HandleFunc("/admin/reference-data", h.Admin.ExportReferenceData).Methods("GET")
+	r.HandleFunc("/admin/reference-data", h.Admin.ImportReferenceData).Methods("POST")
+	r.HandleFunc("/admin/selfcheck", h.Admin.GetSelfCheck).Methods("GET")
+	r.HandleFunc("/admin/locks", h.Admin.GetLocks).Methods("GET")
+	r.HandleFunc("/admin/cluster", h.Admin.GetCluster).Methods("GET")
+	r.HandleFunc("/admin/pipelines", h.Admin.ListPipelines).Methods("GET")
+	r.HandleFunc("/admin/pipelines/runs/{id}", h.Admin.GetPipelineRun).Methods("GET")
+	r.HandleFunc("/admin/pipelines/{name}/runs", h.Admin.ListPipelineRuns).Methods("GET")
+	r.HandleFunc("/admin/pipelines/{name}/runs", h.Admin.RunPipeline).Methods("POST")
+}
@@ -0,0 +1,305 @@
+// Package serverless запускает HTTP handler в AWS Lambda: реализует клиент Lambda Runtime API
+// (custom runtime, provided.al2) и преобразование событий API Gateway (REST API, payload 1.0;
+// HTTP API и Function URL, payload 2.0) в HTTP запросы и обратно.
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// runtimeAPIVersion — версия Lambda Runtime API.
+const runtimeAPIVersion = "2018-06-01"
+
+// Handler создается при первом вызове функции и переиспользуется последующими вызовами того же
+// экземпляра среды выполнения, поэтому соединения с хранилищами открываются один раз.
+type Handler func() (http.Handler, error)
+
+// Run обрабатывает вызовы функции, пока не отменен ctx. Адрес Runtime API берется
+// из переменной окружения AWS_LAMBDA_RUNTIME_API, которую задает среда выполнения Lambda.
+func Run(ctx context.Context, newHandler Handler) error {
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		return fmt.Errorf("AWS_LAMBDA_RUNTIME_API is not set: not running in AWS Lambda")
+	}
+	rt := &runtime{
+		baseURL: "http://" + api + "/" + runtimeAPIVersion + "/runtime",
+		// Ожидание следующего вызова не ограничено: среда выполнения замораживает процесс между вызовами
+		httpClient: &http.Client{},
+	}
+
+	var handler http.Handler
+	for ctx.Err() == nil {
+		inv, err := rt.next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		// Ленивая инициализация: при ошибке вызов завершается ошибкой, а инициализация
+		// повторяется при следующем вызове
+		if handler == nil {
+			if handler, err = newHandler(); err != nil {
+				slog.ErrorContext(ctx, "Error initializing handler", logging.Err(err))
+				rt.fail(ctx, inv.id, err)
+				continue
+			}
+		}
+
+		response, err := serve(inv, handler)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error handling invocation", "invocation_id", inv.id, logging.Err(err))
+			rt.fail(ctx, inv.id, err)
+			continue
+		}
+		if err := rt.respond(ctx, inv.id, response); err != nil {
+			slog.ErrorContext(ctx, "Error sending invocation response", "invocation_id", inv.id, logging.Err(err))
+		}
+	}
+	return ctx.Err()
+}
+
+// invocation — вызов функции, полученный от Runtime API.
+type invocation struct {
+	id       string
+	deadline time.Time
+	payload  []byte
+}
+
+// runtime — клиент Lambda Runtime API.
+type runtime struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// next ожидает следующий вызов функции.
+func (rt *runtime) next(ctx context.Context) (*invocation, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rt.baseURL+"/invocation/next", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	res, err := rt.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next invocation: %w", err)
+	}
+	defer res.Body.Close()
+
+	payload, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invocation: %w", err)
+	}
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("error getting next invocation: status %d, body: %s", res.StatusCode, string(payload))
+	}
+
+	inv := &invocation{id: res.Header.Get("Lambda-Runtime-Aws-Request-Id"), payload: payload}
+	if ms, err := strconv.ParseInt(res.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		inv.deadline = time.UnixMilli(ms)
+	}
+	return inv, nil
+}
+
+// respond отправляет результат вызова.
+func (rt *runtime) respond(ctx context.Context, id string, response interface{}) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return rt.post(ctx, "/invocation/"+id+"/response", body)
+}
+
+// fail сообщает об ошибке вызова.
+func (rt *runtime) fail(ctx context.Context, id string, cause error) {
+	body, _ := json.Marshal(map[string]string{
+		"errorMessage": cause.Error(),
+		"errorType":    fmt.Sprintf("%T", cause),
+	})
+	if err := rt.post(ctx, "/invocation/"+id+"/error", body); err != nil {
+		slog.ErrorContext(ctx, "Error reporting invocation error", "invocation_id", id, logging.Err(err))
+	}
+}
+
+func (rt *runtime) post(ctx context.Context, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", rt.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := rt.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to runtime API: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("error posting to runtime API: status %d, body: %s", res.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// proxyEvent — событие API Gateway или Function URL. Поля payload 1.0 и 2.0 объединены:
+// версия определяется полем version.
+type proxyEvent struct {
+	Version string `json:"version"`
+
+	// Payload 1.0 (REST API)
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+
+	// Payload 2.0 (HTTP API, Function URL)
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+	RequestContext struct {
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+	} `json:"requestContext"`
+
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// proxyResponse — ответ для API Gateway или Function URL.
+type proxyResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// serve преобразует событие в HTTP запрос, выполняет его handler и преобразует ответ.
+func serve(inv *invocation, handler http.Handler) (*proxyResponse, error) {
+	var event proxyEvent
+	if err := json.Unmarshal(inv.payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode event: %w", err)
+	}
+	v2 := event.Version == "2.0"
+
+	req, err := event.request(v2)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if !inv.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, inv.deadline)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return newProxyResponse(rec.Result(), v2)
+}
+
+// request создает HTTP запрос по событию.
+func (e *proxyEvent) request(v2 bool) (*http.Request, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(e.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode body: %w", err)
+		}
+		body = decoded
+	}
+
+	method, path, query, sourceIP := e.HTTPMethod, e.Path, "", e.RequestContext.Identity.SourceIP
+	if v2 {
+		method, path, query, sourceIP = e.RequestContext.HTTP.Method, e.RawPath, e.RawQueryString, e.RequestContext.HTTP.SourceIP
+	} else if len(e.MultiValueQueryStringParameters) > 0 {
+		query = url.Values(e.MultiValueQueryStringParameters).Encode()
+	}
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range e.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, values := range e.MultiValueHeaders {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if len(e.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(e.Cookies, "; "))
+	}
+	req.Host = req.Header.Get("Host")
+	req.RemoteAddr = sourceIP + ":0"
+	req.ContentLength = int64(len(body))
+	req.RequestURI = target
+
+	return req, nil
+}
+
+// newProxyResponse преобразует HTTP ответ. Тело кодируется в base64, если оно не текстовое
+// или сжато middleware compression.
+func newProxyResponse(res *http.Response, v2 bool) (*proxyResponse, error) {
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	response := &proxyResponse{StatusCode: res.StatusCode}
+	if isText(res.Header) {
+		response.Body = string(body)
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.IsBase64Encoded = true
+	}
+
+	if v2 {
+		response.Cookies = res.Header.Values("Set-Cookie")
+		res.Header.Del("Set-Cookie")
+		response.Headers = make(map[string]string, len(res.Header))
+		for name, values := range res.Header {
+			response.Headers[name] = strings.Join(values, ",")
+		}
+	} else {
+		response.MultiValueHeaders = res.Header
+	}
+	return response, nil
+}
+
+func isText(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	return contentType == "" || strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") || strings.Contains(contentType, "xml")
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// AnalyticsService отдает агрегаты локаций по сегментам (регион и тип бизнеса).
+// Агрегаты рассчитываются в Elasticsearch и периодически материализуются в PostgreSQL;
+// если Elasticsearch не ответил за liveTimeout или вернул ошибку, ответ строится по
+// материализованным данным.
+type AnalyticsService struct {
+	esStorage   *storage.ElasticsearchStorage
+	pgStorage   *storage.PostgresStorage
+	liveTimeout time.Duration
+	locks       *lock.Manager     // Блокировка материализации (nil — без блокировки)
+	references  *ReferenceService // Справочник регионов для сводки по региону
+}
+
+// AnalyticsLockName — имя блокировки материализации аналитики.
+const AnalyticsLockName = "analytics"
+
+// NewAnalyticsService создает новый экземпляр AnalyticsService.
+// liveTimeout ограничивает расчет агрегатов в Elasticsearch при запросе.
+func NewAnalyticsService(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, liveTimeout time.Duration) *AnalyticsService {
+	return &AnalyticsService{
+		esStorage:   esStorage,
+		pgStorage:   pgStorage,
+		liveTimeout: liveTimeout,
+	}
+}
+
+// SetLocks задает менеджер блокировок, исключающий одновременную материализацию на нескольких экземплярах.
+func (s *AnalyticsService) SetLocks(locks *lock.Manager) {
+	s.locks = locks
+}
+
+// SetReferences задает справочники, по которым строится иерархия региона в RegionStats.
+func (s *AnalyticsService) SetReferences(references *ReferenceService) {
+	s.references = references
+}
+
+// Materialize рассчитывает агрегаты всех сегментов в Elasticsearch и заменяет ими
+// материализованные данные в PostgreSQL. Возвращает количество сегментов или lock.ErrHeld,
+// если материализация уже выполняется другим экземпляром.
+func (s *AnalyticsService) Materialize(ctx context.Context) (int, error) {
+	var count int
+	err := s.locks.Do(ctx, AnalyticsLockName, func(ctx context.Context) error {
+		var err error
+		count, err = s.materialize(ctx)
+		return err
+	})
+	return count, err
+}
+
+func (s *AnalyticsService) materialize(ctx context.Context) (int, error) {
+	segments, err := s.esStorage.AggregateSegments(ctx, "", "")
+	if err != nil {
+		return 0, err
+	}
+	if err := s.pgStorage.ReplaceAnalyticsSegments(ctx, segments, time.Now()); err != nil {
+		return 0, err
+	}
+	return len(segments), nil
+}
+
+// Runner возвращает фоновый процесс, материализующий агрегаты с интервалом interval.
+func (s *AnalyticsService) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				count, err := s.Materialize(ctx)
+				if errors.Is(err, lock.ErrHeld) {
+					continue
+				}
+				if err != nil {
+					slog.ErrorContext(ctx, "Error materializing analytics", logging.Err(err))
+					continue
+				}
+				slog.InfoContext(ctx, "Analytics materialized", "segments", count)
+			}
+		}
+	}
+}
+
+// Segments возвращает агрегаты сегментов с фильтром по региону и типу бизнеса (пустые — все).
+// source задает источник: пусто — Elasticsearch с переходом на PostgreSQL при ошибке или
+// превышении времени ожидания, "elasticsearch" или "postgres" — только указанный источник.
+func (s *AnalyticsService) Segments(ctx context.Context, region, businessType string, source models.AnalyticsSource) (*models.SegmentAnalyticsResponse, error) {
+	switch source {
+	case "", models.AnalyticsLive, models.AnalyticsMaterialized:
+	default:
+		return nil, newValidationError("source must be %s or %s", models.AnalyticsLive, models.AnalyticsMaterialized)
+	}
+
+	if source != models.AnalyticsMaterialized {
+		liveCtx, cancel := context.WithTimeout(ctx, s.liveTimeout)
+		segments, err := s.esStorage.AggregateSegments(liveCtx, region, businessType)
+		cancel()
+		if err == nil {
+			return &models.SegmentAnalyticsResponse{Source: models.AnalyticsLive, Segments: segments}, nil
+		}
+		if source == models.AnalyticsLive || ctx.Err() != nil {
+			return nil, err
+		}
+		slog.WarnContext(ctx, "Serving materialized analytics, Elasticsearch aggregation failed", logging.Err(err))
+	}
+
+	segments, refreshedAt, err := s.pgStorage.ListAnalyticsSegments(ctx, region, businessType)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.SegmentAnalyticsResponse{Source: models.AnalyticsMaterialized, Segments: segments}
+	if !refreshedAt.IsZero() {
+		response.RefreshedAt = &refreshedAt
+	}
+	return response, nil
+}
+
+// RegionStats возвращает сводку по региону id: его положение в иерархии справочника и агрегаты
+// локаций региона и всех подрегионов, рассчитанные в Elasticsearch за liveTimeout. Локации
+// ссылаются на регион по имени, поэтому в агрегаты попадают и одноименные регионы других ветвей иерархии.
+func (s *AnalyticsService) RegionStats(ctx context.Context, id int) (*models.RegionStats, error) {
+	regions, err := s.references.Regions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]models.Region, len(regions))
+	children := make(map[int][]models.Region)
+	for _, region := range regions {
+		byID[region.ID] = region
+		if region.ParentRegionID != nil {
+			children[*region.ParentRegionID] = append(children[*region.ParentRegionID], region)
+		}
+	}
+	region, ok := byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	stats := &models.RegionStats{
+		Region:    region,
+		Path:      models.RegionPaths(regions)[id],
+		Ancestors: []models.Region{},
+		Children:  children[id],
+	}
+	if stats.Children == nil {
+		stats.Children = []models.Region{}
+	}
+	// Иерархия в PostgreSQL не содержит циклов, но ограничение защищает от зацикливания при ошибке в данных
+	seen := map[int]bool{id: true}
+	for parentID := region.ParentRegionID; parentID != nil && !seen[*parentID]; {
+		parent, ok := byID[*parentID]
+		if !ok {
+			break
+		}
+		seen[parent.ID] = true
+		stats.Ancestors = append([]models.Region{parent}, stats.Ancestors...)
+		parentID = parent.ParentRegionID
+	}
+
+	names := map[string]bool{}
+	queue := []int{id}
+	visited := map[int]bool{}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		names[byID[current].Name] = true
+		for _, child := range children[current] {
+			queue = append(queue, child.ID)
+		}
+	}
+	for name := range names {
+		stats.Regions = append(stats.Regions, name)
+	}
+	sort.Strings(stats.Regions)
+
+	liveCtx, cancel := context.WithTimeout(ctx, s.liveTimeout)
+	defer cancel()
+	locations, err := s.esStorage.AggregateRegion(liveCtx, stats.Regions)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(locations.Competition, func(i, j int) bool {
+		return locations.Competition[i].LocationCount > locations.Competition[j].LocationCount
+	})
+	stats.Locations = *locations
+	return stats, nil
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/cache"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/redis"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+const (
+	// apiKeyPrefix — начало выпускаемых ключей: по нему ключ легко найти в конфигурации и логах партнера.
+	apiKeyPrefix = "esk_"
+	// apiKeyPrefixLength — длина начала ключа, сохраняемого для опознания.
+	apiKeyPrefixLength = 12
+	// quotaCounterTTL — время жизни дневного счетчика в Redis: сутки и запас на расхождение часов.
+	quotaCounterTTL = 48 * time.Hour
+	// quotaRedisRetryDelay — пауза перед повторным обращением к Redis после его ошибки.
+	quotaRedisRetryDelay = 5 * time.Second
+	// apiKeyLength — длина выпускаемого ключа: префикс и 20 случайных байт в шестнадцатеричной записи.
+	apiKeyLength = len(apiKeyPrefix) + 40
+	// unknownAPIKeyTTL — наибольшее время кеширования неизвестного ключа: перебор ключей не должен
+	// обращаться к PostgreSQL на каждый запрос, а выпущенный ключ — долго отклоняться.
+	unknownAPIKeyTTL = 5 * time.Second
+)
+
+// APIKeyService выпускает API ключи внешних клиентов, хранимые в PostgreSQL, аутентифицирует
+// запросы по ним и учитывает дневные квоты запросов. Ключи кешируются на cacheTTL, неизвестные
+// ключи — на время не более unknownAPIKeyTTL. Экземпляр, изменивший ключ, сбрасывает кеш сразу,
+// остальные — при обнаружении изменения (Runner) или не позднее чем через cacheTTL.
+//
+// Запросы за сутки считаются в Redis, если он задан (SetRedis), иначе — в PostgreSQL. Пока Redis
+// недоступен, запросы считаются в PostgreSQL: за это время квота может быть превышена на число
+// запросов, учтенных в другом хранилище.
+type APIKeyService struct {
+	pgStorage *storage.PostgresStorage
+	keys      *cache.Cache[*auth.Principal] // Клиенты по хешу ключа
+	unknown   *cache.Cache[struct{}]        // Хеши ключей, которых нет среди неотозванных
+
+	counters     *redis.Counters
+	redisTimeout time.Duration
+	redisRetryAt atomic.Int64 // Время (UnixNano), до которого Redis не используется
+	redisDown    atomic.Bool
+}
+
+// NewAPIKeyService создает новый экземпляр APIKeyService.
+func NewAPIKeyService(pgStorage *storage.PostgresStorage, cacheTTL time.Duration) *APIKeyService {
+	return &APIKeyService{
+		pgStorage: pgStorage,
+		keys:      cache.New[*auth.Principal](cacheTTL),
+		unknown:   cache.New[struct{}](min(cacheTTL, unknownAPIKeyTTL)),
+	}
+}
+
+// SetRedis включает учет квот в Redis. timeout ограничивает ожидание его ответа.
+func (s *APIKeyService) SetRedis(counters *redis.Counters, timeout time.Duration) {
+	s.counters = counters
+	s.redisTimeout = timeout
+}
+
+// Create проверяет параметры и выпускает API ключ. Ключ возвращается в key.Key только в ответе
+// на выпуск: в PostgreSQL хранится его хеш.
+func (s *APIKeyService) Create(ctx context.Context, key *models.APIKey) error {
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return newValidationError("name is required")
+	}
+	if key.DailyQuota < 0 {
+		return newValidationError("daily_quota must not be negative")
+	}
+	if err := validateRoles(key.Roles); err != nil {
+		return err
+	}
+	if key.Roles == nil {
+		key.Roles = []string{}
+	}
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key.ID = newID()
+	key.Key = apiKeyPrefix + hex.EncodeToString(secret)
+	key.Prefix = key.Key[:apiKeyPrefixLength]
+	if key.Subject == "" {
+		key.Subject = "key-" + key.ID[:8]
+	}
+	key.RevokedAt = nil
+	key.UsedToday = nil
+
+	if err := s.pgStorage.CreateAPIKey(ctx, key, hashAPIKey(key.Key)); err != nil {
+		return err
+	}
+	s.unknown.Clear()
+	return nil
+}
+
+// List возвращает API ключи с числом запросов за текущие сутки UTC.
+func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.pgStorage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		return []models.APIKey{}, nil
+	}
+
+	day := quotaDay(time.Now())
+	usage, err := s.pgStorage.GetAPIKeyUsage(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		used := usage[keys[i].ID]
+		if s.counters != nil {
+			// Запросы, учтенные в PostgreSQL при недоступности Redis, складываются со счетчиком Redis
+			if n, err := s.counters.Get(ctx, keys[i].ID+":"+day); err == nil {
+				used += n
+			}
+		}
+		keys[i].UsedToday = &used
+	}
+	return keys, nil
+}
+
+// Update изменяет название, роли или дневную квоту API ключа.
+func (s *APIKeyService) Update(ctx context.Context, id string, update *models.APIKeyUpdate) (*models.APIKey, error) {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, newValidationError("name must not be empty")
+		}
+		update.Name = &name
+	}
+	if update.DailyQuota != nil && *update.DailyQuota < 0 {
+		return nil, newValidationError("daily_quota must not be negative")
+	}
+	if err := validateRoles(update.Roles); err != nil {
+		return nil, err
+	}
+
+	key, err := s.pgStorage.UpdateAPIKey(ctx, id, update)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.keys.Clear()
+	return key, nil
+}
+
+// Revoke отзывает API ключ.
+func (s *APIKeyService) Revoke(ctx context.Context, id string) error {
+	err := s.pgStorage.RevokeAPIKey(ctx, id)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.keys.Clear()
+	return nil
+}
+
+// AuthenticateAPIKey возвращает клиента, которому принадлежит неотозванный ключ key,
+// или nil, если ключ неизвестен. Ключи не того формата, что выпускает Create, отклоняются
+// без обращения к PostgreSQL.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error) {
+	if len(key) != apiKeyLength || !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil
+	}
+	hash := hashAPIKey(key)
+	if principal, ok := s.keys.Get(hash); ok {
+		return principal, nil
+	}
+	if _, ok := s.unknown.Get(hash); ok {
+		return nil, nil
+	}
+
+	stored, err := s.pgStorage.GetActiveAPIKeyByHash(ctx, hash)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		s.unknown.Set(hash, struct{}{})
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &auth.Principal{
+		Subject:      stored.Subject,
+		Organization: stored.Organization,
+		Roles:        stored.Roles,
+		APIKeyID:     stored.ID,
+		DailyQuota:   stored.DailyQuota,
+	}
+	s.keys.Set(hash, principal)
+	return principal, nil
+}
+
+// Runner возвращает фоновый процесс, который каждые interval сверяет отпечаток неотозванных
+// ключей в PostgreSQL и при его изменении (выпуск, изменение или отзыв ключа на любом экземпляре)
+// сбрасывает кеш ключей экземпляра.
+func (s *APIKeyService) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last string
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				fingerprint, err := s.pgStorage.APIKeysFingerprint(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						slog.ErrorContext(ctx, "Error checking API keys", logging.Err(err))
+					}
+					continue
+				}
+				// Первая сверка тоже сбрасывает кеш: ключи могли измениться до нее
+				if fingerprint != last {
+					s.invalidate()
+				}
+				last = fingerprint
+			}
+		}
+	}
+}
+
+// invalidate сбрасывает кеш известных и неизвестных ключей.
+func (s *APIKeyService) invalidate() {
+	s.keys.Clear()
+	s.unknown.Clear()
+}
+
+// Consume учитывает запрос ключа keyID за сутки day (YYYY-MM-DD) и возвращает число его запросов
+// за эти сутки с учетом добавленного.
+func (s *APIKeyService) Consume(ctx context.Context, keyID, day string) (int64, error) {
+	if s.counters != nil && time.Now().UnixNano() >= s.redisRetryAt.Load() {
+		redisCtx, cancel := context.WithTimeout(ctx, s.redisTimeout)
+		used, err := s.counters.Incr(redisCtx, keyID+":"+day, quotaCounterTTL)
+		cancel()
+		if err == nil {
+			if s.redisDown.Swap(false) {
+				slog.InfoContext(ctx, "API key quota store recovered, counting requests in Redis")
+			}
+			return used, nil
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		s.redisRetryAt.Store(time.Now().Add(quotaRedisRetryDelay).UnixNano())
+		if !s.redisDown.Swap(true) {
+			slog.WarnContext(ctx, "API key quota store unavailable, counting requests in PostgreSQL", logging.Err(err))
+		}
+	}
+	return s.pgStorage.IncrementAPIKeyUsage(ctx, keyID, day)
+}
+
+// quotaDay возвращает сутки UTC, к которым относится момент t, в формате YYYY-MM-DD.
+func quotaDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// hashAPIKey возвращает SHA-256 хеш ключа в шестнадцатеричной записи.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateRoles проверяет, что роли не пустые и не повторяются.
+func validateRoles(roles []string) error {
+	seen := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		if strings.TrimSpace(role) == "" {
+			return newValidationError("roles must not contain empty values")
+		}
+		if seen[role] {
+			return newValidationError("duplicate role %q", role)
+		}
+		seen[role] = true
+	}
+	return nil
+}
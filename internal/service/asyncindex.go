@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/akozadaev/go_es_analytical_system/internal/webhook"
+)
+
+const (
+	asyncCallbackBatchSize   = 100              // Уведомлений, отправляемых за один проход
+	asyncCallbackLease       = time.Minute      // Время, на которое выбранное уведомление скрыто от других экземпляров
+	asyncCallbackTimeout     = 10 * time.Second // Время ожидания ответа callback_url
+	asyncCallbackMaxAttempts = 10               // Попыток отправки уведомления, после которых оно отбрасывается
+	asyncCallbackMaxBackoff  = time.Hour        // Максимальная пауза между попытками отправки
+)
+
+// AsyncIndexService принимает локации на асинхронную индексацию: документы сохраняются в
+// PostgreSQL вместе с записями outbox, а их доставку в Elasticsearch выполняет relay-воркер.
+// Состояние каждого документа доступно по идентификатору отслеживания, а о завершении
+// индексации сервис уведомляет POST запросом на callback_url, если он указан.
+type AsyncIndexService struct {
+	pgStorage    *storage.PostgresStorage
+	maxDocuments int
+	httpClient   *http.Client
+	webhooks     webhook.Policy
+	profiles     *ValidationProfileService
+	references   *ReferenceService
+	editorRole   string
+}
+
+// NewAsyncIndexService создает новый экземпляр AsyncIndexService.
+// maxDocuments ограничивает число документов в одном запросе.
+func NewAsyncIndexService(pgStorage *storage.PostgresStorage, maxDocuments int) *AsyncIndexService {
+	return &AsyncIndexService{
+		pgStorage:    pgStorage,
+		maxDocuments: maxDocuments,
+		httpClient:   webhook.Policy{}.Client(asyncCallbackTimeout),
+	}
+}
+
+// SetWebhookPolicy задает допустимые адреса callback_url. По умолчанию адреса внутренних
+// и служебных сетей запрещены.
+func (s *AsyncIndexService) SetWebhookPolicy(policy webhook.Policy) {
+	s.webhooks = policy
+	s.httpClient = policy.Client(asyncCallbackTimeout)
+}
+
+// SetValidationProfiles включает проверку принимаемых документов по профилям проверки их
+// типов бизнеса: документы с нарушениями получают статус rejected.
+func (s *AsyncIndexService) SetValidationProfiles(profiles *ValidationProfileService) {
+	s.profiles = profiles
+}
+
+// SetEditorRole задает роль редакторов локаций: только они могут отправлять документы на индексацию.
+// Пустая роль и запросы без аутентификации не ограничиваются.
+func (s *AsyncIndexService) SetEditorRole(role string) {
+	s.editorRole = role
+}
+
+// SetReferences включает проверку типов бизнеса и города принимаемых документов по справочникам.
+func (s *AsyncIndexService) SetReferences(references *ReferenceService) {
+	s.references = references
+}
+
+// Submit проверяет документы запроса и ставит прошедшие проверку в очередь индексации.
+// Доступно только редакторам (иначе ErrForbidden). Локация без ID получает сгенерированный ID,
+// локация с существующим ID заменяется с сохранением времени создания, истории демографии
+// и состояния жизненного цикла: status документа учитывается только для новых локаций, как
+// в Create. Документы, не прошедшие проверку, не прерывают прием остальных: они получают
+// статус rejected с описанием ошибки и, как и остальные, сообщаются на callback_url.
+func (s *AsyncIndexService) Submit(ctx context.Context, req *models.AsyncIndexRequest) (*models.AsyncIndexResponse, error) {
+	if !canEdit(ctx, s.editorRole) {
+		return nil, ErrForbidden
+	}
+	if len(req.Locations) == 0 {
+		return nil, newValidationError("locations must not be empty")
+	}
+	if len(req.Locations) > s.maxDocuments {
+		return nil, newValidationError("too many locations: %d (max %d)", len(req.Locations), s.maxDocuments)
+	}
+	if req.CallbackURL != "" {
+		if err := s.webhooks.Check(ctx, req.CallbackURL); err != nil {
+			return nil, newValidationError("callback_url %v", err)
+		}
+	}
+
+	var profiles map[string]*models.ValidationProfile
+	if s.profiles != nil {
+		var err error
+		if profiles, err = s.profiles.Profiles(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var businessTypes map[string]bool
+	var cities models.KnownCities
+	if s.references != nil {
+		var err error
+		if businessTypes, err = s.references.BusinessTypeNames(ctx); err != nil {
+			return nil, err
+		}
+		if cities, err = s.references.KnownCities(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Город заменяемых локаций проверяется, только если он или регион изменились
+	var previous map[string]*models.Location
+	if cities != nil {
+		ids := make([]string, 0, len(req.Locations))
+		for _, location := range req.Locations {
+			if location.ID != "" {
+				ids = append(ids, location.ID)
+			}
+		}
+		var err error
+		if previous, err = s.pgStorage.GetStoredLocations(ctx, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	response := &models.AsyncIndexResponse{BatchID: newID()}
+	items := make([]*models.AsyncIndexItem, len(req.Locations))
+	locations := make([]*models.Location, len(req.Locations))
+	for i := range req.Locations {
+		location := &req.Locations[i]
+		if location.ID == "" {
+			location.ID = newID()
+		}
+		item := &models.AsyncIndexItem{
+			TrackingID:  newID(),
+			BatchID:     response.BatchID,
+			LocationID:  location.ID,
+			Status:      models.AsyncIndexPending,
+			CallbackURL: req.CallbackURL,
+			CreatedAt:   now,
+		}
+		err := validateLocation(location, businessTypes, citiesToCheck(cities, previous[location.ID], location))
+		if err == nil {
+			err = checkProfiles(location, profiles)
+		}
+		switch location.Status {
+		case "":
+			location.Status = models.LocationPublished
+		case models.LocationRetired:
+			if err == nil {
+				err = newValidationError("status must be %q or %q; use POST /locations/{id}/status to retire a location",
+					models.LocationDraft, models.LocationPublished)
+			}
+		}
+		if err != nil {
+			item.Status = models.AsyncIndexRejected
+			item.Error = err.Error()
+			item.CompletedAt = &now
+			response.Rejected++
+		} else {
+			location.CreatedAt = now
+			location.UpdatedAt = now
+			locations[i] = location
+			response.Accepted++
+		}
+		items[i] = item
+	}
+
+	if err := s.pgStorage.EnqueueAsyncLocations(ctx, items, locations); err != nil {
+		return nil, err
+	}
+
+	response.Items = make([]models.AsyncIndexItem, len(items))
+	for i, item := range items {
+		response.Items[i] = *item
+	}
+	return response, nil
+}
+
+// Get возвращает состояние документа по идентификатору отслеживания или ErrNotFound.
+func (s *AsyncIndexService) Get(ctx context.Context, trackingID string) (*models.AsyncIndexItem, error) {
+	item, err := s.pgStorage.GetAsyncIndexItem(ctx, trackingID)
+	if errors.Is(err, storage.ErrAsyncIndexItemNotFound) {
+		return nil, ErrNotFound
+	}
+	return item, err
+}
+
+// ListBatch возвращает состояние документов пакета или ErrNotFound, если пакет не найден.
+func (s *AsyncIndexService) ListBatch(ctx context.Context, batchID string) ([]models.AsyncIndexItem, error) {
+	if batchID == "" {
+		return nil, newValidationError("batch_id is required")
+	}
+	items, err := s.pgStorage.ListAsyncIndexItems(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return items, nil
+}
+
+// DeliverCallbacks отправляет накопившиеся уведомления о завершенных документах.
+// Неудачная отправка повторяется с экспоненциально растущей паузой; после
+// asyncCallbackMaxAttempts попыток уведомление отбрасывается, а ошибка остается в состоянии
+// документа. Возвращает число доставленных и неудачных уведомлений.
+func (s *AsyncIndexService) DeliverCallbacks(ctx context.Context) (delivered, failed int, err error) {
+	items, err := s.pgStorage.ClaimAsyncIndexCallbacks(ctx, asyncCallbackBatchSize, asyncCallbackLease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		sendErr := s.sendCallback(ctx, item)
+		var retryAfter time.Duration
+		if sendErr != nil {
+			failed++
+			retryAfter = asyncCallbackBackoff(item.CallbackAttempts + 1)
+			slog.WarnContext(ctx, "Error sending async index callback", "tracking_id", item.TrackingID,
+				"callback_url", item.CallbackURL, logging.Err(sendErr))
+		} else {
+			delivered++
+		}
+		if err := s.pgStorage.CompleteAsyncIndexCallback(ctx, item.TrackingID, sendErr, retryAfter); err != nil {
+			return delivered, failed, err
+		}
+	}
+	return delivered, failed, nil
+}
+
+// asyncCallbackBackoff возвращает паузу перед повтором после attempts неудачных отправок
+// или 0, если попытки исчерпаны.
+func asyncCallbackBackoff(attempts int) time.Duration {
+	if attempts >= asyncCallbackMaxAttempts {
+		return 0
+	}
+	backoff := 10 * time.Second << (attempts - 1)
+	if backoff > asyncCallbackMaxBackoff {
+		backoff = asyncCallbackMaxBackoff
+	}
+	return backoff
+}
+
+// sendCallback отправляет уведомление о документе POST запросом с JSON телом. Адрес
+// проверяется повторно: с момента приема имя хоста могло начать разрешаться во внутреннюю сеть.
+func (s *AsyncIndexService) sendCallback(ctx context.Context, item *models.AsyncIndexItem) error {
+	if err := s.webhooks.Check(ctx, item.CallbackURL); err != nil {
+		return fmt.Errorf("callback_url %w", err)
+	}
+	body, err := json.Marshal(models.AsyncIndexCallback{
+		TrackingID:  item.TrackingID,
+		BatchID:     item.BatchID,
+		LocationID:  item.LocationID,
+		Status:      item.Status,
+		Error:       item.Error,
+		CompletedAt: item.CompletedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal callback: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", item.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send callback: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// Runner возвращает фоновый процесс, отправляющий уведомления с интервалом interval и удаляющий
+// состояние документов, завершенных раньше retention назад.
+func (s *AsyncIndexService) Runner(interval, retention time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				delivered, failed, err := s.DeliverCallbacks(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "Error delivering async index callbacks", logging.Err(err))
+				} else if delivered > 0 || failed > 0 {
+					slog.InfoContext(ctx, "Async index callbacks delivered", "delivered", delivered, "failed", failed)
+				}
+
+				removed, err := s.pgStorage.DeleteAsyncIndexItems(ctx, time.Now().Add(-retention))
+				if err != nil {
+					slog.ErrorContext(ctx, "Error removing completed async index items", logging.Err(err))
+				} else if removed > 0 {
+					slog.InfoContext(ctx, "Removed completed async index items", "removed", removed)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+const (
+	// defaultChangesLimit — размер страницы ленты изменений по умолчанию.
+	defaultChangesLimit = 100
+	// maxChangesLimit — максимальный размер страницы ленты изменений.
+	maxChangesLimit = 1000
+	// changesSettleDelay — задержка, после которой изменения попадают в ленту. Время изменения
+	// задается до фиксации транзакции, поэтому более позднее изменение может стать видимым
+	// раньше более раннего; без задержки клиент сдвинул бы курсор и пропустил ранее изменение.
+	changesSettleDelay = 2 * time.Second
+)
+
+// ChangeFeedService отдает ленту изменений локаций по курсору для легких клиентов
+// синхронизации: без Kafka и webhooks клиент периодически (или в режиме long polling)
+// запрашивает изменения после последнего полученного курсора.
+type ChangeFeedService struct {
+	pgStorage    *storage.PostgresStorage
+	maxWait      time.Duration
+	pollInterval time.Duration
+	editorRole   string
+}
+
+// NewChangeFeedService создает новый экземпляр ChangeFeedService.
+// maxWait ограничивает ожидание изменений в режиме long polling; pollInterval — период опроса
+// PostgreSQL во время ожидания.
+func NewChangeFeedService(pgStorage *storage.PostgresStorage, maxWait, pollInterval time.Duration) *ChangeFeedService {
+	return &ChangeFeedService{
+		pgStorage:    pgStorage,
+		maxWait:      maxWait,
+		pollInterval: pollInterval,
+	}
+}
+
+// SetEditorRole задает роль редакторов локаций (см. LocationService.SetEditorRole): остальным
+// клиентам сохранения неопубликованных локаций отдаются удалениями без документа, и синхронизируемая
+// копия не содержит черновиков и снятых с публикации локаций.
+func (s *ChangeFeedService) SetEditorRole(role string) {
+	s.editorRole = role
+}
+
+// MaxWait возвращает максимальное время ожидания изменений.
+func (s *ChangeFeedService) MaxWait() time.Duration {
+	return s.maxWait
+}
+
+// Changes возвращает до limit изменений после курсора since (пустой — с начала ленты).
+// Если изменений нет и wait > 0, запрос ждет их появления до wait (не дольше MaxWait).
+func (s *ChangeFeedService) Changes(ctx context.Context, since string, limit int, wait time.Duration) (*models.LocationChangesResponse, error) {
+	afterTime, afterID, err := decodeChangeCursor(since)
+	if err != nil {
+		return nil, newValidationError("invalid cursor")
+	}
+	if limit < 0 {
+		return nil, newValidationError("limit must not be negative")
+	}
+	if limit == 0 {
+		limit = defaultChangesLimit
+	}
+	if limit > maxChangesLimit {
+		limit = maxChangesLimit
+	}
+	if wait < 0 {
+		return nil, newValidationError("wait must not be negative")
+	}
+	if wait > s.maxWait {
+		wait = s.maxWait
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		changes, err := s.pgStorage.ListLocationChanges(ctx, afterTime, afterID, time.Now().Add(-changesSettleDelay), limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 || !time.Now().Before(deadline) {
+			if !canEdit(ctx, s.editorRole) {
+				hideUnpublished(changes)
+			}
+			response := &models.LocationChangesResponse{Changes: changes, NextCursor: since}
+			if len(changes) > 0 {
+				last := changes[len(changes)-1]
+				response.NextCursor = encodeChangeCursor(last.ChangedAt, last.ID)
+			} else {
+				response.Changes = []models.LocationChange{}
+			}
+			return response, nil
+		}
+
+		delay := s.pollInterval
+		if remaining := time.Until(deadline); remaining < delay {
+			delay = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// hideUnpublished заменяет сохранения неопубликованных локаций удалениями без документа.
+func hideUnpublished(changes []models.LocationChange) {
+	for i := range changes {
+		if location := changes[i].Location; location != nil && !location.Published() {
+			changes[i].Operation = models.OutboxDelete
+			changes[i].Location = nil
+		}
+	}
+}
+
+// encodeChangeCursor кодирует позицию в ленте изменений в непрозрачный курсор.
+func encodeChangeCursor(t time.Time, id string) string {
+	raw := strconv.FormatInt(t.UnixMicro(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeChangeCursor разбирает курсор; пустой курсор соответствует началу ленты.
+func decodeChangeCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, "", strconv.ErrSyntax
+	}
+	unix, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return time.UnixMicro(unix), id, nil
+}
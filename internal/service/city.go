@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// CityService управляет справочником городов. Чтение городов выполняется через ReferenceService;
+// после изменения справочника его кеш сбрасывается.
+type CityService struct {
+	cities     storage.CityStore
+	references *ReferenceService
+}
+
+// NewCityService создает новый экземпляр CityService. Кеш справочников references
+// (может быть nil) сбрасывается после изменения городов.
+func NewCityService(cities storage.CityStore, references *ReferenceService) *CityService {
+	return &CityService{
+		cities:     cities,
+		references: references,
+	}
+}
+
+// Get возвращает город по ID или ErrNotFound. Город читается из PostgreSQL, а не из кеша.
+func (s *CityService) Get(ctx context.Context, id int) (*models.City, error) {
+	city, err := s.cities.GetCity(ctx, id)
+	if errors.Is(err, storage.ErrCityNotFound) {
+		return nil, ErrNotFound
+	}
+	return city, err
+}
+
+// Create проверяет и добавляет город. Возвращает ErrConflict, если в регионе уже есть город
+// с таким именем.
+func (s *CityService) Create(ctx context.Context, city *models.City) error {
+	if err := validateCity(city); err != nil {
+		return err
+	}
+	if err := cityError(s.cities.CreateCity(ctx, city), city); err != nil {
+		return err
+	}
+	return s.reload(ctx, city)
+}
+
+// Update проверяет город и заменяет им город id. Возвращает ErrNotFound, если города нет,
+// и ErrConflict, если в регионе уже есть другой город с таким именем.
+func (s *CityService) Update(ctx context.Context, id int, city *models.City) error {
+	if city.ID != 0 && city.ID != id {
+		return newValidationError("id in body must match city ID %d", id)
+	}
+	city.ID = id
+	if err := validateCity(city); err != nil {
+		return err
+	}
+	if err := cityError(s.cities.UpdateCity(ctx, city), city); err != nil {
+		return err
+	}
+	return s.reload(ctx, city)
+}
+
+// Delete удаляет город. Возвращает ErrNotFound, если его нет.
+func (s *CityService) Delete(ctx context.Context, id int) error {
+	if err := cityError(s.cities.DeleteCity(ctx, id), nil); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// reload сбрасывает кеш справочников и перечитывает записанный город, чтобы заполнить название региона.
+func (s *CityService) reload(ctx context.Context, city *models.City) error {
+	s.invalidate()
+	saved, err := s.Get(ctx, city.ID)
+	if err != nil {
+		return err
+	}
+	*city = *saved
+	return nil
+}
+
+func (s *CityService) invalidate() {
+	if s.references != nil {
+		s.references.Invalidate()
+	}
+}
+
+// validateCity проверяет город перед записью. Ошибка валидации перечисляет все нарушения по полям.
+func validateCity(city *models.City) error {
+	var v fieldErrors
+	city.Name = strings.TrimSpace(city.Name)
+	if city.Name == "" {
+		v.add("name", "is required")
+	}
+	if city.RegionID <= 0 {
+		v.add("region_id", "is required")
+	}
+	v.geoPoint("center", city.Center)
+	if city.Center.Lat == 0 && city.Center.Lon == 0 {
+		v.add("center", "is required")
+	}
+	v.nonNegative("population", float64(city.Population))
+	return v.err()
+}
+
+// cityError переводит ошибки справочника городов в ошибки сервиса.
+func cityError(err error, city *models.City) error {
+	switch {
+	case errors.Is(err, storage.ErrCityNotFound):
+		return ErrNotFound
+	case errors.Is(err, storage.ErrCityExists):
+		return ErrConflict
+	case errors.Is(err, storage.ErrCityRegionNotFound):
+		var v fieldErrors
+		v.add("region_id", "region %d not found", city.RegionID)
+		return v.err()
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// recommendCursor — содержимое курсора страницы рекомендаций.
+type recommendCursor struct {
+	After    []interface{} `json:"a"` // Значения сортировки последней локации для search_after
+	MaxScore float64       `json:"m"` // Оценка лучшей локации первой страницы
+}
+
+// encodeCursor кодирует курсор в непрозрачную строку для передачи клиенту.
+func encodeCursor(cursor recommendCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор, полученный от клиента.
+func decodeCursor(token string) (*recommendCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor recommendCursor
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Значения сортировки передаются в Elasticsearch без потери точности
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return nil, err
+	}
+	if len(cursor.After) == 0 {
+		return nil, errors.New("cursor has no sort values")
+	}
+	return &cursor, nil
+}
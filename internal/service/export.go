@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/artifact"
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/jobs"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/report"
+	"github.com/akozadaev/go_es_analytical_system/internal/signedurl"
+)
+
+// ExportResult — результат задачи выгрузки проекта.
+type ExportResult struct {
+	ArtifactID      string    `json:"artifact_id"`
+	DownloadURL     string    `json:"download_url"`      // Ссылка для скачивания с API ключом
+	SignedURL       string    `json:"signed_url"`        // Ссылка для скачивания без аутентификации
+	SignedExpiresAt time.Time `json:"signed_expires_at"` // Срок действия signed_url
+	Size            int64     `json:"size"`
+}
+
+// SignedDownload — подписанная ссылка на скачивание артефакта.
+type SignedDownload struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExportService формирует клиентские отчеты по проектам в фоновых задачах
+// и сохраняет их в хранилище артефактов.
+type ExportService struct {
+	projects *ProjectService
+	jobs     *jobs.Manager
+	store    artifact.Store
+	signer   *signedurl.Signer
+}
+
+// NewExportService создает новый экземпляр ExportService.
+// signer подписывает ссылки на скачивание артефактов без аутентификации.
+func NewExportService(projects *ProjectService, jobManager *jobs.Manager, store artifact.Store, signer *signedurl.Signer) *ExportService {
+	return &ExportService{
+		projects: projects,
+		jobs:     jobManager,
+		store:    store,
+		signer:   signer,
+	}
+}
+
+// ExportProject проверяет доступ к проекту и запускает фоновую задачу формирования отчета.
+// Ссылка на готовый файл возвращается в результате задачи.
+func (s *ExportService) ExportProject(ctx context.Context, projectID string, format report.Format) (*jobs.Job, error) {
+	if !format.Valid() {
+		return nil, newValidationError("unsupported format: %q (available: xlsx, pptx, parquet)", format)
+	}
+	project, err := s.projects.project(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	principal, _ := auth.FromContext(ctx)
+
+	job := s.jobs.SubmitFor(principal.Organization, "project_export", func(ctx context.Context, progress *jobs.Progress) error {
+		// Задача выполняется вне запроса: права пользователя переносятся в ее контекст
+		ctx = auth.WithPrincipal(ctx, principal)
+
+		summary, err := s.projects.Summary(ctx, project.ID)
+		if err != nil {
+			return err
+		}
+		progress.SetTotal(len(summary.Candidates))
+
+		data, err := report.Render(format, summary)
+		if err != nil {
+			return err
+		}
+		progress.Add(len(summary.Candidates), 0)
+
+		result := &artifact.Artifact{
+			Name:         fmt.Sprintf("%s.%s", fileName(summary.Name), format),
+			ContentType:  format.ContentType(),
+			Organization: principal.Organization,
+		}
+		if err := s.store.Put(ctx, result, bytes.NewReader(data)); err != nil {
+			return err
+		}
+
+		signed := s.sign(result.ID)
+		progress.SetResult(&ExportResult{
+			ArtifactID:      result.ID,
+			DownloadURL:     models.APIV1Prefix + "/artifacts/" + result.ID,
+			SignedURL:       signed.URL,
+			SignedExpiresAt: signed.ExpiresAt,
+			Size:            result.Size,
+		})
+		return nil
+	})
+
+	return job, nil
+}
+
+// Job возвращает состояние задачи выгрузки, запущенной организацией пользователя.
+// Задачи других организаций и служебные задачи неотличимы от отсутствующих.
+func (s *ExportService) Job(ctx context.Context, id string) (*jobs.Job, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	job, ok := s.jobs.GetFor(principal.Organization, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job, nil
+}
+
+// OpenArtifact возвращает артефакт, если он принадлежит организации пользователя.
+// Вызывающий код закрывает reader.
+func (s *ExportService) OpenArtifact(ctx context.Context, id string) (*artifact.Artifact, io.ReadCloser, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, nil, ErrUnauthenticated
+	}
+
+	meta, content, err := s.open(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta.Organization != principal.Organization {
+		content.Close()
+		return nil, nil, ErrNotFound
+	}
+
+	return meta, content, nil
+}
+
+// SignArtifact создает новую подписанную ссылку на артефакт организации пользователя,
+// например взамен истекшей ссылки из результата задачи.
+func (s *ExportService) SignArtifact(ctx context.Context, id string) (*SignedDownload, error) {
+	meta, content, err := s.OpenArtifact(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	content.Close()
+	return s.sign(meta.ID), nil
+}
+
+// OpenSignedArtifact возвращает артефакт по подписанной ссылке. Подпись проверяется
+// middleware маршрута; доступ определяется знанием ссылки, а не организацией клиента.
+func (s *ExportService) OpenSignedArtifact(ctx context.Context, id string) (*artifact.Artifact, io.ReadCloser, error) {
+	return s.open(ctx, id)
+}
+
+func (s *ExportService) open(ctx context.Context, id string) (*artifact.Artifact, io.ReadCloser, error) {
+	meta, content, err := s.store.Open(ctx, id)
+	if err != nil {
+		if errors.Is(err, artifact.ErrNotFound) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return meta, content, nil
+}
+
+// sign подписывает ссылку GET /api/v1/downloads/artifacts/{id}.
+func (s *ExportService) sign(id string) *SignedDownload {
+	url, expiresAt := s.signer.Sign(SignedArtifactPath(id))
+	return &SignedDownload{URL: url, ExpiresAt: expiresAt}
+}
+
+// SignedArtifactPath возвращает путь скачивания артефакта по подписанной ссылке. Подпись
+// покрывает путь, поэтому ссылки, выданные до перехода на /api/v1, действуют по прежнему пути.
+func SignedArtifactPath(id string) string {
+	return models.APIV1Prefix + "/downloads/artifacts/" + id
+}
+
+// fileName заменяет в имени проекта символы, недопустимые в именах файлов.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "project"
+	}
+	return name
+}
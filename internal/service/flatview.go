@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+const (
+	// defaultFlatViewLimit — размер страницы плоского представления по умолчанию.
+	defaultFlatViewLimit = 1000
+	// maxFlatViewLimit — максимальный размер страницы плоского представления.
+	maxFlatViewLimit = 10000
+)
+
+// flatColumns перечисляет столбцы плоского представления в порядке models.FlatLocation.
+// Новые столбцы добавляются только в конец.
+var flatColumns = []models.FlatColumn{
+	{Name: "id", Type: "string"},
+	{Name: "name", Type: "string"},
+	{Name: "address", Type: "string"},
+	{Name: "region", Type: "string"},
+	{Name: "city", Type: "string"},
+	{Name: "lat", Type: "number"},
+	{Name: "lon", Type: "number"},
+	{Name: "business_types_suitable", Type: "string"},
+	{Name: "traffic_score", Type: "number"},
+	{Name: "competition_density", Type: "number"},
+	{Name: "event_exposure", Type: "number"},
+	{Name: "safety_score", Type: "number"},
+	{Name: "street_parking_score", Type: "number"},
+	{Name: "paid_lots_nearby", Type: "integer"},
+	{Name: "schools_nearby", Type: "integer"},
+	{Name: "universities_nearby", Type: "integer"},
+	{Name: "demographics_age_group", Type: "string"},
+	{Name: "demographics_average_income", Type: "number"},
+	{Name: "demographics_interests", Type: "string"},
+	{Name: "demographics_population_density", Type: "number"},
+	{Name: "created_at", Type: "datetime"},
+	{Name: "updated_at", Type: "datetime"},
+}
+
+// FlatColumns возвращает столбцы плоского представления локаций.
+func FlatColumns() []models.FlatColumn {
+	return flatColumns
+}
+
+// FlatView возвращает страницу плоского представления локаций с фильтром по региону и городу
+// (пустые — все). Строки упорядочены по ID; cursor — значение next_cursor предыдущей страницы.
+func (s *LocationService) FlatView(ctx context.Context, region, city, cursor string, limit int) (*models.FlatViewResponse, error) {
+	afterID, err := decodeFlatCursor(cursor)
+	if err != nil {
+		return nil, newValidationError("invalid cursor")
+	}
+	if limit < 0 {
+		return nil, newValidationError("limit must not be negative")
+	}
+	if limit == 0 {
+		limit = defaultFlatViewLimit
+	}
+	if limit > maxFlatViewLimit {
+		limit = maxFlatViewLimit
+	}
+
+	filter := &models.LocationFilter{Region: region, City: city, PublishedOnly: true}
+	total, err := s.esStorage.CountLocations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := s.esStorage.ListLocationsPage(ctx, filter, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.FlatViewResponse{
+		SchemaVersion: models.FlatViewSchemaVersion,
+		Columns:       flatColumns,
+		Rows:          make([]models.FlatLocation, 0, len(locations)),
+		Total:         total,
+	}
+	for _, location := range locations {
+		response.Rows = append(response.Rows, flattenLocation(location))
+	}
+	if len(locations) == limit {
+		response.NextCursor = encodeFlatCursor(locations[len(locations)-1].ID)
+	}
+
+	return response, nil
+}
+
+// FlatRecord возвращает значения строки в порядке столбцов FlatColumns (для CSV).
+func FlatRecord(row *models.FlatLocation) []string {
+	number := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		row.ID,
+		row.Name,
+		row.Address,
+		row.Region,
+		row.City,
+		number(row.Lat),
+		number(row.Lon),
+		row.BusinessTypesSuitable,
+		number(row.TrafficScore),
+		number(row.CompetitionDensity),
+		number(row.EventExposure),
+		number(row.SafetyScore),
+		number(row.StreetParkingScore),
+		strconv.Itoa(row.PaidLotsNearby),
+		strconv.Itoa(row.SchoolsNearby),
+		strconv.Itoa(row.UniversitiesNearby),
+		row.DemographicsAgeGroup,
+		number(row.DemographicsAverageIncome),
+		row.DemographicsInterests,
+		number(row.DemographicsPopulationDensity),
+		row.CreatedAt,
+		row.UpdatedAt,
+	}
+}
+
+// flattenLocation разворачивает локацию в строку плоского представления.
+func flattenLocation(l *models.Location) models.FlatLocation {
+	return models.FlatLocation{
+		ID:                            l.ID,
+		Name:                          l.Name,
+		Address:                       l.Address,
+		Region:                        l.Region,
+		City:                          l.City,
+		Lat:                           l.Coordinates.Lat,
+		Lon:                           l.Coordinates.Lon,
+		BusinessTypesSuitable:         strings.Join(l.BusinessTypesSuitable, ";"),
+		TrafficScore:                  l.TrafficScore,
+		CompetitionDensity:            l.CompetitionDensity,
+		EventExposure:                 l.EventExposure,
+		SafetyScore:                   l.SafetyScore,
+		StreetParkingScore:            l.StreetParkingScore,
+		PaidLotsNearby:                l.PaidLotsNearby,
+		SchoolsNearby:                 l.SchoolsNearby,
+		UniversitiesNearby:            l.UniversitiesNearby,
+		DemographicsAgeGroup:          l.Demographics.AgeGroup,
+		DemographicsAverageIncome:     l.Demographics.AverageIncome,
+		DemographicsInterests:         strings.Join(l.Demographics.Interests, ";"),
+		DemographicsPopulationDensity: l.Demographics.PopulationDensity,
+		CreatedAt:                     l.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:                     l.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// encodeFlatCursor кодирует ID последней строки страницы в непрозрачный курсор.
+func encodeFlatCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// decodeFlatCursor разбирает курсор; пустой курсор соответствует первой странице.
+func decodeFlatCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/footfall"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// FootfallService реализует прогноз посещаемости локаций.
+type FootfallService struct {
+	model     footfall.Model
+	locations *LocationService
+	pgStorage *storage.PostgresStorage
+}
+
+// NewFootfallService создает новый экземпляр FootfallService. При model = nil прогноз недоступен.
+// Прогнозы сохраняются в PostgreSQL вместе с версией построившей их модели.
+func NewFootfallService(model footfall.Model, locations *LocationService, pgStorage *storage.PostgresStorage) *FootfallService {
+	return &FootfallService{model: model, locations: locations, pgStorage: pgStorage}
+}
+
+// Predict прогнозирует ожидаемое число посетителей в день для локации из индекса или кандидата.
+func (s *FootfallService) Predict(ctx context.Context, req *models.FootfallRequest) (*models.FootfallPrediction, error) {
+	// Прогноз и версия берутся у одной модели, даже если реестр переключит ее во время запроса
+	model := footfall.Current(s.model)
+	if model == nil {
+		return nil, ErrUnavailable
+	}
+	if req.BusinessType == "" {
+		return nil, newValidationError("business_type is required")
+	}
+	if (req.LocationID == "") == (req.Location == nil) {
+		return nil, newValidationError("exactly one of location_id and location is required")
+	}
+
+	location := req.Location
+	if req.LocationID != "" {
+		var err error
+		location, err = s.locations.Get(ctx, req.LocationID, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	predictions, err := model.Predict(ctx, []footfall.Features{footfall.LocationFeatures(location, req.BusinessType)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to predict footfall: %w", err)
+	}
+
+	prediction := &models.FootfallPrediction{
+		LocationID:            req.LocationID,
+		BusinessType:          req.BusinessType,
+		ExpectedDailyVisitors: predictions[0],
+		ModelVersion:          model.Version(),
+	}
+	s.recordPrediction(prediction)
+
+	return prediction, nil
+}
+
+// recordPrediction асинхронно сохраняет прогноз с версией модели. Ошибки записи только логируются.
+func (s *FootfallService) recordPrediction(prediction *models.FootfallPrediction) {
+	if s.pgStorage == nil {
+		return
+	}
+
+	record := &models.ModelPrediction{
+		Kind:         "footfall",
+		Version:      prediction.ModelVersion,
+		LocationID:   prediction.LocationID,
+		BusinessType: prediction.BusinessType,
+		Value:        prediction.ExpectedDailyVisitors,
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.pgStorage.RecordPrediction(ctx, record); err != nil {
+			slog.ErrorContext(ctx, "Error recording footfall prediction", logging.Err(err))
+		}
+	}()
+}
+
+// blendFootfall смешивает оценки кандидатов с прогнозом посещаемости, нормализованным относительно
+// лучшего прогноза: score = (1 - weight) * score + weight * прогноз. Возвращает копию кандидатов,
+// отсортированную по новой оценке, и версию модели, построившей прогноз.
+func (s *RecommendationService) blendFootfall(ctx context.Context, candidates []models.Location, businessType string, weight float64) ([]models.Location, string, error) {
+	model := footfall.Current(s.footfall)
+	if model == nil {
+		return nil, "", ErrUnavailable
+	}
+
+	features := make([]footfall.Features, len(candidates))
+	for i := range candidates {
+		features[i] = footfall.LocationFeatures(&candidates[i], businessType)
+	}
+	predictions, err := model.Predict(ctx, features)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to predict footfall: %w", err)
+	}
+
+	var maxPrediction float64
+	for _, p := range predictions {
+		if p > maxPrediction {
+			maxPrediction = p
+		}
+	}
+
+	// Копия: candidates может быть разделяемым значением из кеша
+	blended := make([]models.Location, len(candidates))
+	for i, loc := range candidates {
+		loc.ExpectedDailyVisitors = predictions[i]
+		normalized := 0.0
+		if maxPrediction > 0 {
+			normalized = predictions[i] / maxPrediction
+		}
+		loc.Score = (1-weight)*loc.Score + weight*normalized
+		blended[i] = loc
+	}
+	sort.SliceStable(blended, func(i, j int) bool { return blended[i].Score > blended[j].Score })
+
+	return blended, model.Version(), nil
+}
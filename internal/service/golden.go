@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// goldenIDPattern ограничивает ID эталонных запросов: ID задается в фикстурах и путях API.
+var goldenIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// GoldenQueryService хранит эталонные запросы с ожидаемыми лучшими результатами и проверяет
+// по ним текущую выдачу, чтобы регрессии релевантности после загрузки данных или изменения
+// маппинга не проходили незамеченными.
+type GoldenQueryService struct {
+	recommendations *RecommendationService
+	pgStorage       *storage.PostgresStorage
+}
+
+// NewGoldenQueryService создает новый экземпляр GoldenQueryService.
+func NewGoldenQueryService(recommendations *RecommendationService, pgStorage *storage.PostgresStorage) *GoldenQueryService {
+	return &GoldenQueryService{
+		recommendations: recommendations,
+		pgStorage:       pgStorage,
+	}
+}
+
+// List возвращает эталонные запросы.
+func (s *GoldenQueryService) List(ctx context.Context) ([]models.GoldenQuery, error) {
+	return s.pgStorage.ListGoldenQueries(ctx)
+}
+
+// Save проверяет эталонный запрос и сохраняет его, заменяя запрос с тем же ID.
+func (s *GoldenQueryService) Save(ctx context.Context, gq *models.GoldenQuery) error {
+	if !goldenIDPattern.MatchString(gq.ID) {
+		return newValidationError("id must be 1-64 letters, digits, dots, dashes or underscores")
+	}
+	if len(gq.ExpectedIDs) == 0 {
+		return newValidationError("expected_ids must not be empty")
+	}
+	if gq.TopK < 0 {
+		return newValidationError("top_k must not be negative")
+	}
+	if gq.TopK == 0 {
+		gq.TopK = len(gq.ExpectedIDs)
+	}
+	if gq.TopK < len(gq.ExpectedIDs) {
+		return newValidationError("top_k must not be less than the number of expected_ids")
+	}
+
+	// Выдача должна вмещать top_k результатов
+	if gq.Request.Limit < gq.TopK {
+		gq.Request.Limit = gq.TopK
+	}
+	if err := s.recommendations.Validate(&gq.Request); err != nil {
+		return err
+	}
+
+	return s.pgStorage.UpsertGoldenQuery(ctx, gq)
+}
+
+// Delete удаляет эталонный запрос.
+func (s *GoldenQueryService) Delete(ctx context.Context, id string) error {
+	err := s.pgStorage.DeleteGoldenQuery(ctx, id)
+	if errors.Is(err, storage.ErrGoldenQueryNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Run выполняет все эталонные запросы на текущем индексе (без кеша и записи в историю)
+// и сообщает об отклонениях: ожидаемых локациях, не вошедших в первые top_k результатов.
+func (s *GoldenQueryService) Run(ctx context.Context) (*models.GoldenRunReport, error) {
+	queries, err := s.pgStorage.ListGoldenQueries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.GoldenRunReport{
+		StartedAt: time.Now(),
+		Results:   make([]models.GoldenQueryResult, 0, len(queries)),
+	}
+	for i := range queries {
+		result := s.check(ctx, &queries[i])
+		report.Total++
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	return report, nil
+}
+
+// check выполняет эталонный запрос и сравнивает top_k выдачи с ожидаемыми локациями.
+func (s *GoldenQueryService) check(ctx context.Context, gq *models.GoldenQuery) models.GoldenQueryResult {
+	result := models.GoldenQueryResult{ID: gq.ID, Name: gq.Name, Actual: []string{}}
+
+	req := gq.Request
+	locations, err := s.recommendations.Replay(ctx, &req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	positions := make(map[string]int, gq.TopK)
+	for i, loc := range locations {
+		if i == gq.TopK {
+			break
+		}
+		result.Actual = append(result.Actual, loc.ID)
+		positions[loc.ID] = i + 1
+	}
+
+	result.Positions = make(map[string]int, len(gq.ExpectedIDs))
+	for _, id := range gq.ExpectedIDs {
+		if position, ok := positions[id]; ok {
+			result.Positions[id] = position
+		} else {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	result.Passed = len(result.Missing) == 0
+
+	return result
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// Индексы, настройки размещения которых можно изменять.
+const (
+	IndexLocations = "locations" // Основной индекс локаций
+	IndexArchive   = "archive"   // Архивный индекс холодных локаций
+)
+
+// dataTiers перечисляет уровни узлов данных Elasticsearch.
+var dataTiers = map[string]bool{
+	"data_content": true,
+	"data_hot":     true,
+	"data_warm":    true,
+	"data_cold":    true,
+	"data_frozen":  true,
+}
+
+// maxReplicas ограничивает число реплик, чтобы опечатка не перегрузила кластер копированием шардов.
+const maxReplicas = 5
+
+// IndexSettingsService управляет размещением индексов локаций в кластере: числом реплик
+// и предпочтительными уровнями узлов данных (hot, warm, cold), — чтобы операторы переносили
+// индексы на более дешевые узлы по мере устаревания данных без прямого доступа к кластеру.
+type IndexSettingsService struct {
+	esStorage *storage.ElasticsearchStorage
+}
+
+// NewIndexSettingsService создает новый экземпляр IndexSettingsService.
+func NewIndexSettingsService(esStorage *storage.ElasticsearchStorage) *IndexSettingsService {
+	return &IndexSettingsService{esStorage: esStorage}
+}
+
+// Get возвращает настройки индекса index: IndexLocations (по умолчанию) или IndexArchive.
+func (s *IndexSettingsService) Get(ctx context.Context, index string) (*models.IndexSettings, error) {
+	archived, err := parseSettingsIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	return s.esStorage.GetIndexSettings(ctx, archived)
+}
+
+// Update проверяет и применяет настройки индекса index и возвращает настройки после изменения.
+func (s *IndexSettingsService) Update(ctx context.Context, index string, settings *models.IndexSettings) (*models.IndexSettings, error) {
+	archived, err := parseSettingsIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	if settings.NumberOfReplicas == nil && settings.TierPreference == nil {
+		return nil, newValidationError("number_of_replicas or tier_preference is required")
+	}
+	if replicas := settings.NumberOfReplicas; replicas != nil && (*replicas < 0 || *replicas > maxReplicas) {
+		return nil, newValidationError("number_of_replicas must be between 0 and %d", maxReplicas)
+	}
+	if settings.TierPreference != nil && *settings.TierPreference != "" {
+		tiers := strings.Split(*settings.TierPreference, ",")
+		for i, tier := range tiers {
+			tiers[i] = strings.TrimSpace(tier)
+			if !dataTiers[tiers[i]] {
+				return nil, newValidationError("unknown data tier %q (available: data_content, data_hot, data_warm, data_cold, data_frozen)", tiers[i])
+			}
+		}
+		preference := strings.Join(tiers, ",")
+		settings.TierPreference = &preference
+	}
+
+	if err := s.esStorage.UpdateIndexSettings(ctx, archived, settings); err != nil {
+		return nil, err
+	}
+	// Изменение уже применено: если перечитать настройки не удалось, возвращаются переданные
+	applied, err := s.esStorage.GetIndexSettings(ctx, archived)
+	if err != nil {
+		slog.WarnContext(ctx, "Index settings updated but could not be read back", logging.Err(err))
+		return settings, nil
+	}
+	return applied, nil
+}
+
+// parseSettingsIndex сообщает, относится ли index к архивному индексу.
+func parseSettingsIndex(index string) (bool, error) {
+	switch index {
+	case "", IndexLocations:
+		return false, nil
+	case IndexArchive:
+		return true, nil
+	}
+	return false, newValidationError("unknown index %q (available: %s, %s)", index, IndexLocations, IndexArchive)
+}
@@ -0,0 +1,523 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// maxStatusCommentLength ограничивает длину комментария к переходу локации между состояниями.
+const maxStatusCommentLength = 1000
+
+// LocationService реализует операции с отдельными локациями.
+// Чтение выполняется из Elasticsearch, запись — в PostgreSQL (система-источник истины) через outbox,
+// из которого изменения доставляются в Elasticsearch relay-воркером.
+// Черновики и снятые с публикации локации видны только редакторам (см. SetEditorRole).
+type LocationService struct {
+	esStorage  storage.LocationSearcher
+	pgStorage  storage.LocationStore
+	maxIDs     int
+	editorRole string
+	profiles   *ValidationProfileService
+	references *ReferenceService
+}
+
+// NewLocationService создает новый экземпляр LocationService.
+// maxIDs ограничивает число ID в одном запросе нескольких локаций.
+func NewLocationService(esStorage storage.LocationSearcher, pgStorage storage.LocationStore, maxIDs int) *LocationService {
+	return &LocationService{
+		esStorage: esStorage,
+		pgStorage: pgStorage,
+		maxIDs:    maxIDs,
+	}
+}
+
+// SetEditorRole задает роль редакторов локаций: только они видят неопубликованные локации
+// и переводят локации между состояниями. Пустая роль и запросы без аутентификации
+// не ограничиваются.
+func (s *LocationService) SetEditorRole(role string) {
+	s.editorRole = role
+}
+
+// SetValidationProfiles включает проверку создаваемых и изменяемых локаций по профилям
+// проверки их типов бизнеса.
+func (s *LocationService) SetValidationProfiles(profiles *ValidationProfileService) {
+	s.profiles = profiles
+}
+
+// SetReferences включает проверку типов бизнеса и города локаций по справочникам.
+func (s *LocationService) SetReferences(references *ReferenceService) {
+	s.references = references
+}
+
+// businessTypes возвращает множество известных типов бизнеса или nil, если проверка не включена.
+func (s *LocationService) businessTypes(ctx context.Context) (map[string]bool, error) {
+	if s.references == nil {
+		return nil, nil
+	}
+	return s.references.BusinessTypeNames(ctx)
+}
+
+// knownCities возвращает города справочника для проверки локаций или nil, если справочник не подключен.
+func (s *LocationService) knownCities(ctx context.Context) (models.KnownCities, error) {
+	if s.references == nil {
+		return nil, nil
+	}
+	return s.references.KnownCities(ctx)
+}
+
+// checkProfiles проверяет локацию по профилям проверки, если они включены.
+func (s *LocationService) checkProfiles(ctx context.Context, location *models.Location) error {
+	if s.profiles == nil {
+		return nil
+	}
+	return s.profiles.Check(ctx, location)
+}
+
+// canEdit сообщает, является ли клиент запроса редактором с ролью role.
+func canEdit(ctx context.Context, role string) bool {
+	if role == "" {
+		return true
+	}
+	principal, ok := auth.FromContext(ctx)
+	return !ok || principal.HasRole(role)
+}
+
+// visible сообщает, видна ли локация клиенту запроса.
+func (s *LocationService) visible(ctx context.Context, location *models.Location) bool {
+	return location.Published() || canEdit(ctx, s.editorRole)
+}
+
+// Get возвращает локацию по ID или ErrNotFound, если она отсутствует или не видна клиенту.
+// При includeArchived = true локация, не найденная в основном индексе, ищется в архиве.
+func (s *LocationService) Get(ctx context.Context, id string, includeArchived bool) (*models.Location, error) {
+	if id == "" {
+		return nil, newValidationError("Location ID is required")
+	}
+
+	location, err := s.esStorage.GetLocation(ctx, id)
+	if errors.Is(err, storage.ErrLocationNotFound) && includeArchived {
+		location, err = s.esStorage.GetArchivedLocation(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrLocationNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !s.visible(ctx, location) {
+		return nil, ErrNotFound
+	}
+
+	return location, nil
+}
+
+// GetMany возвращает локации по списку ID одним запросом: найденные — в порядке запроса,
+// отсутствующие и невидимые клиенту — списком ID. Повторяющиеся ID учитываются один раз.
+func (s *LocationService) GetMany(ctx context.Context, req *models.MultiGetRequest) (*models.MultiGetResponse, error) {
+	ids, err := s.checkIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.esStorage.MultiGetLocations(ctx, ids, req.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.MultiGetResponse{
+		Found:   make([]models.Location, 0, len(found)),
+		Missing: []string{},
+	}
+	for _, id := range ids {
+		if loc, ok := found[id]; ok && s.visible(ctx, loc) {
+			response.Found = append(response.Found, *loc)
+		} else {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+
+	return response, nil
+}
+
+// Exists сообщает, существует ли локация, не получая ее документ.
+func (s *LocationService) Exists(ctx context.Context, id string, includeArchived bool) (bool, error) {
+	if id == "" {
+		return false, newValidationError("Location ID is required")
+	}
+	exists, err := s.exist(ctx, []string{id}, includeArchived)
+	if err != nil {
+		return false, err
+	}
+	return exists[id], nil
+}
+
+// ValidateRefs проверяет ссылки внешних систем на локации: какие из ID существуют.
+// Документы локаций не передаются, поэтому проверка дешевле GetMany.
+func (s *LocationService) ValidateRefs(ctx context.Context, req *models.MultiGetRequest) (*models.ValidateRefsResponse, error) {
+	ids, err := s.checkIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.exist(ctx, ids, req.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ValidateRefsResponse{Existing: []string{}, Missing: []string{}}
+	for _, id := range ids {
+		if exists[id] {
+			response.Existing = append(response.Existing, id)
+		} else {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+
+	return response, nil
+}
+
+// exist сообщает, какие из локаций существуют и видны клиенту. Для редакторов документы
+// не запрашиваются; остальным клиентам неопубликованные локации не видны, поэтому
+// проверяется состояние документов.
+func (s *LocationService) exist(ctx context.Context, ids []string, includeArchived bool) (map[string]bool, error) {
+	if canEdit(ctx, s.editorRole) {
+		return s.esStorage.LocationsExist(ctx, ids, includeArchived)
+	}
+
+	found, err := s.esStorage.MultiGetLocations(ctx, ids, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(found))
+	for id, location := range found {
+		exists[id] = location.Published()
+	}
+	return exists, nil
+}
+
+// checkIDs проверяет список ID запроса нескольких локаций и возвращает его без повторов.
+func (s *LocationService) checkIDs(ids []string) ([]string, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, newValidationError("ids must not be empty")
+	}
+	if len(ids) > s.maxIDs {
+		return nil, newValidationError("ids must not contain more than %d items", s.maxIDs)
+	}
+	for _, id := range ids {
+		if id == "" {
+			return nil, newValidationError("ids must not contain empty values")
+		}
+	}
+	return ids, nil
+}
+
+// uniqueIDs возвращает ID без повторов в исходном порядке.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Save создает или обновляет локацию. Изменение фиксируется в PostgreSQL вместе с записью outbox
+// и становится видимым в поиске после доставки relay-воркером.
+func (s *LocationService) Save(ctx context.Context, location *models.Location) error {
+	if location.ID == "" {
+		return newValidationError("Location ID is required")
+	}
+
+	now := time.Now()
+	if location.CreatedAt.IsZero() {
+		location.CreatedAt = now
+	}
+	location.UpdatedAt = now
+
+	return s.pgStorage.SaveLocation(ctx, location)
+}
+
+// Create создает локацию через API. Без ID локации присваивается сгенерированный ID;
+// ErrConflict возвращается, если локация с таким ID уже есть. Локация создается опубликованной
+// или черновиком. Создавать локации могут только редакторы (иначе ErrForbidden). Как и Save,
+// изменение становится видимым в поиске после доставки relay-воркером.
+func (s *LocationService) Create(ctx context.Context, location *models.Location) error {
+	if !canEdit(ctx, s.editorRole) {
+		return ErrForbidden
+	}
+	if location.ID == "" {
+		location.ID = newID()
+	}
+	businessTypes, err := s.businessTypes(ctx)
+	if err != nil {
+		return err
+	}
+	cities, err := s.knownCities(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateLocation(location, businessTypes, cities); err != nil {
+		return err
+	}
+	if err := s.checkProfiles(ctx, location); err != nil {
+		return err
+	}
+	switch location.Status {
+	case "":
+		location.Status = models.LocationPublished
+	case models.LocationRetired:
+		return newValidationError("status of a new location must be %q or %q", models.LocationDraft, models.LocationPublished)
+	}
+
+	now := time.Now()
+	location.CreatedAt = now
+	location.UpdatedAt = now
+	location.RecordDemographics(now)
+
+	if err := s.pgStorage.CreateLocation(ctx, location); err != nil {
+		if errors.Is(err, storage.ErrLocationExists) {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// Update заменяет локацию id данными location, сохраняя время создания и состояние жизненного
+// цикла (оно меняется только через Transition). Доступно только редакторам (иначе ErrForbidden).
+// Возвращает ErrNotFound, если локация отсутствует.
+func (s *LocationService) Update(ctx context.Context, id string, location *models.Location) error {
+	if !canEdit(ctx, s.editorRole) {
+		return ErrForbidden
+	}
+	if id == "" {
+		return newValidationError("Location ID is required")
+	}
+	if location.ID != "" && location.ID != id {
+		return newValidationError("id in body must match location ID %q", id)
+	}
+	location.ID = id
+	businessTypes, err := s.businessTypes(ctx)
+	if err != nil {
+		return err
+	}
+	cities, err := s.knownCities(ctx)
+	if err != nil {
+		return err
+	}
+	if cities != nil {
+		previous, err := s.pgStorage.GetStoredLocations(ctx, []string{id})
+		if err != nil {
+			return err
+		}
+		cities = citiesToCheck(cities, previous[id], location)
+	}
+	if err := validateLocation(location, businessTypes, cities); err != nil {
+		return err
+	}
+	if err := s.checkProfiles(ctx, location); err != nil {
+		return err
+	}
+	location.UpdatedAt = time.Now()
+
+	if err := s.pgStorage.UpdateLocation(ctx, location); err != nil {
+		if errors.Is(err, storage.ErrLocationNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// citiesToCheck возвращает справочник городов для проверки локации location, заменяющей previous
+// (nil — новая локация), или nil, если регион и город не изменились: город, удаленный из
+// справочника после записи локации, не мешает изменять ее остальные поля.
+func citiesToCheck(cities models.KnownCities, previous, location *models.Location) models.KnownCities {
+	if previous != nil &&
+		models.NormalizeCityName(previous.Region) == models.NormalizeCityName(location.Region) &&
+		models.NormalizeCityName(previous.City) == models.NormalizeCityName(location.City) {
+		return nil
+	}
+	return cities
+}
+
+// validateLocation проверяет локацию, записываемую через API, и сбрасывает поля,
+// которые вычисляются при поиске и не хранятся в документе. Типы бизнеса сверяются
+// с businessTypes, а город — с cities, если справочники переданы. Ошибка валидации
+// перечисляет все нарушения по полям.
+func validateLocation(location *models.Location, businessTypes map[string]bool, cities models.KnownCities) error {
+	var v fieldErrors
+	if strings.TrimSpace(location.Name) == "" {
+		v.add("name", "is required")
+	}
+	if strings.TrimSpace(location.Region) == "" {
+		v.add("region", "is required")
+	}
+	v.geoPoint("coordinates", location.Coordinates)
+	if location.Status != "" && !models.IsLocationStatus(location.Status) {
+		v.add("status", "must be one of %q, %q, %q", models.LocationDraft, models.LocationPublished, models.LocationRetired)
+	}
+	if location.PublishAt != nil && location.ExpireAt != nil && !location.ExpireAt.After(*location.PublishAt) {
+		v.add("expire_at", "must be after publish_at")
+	}
+	if businessTypes != nil {
+		for i, businessType := range location.BusinessTypesSuitable {
+			if !businessTypes[businessType] {
+				v.add(fmt.Sprintf("business_types_suitable[%d]", i), "is not a known business type: %q", businessType)
+			}
+		}
+	}
+	if cities != nil && !cities.Allows(location.Region, location.City) {
+		v.add("city", "is not a known city of region %q: %q", location.Region, location.City)
+	}
+	v.score("traffic_score", location.TrafficScore)
+	v.nonNegative("competition_density", location.CompetitionDensity)
+	v.score("event_exposure", location.EventExposure)
+	v.score("street_parking_score", location.StreetParkingScore)
+	v.score("safety_score", location.SafetyScore)
+	v.nonNegative("schools_nearby", float64(location.SchoolsNearby))
+	v.nonNegative("universities_nearby", float64(location.UniversitiesNearby))
+	v.nonNegative("paid_lots_nearby", float64(location.PaidLotsNearby))
+	v.nonNegative("demographics.average_income", location.Demographics.AverageIncome)
+	v.nonNegative("demographics.population_density", location.Demographics.PopulationDensity)
+	for month, coefficient := range location.Seasonality {
+		if month < 1 || month > 12 {
+			v.add("seasonality", "months must be between 1 and 12, got %d", month)
+		}
+		v.nonNegative(fmt.Sprintf("seasonality.%d", month), coefficient)
+	}
+	validateSegments(&v, "demographics.segments", location.Demographics.Segments)
+	for i := range location.DemographicsHistory {
+		snapshot := &location.DemographicsHistory[i]
+		field := fmt.Sprintf("demographics_history[%d]", i)
+		if snapshot.EffectiveFrom.IsZero() {
+			v.add(field+".effective_from", "is required")
+		} else if snapshot.EffectiveFrom.After(time.Now()) {
+			v.add(field+".effective_from", "must not be in the future")
+		}
+		if i > 0 && !snapshot.EffectiveFrom.After(location.DemographicsHistory[i-1].EffectiveFrom) {
+			v.add(field+".effective_from", "must be after the previous version (ordered without duplicates)")
+		}
+		validateSegments(&v, field+".demographics.segments", snapshot.Demographics.Segments)
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	location.Demographics.Summarize()
+	location.DemographicEmbedding = embedding.DemographicVector(location.Demographics)
+	for i := range location.DemographicsHistory {
+		location.DemographicsHistory[i].Demographics.Summarize()
+	}
+
+	location.Score = 0
+	location.Archived = false
+	location.TravelTimeSeconds = 0
+	location.ExpectedDailyVisitors = 0
+	location.MatchedSegments = nil
+	return nil
+}
+
+// validateSegments проверяет демографические сегменты; field — имя поля в нарушениях.
+func validateSegments(v *fieldErrors, field string, segments []models.DemographicSegment) {
+	var shares float64
+	for i, segment := range segments {
+		name := fmt.Sprintf("%s[%d]", field, i)
+		if segment.AgeGroup == "" {
+			v.add(name+".age_group", "is required")
+		}
+		if segment.Share < 0 || segment.Share > 1 {
+			v.add(name+".share", "must be between 0 and 1")
+		}
+		v.nonNegative(name+".average_income", segment.AverageIncome)
+		shares += segment.Share
+	}
+	// Доли округляются источниками данных, небольшое превышение единицы допустимо
+	if shares > 1.01 {
+		v.add(field, "shares must not sum to more than 1")
+	}
+}
+
+// Transition переводит локацию id в состояние req.Status и записывает переход в журнал с автором
+// запроса. Доступно только редакторам (иначе ErrForbidden); недопустимый переход — ошибка валидации.
+// Как и запись локации, новое состояние применяется в поиске после доставки relay-воркером.
+func (s *LocationService) Transition(ctx context.Context, id string, req *models.LocationStatusRequest) (*models.LocationStatusTransition, error) {
+	if !canEdit(ctx, s.editorRole) {
+		return nil, ErrForbidden
+	}
+	if id == "" {
+		return nil, newValidationError("Location ID is required")
+	}
+	if !models.IsLocationStatus(req.Status) {
+		return nil, newValidationError("status must be one of %q, %q, %q", models.LocationDraft, models.LocationPublished, models.LocationRetired)
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if len([]rune(comment)) > maxStatusCommentLength {
+		return nil, newValidationError("comment must not be longer than %d characters", maxStatusCommentLength)
+	}
+
+	transition := &models.LocationStatusTransition{
+		LocationID: id,
+		To:         req.Status,
+		Comment:    comment,
+	}
+	if principal, ok := auth.FromContext(ctx); ok {
+		transition.Actor = principal.Subject
+		transition.Organization = principal.Organization
+	}
+
+	err := s.pgStorage.TransitionLocationStatus(ctx, transition)
+	if errors.Is(err, storage.ErrLocationNotFound) {
+		return nil, ErrNotFound
+	}
+	if errors.Is(err, storage.ErrLocationStatusTransition) {
+		return nil, newValidationError("location cannot transition from %q to %q", transition.From, transition.To)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transition, nil
+}
+
+// StatusHistory возвращает журнал переходов локации между состояниями. Доступно только редакторам.
+func (s *LocationService) StatusHistory(ctx context.Context, id string) ([]models.LocationStatusTransition, error) {
+	if !canEdit(ctx, s.editorRole) {
+		return nil, ErrForbidden
+	}
+	if id == "" {
+		return nil, newValidationError("Location ID is required")
+	}
+	return s.pgStorage.ListLocationStatusTransitions(ctx, id)
+}
+
+// Delete удаляет локацию или возвращает ErrNotFound, если она отсутствует. Доступно только
+// редакторам (иначе ErrForbidden).
+func (s *LocationService) Delete(ctx context.Context, id string) error {
+	if !canEdit(ctx, s.editorRole) {
+		return ErrForbidden
+	}
+	if id == "" {
+		return newValidationError("Location ID is required")
+	}
+
+	if err := s.pgStorage.DeleteLocation(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrLocationNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
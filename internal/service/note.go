@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// maxNoteLength ограничивает длину текста заметки в символах.
+const maxNoteLength = 4000
+
+// NoteService реализует заметки и оценки локаций пользователями.
+// Заметки видны всем пользователям организации автора; изменять и удалять их может только автор.
+type NoteService struct {
+	locations *LocationService
+	pgStorage *storage.PostgresStorage
+}
+
+// NewNoteService создает новый экземпляр NoteService.
+func NewNoteService(locations *LocationService, pgStorage *storage.PostgresStorage) *NoteService {
+	return &NoteService{
+		locations: locations,
+		pgStorage: pgStorage,
+	}
+}
+
+// List возвращает заметки организации пользователя о локации.
+func (s *NoteService) List(ctx context.Context, locationID string) ([]models.Note, error) {
+	principal, err := notePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.pgStorage.ListNotes(ctx, locationID, principal.Organization)
+}
+
+// Details возвращает локацию вместе с заметками и средней оценкой организации пользователя.
+// Для неаутентифицированного запроса и клиента без организации возвращается только локация.
+func (s *NoteService) Details(ctx context.Context, location *models.Location) (*models.LocationDetails, error) {
+	details := &models.LocationDetails{Location: *location}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.Organization == "" {
+		return details, nil
+	}
+
+	notes, err := s.pgStorage.ListNotes(ctx, location.ID, principal.Organization)
+	if err != nil {
+		return nil, err
+	}
+	details.Notes = notes
+	details.Rating = summarizeRatings(notes)
+
+	return details, nil
+}
+
+// Create добавляет заметку к существующей локации от имени пользователя.
+func (s *NoteService) Create(ctx context.Context, locationID string, req *models.NoteRequest) (*models.Note, error) {
+	principal, err := notePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateNote(req); err != nil {
+		return nil, err
+	}
+
+	// Заметку можно оставить и к архивной локации
+	if _, err := s.locations.Get(ctx, locationID, true); err != nil {
+		return nil, err
+	}
+
+	note := &models.Note{
+		LocationID:   locationID,
+		Organization: principal.Organization,
+		Author:       principal.Subject,
+		Text:         strings.TrimSpace(req.Text),
+		Rating:       req.Rating,
+	}
+	if err := s.pgStorage.CreateNote(ctx, note); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// Update изменяет заметку. Изменять заметку может только ее автор.
+func (s *NoteService) Update(ctx context.Context, locationID string, id int64, req *models.NoteRequest) (*models.Note, error) {
+	note, err := s.authorNote(ctx, locationID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateNote(req); err != nil {
+		return nil, err
+	}
+
+	note.Text = strings.TrimSpace(req.Text)
+	note.Rating = req.Rating
+	if err := s.pgStorage.UpdateNote(ctx, note); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// Delete удаляет заметку. Удалять заметку может только ее автор.
+func (s *NoteService) Delete(ctx context.Context, locationID string, id int64) error {
+	if _, err := s.authorNote(ctx, locationID, id); err != nil {
+		return err
+	}
+
+	if err := s.pgStorage.DeleteNote(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// authorNote загружает заметку и проверяет, что текущий пользователь — ее автор.
+// Заметки других организаций неотличимы от отсутствующих.
+func (s *NoteService) authorNote(ctx context.Context, locationID string, id int64) (*models.Note, error) {
+	principal, err := notePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.pgStorage.GetNote(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if note.LocationID != locationID || note.Organization != principal.Organization {
+		return nil, ErrNotFound
+	}
+	if note.Author != principal.Subject {
+		return nil, ErrForbidden
+	}
+
+	return note, nil
+}
+
+// notePrincipal возвращает клиента, работающего с заметками. Заметки разделены по организациям,
+// поэтому клиенту без организации они недоступны: иначе все такие клиенты видели бы заметки
+// друг друга.
+func notePrincipal(ctx context.Context) (*auth.Principal, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if principal.Organization == "" {
+		return nil, ErrForbidden
+	}
+	return principal, nil
+}
+
+func validateNote(req *models.NoteRequest) error {
+	text := strings.TrimSpace(req.Text)
+	if text == "" && req.Rating == nil {
+		return newValidationError("text or rating is required")
+	}
+	if utf8.RuneCountInString(text) > maxNoteLength {
+		return newValidationError("text must not exceed %d characters", maxNoteLength)
+	}
+	if req.Rating != nil && (*req.Rating < 1 || *req.Rating > 5) {
+		return newValidationError("rating must be between 1 and 5")
+	}
+	return nil
+}
+
+// summarizeRatings вычисляет среднюю оценку по заметкам с оценкой.
+// Возвращает nil, если оценок нет.
+func summarizeRatings(notes []models.Note) *models.RatingSummary {
+	var sum, count int
+	for _, note := range notes {
+		if note.Rating != nil {
+			sum += *note.Rating
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return &models.RatingSummary{
+		Average: float64(sum) / float64(count),
+		Count:   count,
+	}
+}
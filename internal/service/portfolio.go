@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// maxPortfolioRegions ограничивает число регионов в запросе портфеля: на каждый выполняется отдельный поиск.
+const maxPortfolioRegions = 20
+
+// portfolioCandidate — кандидат в портфель вместе с ограничениями его региона.
+type portfolioCandidate struct {
+	location models.Location
+	region   int
+	cost     float64
+}
+
+// Portfolio подбирает до req.Outlets локаций в нескольких регионах, максимизируя суммарную оценку
+// при ограничениях на бюджет, число точек в регионе и взаимное расстояние.
+//
+// Кандидаты каждого региона берутся из обычного поиска рекомендаций. Поиск нормализует оценки
+// по лучшей локации региона, и такие оценки нельзя сравнивать между регионами, поэтому портфель
+// ранжирует и суммирует исходные оценки Elasticsearch: запросы регионов отличаются только
+// фильтрами, и их оценки вычисляются одинаково. Отбор жадный: при заданном бюджете кандидаты
+// перебираются по убыванию оценки на единицу стоимости, иначе — по убыванию оценки.
+func (s *RecommendationService) Portfolio(ctx context.Context, req *models.PortfolioRequest) (*models.PortfolioResponse, error) {
+	if err := s.validatePortfolio(req); err != nil {
+		return nil, err
+	}
+
+	poolSize := req.Outlets * candidatePoolFactor
+	if poolSize > maxCandidatePool {
+		poolSize = maxCandidatePool
+	}
+
+	var candidates []portfolioCandidate
+	for i, region := range req.Regions {
+		page, err := s.searchPage(ctx, &models.RecommendRequest{
+			Region:       region.Region,
+			City:         region.City,
+			BusinessType: req.BusinessType,
+			Limit:        poolSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Локации страницы общие с кешем поиска: оценка меняется у копии
+		for _, loc := range page.Locations {
+			if page.MaxScore != 0 {
+				loc.Score *= page.MaxScore
+			}
+			candidates = append(candidates, portfolioCandidate{location: loc, region: i, cost: region.OutletCost})
+		}
+	}
+
+	byValue := req.Budget > 0
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if byValue {
+			return betterValue(candidates[i], candidates[j])
+		}
+		return candidates[i].location.Score > candidates[j].location.Score
+	})
+
+	response := &models.PortfolioResponse{Locations: []models.PortfolioItem{}}
+	perRegion := make([]int, len(req.Regions))
+	seen := make(map[string]bool)
+	var points []models.GeoPoint
+	for _, c := range candidates {
+		if response.Outlets == req.Outlets {
+			break
+		}
+		if seen[c.location.ID] {
+			continue
+		}
+		if limit := req.Regions[c.region].MaxOutlets; limit > 0 && perRegion[c.region] >= limit {
+			continue
+		}
+		if req.Budget > 0 && response.TotalCost+c.cost > req.Budget {
+			continue
+		}
+		if tooClose(c.location.Coordinates, points, req.MinDistanceMeters) {
+			continue
+		}
+
+		seen[c.location.ID] = true
+		points = append(points, c.location.Coordinates)
+		perRegion[c.region]++
+		response.Locations = append(response.Locations, models.PortfolioItem{Location: c.location, Cost: c.cost})
+		response.Outlets++
+		response.TotalScore += c.location.Score
+		response.TotalCost += c.cost
+	}
+	response.Complete = response.Outlets == req.Outlets
+
+	return response, nil
+}
+
+// validatePortfolio проверяет запрос портфеля.
+func (s *RecommendationService) validatePortfolio(req *models.PortfolioRequest) error {
+	if req.BusinessType == "" {
+		return newValidationError("business_type is required")
+	}
+	if len(req.Regions) == 0 {
+		return newValidationError("at least one region is required")
+	}
+	if len(req.Regions) > maxPortfolioRegions {
+		return newValidationError("regions must not exceed %d", maxPortfolioRegions)
+	}
+	if req.Outlets <= 0 {
+		return newValidationError("outlets must be positive")
+	}
+	if req.Outlets > s.maxLimit {
+		return newValidationError("outlets must not exceed %d", s.maxLimit)
+	}
+	if req.Budget < 0 || req.MinDistanceMeters < 0 {
+		return newValidationError("budget and min_distance_meters must not be negative")
+	}
+	for _, region := range req.Regions {
+		if region.Region == "" {
+			return newValidationError("region is required for every portfolio region")
+		}
+		if region.MaxOutlets < 0 || region.OutletCost < 0 {
+			return newValidationError("max_outlets and outlet_cost must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// betterValue сравнивает кандидатов по оценке на единицу стоимости. Бесплатные кандидаты идут первыми.
+func betterValue(a, b portfolioCandidate) bool {
+	if (a.cost == 0) != (b.cost == 0) {
+		return a.cost == 0
+	}
+	if a.cost == 0 {
+		return a.location.Score > b.location.Score
+	}
+	return a.location.Score/a.cost > b.location.Score/b.cost
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// ProjectService реализует проекты подбора локаций: шорт-лист кандидатов и их статусы.
+// Проект доступен всем пользователям организации, в которой он создан.
+type ProjectService struct {
+	esStorage *storage.ElasticsearchStorage
+	pgStorage *storage.PostgresStorage
+	locations *LocationService
+}
+
+// NewProjectService создает новый экземпляр ProjectService.
+func NewProjectService(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, locations *LocationService) *ProjectService {
+	return &ProjectService{
+		esStorage: esStorage,
+		pgStorage: pgStorage,
+		locations: locations,
+	}
+}
+
+// List возвращает проекты организации пользователя.
+func (s *ProjectService) List(ctx context.Context) ([]models.Project, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return s.pgStorage.ListProjects(ctx, principal.Organization)
+}
+
+// Create создает проект в организации пользователя.
+func (s *ProjectService) Create(ctx context.Context, req *models.ProjectRequest) (*models.Project, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if err := validateProject(req); err != nil {
+		return nil, err
+	}
+
+	project := &models.Project{
+		ID:           newID(),
+		Organization: principal.Organization,
+		Owner:        principal.Subject,
+		Name:         strings.TrimSpace(req.Name),
+		BusinessType: req.BusinessType,
+		Region:       req.Region,
+		Budget:       req.Budget,
+	}
+	if err := s.pgStorage.CreateProject(ctx, project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// Update изменяет параметры проекта.
+func (s *ProjectService) Update(ctx context.Context, id string, req *models.ProjectRequest) (*models.Project, error) {
+	project, err := s.project(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateProject(req); err != nil {
+		return nil, err
+	}
+
+	project.Name = strings.TrimSpace(req.Name)
+	project.BusinessType = req.BusinessType
+	project.Region = req.Region
+	project.Budget = req.Budget
+	if err := s.pgStorage.UpdateProject(ctx, project); err != nil {
+		return nil, mapProjectError(err)
+	}
+
+	return project, nil
+}
+
+// Delete удаляет проект. Удалить проект может только его владелец.
+func (s *ProjectService) Delete(ctx context.Context, id string) error {
+	project, err := s.project(ctx, id)
+	if err != nil {
+		return err
+	}
+	principal, _ := auth.FromContext(ctx)
+	if project.Owner != principal.Subject {
+		return ErrForbidden
+	}
+
+	return mapProjectError(s.pgStorage.DeleteProject(ctx, id))
+}
+
+// Summary возвращает проект с кандидатами, данными их локаций и количеством кандидатов по статусам.
+func (s *ProjectService) Summary(ctx context.Context, id string) (*models.ProjectSummary, error) {
+	project, err := s.project(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.pgStorage.ListCandidates(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.LocationID
+	}
+	locations, err := s.esStorage.GetLocationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ProjectSummary{
+		Project:      *project,
+		Candidates:   candidates,
+		StatusCounts: make(map[models.CandidateStatus]int),
+	}
+	for i := range summary.Candidates {
+		candidate := &summary.Candidates[i]
+		candidate.Location = locations[candidate.LocationID]
+		summary.StatusCounts[candidate.Status]++
+	}
+
+	return summary, nil
+}
+
+// AddCandidate добавляет существующую локацию в проект.
+func (s *ProjectService) AddCandidate(ctx context.Context, projectID string, req *models.CandidateRequest) (*models.Candidate, error) {
+	if _, err := s.project(ctx, projectID); err != nil {
+		return nil, err
+	}
+	if req.LocationID == "" {
+		return nil, newValidationError("location_id is required")
+	}
+	if req.Status == "" {
+		req.Status = models.CandidateReviewing
+	}
+	if !req.Status.Valid() {
+		return nil, newValidationError("invalid status: %q", req.Status)
+	}
+
+	location, err := s.locations.Get(ctx, req.LocationID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	candidate := &models.Candidate{
+		ProjectID:  projectID,
+		LocationID: req.LocationID,
+		Status:     req.Status,
+		Comment:    req.Comment,
+		AddedBy:    principal.Subject,
+		Location:   location,
+	}
+	if err := s.pgStorage.AddCandidate(ctx, candidate); err != nil {
+		if errors.Is(err, storage.ErrCandidateExists) {
+			return nil, ErrConflict
+		}
+		return nil, err
+	}
+
+	return candidate, nil
+}
+
+// UpdateCandidate изменяет статус и комментарий кандидата.
+func (s *ProjectService) UpdateCandidate(ctx context.Context, projectID, locationID string, req *models.CandidateRequest) (*models.Candidate, error) {
+	if _, err := s.project(ctx, projectID); err != nil {
+		return nil, err
+	}
+	if !req.Status.Valid() {
+		return nil, newValidationError("invalid status: %q", req.Status)
+	}
+
+	candidate := &models.Candidate{
+		ProjectID:  projectID,
+		LocationID: locationID,
+		Status:     req.Status,
+		Comment:    req.Comment,
+	}
+	if err := s.pgStorage.UpdateCandidate(ctx, candidate); err != nil {
+		return nil, mapProjectError(err)
+	}
+
+	return candidate, nil
+}
+
+// RemoveCandidate удаляет локацию из проекта.
+func (s *ProjectService) RemoveCandidate(ctx context.Context, projectID, locationID string) error {
+	if _, err := s.project(ctx, projectID); err != nil {
+		return err
+	}
+	return mapProjectError(s.pgStorage.RemoveCandidate(ctx, projectID, locationID))
+}
+
+// project загружает проект и проверяет, что он принадлежит организации пользователя.
+// Проекты других организаций неотличимы от отсутствующих.
+func (s *ProjectService) project(ctx context.Context, id string) (*models.Project, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	project, err := s.pgStorage.GetProject(ctx, id)
+	if err != nil {
+		return nil, mapProjectError(err)
+	}
+	if project.Organization != principal.Organization {
+		return nil, ErrNotFound
+	}
+
+	return project, nil
+}
+
+func validateProject(req *models.ProjectRequest) error {
+	if strings.TrimSpace(req.Name) == "" || req.BusinessType == "" || req.Region == "" {
+		return newValidationError("name, business_type and region are required")
+	}
+	if req.Budget != nil && *req.Budget < 0 {
+		return newValidationError("budget must not be negative")
+	}
+	return nil
+}
+
+// mapProjectError преобразует ошибки хранилища проектов в ошибки сервисного слоя.
+func mapProjectError(err error) error {
+	if errors.Is(err, storage.ErrProjectNotFound) || errors.Is(err, storage.ErrCandidateNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
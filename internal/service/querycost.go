@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
+	"github.com/akozadaev/go_es_analytical_system/internal/querycost"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// anonymousSubject — клиент, которому относится стоимость запросов при отключенной аутентификации.
+const anonymousSubject = "anonymous"
+
+// ParseCostBudgets разбирает дневные бюджеты клиентов из записей вида "subject:budget".
+func ParseCostBudgets(entries []string) (map[string]float64, error) {
+	budgets := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		subject, value, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(subject) == "" {
+			return nil, fmt.Errorf("invalid query cost budget %q, expected subject:budget", entry)
+		}
+		budget, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || budget < 0 {
+			return nil, fmt.Errorf("invalid query cost budget %q, expected subject:budget", entry)
+		}
+		budgets[strings.TrimSpace(subject)] = budget
+	}
+	return budgets, nil
+}
+
+// CostService учитывает стоимость запросов к Elasticsearch по клиентам API и отправляет алерт,
+// когда дневная стоимость клиента превышает его бюджет. Показатели накапливаются в памяти
+// и периодически записываются в PostgreSQL (Flush).
+type CostService struct {
+	pgStorage     *storage.PostgresStorage
+	notifier      notify.Notifier
+	budgets       map[string]float64 // Бюджеты клиентов по subject
+	defaultBudget float64
+
+	mu      sync.Mutex
+	pending map[costKey]*models.QueryCostUsage
+}
+
+// costKey идентифицирует дневную запись клиента.
+type costKey struct {
+	subject string
+	day     string
+}
+
+// NewCostService создает новый экземпляр CostService. budgets задает дневные бюджеты клиентов
+// по subject, defaultBudget — бюджет остальных клиентов (0 — без ограничения).
+func NewCostService(pgStorage *storage.PostgresStorage, notifier notify.Notifier, budgets map[string]float64, defaultBudget float64) *CostService {
+	return &CostService{
+		pgStorage:     pgStorage,
+		notifier:      notifier,
+		budgets:       budgets,
+		defaultBudget: defaultBudget,
+		pending:       make(map[costKey]*models.QueryCostUsage),
+	}
+}
+
+// Record учитывает стоимость запросов к Elasticsearch, выполненных при обработке одного запроса API.
+// principal — клиент запроса (nil при отключенной аутентификации). Запросы без поиска не учитываются.
+func (s *CostService) Record(principal *auth.Principal, usage querycost.Usage) {
+	if usage.Searches == 0 {
+		return
+	}
+	key := costKey{subject: anonymousSubject, day: time.Now().UTC().Format("2006-01-02")}
+	organization := ""
+	if principal != nil {
+		key.subject, organization = principal.Subject, principal.Organization
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.pending[key]
+	if !ok {
+		entry = &models.QueryCostUsage{Subject: key.subject, Day: key.day}
+		s.pending[key] = entry
+	}
+	entry.Organization = organization
+	entry.Requests++
+	entry.Searches += usage.Searches
+	entry.TookMs += usage.TookMs
+	entry.Hits += usage.Hits
+	entry.Buckets += usage.Buckets
+	entry.Cost += usage.Cost()
+}
+
+// Flush записывает накопленные показатели в PostgreSQL и проверяет бюджеты клиентов.
+// Алерт о превышении отправляется один раз за день на клиента, в том числе при нескольких экземплярах.
+// Показатели, которые не удалось записать, возвращаются в очередь.
+func (s *CostService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[costKey]*models.QueryCostUsage)
+	s.mu.Unlock()
+
+	var firstErr error
+	for key, entry := range pending {
+		total, err := s.pgStorage.AddQueryCost(ctx, entry)
+		if err != nil {
+			s.requeue(key, entry)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		budget := s.budget(entry.Subject)
+		if budget <= 0 || total <= budget {
+			continue
+		}
+		first, err := s.pgStorage.MarkQueryCostAlerted(ctx, entry.Subject, entry.Day)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error marking query cost alert", "subject", entry.Subject, logging.Err(err))
+			continue
+		}
+		if first {
+			s.alert(ctx, entry, total, budget)
+		}
+	}
+
+	return firstErr
+}
+
+// requeue возвращает незаписанные показатели в очередь, объединяя их с накопленными за это время.
+func (s *CostService) requeue(key costKey, entry *models.QueryCostUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.pending[key]
+	if !ok {
+		s.pending[key] = entry
+		return
+	}
+	current.Requests += entry.Requests
+	current.Searches += entry.Searches
+	current.TookMs += entry.TookMs
+	current.Hits += entry.Hits
+	current.Buckets += entry.Buckets
+	current.Cost += entry.Cost
+}
+
+func (s *CostService) alert(ctx context.Context, entry *models.QueryCostUsage, total, budget float64) {
+	err := s.notifier.Notify(ctx, notify.Alert{
+		Source:   "query_cost",
+		Severity: notify.SeverityWarning,
+		Title:    "Query cost budget exceeded",
+		Message: fmt.Sprintf("Client %s exceeded daily query cost budget: %.0f of %.0f on %s",
+			entry.Subject, total, budget, entry.Day),
+		Details: map[string]interface{}{
+			"subject":      entry.Subject,
+			"organization": entry.Organization,
+			"day":          entry.Day,
+			"cost":         total,
+			"budget":       budget,
+		},
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending query cost alert", logging.Err(err))
+	}
+}
+
+// budget возвращает дневной бюджет клиента (0 — без ограничения).
+func (s *CostService) budget(subject string) float64 {
+	if budget, ok := s.budgets[subject]; ok {
+		return budget
+	}
+	return s.defaultBudget
+}
+
+// Usage возвращает дневную стоимость запросов за период [from, to] (даты YYYY-MM-DD) с бюджетами
+// клиентов. Пустой subject — все клиенты; пустые даты — последние 7 дней.
+func (s *CostService) Usage(ctx context.Context, subject, from, to string) ([]models.QueryCostUsage, error) {
+	now := time.Now().UTC()
+	if to == "" {
+		to = now.Format("2006-01-02")
+	}
+	if from == "" {
+		from = now.AddDate(0, 0, -6).Format("2006-01-02")
+	}
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, newValidationError("invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	usages, err := s.pgStorage.ListQueryCosts(ctx, subject, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range usages {
+		usages[i].Budget = s.budget(usages[i].Subject)
+	}
+	if usages == nil {
+		usages = []models.QueryCostUsage{}
+	}
+
+	return usages, nil
+}
+
+// Runner возвращает фоновый процесс, записывающий накопленные показатели с интервалом interval.
+// При остановке накопленные показатели записываются последний раз.
+func (s *CostService) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := s.Flush(flushCtx); err != nil {
+					slog.ErrorContext(flushCtx, "Error flushing query costs", logging.Err(err))
+				}
+				return ctx.Err()
+			case <-ticker.C:
+				if err := s.Flush(ctx); err != nil {
+					slog.ErrorContext(ctx, "Error flushing query costs", logging.Err(err))
+				}
+			}
+		}
+	}
+}
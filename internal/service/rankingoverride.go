@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// Области действия ранжирования по умолчанию.
+const (
+	RankingScopeOrganization = "organization" // Для всех клиентов организации
+	RankingScopeKey          = "key"          // Только для клиента (API ключа) запроса
+)
+
+// maxBoostOverrides ограничивает число бустов в ранжировании по умолчанию.
+const maxBoostOverrides = 20
+
+// RankingOverrideService управляет ранжированием по умолчанию организаций и клиентов:
+// весами факторов и бустами полей, которые применяются к запросам рекомендаций клиента
+// без передачи их в каждом запросе.
+type RankingOverrideService struct {
+	pgStorage       *storage.PostgresStorage
+	recommendations *RecommendationService // Кеш ранжирования по умолчанию сбрасывается при изменениях
+}
+
+// NewRankingOverrideService создает новый экземпляр RankingOverrideService.
+func NewRankingOverrideService(pgStorage *storage.PostgresStorage, recommendations *RecommendationService) *RankingOverrideService {
+	return &RankingOverrideService{pgStorage: pgStorage, recommendations: recommendations}
+}
+
+// Get возвращает ранжирование по умолчанию области scope клиента запроса.
+func (s *RankingOverrideService) Get(ctx context.Context, scope string) (*models.RankingOverride, error) {
+	organization, subject, err := rankingOwner(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := s.pgStorage.GetRankingOverride(ctx, organization, subject)
+	if errors.Is(err, storage.ErrRankingOverrideNotFound) {
+		return nil, ErrNotFound
+	}
+	return override, err
+}
+
+// Put сохраняет ранжирование по умолчанию области scope клиента запроса, заменяя прежнее.
+func (s *RankingOverrideService) Put(ctx context.Context, scope string, override *models.RankingOverride) error {
+	organization, subject, err := rankingOwner(ctx, scope)
+	if err != nil {
+		return err
+	}
+	if err := validateRankingOverride(override); err != nil {
+		return err
+	}
+	override.Organization = organization
+	override.Subject = subject
+
+	if err := s.pgStorage.PutRankingOverride(ctx, override); err != nil {
+		return err
+	}
+	// Значения организации действуют для всех ее клиентов, поэтому кеш сбрасывается целиком
+	s.recommendations.overrides.Clear()
+	return nil
+}
+
+// Delete удаляет ранжирование по умолчанию области scope клиента запроса.
+func (s *RankingOverrideService) Delete(ctx context.Context, scope string) error {
+	organization, subject, err := rankingOwner(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	err = s.pgStorage.DeleteRankingOverride(ctx, organization, subject)
+	if errors.Is(err, storage.ErrRankingOverrideNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.recommendations.overrides.Clear()
+	return nil
+}
+
+// rankingOwner возвращает организацию и клиента, которым принадлежит ранжирование по умолчанию
+// области scope (пусто — организация); для области организации subject пуст.
+func rankingOwner(ctx context.Context, scope string) (organization, subject string, err error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return "", "", ErrUnauthenticated
+	}
+
+	switch scope {
+	case "", RankingScopeOrganization:
+		if principal.Organization == "" {
+			return "", "", newValidationError("scope %s requires a client with organization, use scope %s", RankingScopeOrganization, RankingScopeKey)
+		}
+		return principal.Organization, "", nil
+	case RankingScopeKey:
+		return principal.Organization, principal.Subject, nil
+	default:
+		return "", "", newValidationError("scope must be %s or %s", RankingScopeOrganization, RankingScopeKey)
+	}
+}
+
+// validateRankingOverride проверяет веса и бусты ранжирования по умолчанию.
+func validateRankingOverride(override *models.RankingOverride) error {
+	if w := override.Weights; w != nil {
+		if w.Traffic < 0 || w.Competition < 0 || w.Demographics < 0 || w.Distance < 0 {
+			return newValidationError("weights must not be negative")
+		}
+		if w.Traffic+w.Competition+w.Demographics+w.Distance == 0 {
+			return newValidationError("weights must not all be zero")
+		}
+	}
+	if override.Weights == nil && len(override.Boosts) == 0 {
+		return newValidationError("weights or boosts are required")
+	}
+
+	if len(override.Boosts) > maxBoostOverrides {
+		return newValidationError("boosts must not contain more than %d items", maxBoostOverrides)
+	}
+	seen := make(map[string]bool, len(override.Boosts))
+	for _, b := range override.Boosts {
+		if !storage.IsBoostableField(b.Field) {
+			return newValidationError("boosts: field %q cannot be boosted", b.Field)
+		}
+		if b.Boost <= 0 {
+			return newValidationError("boosts: boost must be positive")
+		}
+		key := b.BusinessType + "/" + b.Field
+		if seen[key] {
+			return newValidationError("boosts: duplicate field %q for business type %q", b.Field, b.BusinessType)
+		}
+		seen[key] = true
+	}
+	return nil
+}
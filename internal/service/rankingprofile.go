@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// RankingProfileService управляет профилями ранжирования типов бизнеса: бустингом числовых
+// полей локации и весами векторного поиска. Профили читаются при каждом запросе рекомендаций,
+// поэтому изменения применяются сразу.
+type RankingProfileService struct {
+	pgStorage *storage.PostgresStorage
+}
+
+// NewRankingProfileService создает новый экземпляр RankingProfileService.
+func NewRankingProfileService(pgStorage *storage.PostgresStorage) *RankingProfileService {
+	return &RankingProfileService{pgStorage: pgStorage}
+}
+
+// List возвращает профили ранжирования всех типов бизнеса.
+func (s *RankingProfileService) List(ctx context.Context) ([]models.RankingProfile, error) {
+	return s.pgStorage.ListRankingProfiles(ctx)
+}
+
+// Save проверяет профиль ранжирования и сохраняет его, заменяя прежний профиль типа бизнеса.
+func (s *RankingProfileService) Save(ctx context.Context, profile *models.RankingProfile) error {
+	var v fieldErrors
+	seen := make(map[string]bool, len(profile.Boosts))
+	for i := range profile.Boosts {
+		boost := &profile.Boosts[i]
+		boost.BusinessType = profile.BusinessType
+		field := fmt.Sprintf("boosts[%d]", i)
+		if !storage.IsBoostableField(boost.Field) {
+			v.add(field+".field", "must be a numeric location field, got %q", boost.Field)
+		} else if seen[boost.Field] {
+			v.add(field+".field", "duplicates %q", boost.Field)
+		}
+		seen[boost.Field] = true
+		if boost.Boost <= 0 {
+			v.add(field+".boost", "must be positive")
+		}
+	}
+	if weights := profile.VectorWeights; weights != nil {
+		v.nonNegative("vector_weights.description", weights.Description)
+		v.nonNegative("vector_weights.demographics", weights.Demographics)
+		if weights.Description+weights.Demographics <= 0 {
+			v.add("vector_weights", "must not both be zero")
+		}
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	businessTypes, err := s.pgStorage.GetBusinessTypes(ctx)
+	if err != nil {
+		return err
+	}
+	known := false
+	for _, bt := range businessTypes {
+		known = known || bt.Name == profile.BusinessType
+	}
+	if !known {
+		return newValidationError("unknown business type %q", profile.BusinessType)
+	}
+	if profile.Boosts == nil {
+		profile.Boosts = []models.ScoringBoost{}
+	}
+
+	return s.pgStorage.PutRankingProfile(ctx, profile)
+}
@@ -0,0 +1,1121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/cache"
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+	"github.com/akozadaev/go_es_analytical_system/internal/footfall"
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/routing"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/akozadaev/go_es_analytical_system/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultLimit — количество результатов по умолчанию, если limit не указан.
+const DefaultLimit = 20
+
+const (
+	// candidatePoolFactor — во сколько раз пул кандидатов больше limit при ограничении
+	// на взаимное расстояние: часть лучших локаций отсеется как слишком близкие.
+	candidatePoolFactor = 5
+	maxCandidatePool    = 500
+	// maxSafetyWeight ограничивает бустинг безопасности, чтобы он не подавлял остальные факторы
+	maxSafetyWeight = 5.0
+	// maxPolygonPoints ограничивает число вершин geo_polygon
+	maxPolygonPoints = 1000
+	// maxFieldRanges ограничивает число фильтров field_ranges
+	maxFieldRanges = 20
+	// maxIntentLength ограничивает длину intent в символах
+	maxIntentLength = 500
+	// defaultIntentWeight — доля сходства с intent в оценке, если intent_weight не указан
+	defaultIntentWeight = 0.5
+)
+
+// fieldNamePattern — допустимое имя поля в field_ranges, в том числе вложенного (demographics.average_income).
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+
+// RecommendationService реализует получение рекомендаций локаций.
+type RecommendationService struct {
+	esStorage storage.RecommendationSearcher
+	pgStorage storage.RankingStore // nil — без настроек ранжирования и журнала запросов
+	cache     *cache.Cache[*searchPage]
+	places    *cache.Cache[map[string][]string]
+	vector    *cache.Cache[*storage.VectorIndexOptions]
+	intents   *cache.Cache[[]float64]                // Embeddings intent по версии модели и тексту
+	boosts    *cache.Cache[[]models.ScoringBoost]    // Профили ранжирования по типу бизнеса
+	overrides *cache.Cache[[]models.RankingOverride] // Ранжирование по умолчанию по организации и клиенту
+	maxLimit  int
+	router    routing.Provider
+	footfall  footfall.Model
+	// relaxation — политика ослабления ограничений запроса при недостатке результатов
+	relaxation RelaxationPolicy
+	// embedder строит embedding текста intent
+	embedder embedding.Provider
+	// references — справочник для проверки типа бизнеса запроса и центров городов (может быть nil)
+	references *ReferenceService
+	// recorder принимает длительность и ошибки рекомендаций (может быть nil)
+	recorder metrics.RecommendRecorder
+}
+
+// NewRecommendationService создает новый экземпляр RecommendationService.
+// Результаты поиска кешируются на cacheTTL; maxLimit ограничивает размер выдачи;
+// router рассчитывает время в пути для запросов с точкой отсчета; footfallModel (может быть nil)
+// прогнозирует посещаемость для запросов с footfall_weight; relaxation задает ослабление
+// ограничений запроса при недостатке результатов.
+func NewRecommendationService(esStorage storage.RecommendationSearcher, pgStorage storage.RankingStore, cacheTTL time.Duration, maxLimit int, router routing.Provider, footfallModel footfall.Model, relaxation RelaxationPolicy) *RecommendationService {
+	return &RecommendationService{
+		esStorage:  esStorage,
+		pgStorage:  pgStorage,
+		cache:      cache.New[*searchPage](cacheTTL),
+		places:     cache.New[map[string][]string](cacheTTL),
+		vector:     cache.New[*storage.VectorIndexOptions](cacheTTL),
+		intents:    cache.New[[]float64](cacheTTL),
+		boosts:     cache.New[[]models.ScoringBoost](cacheTTL),
+		overrides:  cache.New[[]models.RankingOverride](cacheTTL),
+		maxLimit:   maxLimit,
+		router:     router,
+		footfall:   footfallModel,
+		relaxation: relaxation,
+	}
+}
+
+// SetEmbedder задает провайдер embeddings для запросов с intent. Без него intent не поддерживается.
+func (s *RecommendationService) SetEmbedder(provider embedding.Provider) {
+	s.embedder = provider
+}
+
+// SetRecorder включает метрики длительности и ошибок рекомендаций по профилю ранжирования.
+func (s *RecommendationService) SetRecorder(recorder metrics.RecommendRecorder) {
+	s.recorder = recorder
+}
+
+// SetReferences включает проверку типа бизнеса запросов рекомендаций по справочнику и фактор
+// расстояния от центра города запроса без origin.
+func (s *RecommendationService) SetReferences(references *ReferenceService) {
+	s.references = references
+}
+
+// Validate проверяет запрос и подставляет значения по умолчанию. Ошибка валидации перечисляет
+// все нарушения запроса по полям.
+func (s *RecommendationService) Validate(req *models.RecommendRequest) error {
+	var v fieldErrors
+	if req.Region == "" {
+		v.add("region", "is required")
+	}
+	if req.BusinessType == "" {
+		v.add("business_type", "is required")
+	}
+
+	if req.Limit < 0 {
+		v.add("limit", "must be positive")
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultLimit
+	}
+	if req.Limit > s.maxLimit {
+		v.add("limit", "must not exceed %d", s.maxLimit)
+	}
+	if req.Origin != nil {
+		v.geoPoint("origin", *req.Origin)
+	}
+	v.nonNegative("min_distance_meters", req.MinDistanceMeters)
+	v.nonNegative("radius_meters", req.RadiusMeters)
+	if req.RadiusMeters > 0 && req.Origin == nil {
+		v.add("radius_meters", "requires origin")
+	}
+	if box := req.BoundingBox; box != nil {
+		v.geoPoint("geo_bounding_box.top_left", box.TopLeft)
+		v.geoPoint("geo_bounding_box.bottom_right", box.BottomRight)
+		if box.TopLeft.Lat < box.BottomRight.Lat {
+			v.add("geo_bounding_box.top_left", "must not be south of bottom_right")
+		}
+	}
+	if len(req.Polygon) > 0 {
+		if len(req.Polygon) < 3 || len(req.Polygon) > maxPolygonPoints {
+			v.add("geo_polygon", "must have from 3 to %d points", maxPolygonPoints)
+		}
+		for i, point := range req.Polygon {
+			v.geoPoint(fmt.Sprintf("geo_polygon[%d]", i), point)
+		}
+	}
+	v.nonNegative("max_travel_minutes", req.MaxTravelMinutes)
+	if req.MaxTravelMinutes > 0 && req.Origin == nil {
+		v.add("max_travel_minutes", "requires origin")
+	}
+	v.score("min_parking_score", req.MinParkingScore)
+	v.score("min_safety", req.MinSafety)
+	if req.SafetyWeight < 0 || req.SafetyWeight > maxSafetyWeight {
+		v.add("safety_weight", "must be between 0 and %g", maxSafetyWeight)
+	}
+	if req.FootfallWeight < 0 || req.FootfallWeight > 1 {
+		v.add("footfall_weight", "must be between 0 and 1")
+	}
+	if seg := req.Segment; seg != nil {
+		if seg.AgeGroup == "" && seg.MinShare == 0 && seg.MinIncome == 0 && seg.MaxIncome == 0 {
+			v.add("segment", "must set age_group, min_share, min_income or max_income")
+		}
+		if seg.MinShare < 0 || seg.MinShare > 1 {
+			v.add("segment.min_share", "must be between 0 and 1")
+		}
+		v.nonNegative("segment.min_income", seg.MinIncome)
+		v.nonNegative("segment.max_income", seg.MaxIncome)
+		if seg.MaxIncome > 0 && seg.MinIncome > seg.MaxIncome {
+			v.add("segment.min_income", "must not exceed max_income")
+		}
+	}
+	if req.AsOf != nil {
+		if req.AsOf.After(time.Now()) {
+			v.add("as_of", "must not be in the future")
+		}
+		// Сегменты фильтруются по текущим данным и не согласуются с версией на дату
+		if req.Segment != nil {
+			v.add("segment", "is not supported with as_of")
+		}
+	}
+	if len(req.FieldRanges) > maxFieldRanges {
+		v.add("field_ranges", "must not contain more than %d items", maxFieldRanges)
+	}
+	for i, r := range req.FieldRanges {
+		field := fmt.Sprintf("field_ranges[%d]", i)
+		if !fieldNamePattern.MatchString(r.Field) {
+			v.add(field+".field", "is not a valid field name: %q", r.Field)
+		}
+		if r.Gte == nil && r.Lte == nil {
+			v.add(field, "requires gte or lte")
+		}
+		if r.Gte != nil && r.Lte != nil && *r.Gte > *r.Lte {
+			v.add(field+".gte", "must not exceed lte")
+		}
+	}
+	if req.FootfallWeight > 0 && footfall.Current(s.footfall) == nil {
+		v.add("footfall_weight", "requires a configured footfall model")
+	}
+	v.nonNegative("min_results", float64(req.MinResults))
+	if req.TargetMonth < 0 || req.TargetMonth > 12 {
+		v.add("target_month", "must be between 1 and 12")
+	}
+	if w := req.Weights; w != nil {
+		if w.Traffic < 0 || w.Competition < 0 || w.Demographics < 0 || w.Distance < 0 {
+			v.add("weights", "must not be negative")
+		} else if w.Traffic+w.Competition+w.Demographics+w.Distance == 0 {
+			v.add("weights", "must not all be zero")
+		}
+	}
+	if req.Cursor != "" {
+		if constrained(req) {
+			v.add("cursor", "is not supported with origin, min_distance_meters or footfall_weight")
+		} else if cursor, err := decodeCursor(req.Cursor); err != nil {
+			v.add("cursor", "is invalid")
+		} else {
+			req.SearchAfter, req.MaxScore = cursor.After, cursor.MaxScore
+		}
+	}
+	if req.UseEmbedding && len(req.QueryVector) == 0 && req.TargetDemographics == nil {
+		v.add("use_embedding", "requires query_vector or target_demographics")
+	}
+	if !req.UseEmbedding && len(req.QueryVector) > 0 {
+		v.add("query_vector", "requires use_embedding")
+	}
+	if len(req.QueryVector) > 0 && isZeroVector(req.QueryVector) {
+		v.add("query_vector", "must not be a zero vector")
+	}
+	if req.Similarity != "" {
+		if !req.UseEmbedding {
+			v.add("similarity", "requires use_embedding")
+		}
+		if len(req.QueryVector) == 0 {
+			v.add("similarity", "requires query_vector")
+		}
+		if !storage.IsSupportedSimilarity(req.Similarity) {
+			v.add("similarity", "must be one of cosine, dot_product, l2_norm")
+		}
+	}
+	if target := req.TargetDemographics; target != nil {
+		if !req.UseEmbedding {
+			v.add("target_demographics", "requires use_embedding")
+		}
+		validateSegments(&v, "target_demographics.segments", target.Segments)
+		if demographicVector(target) == nil {
+			v.add("target_demographics", "must set age_group, segments, interests, average_income or population_density")
+		}
+	}
+	if w := req.VectorWeights; w != nil {
+		if !req.UseEmbedding {
+			v.add("vector_weights", "requires use_embedding")
+		}
+		if w.Description < 0 || w.Demographics < 0 {
+			v.add("vector_weights", "must not be negative")
+		} else if w.Description+w.Demographics == 0 {
+			v.add("vector_weights", "must not all be zero")
+		}
+	}
+	req.Intent = strings.TrimSpace(req.Intent)
+	if req.Intent == "" {
+		if req.IntentWeight != 0 {
+			v.add("intent_weight", "requires intent")
+		}
+	} else {
+		if req.UseEmbedding {
+			v.add("intent", "is not supported with use_embedding")
+		}
+		if utf8.RuneCountInString(req.Intent) > maxIntentLength {
+			v.add("intent", "must not exceed %d characters", maxIntentLength)
+		}
+		if req.IntentWeight < 0 || req.IntentWeight > 1 {
+			v.add("intent_weight", "must be between 0 and 1")
+		}
+		if req.IntentWeight == 0 {
+			req.IntentWeight = defaultIntentWeight
+		}
+		if s.embedder == nil {
+			v.add("intent", "requires a configured embedding provider")
+		}
+	}
+
+	return v.err()
+}
+
+// Recommend валидирует запрос, выполняет поиск (с использованием кеша), нормализует оценки
+// и записывает запрос в историю. Если результатов меньше минимума, ограничения запроса
+// ослабляются по политике сервиса, и примененные ослабления указываются в ответе. Если по
+// региону и городу ничего не найдено, в ответ добавляются варианты названий с опечаткой
+// (did_you_mean), а с autocorrect запрос выполняется по исправленным названиям.
+// Если выдача не исправлялась и не ослаблялась, в ответе возвращается курсор следующей страницы.
+// Длительность и ошибки допустимых запросов передаются recorder по профилю ранжирования.
+func (s *RecommendationService) Recommend(ctx context.Context, req *models.RecommendRequest) (*models.RecommendResponse, error) {
+	if err := s.Validate(req); err != nil {
+		return nil, err
+	}
+	if err := s.validateBusinessType(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := s.validateVectorQuery(ctx, req); err != nil {
+		return nil, err
+	}
+	if s.recorder == nil {
+		return s.recommend(ctx, req)
+	}
+
+	start := time.Now()
+	response, err := s.recommend(ctx, req)
+	similarity := req.Similarity
+	if similarity == "" {
+		similarity = "none"
+	}
+	s.recorder.ObserveRecommend(s.rankingProfile(ctx, req), similarity, time.Since(start), err)
+	return response, err
+}
+
+// rankingProfile возвращает метку профиля ранжирования запроса для метрик: тип бизнеса, если
+// для него сохранен профиль, custom — для бустов из запроса, default — без профиля. Число меток
+// ограничено сохраненными профилями, а не значениями из запросов.
+func (s *RecommendationService) rankingProfile(ctx context.Context, req *models.RecommendRequest) string {
+	if req.Boosts != nil {
+		return "custom"
+	}
+	if s.pgStorage == nil {
+		return "default"
+	}
+	boosts, err := s.scoringBoosts(ctx, req.BusinessType)
+	if err != nil || len(boosts) == 0 {
+		return "default"
+	}
+	return req.BusinessType
+}
+
+// recommend выполняет проверенный запрос рекомендаций.
+func (s *RecommendationService) recommend(ctx context.Context, req *models.RecommendRequest) (*models.RecommendResponse, error) {
+	req, err := s.applyRankingOverrides(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "recommend",
+		attribute.String("business_type", req.BusinessType), attribute.String("region", req.Region))
+	defer span.End()
+
+	// Без ограничений, применяемых после поиска, выдача — страница поиска, и ее можно продолжить
+	var (
+		page          *searchPage
+		locations     []models.Location
+		modelVersions map[string]string
+	)
+	searchCtx, searchSpan := tracing.Start(ctx, "recommend.search")
+	if constrained(req) {
+		locations, modelVersions, err = s.rank(searchCtx, req, s.search)
+	} else {
+		page, err = s.searchPage(searchCtx, req)
+		if page != nil {
+			locations = page.Locations
+		}
+	}
+	tracing.SetError(searchSpan, err)
+	searchSpan.End()
+	if err != nil {
+		tracing.SetError(span, err)
+		return nil, err
+	}
+
+	// Следующие страницы продолжают выдачу первой без исправлений и ослаблений
+	if req.Cursor != "" {
+		response := &models.RecommendResponse{
+			QueryID:    newID(),
+			Locations:  locations,
+			Total:      page.Total,
+			NextCursor: page.nextCursor(),
+		}
+		s.recordHistory(ctx, response, req, modelVersions, time.Since(start))
+		return response, nil
+	}
+
+	// Пустая выдача часто означает опечатку в названии региона или города
+	var (
+		suggestions *models.PlaceSuggestions
+		corrected   *models.PlaceCorrection
+	)
+	query := req
+	if len(locations) == 0 {
+		suggestions, err = s.suggestPlaces(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if suggestions != nil && req.AutoCorrect {
+			corrected = correction(req, suggestions)
+		}
+		if corrected != nil {
+			fixed := *req
+			if corrected.Region != "" {
+				fixed.Region = corrected.Region
+			}
+			if corrected.City != "" {
+				fixed.City = corrected.City
+			}
+			query = &fixed
+			suggestions = nil
+			page = nil
+			locations, modelVersions, err = s.rank(ctx, query, s.search)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	broadenCtx, broadenSpan := tracing.Start(ctx, "recommend.broaden")
+	locations, modelVersions, relaxations, substitution, err := s.broaden(broadenCtx, query, locations, modelVersions)
+	tracing.SetError(broadenSpan, err)
+	broadenSpan.End()
+	if err != nil {
+		tracing.SetError(span, err)
+		return nil, err
+	}
+
+	response := &models.RecommendResponse{
+		QueryID:      newID(),
+		Locations:    locations,
+		Total:        len(locations),
+		Substitution: substitution,
+		Relaxations:  relaxations,
+		DidYouMean:   suggestions,
+		Corrected:    corrected,
+	}
+	if page != nil && len(relaxations) == 0 && substitution == nil {
+		response.Total = page.Total
+		response.NextCursor = page.nextCursor()
+	}
+
+	span.SetAttributes(attribute.Int("results", len(locations)))
+	s.recordHistory(ctx, response, req, modelVersions, time.Since(start))
+
+	return response, nil
+}
+
+// rankSubstitutes повторяет поиск по замещающим типам бизнеса в порядке убывания доли
+// замещаемости и возвращает первую непустую выдачу с описанием замены.
+// Если непустой выдачи нет, возвращается пустой результат без замены.
+func (s *RecommendationService) rankSubstitutes(ctx context.Context, req *models.RecommendRequest) ([]models.Location, map[string]string, *models.Substitution, error) {
+	if s.pgStorage == nil {
+		return nil, nil, nil, nil
+	}
+	substitutes, err := s.pgStorage.ListBusinessTypeSubstitutes(ctx, req.BusinessType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, sub := range substitutes {
+		query := *req
+		query.BusinessType = sub.Substitute
+		query.BusinessTypes = nil
+		locations, modelVersions, err := s.rank(ctx, &query, s.search)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(locations) > 0 {
+			return locations, modelVersions, &models.Substitution{
+				BusinessType: req.BusinessType,
+				Substitute:   sub.Substitute,
+				Weight:       sub.Weight,
+			}, nil
+		}
+	}
+
+	return nil, nil, nil, nil
+}
+
+// Replay выполняет запрос так же, как Recommend, но без кеша и без записи в историю.
+// Используется для офлайн-оценки изменений ранжирования на сохраненных запросах: профиль
+// ранжирования можно подменить, заполнив req.Boosts.
+func (s *RecommendationService) Replay(ctx context.Context, req *models.RecommendRequest) ([]models.Location, error) {
+	if err := s.Validate(req); err != nil {
+		return nil, err
+	}
+	if err := s.validateVectorQuery(ctx, req); err != nil {
+		return nil, err
+	}
+
+	locations, _, err := s.rank(ctx, req, s.searchUncached)
+	return locations, err
+}
+
+// rank выполняет поиск функцией search и применяет к результату ограничения запроса.
+// Возвращает локации и версии моделей, участвовавших в ранжировании.
+// Ограничения применяются только к пулу из не более maxCandidatePool лучших по оценке локаций:
+// время в пути не поднимает в выдачу локации за пределами пула.
+func (s *RecommendationService) rank(ctx context.Context, req *models.RecommendRequest, search func(context.Context, *models.RecommendRequest) ([]models.Location, error)) ([]models.Location, map[string]string, error) {
+	// При ограничении на расстояние, ранжировании по времени в пути и учете прогноза посещаемости
+	// отбор идет из расширенного пула лучших кандидатов
+	query := *req
+	if constrained(req) {
+		query.Limit = req.Limit * candidatePoolFactor
+		if query.Limit > maxCandidatePool {
+			query.Limit = maxCandidatePool
+		}
+	}
+
+	locations, err := search(ctx, &query)
+	if err != nil {
+		return nil, nil, err
+	}
+	var modelVersions map[string]string
+	if req.FootfallWeight > 0 {
+		var version string
+		locations, version, err = s.blendFootfall(ctx, locations, req.BusinessType, req.FootfallWeight)
+		if err != nil {
+			return nil, nil, err
+		}
+		modelVersions = map[string]string{"footfall": version}
+	}
+	if req.Origin != nil {
+		locations, err = s.rankByTravelTime(ctx, locations, *req.Origin, req.MaxTravelMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if constrained(req) {
+		locations = selectSpaced(locations, req.MinDistanceMeters, req.Limit)
+	}
+
+	return locations, modelVersions, nil
+}
+
+// Warm сбрасывает кеш результатов и заполняет его ответами на запросы reqs,
+// чтобы после обновления данных частые запросы не выполнялись в Elasticsearch повторно.
+// Возвращает количество прогретых запросов; некорректные запросы пропускаются.
+func (s *RecommendationService) Warm(ctx context.Context, reqs []models.RecommendRequest) (int, error) {
+	s.cache.Clear()
+
+	warmed := 0
+	for i := range reqs {
+		req := reqs[i]
+		if err := s.Validate(&req); err != nil {
+			continue
+		}
+		if err := s.validateVectorQuery(ctx, &req); err != nil {
+			if IsValidationError(err) {
+				continue
+			}
+			return warmed, err
+		}
+		if _, err := s.search(ctx, &req); err != nil {
+			return warmed, err
+		}
+		warmed++
+	}
+
+	return warmed, nil
+}
+
+// constrained сообщает, применяются ли к результатам поиска ограничения запроса
+// (расстояние между локациями, время в пути, прогноз посещаемости).
+func constrained(req *models.RecommendRequest) bool {
+	return req.MinDistanceMeters > 0 || req.Origin != nil || req.FootfallWeight > 0
+}
+
+// searchPage — страница результатов поиска с нормализованными оценками.
+type searchPage struct {
+	Locations []models.Location
+	Total     int           // Всего локаций, подходящих под запрос
+	Next      []interface{} // Значения сортировки последней локации; nil — страница последняя
+	MaxScore  float64       // Оценка лучшей локации первой страницы до нормализации
+}
+
+// nextCursor возвращает курсор следующей страницы или пустую строку для последней.
+func (p *searchPage) nextCursor() string {
+	if p.Next == nil {
+		return ""
+	}
+	return encodeCursor(recommendCursor{After: p.Next, MaxScore: p.MaxScore})
+}
+
+// PurgeGenerations удаляет из кеша результаты поколений данных старше generation: после записи
+// они недоступны по ключу и иначе занимали бы память до истечения TTL.
+func (s *RecommendationService) PurgeGenerations(generation uint64) {
+	s.cache.DeleteFunc(func(key string) bool {
+		prefix, _, _ := strings.Cut(key, ":")
+		keyGeneration, err := strconv.ParseUint(prefix, 10, 64)
+		return err == nil && keyGeneration < generation
+	})
+}
+
+// search выполняет поиск с использованием кеша и нормализует оценки.
+func (s *RecommendationService) search(ctx context.Context, req *models.RecommendRequest) ([]models.Location, error) {
+	page, err := s.searchPage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return page.Locations, nil
+}
+
+// searchPage выполняет поиск страницы с использованием кеша.
+func (s *RecommendationService) searchPage(ctx context.Context, req *models.RecommendRequest) (*searchPage, error) {
+	// Центр города входит в ключ: после его правки в справочнике выдача пересчитывается
+	req, err := s.resolveCityCenter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// Ключ вычисляется до поиска: результат, полученный во время записи, сохраняется
+	// под прежним поколением данных и не будет выдан после нее
+	key := fmt.Sprintf("%d:%s", s.esStorage.Generation(), cacheKey(req))
+	if page, ok := s.cache.GetContext(ctx, key); ok {
+		return page, nil
+	}
+
+	page, err := s.fetchPage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, page)
+
+	return page, nil
+}
+
+// searchUncached выполняет поиск в Elasticsearch с параметрами ранжирования из справочников
+// и нормализует оценки.
+func (s *RecommendationService) searchUncached(ctx context.Context, req *models.RecommendRequest) ([]models.Location, error) {
+	page, err := s.fetchPage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return page.Locations, nil
+}
+
+// fetchPage выполняет поиск страницы в Elasticsearch с параметрами ранжирования из справочников.
+// Оценки нормализуются относительно лучшей локации первой страницы.
+func (s *RecommendationService) fetchPage(ctx context.Context, req *models.RecommendRequest) (*searchPage, error) {
+	req, err := s.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.esStorage.RecommendLocations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &searchPage{
+		Locations: make([]models.Location, len(found.Locations)),
+		Total:     found.Total,
+		Next:      found.Next,
+		MaxScore:  req.MaxScore,
+	}
+	for i, loc := range found.Locations {
+		page.Locations[i] = *loc
+		if req.AsOf != nil {
+			// В выдаче — версия демографии, по которой рассчитана оценка
+			page.Locations[i].Demographics, _ = loc.DemographicsAt(*req.AsOf)
+		}
+	}
+	if page.MaxScore == 0 {
+		page.MaxScore = maxScore(page.Locations)
+	}
+	normalizeScores(page.Locations, page.MaxScore)
+
+	return page, nil
+}
+
+// resolve возвращает копию запроса с embedding текста intent, вектором целевого демографического
+// профиля, центром города запроса (см. resolveCityCenter) и параметрами ранжирования из справочников:
+// коэффициентами сезонности для TargetMonth, бустингом мероприятий, типами бизнеса по таксономии
+// и профилем ранжирования типа бизнеса (бустами полей и весами векторного поиска).
+func (s *RecommendationService) resolve(ctx context.Context, req *models.RecommendRequest) (*models.RecommendRequest, error) {
+	req, err := s.resolveCityCenter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *req
+	if req.Intent != "" && req.IntentVector == nil {
+		if err := s.embedIntent(ctx, &resolved); err != nil {
+			return nil, err
+		}
+	}
+	if req.TargetDemographics != nil && req.DemographicVector == nil {
+		resolved.DemographicVector = demographicVector(req.TargetDemographics)
+	}
+	if s.pgStorage == nil {
+		return &resolved, nil
+	}
+
+	if len(req.QueryVector) > 0 && len(resolved.DemographicVector) > 0 && req.VectorWeights == nil {
+		weights, err := s.pgStorage.GetVectorWeights(ctx, req.BusinessType)
+		if err != nil {
+			return nil, err
+		}
+		resolved.VectorWeights = weights
+	}
+
+	if req.TargetMonth > 0 && req.Seasonal == nil {
+		coefficients, err := s.pgStorage.GetSeasonalCoefficients(ctx, req.BusinessType, req.TargetMonth)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Seasonal = coefficients
+	}
+
+	if req.EventBoost == nil {
+		boost, err := s.benefitsFromEvents(ctx, req.BusinessType)
+		if err != nil {
+			return nil, err
+		}
+		resolved.EventBoost = &boost
+	}
+
+	if req.BusinessTypes == nil {
+		businessTypes, err := s.pgStorage.ExpandBusinessType(ctx, req.BusinessType)
+		if err != nil {
+			return nil, err
+		}
+		resolved.BusinessTypes = businessTypes
+	}
+
+	if req.Boosts == nil {
+		boosts, err := s.scoringBoosts(ctx, req.BusinessType)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Boosts = overrideBoosts(boosts, req.BoostOverrides)
+	}
+
+	return &resolved, nil
+}
+
+// resolveCityCenter возвращает копию запроса без origin с центром его города из справочника —
+// точкой отсчета фактора расстояния, если distance_from_city_center не выключен. Запрос с origin,
+// без города или с городом не из справочника возвращается как есть.
+func (s *RecommendationService) resolveCityCenter(ctx context.Context, req *models.RecommendRequest) (*models.RecommendRequest, error) {
+	if req.Origin != nil || req.City == "" || req.CityCenter != nil || s.references == nil ||
+		(req.DistanceFromCityCenter != nil && !*req.DistanceFromCityCenter) {
+		return req, nil
+	}
+	city, err := s.references.FindCity(ctx, req.Region, req.City)
+	if err != nil || city == nil {
+		return req, err
+	}
+	resolved := *req
+	center := city.Center
+	resolved.CityCenter = &center
+	return &resolved, nil
+}
+
+// benefitsFromEvents сообщает, включен ли бустинг мероприятий для типа бизнеса. Справочник
+// берется из кеша ReferenceService, без него — из PostgreSQL.
+func (s *RecommendationService) benefitsFromEvents(ctx context.Context, businessType string) (bool, error) {
+	var businessTypes []models.BusinessType
+	if s.references != nil {
+		cached, err := s.references.BusinessTypes(ctx)
+		if err != nil {
+			return false, err
+		}
+		businessTypes = cached
+	} else {
+		stored, err := s.pgStorage.GetBusinessTypes(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, bt := range stored {
+			businessTypes = append(businessTypes, *bt)
+		}
+	}
+	for _, bt := range businessTypes {
+		if bt.Name == businessType {
+			return bt.BenefitsFromEvents, nil
+		}
+	}
+	return false, nil
+}
+
+// scoringBoosts возвращает профиль ранжирования типа бизнеса из кеша или PostgreSQL.
+// Изменения профиля учитываются по истечении времени жизни кеша.
+func (s *RecommendationService) scoringBoosts(ctx context.Context, businessType string) ([]models.ScoringBoost, error) {
+	if cached, ok := s.boosts.GetContext(ctx, businessType); ok {
+		return cached, nil
+	}
+	boosts, err := s.pgStorage.GetScoringBoosts(ctx, businessType)
+	if err != nil {
+		return nil, err
+	}
+	s.boosts.Set(businessType, boosts)
+	return boosts, nil
+}
+
+// rankingOverrides возвращает ранжирование по умолчанию организации и клиента из кеша или PostgreSQL.
+// Изменения на других экземплярах учитываются по истечении времени жизни кеша.
+func (s *RecommendationService) rankingOverrides(ctx context.Context, organization, subject string) ([]models.RankingOverride, error) {
+	key := organization + "\x00" + subject
+	if cached, ok := s.overrides.GetContext(ctx, key); ok {
+		return cached, nil
+	}
+	overrides, err := s.pgStorage.GetRankingOverrides(ctx, organization, subject)
+	if err != nil {
+		return nil, err
+	}
+	s.overrides.Set(key, overrides)
+	return overrides, nil
+}
+
+// applyRankingOverrides дополняет запрос ранжированием по умолчанию организации и клиента запроса:
+// весами — если запрос их не задает, бустами полей — поверх профиля ранжирования типа бизнеса.
+// Значения клиента приоритетнее значений организации. Запрос без клиента не меняется.
+func (s *RecommendationService) applyRankingOverrides(ctx context.Context, req *models.RecommendRequest) (*models.RecommendRequest, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || s.pgStorage == nil || req.BoostOverrides != nil {
+		return req, nil
+	}
+	overrides, err := s.rankingOverrides(ctx, principal.Organization, principal.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) == 0 {
+		return req, nil
+	}
+
+	resolved := *req
+	for _, override := range overrides {
+		if req.Weights == nil && override.Weights != nil {
+			weights := *override.Weights
+			resolved.Weights = &weights
+		}
+		// Правила для всех типов бизнеса идут раньше правил типа запроса, чтобы при совпадении
+		// поля действовало более точное
+		for _, b := range override.Boosts {
+			if b.BusinessType == "" {
+				resolved.BoostOverrides = append(resolved.BoostOverrides, b)
+			}
+		}
+		for _, b := range override.Boosts {
+			if b.BusinessType == req.BusinessType {
+				resolved.BoostOverrides = append(resolved.BoostOverrides, b)
+			}
+		}
+	}
+	return &resolved, nil
+}
+
+// overrideBoosts возвращает профиль ранжирования, в котором правила с полями из overrides
+// заменены ими, а правила для остальных полей добавлены. Позднее правило для поля заменяет раннее.
+func overrideBoosts(profile, overrides []models.ScoringBoost) []models.ScoringBoost {
+	if len(overrides) == 0 {
+		return profile
+	}
+	boosts := append([]models.ScoringBoost(nil), profile...)
+	for _, override := range overrides {
+		replaced := false
+		for i := range boosts {
+			if boosts[i].Field == override.Field {
+				boosts[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			boosts = append(boosts, override)
+		}
+	}
+	return boosts
+}
+
+// recordHistory асинхронно сохраняет запрос в историю, чтобы не увеличивать время ответа.
+// Ошибки записи только логируются.
+// modelVersions — версии моделей, участвовавших в ранжировании.
+func (s *RecommendationService) recordHistory(ctx context.Context, response *models.RecommendResponse, req *models.RecommendRequest, modelVersions map[string]string, duration time.Duration) {
+	if s.pgStorage == nil {
+		return
+	}
+
+	ids := make([]string, len(response.Locations))
+	scores := make([]float64, len(response.Locations))
+	for i, loc := range response.Locations {
+		ids[i] = loc.ID
+		scores[i] = loc.Score
+	}
+
+	entry := &models.QueryHistoryEntry{
+		ID:            response.QueryID,
+		Request:       *req,
+		ResultIDs:     ids,
+		Scores:        scores,
+		ResultCount:   len(ids),
+		DurationMs:    duration.Milliseconds(),
+		ModelVersions: modelVersions,
+		CreatedAt:     time.Now(),
+	}
+	if principal, ok := auth.FromContext(ctx); ok {
+		entry.Organization = principal.Organization
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.pgStorage.RecordQuery(ctx, entry); err != nil {
+			slog.ErrorContext(ctx, "Error recording query history", logging.Err(err))
+		}
+	}()
+}
+
+// rankByTravelTime сортирует кандидатов по времени в пути от origin, исключая недоступные
+// и, если maxMinutes > 0, более далекие. При равном времени сохраняется порядок по оценке.
+func (s *RecommendationService) rankByTravelTime(ctx context.Context, candidates []models.Location, origin models.GeoPoint, maxMinutes float64) ([]models.Location, error) {
+	points := make([]models.GeoPoint, len(candidates))
+	for i, loc := range candidates {
+		points[i] = loc.Coordinates
+	}
+	durations, err := s.router.Durations(ctx, origin, points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get travel times: %w", err)
+	}
+
+	// Копия: candidates может быть разделяемым значением из кеша
+	ranked := make([]models.Location, 0, len(candidates))
+	for i, loc := range candidates {
+		if durations[i] == routing.Unreachable || (maxMinutes > 0 && durations[i] > maxMinutes*60) {
+			continue
+		}
+		loc.TravelTimeSeconds = durations[i]
+		ranked = append(ranked, loc)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].TravelTimeSeconds < ranked[j].TravelTimeSeconds })
+
+	return ranked, nil
+}
+
+// selectSpaced жадно отбирает до limit локаций в порядке убывания оценки, пропуская те,
+// что ближе minDistance метров к уже отобранным. Результат может быть короче limit,
+// если подходящих кандидатов в пуле не хватило.
+func selectSpaced(candidates []models.Location, minDistance float64, limit int) []models.Location {
+	selected := make([]models.Location, 0, limit)
+	points := make([]models.GeoPoint, 0, limit)
+	for _, candidate := range candidates {
+		if len(selected) == limit {
+			break
+		}
+		if tooClose(candidate.Coordinates, points, minDistance) {
+			continue
+		}
+		selected = append(selected, candidate)
+		points = append(points, candidate.Coordinates)
+	}
+	return selected
+}
+
+// tooClose сообщает, находится ли точка ближе minDistance метров к одной из выбранных.
+func tooClose(point models.GeoPoint, selected []models.GeoPoint, minDistance float64) bool {
+	if minDistance <= 0 {
+		return false
+	}
+	for _, other := range selected {
+		if geo.DistanceMeters(point, other) < minDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// maxScore возвращает наибольшую оценку релевантности.
+func maxScore(locations []models.Location) float64 {
+	var best float64
+	for _, loc := range locations {
+		if loc.Score > best {
+			best = loc.Score
+		}
+	}
+	return best
+}
+
+// normalizeScores приводит оценки релевантности к диапазону [0, 1] относительно лучшего результата
+// best, чтобы оценки были сопоставимы между разными запросами.
+func normalizeScores(locations []models.Location, best float64) {
+	if best == 0 {
+		return
+	}
+	for i := range locations {
+		locations[i].Score /= best
+	}
+}
+
+// validateBusinessType проверяет, что тип бизнеса запроса есть в справочнике, если проверка включена.
+func (s *RecommendationService) validateBusinessType(ctx context.Context, req *models.RecommendRequest) error {
+	if s.references == nil {
+		return nil
+	}
+	names, err := s.references.BusinessTypeNames(ctx)
+	if err != nil {
+		return err
+	}
+	var v fieldErrors
+	if !names[req.BusinessType] {
+		v.add("business_type", "is not a known business type: %q", req.BusinessType)
+	}
+	return v.err()
+}
+
+// unitLengthTolerance — допустимое отклонение квадрата нормы вектора от 1 для dot_product.
+const unitLengthTolerance = 1e-3
+
+// validateVectorQuery проверяет векторный запрос по параметрам kNN индекса: размерность
+// query_vector и совместимость метрики сходства с индексом. Без указанной метрики подставляет
+// метрику индекса. dot_product имеет смысл только для векторов единичной длины, поэтому
+// допускается лишь для индекса, построенного с dot_product (Elasticsearch требует от его
+// документов единичной нормы), и для вектора запроса единичной длины; cosine и l2_norm
+// вычисляются по любому индексу.
+func (s *RecommendationService) validateVectorQuery(ctx context.Context, req *models.RecommendRequest) error {
+	if !req.UseEmbedding || len(req.QueryVector) == 0 {
+		return nil
+	}
+	options, ok := s.vector.Get("")
+	if !ok {
+		var err error
+		options, err = s.esStorage.GetVectorIndexOptions(ctx)
+		if err != nil {
+			return err
+		}
+		s.vector.Set("", options)
+	}
+
+	if options.Dims > 0 && len(req.QueryVector) != options.Dims {
+		return newValidationError("query_vector must have %d dimensions", options.Dims)
+	}
+	if req.Similarity == "" {
+		req.Similarity = options.Similarity
+		if !storage.IsSupportedSimilarity(req.Similarity) {
+			req.Similarity = "cosine"
+		}
+	}
+	if req.Similarity == "dot_product" {
+		if options.Similarity != "dot_product" {
+			return newValidationError("similarity dot_product requires an index built with dot_product, index uses %q", options.Similarity)
+		}
+		var norm float64
+		for _, v := range req.QueryVector {
+			norm += v * v
+		}
+		if math.Abs(norm-1) > unitLengthTolerance {
+			return newValidationError("query_vector must be unit length for dot_product similarity")
+		}
+	}
+	return nil
+}
+
+// demographicVector возвращает вектор целевого демографического профиля запроса: сводка профиля
+// согласуется с сегментами так же, как при записи локации.
+func demographicVector(target *models.Demographics) []float64 {
+	d := *target
+	d.Summarize()
+	return embedding.DemographicVector(d)
+}
+
+// embedIntent строит embedding текста req.Intent текущей моделью провайдера и записывает его
+// с версией модели в запрос. Embeddings кешируются по версии модели и тексту.
+func (s *RecommendationService) embedIntent(ctx context.Context, req *models.RecommendRequest) error {
+	if s.embedder == nil {
+		return newValidationError("intent requires a configured embedding provider")
+	}
+	provider := embedding.Current(s.embedder)
+	version := provider.Version()
+	key := fmt.Sprintf("%d|%s", version, req.Intent)
+	if vector, ok := s.intents.Get(key); ok {
+		req.IntentVector, req.IntentEmbeddingVersion = vector, version
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "recommend.intent", attribute.Int("embedding_version", version))
+	defer span.End()
+
+	vectors, err := provider.Embed(ctx, []string{req.Intent})
+	if err != nil {
+		tracing.SetError(span, err)
+		return fmt.Errorf("failed to embed intent: %w", err)
+	}
+	if len(vectors) != 1 {
+		err := fmt.Errorf("failed to embed intent: provider returned %d vectors", len(vectors))
+		tracing.SetError(span, err)
+		return err
+	}
+	if isZeroVector(vectors[0]) {
+		return newValidationError("intent has no recognizable words")
+	}
+
+	s.intents.Set(key, vectors[0])
+	req.IntentVector, req.IntentEmbeddingVersion = vectors[0], version
+	return nil
+}
+
+// isZeroVector сообщает, что все компоненты вектора равны нулю: косинусное сходство
+// с таким вектором не определено.
+func isZeroVector(vector []float64) bool {
+	for _, v := range vector {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey строит ключ кеша по параметрам запроса и бустам ранжирования по умолчанию клиента.
+func cacheKey(req *models.RecommendRequest) string {
+	data, _ := json.Marshal(req)
+	if len(req.BoostOverrides) > 0 {
+		// Бусты клиента не сериализуются вместе с запросом, но меняют выдачу
+		overrides, _ := json.Marshal(req.BoostOverrides)
+		data = append(append(data, '|'), overrides...)
+	}
+	if req.CityCenter != nil {
+		// Центр города не сериализуется, а после правки справочника меняет выдачу
+		data = fmt.Appendf(append(data, '|'), "center:%g,%g", req.CityCenter.Lat, req.CityCenter.Lon)
+	}
+	return string(data)
+}
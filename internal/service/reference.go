@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/cache"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+const (
+	// defaultReferenceLimit — размер страницы справочника по умолчанию.
+	defaultReferenceLimit = 100
+	// maxReferenceLimit — максимальный размер страницы справочника.
+	maxReferenceLimit = 1000
+)
+
+// ReferenceService предоставляет доступ к справочникам (типы бизнеса, регионы, города) с кешированием.
+type ReferenceService struct {
+	pgStorage     storage.ReferenceStore
+	businessTypes *cache.Cache[[]models.BusinessType]
+	regions       *cache.Cache[[]models.Region]
+	cities        *cache.Cache[[]models.City]
+}
+
+// NewReferenceService создает новый экземпляр ReferenceService.
+// Справочники кешируются на cacheTTL.
+func NewReferenceService(pgStorage storage.ReferenceStore, cacheTTL time.Duration) *ReferenceService {
+	return &ReferenceService{
+		pgStorage:     pgStorage,
+		businessTypes: cache.New[[]models.BusinessType](cacheTTL),
+		regions:       cache.New[[]models.Region](cacheTTL),
+		cities:        cache.New[[]models.City](cacheTTL),
+	}
+}
+
+// BusinessTypes возвращает список всех типов бизнеса.
+func (s *ReferenceService) BusinessTypes(ctx context.Context) ([]models.BusinessType, error) {
+	if cached, ok := s.businessTypes.GetContext(ctx, ""); ok {
+		return cached, nil
+	}
+
+	businessTypes, err := s.pgStorage.GetBusinessTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.BusinessType, len(businessTypes))
+	for i, bt := range businessTypes {
+		result[i] = *bt
+	}
+	s.businessTypes.Set("", result)
+
+	return result, nil
+}
+
+// BusinessTypeNames возвращает множество названий известных типов бизнеса для проверки
+// запросов и записываемых локаций.
+func (s *ReferenceService) BusinessTypeNames(ctx context.Context) (map[string]bool, error) {
+	businessTypes, err := s.BusinessTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(businessTypes))
+	for _, bt := range businessTypes {
+		names[bt.Name] = true
+	}
+	return names, nil
+}
+
+// Invalidate сбрасывает кеш справочников, например после их загрузки.
+func (s *ReferenceService) Invalidate() {
+	s.businessTypes.Clear()
+	s.regions.Clear()
+	s.cities.Clear()
+}
+
+// Regions возвращает список всех регионов.
+func (s *ReferenceService) Regions(ctx context.Context) ([]models.Region, error) {
+	if cached, ok := s.regions.GetContext(ctx, ""); ok {
+		return cached, nil
+	}
+
+	regions, err := s.pgStorage.GetRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Region, len(regions))
+	for i, r := range regions {
+		result[i] = *r
+	}
+	s.regions.Set("", result)
+
+	return result, nil
+}
+
+// Cities возвращает список всех городов.
+func (s *ReferenceService) Cities(ctx context.Context) ([]models.City, error) {
+	if cached, ok := s.cities.GetContext(ctx, ""); ok {
+		return cached, nil
+	}
+
+	cities, err := s.pgStorage.GetCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.City, len(cities))
+	for i, c := range cities {
+		result[i] = *c
+	}
+	s.cities.Set("", result)
+
+	return result, nil
+}
+
+// KnownCities возвращает города справочника по регионам для проверки записываемых локаций.
+func (s *ReferenceService) KnownCities(ctx context.Context) (models.KnownCities, error) {
+	cities, err := s.Cities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewKnownCities(cities), nil
+}
+
+// City возвращает город справочника по ID или ErrNotFound.
+func (s *ReferenceService) City(ctx context.Context, id int) (*models.City, error) {
+	cities, err := s.Cities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cities {
+		if cities[i].ID == id {
+			return &cities[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindCity возвращает город name региона region или nil, если его нет в справочнике.
+// Названия сравниваются так же, как при проверке города локации (см. models.NormalizeCityName).
+func (s *ReferenceService) FindCity(ctx context.Context, region, name string) (*models.City, error) {
+	cities, err := s.Cities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	region, name = models.NormalizeCityName(region), models.NormalizeCityName(name)
+	for i := range cities {
+		if models.NormalizeCityName(cities[i].Region) == region && models.NormalizeCityName(cities[i].Name) == name {
+			return &cities[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// LookupCities возвращает города с именем name без учета регистра; непустой region
+// оставляет только города этого региона.
+func (s *ReferenceService) LookupCities(ctx context.Context, name, region string) ([]models.City, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, newValidationError("name is required")
+	}
+	cities, err := s.Cities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = models.NormalizeCityName(name)
+	region = models.NormalizeCityName(region)
+	found := []models.City{}
+	for _, city := range cities {
+		if models.NormalizeCityName(city.Name) != name {
+			continue
+		}
+		if region != "" && models.NormalizeCityName(city.Region) != region {
+			continue
+		}
+		found = append(found, city)
+	}
+	return found, nil
+}
+
+// ListBusinessTypes возвращает страницу типов бизнеса, отобранных и упорядоченных по query.
+// Выборка выполняется по кешированному справочнику.
+func (s *ReferenceService) ListBusinessTypes(ctx context.Context, query models.ReferenceQuery) (*models.BusinessTypePage, error) {
+	if err := normalizeReferenceQuery(&query); err != nil {
+		return nil, err
+	}
+	businessTypes, err := s.BusinessTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, total := referencePage(businessTypes, query, func(bt models.BusinessType) referenceEntry {
+		return referenceEntry{id: bt.ID, name: bt.Name, parentID: bt.ParentID}
+	})
+	return &models.BusinessTypePage{Items: items, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// ListRegions возвращает страницу регионов, отобранных и упорядоченных по query.
+// Выборка выполняется по кешированному справочнику.
+func (s *ReferenceService) ListRegions(ctx context.Context, query models.ReferenceQuery) (*models.RegionPage, error) {
+	if err := normalizeReferenceQuery(&query); err != nil {
+		return nil, err
+	}
+	regions, err := s.Regions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, total := referencePage(regions, query, func(r models.Region) referenceEntry {
+		return referenceEntry{id: r.ID, name: r.Name, parentID: r.ParentRegionID}
+	})
+	return &models.RegionPage{Items: items, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// ListCities возвращает страницу городов, отобранных и упорядоченных по query; родитель
+// города — его регион. Выборка выполняется по кешированному справочнику.
+func (s *ReferenceService) ListCities(ctx context.Context, query models.ReferenceQuery) (*models.CityPage, error) {
+	if err := normalizeReferenceQuery(&query); err != nil {
+		return nil, err
+	}
+	cities, err := s.Cities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, total := referencePage(cities, query, func(c models.City) referenceEntry {
+		return referenceEntry{id: c.ID, name: c.Name, parentID: &c.RegionID}
+	})
+	return &models.CityPage{Items: items, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// normalizeReferenceQuery проверяет параметры выборки справочника и подставляет значения по умолчанию.
+func normalizeReferenceQuery(query *models.ReferenceQuery) error {
+	if query.Limit < 0 {
+		return newValidationError("limit must not be negative")
+	}
+	if query.Limit > maxReferenceLimit {
+		return newValidationError("limit must not exceed %d", maxReferenceLimit)
+	}
+	if query.Offset < 0 {
+		return newValidationError("offset must not be negative")
+	}
+	if query.ParentID != nil && *query.ParentID < 0 {
+		return newValidationError("parent_id must not be negative")
+	}
+	if query.Limit == 0 && !query.All {
+		query.Limit = defaultReferenceLimit
+	}
+	switch strings.TrimPrefix(query.Sort, "-") {
+	case "":
+		query.Sort = models.ReferenceSortName
+	case models.ReferenceSortName, models.ReferenceSortID:
+	default:
+		return newValidationError("unsupported sort %q (available: name, -name, id, -id)", query.Sort)
+	}
+	return nil
+}
+
+// referenceEntry — атрибуты записи справочника, по которым она отбирается и сортируется.
+type referenceEntry struct {
+	id       int
+	name     string
+	parentID *int
+}
+
+// referencePage отбирает записи справочника по префиксу имени и родителю, сортирует их
+// и возвращает страницу query (с query.All — все отобранные записи) вместе с числом отобранных записей.
+func referencePage[T any](all []T, query models.ReferenceQuery, entry func(T) referenceEntry) ([]T, int) {
+	prefix := strings.ToLower(query.Prefix)
+	matched := make([]T, 0, len(all))
+	for _, item := range all {
+		e := entry(item)
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(e.name), prefix) {
+			continue
+		}
+		if query.ParentID != nil {
+			parentID := 0
+			if e.parentID != nil {
+				parentID = *e.parentID
+			}
+			if parentID != *query.ParentID {
+				continue
+			}
+		}
+		matched = append(matched, item)
+	}
+
+	descending := strings.HasPrefix(query.Sort, "-")
+	byID := strings.TrimPrefix(query.Sort, "-") == models.ReferenceSortID
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := entry(matched[i]), entry(matched[j])
+		if descending {
+			a, b = b, a
+		}
+		if byID {
+			return a.id < b.id
+		}
+		// Имена регионов уникальны только в пределах родителя — при равных именах порядок задает ID
+		if nameA, nameB := strings.ToLower(a.name), strings.ToLower(b.name); nameA != nameB {
+			return nameA < nameB
+		}
+		return a.id < b.id
+	})
+
+	total := len(matched)
+	if query.All {
+		return matched, total
+	}
+	if query.Offset >= total {
+		return []T{}, total
+	}
+	end := query.Offset + query.Limit
+	if end > total {
+		end = total
+	}
+	return matched[query.Offset:end], total
+}
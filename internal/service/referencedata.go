@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/refdata"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// ReferenceDataService выгружает и загружает справочники типов бизнеса, регионов и городов целиком,
+// чтобы окружения синхронизировались без SQL дампов. Загрузка в режиме dry-run только
+// рассчитывает изменения.
+type ReferenceDataService struct {
+	pgStorage  *storage.PostgresStorage
+	references *ReferenceService
+}
+
+// NewReferenceDataService создает новый экземпляр ReferenceDataService. Кеш справочников
+// references (может быть nil) сбрасывается после загрузки.
+func NewReferenceDataService(pgStorage *storage.PostgresStorage, references *ReferenceService) *ReferenceDataService {
+	return &ReferenceDataService{
+		pgStorage:  pgStorage,
+		references: references,
+	}
+}
+
+// Export возвращает справочники окружения в переносимом формате. Справочники читаются
+// из PostgreSQL, а не из кеша.
+func (s *ReferenceDataService) Export(ctx context.Context) (*models.ReferenceData, error) {
+	businessTypes, err := s.pgStorage.GetBusinessTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	regions, err := s.pgStorage.GetRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := s.pgStorage.GetCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bts := make([]models.BusinessType, len(businessTypes))
+	for i, bt := range businessTypes {
+		bts[i] = *bt
+	}
+	rs := make([]models.Region, len(regions))
+	for i, r := range regions {
+		rs[i] = *r
+	}
+	cs := make([]models.City, len(cities))
+	for i, c := range cities {
+		cs[i] = *c
+	}
+	return refdata.FromModels(bts, rs, cs), nil
+}
+
+// Import проверяет выгрузку и рассчитывает ее отличия от справочников окружения в транзакции
+// загрузки. Без dryRun изменения применяются: типы бизнеса и города добавляются и обновляются,
+// регионы добавляются, а с prune отсутствующие в выгрузке записи удаляются (вместе с замещаемостью
+// удаленных типов, перечисленной в Cascade). Удаление региона, у которого остались города, —
+// ошибка валидации и в режиме dry-run.
+func (s *ReferenceDataService) Import(ctx context.Context, data *models.ReferenceData, dryRun, prune bool) (*models.ReferenceDiff, error) {
+	if err := refdata.Validate(data); err != nil {
+		return nil, &ValidationError{Message: err.Error()}
+	}
+
+	diff, err := s.pgStorage.ImportReferenceData(ctx, data, prune, dryRun)
+	if err != nil {
+		if errors.Is(err, storage.ErrRegionHasCities) {
+			return nil, &ValidationError{Message: err.Error()}
+		}
+		return nil, err
+	}
+	if !dryRun && s.references != nil {
+		s.references.Invalidate()
+	}
+	return diff, nil
+}
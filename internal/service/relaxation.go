@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// Ослабления ограничений запроса рекомендаций при недостатке результатов.
+const (
+	// RelaxDropCity снимает фильтр по городу: поиск идет по всему региону
+	RelaxDropCity = "drop_city"
+	// RelaxWidenRadius увеличивает radius_meters и max_travel_minutes в RadiusFactor раз
+	RelaxWidenRadius = "widen_radius"
+	// RelaxSubstituteTypes дополняет выдачу локациями замещающих типов бизнеса
+	RelaxSubstituteTypes = "substitute_types"
+)
+
+// RelaxationPolicy задает, когда и в каком порядке ослабляются ограничения запроса.
+// Ослабления накапливаются: каждый следующий шаг применяется к уже ослабленному запросу.
+type RelaxationPolicy struct {
+	Steps        []string // Порядок ослаблений
+	MinResults   int      // Минимум результатов по умолчанию (0 — не ослаблять)
+	RadiusFactor float64  // Множитель radius_meters и max_travel_minutes для RelaxWidenRadius
+}
+
+// Validate проверяет шаги и параметры политики.
+func (p RelaxationPolicy) Validate() error {
+	for _, step := range p.Steps {
+		switch step {
+		case RelaxDropCity, RelaxWidenRadius, RelaxSubstituteTypes:
+		default:
+			return fmt.Errorf("unknown relaxation %q", step)
+		}
+	}
+	if p.MinResults < 0 {
+		return fmt.Errorf("min results must not be negative")
+	}
+	if p.RadiusFactor < 1 {
+		return fmt.Errorf("radius factor must be at least 1")
+	}
+	return nil
+}
+
+// broaden ослабляет ограничения запроса по политике сервиса, пока выдача меньше минимума.
+// Возвращает итоговую выдачу, версии моделей, примененные ослабления и замену типа бизнеса.
+func (s *RecommendationService) broaden(ctx context.Context, req *models.RecommendRequest, locations []models.Location, modelVersions map[string]string) ([]models.Location, map[string]string, []string, *models.Substitution, error) {
+	minResults := req.MinResults
+	if minResults == 0 {
+		minResults = s.relaxation.MinResults
+	}
+	if minResults > req.Limit {
+		minResults = req.Limit
+	}
+
+	var (
+		relaxations  []string
+		substitution *models.Substitution
+	)
+	query := *req
+	for _, step := range s.relaxation.Steps {
+		if len(locations) >= minResults {
+			break
+		}
+
+		switch step {
+		case RelaxDropCity:
+			if query.City == "" {
+				continue
+			}
+			query.City = ""
+		case RelaxWidenRadius:
+			if query.RadiusMeters == 0 && query.MaxTravelMinutes == 0 {
+				continue
+			}
+			query.RadiusMeters *= s.relaxation.RadiusFactor
+			query.MaxTravelMinutes *= s.relaxation.RadiusFactor
+		case RelaxSubstituteTypes:
+			substituted, _, sub, err := s.rankSubstitutes(ctx, &query)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			if sub == nil {
+				continue
+			}
+			locations = appendUnique(locations, substituted, req.Limit)
+			relaxations = append(relaxations, step)
+			substitution = sub
+			continue
+		}
+
+		relaxed, versions, err := s.rank(ctx, &query, s.search)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		relaxations = append(relaxations, step)
+		if len(relaxed) > len(locations) {
+			locations, modelVersions = relaxed, versions
+		}
+	}
+
+	return locations, modelVersions, relaxations, substitution, nil
+}
+
+// appendUnique дополняет выдачу locations локациями extra, которых в ней еще нет, до limit.
+func appendUnique(locations, extra []models.Location, limit int) []models.Location {
+	seen := make(map[string]bool, len(locations))
+	for _, loc := range locations {
+		seen[loc.ID] = true
+	}
+	for _, loc := range extra {
+		if len(locations) >= limit {
+			break
+		}
+		if !seen[loc.ID] {
+			seen[loc.ID] = true
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
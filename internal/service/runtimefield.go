@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// runtimeFieldTypes перечисляет типы вычисляемых полей.
+var runtimeFieldTypes = map[string]bool{
+	"double":  true,
+	"long":    true,
+	"keyword": true,
+	"boolean": true,
+	"date":    true,
+}
+
+// runtimeFieldNamePattern — допустимое имя вычисляемого поля.
+var runtimeFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// RuntimeFieldService управляет вычисляемыми полями индекса локаций (runtime fields):
+// производными метриками вроде opportunity_index = traffic_score / (1 + competition_density).
+// Поля хранятся в маппинге основного и архивного индексов и доступны сразу после определения,
+// без переиндексации; сервис использует их только в фильтре field_ranges запросов рекомендаций
+// и в статистике значений — сортировки и агрегаций по ним API не предоставляет.
+// OpenSearch вычисляемые поля не поддерживает.
+type RuntimeFieldService struct {
+	esStorage       *storage.ElasticsearchStorage
+	recommendations *RecommendationService
+}
+
+// NewRuntimeFieldService создает новый экземпляр RuntimeFieldService.
+func NewRuntimeFieldService(esStorage *storage.ElasticsearchStorage, recommendations *RecommendationService) *RuntimeFieldService {
+	return &RuntimeFieldService{
+		esStorage:       esStorage,
+		recommendations: recommendations,
+	}
+}
+
+// List возвращает вычисляемые поля индекса локаций.
+func (s *RuntimeFieldService) List(ctx context.Context) ([]models.RuntimeField, error) {
+	return s.esStorage.ListRuntimeFields(ctx)
+}
+
+// Get возвращает вычисляемое поле name; для числовых полей — со статистикой значений по индексу.
+func (s *RuntimeFieldService) Get(ctx context.Context, name string) (*models.RuntimeField, error) {
+	field, err := s.find(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if field.Type == "double" || field.Type == "long" {
+		stats, err := s.esStorage.RuntimeFieldStats(ctx, name)
+		if errors.Is(err, storage.ErrInvalidRuntimeField) {
+			return nil, newValidationError("%v", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		field.Stats = stats
+	}
+	return field, nil
+}
+
+// Put проверяет и сохраняет определение вычисляемого поля, заменяя прежнее.
+// Скрипт компилируется кластером; ошибка компиляции возвращается как ошибка валидации.
+// Кеш рекомендаций сбрасывается: фильтры по полю могут давать другие результаты.
+func (s *RuntimeFieldService) Put(ctx context.Context, field *models.RuntimeField) (*models.RuntimeField, error) {
+	if !runtimeFieldNamePattern.MatchString(field.Name) {
+		return nil, newValidationError("name must contain only lowercase letters, digits and underscores")
+	}
+	if !runtimeFieldTypes[field.Type] {
+		return nil, newValidationError("unknown type %q (available: double, long, keyword, boolean, date)", field.Type)
+	}
+	if strings.TrimSpace(field.Script) == "" {
+		return nil, newValidationError("script is required")
+	}
+
+	// Вычисляемое поле с именем поля документа скрыло бы исходные значения во всех запросах
+	mapping, err := s.esStorage.GetMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if properties, _ := mapping["properties"].(map[string]interface{}); properties[field.Name] != nil {
+		return nil, newValidationError("%s is a document field and cannot be redefined", field.Name)
+	}
+
+	if err := s.esStorage.PutRuntimeField(ctx, field); err != nil {
+		if errors.Is(err, storage.ErrInvalidRuntimeField) || errors.Is(err, storage.ErrRuntimeFieldsUnsupported) {
+			return nil, newValidationError("%v", err)
+		}
+		return nil, err
+	}
+	s.recommendations.cache.Clear()
+
+	return s.Get(ctx, field.Name)
+}
+
+// Delete удаляет вычисляемое поле или возвращает ErrNotFound, если оно отсутствует.
+// Запросы с field_ranges по удаленному полю перестают находить локации.
+func (s *RuntimeFieldService) Delete(ctx context.Context, name string) error {
+	if _, err := s.find(ctx, name); err != nil {
+		return err
+	}
+	if err := s.esStorage.DeleteRuntimeField(ctx, name); err != nil {
+		return err
+	}
+	s.recommendations.cache.Clear()
+	return nil
+}
+
+// find возвращает определение вычисляемого поля name или ErrNotFound.
+func (s *RuntimeFieldService) find(ctx context.Context, name string) (*models.RuntimeField, error) {
+	fields, err := s.esStorage.ListRuntimeFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
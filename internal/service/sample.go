@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+const (
+	// defaultSampleSize — объем выборки по умолчанию.
+	defaultSampleSize = 100
+	// maxSampleSize — максимальный объем выборки (ограничен окном поиска Elasticsearch).
+	maxSampleSize = 10000
+)
+
+// Описание метода отбора, возвращаемое вместе с выборкой.
+const (
+	sampleMethodSimple = "Простая случайная выборка без возвращения: каждой локации сопоставляется " +
+		"псевдослучайный ключ random_score Elasticsearch, вычисляемый из seed и id локации, " +
+		"в выборку входят n локаций с наибольшими ключами (при равенстве ключей — по id)."
+	sampleMethodStratified = "Стратифицированная выборка с пропорциональным размещением: объем n распределяется " +
+		"между стратами пропорционально числу локаций в них методом наибольших остатков, " +
+		"внутри страты отбор такой же, как в простой случайной выборке (ключ random_score из seed и id)."
+)
+
+// Sample возвращает воспроизводимую случайную выборку локаций, подходящих под фильтр запроса,
+// при необходимости стратифицированную по региону или типу бизнеса. Выборка повторяется при тех же
+// seed и параметрах, пока не изменились данные индекса; переиндексация с другим числом шардов
+// меняет ключи random_score.
+func (s *LocationService) Sample(ctx context.Context, req *models.SampleRequest) (*models.SampleResponse, error) {
+	if req.N < 0 {
+		return nil, newValidationError("n must not be negative")
+	}
+	n := req.N
+	if n == 0 {
+		n = defaultSampleSize
+	}
+	if n > maxSampleSize {
+		return nil, newValidationError("n must not exceed %d", maxSampleSize)
+	}
+
+	var field string
+	switch req.StratifyBy {
+	case models.StratifyNone:
+	case models.StratifyRegion:
+		field = "region"
+	case models.StratifyBusinessType:
+		field = "business_types_suitable"
+	default:
+		return nil, newValidationError("stratify_by must be region or business_type")
+	}
+
+	seed := rand.Int63n(math.MaxInt32)
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	filter := &models.LocationFilter{Region: req.Region, City: req.City, BusinessType: req.BusinessType, PublishedOnly: true}
+	population, err := s.esStorage.CountLocations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.SampleResponse{
+		Seed:       seed,
+		N:          n,
+		StratifyBy: req.StratifyBy,
+		Population: population,
+		Method:     sampleMethodSimple,
+		Locations:  []models.SampledLocation{},
+	}
+
+	if req.StratifyBy == models.StratifyNone {
+		locations, err := s.esStorage.SampleLocations(ctx, filter, seed, n)
+		if err != nil {
+			return nil, err
+		}
+		for _, location := range locations {
+			response.Locations = append(response.Locations, models.SampledLocation{Location: *location})
+		}
+		return response, nil
+	}
+
+	counts, err := s.esStorage.CountLocationsBy(ctx, filter, field)
+	if err != nil {
+		return nil, err
+	}
+	if req.StratifyBy == models.StratifyBusinessType && req.BusinessType != "" {
+		// Локации отфильтрованного типа подходят и другим типам — страта остается одна
+		counts = map[string]int{req.BusinessType: counts[req.BusinessType]}
+	}
+
+	response.Method = sampleMethodStratified
+	response.Strata = allocateSample(counts, n)
+	for _, stratum := range response.Strata {
+		if stratum.Size == 0 {
+			continue
+		}
+		stratumFilter := *filter
+		if req.StratifyBy == models.StratifyRegion {
+			stratumFilter.Region = stratum.Key
+		} else {
+			stratumFilter.BusinessType = stratum.Key
+		}
+
+		locations, err := s.esStorage.SampleLocations(ctx, &stratumFilter, seed, stratum.Size)
+		if err != nil {
+			return nil, err
+		}
+		for _, location := range locations {
+			response.Locations = append(response.Locations, models.SampledLocation{Location: *location, Stratum: stratum.Key})
+		}
+	}
+
+	return response, nil
+}
+
+// allocateSample распределяет объем выборки n между стратами пропорционально их численности
+// методом наибольших остатков. Страты упорядочены по ключу; при равных остатках дополнительная
+// единица достается страте с меньшим ключом. Если n не меньше суммарной численности, страты
+// включаются целиком.
+func allocateSample(counts map[string]int, n int) []models.SampleStratum {
+	strata := make([]models.SampleStratum, 0, len(counts))
+	total := 0
+	for key, count := range counts {
+		if count == 0 {
+			continue
+		}
+		strata = append(strata, models.SampleStratum{Key: key, Population: count})
+		total += count
+	}
+	sort.Slice(strata, func(i, j int) bool { return strata[i].Key < strata[j].Key })
+
+	if n >= total {
+		for i := range strata {
+			strata[i].Size = strata[i].Population
+		}
+		return strata
+	}
+
+	remainders := make([]int, len(strata))
+	allocated := 0
+	for i := range strata {
+		quota := n * strata[i].Population
+		strata[i].Size = quota / total
+		remainders[i] = quota % total
+		allocated += strata[i].Size
+	}
+
+	order := make([]int, len(strata))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for _, i := range order[:n-allocated] {
+		strata[i].Size++
+	}
+
+	return strata
+}
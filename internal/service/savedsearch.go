@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/notify"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+	"github.com/akozadaev/go_es_analytical_system/internal/webhook"
+)
+
+// maxSavedSearchNameLength ограничивает длину названия сохраненного поиска.
+const maxSavedSearchNameLength = 255
+
+// SavedSearchService реализует сохраненные поиски с уведомлениями о новых подходящих локациях.
+// Фильтры поиска регистрируются запросами percolator: при индексации локации кластер сам
+// находит совпавшие поиски, и сервису не нужно перебирать их все.
+// Уведомления о совпадениях ставятся в очередь в PostgreSQL и отправляются фоновым процессом
+// Runner, чтобы медленные webhook_url не задерживали доставку outbox.
+// Сохраненные поиски видны всем пользователям организации; удалять их может только владелец.
+type SavedSearchService struct {
+	esStorage       *storage.ElasticsearchStorage
+	pgStorage       *storage.PostgresStorage
+	recommendations *RecommendationService
+	httpClient      *http.Client
+	webhooks        webhook.Policy
+}
+
+// NewSavedSearchService создает новый экземпляр SavedSearchService.
+func NewSavedSearchService(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, recommendations *RecommendationService) *SavedSearchService {
+	return &SavedSearchService{
+		esStorage:       esStorage,
+		pgStorage:       pgStorage,
+		recommendations: recommendations,
+		httpClient:      webhook.Policy{}.Client(asyncCallbackTimeout),
+	}
+}
+
+// SetWebhookPolicy задает допустимые адреса webhook_url. По умолчанию адреса внутренних
+// и служебных сетей запрещены.
+func (s *SavedSearchService) SetWebhookPolicy(policy webhook.Policy) {
+	s.webhooks = policy
+	s.httpClient = policy.Client(asyncCallbackTimeout)
+}
+
+// List возвращает сохраненные поиски организации пользователя.
+func (s *SavedSearchService) List(ctx context.Context) ([]models.SavedSearch, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return s.pgStorage.ListSavedSearches(ctx, principal.Organization)
+}
+
+// Create проверяет запрос и сохраняет поиск от имени пользователя. Тип бизнеса раскрывается
+// по таксономии на момент сохранения.
+func (s *SavedSearchService) Create(ctx context.Context, req *models.SavedSearchRequest) (*models.SavedSearch, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxSavedSearchNameLength {
+		return nil, newValidationError("name must be 1-%d characters", maxSavedSearchNameLength)
+	}
+	if req.WebhookURL != "" {
+		if err := s.webhooks.Check(ctx, req.WebhookURL); err != nil {
+			return nil, newValidationError("webhook_url %v", err)
+		}
+	}
+	if err := s.recommendations.Validate(&req.Request); err != nil {
+		return nil, err
+	}
+	resolved, err := s.recommendations.resolve(ctx, &req.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &models.SavedSearch{
+		ID:           newID(),
+		Organization: principal.Organization,
+		Owner:        principal.Subject,
+		Name:         name,
+		Request:      req.Request,
+		WebhookURL:   req.WebhookURL,
+	}
+	if err := s.pgStorage.CreateSavedSearch(ctx, search); err != nil {
+		return nil, err
+	}
+	if err := s.esStorage.RegisterSavedSearch(ctx, search.ID, resolved); err != nil {
+		// Поиск без запроса percolator не получал бы уведомлений
+		if delErr := s.pgStorage.DeleteSavedSearch(ctx, search.ID); delErr != nil {
+			slog.ErrorContext(ctx, "Error removing unregistered saved search", "saved_search_id", search.ID, logging.Err(delErr))
+		}
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// Delete удаляет сохраненный поиск. Удалять поиск может только его владелец.
+func (s *SavedSearchService) Delete(ctx context.Context, id string) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	search, err := s.pgStorage.GetSavedSearch(ctx, id)
+	if errors.Is(err, storage.ErrSavedSearchNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// Поиски других организаций неотличимы от отсутствующих
+	if search.Organization != principal.Organization {
+		return ErrNotFound
+	}
+	if search.Owner != principal.Subject {
+		return ErrForbidden
+	}
+
+	if err := s.esStorage.UnregisterSavedSearch(ctx, id); err != nil {
+		return err
+	}
+	if err := s.pgStorage.DeleteSavedSearch(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrSavedSearchNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Match находит сохраненные поиски, под фильтры которых подходит проиндексированная локация,
+// и ставит уведомления их владельцам в очередь; отправляет их DeliverNotifications. Неопубликованные
+// локации и локации вне срока показа не участвуют в поиске, поэтому уведомления о них не ставятся;
+// о локации с отложенной публикацией уведомляют, когда задание расписания публикует ее.
+func (s *SavedSearchService) Match(ctx context.Context, location *models.Location) error {
+	if !location.Published() {
+		return nil
+	}
+	ids, err := s.esStorage.PercolateLocation(ctx, location)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err = s.pgStorage.EnqueueSavedSearchNotifications(ctx, ids, location)
+	return err
+}
+
+// DeliverNotifications отправляет накопившиеся уведомления о совпадениях: пишет их в лог и
+// POST запросом на webhook_url поиска, если он задан. Неудачная отправка повторяется с той же
+// паузой, что и уведомления асинхронной индексации; после asyncCallbackMaxAttempts попыток
+// уведомление отбрасывается. Возвращает число доставленных и неудачных уведомлений.
+func (s *SavedSearchService) DeliverNotifications(ctx context.Context) (delivered, failed int, err error) {
+	notifications, err := s.pgStorage.ClaimSavedSearchNotifications(ctx, asyncCallbackBatchSize, asyncCallbackLease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := range notifications {
+		notification := &notifications[i]
+		sendErr := s.sendNotification(ctx, notification)
+		var retryAfter time.Duration
+		if sendErr != nil {
+			failed++
+			retryAfter = asyncCallbackBackoff(notification.Attempts + 1)
+			slog.WarnContext(ctx, "Error notifying saved search", "saved_search_id", notification.Search.ID,
+				"location_id", notification.Location.ID, "attempts", notification.Attempts+1, logging.Err(sendErr))
+		} else {
+			delivered++
+		}
+		if err := s.pgStorage.CompleteSavedSearchNotification(ctx, notification.ID, sendErr, retryAfter); err != nil {
+			return delivered, failed, err
+		}
+	}
+	return delivered, failed, nil
+}
+
+// sendNotification пишет уведомление в лог при первой попытке и отправляет его на webhook_url
+// поиска. Адрес проверяется повторно: с момента сохранения поиска имя хоста могло начать
+// разрешаться во внутреннюю сеть.
+func (s *SavedSearchService) sendNotification(ctx context.Context, notification *models.SavedSearchNotification) error {
+	search, location := &notification.Search, &notification.Location
+	alert := notify.Alert{
+		Source:   "saved_search",
+		Severity: notify.SeverityInfo,
+		Title:    fmt.Sprintf("Location matches saved search %q", search.Name),
+		Message:  fmt.Sprintf("Location %s (%s, %s) matches saved search %s", location.ID, location.Name, location.City, search.ID),
+		Details: map[string]interface{}{
+			"saved_search_id": search.ID,
+			"organization":    search.Organization,
+			"owner":           search.Owner,
+			"location":        location,
+		},
+		CreatedAt: time.Now(),
+	}
+	if notification.Attempts == 0 {
+		slog.InfoContext(ctx, alert.Title, "saved_search_id", search.ID, "organization", search.Organization,
+			"location_id", location.ID)
+	}
+	if search.WebhookURL == "" {
+		return nil
+	}
+	if err := s.webhooks.Check(ctx, search.WebhookURL); err != nil {
+		return fmt.Errorf("webhook_url %w", err)
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", search.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// Runner возвращает фоновый процесс, отправляющий уведомления о совпадениях с интервалом interval.
+func (s *SavedSearchService) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				delivered, failed, err := s.DeliverNotifications(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "Error delivering saved search notifications", logging.Err(err))
+				} else if delivered > 0 || failed > 0 {
+					slog.InfoContext(ctx, "Saved search notifications delivered", "delivered", delivered, "failed", failed)
+				}
+			}
+		}
+	}
+}
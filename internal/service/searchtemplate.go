@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// SearchTemplateService управляет версиями шаблона поиска рекомендаций: шаблоны mustache
+// хранятся в кластере, история версий — в PostgreSQL. Инженеры по релевантности меняют
+// структуру запроса через новую версию шаблона, проверяют ее на запросах и активируют
+// без выпуска сервиса; откат — активация прежней версии.
+type SearchTemplateService struct {
+	esStorage       *storage.ElasticsearchStorage
+	pgStorage       *storage.PostgresStorage
+	recommendations *RecommendationService
+}
+
+// NewSearchTemplateService создает новый экземпляр SearchTemplateService.
+func NewSearchTemplateService(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, recommendations *RecommendationService) *SearchTemplateService {
+	return &SearchTemplateService{
+		esStorage:       esStorage,
+		pgStorage:       pgStorage,
+		recommendations: recommendations,
+	}
+}
+
+// LoadActive переключает поиск на активную версию шаблона из PostgreSQL, если экземпляр
+// использует другую: шаблон сохраняется в кластере, кеш результатов сбрасывается. Без активной
+// версии используется встроенный запрос.
+func (s *SearchTemplateService) LoadActive(ctx context.Context) error {
+	var id, source string
+	t, err := s.pgStorage.ActiveSearchTemplate(ctx, storage.RecommendTemplateName)
+	switch {
+	case err == nil:
+		id, source = storage.SearchTemplateID(t.Name, t.Version), t.Source
+	case !errors.Is(err, storage.ErrSearchTemplateNotFound):
+		return err
+	}
+	if id == s.esStorage.RecommendTemplate() {
+		return nil
+	}
+
+	if id != "" {
+		if err := s.esStorage.PutSearchTemplate(ctx, id, source); err != nil {
+			return err
+		}
+	}
+	s.esStorage.SetRecommendTemplate(id)
+	s.recommendations.cache.Clear()
+	if id == "" {
+		slog.InfoContext(ctx, "Using built-in recommendation query")
+	} else {
+		slog.InfoContext(ctx, "Using search template", "template_id", id)
+	}
+	return nil
+}
+
+// Runner возвращает фоновый процесс, который с интервалом interval применяет активную версию
+// шаблона (LoadActive): так активация и откат на одном экземпляре доходят до остальных.
+func (s *SearchTemplateService) Runner(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				if err := s.LoadActive(ctx); err != nil {
+					slog.ErrorContext(ctx, "Error loading active search template", logging.Err(err))
+				}
+			}
+		}
+	}
+}
+
+// List возвращает версии шаблона поиска рекомендаций, начиная с последней.
+func (s *SearchTemplateService) List(ctx context.Context) ([]models.SearchTemplate, error) {
+	return s.pgStorage.ListSearchTemplates(ctx, storage.RecommendTemplateName)
+}
+
+// Get возвращает версию шаблона поиска рекомендаций.
+func (s *SearchTemplateService) Get(ctx context.Context, version int) (*models.SearchTemplate, error) {
+	t, err := s.pgStorage.GetSearchTemplate(ctx, storage.RecommendTemplateName, version)
+	if errors.Is(err, storage.ErrSearchTemplateNotFound) {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+// Create проверяет шаблон и сохраняет его новой версией без активации.
+// Пустой source создает версию со встроенным запросом — отправную точку для изменений.
+func (s *SearchTemplateService) Create(ctx context.Context, t *models.SearchTemplate) error {
+	t.Name = storage.RecommendTemplateName
+	if t.Source == "" {
+		t.Source = storage.DefaultRecommendTemplate
+	}
+
+	// Шаблон должен давать корректный JSON хотя бы на простом запросе
+	sample := &models.RecommendRequest{Region: "sample", BusinessType: "sample"}
+	if _, err := s.render(ctx, t.Source, sample); err != nil {
+		return err
+	}
+
+	return s.pgStorage.CreateSearchTemplate(ctx, t)
+}
+
+// Render подставляет в версию шаблона параметры запроса рекомендаций req с профилем
+// ранжирования и таксономией и возвращает получившийся запрос к Elasticsearch.
+func (s *SearchTemplateService) Render(ctx context.Context, version int, req *models.RecommendRequest) (json.RawMessage, error) {
+	t, err := s.Get(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recommendations.Validate(req); err != nil {
+		return nil, err
+	}
+	resolved, err := s.recommendations.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(ctx, t.Source, resolved)
+}
+
+// Activate сохраняет версию шаблона в кластере и переключает на нее поиск рекомендаций.
+// Кеш результатов сбрасывается; другие экземпляры переключаются при следующей проверке (Runner).
+func (s *SearchTemplateService) Activate(ctx context.Context, version int) (*models.SearchTemplate, error) {
+	t, err := s.Get(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Шаблон сохраняется в кластере до переключения: поиск не должен ссылаться на отсутствующий ID
+	id := storage.SearchTemplateID(t.Name, t.Version)
+	if err := s.esStorage.PutSearchTemplate(ctx, id, t.Source); err != nil {
+		if errors.Is(err, storage.ErrInvalidSearchTemplate) {
+			return nil, newValidationError("%v", err)
+		}
+		return nil, err
+	}
+	if err := s.pgStorage.ActivateSearchTemplate(ctx, t.Name, t.Version); err != nil {
+		return nil, err
+	}
+	s.esStorage.SetRecommendTemplate(id)
+	s.recommendations.cache.Clear()
+
+	return s.Get(ctx, version)
+}
+
+// Deactivate возвращает поиск рекомендаций к встроенному запросу. Кеш результатов сбрасывается;
+// другие экземпляры переключаются при следующей проверке (Runner).
+func (s *SearchTemplateService) Deactivate(ctx context.Context) error {
+	if err := s.pgStorage.ActivateSearchTemplate(ctx, storage.RecommendTemplateName, 0); err != nil {
+		return err
+	}
+	s.esStorage.SetRecommendTemplate("")
+	s.recommendations.cache.Clear()
+	return nil
+}
+
+// render отрисовывает шаблон source; ошибка шаблона возвращается как ошибка валидации.
+func (s *SearchTemplateService) render(ctx context.Context, source string, req *models.RecommendRequest) (json.RawMessage, error) {
+	rendered, err := s.esStorage.RenderSearchTemplate(ctx, source, req)
+	if errors.Is(err, storage.ErrInvalidSearchTemplate) {
+		return nil, newValidationError("%v", err)
+	}
+	return rendered, err
+}
@@ -0,0 +1,61 @@
+// Package service содержит бизнес-логику рекомендательной системы (валидация, нормализация оценок,
+// кеширование, запись истории), общую для всех точек входа: HTTP, gRPC и CLI.
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ErrNotFound возвращается, если запрошенный ресурс не найден.
+var ErrNotFound = errors.New("not found")
+
+// ErrUnauthenticated возвращается, если операция требует аутентифицированного пользователя.
+var ErrUnauthenticated = errors.New("authentication required")
+
+// ErrForbidden возвращается, если у пользователя нет прав на операцию.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrConflict возвращается, если ресурс уже существует.
+var ErrConflict = errors.New("conflict")
+
+// ErrExpired возвращается, если срок действия запрошенного ресурса истек.
+var ErrExpired = errors.New("expired")
+
+// ErrUnavailable возвращается, если операция требует не настроенного компонента.
+var ErrUnavailable = errors.New("unavailable")
+
+// ValidationError описывает ошибку валидации входных данных.
+type ValidationError struct {
+	Message    string
+	Violations []models.FieldViolation // Нарушения по полям, если проверка их различает
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError создает ValidationError с форматированным сообщением.
+func newValidationError(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// IsValidationError сообщает, является ли err ошибкой валидации.
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// newID генерирует случайный идентификатор.
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/auth"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// SnapshotService создает неизменяемые снимки выдачи рекомендаций, доступные по публичной ссылке.
+type SnapshotService struct {
+	esStorage  *storage.ElasticsearchStorage
+	pgStorage  *storage.PostgresStorage
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	// editorRole — роль, которой видны черновики (пусто — черновики видны всем)
+	editorRole string
+}
+
+// NewSnapshotService создает новый экземпляр SnapshotService.
+// defaultTTL используется, если срок действия ссылки не указан; maxTTL ограничивает его сверху.
+func NewSnapshotService(esStorage *storage.ElasticsearchStorage, pgStorage *storage.PostgresStorage, defaultTTL, maxTTL time.Duration) *SnapshotService {
+	return &SnapshotService{
+		esStorage:  esStorage,
+		pgStorage:  pgStorage,
+		defaultTTL: defaultTTL,
+		maxTTL:     maxTTL,
+	}
+}
+
+// SetEditorRole задает роль, которой видны черновики локаций: локации, ставшие черновиками после
+// запроса, попадают в снимок только у клиента с этой ролью.
+func (s *SnapshotService) SetEditorRole(role string) {
+	s.editorRole = role
+}
+
+// Share создает снимок выдачи запроса queryID из истории запросов.
+// Локации фиксируются в состоянии на момент создания снимка в порядке и с оценками исходной выдачи;
+// локации, удаленные с тех пор или не видимые клиенту, в снимок не попадают. Если ttl = 0,
+// используется срок по умолчанию. Запросы других организаций неотличимы от отсутствующих.
+func (s *SnapshotService) Share(ctx context.Context, queryID string, ttl time.Duration) (*models.Snapshot, error) {
+	if queryID == "" {
+		return nil, newValidationError("Query ID is required")
+	}
+	if ttl < 0 {
+		return nil, newValidationError("expires_in_hours must be positive")
+	}
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl > s.maxTTL {
+		return nil, newValidationError("expires_in_hours must not exceed %d", int(s.maxTTL.Hours()))
+	}
+
+	entry, err := s.pgStorage.GetQuery(ctx, queryID)
+	if err != nil {
+		if errors.Is(err, storage.ErrQueryNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var organization string
+	if principal, ok := auth.FromContext(ctx); ok {
+		organization = principal.Organization
+	}
+	if entry.Organization != organization {
+		return nil, ErrNotFound
+	}
+
+	found, err := s.esStorage.GetLocationsByIDs(ctx, entry.ResultIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]models.Location, 0, len(entry.ResultIDs))
+	for i, id := range entry.ResultIDs {
+		location, ok := found[id]
+		if !ok || !(location.Published() || canEdit(ctx, s.editorRole)) {
+			continue
+		}
+		if i < len(entry.Scores) {
+			location.Score = entry.Scores[i]
+		}
+		locations = append(locations, *location)
+	}
+
+	now := time.Now()
+	snapshot := &models.Snapshot{
+		Token:     newID() + newID(),
+		QueryID:   entry.ID,
+		Request:   entry.Request,
+		Locations: locations,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := s.pgStorage.SaveSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// Get возвращает снимок по токену. Возвращает ErrNotFound для неизвестного токена
+// и ErrExpired, если срок действия ссылки истек.
+func (s *SnapshotService) Get(ctx context.Context, token string) (*models.Snapshot, error) {
+	snapshot, err := s.pgStorage.GetSnapshot(ctx, token)
+	if err != nil {
+		if errors.Is(err, storage.ErrSnapshotNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if time.Now().After(snapshot.ExpiresAt) {
+		return nil, ErrExpired
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// SubstituteService управляет связями замещаемости типов бизнеса (например, барбершоп частично
+// конкурирует с салоном красоты). Связи учитываются при расчете competition_density и при
+// расширении поиска, когда для запрошенного типа бизнеса нет подходящих локаций.
+type SubstituteService struct {
+	pgStorage *storage.PostgresStorage
+}
+
+// NewSubstituteService создает новый экземпляр SubstituteService.
+func NewSubstituteService(pgStorage *storage.PostgresStorage) *SubstituteService {
+	return &SubstituteService{pgStorage: pgStorage}
+}
+
+// List возвращает связи замещаемости типа бизнеса businessType, при пустом — все связи.
+func (s *SubstituteService) List(ctx context.Context, businessType string) ([]models.BusinessTypeSubstitute, error) {
+	return s.pgStorage.ListBusinessTypeSubstitutes(ctx, businessType)
+}
+
+// Save проверяет связь замещаемости и сохраняет ее, заменяя долю существующей связи.
+func (s *SubstituteService) Save(ctx context.Context, sub *models.BusinessTypeSubstitute) error {
+	if sub.BusinessType == sub.Substitute {
+		return newValidationError("substitute must differ from business_type")
+	}
+	if sub.Weight <= 0 || sub.Weight > 1 {
+		return newValidationError("weight must be in (0, 1]")
+	}
+
+	businessTypes, err := s.pgStorage.GetBusinessTypes(ctx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(businessTypes))
+	for _, bt := range businessTypes {
+		known[bt.Name] = true
+	}
+	for _, name := range []string{sub.BusinessType, sub.Substitute} {
+		if !known[name] {
+			return newValidationError("unknown business type %q", name)
+		}
+	}
+
+	return s.pgStorage.UpsertBusinessTypeSubstitute(ctx, sub)
+}
+
+// Delete удаляет связь замещаемости.
+func (s *SubstituteService) Delete(ctx context.Context, businessType, substitute string) error {
+	err := s.pgStorage.DeleteBusinessTypeSubstitute(ctx, businessType, substitute)
+	if errors.Is(err, storage.ErrSubstituteNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
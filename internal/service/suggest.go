@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// maxSuggestions — максимальное число вариантов названия в did_you_mean.
+const maxSuggestions = 3
+
+// suggestPlaces подбирает известные регионы и города, близкие к указанным в запросе.
+// Варианты предлагаются только для названий, отсутствующих в индексе; город ищется среди
+// городов региона, если регион известен. Возвращает nil, если предложить нечего.
+func (s *RecommendationService) suggestPlaces(ctx context.Context, req *models.RecommendRequest) (*models.PlaceSuggestions, error) {
+	places, ok := s.places.Get("")
+	if !ok {
+		var err error
+		places, err = s.esStorage.GetRegionCities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.places.Set("", places)
+	}
+
+	var suggestions models.PlaceSuggestions
+	cities, regionKnown := places[req.Region]
+	if !regionKnown {
+		regions := make([]string, 0, len(places))
+		for region := range places {
+			regions = append(regions, region)
+		}
+		suggestions.Region = closestNames(req.Region, regions)
+		if len(suggestions.Region) > 0 {
+			cities = places[suggestions.Region[0]]
+		}
+	}
+
+	if req.City != "" && !contains(cities, req.City) {
+		candidates := cities
+		if len(candidates) == 0 {
+			for _, regionCities := range places {
+				candidates = append(candidates, regionCities...)
+			}
+		}
+		suggestions.City = closestNames(req.City, candidates)
+	}
+
+	if len(suggestions.Region) == 0 && len(suggestions.City) == 0 {
+		return nil, nil
+	}
+	return &suggestions, nil
+}
+
+// correction возвращает исправление запроса по подсказкам: название заменяется, только если
+// ближайший вариант единственный.
+func correction(req *models.RecommendRequest, suggestions *models.PlaceSuggestions) *models.PlaceCorrection {
+	var c models.PlaceCorrection
+	if best, ok := unambiguous(req.Region, suggestions.Region); ok {
+		c.Region = best
+	}
+	if best, ok := unambiguous(req.City, suggestions.City); ok {
+		c.City = best
+	}
+	if c.Region == "" && c.City == "" {
+		return nil
+	}
+	return &c
+}
+
+func unambiguous(name string, candidates []string) (string, bool) {
+	if len(candidates) == 0 {
+		return "", false
+	}
+	if len(candidates) > 1 && nameDistance(name, candidates[0]) == nameDistance(name, candidates[1]) {
+		return "", false
+	}
+	return candidates[0], true
+}
+
+// closestNames возвращает до maxSuggestions названий из candidates в пределах допустимого
+// числа опечаток, ближайшие к name. Допуск растет с длиной названия.
+func closestNames(name string, candidates []string) []string {
+	maxDistance := 1 + len([]rune(name))/5
+	if maxDistance > 3 {
+		maxDistance = 3
+	}
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		if d := nameDistance(name, candidate); d <= maxDistance {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// nameDistance — расстояние Левенштейна между названиями без учета регистра и различия «е» и «ё».
+func nameDistance(a, b string) int {
+	normalize := func(s string) []rune {
+		return []rune(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "ё", "е"))
+	}
+	ra, rb := normalize(a), normalize(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// fieldErrors накапливает нарушения проверки запроса по полям, чтобы клиент получил все ошибки
+// запроса в одном ответе 400, а не исправлял их по одной.
+type fieldErrors []models.FieldViolation
+
+// add добавляет нарушение поля field с форматированным сообщением.
+func (e *fieldErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, models.FieldViolation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// score проверяет оценку по шкале 0–10.
+func (e *fieldErrors) score(field string, value float64) {
+	if value < 0 || value > 10 {
+		e.add(field, "must be between 0 and 10")
+	}
+}
+
+// nonNegative проверяет, что значение поля не отрицательно.
+func (e *fieldErrors) nonNegative(field string, value float64) {
+	if value < 0 {
+		e.add(field, "must not be negative")
+	}
+}
+
+// geoPoint проверяет диапазоны широты и долготы точки.
+func (e *fieldErrors) geoPoint(field string, point models.GeoPoint) {
+	if point.Lat < -90 || point.Lat > 90 {
+		e.add(field+".lat", "must be between -90 and 90")
+	}
+	if point.Lon < -180 || point.Lon > 180 {
+		e.add(field+".lon", "must be between -180 and 180")
+	}
+}
+
+// err возвращает ValidationError со всеми нарушениями или nil, если их нет. Сообщение ошибки
+// перечисляет нарушения через точку с запятой в порядке проверки.
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	parts := make([]string, len(e))
+	for i, v := range e {
+		parts[i] = v.Field + " " + v.Message
+	}
+	return &ValidationError{Message: strings.Join(parts, "; "), Violations: e}
+}
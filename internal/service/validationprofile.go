@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/cache"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// ValidationProfileService управляет профилями проверки локаций по типам бизнеса и проверяет
+// по ним локации при записи: у каждого типа бизнеса свои обязательные поля и допустимые
+// диапазоны (например, для кафе обязателен трафик не ниже 3, для склада — нет).
+type ValidationProfileService struct {
+	pgStorage *storage.PostgresStorage
+	profiles  *cache.Cache[map[string]*models.ValidationProfile] // Профили по типам бизнеса для проверки записей
+}
+
+// profilesCacheKey — ключ единственной записи кеша профилей.
+const profilesCacheKey = "all"
+
+// NewValidationProfileService создает новый экземпляр ValidationProfileService. Профили для проверки
+// записей кешируются на cacheTTL: изменения через сервис видны сразу, остальным экземплярам —
+// по истечении cacheTTL.
+func NewValidationProfileService(pgStorage *storage.PostgresStorage, cacheTTL time.Duration) *ValidationProfileService {
+	return &ValidationProfileService{
+		pgStorage: pgStorage,
+		profiles:  cache.New[map[string]*models.ValidationProfile](cacheTTL),
+	}
+}
+
+// List возвращает все профили проверки.
+func (s *ValidationProfileService) List(ctx context.Context) ([]models.ValidationProfile, error) {
+	return s.pgStorage.ListValidationProfiles(ctx)
+}
+
+// Get возвращает профиль проверки типа бизнеса или ErrNotFound.
+func (s *ValidationProfileService) Get(ctx context.Context, businessType string) (*models.ValidationProfile, error) {
+	profile, err := s.pgStorage.GetValidationProfile(ctx, businessType)
+	if errors.Is(err, storage.ErrValidationProfileNotFound) {
+		return nil, ErrNotFound
+	}
+	return profile, err
+}
+
+// Save проверяет профиль и сохраняет его, заменяя прежний профиль типа бизнеса.
+// Профиль применяется к локациям, записываемым после сохранения; записанные ранее не проверяются.
+func (s *ValidationProfileService) Save(ctx context.Context, profile *models.ValidationProfile) error {
+	seen := make(map[string]bool, len(profile.Required))
+	for _, field := range profile.Required {
+		if !models.IsProfileField(field) {
+			return newValidationError("unknown required field %q", field)
+		}
+		if seen[field] {
+			return newValidationError("duplicate required field %q", field)
+		}
+		seen[field] = true
+	}
+	ranged := make(map[string]bool, len(profile.Ranges))
+	for _, r := range profile.Ranges {
+		if !models.IsProfileRangeField(r.Field) {
+			return newValidationError("ranges: %q is not a numeric field", r.Field)
+		}
+		if ranged[r.Field] {
+			return newValidationError("ranges: duplicate field %q", r.Field)
+		}
+		ranged[r.Field] = true
+		if r.Gte == nil && r.Lte == nil {
+			return newValidationError("ranges: %s must have gte or lte", r.Field)
+		}
+		if r.Gte != nil && r.Lte != nil && *r.Gte > *r.Lte {
+			return newValidationError("ranges: %s gte must not exceed lte", r.Field)
+		}
+	}
+
+	businessTypes, err := s.pgStorage.GetBusinessTypes(ctx)
+	if err != nil {
+		return err
+	}
+	known := false
+	for _, bt := range businessTypes {
+		if bt.Name == profile.BusinessType {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return newValidationError("unknown business type %q", profile.BusinessType)
+	}
+
+	if err := s.pgStorage.PutValidationProfile(ctx, profile); err != nil {
+		return err
+	}
+	s.profiles.Clear()
+	return nil
+}
+
+// Delete удаляет профиль проверки типа бизнеса.
+func (s *ValidationProfileService) Delete(ctx context.Context, businessType string) error {
+	err := s.pgStorage.DeleteValidationProfile(ctx, businessType)
+	if errors.Is(err, storage.ErrValidationProfileNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.profiles.Clear()
+	return nil
+}
+
+// Profiles возвращает профили проверки по типам бизнеса для models.CheckProfiles из кеша
+// или PostgreSQL. Возвращаемые профили общие для всех вызовов и не должны изменяться.
+func (s *ValidationProfileService) Profiles(ctx context.Context) (map[string]*models.ValidationProfile, error) {
+	if cached, ok := s.profiles.GetContext(ctx, profilesCacheKey); ok {
+		return cached, nil
+	}
+	profiles, err := s.pgStorage.ListValidationProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[string]*models.ValidationProfile, len(profiles))
+	for i := range profiles {
+		byType[profiles[i].BusinessType] = &profiles[i]
+	}
+	s.profiles.Set(profilesCacheKey, byType)
+	return byType, nil
+}
+
+// Check проверяет локацию по профилям ее типов бизнеса и возвращает ValidationError
+// с нарушениями по полям.
+func (s *ValidationProfileService) Check(ctx context.Context, location *models.Location) error {
+	profiles, err := s.Profiles(ctx)
+	if err != nil {
+		return err
+	}
+	return checkProfiles(location, profiles)
+}
+
+// checkProfiles проверяет локацию по profiles и возвращает ValidationError с нарушениями по полям.
+func checkProfiles(location *models.Location, profiles map[string]*models.ValidationProfile) error {
+	violations := models.CheckProfiles(location, profiles)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		parts[i] = fmt.Sprintf("%s %s (business type %q)", v.Field, v.Message, v.BusinessType)
+	}
+	return &ValidationError{
+		Message:    "location violates validation profiles: " + strings.Join(parts, "; "),
+		Violations: violations,
+	}
+}
@@ -0,0 +1,109 @@
+// Package sizing подбирает параметры следующей версии индекса локаций: число шардов и реплик
+// и refresh_interval — по текущему объему индекса, темпу роста и числу узлов данных кластера.
+package sizing
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// DefaultTargetShardBytes — целевой размер шарда по рекомендациям Elasticsearch (10–50 ГБ).
+	DefaultTargetShardBytes = 30 << 30
+	// DefaultMaxDocsPerShard — предел документов в шарде с запасом от жесткого ограничения Lucene.
+	DefaultMaxDocsPerShard = 200_000_000
+)
+
+// Stats содержит текущие показатели индекса и кластера.
+type Stats struct {
+	Docs       int64   `json:"docs"`         // Число документов в первичных шардах
+	StoreBytes int64   `json:"store_bytes"`  // Размер первичных шардов, байты
+	DocsPerDay float64 `json:"docs_per_day"` // Темп роста, новых документов в день
+	DataNodes  int     `json:"data_nodes"`   // Число узлов данных кластера
+}
+
+// Options задает цели подбора.
+type Options struct {
+	HorizonDays      int   // Срок, на который рассчитывается индекс, дни
+	TargetShardBytes int64 // Целевой размер шарда, байты
+	MaxDocsPerShard  int64 // Максимум документов в шарде
+}
+
+// Recommendation содержит рекомендуемые параметры индекса и обоснование.
+type Recommendation struct {
+	Shards          int      `json:"number_of_shards"`
+	Replicas        int      `json:"number_of_replicas"`
+	RefreshInterval string   `json:"refresh_interval"`
+	AvgDocBytes     float64  `json:"avg_doc_bytes"`
+	ProjectedDocs   int64    `json:"projected_docs"`  // Ожидаемое число документов к концу срока
+	ProjectedBytes  int64    `json:"projected_bytes"` // Ожидаемый размер первичных шардов к концу срока
+	Reasons         []string `json:"reasons"`
+}
+
+// Advise рассчитывает рекомендуемые параметры индекса.
+//
+// Шардов столько, чтобы к концу срока каждый был не больше TargetShardBytes и MaxDocsPerShard.
+// Одна реплика — при двух и более узлах данных (реплика не размещается на узле первичного шарда).
+// refresh_interval растет с темпом записи: частое обновление при интенсивной загрузке
+// создает много мелких сегментов.
+func Advise(stats Stats, opts Options) Recommendation {
+	if opts.TargetShardBytes <= 0 {
+		opts.TargetShardBytes = DefaultTargetShardBytes
+	}
+	if opts.MaxDocsPerShard <= 0 {
+		opts.MaxDocsPerShard = DefaultMaxDocsPerShard
+	}
+
+	var rec Recommendation
+	if stats.Docs > 0 {
+		rec.AvgDocBytes = float64(stats.StoreBytes) / float64(stats.Docs)
+	}
+	rec.ProjectedDocs = stats.Docs + int64(math.Ceil(stats.DocsPerDay*float64(opts.HorizonDays)))
+	rec.ProjectedBytes = int64(rec.AvgDocBytes * float64(rec.ProjectedDocs))
+
+	bySize := int(math.Ceil(float64(rec.ProjectedBytes) / float64(opts.TargetShardBytes)))
+	byDocs := int(math.Ceil(float64(rec.ProjectedDocs) / float64(opts.MaxDocsPerShard)))
+	rec.Shards = max(1, bySize, byDocs)
+	rec.Reasons = append(rec.Reasons, fmt.Sprintf(
+		"%d docs of %.0f bytes growing by %.0f docs/day reach %d docs (%s) in %d days; %s per shard gives %d shard(s)",
+		stats.Docs, rec.AvgDocBytes, stats.DocsPerDay, rec.ProjectedDocs, formatBytes(rec.ProjectedBytes),
+		opts.HorizonDays, formatBytes(opts.TargetShardBytes), rec.Shards))
+	if byDocs > bySize {
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("shard count raised to keep under %d docs per shard", opts.MaxDocsPerShard))
+	}
+
+	if stats.DataNodes >= 2 {
+		rec.Replicas = 1
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("%d data nodes: 1 replica for fault tolerance", stats.DataNodes))
+	} else {
+		rec.Reasons = append(rec.Reasons, "single data node: replicas cannot be allocated")
+	}
+
+	switch {
+	case stats.DocsPerDay >= 1_000_000:
+		rec.RefreshInterval = "30s"
+	case stats.DocsPerDay >= 100_000:
+		rec.RefreshInterval = "10s"
+	case stats.DocsPerDay >= 10_000:
+		rec.RefreshInterval = "5s"
+	default:
+		rec.RefreshInterval = "1s"
+	}
+	rec.Reasons = append(rec.Reasons, fmt.Sprintf("write rate %.0f docs/day: refresh_interval %s", stats.DocsPerDay, rec.RefreshInterval))
+
+	return rec
+}
+
+// formatBytes форматирует размер в двоичных единицах.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exp])
+}
@@ -0,0 +1,148 @@
+// Package sqlhook оборачивает коннектор database/sql, вызывая хуки для каждого запроса,
+// выполненного через соединения: на них построены метрики запросов к PostgreSQL.
+package sqlhook
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+)
+
+// Hook вызывается перед выполнением запроса query и возвращает функцию, которую вызывают
+// по его завершении с ошибкой выполнения.
+type Hook func(ctx context.Context, query string) (done func(err error))
+
+// Connector возвращает коннектор, вызывающий hooks для запросов ExecContext и QueryContext.
+// Запросы подготовленных выражений (Prepare) передаются драйверу без хуков.
+func Connector(next driver.Connector, hooks ...Hook) driver.Connector {
+	if len(hooks) == 0 {
+		return next
+	}
+	return &connector{next: next, hooks: hooks}
+}
+
+type connector struct {
+	next  driver.Connector
+	hooks []Hook
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedConn{Conn: conn, hooks: c.hooks}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// hookedConn передает вызовы соединению драйвера и вызывает хуки вокруг запросов.
+type hookedConn struct {
+	driver.Conn
+	hooks []Hook
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	done := c.before(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	done(err)
+	return result, err
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	done := c.before(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	done(err)
+	return rows, err
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *hookedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// before вызывает хуки и возвращает функцию завершения, вызывающую их в обратном порядке.
+// driver.ErrSkip означает, что database/sql выполнит запрос через подготовленное выражение:
+// такой вызов хукам не передается как ошибка.
+func (c *hookedConn) before(ctx context.Context, query string) func(err error) {
+	dones := make([]func(error), len(c.hooks))
+	for i, hook := range c.hooks {
+		dones[i] = hook(ctx, query)
+	}
+	return func(err error) {
+		if errors.Is(err, driver.ErrSkip) {
+			err = nil
+		}
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+}
+
+// operations — операции, выделяемые Operation; остальные относятся к other.
+var operations = map[string]bool{
+	"select": true, "insert": true, "update": true, "delete": true, "with": true,
+	"create": true, "alter": true, "drop": true, "reset": true, "set": true,
+}
+
+// Operation возвращает первое ключевое слово запроса в нижнем регистре (select, insert...)
+// или other для остальных запросов.
+func Operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	operation := strings.ToLower(fields[0])
+	if !operations[operation] {
+		return "other"
+	}
+	return operation
+}
@@ -0,0 +1,382 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// AggregateSegments рассчитывает агрегаты локаций основного индекса по сегментам —
+// парам регион и подходящий тип бизнеса. Пустые region и businessType не участвуют в фильтрации;
+// учитываются только опубликованные локации.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) AggregateSegments(ctx context.Context, region, businessType string) ([]models.SegmentStats, error) {
+	filterClauses := []map[string]interface{}{publishedFilter()}
+	if region != "" {
+		filterClauses = append(filterClauses, map[string]interface{}{"term": map[string]interface{}{"region": region}})
+	}
+	if businessType != "" {
+		filterClauses = append(filterClauses, map[string]interface{}{"term": map[string]interface{}{"business_types_suitable": businessType}})
+	}
+
+	businessTypes := map[string]interface{}{
+		"field": "business_types_suitable",
+		"size":  1000,
+	}
+	if businessType != "" {
+		// Локация подходит нескольким типам — без include в выдачу попали бы и остальные
+		businessTypes["include"] = []string{businessType}
+	}
+
+	query := map[string]interface{}{
+		"size":  0,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": filterClauses}},
+		"aggs": map[string]interface{}{
+			"regions": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "region",
+					"size":  10000,
+				},
+				"aggs": map[string]interface{}{
+					"business_types": map[string]interface{}{
+						"terms": businessTypes,
+						"aggs": map[string]interface{}{
+							"avg_traffic": map[string]interface{}{
+								"avg": map[string]interface{}{"field": "traffic_score"},
+							},
+							"traffic_percentiles": map[string]interface{}{
+								"percentiles": map[string]interface{}{
+									"field":    "traffic_score",
+									"percents": []float64{50, 90},
+									"keyed":    false,
+								},
+							},
+							"avg_competition": map[string]interface{}{
+								"avg": map[string]interface{}{"field": "competition_density"},
+							},
+							"avg_income": map[string]interface{}{
+								"avg": map[string]interface{}{"field": "demographics.average_income"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return []models.SegmentStats{}, nil
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	type metric struct {
+		Value *float64 `json:"value"`
+	}
+	var result struct {
+		Aggregations struct {
+			Regions struct {
+				Buckets []struct {
+					Key           string `json:"key"`
+					BusinessTypes struct {
+						Buckets []struct {
+							Key                string `json:"key"`
+							DocCount           int    `json:"doc_count"`
+							AvgTraffic         metric `json:"avg_traffic"`
+							AvgCompetition     metric `json:"avg_competition"`
+							AvgIncome          metric `json:"avg_income"`
+							TrafficPercentiles struct {
+								Values []struct {
+									Key   float64  `json:"key"`
+									Value *float64 `json:"value"`
+								} `json:"values"`
+							} `json:"traffic_percentiles"`
+						} `json:"buckets"`
+					} `json:"business_types"`
+				} `json:"buckets"`
+			} `json:"regions"`
+		} `json:"aggregations"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	value := func(m metric) float64 {
+		if m.Value == nil {
+			return 0
+		}
+		return *m.Value
+	}
+
+	segments := []models.SegmentStats{}
+	for _, regionBucket := range result.Aggregations.Regions.Buckets {
+		for _, bucket := range regionBucket.BusinessTypes.Buckets {
+			segment := models.SegmentStats{
+				Region:                regionBucket.Key,
+				BusinessType:          bucket.Key,
+				LocationCount:         bucket.DocCount,
+				AvgTrafficScore:       value(bucket.AvgTraffic),
+				AvgCompetitionDensity: value(bucket.AvgCompetition),
+				AvgIncome:             value(bucket.AvgIncome),
+			}
+			for _, p := range bucket.TrafficPercentiles.Values {
+				if p.Value == nil {
+					continue
+				}
+				switch p.Key {
+				case 50:
+					segment.P50TrafficScore = *p.Value
+				case 90:
+					segment.P90TrafficScore = *p.Value
+				}
+			}
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments, nil
+}
+
+// AggregateRegion рассчитывает агрегаты опубликованных локаций регионов regions: количество,
+// средние traffic_score и competition_density, конкуренцию по подходящим типам бизнеса
+// и распределение дохода. Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) AggregateRegion(ctx context.Context, regions []string) (*models.RegionLocationStats, error) {
+	query := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": []map[string]interface{}{
+			publishedFilter(),
+			{"terms": map[string]interface{}{"region": regions}},
+		}}},
+		"aggs": map[string]interface{}{
+			"avg_traffic": map[string]interface{}{
+				"avg": map[string]interface{}{"field": "traffic_score"},
+			},
+			"avg_competition": map[string]interface{}{
+				"avg": map[string]interface{}{"field": "competition_density"},
+			},
+			"business_types": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "business_types_suitable",
+					"size":  1000,
+				},
+				"aggs": map[string]interface{}{
+					"avg_competition": map[string]interface{}{
+						"avg": map[string]interface{}{"field": "competition_density"},
+					},
+				},
+			},
+			"income": map[string]interface{}{
+				"stats": map[string]interface{}{"field": "demographics.average_income"},
+			},
+			"income_percentiles": map[string]interface{}{
+				"percentiles": map[string]interface{}{
+					"field":    "demographics.average_income",
+					"percents": []float64{25, 50, 75, 90},
+					"keyed":    false,
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	stats := &models.RegionLocationStats{Competition: []models.BusinessTypeCompetition{}}
+	if res.StatusCode == 404 {
+		return stats, nil
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	type metric struct {
+		Value *float64 `json:"value"`
+	}
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			AvgTraffic     metric `json:"avg_traffic"`
+			AvgCompetition metric `json:"avg_competition"`
+			BusinessTypes  struct {
+				Buckets []struct {
+					Key            string `json:"key"`
+					DocCount       int    `json:"doc_count"`
+					AvgCompetition metric `json:"avg_competition"`
+				} `json:"buckets"`
+			} `json:"business_types"`
+			Income struct {
+				Min *float64 `json:"min"`
+				Max *float64 `json:"max"`
+				Avg *float64 `json:"avg"`
+			} `json:"income"`
+			IncomePercentiles struct {
+				Values []struct {
+					Key   float64  `json:"key"`
+					Value *float64 `json:"value"`
+				} `json:"values"`
+			} `json:"income_percentiles"`
+		} `json:"aggregations"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Метрики без документов Elasticsearch возвращает как null
+	value := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+
+	aggs := result.Aggregations
+	stats.Count = result.Hits.Total.Value
+	stats.AvgTrafficScore = value(aggs.AvgTraffic.Value)
+	stats.AvgCompetitionDensity = value(aggs.AvgCompetition.Value)
+	for _, bucket := range aggs.BusinessTypes.Buckets {
+		stats.Competition = append(stats.Competition, models.BusinessTypeCompetition{
+			BusinessType:          bucket.Key,
+			LocationCount:         bucket.DocCount,
+			AvgCompetitionDensity: value(bucket.AvgCompetition.Value),
+		})
+	}
+	stats.Income = models.IncomeDistribution{
+		Min: value(aggs.Income.Min),
+		Max: value(aggs.Income.Max),
+		Avg: value(aggs.Income.Avg),
+	}
+	for _, p := range aggs.IncomePercentiles.Values {
+		switch p.Key {
+		case 25:
+			stats.Income.P25 = value(p.Value)
+		case 50:
+			stats.Income.P50 = value(p.Value)
+		case 75:
+			stats.Income.P75 = value(p.Value)
+		case 90:
+			stats.Income.P90 = value(p.Value)
+		}
+	}
+
+	return stats, nil
+}
+
+// ReplaceAnalyticsSegments заменяет материализованные агрегаты сегментов в одной транзакции.
+func (ps *PostgresStorage) ReplaceAnalyticsSegments(ctx context.Context, segments []models.SegmentStats, refreshedAt time.Time) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM analytics_segments`); err != nil {
+		return fmt.Errorf("failed to clear analytics segments: %w", err)
+	}
+
+	query := `INSERT INTO analytics_segments (region, business_type, location_count, avg_traffic_score,
+			p50_traffic_score, p90_traffic_score, avg_competition_density, avg_income, refreshed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	for _, s := range segments {
+		if _, err := tx.ExecContext(ctx, query, s.Region, s.BusinessType, s.LocationCount, s.AvgTrafficScore,
+			s.P50TrafficScore, s.P90TrafficScore, s.AvgCompetitionDensity, s.AvgIncome, refreshedAt); err != nil {
+			return fmt.Errorf("failed to insert analytics segment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListAnalyticsSegments возвращает материализованные агрегаты сегментов и время их расчета.
+// Пустые region и businessType не участвуют в фильтрации. Если агрегаты еще не рассчитывались,
+// возвращается нулевое время.
+func (ps *PostgresStorage) ListAnalyticsSegments(ctx context.Context, region, businessType string) ([]models.SegmentStats, time.Time, error) {
+	query := `SELECT region, business_type, location_count, avg_traffic_score, p50_traffic_score,
+			p90_traffic_score, avg_competition_density, avg_income, refreshed_at
+		FROM analytics_segments
+		WHERE ($1 = '' OR region = $1) AND ($2 = '' OR business_type = $2)
+		ORDER BY region, business_type`
+
+	rows, err := ps.db.QueryContext(ctx, query, region, businessType)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to query analytics segments: %w", err)
+	}
+	defer rows.Close()
+
+	segments := []models.SegmentStats{}
+	var refreshedAt sql.NullTime
+	for rows.Next() {
+		var s models.SegmentStats
+		if err := rows.Scan(&s.Region, &s.BusinessType, &s.LocationCount, &s.AvgTrafficScore, &s.P50TrafficScore,
+			&s.P90TrafficScore, &s.AvgCompetitionDensity, &s.AvgIncome, &refreshedAt); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to scan analytics segment: %w", err)
+		}
+		segments = append(segments, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("error iterating analytics segments: %w", err)
+	}
+
+	if len(segments) == 0 {
+		// Время расчета нужно и для пустой выборки, чтобы отличать ее от отсутствия данных
+		err := ps.db.QueryRowContext(ctx, `SELECT MAX(refreshed_at) FROM analytics_segments`).Scan(&refreshedAt)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to query analytics refresh time: %w", err)
+		}
+	}
+
+	return segments, refreshedAt.Time, nil
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/lib/pq"
+)
+
+// ErrAPIKeyNotFound возвращается, если API ключ не найден или отозван.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// apiKeyColumns — столбцы api_keys в порядке scanAPIKey.
+const apiKeyColumns = `id, prefix, name, organization, subject, roles, daily_quota, created_at, revoked_at`
+
+// CreateAPIKey сохраняет API ключ с хешем keyHash и записывает время создания в key.
+func (ps *PostgresStorage) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	query := `INSERT INTO api_keys (id, key_hash, prefix, name, organization, subject, roles, daily_quota)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`
+
+	err := ps.db.QueryRowContext(ctx, query, key.ID, keyHash, key.Prefix, key.Name, key.Organization,
+		key.Subject, pq.Array(key.Roles), key.DailyQuota).Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetActiveAPIKeyByHash возвращает неотозванный API ключ по хешу или ErrAPIKeyNotFound.
+func (ps *PostgresStorage) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	key, err := scanAPIKey(ps.db.QueryRowContext(ctx, query, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// APIKeysFingerprint возвращает отпечаток неотозванных API ключей: он меняется при выпуске,
+// изменении и отзыве ключа, поэтому по нему экземпляры обнаруживают изменения для сброса кеша.
+func (ps *PostgresStorage) APIKeysFingerprint(ctx context.Context) (string, error) {
+	query := `SELECT md5(COALESCE(string_agg(
+			id || ':' || organization || ':' || subject || ':' || array_to_string(roles, ',') || ':' || daily_quota,
+			';' ORDER BY id), ''))
+		FROM api_keys WHERE revoked_at IS NULL`
+
+	var fingerprint string
+	if err := ps.db.QueryRowContext(ctx, query).Scan(&fingerprint); err != nil {
+		return "", fmt.Errorf("failed to query api keys fingerprint: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// ListAPIKeys возвращает API ключи, включая отозванные, в порядке создания.
+func (ps *PostgresStorage) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// UpdateAPIKey изменяет заданные в update параметры неотозванного API ключа и возвращает ключ
+// после изменения или ErrAPIKeyNotFound.
+func (ps *PostgresStorage) UpdateAPIKey(ctx context.Context, id string, update *models.APIKeyUpdate) (*models.APIKey, error) {
+	// Пустой список ролей в запросе допустим: он снимает роли, а NULL оставляет их без изменений
+	var roles interface{}
+	if update.Roles != nil {
+		roles = pq.Array(update.Roles)
+	}
+
+	query := `UPDATE api_keys SET
+			name = COALESCE($2, name),
+			roles = COALESCE($3, roles),
+			daily_quota = COALESCE($4, daily_quota)
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(ps.db.QueryRowContext(ctx, query, id, update.Name, roles, update.DailyQuota))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// RevokeAPIKey отзывает API ключ. Возвращает ErrAPIKeyNotFound, если ключ не найден или уже отозван.
+func (ps *PostgresStorage) RevokeAPIKey(ctx context.Context, id string) error {
+	result, err := ps.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// IncrementAPIKeyUsage учитывает запрос ключа keyID за сутки day (YYYY-MM-DD) и возвращает
+// число его запросов за эти сутки с учетом добавленного.
+func (ps *PostgresStorage) IncrementAPIKeyUsage(ctx context.Context, keyID, day string) (int64, error) {
+	query := `
+		INSERT INTO api_key_usage (key_id, day, requests) VALUES ($1, $2, 1)
+		ON CONFLICT (key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+		RETURNING requests
+	`
+
+	var requests int64
+	if err := ps.db.QueryRowContext(ctx, query, keyID, day).Scan(&requests); err != nil {
+		return 0, fmt.Errorf("failed to increment api key usage: %w", err)
+	}
+	return requests, nil
+}
+
+// GetAPIKeyUsage возвращает число запросов ключей за сутки day (YYYY-MM-DD) по ID ключа.
+// Ключи без запросов в результат не входят.
+func (ps *PostgresStorage) GetAPIKeyUsage(ctx context.Context, day string) (map[string]int64, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT key_id, requests FROM api_key_usage WHERE day = $1`, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]int64)
+	for rows.Next() {
+		var keyID string
+		var requests int64
+		if err := rows.Scan(&keyID, &requests); err != nil {
+			return nil, fmt.Errorf("failed to scan api key usage: %w", err)
+		}
+		usage[keyID] = requests
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api key usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// scanAPIKey читает строку api_keys (столбцы apiKeyColumns).
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Prefix, &key.Name, &key.Organization, &key.Subject,
+		pq.Array(&key.Roles), &key.DailyQuota, &key.CreatedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if key.Roles == nil {
+		key.Roles = []string{}
+	}
+	return &key, nil
+}
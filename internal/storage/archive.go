@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ArchiveIndexSuffix добавляется к имени основного индекса для получения имени архивного.
+const ArchiveIndexSuffix = "-archive"
+
+// ArchiveIndex возвращает имя архивного индекса локаций.
+func (es *ElasticsearchStorage) ArchiveIndex() string {
+	return es.archive
+}
+
+// MoveToArchive переносит локации из основного индекса в архивный.
+// Сначала документы индексируются в архив, затем из основного индекса удаляются
+// только успешно заархивированные, поэтому при сбое локация не теряется.
+// Возвращает ID перенесенных локаций.
+func (es *ElasticsearchStorage) MoveToArchive(ctx context.Context, locations []*models.Location) ([]string, error) {
+	if len(locations) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	for _, location := range locations {
+		meta := map[string]interface{}{
+			"index": map[string]interface{}{
+				"_index": es.archive,
+				"_id":    location.ID,
+			},
+		}
+		if err := json.NewEncoder(&buf).Encode(meta); err != nil {
+			return nil, fmt.Errorf("failed to encode meta: %w", err)
+		}
+		if err := json.NewEncoder(&buf).Encode(location); err != nil {
+			return nil, fmt.Errorf("failed to encode location: %w", err)
+		}
+	}
+
+	archived, err := es.bulk(ctx, &buf, "index")
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive locations: %w", err)
+	}
+	if len(archived) == 0 {
+		return nil, nil
+	}
+
+	buf.Reset()
+	for _, id := range archived {
+		meta := map[string]interface{}{
+			"delete": map[string]interface{}{
+				"_index": es.index,
+				"_id":    id,
+			},
+		}
+		if err := json.NewEncoder(&buf).Encode(meta); err != nil {
+			return nil, fmt.Errorf("failed to encode meta: %w", err)
+		}
+	}
+
+	moved, err := es.bulk(ctx, &buf, "delete")
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove archived locations from index: %w", err)
+	}
+
+	return moved, nil
+}
+
+// bulk выполняет Bulk API запрос и возвращает ID документов, для которых операция op
+// завершилась успешно. Для delete отсутствующий документ тоже считается успехом.
+func (es *ElasticsearchStorage) bulk(ctx context.Context, body io.Reader, op string) ([]string, error) {
+	url := fmt.Sprintf("%s/_bulk?refresh=true", es.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute bulk: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error executing bulk", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Items []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		r, ok := item[op]
+		if !ok {
+			continue
+		}
+		if r.Status < 300 || (op == "delete" && r.Status == 404) {
+			ids = append(ids, r.ID)
+		}
+	}
+	if len(ids) > 0 {
+		es.markWritten(true)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// AsyncIndexMaxAttempts — число неудачных попыток доставки документа асинхронной индексации,
+// после которого он получает статус failed. Запись outbox продолжает повторяться: если документ
+// будет проиндексирован позже, статус сменится на indexed и уведомление будет отправлено снова.
+const AsyncIndexMaxAttempts = 5
+
+// ErrAsyncIndexItemNotFound возвращается, если документ асинхронной индексации не найден.
+var ErrAsyncIndexItemNotFound = errors.New("async index item not found")
+
+const asyncIndexItemColumns = `tracking_id, batch_id, location_id, status, COALESCE(error, ''), attempts,
+	callback_url, callback_attempts, callback_delivered_at, COALESCE(callback_error, ''), created_at, completed_at`
+
+// EnqueueAsyncLocations в одной транзакции сохраняет локации, ставит их в outbox и записывает
+// документы асинхронной индексации items. locations[i] соответствует items[i]; для отклоненных
+// документов (статус rejected) локация nil, и они только записываются с уведомлением.
+// Существующая локация заменяется, как в UpdateLocation: время создания, состояние жизненного
+// цикла и история демографии сохраненной версии переносятся в новую.
+func (ps *PostgresStorage) EnqueueAsyncLocations(ctx context.Context, items []*models.AsyncIndexItem, locations []*models.Location) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, item := range items {
+		var outboxID sql.NullInt64
+		if location := locations[i]; location != nil {
+			var createdAt time.Time
+			var previousData []byte
+			err := tx.QueryRowContext(ctx, `SELECT created_at, data FROM locations WHERE id = $1 FOR UPDATE`, location.ID).Scan(&createdAt, &previousData)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				location.RecordDemographics(location.UpdatedAt)
+			case err != nil:
+				return fmt.Errorf("failed to get location: %w", err)
+			default:
+				if err := preserveLocationState(location, createdAt, previousData); err != nil {
+					return err
+				}
+			}
+
+			data, err := json.Marshal(location)
+			if err != nil {
+				return fmt.Errorf("failed to marshal location: %w", err)
+			}
+			query := `INSERT INTO locations (id, data, created_at, updated_at) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`
+			if _, err := tx.ExecContext(ctx, query, location.ID, data, location.CreatedAt, location.UpdatedAt); err != nil {
+				return fmt.Errorf("failed to upsert location: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM location_tombstones WHERE id = $1`, location.ID); err != nil {
+				return fmt.Errorf("failed to delete location tombstone: %w", err)
+			}
+			err = tx.QueryRowContext(ctx,
+				`INSERT INTO location_outbox (location_id, operation, payload) VALUES ($1, $2, $3) RETURNING id`,
+				location.ID, string(models.OutboxUpsert), data,
+			).Scan(&outboxID)
+			if err != nil {
+				return fmt.Errorf("failed to insert outbox entry: %w", err)
+			}
+		}
+
+		// Отклоненный документ завершен сразу, и уведомление о нем отправляется без ожидания
+		query := `INSERT INTO async_index_items (tracking_id, batch_id, location_id, outbox_id, status, error,
+				callback_url, next_callback_at, created_at, completed_at)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7,
+				CASE WHEN $7 <> '' AND $5 = 'rejected' THEN $8::timestamp END, $8,
+				CASE WHEN $5 = 'rejected' THEN $8::timestamp END)`
+		if _, err := tx.ExecContext(ctx, query, item.TrackingID, item.BatchID, item.LocationID, outboxID,
+			string(item.Status), item.Error, item.CallbackURL, item.CreatedAt); err != nil {
+			return fmt.Errorf("failed to insert async index item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// updateAsyncIndexItems отражает результат доставки записи outbox outboxID в документах
+// асинхронной индексации: успех или очередную неудачу (fnErr). При переходе в конечное
+// состояние планируется уведомление. Вызывается в транзакции ProcessOutbox.
+func updateAsyncIndexItems(ctx context.Context, tx *sql.Tx, outboxID int64, fnErr error) error {
+	var err error
+	if fnErr == nil {
+		_, err = tx.ExecContext(ctx, `UPDATE async_index_items SET status = 'indexed', error = NULL,
+				attempts = attempts + 1, completed_at = CURRENT_TIMESTAMP, callback_attempts = 0,
+				next_callback_at = CASE WHEN callback_url <> '' THEN CURRENT_TIMESTAMP END
+			WHERE outbox_id = $1 AND status IN ('pending', 'failed')`, outboxID)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE async_index_items SET error = $2, attempts = attempts + 1,
+				status = CASE WHEN attempts + 1 >= $3 THEN 'failed' ELSE status END,
+				completed_at = CASE WHEN attempts + 1 >= $3 THEN CURRENT_TIMESTAMP END,
+				next_callback_at = CASE WHEN attempts + 1 >= $3 AND callback_url <> '' THEN CURRENT_TIMESTAMP END
+			WHERE outbox_id = $1 AND status = 'pending'`, outboxID, fnErr.Error(), AsyncIndexMaxAttempts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update async index items: %w", err)
+	}
+	return nil
+}
+
+// GetAsyncIndexItem возвращает документ асинхронной индексации по идентификатору отслеживания.
+// Возвращает ErrAsyncIndexItemNotFound, если документ не найден.
+func (ps *PostgresStorage) GetAsyncIndexItem(ctx context.Context, trackingID string) (*models.AsyncIndexItem, error) {
+	query := `SELECT ` + asyncIndexItemColumns + ` FROM async_index_items WHERE tracking_id = $1`
+	item, err := scanAsyncIndexItem(ps.db.QueryRowContext(ctx, query, trackingID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAsyncIndexItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get async index item: %w", err)
+	}
+	return item, nil
+}
+
+// ListAsyncIndexItems возвращает документы пакета batchID в порядке приема.
+func (ps *PostgresStorage) ListAsyncIndexItems(ctx context.Context, batchID string) ([]models.AsyncIndexItem, error) {
+	query := `SELECT ` + asyncIndexItemColumns + ` FROM async_index_items WHERE batch_id = $1 ORDER BY created_at, tracking_id`
+	rows, err := ps.db.QueryContext(ctx, query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query async index items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.AsyncIndexItem{}
+	for rows.Next() {
+		item, err := scanAsyncIndexItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan async index item: %w", err)
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating async index items: %w", err)
+	}
+	return items, nil
+}
+
+// ClaimAsyncIndexCallbacks выбирает до limit документов, уведомления о которых пора отправить,
+// и откладывает их следующую отправку на lease, чтобы другие экземпляры не отправили их
+// одновременно. Возвращает документы с адресами уведомлений.
+func (ps *PostgresStorage) ClaimAsyncIndexCallbacks(ctx context.Context, limit int, lease time.Duration) ([]models.AsyncIndexItem, error) {
+	query := `UPDATE async_index_items SET next_callback_at = CURRENT_TIMESTAMP + $2::float8 * INTERVAL '1 second'
+		WHERE tracking_id IN (
+			SELECT tracking_id FROM async_index_items
+			WHERE next_callback_at IS NOT NULL AND next_callback_at <= CURRENT_TIMESTAMP
+			ORDER BY next_callback_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING ` + asyncIndexItemColumns
+	rows, err := ps.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim async index callbacks: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.AsyncIndexItem
+	for rows.Next() {
+		item, err := scanAsyncIndexItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan async index item: %w", err)
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating async index items: %w", err)
+	}
+	return items, nil
+}
+
+// CompleteAsyncIndexCallback фиксирует результат отправки уведомления о документе. При ошибке
+// sendErr следующая отправка планируется через retryAfter; нулевой retryAfter прекращает попытки.
+func (ps *PostgresStorage) CompleteAsyncIndexCallback(ctx context.Context, trackingID string, sendErr error, retryAfter time.Duration) error {
+	var err error
+	if sendErr == nil {
+		_, err = ps.db.ExecContext(ctx, `UPDATE async_index_items SET next_callback_at = NULL,
+			callback_delivered_at = CURRENT_TIMESTAMP, callback_error = NULL,
+			callback_attempts = callback_attempts + 1 WHERE tracking_id = $1`, trackingID)
+	} else {
+		_, err = ps.db.ExecContext(ctx, `UPDATE async_index_items SET callback_error = $2,
+			callback_attempts = callback_attempts + 1,
+			next_callback_at = CASE WHEN $3::float8 > 0 THEN CURRENT_TIMESTAMP + $3::float8 * INTERVAL '1 second' END
+			WHERE tracking_id = $1`, trackingID, sendErr.Error(), retryAfter.Seconds())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update async index callback: %w", err)
+	}
+	return nil
+}
+
+// DeleteAsyncIndexItems удаляет документы, завершенные раньше before, без ожидающих уведомлений.
+// Возвращает число удаленных документов.
+func (ps *PostgresStorage) DeleteAsyncIndexItems(ctx context.Context, before time.Time) (int64, error) {
+	res, err := ps.db.ExecContext(ctx,
+		`DELETE FROM async_index_items WHERE completed_at < $1 AND next_callback_at IS NULL`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete async index items: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func scanAsyncIndexItem(row rowScanner) (*models.AsyncIndexItem, error) {
+	var item models.AsyncIndexItem
+	var status string
+	var deliveredAt, completedAt sql.NullTime
+	if err := row.Scan(&item.TrackingID, &item.BatchID, &item.LocationID, &status, &item.Error, &item.Attempts,
+		&item.CallbackURL, &item.CallbackAttempts, &deliveredAt, &item.CallbackError, &item.CreatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	item.Status = models.AsyncIndexStatus(status)
+	if deliveredAt.Valid {
+		item.CallbackDeliveredAt = &deliveredAt.Time
+	}
+	if completedAt.Valid {
+		item.CompletedAt = &completedAt.Time
+	}
+	return &item, nil
+}
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+const (
+	// DefaultBulkFlushBytes — размер тела Bulk запроса по умолчанию, как у esutil.BulkIndexer.
+	DefaultBulkFlushBytes = 5 << 20
+	// DefaultBulkWorkers — число параллельных Bulk запросов по умолчанию.
+	DefaultBulkWorkers = 2
+)
+
+// BulkIndexOptions задает параметры массовой индексации BulkIndexLocations.
+type BulkIndexOptions struct {
+	FlushBytes int // Размер тела одного Bulk запроса, байты (0 — DefaultBulkFlushBytes)
+	Workers    int // Число параллельных Bulk запросов (0 — DefaultBulkWorkers)
+	// OnFailure вызывается для каждой локации, которую не удалось проиндексировать: с ошибкой
+	// документа из ответа (*BulkItemError) или ошибкой всего запроса. Вызовы не пересекаются
+	OnFailure func(location *models.Location, err error)
+}
+
+// BulkIndexStats — итог массовой индексации.
+type BulkIndexStats struct {
+	Indexed  int `json:"indexed"`
+	Failed   int `json:"failed"`
+	Requests int `json:"requests"` // Отправлено Bulk запросов
+}
+
+// BulkItemError — ошибка индексации документа из ответа Bulk API.
+type BulkItemError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("status %d: %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// bulkItem — результат операции в ответе Bulk API.
+type bulkItem struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// err возвращает ошибку операции или nil при успехе.
+func (item bulkItem) err() error {
+	if item.Status < 300 {
+		return nil
+	}
+	itemErr := &BulkItemError{Status: item.Status}
+	if item.Error != nil {
+		itemErr.Type, itemErr.Reason = item.Error.Type, item.Error.Reason
+	}
+	return itemErr
+}
+
+// bulkChunk — часть локаций и NDJSON тело Bulk запроса для нее.
+type bulkChunk struct {
+	body      *bytes.Buffer
+	locations []*models.Location
+}
+
+// BulkIndexLocations индексирует локации Bulk запросами размером около opts.FlushBytes,
+// которые отправляют opts.Workers параллельных воркеров. Неудачные документы не прерывают
+// индексацию: они передаются в opts.OnFailure и учитываются в Failed. Возвращает ошибку,
+// если хотя бы один документ не проиндексирован, или при отмене ctx.
+// Использует прямые HTTP запросы для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) BulkIndexLocations(ctx context.Context, locations []*models.Location, opts BulkIndexOptions) (*BulkIndexStats, error) {
+	if opts.FlushBytes <= 0 {
+		opts.FlushBytes = DefaultBulkFlushBytes
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultBulkWorkers
+	}
+
+	chunks := make(chan bulkChunk, opts.Workers)
+	stats := &BulkIndexStats{}
+	var mu sync.Mutex // Защищает stats и вызовы OnFailure
+	fail := func(location *models.Location, err error) {
+		stats.Failed++
+		if opts.OnFailure != nil {
+			opts.OnFailure(location, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				items, err := es.sendBulk(ctx, chunk.body)
+
+				mu.Lock()
+				stats.Requests++
+				for i, location := range chunk.locations {
+					switch {
+					case err != nil:
+						fail(location, err)
+					case i >= len(items):
+						fail(location, fmt.Errorf("no result in bulk response"))
+					case items[i].err() != nil:
+						fail(location, items[i].err())
+					default:
+						stats.Indexed++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	encodeErr := es.chunkIndexBulk(ctx, locations, opts.FlushBytes, chunks)
+	close(chunks)
+	wg.Wait()
+
+	if stats.Indexed > 0 {
+		es.markWritten(false)
+	}
+	if encodeErr != nil {
+		return stats, encodeErr
+	}
+	if stats.Failed > 0 {
+		return stats, fmt.Errorf("%d of %d locations failed to index", stats.Failed, len(locations))
+	}
+	return stats, nil
+}
+
+// chunkIndexBulk кодирует операции индексации локаций и отправляет в chunks тела размером
+// не меньше flushBytes (последнее — с остатком). Останавливается при отмене ctx.
+func (es *ElasticsearchStorage) chunkIndexBulk(ctx context.Context, locations []*models.Location, flushBytes int, chunks chan<- bulkChunk) error {
+	chunk := bulkChunk{body: &bytes.Buffer{}}
+	flush := func() error {
+		select {
+		case chunks <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		chunk = bulkChunk{body: &bytes.Buffer{}}
+		return nil
+	}
+
+	for _, location := range locations {
+		if err := es.encodeIndexOperation(chunk.body, location); err != nil {
+			return err
+		}
+		chunk.locations = append(chunk.locations, location)
+		if chunk.body.Len() >= flushBytes {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(chunk.locations) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// sendBulk отправляет NDJSON тело в Bulk API и возвращает результаты операций в порядке запроса.
+func (es *ElasticsearchStorage) sendBulk(ctx context.Context, body io.Reader) ([]bulkItem, error) {
+	url := fmt.Sprintf("%s/_bulk", es.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bulk index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		body, _ := io.ReadAll(res.Body)
+		return nil, &bulkRequestError{status: res.StatusCode, body: string(body)}
+	}
+
+	var parsed struct {
+		Items []map[string]bulkItem `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	items := make([]bulkItem, len(parsed.Items))
+	failed := 0
+	for i, item := range parsed.Items {
+		// Каждый элемент содержит один ключ — тип операции
+		for _, result := range item {
+			items[i] = result
+		}
+		if items[i].err() != nil {
+			failed++
+		}
+	}
+	if es.recorder != nil {
+		es.recorder.ObserveBulkIndex(len(items)-failed, failed)
+	}
+	return items, nil
+}
+
+// bulkRequestError — ответ Bulk API с кодом ошибки на весь запрос.
+type bulkRequestError struct {
+	status int
+	body   string
+}
+
+func (e *bulkRequestError) Error() string {
+	return fmt.Sprintf("error bulk indexing: status %d, body: %s", e.status, e.body)
+}
+
+// LogAttrs возвращает поля журнала с подробностями ошибки.
+func (e *bulkRequestError) LogAttrs() []slog.Attr {
+	return []slog.Attr{slog.String("source", "elasticsearch"), slog.Int("status", e.status)}
+}
+
+// encodeIndexOperation добавляет в buf операцию индексации локации в формате NDJSON.
+func (es *ElasticsearchStorage) encodeIndexOperation(buf *bytes.Buffer, location *models.Location) error {
+	meta := map[string]interface{}{
+		"index": map[string]interface{}{
+			"_index": es.index,
+			"_id":    location.ID,
+		},
+	}
+
+	if err := json.NewEncoder(buf).Encode(meta); err != nil {
+		return fmt.Errorf("failed to encode meta: %w", err)
+	}
+	if err := json.NewEncoder(buf).Encode(location); err != nil {
+		return fmt.Errorf("failed to encode location: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ListLocationChanges возвращает до limit изменений локаций после позиции (afterTime, afterID)
+// в порядке (время изменения, ID): сохранения по updated_at из locations и удаления из
+// location_tombstones. Изменения позже until не возвращаются.
+func (ps *PostgresStorage) ListLocationChanges(ctx context.Context, afterTime time.Time, afterID string, until time.Time, limit int) ([]models.LocationChange, error) {
+	query := `
+		SELECT id, 'upsert', updated_at, data FROM locations
+		WHERE (updated_at, id) > ($1, $2) AND updated_at <= $3
+		UNION ALL
+		SELECT id, 'delete', deleted_at, NULL FROM location_tombstones
+		WHERE (deleted_at, id) > ($1, $2) AND deleted_at <= $3
+		ORDER BY 3, 1
+		LIMIT $4`
+
+	rows, err := ps.db.QueryContext(ctx, query, afterTime, afterID, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query location changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.LocationChange
+	for rows.Next() {
+		var (
+			change    models.LocationChange
+			operation string
+			data      sql.NullString
+		)
+		if err := rows.Scan(&change.ID, &operation, &change.ChangedAt, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan location change: %w", err)
+		}
+		change.Operation = models.OutboxOperation(operation)
+		if data.Valid {
+			var location models.Location
+			if err := json.Unmarshal([]byte(data.String), &location); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+			}
+			change.Location = &location
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCityNotFound возвращается, если города с указанным ID нет.
+	ErrCityNotFound = errors.New("city not found")
+	// ErrCityExists возвращается, если в регионе уже есть город с таким именем.
+	ErrCityExists = errors.New("city already exists")
+	// ErrCityRegionNotFound возвращается, если региона города нет в справочнике.
+	ErrCityRegionNotFound = errors.New("city region not found")
+)
+
+// Коды ошибок PostgreSQL, которые переводятся в ошибки справочника городов.
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+const citySelect = `SELECT c.id, c.name, c.region_id, r.name, c.center_lat, c.center_lon, c.population,
+		c.created_at, c.updated_at
+	FROM cities c JOIN regions r ON r.id = c.region_id`
+
+// GetCities возвращает все города справочника с названиями их регионов.
+// Результаты отсортированы по имени.
+func (ps *PostgresStorage) GetCities(ctx context.Context) ([]*models.City, error) {
+	rows, err := ps.db.QueryContext(ctx, citySelect+` ORDER BY c.name, c.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cities: %w", err)
+	}
+	defer rows.Close()
+
+	var cities []*models.City
+	for rows.Next() {
+		city, err := scanCity(rows)
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cities: %w", err)
+	}
+
+	return cities, nil
+}
+
+// GetCity возвращает город по ID или ErrCityNotFound.
+func (ps *PostgresStorage) GetCity(ctx context.Context, id int) (*models.City, error) {
+	city, err := scanCity(ps.db.QueryRowContext(ctx, citySelect+` WHERE c.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCityNotFound
+	}
+	return city, err
+}
+
+// CreateCity добавляет город и записывает в city его ID и время создания.
+// Возвращает ErrCityExists или ErrCityRegionNotFound.
+func (ps *PostgresStorage) CreateCity(ctx context.Context, city *models.City) error {
+	query := `INSERT INTO cities (name, region_id, center_lat, center_lon, population)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	err := ps.db.QueryRowContext(ctx, query, city.Name, city.RegionID, city.Center.Lat, city.Center.Lon, city.Population).
+		Scan(&city.ID, &city.CreatedAt, &city.UpdatedAt)
+	if err != nil {
+		return cityError("failed to create city", err)
+	}
+	return nil
+}
+
+// UpdateCity заменяет данные города city.ID и записывает в city время создания и обновления.
+// Возвращает ErrCityNotFound, ErrCityExists или ErrCityRegionNotFound.
+func (ps *PostgresStorage) UpdateCity(ctx context.Context, city *models.City) error {
+	query := `UPDATE cities
+		SET name = $2, region_id = $3, center_lat = $4, center_lon = $5, population = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at`
+
+	err := ps.db.QueryRowContext(ctx, query, city.ID, city.Name, city.RegionID, city.Center.Lat, city.Center.Lon, city.Population).
+		Scan(&city.CreatedAt, &city.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCityNotFound
+	}
+	if err != nil {
+		return cityError("failed to update city", err)
+	}
+	return nil
+}
+
+// DeleteCity удаляет город. Возвращает ErrCityNotFound, если его нет.
+func (ps *PostgresStorage) DeleteCity(ctx context.Context, id int) error {
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM cities WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete city: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrCityNotFound
+	}
+	return nil
+}
+
+// cityError переводит нарушения ограничений таблицы cities в ошибки справочника городов.
+func cityError(message string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			return ErrCityExists
+		case pqForeignKeyViolation:
+			return ErrCityRegionNotFound
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// scanCity читает строку запроса citySelect.
+func scanCity(row rowScanner) (*models.City, error) {
+	var city models.City
+	err := row.Scan(&city.ID, &city.Name, &city.RegionID, &city.Region, &city.Center.Lat, &city.Center.Lon,
+		&city.Population, &city.CreatedAt, &city.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan city: %w", err)
+	}
+	return &city, nil
+}
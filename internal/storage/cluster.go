@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// UpsertClusterInstance сохраняет роль экземпляра и обновляет время его heartbeat.
+func (ps *PostgresStorage) UpsertClusterInstance(ctx context.Context, instance *models.ClusterInstance) error {
+	query := `INSERT INTO cluster_instances (id, role, version, started_at, heartbeat_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET role = EXCLUDED.role, version = EXCLUDED.version,
+			started_at = EXCLUDED.started_at, heartbeat_at = EXCLUDED.heartbeat_at`
+
+	_, err := ps.db.ExecContext(ctx, query, instance.ID, instance.Role, instance.Version, instance.StartedAt, instance.HeartbeatAt)
+	if err != nil {
+		return fmt.Errorf("failed to save cluster instance: %w", err)
+	}
+	return nil
+}
+
+// ListClusterInstances возвращает экземпляры с heartbeat не раньше since, упорядоченные по ID.
+// Записи экземпляров без heartbeat дольше since удаляются.
+func (ps *PostgresStorage) ListClusterInstances(ctx context.Context, since time.Time) ([]models.ClusterInstance, error) {
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM cluster_instances WHERE heartbeat_at < $1`, since); err != nil {
+		return nil, fmt.Errorf("failed to delete stale cluster instances: %w", err)
+	}
+
+	query := `SELECT id, role, version, started_at, heartbeat_at FROM cluster_instances ORDER BY id`
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster instances: %w", err)
+	}
+	defer rows.Close()
+
+	instances := []models.ClusterInstance{}
+	for rows.Next() {
+		var instance models.ClusterInstance
+		if err := rows.Scan(&instance.ID, &instance.Role, &instance.Version, &instance.StartedAt, &instance.HeartbeatAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster instance: %w", err)
+		}
+		instances = append(instances, instance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cluster instances: %w", err)
+	}
+
+	return instances, nil
+}
+
+// DeleteClusterInstance удаляет запись экземпляра при его остановке.
+func (ps *PostgresStorage) DeleteClusterInstance(ctx context.Context, id string) error {
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM cluster_instances WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete cluster instance: %w", err)
+	}
+	return nil
+}
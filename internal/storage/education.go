@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ReplaceEducationInstitutions заменяет справочник учебных заведений в одной транзакции.
+func (ps *PostgresStorage) ReplaceEducationInstitutions(ctx context.Context, institutions []models.EducationInstitution) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM education_institutions`); err != nil {
+		return fmt.Errorf("failed to clear education institutions: %w", err)
+	}
+
+	query := `INSERT INTO education_institutions (name, kind, lat, lon) VALUES ($1, $2, $3, $4)`
+	for _, inst := range institutions {
+		if _, err := tx.ExecContext(ctx, query, inst.Name, inst.Kind, inst.Coordinates.Lat, inst.Coordinates.Lon); err != nil {
+			return fmt.Errorf("failed to insert education institution: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListEducationInstitutions возвращает все учебные заведения.
+func (ps *PostgresStorage) ListEducationInstitutions(ctx context.Context) ([]models.EducationInstitution, error) {
+	query := `SELECT id, name, kind, lat, lon FROM education_institutions ORDER BY id`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query education institutions: %w", err)
+	}
+	defer rows.Close()
+
+	var institutions []models.EducationInstitution
+	for rows.Next() {
+		var inst models.EducationInstitution
+		if err := rows.Scan(&inst.ID, &inst.Name, &inst.Kind, &inst.Coordinates.Lat, &inst.Coordinates.Lon); err != nil {
+			return nil, fmt.Errorf("failed to scan education institution: %w", err)
+		}
+		institutions = append(institutions, inst)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating education institutions: %w", err)
+	}
+
+	return institutions, nil
+}
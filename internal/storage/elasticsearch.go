@@ -5,23 +5,41 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
 	"github.com/akozadaev/go_es_analytical_system/internal/models"
 	"github.com/elastic/go-elasticsearch/v8"
-	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
+// ErrLocationNotFound возвращается, если локация с указанным ID отсутствует в индексе.
+var ErrLocationNotFound = errors.New("location not found")
+
 // ElasticsearchStorage предоставляет методы для работы с Elasticsearch/OpenSearch.
 // Использует прямые HTTP запросы для совместимости с OpenSearch.
 type ElasticsearchStorage struct {
 	client     *elasticsearch.Client // Официальный клиент Elasticsearch
-	index      string                 // Имя индекса для локаций
-	httpClient *http.Client           // HTTP клиент для прямых запросов
-	baseURL    string                 // Базовый URL Elasticsearch/OpenSearch
+	index      string                // Имя индекса для локаций
+	archive    string                // Имя архивного индекса для холодных локаций
+	percolator string                // Имя индекса запросов сохраненных поисков (percolator)
+	httpClient *http.Client          // HTTP клиент для прямых запросов
+	baseURL    string                // Базовый URL Elasticsearch/OpenSearch
+	// recommendTemplate — ID активного шаблона поиска рекомендаций в кластере; пусто — встроенный запрос
+	recommendTemplate atomic.Pointer[string]
+	// generation — поколение данных индексов (см. Generation), refreshedAt — время начала
+	// последнего принудительного обновления индекса, наносекунды Unix
+	generation  atomic.Uint64
+	refreshedAt atomic.Int64
+	recorder    metrics.StorageRecorder // Метрики Bulk запросов (nil — не собираются)
+	// generationHooks вызываются после каждого увеличения поколения (см. OnGenerationChange)
+	generationHooks []func(generation uint64)
 }
 
 // NewElasticsearchStorageWithURL создает новый экземпляр ElasticsearchStorage с указанным URL.
@@ -30,11 +48,24 @@ func NewElasticsearchStorageWithURL(client *elasticsearch.Client, index string,
 	return &ElasticsearchStorage{
 		client:     client,
 		index:      index,
+		archive:    index + ArchiveIndexSuffix,
+		percolator: index + SavedSearchIndexSuffix,
 		httpClient: &http.Client{},
 		baseURL:    baseURL,
 	}
 }
 
+// SetTransport задает транспорт прямых HTTP запросов к Elasticsearch/OpenSearch
+// (например, для внедрения сбоев на тестовом стенде, см. пакет chaos).
+func (es *ElasticsearchStorage) SetTransport(transport http.RoundTripper) {
+	es.httpClient.Transport = transport
+}
+
+// SetRecorder задает получателя метрик документов Bulk запросов.
+func (es *ElasticsearchStorage) SetRecorder(recorder metrics.StorageRecorder) {
+	es.recorder = recorder
+}
+
 // NewElasticsearchStorage создает новый экземпляр ElasticsearchStorage с URL по умолчанию.
 // Использует http://localhost:9200 как базовый URL.
 func NewElasticsearchStorage(client *elasticsearch.Client, index string) *ElasticsearchStorage {
@@ -42,10 +73,101 @@ func NewElasticsearchStorage(client *elasticsearch.Client, index string) *Elasti
 	return NewElasticsearchStorageWithURL(client, index, "http://localhost:9200")
 }
 
-// CreateIndex создает индекс в Elasticsearch/OpenSearch с заданным маппингом.
-// Если индекс уже существует, функция возвращает nil без ошибки.
+// CreateIndex создает индекс локаций, архивный индекс и индекс сохраненных поисков
+// в Elasticsearch/OpenSearch с заданным маппингом. Если индексы уже существуют, функция
+// возвращает nil без ошибки.
 func (es *ElasticsearchStorage) CreateIndex(ctx context.Context, mappingJSON string) error {
-	res, err := es.client.Indices.Exists([]string{es.index})
+	if err := es.createIndex(ctx, es.index, mappingJSON); err != nil {
+		return err
+	}
+	if err := es.createIndex(ctx, es.archive, mappingJSON); err != nil {
+		return err
+	}
+	percolatorJSON, err := percolatorMapping(mappingJSON)
+	if err != nil {
+		return err
+	}
+	return es.createIndex(ctx, es.percolator, percolatorJSON)
+}
+
+// UpdateMapping добавляет в существующие индексы локаций, архива и сохраненных поисков поля
+// маппинга mappingJSON, которых в них нет: CreateIndex не меняет маппинг созданных ранее индексов.
+// Типы существующих полей не меняются: если поле уже проиндексировано с другим типом (например,
+// demographics.segments как object вместо nested), кластер отклоняет обновление, и индекс
+// требует переиндексации. Возвращает пути добавленных в индекс локаций полей.
+func (es *ElasticsearchStorage) UpdateMapping(ctx context.Context, mappingJSON string) ([]string, error) {
+	percolatorJSON, err := percolatorMapping(mappingJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, target := range []struct{ name, mapping string }{
+		{es.index, mappingJSON},
+		{es.archive, mappingJSON},
+		{es.percolator, percolatorJSON},
+	} {
+		fields, err := es.updateMapping(ctx, target.name, target.mapping)
+		if err != nil {
+			return nil, err
+		}
+		if target.name == es.index {
+			added = fields
+		}
+	}
+	return added, nil
+}
+
+// updateMapping добавляет в маппинг индекса name недостающие поля mappingJSON.
+func (es *ElasticsearchStorage) updateMapping(ctx context.Context, name, mappingJSON string) ([]string, error) {
+	var desired struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(mappingJSON), &desired); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+	current, err := es.getMapping(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	properties, _ := current["properties"].(map[string]interface{})
+	missing := missingProperties(desired.Mappings.Properties, properties, "")
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	sort.Strings(missing)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"properties": mappingAdditions(desired.Mappings.Properties, properties),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+	url := fmt.Sprintf("%s/%s/_mapping", es.baseURL, name)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update mapping of %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError(fmt.Sprintf("error adding fields %s to mapping of %s", strings.Join(missing, ", "), name),
+			res.StatusCode, res.Body)
+	}
+	return missing, nil
+}
+
+// createIndex создает индекс name с маппингом, если он еще не существует.
+func (es *ElasticsearchStorage) createIndex(ctx context.Context, name string, mappingJSON string) error {
+	res, err := es.client.Indices.Exists([]string{name})
 	if err != nil {
 		return fmt.Errorf("failed to check index existence: %w", err)
 	}
@@ -58,103 +180,121 @@ func (es *ElasticsearchStorage) CreateIndex(ctx context.Context, mappingJSON str
 
 	// Создаем индекс с маппингом
 	res, err = es.client.Indices.Create(
-		es.index,
+		name,
 		es.client.Indices.Create.WithBody(strings.NewReader(mappingJSON)),
 		es.client.Indices.Create.WithContext(ctx),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create index: %w", err)
+		return fmt.Errorf("failed to create index %s: %w", name, err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
 		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("error creating index: %s", string(body))
+		return fmt.Errorf("error creating index %s: %s", name, string(body))
 	}
 
 	return nil
 }
 
 // IndexLocation индексирует одну локацию в Elasticsearch/OpenSearch.
-// Если локация с таким ID уже существует, она будет обновлена.
+// Если локация с таким ID уже существует, она будет обновлена. Индекс не обновляется
+// принудительно: поиску запись видна после периодического обновления (refresh_interval).
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
 func (es *ElasticsearchStorage) IndexLocation(ctx context.Context, location *models.Location) error {
 	body, err := json.Marshal(location)
 	if err != nil {
 		return fmt.Errorf("failed to marshal location: %w", err)
 	}
 
-	req := esapi.IndexRequest{
-		Index:      es.index,
-		DocumentID: location.ID,
-		Body:       bytes.NewReader(body),
-		Refresh:    "true",
+	url := fmt.Sprintf("%s/%s/_doc/%s", es.baseURL, es.index, location.ID)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	res, err := req.Do(ctx, es.client)
+	res, err := es.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to index location: %w", err)
 	}
 	defer res.Body.Close()
 
-	if res.IsError() {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("error indexing location: %s", string(body))
+	if res.StatusCode >= 400 {
+		return newESError("error indexing location", res.StatusCode, res.Body)
 	}
 
+	es.markWritten(false)
 	return nil
 }
 
-// BulkIndexLocations индексирует несколько локаций за один запрос.
-// Использует Bulk API для эффективной массовой индексации.
-// Использует прямые HTTP запросы для совместимости с OpenSearch.
-func (es *ElasticsearchStorage) BulkIndexLocations(ctx context.Context, locations []*models.Location) error {
-	var buf bytes.Buffer
+// BulkIndexResult — итог Bulk API запроса индексации с разбором ответов по документам.
+type BulkIndexResult struct {
+	Indexed  int                // Успешно проиндексировано
+	Rejected []*models.Location // Отклонены из-за перегрузки кластера (429), можно повторить
+	Failed   int                // Отклонены по другим причинам
+}
 
+// IndexBatch индексирует пачку локаций и разбирает ответ по документам.
+// Ответ 429 на весь запрос не считается ошибкой: все документы возвращаются в Rejected,
+// чтобы вызывающая сторона могла снизить нагрузку и повторить.
+func (es *ElasticsearchStorage) IndexBatch(ctx context.Context, locations []*models.Location) (*BulkIndexResult, error) {
+	var body bytes.Buffer
 	for _, location := range locations {
-		meta := map[string]interface{}{
-			"index": map[string]interface{}{
-				"_index": es.index,
-				"_id":    location.ID,
-			},
-		}
-
-		if err := json.NewEncoder(&buf).Encode(meta); err != nil {
-			return fmt.Errorf("failed to encode meta: %w", err)
-		}
-
-		if err := json.NewEncoder(&buf).Encode(location); err != nil {
-			return fmt.Errorf("failed to encode location: %w", err)
+		if err := es.encodeIndexOperation(&body, location); err != nil {
+			return nil, err
 		}
 	}
 
-	// Используем прямой HTTP запрос для обхода проверки типа сервера
-	url := fmt.Sprintf("%s/_bulk", es.baseURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	items, err := es.sendBulk(ctx, &body)
+	var requestErr *bulkRequestError
+	if errors.As(err, &requestErr) && requestErr.status == http.StatusTooManyRequests {
+		return &BulkIndexResult{Rejected: locations}, nil
 	}
-	req.Header.Set("Content-Type", "application/x-ndjson")
-
-	res, err := es.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to bulk index: %w", err)
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("error bulk indexing: status %d, body: %s", res.StatusCode, string(body))
+	// Элементы ответа Bulk API идут в порядке операций запроса
+	result := &BulkIndexResult{}
+	for i, item := range items {
+		switch {
+		case item.Status < 300:
+			result.Indexed++
+		case item.Status == http.StatusTooManyRequests && i < len(locations):
+			result.Rejected = append(result.Rejected, locations[i])
+		default:
+			result.Failed++
+		}
+	}
+	if result.Indexed > 0 {
+		es.markWritten(false)
 	}
 
-	return nil
+	return result, nil
 }
 
 // GetLocation получает локацию по её уникальному идентификатору.
 // Возвращает ошибку, если локация не найдена.
 // Использует прямой HTTP запрос для совместимости с OpenSearch.
 func (es *ElasticsearchStorage) GetLocation(ctx context.Context, id string) (*models.Location, error) {
+	return es.getLocation(ctx, es.index, id)
+}
+
+// GetArchivedLocation получает локацию из архивного индекса.
+// Возвращает ErrLocationNotFound, если локация не архивирована.
+func (es *ElasticsearchStorage) GetArchivedLocation(ctx context.Context, id string) (*models.Location, error) {
+	location, err := es.getLocation(ctx, es.archive, id)
+	if err != nil {
+		return nil, err
+	}
+	location.Archived = true
+	return location, nil
+}
+
+func (es *ElasticsearchStorage) getLocation(ctx context.Context, index, id string) (*models.Location, error) {
 	// Используем прямой HTTP запрос для обхода проверки типа сервера
-	url := fmt.Sprintf("%s/%s/_doc/%s", es.baseURL, es.index, id)
+	url := fmt.Sprintf("%s/%s/_doc/%s", es.baseURL, index, id)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -167,16 +307,15 @@ func (es *ElasticsearchStorage) GetLocation(ctx context.Context, id string) (*mo
 	defer res.Body.Close()
 
 	if res.StatusCode == 404 {
-		return nil, fmt.Errorf("location not found")
+		return nil, ErrLocationNotFound
 	}
 
 	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("error getting location: status %d, body: %s", res.StatusCode, string(body))
+		return nil, newESError("error getting location", res.StatusCode, res.Body)
 	}
 
 	var result struct {
-		Found  bool           `json:"found"`
+		Found  bool            `json:"found"`
 		Source models.Location `json:"_source"`
 	}
 
@@ -185,25 +324,46 @@ func (es *ElasticsearchStorage) GetLocation(ctx context.Context, id string) (*mo
 	}
 
 	if !result.Found {
-		return nil, fmt.Errorf("location not found")
+		return nil, ErrLocationNotFound
 	}
 
 	return &result.Source, nil
 }
 
+// RecommendResult содержит страницу результатов поиска рекомендаций.
+type RecommendResult struct {
+	Locations []*models.Location
+	Total     int           // Всего локаций, подходящих под запрос
+	Next      []interface{} // Значения сортировки последней локации для search_after; nil — страница последняя
+}
+
 // RecommendLocations выполняет поиск и ранжирование локаций на основе критериев запроса.
-// Использует комбинированное ранжирование по traffic_score, competition_density и демографии.
+// Использует комбинированное ранжирование по traffic_score, competition_density и демографии:
+// встроенный запрос или активный шаблон поиска (SetRecommendTemplate).
+// Следующая страница запрашивается с req.SearchAfter = Next предыдущей.
 // Использует прямые HTTP запросы для совместимости с OpenSearch.
-func (es *ElasticsearchStorage) RecommendLocations(ctx context.Context, req *models.RecommendRequest) ([]*models.Location, error) {
+func (es *ElasticsearchStorage) RecommendLocations(ctx context.Context, req *models.RecommendRequest) (*RecommendResult, error) {
 	query := es.buildRecommendQuery(req)
 
+	// Архивный индекс подключается к поиску только по явному запросу
+	index := es.index
+	if req.IncludeArchived {
+		index = es.index + "," + es.archive
+	}
+
+	// Используем прямой HTTP запрос для обхода проверки типа сервера
+	url := fmt.Sprintf("%s/%s/_search?size=%d", es.baseURL, index, req.Limit)
+
+	// Активный шаблон поиска задает структуру запроса, сервис передает только параметры
+	if id := es.recommendTemplateFor(req); id != "" {
+		query = map[string]interface{}{"id": id, "params": recommendTemplateParams(req)}
+		url = fmt.Sprintf("%s/%s/_search/template", es.baseURL, index)
+	}
+
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(query); err != nil {
 		return nil, fmt.Errorf("failed to encode query: %w", err)
 	}
-
-	// Используем прямой HTTP запрос для обхода проверки типа сервера
-	url := fmt.Sprintf("%s/%s/_search?size=%d", es.baseURL, es.index, req.Limit)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -217,8 +377,7 @@ func (es *ElasticsearchStorage) RecommendLocations(ctx context.Context, req *mod
 	defer res.Body.Close()
 
 	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("error searching: status %d, body: %s", res.StatusCode, string(body))
+		return nil, newESError("error searching", res.StatusCode, res.Body)
 	}
 
 	var result struct {
@@ -227,30 +386,124 @@ func (es *ElasticsearchStorage) RecommendLocations(ctx context.Context, req *mod
 				Value int `json:"value"`
 			} `json:"total"`
 			Hits []struct {
-				Source models.Location `json:"_source"`
-				Score  float64         `json:"_score"`
+				Index     string          `json:"_index"`
+				Source    models.Location `json:"_source"`
+				Score     float64         `json:"_score"`
+				Sort      []interface{}   `json:"sort"`
+				InnerHits struct {
+					Segments struct {
+						Hits struct {
+							Hits []struct {
+								Source models.DemographicSegment `json:"_source"`
+							} `json:"hits"`
+						} `json:"hits"`
+					} `json:"segments"`
+				} `json:"inner_hits"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
 
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+	decoder := json.NewDecoder(res.Body)
+	// Значения сортировки возвращаются в search_after без потери точности
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	locations := make([]*models.Location, 0, len(result.Hits.Hits))
+	page := &RecommendResult{
+		Locations: make([]*models.Location, 0, len(result.Hits.Hits)),
+		Total:     result.Hits.Total.Value,
+	}
 	for _, hit := range result.Hits.Hits {
 		location := hit.Source
 		location.Score = hit.Score
-		locations = append(locations, &location)
+		location.Archived = hit.Index == es.archive
+		for _, segment := range hit.InnerHits.Segments.Hits.Hits {
+			location.MatchedSegments = append(location.MatchedSegments, segment.Source)
+		}
+		page.Locations = append(page.Locations, &location)
+	}
+	if hits := result.Hits.Hits; len(hits) > 0 && len(hits) == req.Limit && len(hits) < page.Total {
+		page.Next = hits[len(hits)-1].Sort
 	}
 
-	return locations, nil
+	return page, nil
 }
 
-// buildRecommendQuery строит запрос для рекомендаций
-func (es *ElasticsearchStorage) buildRecommendQuery(req *models.RecommendRequest) map[string]interface{} {
+// HasParkingMinStreetScore — оценка уличной парковки, начиная с которой у локации считается есть парковка.
+const HasParkingMinStreetScore = 5.0
+
+// SafeDistrictMinScore — индекс безопасности, начиная с которого район считается безопасным при бустинге.
+const SafeDistrictMinScore = 7.0
+
+// DefaultScoringWeights — веса факторов ранжирования для запросов без weights.
+// Вес расстояния учитывается только в запросах с точкой отсчета.
+var DefaultScoringWeights = models.ScoringWeights{Traffic: 0.5, Competition: 0.3, Demographics: 0.2, Distance: 0.3}
+
+const (
+	// weightScale — сумма весов факторов ранжирования после нормализации; равна сумме прежних
+	// бустов трафика и конкуренции, чтобы бусты профиля сохранили свою долю в оценке.
+	weightScale = 3.5
+	// competitionDecayScale — плотность конкурентов, при которой фактор конкуренции равен 0.5.
+	competitionDecayScale = 3.0
+	// distanceDecayScale — расстояние от точки отсчета без радиуса поиска, метры,
+	// на котором фактор расстояния равен 0.5.
+	distanceDecayScale = 2000.0
+	// populationDensityPivot — плотность населения (чел./км²), при которой демографический фактор равен 0.5.
+	populationDensityPivot = 5000.0
+)
+
+// boostableFields перечисляет числовые поля локации, допустимые в профилях ранжирования.
+var boostableFields = map[string]bool{
+	"traffic_score":        true,
+	"competition_density":  true,
+	"event_exposure":       true,
+	"schools_nearby":       true,
+	"universities_nearby":  true,
+	"street_parking_score": true,
+	"paid_lots_nearby":     true,
+	"safety_score":         true,
+}
+
+// publishedFilter отбирает опубликованные локации, срок показа которых идет сейчас. Документы
+// без status записаны до появления жизненного цикла и считаются опубликованными, поэтому
+// исключаются остальные состояния, а также локации с еще не наступившим publish_at и истекшим
+// expire_at. Время округляется до минуты, чтобы условие кешировалось в Elasticsearch.
+func publishedFilter() map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must_not": []map[string]interface{}{
+				{
+					"terms": map[string]interface{}{
+						"status": []string{models.LocationDraft, models.LocationRetired},
+					},
+				},
+				{
+					"range": map[string]interface{}{
+						"publish_at": map[string]interface{}{"gt": "now/m"},
+					},
+				},
+				{
+					"range": map[string]interface{}{
+						"expire_at": map[string]interface{}{"lte": "now/m"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// searchFilters строит фильтры поиска рекомендаций: в выдачу попадают только опубликованные
+// локации, подходящие под фильтры запроса.
+func searchFilters(req *models.RecommendRequest) []map[string]interface{} {
+	return append([]map[string]interface{}{publishedFilter()}, recommendFilters(req)...)
+}
+
+// recommendFilters строит фильтры запроса рекомендаций без учета публикации. Они же
+// регистрируются запросами percolator сохраненных поисков, где условия на текущее время
+// недопустимы; публикацию при уведомлениях проверяет сервис.
+func recommendFilters(req *models.RecommendRequest) []map[string]interface{} {
 	mustClauses := []map[string]interface{}{}
-	shouldClauses := []map[string]interface{}{}
 
 	// Фильтр по региону
 	if req.Region != "" {
@@ -270,8 +523,14 @@ func (es *ElasticsearchStorage) buildRecommendQuery(req *models.RecommendRequest
 		})
 	}
 
-	// Фильтр по типу бизнеса
-	if req.BusinessType != "" {
+	// Фильтр по типу бизнеса; с таксономией — по типу, его подтипам и категориям
+	if len(req.BusinessTypes) > 0 {
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"business_types_suitable": req.BusinessTypes,
+			},
+		})
+	} else if req.BusinessType != "" {
 		mustClauses = append(mustClauses, map[string]interface{}{
 			"term": map[string]interface{}{
 				"business_types_suitable": req.BusinessType,
@@ -279,31 +538,215 @@ func (es *ElasticsearchStorage) buildRecommendQuery(req *models.RecommendRequest
 		})
 	}
 
-	// Бустинг для высокого traffic_score и низкого competition_density
-	shouldClauses = append(shouldClauses, map[string]interface{}{
-		"range": map[string]interface{}{
-			"traffic_score": map[string]interface{}{
-				"gte": 7.0,
-				"boost": 2.0,
+	// Фильтр по расстоянию от точки отсчета
+	if req.RadiusMeters > 0 && req.Origin != nil {
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"geo_distance": map[string]interface{}{
+				"distance":    fmt.Sprintf("%gm", req.RadiusMeters),
+				"coordinates": map[string]interface{}{"lat": req.Origin.Lat, "lon": req.Origin.Lon},
+			},
+		})
+	}
+
+	// Фильтр по области карты
+	if box := req.BoundingBox; box != nil {
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"geo_bounding_box": map[string]interface{}{
+				"coordinates": map[string]interface{}{
+					"top_left":     map[string]interface{}{"lat": box.TopLeft.Lat, "lon": box.TopLeft.Lon},
+					"bottom_right": map[string]interface{}{"lat": box.BottomRight.Lat, "lon": box.BottomRight.Lon},
+				},
 			},
-		},
-	})
+		})
+	}
+
+	// Фильтр по границе района; geo_polygon поддерживается и Elasticsearch, и OpenSearch
+	if len(req.Polygon) > 0 {
+		points := make([]map[string]interface{}, len(req.Polygon))
+		for i, point := range req.Polygon {
+			points[i] = map[string]interface{}{"lat": point.Lat, "lon": point.Lon}
+		}
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"geo_polygon": map[string]interface{}{
+				"coordinates": map[string]interface{}{"points": points},
+			},
+		})
+	}
+
+	// Фильтры по парковке для бизнеса, зависящего от посетителей на автомобилях
+	if req.HasParking {
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{"range": map[string]interface{}{"paid_lots_nearby": map[string]interface{}{"gte": 1}}},
+					{"range": map[string]interface{}{"street_parking_score": map[string]interface{}{"gte": HasParkingMinStreetScore}}},
+				},
+				"minimum_should_match": 1,
+			},
+		})
+	}
+	if req.MinParkingScore > 0 {
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"street_parking_score": map[string]interface{}{"gte": req.MinParkingScore},
+			},
+		})
+	}
+
+	// Фильтр по безопасности района
+	if req.MinSafety > 0 {
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"safety_score": map[string]interface{}{"gte": req.MinSafety},
+			},
+		})
+	}
+
+	// Демографический сегмент: условия проверяются в пределах одного сегмента,
+	// подошедшие сегменты возвращаются через inner_hits
+	if req.Segment != nil {
+		mustClauses = append(mustClauses, segmentQuery(req.Segment))
+	}
+
+	// Границы значений полей, в том числе вычисляемых (runtime fields)
+	for _, r := range req.FieldRanges {
+		bounds := map[string]interface{}{}
+		if r.Gte != nil {
+			bounds["gte"] = *r.Gte
+		}
+		if r.Lte != nil {
+			bounds["lte"] = *r.Lte
+		}
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"range": map[string]interface{}{r.Field: bounds},
+		})
+	}
 
-	shouldClauses = append(shouldClauses, map[string]interface{}{
-		"range": map[string]interface{}{
-			"competition_density": map[string]interface{}{
-				"lte": 3.0,
-				"boost": 1.5,
+	return mustClauses
+}
+
+// segmentPath — путь вложенных документов демографических сегментов.
+const segmentPath = "demographics.segments"
+
+// segmentQuery строит nested запрос по демографическим сегментам с inner_hits.
+// Индексы, созданные до появления сегментов, не содержат локаций, подходящих под запрос.
+func segmentQuery(filter *models.SegmentFilter) map[string]interface{} {
+	conditions := []map[string]interface{}{}
+	if filter.AgeGroup != "" {
+		conditions = append(conditions, map[string]interface{}{
+			"term": map[string]interface{}{segmentPath + ".age_group": filter.AgeGroup},
+		})
+	}
+	if filter.MinShare > 0 {
+		conditions = append(conditions, map[string]interface{}{
+			"range": map[string]interface{}{segmentPath + ".share": map[string]interface{}{"gte": filter.MinShare}},
+		})
+	}
+	if filter.MinIncome > 0 || filter.MaxIncome > 0 {
+		income := map[string]interface{}{}
+		if filter.MinIncome > 0 {
+			income["gte"] = filter.MinIncome
+		}
+		if filter.MaxIncome > 0 {
+			income["lte"] = filter.MaxIncome
+		}
+		conditions = append(conditions, map[string]interface{}{
+			"range": map[string]interface{}{segmentPath + ".average_income": income},
+		})
+	}
+
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path":            segmentPath,
+			"query":           map[string]interface{}{"bool": map[string]interface{}{"filter": conditions}},
+			"ignore_unmapped": true,
+			"inner_hits": map[string]interface{}{
+				"name": "segments",
+				"sort": []map[string]interface{}{{segmentPath + ".share": map[string]interface{}{"order": "desc"}}},
 			},
 		},
-	})
+	}
+}
+
+// recommendBoosts строит бусты запроса рекомендаций: профиль ранжирования типа бизнеса,
+// безопасность района и близость к площадкам мероприятий.
+func recommendBoosts(req *models.RecommendRequest) []map[string]interface{} {
+	shouldClauses := []map[string]interface{}{}
+
+	// Бустинг по профилю ранжирования типа бизнеса
+	for _, b := range req.Boosts {
+		if !boostableFields[b.Field] {
+			continue
+		}
+		shouldClauses = append(shouldClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				b.Field: map[string]interface{}{
+					"gte":   b.Min,
+					"boost": b.Boost,
+				},
+			},
+		})
+	}
+
+	// Бустинг локаций в безопасных районах с весом из запроса
+	if req.SafetyWeight > 0 {
+		shouldClauses = append(shouldClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"safety_score": map[string]interface{}{
+					"gte":   SafeDistrictMinScore,
+					"boost": req.SafetyWeight,
+				},
+			},
+		})
+	}
+
+	// Бустинг близости к площадкам мероприятий для типов бизнеса, выигрывающих от их посетителей
+	if req.EventBoost != nil && *req.EventBoost {
+		shouldClauses = append(shouldClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"event_exposure": map[string]interface{}{
+					"gte":   5.0,
+					"boost": 1.5,
+				},
+			},
+		})
+	}
+
+	return shouldClauses
+}
+
+// buildRecommendQuery строит запрос для рекомендаций: фильтры запроса и function_score
+// с весами факторов ранжирования.
+func (es *ElasticsearchStorage) buildRecommendQuery(req *models.RecommendRequest) map[string]interface{} {
+	filters := searchFilters(req)
+	shouldClauses := recommendBoosts(req)
+
+	// Оценка складывается из функций факторов с весами из запроса и бустов профиля,
+	// безопасности и мероприятий. Фильтры не влияют на оценку: иначе term по региону и типу
+	// бизнеса добавлял бы почти одинаковую для всех документов составляющую
+	weights := scaledWeights(req.Weights, distanceOrigin(req) != nil)
+	var intent map[string]interface{}
+	if len(req.IntentVector) > 0 {
+		weights, intent = intentFunction(weights, req)
+	}
+	functions := weightFunctions(weights, req)
+	if intent != nil {
+		functions = append(functions, intent)
+	}
 
 	query := map[string]interface{}{
 		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": mustClauses,
-				"should": shouldClauses,
-				"minimum_should_match": 0,
+			"function_score": map[string]interface{}{
+				"query": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"filter":               filters,
+						"should":               shouldClauses,
+						"minimum_should_match": 0,
+					},
+				},
+				"functions":  functions,
+				"score_mode": "sum",
+				"boost_mode": "sum",
 			},
 		},
 		"sort": []map[string]interface{}{
@@ -322,7 +765,28 @@ func (es *ElasticsearchStorage) buildRecommendQuery(req *models.RecommendRequest
 					"order": "asc",
 				},
 			},
+			// Уникальный id делает порядок однозначным для постраничного обхода через search_after
+			{
+				"id": map[string]interface{}{
+					"order": "asc",
+				},
+			},
 		},
+		// Точное число подходящих локаций вместо нижней оценки 10000
+		"track_total_hits": true,
+	}
+
+	if len(req.SearchAfter) > 0 {
+		query["search_after"] = req.SearchAfter
+	}
+
+	// Векторный режим: фильтры сохраняются, а оценка — сходство векторов локации с векторами запроса
+	if req.UseEmbedding {
+		query["query"] = embeddingQuery(filters, req)
+	}
+
+	if req.TargetMonth > 0 {
+		query["query"] = seasonalQuery(query["query"], req)
 	}
 
 	if req.Limit == 0 {
@@ -332,3 +796,1109 @@ func (es *ElasticsearchStorage) buildRecommendQuery(req *models.RecommendRequest
 	return query
 }
 
+// competitionScript — обратный фактор плотности конкурентов: 1 без конкурентов, 0.5 при params.scale.
+const competitionScript = `if (doc['competition_density'].size() == 0) { return 1; }
+return params.scale / (params.scale + doc['competition_density'].value);`
+
+// populationDensityScript — насыщение плотности населения: 0.5 при params.pivot, стремится к 1.
+const populationDensityScript = `if (doc['demographics.population_density'].size() == 0) { return 0; }
+double d = doc['demographics.population_density'].value;
+return d / (d + params.pivot);`
+
+// populationDensityAsOfScript — насыщение плотности населения по версии демографии, действовавшей
+// в момент params.as_of (миллисекунды Unix): последней версии demographics_history с effective_from
+// не позже него. Локации без истории оцениваются по текущим данным, с историей, начинающейся
+// позже params.as_of, — как локации без данных.
+const populationDensityAsOfScript = `def history = params._source.demographics_history;
+double d = 0;
+if (history == null || history.isEmpty()) {
+  if (doc['demographics.population_density'].size() == 0) { return 0; }
+  d = doc['demographics.population_density'].value;
+} else {
+  long best = Long.MIN_VALUE;
+  for (def snapshot : history) {
+    long from = ZonedDateTime.parse(snapshot.effective_from).toInstant().toEpochMilli();
+    if (from <= params.as_of && from > best) {
+      best = from;
+      def density = snapshot.demographics == null ? null : snapshot.demographics.population_density;
+      d = density == null ? 0 : ((Number) density).doubleValue();
+    }
+  }
+}
+return d / (d + params.pivot);`
+
+// scaledWeights возвращает веса факторов ранжирования запроса (без них — DefaultScoringWeights),
+// нормализованные к сумме 1 и масштабированные к weightScale. Вес расстояния учитывается
+// только при заданной точке отсчета (withDistance).
+func scaledWeights(requested *models.ScoringWeights, withDistance bool) models.ScoringWeights {
+	weights := DefaultScoringWeights
+	if requested != nil {
+		weights = *requested
+	}
+	if !withDistance {
+		weights.Distance = 0
+	}
+	total := weights.Traffic + weights.Competition + weights.Demographics + weights.Distance
+	if total <= 0 {
+		return scaledWeights(nil, withDistance)
+	}
+	scale := weightScale / total
+	return models.ScoringWeights{
+		Traffic:      weights.Traffic * scale,
+		Competition:  weights.Competition * scale,
+		Demographics: weights.Demographics * scale,
+		Distance:     weights.Distance * scale,
+	}
+}
+
+// intentScript — сходство embedding локации с вектором намерения от 0 до 1: (cosine + 1) / 2.
+const intentScript = `(cosineSimilarity(params.query_vector, 'embedding') + 1.0) / 2.0`
+
+// intentFunction делит weightScale между факторами ранжирования и сходством с текстом намерения
+// в доле req.IntentWeight: возвращает уменьшенные веса факторов и функцию function_score сходства
+// с req.IntentVector. Сходство считается только для локаций с embedding той же версии модели,
+// что и вектор намерения; остальные локации ранжируются по показателям.
+func intentFunction(weights models.ScoringWeights, req *models.RecommendRequest) (models.ScoringWeights, map[string]interface{}) {
+	rest := 1 - req.IntentWeight
+	scaled := models.ScoringWeights{
+		Traffic:      weights.Traffic * rest,
+		Competition:  weights.Competition * rest,
+		Demographics: weights.Demographics * rest,
+		Distance:     weights.Distance * rest,
+	}
+	return scaled, map[string]interface{}{
+		"filter": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"term": map[string]interface{}{"embedding_version": req.IntentEmbeddingVersion}},
+					{"exists": map[string]interface{}{"field": "embedding"}},
+				},
+			},
+		},
+		"script_score": map[string]interface{}{
+			"script": map[string]interface{}{
+				"source": intentScript,
+				"params": map[string]interface{}{"query_vector": req.IntentVector},
+			},
+		},
+		"weight": req.IntentWeight * weightScale,
+	}
+}
+
+// distanceOrigin возвращает точку отсчета фактора расстояния: req.Origin, а без него — центр
+// города запроса req.CityCenter. nil — фактор расстояния не учитывается.
+func distanceOrigin(req *models.RecommendRequest) *models.GeoPoint {
+	if req.Origin != nil {
+		return req.Origin
+	}
+	return req.CityCenter
+}
+
+// distanceScale возвращает расстояние, на котором фактор расстояния падает до 0.5:
+// половину радиуса поиска или distanceDecayScale без радиуса.
+func distanceScale(req *models.RecommendRequest) string {
+	if req.RadiusMeters > 0 {
+		return fmt.Sprintf("%gm", req.RadiusMeters/2)
+	}
+	return fmt.Sprintf("%gm", distanceDecayScale)
+}
+
+// weightFunctions строит функции function_score для факторов ранжирования с весами weights.
+// Каждая функция дает значение от 0 до 1: traffic_score / 10 (field_value_factor), обратный
+// фактор competition_density (0.5 при competitionDecayScale), насыщение population_density
+// (0.5 при populationDensityPivot) и, с точкой отсчета (см. distanceOrigin), гауссово затухание по расстоянию.
+func weightFunctions(weights models.ScoringWeights, req *models.RecommendRequest) []map[string]interface{} {
+	var functions []map[string]interface{}
+	if weights.Traffic > 0 {
+		functions = append(functions, map[string]interface{}{
+			"field_value_factor": map[string]interface{}{
+				"field":   "traffic_score",
+				"factor":  0.1,
+				"missing": 0,
+			},
+			"weight": weights.Traffic,
+		})
+	}
+	if weights.Competition > 0 {
+		functions = append(functions, map[string]interface{}{
+			"script_score": map[string]interface{}{
+				"script": map[string]interface{}{
+					"source": competitionScript,
+					"params": map[string]interface{}{"scale": competitionDecayScale},
+				},
+			},
+			"weight": weights.Competition,
+		})
+	}
+	if weights.Demographics > 0 {
+		script := map[string]interface{}{
+			"source": populationDensityScript,
+			"params": map[string]interface{}{"pivot": populationDensityPivot},
+		}
+		if req.AsOf != nil {
+			script = map[string]interface{}{
+				"source": populationDensityAsOfScript,
+				"params": map[string]interface{}{"pivot": populationDensityPivot, "as_of": req.AsOf.UnixMilli()},
+			}
+		}
+		functions = append(functions, map[string]interface{}{
+			"script_score": map[string]interface{}{"script": script},
+			"weight":       weights.Demographics,
+		})
+	}
+	if origin := distanceOrigin(req); weights.Distance > 0 && origin != nil {
+		functions = append(functions, map[string]interface{}{
+			"gauss": map[string]interface{}{
+				"coordinates": map[string]interface{}{
+					"origin": map[string]interface{}{"lat": origin.Lat, "lon": origin.Lon},
+					"scale":  distanceScale(req),
+					"decay":  0.5,
+				},
+			},
+			"weight": weights.Distance,
+		})
+	}
+	return functions
+}
+
+// demographicSimilarityScript — сходство вектора демографического профиля локации с целевым
+// профилем запроса: cosine + 1 (от 0 до 2), как у similarityScript для cosine.
+const demographicSimilarityScript = "cosineSimilarity(params.demographic_vector, 'demographic_embedding') + 1.0"
+
+// DefaultVectorWeights — веса сходства векторов, если их не задают ни запрос, ни профиль
+// ранжирования типа бизнеса.
+var DefaultVectorWeights = models.VectorWeights{Description: 1, Demographics: 1}
+
+// embeddingQuery строит точный векторный поиск через script_score: локации, подходящие под фильтры,
+// ранжируются по сходству embedding с req.QueryVector в метрике req.Similarity (см. similarityScript)
+// и (или) вектора демографического профиля с req.DemographicVector. Если заданы оба вектора,
+// оценка — сумма сходств с весами req.VectorWeights (без них — DefaultVectorWeights); сходство
+// с нулевым весом не учитывается. Документы без используемых векторов исключаются.
+func embeddingQuery(filters []map[string]interface{}, req *models.RecommendRequest) map[string]interface{} {
+	weights := models.VectorWeights{Description: 1, Demographics: 1}
+	if len(req.QueryVector) > 0 && len(req.DemographicVector) > 0 {
+		weights = DefaultVectorWeights
+		if req.VectorWeights != nil {
+			weights = *req.VectorWeights
+		}
+	}
+
+	var terms []string
+	params := map[string]interface{}{}
+	if len(req.QueryVector) > 0 && weights.Description > 0 {
+		filters = append(filters, map[string]interface{}{
+			"exists": map[string]interface{}{"field": "embedding"},
+		})
+		terms = append(terms, "params.description_weight * ("+similarityScript(req.Similarity)+")")
+		params["query_vector"] = req.QueryVector
+		params["description_weight"] = weights.Description
+	}
+	if len(req.DemographicVector) > 0 && weights.Demographics > 0 {
+		filters = append(filters, map[string]interface{}{
+			"exists": map[string]interface{}{"field": "demographic_embedding"},
+		})
+		terms = append(terms, "params.demographics_weight * ("+demographicSimilarityScript+")")
+		params["demographic_vector"] = req.DemographicVector
+		params["demographics_weight"] = weights.Demographics
+	}
+
+	return map[string]interface{}{
+		"script_score": map[string]interface{}{
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{"filter": filters},
+			},
+			"script": map[string]interface{}{
+				"source": strings.Join(terms, " + "),
+				"params": params,
+			},
+		},
+	}
+}
+
+// seasonalScript умножает оценку на коэффициент сезонности трафика: собственный коэффициент
+// локации на месяц, иначе коэффициент ее города, иначе общий коэффициент типа бизнеса.
+const seasonalScript = `double c = params.default_coefficient;
+String field = 'seasonality.' + params.month;
+if (doc.containsKey(field) && doc[field].size() > 0) {
+  c = doc[field].value;
+} else if (doc['city'].size() > 0 && params.by_city.containsKey(doc['city'].value)) {
+  c = params.by_city[doc['city'].value];
+}
+return _score * c;`
+
+// seasonalQuery оборачивает запрос в script_score с сезонной корректировкой на req.TargetMonth.
+func seasonalQuery(query interface{}, req *models.RecommendRequest) map[string]interface{} {
+	coefficients := req.Seasonal
+	if coefficients == nil {
+		coefficients = &models.SeasonalCoefficients{Default: 1}
+	}
+	byCity := coefficients.ByCity
+	if byCity == nil {
+		byCity = map[string]float64{}
+	}
+
+	return map[string]interface{}{
+		"script_score": map[string]interface{}{
+			"query": query,
+			"script": map[string]interface{}{
+				"source": seasonalScript,
+				"params": map[string]interface{}{
+					"month":               fmt.Sprint(req.TargetMonth),
+					"default_coefficient": coefficients.Default,
+					"by_city":             byCity,
+				},
+			},
+		},
+	}
+}
+
+// Refresh принудительно обновляет индекс, делая проиндексированные документы доступными для поиска.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) Refresh(ctx context.Context) error {
+	started := time.Now().UnixNano()
+	url := fmt.Sprintf("%s/%s/_refresh", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to refresh index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return newESError("error refreshing index", res.StatusCode, res.Body)
+	}
+
+	// Записи до начала обновления стали видны поиску
+	es.refreshedAt.Store(started)
+	es.markWritten(true)
+	return nil
+}
+
+// GetCityScoreStats возвращает статистику traffic_score по каждому городу:
+// количество документов, среднее значение и количество нулевых значений.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) GetCityScoreStats(ctx context.Context) (map[string]*models.CityScoreStats, error) {
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"cities": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "city",
+					"size":  10000,
+				},
+				"aggs": map[string]interface{}{
+					"avg_traffic": map[string]interface{}{
+						"avg": map[string]interface{}{
+							"field": "traffic_score",
+						},
+					},
+					"zero_traffic": map[string]interface{}{
+						"filter": map[string]interface{}{
+							"term": map[string]interface{}{
+								"traffic_score": 0,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		// Индекса еще нет — статистика пустая
+		return map[string]*models.CityScoreStats{}, nil
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Aggregations struct {
+			Cities struct {
+				Buckets []struct {
+					Key        string `json:"key"`
+					DocCount   int    `json:"doc_count"`
+					AvgTraffic struct {
+						Value *float64 `json:"value"`
+					} `json:"avg_traffic"`
+					ZeroTraffic struct {
+						DocCount int `json:"doc_count"`
+					} `json:"zero_traffic"`
+				} `json:"buckets"`
+			} `json:"cities"`
+		} `json:"aggregations"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	stats := make(map[string]*models.CityScoreStats, len(result.Aggregations.Cities.Buckets))
+	for _, bucket := range result.Aggregations.Cities.Buckets {
+		cityStats := &models.CityScoreStats{
+			City:             bucket.Key,
+			DocCount:         bucket.DocCount,
+			ZeroTrafficCount: bucket.ZeroTraffic.DocCount,
+		}
+		if bucket.AvgTraffic.Value != nil {
+			cityStats.AvgTrafficScore = *bucket.AvgTraffic.Value
+		}
+		stats[bucket.Key] = cityStats
+	}
+
+	return stats, nil
+}
+
+// GetRegionCities возвращает регионы основного индекса и города каждого региона.
+// Используется для подсказок при опечатках в названиях регионов и городов, поэтому
+// учитываются только опубликованные локации.
+func (es *ElasticsearchStorage) GetRegionCities(ctx context.Context) (map[string][]string, error) {
+	query := map[string]interface{}{
+		"size":  0,
+		"query": publishedFilter(),
+		"aggs": map[string]interface{}{
+			"regions": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "region",
+					"size":  10000,
+				},
+				"aggs": map[string]interface{}{
+					"cities": map[string]interface{}{
+						"terms": map[string]interface{}{
+							"field": "city",
+							"size":  10000,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return map[string][]string{}, nil
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	type bucket struct {
+		Key string `json:"key"`
+	}
+	var result struct {
+		Aggregations struct {
+			Regions struct {
+				Buckets []struct {
+					Key    string `json:"key"`
+					Cities struct {
+						Buckets []bucket `json:"buckets"`
+					} `json:"cities"`
+				} `json:"buckets"`
+			} `json:"regions"`
+		} `json:"aggregations"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	places := make(map[string][]string, len(result.Aggregations.Regions.Buckets))
+	for _, region := range result.Aggregations.Regions.Buckets {
+		cities := make([]string, 0, len(region.Cities.Buckets))
+		for _, city := range region.Cities.Buckets {
+			cities = append(cities, city.Key)
+		}
+		places[region.Key] = cities
+	}
+
+	return places, nil
+}
+
+// buildFilterQuery строит запрос отбора локаций по LocationFilter.
+func buildFilterQuery(filter *models.LocationFilter) map[string]interface{} {
+	filterClauses := []map[string]interface{}{}
+
+	if filter.Region != "" {
+		filterClauses = append(filterClauses, map[string]interface{}{
+			"term": map[string]interface{}{
+				"region": filter.Region,
+			},
+		})
+	}
+
+	if filter.City != "" {
+		filterClauses = append(filterClauses, map[string]interface{}{
+			"term": map[string]interface{}{
+				"city": filter.City,
+			},
+		})
+	}
+
+	if filter.BusinessType != "" {
+		filterClauses = append(filterClauses, map[string]interface{}{
+			"term": map[string]interface{}{
+				"business_types_suitable": filter.BusinessType,
+			},
+		})
+	}
+
+	// Документы без embedding_version считаются версией 0
+	if filter.EmbeddingVersionBelow > 0 {
+		filterClauses = append(filterClauses, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{
+						"range": map[string]interface{}{
+							"embedding_version": map[string]interface{}{
+								"lt": filter.EmbeddingVersionBelow,
+							},
+						},
+					},
+					{
+						"bool": map[string]interface{}{
+							"must_not": map[string]interface{}{
+								"exists": map[string]interface{}{
+									"field": "embedding_version",
+								},
+							},
+						},
+					},
+				},
+				"minimum_should_match": 1,
+			},
+		})
+	}
+
+	if filter.UpdatedBefore != nil {
+		filterClauses = append(filterClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"updated_at": map[string]interface{}{
+					"lt": filter.UpdatedBefore.Format(time.RFC3339),
+				},
+			},
+		})
+	}
+	if filter.CreatedSince != nil {
+		filterClauses = append(filterClauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"created_at": map[string]interface{}{
+					"gte": filter.CreatedSince.Format(time.RFC3339),
+				},
+			},
+		})
+	}
+	if filter.PublishedOnly {
+		filterClauses = append(filterClauses, publishedFilter())
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": filterClauses,
+		},
+	}
+}
+
+// CountLocations возвращает количество локаций, подходящих под фильтр.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) CountLocations(ctx context.Context, filter *models.LocationFilter) (int, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"query": buildFilterQuery(filter)}); err != nil {
+		return 0, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_count", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count locations: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return 0, newESError("error counting locations", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// ScanLocations последовательно обходит все локации, подходящие под фильтр, пачками размера batchSize.
+// Для постраничного обхода используется search_after с сортировкой по id.
+// Обход прекращается при первой ошибке, возвращенной fn.
+func (es *ElasticsearchStorage) ScanLocations(ctx context.Context, filter *models.LocationFilter, batchSize int, fn func([]*models.Location) error) error {
+	afterID := ""
+	for {
+		locations, err := es.searchLocationsAfter(ctx, filter, afterID, batchSize, nil)
+		if err != nil {
+			return err
+		}
+		if len(locations) == 0 {
+			return nil
+		}
+
+		if err := fn(locations); err != nil {
+			return err
+		}
+
+		if len(locations) < batchSize {
+			return nil
+		}
+		afterID = locations[len(locations)-1].ID
+	}
+}
+
+// ListLocationsPage возвращает до size локаций, подходящих под фильтр, с id больше afterID
+// (пустой — с начала) в порядке id. Поля embedding и demographic_embedding не загружаются.
+func (es *ElasticsearchStorage) ListLocationsPage(ctx context.Context, filter *models.LocationFilter, afterID string, size int) ([]*models.Location, error) {
+	return es.searchLocationsAfter(ctx, filter, afterID, size, []string{"embedding", "demographic_embedding"})
+}
+
+// searchLocationsAfter возвращает до size локаций, подходящих под фильтр, с id больше afterID
+// в порядке id без полей excludes.
+func (es *ElasticsearchStorage) searchLocationsAfter(ctx context.Context, filter *models.LocationFilter, afterID string, size int, excludes []string) ([]*models.Location, error) {
+	query := map[string]interface{}{
+		"size":  size,
+		"query": buildFilterQuery(filter),
+		"sort": []map[string]interface{}{
+			{"id": map[string]interface{}{"order": "asc"}},
+		},
+	}
+	if afterID != "" {
+		query["search_after"] = []interface{}{afterID}
+	}
+	if len(excludes) > 0 {
+		query["_source"] = map[string]interface{}{"excludes": excludes}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Location `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	hits := result.Hits.Hits
+	locations := make([]*models.Location, 0, len(hits))
+	for i := range hits {
+		locations = append(locations, &hits[i].Source)
+	}
+
+	return locations, nil
+}
+
+// BulkUpdateEmbeddings частично обновляет embedding, embedding_version и demographic_embedding
+// у документов через Bulk API. Возвращает количество документов, которые не удалось обновить.
+func (es *ElasticsearchStorage) BulkUpdateEmbeddings(ctx context.Context, embeddings map[string]LocationVectors, version int) (int, error) {
+	updates := make(map[string]map[string]interface{}, len(embeddings))
+	for id, vectors := range embeddings {
+		updates[id] = map[string]interface{}{
+			"embedding":             vectors.Text,
+			"embedding_version":     version,
+			"demographic_embedding": vectors.Demographic,
+		}
+	}
+	return es.BulkUpdateFields(ctx, updates)
+}
+
+// BulkUpdateFields частично обновляет поля документов через Bulk API: для каждого ID
+// передаются только изменяемые поля. Возвращает количество документов, которые не удалось обновить.
+// Использует прямые HTTP запросы для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) BulkUpdateFields(ctx context.Context, updates map[string]map[string]interface{}) (int, error) {
+	var buf bytes.Buffer
+
+	for id, fields := range updates {
+		meta := map[string]interface{}{
+			"update": map[string]interface{}{
+				"_index": es.index,
+				"_id":    id,
+			},
+		}
+		doc := map[string]interface{}{
+			"doc": fields,
+		}
+
+		if err := json.NewEncoder(&buf).Encode(meta); err != nil {
+			return 0, fmt.Errorf("failed to encode meta: %w", err)
+		}
+		if err := json.NewEncoder(&buf).Encode(doc); err != nil {
+			return 0, fmt.Errorf("failed to encode document: %w", err)
+		}
+	}
+
+	url := fmt.Sprintf("%s/_bulk", es.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bulk update: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return 0, newESError("error bulk updating", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	failed := 0
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, op := range item {
+				if op.Status >= 300 {
+					failed++
+				}
+			}
+		}
+	}
+	if failed < len(result.Items) {
+		es.markWritten(false)
+	}
+
+	return failed, nil
+}
+
+// GetMapping возвращает текущий маппинг индекса локаций из кластера.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) GetMapping(ctx context.Context) (map[string]interface{}, error) {
+	return es.getMapping(ctx, es.index)
+}
+
+// getMapping возвращает маппинг индекса name.
+func (es *ElasticsearchStorage) getMapping(ctx context.Context, name string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/%s/_mapping", es.baseURL, name)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, fmt.Errorf("index not found")
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error getting mapping", res.StatusCode, res.Body)
+	}
+
+	// Ответ имеет вид {"<index>": {"mappings": {...}}}; имя индекса может отличаться при использовании алиаса
+	var result map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for _, index := range result {
+		return index.Mappings, nil
+	}
+
+	return nil, fmt.Errorf("index not found")
+}
+
+// GetVectorIndexOptions извлекает параметры kNN индекса поля embedding из текущего маппинга.
+func (es *ElasticsearchStorage) GetVectorIndexOptions(ctx context.Context) (*VectorIndexOptions, error) {
+	mapping, err := es.GetMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+
+	var parsed struct {
+		Properties struct {
+			Embedding struct {
+				Dims         int    `json:"dims"`
+				Similarity   string `json:"similarity"`
+				IndexOptions struct {
+					M              int `json:"m"`
+					EfConstruction int `json:"ef_construction"`
+				} `json:"index_options"`
+			} `json:"embedding"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+
+	embedding := parsed.Properties.Embedding
+	return &VectorIndexOptions{
+		Dims:           embedding.Dims,
+		Similarity:     embedding.Similarity,
+		M:              embedding.IndexOptions.M,
+		EfConstruction: embedding.IndexOptions.EfConstruction,
+	}, nil
+}
+
+// VectorSearchResult содержит результат векторного поиска: ID найденных документов и время выполнения.
+type VectorSearchResult struct {
+	IDs  []string
+	Took int // Время выполнения запроса на стороне кластера, мс
+}
+
+// KNNSearch выполняет приближенный kNN поиск (HNSW) по полю embedding.
+// Использует синтаксис top-level knn Elasticsearch 8.
+func (es *ElasticsearchStorage) KNNSearch(ctx context.Context, vector []float64, k, numCandidates int) (*VectorSearchResult, error) {
+	query := map[string]interface{}{
+		"size":    k,
+		"_source": []string{"id"},
+		"knn": map[string]interface{}{
+			"field":          "embedding",
+			"query_vector":   vector,
+			"k":              k,
+			"num_candidates": numCandidates,
+		},
+	}
+	return es.vectorSearch(ctx, query)
+}
+
+// ExactVectorSearch выполняет точный (brute force) векторный поиск через script_score.
+// Используется как эталон для оценки recall приближенного поиска.
+func (es *ElasticsearchStorage) ExactVectorSearch(ctx context.Context, vector []float64, k int, similarity string) (*VectorSearchResult, error) {
+	query := map[string]interface{}{
+		"size":    k,
+		"_source": []string{"id"},
+		"query": map[string]interface{}{
+			"script_score": map[string]interface{}{
+				"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+				"script": map[string]interface{}{
+					"source": similarityScript(similarity),
+					"params": map[string]interface{}{"query_vector": vector},
+				},
+			},
+		},
+	}
+	return es.vectorSearch(ctx, query)
+}
+
+// similarityScript возвращает painless выражение точной оценки сходства для script_score.
+// Оценка должна быть неотрицательной, поэтому метрики приводятся к положительному диапазону:
+// cosine + 1 (от 0 до 2), сигмоида скалярного произведения, 1 / (1 + L2 расстояние).
+func similarityScript(similarity string) string {
+	switch similarity {
+	case "dot_product":
+		return "sigmoid(1, Math.E, -dotProduct(params.query_vector, 'embedding'))"
+	case "l2_norm":
+		return "1 / (1 + l2norm(params.query_vector, 'embedding'))"
+	default:
+		return "cosineSimilarity(params.query_vector, 'embedding') + 1.0"
+	}
+}
+
+func (es *ElasticsearchStorage) vectorSearch(ctx context.Context, query map[string]interface{}) (*VectorSearchResult, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Took int `json:"took"`
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		ids = append(ids, hit.ID)
+	}
+
+	return &VectorSearchResult{IDs: ids, Took: result.Took}, nil
+}
+
+// DeleteLocation удаляет локацию из индекса по ID без принудительного обновления индекса.
+// Возвращает ErrLocationNotFound, если документ отсутствует.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) DeleteLocation(ctx context.Context, id string) error {
+	return es.deleteLocation(ctx, es.index, id)
+}
+
+// DeleteArchivedLocation удаляет локацию из архивного индекса.
+// Возвращает ErrLocationNotFound, если локация не архивирована.
+func (es *ElasticsearchStorage) DeleteArchivedLocation(ctx context.Context, id string) error {
+	return es.deleteLocation(ctx, es.archive, id)
+}
+
+func (es *ElasticsearchStorage) deleteLocation(ctx context.Context, index, id string) error {
+	url := fmt.Sprintf("%s/%s/_doc/%s", es.baseURL, index, id)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete location: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return ErrLocationNotFound
+	}
+
+	if res.StatusCode >= 400 {
+		return newESError("error deleting location", res.StatusCode, res.Body)
+	}
+
+	es.markWritten(false)
+	return nil
+}
+
+// ScanLocationIDs последовательно обходит ID всех документов основного и архивного индексов
+// пачками размера batchSize, не загружая тела документов.
+func (es *ElasticsearchStorage) ScanLocationIDs(ctx context.Context, batchSize int, fn func([]string) error) error {
+	var searchAfter []interface{}
+
+	for {
+		query := map[string]interface{}{
+			"size":    batchSize,
+			"_source": false,
+			"sort": []map[string]interface{}{
+				{"id": map[string]interface{}{"order": "asc"}},
+			},
+		}
+		if searchAfter != nil {
+			query["search_after"] = searchAfter
+		}
+
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(query); err != nil {
+			return fmt.Errorf("failed to encode query: %w", err)
+		}
+
+		url := fmt.Sprintf("%s/%s,%s/_search", es.baseURL, es.index, es.archive)
+		req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := es.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to search: %w", err)
+		}
+
+		if res.StatusCode >= 400 {
+			err := newESError("error searching", res.StatusCode, res.Body)
+			res.Body.Close()
+			return err
+		}
+
+		var result struct {
+			Hits struct {
+				Hits []struct {
+					ID   string        `json:"_id"`
+					Sort []interface{} `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		hits := result.Hits.Hits
+		if len(hits) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.ID
+		}
+
+		if err := fn(ids); err != nil {
+			return err
+		}
+
+		if len(hits) < batchSize {
+			return nil
+		}
+		searchAfter = hits[len(hits)-1].Sort
+	}
+}
+
+// GetLocationsByIDs возвращает локации с указанными ID из основного и архивного индексов.
+// Отсутствующие ID пропускаются; порядок результата не гарантируется.
+func (es *ElasticsearchStorage) GetLocationsByIDs(ctx context.Context, ids []string) (map[string]*models.Location, error) {
+	locations := make(map[string]*models.Location, len(ids))
+	if len(ids) == 0 {
+		return locations, nil
+	}
+
+	query := map[string]interface{}{
+		"size": len(ids),
+		"query": map[string]interface{}{
+			"ids": map[string]interface{}{
+				"values": ids,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s,%s/_search", es.baseURL, es.index, es.archive)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Index  string          `json:"_index"`
+				ID     string          `json:"_id"`
+				Source models.Location `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for i := range result.Hits.Hits {
+		hit := &result.Hits.Hits[i]
+		hit.Source.Archived = hit.Index == es.archive
+		// При расхождении индексов приоритет у основного
+		if existing, ok := locations[hit.ID]; ok && !existing.Archived {
+			continue
+		}
+		locations[hit.ID] = &hit.Source
+	}
+
+	return locations, nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// ESError — ответ Elasticsearch/OpenSearch с кодом ошибки. Помимо текста ошибки содержит
+// тип и причину из тела ответа, которые выводятся отдельными полями журнала (см. LogAttrs).
+type ESError struct {
+	Message string // Описание операции, например "error searching"
+	Status  int    // HTTP статус ответа
+	Type    string // error.type из тела ответа, например index_not_found_exception
+	Reason  string // error.reason из тела ответа
+	Body    string // Тело ответа
+}
+
+// newESError читает тело ответа с кодом ошибки status и возвращает ESError.
+func newESError(message string, status int, body io.Reader) *ESError {
+	data, _ := io.ReadAll(body)
+	e := &ESError{Message: message, Status: status, Body: string(data)}
+
+	var parsed struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &parsed) == nil {
+		e.Type, e.Reason = parsed.Error.Type, parsed.Error.Reason
+	}
+	return e
+}
+
+func (e *ESError) Error() string {
+	return fmt.Sprintf("%s: status %d, body: %s", e.Message, e.Status, e.Body)
+}
+
+// LogAttrs возвращает поля журнала с подробностями ошибки.
+func (e *ESError) LogAttrs() []slog.Attr {
+	attrs := []slog.Attr{slog.String("source", "elasticsearch"), slog.Int("status", e.Status)}
+	if e.Type != "" {
+		attrs = append(attrs, slog.String("type", e.Type))
+	}
+	if e.Reason != "" {
+		attrs = append(attrs, slog.String("reason", e.Reason))
+	}
+	return attrs
+}
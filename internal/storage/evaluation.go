@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// RecentQueries возвращает до limit последних запросов рекомендаций начиная с since.
+func (ps *PostgresStorage) RecentQueries(ctx context.Context, since time.Time, limit int) ([]models.RecommendRequest, error) {
+	query := `SELECT request FROM query_history WHERE created_at >= $1 ORDER BY created_at DESC LIMIT $2`
+
+	rows, err := ps.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent queries: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.RecommendRequest
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan query: %w", err)
+		}
+		var req models.RecommendRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return requests, nil
+}
+
+// RelevanceLabels возвращает оценки релевантности локаций (0–3) по отзывам пользователей:
+// статусу локации в проектах (selected — 3, visited — 2, reviewing — 1, rejected — 0)
+// и средней оценке в заметках (5 — 3, 4 — 2, 3 — 1, ниже — 0). Берется максимум из источников;
+// локации без отзывов в результат не входят.
+func (ps *PostgresStorage) RelevanceLabels(ctx context.Context) (map[string]float64, error) {
+	query := `SELECT location_id, MAX(label) FROM (
+			SELECT location_id, CASE status
+				WHEN 'selected' THEN 3 WHEN 'visited' THEN 2 WHEN 'reviewing' THEN 1 ELSE 0 END AS label
+			FROM project_candidates
+			UNION ALL
+			SELECT location_id, GREATEST(ROUND(AVG(rating)) - 2, 0) AS label
+			FROM location_notes WHERE rating IS NOT NULL GROUP BY location_id
+		) labels GROUP BY location_id`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query relevance labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := make(map[string]float64)
+	for rows.Next() {
+		var (
+			id    string
+			label float64
+		)
+		if err := rows.Scan(&id, &label); err != nil {
+			return nil, fmt.Errorf("failed to scan relevance label: %w", err)
+		}
+		labels[id] = label
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return labels, nil
+}
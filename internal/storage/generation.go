@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/logging"
+)
+
+// generationSettleDelay — через сколько после записи без принудительного обновления индекса поколение
+// данных увеличивается повторно. Такие записи становятся видны поиску после периодического обновления
+// (refresh_interval, по умолчанию 1 с), и результаты, закешированные в этом промежутке, могут их не содержать.
+const generationSettleDelay = 2 * time.Second
+
+// Generation возвращает поколение данных индексов локаций — число, которое увеличивается
+// после каждой успешной записи через это хранилище и при записях других процессов,
+// обнаруженных WatchGeneration. Кеши результатов поиска включают поколение в ключ,
+// чтобы изменение данных сразу делало прежние записи кеша недоступными.
+func (es *ElasticsearchStorage) Generation() uint64 {
+	return es.generation.Load()
+}
+
+// OnGenerationChange регистрирует функцию, которую хранилище вызывает с новым поколением данных
+// после каждого его увеличения, например для удаления записей кеша прежних поколений. Функции
+// вызываются синхронно и, возможно, одновременно из разных горутин, не по порядку поколений.
+// Регистрировать их нужно до начала работы хранилища.
+func (es *ElasticsearchStorage) OnGenerationChange(fn func(generation uint64)) {
+	es.generationHooks = append(es.generationHooks, fn)
+}
+
+// markWritten увеличивает поколение данных после записи. Запись, видимая поиску не сразу
+// (visible == false), увеличивает поколение еще раз через generationSettleDelay,
+// если индекс не был обновлен принудительно после нее.
+func (es *ElasticsearchStorage) markWritten(visible bool) {
+	es.nextGeneration()
+	if visible {
+		return
+	}
+	written := time.Now().UnixNano()
+	time.AfterFunc(generationSettleDelay, func() {
+		if es.refreshedAt.Load() < written {
+			es.nextGeneration()
+		}
+	})
+}
+
+// nextGeneration увеличивает поколение данных и сообщает о нем функциям OnGenerationChange.
+func (es *ElasticsearchStorage) nextGeneration() {
+	generation := es.generation.Add(1)
+	for _, fn := range es.generationHooks {
+		fn(generation)
+	}
+}
+
+// WatchGeneration каждые interval сравнивает счетчики операций записи индекса локаций и архивного
+// индекса с предыдущими и при их изменении увеличивает поколение данных: так кеши экземпляра
+// учитывают записи других экземпляров сервиса и индексатора. Ошибки опроса пишутся в лог.
+// Блокируется до отмены ctx.
+func (es *ElasticsearchStorage) WatchGeneration(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last int64 = -1
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		ops, err := es.writeOperations(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Could not check index write operations", logging.Err(err))
+			continue
+		}
+		// Первый опрос только запоминает счетчики; они сбрасываются при перезапуске узлов,
+		// что тоже считается изменением
+		if last >= 0 && ops != last {
+			es.markWritten(false)
+		}
+		last = ops
+	}
+}
+
+// writeOperations возвращает суммарное число операций индексации и удаления в первичных шардах
+// индекса локаций и архивного индекса.
+func (es *ElasticsearchStorage) writeOperations(ctx context.Context) (int64, error) {
+	var result struct {
+		All struct {
+			Primaries struct {
+				Indexing struct {
+					IndexTotal  int64 `json:"index_total"`
+					DeleteTotal int64 `json:"delete_total"`
+				} `json:"indexing"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	url := fmt.Sprintf("%s/%s,%s/_stats/indexing?ignore_unavailable=true", es.baseURL, es.index, es.archive)
+	if err := es.getJSON(ctx, url, &result); err != nil {
+		return 0, fmt.Errorf("failed to get indexing stats: %w", err)
+	}
+
+	indexing := result.All.Primaries.Indexing
+	return indexing.IndexTotal + indexing.DeleteTotal, nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/lib/pq"
+)
+
+// ErrGoldenQueryNotFound возвращается, если эталонный запрос отсутствует.
+var ErrGoldenQueryNotFound = errors.New("golden query not found")
+
+// UpsertGoldenQuery создает эталонный запрос или заменяет существующий с тем же ID.
+func (ps *PostgresStorage) UpsertGoldenQuery(ctx context.Context, gq *models.GoldenQuery) error {
+	request, err := json.Marshal(gq.Request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	query := `INSERT INTO golden_queries (id, name, request, expected_ids, top_k)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, request = EXCLUDED.request,
+			expected_ids = EXCLUDED.expected_ids, top_k = EXCLUDED.top_k, updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at`
+
+	err = ps.db.QueryRowContext(ctx, query, gq.ID, gq.Name, request, pq.Array(gq.ExpectedIDs), gq.TopK).
+		Scan(&gq.CreatedAt, &gq.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert golden query: %w", err)
+	}
+
+	return nil
+}
+
+// ListGoldenQueries возвращает все эталонные запросы в порядке ID.
+func (ps *PostgresStorage) ListGoldenQueries(ctx context.Context) ([]models.GoldenQuery, error) {
+	query := `SELECT id, name, request, expected_ids, top_k, created_at, updated_at FROM golden_queries ORDER BY id`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query golden queries: %w", err)
+	}
+	defer rows.Close()
+
+	var queries []models.GoldenQuery
+	for rows.Next() {
+		var (
+			gq      models.GoldenQuery
+			request []byte
+		)
+		if err := rows.Scan(&gq.ID, &gq.Name, &request, pq.Array(&gq.ExpectedIDs), &gq.TopK, &gq.CreatedAt, &gq.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan golden query: %w", err)
+		}
+		if err := json.Unmarshal(request, &gq.Request); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+		}
+		queries = append(queries, gq)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return queries, nil
+}
+
+// DeleteGoldenQuery удаляет эталонный запрос. Возвращает ErrGoldenQueryNotFound, если его нет.
+func (ps *PostgresStorage) DeleteGoldenQuery(ctx context.Context, id string) error {
+	res, err := ps.db.ExecContext(ctx, `DELETE FROM golden_queries WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete golden query: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrGoldenQueryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Статусы кластера Elasticsearch.
+const (
+	ClusterStatusGreen  = "green"
+	ClusterStatusYellow = "yellow" // Не все реплики размещены, данные доступны
+	ClusterStatusRed    = "red"    // Часть первичных шардов недоступна
+)
+
+// Health проверяет доступность Elasticsearch для обслуживания запросов: возвращает статус
+// кластера и ошибку, если кластер недоступен, находится в статусе red или индекс локаций
+// не существует.
+func (es *ElasticsearchStorage) Health(ctx context.Context) (string, error) {
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := es.getJSON(ctx, es.baseURL+"/_cluster/health", &result); err != nil {
+		return "", fmt.Errorf("failed to get cluster health: %w", err)
+	}
+	if result.Status == ClusterStatusRed {
+		return result.Status, fmt.Errorf("cluster status is %s", result.Status)
+	}
+
+	exists, err := es.IndexExists(ctx)
+	if err != nil {
+		return result.Status, err
+	}
+	if !exists {
+		return result.Status, fmt.Errorf("index %s does not exist", es.index)
+	}
+	return result.Status, nil
+}
+
+// IndexExists сообщает, существует ли индекс (или псевдоним) локаций.
+func (es *ElasticsearchStorage) IndexExists(ctx context.Context) (bool, error) {
+	res, err := es.client.Indices.Exists([]string{es.index}, es.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to check index existence: %w", err)
+	}
+	defer res.Body.Close()
+	return res.StatusCode == 200, nil
+}
+
+// Ping проверяет подключение к PostgreSQL.
+func (ps *PostgresStorage) Ping(ctx context.Context) error {
+	return ps.db.PingContext(ctx)
+}
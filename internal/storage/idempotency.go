@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ReserveIdempotencyKey резервирует ключ идемпотентности для выполнения запроса.
+// Если ключ свободен или срок его хранения истек, он занимается и возвращается nil.
+// Иначе возвращается сохраненная запись (возможно, еще не завершенная).
+func (ps *PostgresStorage) ReserveIdempotencyKey(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	query := `INSERT INTO idempotency_keys (scope, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, completed = FALSE, status_code = NULL,
+			content_type = NULL, body = NULL, created_at = CURRENT_TIMESTAMP, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < CURRENT_TIMESTAMP`
+
+	res, err := ps.db.ExecContext(ctx, query, scope, key, requestHash, time.Now().Add(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil, nil
+	}
+
+	record := &models.IdempotencyRecord{}
+	var status sql.NullInt64
+	var contentType sql.NullString
+	err = ps.db.QueryRowContext(ctx,
+		`SELECT request_hash, completed, status_code, content_type, body FROM idempotency_keys WHERE scope = $1 AND key = $2`,
+		scope, key,
+	).Scan(&record.RequestHash, &record.Completed, &status, &contentType, &record.Body)
+	if err == sql.ErrNoRows {
+		// Ключ удалили между вставкой и чтением — повторяем резервирование
+		return ps.ReserveIdempotencyKey(ctx, scope, key, requestHash, ttl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	record.StatusCode = int(status.Int64)
+	record.ContentType = contentType.String
+
+	return record, nil
+}
+
+// CompleteIdempotencyKey сохраняет ответ на запрос, выполненный под ключом идемпотентности.
+func (ps *PostgresStorage) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error {
+	query := `UPDATE idempotency_keys SET completed = TRUE, status_code = $3, content_type = $4, body = $5
+		WHERE scope = $1 AND key = $2`
+
+	if _, err := ps.db.ExecContext(ctx, query, scope, key, statusCode, contentType, body); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ, чтобы запрос можно было повторить.
+func (ps *PostgresStorage) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys удаляет ключи с истекшим сроком хранения и возвращает их количество.
+func (ps *PostgresStorage) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	res, err := ps.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// LocationSearcher — чтение локаций из поискового индекса. Реализуется ElasticsearchStorage;
+// сервисы, зависящие от интерфейса, можно проверять с хранилищем в памяти (пакет storage/memory)
+// и переводить на другие поисковые движки.
+type LocationSearcher interface {
+	// GetLocation возвращает локацию основного индекса или ErrLocationNotFound
+	GetLocation(ctx context.Context, id string) (*models.Location, error)
+	// GetArchivedLocation возвращает локацию архивного индекса или ErrLocationNotFound
+	GetArchivedLocation(ctx context.Context, id string) (*models.Location, error)
+	// MultiGetLocations возвращает найденные локации по ID (при includeArchived — также из архива)
+	MultiGetLocations(ctx context.Context, ids []string, includeArchived bool) (map[string]*models.Location, error)
+	// LocationsExist сообщает, какие из локаций есть в индексе (при includeArchived — также в архиве)
+	LocationsExist(ctx context.Context, ids []string, includeArchived bool) (map[string]bool, error)
+	// CountLocations возвращает количество локаций основного индекса, подходящих под фильтр
+	CountLocations(ctx context.Context, filter *models.LocationFilter) (int, error)
+	// ScanLocations обходит локации основного индекса, подходящие под фильтр, пачками в порядке ID
+	ScanLocations(ctx context.Context, filter *models.LocationFilter, batchSize int, fn func([]*models.Location) error) error
+	// ListLocationsPage возвращает до size локаций с ID больше afterID в порядке ID без embeddings
+	ListLocationsPage(ctx context.Context, filter *models.LocationFilter, afterID string, size int) ([]*models.Location, error)
+	// SampleLocations возвращает воспроизводимую при том же seed псевдослучайную выборку локаций
+	SampleLocations(ctx context.Context, filter *models.LocationFilter, seed int64, size int) ([]*models.Location, error)
+	// CountLocationsBy возвращает количество подходящих под фильтр локаций по значениям поля
+	CountLocationsBy(ctx context.Context, filter *models.LocationFilter, field string) (map[string]int, error)
+}
+
+// LocationIndex — поисковый индекс локаций для обслуживания: помимо чтения, маппинг индекса
+// и обновление embeddings. Реализуется ElasticsearchStorage.
+type LocationIndex interface {
+	LocationSearcher
+	// GetMapping возвращает маппинг индекса локаций (секции properties и runtime)
+	GetMapping(ctx context.Context) (map[string]interface{}, error)
+	// GetVectorIndexOptions возвращает параметры kNN индекса поля embedding
+	GetVectorIndexOptions(ctx context.Context) (*VectorIndexOptions, error)
+	// BulkUpdateEmbeddings обновляет embeddings локаций и возвращает число неудачных обновлений
+	BulkUpdateEmbeddings(ctx context.Context, embeddings map[string]LocationVectors, version int) (int, error)
+}
+
+// RecommendationSearcher — поиск рекомендаций в индексе локаций. Реализуется ElasticsearchStorage.
+type RecommendationSearcher interface {
+	// Generation возвращает поколение данных индекса; кеши результатов поиска включают его в ключ
+	Generation() uint64
+	// RecommendLocations возвращает страницу локаций, подходящих под запрос, в порядке ранжирования
+	RecommendLocations(ctx context.Context, req *models.RecommendRequest) (*RecommendResult, error)
+	// GetVectorIndexOptions возвращает параметры kNN индекса поля embedding
+	GetVectorIndexOptions(ctx context.Context) (*VectorIndexOptions, error)
+	// GetRegionCities возвращает города индекса по регионам
+	GetRegionCities(ctx context.Context) (map[string][]string, error)
+}
+
+// LocationVectors — векторы локации, записываемые пересчетом embeddings.
+type LocationVectors struct {
+	Text        []float64 // Embedding текста локации (поле embedding)
+	Demographic []float64 // Вектор демографического профиля (поле demographic_embedding), nil — без демографии
+}
+
+// LocationStore — система-источник истины для записи локаций. Реализуется PostgresStorage,
+// изменения которого доставляются в поисковый индекс через outbox.
+type LocationStore interface {
+	// SaveLocation создает или заменяет локацию
+	SaveLocation(ctx context.Context, location *models.Location) error
+	// CreateLocation создает локацию или возвращает ErrLocationExists
+	CreateLocation(ctx context.Context, location *models.Location) error
+	// UpdateLocation заменяет локацию, сохраняя время создания, состояние жизненного цикла
+	// и историю демографии, или возвращает ErrLocationNotFound
+	UpdateLocation(ctx context.Context, location *models.Location) error
+	// DeleteLocation удаляет локацию или возвращает ErrLocationNotFound
+	DeleteLocation(ctx context.Context, id string) error
+	// GetStoredLocations возвращает записанные локации по ID; отсутствующие ID в результат не попадают
+	GetStoredLocations(ctx context.Context, ids []string) (map[string]*models.Location, error)
+	// TransitionLocationStatus переводит локацию в состояние transition.To и записывает переход
+	// в журнал; возвращает ErrLocationNotFound или ErrLocationStatusTransition
+	TransitionLocationStatus(ctx context.Context, transition *models.LocationStatusTransition) error
+	// ListLocationStatusTransitions возвращает журнал переходов локации в порядке выполнения
+	ListLocationStatusTransitions(ctx context.Context, locationID string) ([]models.LocationStatusTransition, error)
+}
+
+// ReferenceStore — справочники типов бизнеса, регионов и городов. Реализуется PostgresStorage.
+type ReferenceStore interface {
+	GetBusinessTypes(ctx context.Context) ([]*models.BusinessType, error)
+	GetRegions(ctx context.Context) ([]*models.Region, error)
+	GetCities(ctx context.Context) ([]*models.City, error)
+}
+
+// CityStore — запись справочника городов. Реализуется PostgresStorage.
+type CityStore interface {
+	// GetCity возвращает город по ID или ErrCityNotFound
+	GetCity(ctx context.Context, id int) (*models.City, error)
+	// CreateCity добавляет город или возвращает ErrCityExists, ErrCityRegionNotFound
+	CreateCity(ctx context.Context, city *models.City) error
+	// UpdateCity заменяет город city.ID или возвращает ErrCityNotFound, ErrCityExists, ErrCityRegionNotFound
+	UpdateCity(ctx context.Context, city *models.City) error
+	// DeleteCity удаляет город или возвращает ErrCityNotFound
+	DeleteCity(ctx context.Context, id int) error
+}
+
+// RankingStore — настройки ранжирования рекомендаций и журнал запросов. Реализуется PostgresStorage.
+type RankingStore interface {
+	// GetBusinessTypes возвращает справочник типов бизнеса
+	GetBusinessTypes(ctx context.Context) ([]*models.BusinessType, error)
+	// ExpandBusinessType возвращает тип бизнеса с подтипами и категориями таксономии
+	ExpandBusinessType(ctx context.Context, name string) ([]string, error)
+	// ListBusinessTypeSubstitutes возвращает замещающие типы бизнеса в порядке убывания доли
+	ListBusinessTypeSubstitutes(ctx context.Context, businessType string) ([]models.BusinessTypeSubstitute, error)
+	// GetVectorWeights возвращает веса векторного поиска типа бизнеса или nil, если они не заданы
+	GetVectorWeights(ctx context.Context, businessType string) (*models.VectorWeights, error)
+	// GetSeasonalCoefficients возвращает сезонные коэффициенты типа бизнеса для месяца
+	GetSeasonalCoefficients(ctx context.Context, businessType string, month int) (*models.SeasonalCoefficients, error)
+	// GetScoringBoosts возвращает профиль ранжирования типа бизнеса
+	GetScoringBoosts(ctx context.Context, businessType string) ([]models.ScoringBoost, error)
+	// GetRankingOverrides возвращает ранжирование по умолчанию организации и клиента
+	GetRankingOverrides(ctx context.Context, organization, subject string) ([]models.RankingOverride, error)
+	// RecordQuery записывает запрос рекомендаций в журнал
+	RecordQuery(ctx context.Context, entry *models.QueryHistoryEntry) error
+}
+
+// Проверка соответствия реализаций интерфейсам при компиляции
+var (
+	_ LocationIndex          = (*ElasticsearchStorage)(nil)
+	_ RecommendationSearcher = (*ElasticsearchStorage)(nil)
+	_ LocationStore          = (*PostgresStorage)(nil)
+	_ ReferenceStore         = (*PostgresStorage)(nil)
+	_ CityStore              = (*PostgresStorage)(nil)
+	_ RankingStore           = (*PostgresStorage)(nil)
+)
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ListDuePublications возвращает до limit ID опубликованных локаций, срок публикации которых
+// (publish_at) наступил к моменту now.
+func (ps *PostgresStorage) ListDuePublications(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	query := `SELECT id FROM locations
+		WHERE data->>'publish_at' IS NOT NULL AND (data->>'publish_at')::timestamptz <= $1
+			AND COALESCE(NULLIF(data->>'status', ''), $3) = $3
+		ORDER BY id LIMIT $2`
+	return ps.listLocationIDs(ctx, query, now, limit, models.LocationPublished)
+}
+
+// ListExpiredLocations возвращает до limit ID не снятых с публикации локаций, срок показа
+// которых (expire_at) истек к моменту now.
+func (ps *PostgresStorage) ListExpiredLocations(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	query := `SELECT id FROM locations
+		WHERE data->>'expire_at' IS NOT NULL AND (data->>'expire_at')::timestamptz <= $1
+			AND COALESCE(data->>'status', '') <> $3
+		ORDER BY id LIMIT $2`
+	return ps.listLocationIDs(ctx, query, now, limit, models.LocationRetired)
+}
+
+func (ps *PostgresStorage) listLocationIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan location id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating locations: %w", err)
+	}
+
+	return ids, nil
+}
+
+// CompletePublication снимает с опубликованной локации наступивший к моменту now срок
+// публикации (publish_at) и записывает ее в outbox: после доставки локация переиндексируется
+// и проверяется по сохраненным поискам. Возвращает false, если локации нет, она не опубликована
+// или срок публикации не наступил (например, его перенесли после выборки).
+func (ps *PostgresStorage) CompletePublication(ctx context.Context, id string, now time.Time) (bool, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previousData []byte
+	err = tx.QueryRowContext(ctx, `SELECT data FROM locations WHERE id = $1 FOR UPDATE`, id).Scan(&previousData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	var location models.Location
+	if err := json.Unmarshal(previousData, &location); err != nil {
+		return false, fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	if location.CurrentStatus() != models.LocationPublished || location.PublishAt == nil || location.PublishAt.After(now) {
+		return false, nil
+	}
+
+	location.PublishAt = nil
+	location.UpdatedAt = now
+	data, err := json.Marshal(&location)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	query := `UPDATE locations SET data = $2, updated_at = $3 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, location.ID, data, location.UpdatedAt); err != nil {
+		return false, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	if err := commitLocationWrite(ctx, tx, location.ID, data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ErrLocationStatusTransition возвращается, если локацию нельзя перевести из текущего состояния в запрошенное.
+var ErrLocationStatusTransition = errors.New("location status transition not allowed")
+
+// TransitionLocationStatus переводит локацию transition.LocationID в состояние transition.To,
+// если переход из текущего состояния допустим (см. models.LocationStatusTransitionAllowed).
+// Новое состояние документа, запись журнала переходов и запись outbox фиксируются в одной
+// транзакции; в transition заполняются исходное состояние, ID и время перехода.
+// Возвращает ErrLocationNotFound, если локация отсутствует, и ErrLocationStatusTransition,
+// если переход недопустим.
+func (ps *PostgresStorage) TransitionLocationStatus(ctx context.Context, transition *models.LocationStatusTransition) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previousData []byte
+	err = tx.QueryRowContext(ctx, `SELECT data FROM locations WHERE id = $1 FOR UPDATE`, transition.LocationID).Scan(&previousData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLocationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+
+	var location models.Location
+	if err := json.Unmarshal(previousData, &location); err != nil {
+		return fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	transition.From = location.CurrentStatus()
+	if !models.LocationStatusTransitionAllowed(transition.From, transition.To) {
+		return ErrLocationStatusTransition
+	}
+
+	audit := `INSERT INTO location_status_transitions (location_id, from_status, to_status, comment, actor, organization)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	if err := tx.QueryRowContext(ctx, audit,
+		transition.LocationID,
+		transition.From,
+		transition.To,
+		transition.Comment,
+		transition.Actor,
+		transition.Organization,
+	).Scan(&transition.ID, &transition.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert location status transition: %w", err)
+	}
+
+	location.Status = transition.To
+	location.UpdatedAt = transition.CreatedAt
+	data, err := json.Marshal(&location)
+	if err != nil {
+		return fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	query := `UPDATE locations SET data = $2, updated_at = $3 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, location.ID, data, location.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+
+	return commitLocationWrite(ctx, tx, location.ID, data)
+}
+
+// ListLocationStatusTransitions возвращает журнал переходов локации между состояниями
+// в порядке выполнения.
+func (ps *PostgresStorage) ListLocationStatusTransitions(ctx context.Context, locationID string) ([]models.LocationStatusTransition, error) {
+	query := `SELECT id, location_id, from_status, to_status, comment, actor, organization, created_at
+		FROM location_status_transitions WHERE location_id = $1 ORDER BY created_at, id`
+
+	rows, err := ps.db.QueryContext(ctx, query, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query location status transitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := []models.LocationStatusTransition{}
+	for rows.Next() {
+		var t models.LocationStatusTransition
+		if err := rows.Scan(&t.ID, &t.LocationID, &t.From, &t.To, &t.Comment, &t.Actor, &t.Organization, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan location status transition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating location status transitions: %w", err)
+	}
+
+	return transitions, nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/lock"
+	"github.com/lib/pq"
+)
+
+// advisoryLockNamespace — первый ключ рекомендательных блокировок PostgreSQL сервиса,
+// отделяющий их от блокировок других приложений той же базы. Второй ключ — hashtext(имя).
+const advisoryLockNamespace = 0x474f4553
+
+// advisoryUnlockTimeout ограничивает освобождение блокировки: зависшее соединение не должно
+// задерживать завершение задания и остановку сервиса.
+const advisoryUnlockTimeout = 10 * time.Second
+
+// AdvisoryLocks — хранилище блокировок заданий на рекомендательных блокировках PostgreSQL
+// (pg_try_advisory_lock). Блокировка удерживается выделенным соединением пула и освобождается
+// сервером при разрыве соединения, поэтому упавший экземпляр не оставляет блокировок.
+type AdvisoryLocks struct {
+	ps *PostgresStorage
+}
+
+// AdvisoryLocks возвращает хранилище блокировок заданий в базе PostgresStorage.
+func (ps *PostgresStorage) AdvisoryLocks() *AdvisoryLocks {
+	return &AdvisoryLocks{ps: ps}
+}
+
+// TryLock захватывает блокировку name без ожидания. На время удержания application_name
+// соединения равен instance: по нему Holders определяет удерживающий экземпляр.
+func (l *AdvisoryLocks) TryLock(ctx context.Context, name, instance string) (func() error, bool, error) {
+	conn, err := l.ps.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, advisoryLockNamespace, name).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), advisoryUnlockTimeout)
+		defer cancel()
+		// Соединение возвращается в пул: блокировка и имя экземпляра не должны остаться на нем.
+		// Если их не удалось снять, соединение закрывается, и сервер освобождает блокировку сам
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, hashtext($2))`, advisoryLockNamespace, name); err != nil {
+			discardConn(conn)
+			return fmt.Errorf("failed to release advisory lock: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, `RESET application_name`); err != nil {
+			discardConn(conn)
+			return fmt.Errorf("failed to reset application name: %w", err)
+		}
+		return conn.Close()
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT set_config('application_name', $1, false)`, instance); err != nil {
+		release()
+		return nil, false, fmt.Errorf("failed to set application name: %w", err)
+	}
+	return release, true, nil
+}
+
+// discardConn закрывает соединение conn, не возвращая его в пул.
+func discardConn(conn *sql.Conn) {
+	// driver.ErrBadConn из Raw помечает соединение негодным: пул закрывает его вместо повторного использования
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
+
+// Holders возвращает экземпляры, удерживающие блокировки из names, по pg_locks и pg_stat_activity.
+func (l *AdvisoryLocks) Holders(ctx context.Context, names []string) (map[string]lock.Holder, error) {
+	// Ключи блокировки с двумя int4 хранятся в classid и objid (objsubid = 2) как беззнаковые
+	query := `SELECT n.name, a.application_name, COALESCE(host(a.client_addr), '')
+		FROM unnest($2::text[]) AS n(name)
+		JOIN pg_locks l ON l.locktype = 'advisory' AND l.granted AND l.objsubid = 2
+			AND l.classid::bigint = $1 AND l.objid::bigint = (hashtext(n.name)::bigint & 4294967295)
+		JOIN pg_stat_activity a ON a.pid = l.pid`
+
+	rows, err := l.ps.db.QueryContext(ctx, query, advisoryLockNamespace, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query advisory locks: %w", err)
+	}
+	defer rows.Close()
+
+	holders := make(map[string]lock.Holder)
+	for rows.Next() {
+		var name string
+		var holder lock.Holder
+		if err := rows.Scan(&name, &holder.Instance, &holder.Address); err != nil {
+			return nil, fmt.Errorf("failed to scan advisory lock: %w", err)
+		}
+		holders[name] = holder
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating advisory locks: %w", err)
+	}
+
+	return holders, nil
+}
+
+// Проверка соответствия интерфейсу при компиляции
+var _ lock.Backend = (*AdvisoryLocks)(nil)
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/embedding"
+)
+
+// VectorIndexOptions содержит параметры kNN индекса для поля embedding.
+type VectorIndexOptions struct {
+	Dims           int    `json:"dims"`            // Размерность векторов
+	Similarity     string `json:"similarity"`      // Метрика сходства: cosine, dot_product, l2_norm
+	M              int    `json:"m"`               // HNSW: число связей узла графа
+	EfConstruction int    `json:"ef_construction"` // HNSW: размер списка кандидатов при построении графа
+}
+
+// supportedSimilarities перечисляет метрики сходства dense_vector в Elasticsearch.
+var supportedSimilarities = map[string]bool{
+	"cosine":      true,
+	"dot_product": true,
+	"l2_norm":     true,
+}
+
+// IsSupportedSimilarity сообщает, поддерживается ли метрика сходства векторов.
+func IsSupportedSimilarity(similarity string) bool {
+	return supportedSimilarities[similarity]
+}
+
+// Validate проверяет корректность параметров kNN индекса.
+func (o VectorIndexOptions) Validate() error {
+	if o.Dims <= 0 {
+		return fmt.Errorf("invalid vector dims: %d", o.Dims)
+	}
+	if !supportedSimilarities[o.Similarity] {
+		return fmt.Errorf("unsupported vector similarity: %q", o.Similarity)
+	}
+	if o.M <= 0 {
+		return fmt.Errorf("invalid HNSW m: %d", o.M)
+	}
+	if o.EfConstruction <= 0 {
+		return fmt.Errorf("invalid HNSW ef_construction: %d", o.EfConstruction)
+	}
+	return nil
+}
+
+// BuildLocationsMapping строит маппинг индекса локаций с заданными параметрами kNN индекса.
+// Вектор демографического профиля индексируется отдельным kNN полем demographic_embedding
+// фиксированной размерности с метрикой cosine и теми же параметрами HNSW.
+func BuildLocationsMapping(vector VectorIndexOptions) (string, error) {
+	if err := vector.Validate(); err != nil {
+		return "", err
+	}
+
+	textWithKeyword := map[string]interface{}{
+		"type": "text",
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword"},
+		},
+	}
+
+	// Коэффициенты сезонности хранятся объектом с полями по номерам месяцев, чтобы скрипт
+	// ранжирования обращался к месяцу напрямую (doc values массива не сохраняют порядок)
+	seasonality := make(map[string]interface{}, 12)
+	for month := 1; month <= 12; month++ {
+		seasonality[fmt.Sprint(month)] = map[string]interface{}{"type": "float"}
+	}
+
+	mapping := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"id":                      map[string]interface{}{"type": "keyword"},
+				"name":                    textWithKeyword,
+				"address":                 textWithKeyword,
+				"coordinates":             map[string]interface{}{"type": "geo_point"},
+				"region":                  map[string]interface{}{"type": "keyword"},
+				"city":                    map[string]interface{}{"type": "keyword"},
+				"description":             map[string]interface{}{"type": "text"},
+				"business_types_suitable": map[string]interface{}{"type": "keyword"},
+				"status":                  map[string]interface{}{"type": "keyword"},
+				"publish_at":              map[string]interface{}{"type": "date"},
+				"expire_at":               map[string]interface{}{"type": "date"},
+				"traffic_score":           map[string]interface{}{"type": "float"},
+				"competition_density":     map[string]interface{}{"type": "float"},
+				"event_exposure":          map[string]interface{}{"type": "float"},
+				"schools_nearby":          map[string]interface{}{"type": "integer"},
+				"universities_nearby":     map[string]interface{}{"type": "integer"},
+				"street_parking_score":    map[string]interface{}{"type": "float"},
+				"paid_lots_nearby":        map[string]interface{}{"type": "integer"},
+				"safety_score":            map[string]interface{}{"type": "float"},
+				"demographics": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"age_group":          map[string]interface{}{"type": "keyword"},
+						"average_income":     map[string]interface{}{"type": "float"},
+						"interests":          map[string]interface{}{"type": "keyword"},
+						"population_density": map[string]interface{}{"type": "float"},
+						// Сегменты индексируются отдельными документами: условия запроса по возрасту,
+						// доле и доходу должны выполняться в одном сегменте
+						"segments": map[string]interface{}{
+							"type": "nested",
+							"properties": map[string]interface{}{
+								"age_group":      map[string]interface{}{"type": "keyword"},
+								"share":          map[string]interface{}{"type": "float"},
+								"average_income": map[string]interface{}{"type": "float"},
+							},
+						},
+					},
+				},
+				// История демографии читается скриптом ранжирования из _source, индексируется
+				// только дата начала действия версии
+				"demographics_history": map[string]interface{}{
+					"type": "nested",
+					"properties": map[string]interface{}{
+						"effective_from": map[string]interface{}{"type": "date"},
+						"demographics":   map[string]interface{}{"type": "object", "enabled": false},
+					},
+				},
+				"embedding": map[string]interface{}{
+					"type":       "dense_vector",
+					"dims":       vector.Dims,
+					"index":      true,
+					"similarity": vector.Similarity,
+					"index_options": map[string]interface{}{
+						"type":            "hnsw",
+						"m":               vector.M,
+						"ef_construction": vector.EfConstruction,
+					},
+				},
+				"demographic_embedding": map[string]interface{}{
+					"type":       "dense_vector",
+					"dims":       embedding.DemographicDims,
+					"index":      true,
+					"similarity": "cosine",
+					"index_options": map[string]interface{}{
+						"type":            "hnsw",
+						"m":               vector.M,
+						"ef_construction": vector.EfConstruction,
+					},
+				},
+				"seasonality":       map[string]interface{}{"properties": seasonality},
+				"embedding_version": map[string]interface{}{"type": "integer"},
+				"created_at":        map[string]interface{}{"type": "date"},
+				"updated_at":        map[string]interface{}{"type": "date"},
+			},
+		},
+	}
+
+	data, err := json.Marshal(mapping)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// MissingMappingFields возвращает поля маппинга, построенного BuildLocationsMapping, которых нет
+// в маппинге индекса current (результат GetMapping). Отсутствие полей означает, что индекс создан
+// по устаревшему маппингу и требует обновления маппинга или переиндексации.
+func MissingMappingFields(current map[string]interface{}, vector VectorIndexOptions) ([]string, error) {
+	mapping, err := BuildLocationsMapping(vector)
+	if err != nil {
+		return nil, err
+	}
+	var desired struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(mapping), &desired); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+
+	properties, _ := current["properties"].(map[string]interface{})
+	missing := missingProperties(desired.Mappings.Properties, properties, "")
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// MappingTypeMismatches возвращает поля маппинга индекса current (результат GetMapping), тип
+// которых отличается от маппинга, построенного BuildLocationsMapping, например
+// "demographics.segments: object, expected nested". Такие поля появляются, если документы
+// проиндексированы до обновления маппинга и кластер создал поля динамически (например,
+// demographic_embedding как массив float вместо dense_vector); исправить их может только
+// переиндексация. Для векторных полей сверяется и размерность.
+func MappingTypeMismatches(current map[string]interface{}, vector VectorIndexOptions) ([]string, error) {
+	mapping, err := BuildLocationsMapping(vector)
+	if err != nil {
+		return nil, err
+	}
+	var desired struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(mapping), &desired); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+
+	properties, _ := current["properties"].(map[string]interface{})
+	mismatches := mismatchedProperties(desired.Mappings.Properties, properties, "")
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// mismatchedProperties возвращает описания полей desired, которые есть в current с другим типом,
+// включая поля вложенных объектов.
+func mismatchedProperties(desired, current map[string]interface{}, prefix string) []string {
+	var mismatches []string
+	for field, definition := range desired {
+		existing, ok := current[field]
+		if !ok {
+			continue
+		}
+		desiredDef, _ := definition.(map[string]interface{})
+		existingDef, _ := existing.(map[string]interface{})
+		if want, got := fieldType(desiredDef), fieldType(existingDef); want != got {
+			mismatches = append(mismatches, fmt.Sprintf("%s%s: %s, expected %s", prefix, field, got, want))
+			continue
+		}
+		// Размерность векторов задается при создании поля: векторы другой размерности не индексируются
+		if want, got := fmt.Sprint(desiredDef["dims"]), fmt.Sprint(existingDef["dims"]); desiredDef["dims"] != nil && want != got {
+			mismatches = append(mismatches, fmt.Sprintf("%s%s: dims %s, expected %s", prefix, field, got, want))
+			continue
+		}
+		desiredChildren, _ := desiredDef["properties"].(map[string]interface{})
+		currentChildren, _ := existingDef["properties"].(map[string]interface{})
+		if len(desiredChildren) > 0 {
+			mismatches = append(mismatches, mismatchedProperties(desiredChildren, currentChildren, prefix+field+".")...)
+		}
+	}
+	return mismatches
+}
+
+// fieldType возвращает тип поля маппинга; поля с вложенными полями без типа — объекты.
+func fieldType(definition map[string]interface{}) string {
+	if t, ok := definition["type"].(string); ok {
+		return t
+	}
+	return "object"
+}
+
+// mappingAdditions возвращает поля desired, которых нет в current, в виде тела PUT _mapping.
+// Для существующих объектов в тело попадают только недостающие вложенные поля вместе с типом
+// объекта: без него кластер принял бы nested поле за object и отклонил обновление.
+func mappingAdditions(desired, current map[string]interface{}) map[string]interface{} {
+	additions := make(map[string]interface{})
+	for field, definition := range desired {
+		existing, ok := current[field]
+		if !ok {
+			additions[field] = definition
+			continue
+		}
+		desiredDef, _ := definition.(map[string]interface{})
+		desiredChildren, _ := desiredDef["properties"].(map[string]interface{})
+		if len(desiredChildren) == 0 {
+			continue
+		}
+		existingDef, _ := existing.(map[string]interface{})
+		currentChildren, _ := existingDef["properties"].(map[string]interface{})
+		children := mappingAdditions(desiredChildren, currentChildren)
+		if len(children) == 0 {
+			continue
+		}
+		addition := map[string]interface{}{"properties": children}
+		if t, ok := desiredDef["type"]; ok {
+			addition["type"] = t
+		}
+		additions[field] = addition
+	}
+	return additions
+}
+
+// missingProperties возвращает пути полей desired, которых нет в current, включая поля
+// вложенных объектов (например, demographics.segments).
+func missingProperties(desired, current map[string]interface{}, prefix string) []string {
+	var missing []string
+	for field, definition := range desired {
+		existing, ok := current[field]
+		if !ok {
+			missing = append(missing, prefix+field)
+			continue
+		}
+		desiredDef, _ := definition.(map[string]interface{})
+		desiredChildren, _ := desiredDef["properties"].(map[string]interface{})
+		if len(desiredChildren) == 0 {
+			continue
+		}
+		existingDef, _ := existing.(map[string]interface{})
+		currentChildren, _ := existingDef["properties"].(map[string]interface{})
+		missing = append(missing, missingProperties(desiredChildren, currentChildren, prefix+field+".")...)
+	}
+	return missing
+}
@@ -0,0 +1,423 @@
+// Package memory содержит реализации интерфейсов хранилищ пакета storage в памяти процесса —
+// для модульных тестов сервисов и handlers и локальной разработки без Elasticsearch и PostgreSQL.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// Locations хранит локации основного и архивного индексов в памяти. Реализует
+// storage.LocationIndex, storage.RecommendationSearcher и storage.LocationStore: записанные локации сразу доступны чтению,
+// как после доставки outbox в индекс. Локации копируются при записи и чтении.
+type Locations struct {
+	mu          sync.RWMutex
+	active      map[string]*models.Location
+	archived    map[string]*models.Location
+	vector      storage.VectorIndexOptions
+	transitions []models.LocationStatusTransition
+	generation  atomic.Uint64
+}
+
+// NewLocations создает пустое хранилище локаций с параметрами kNN индекса vector.
+func NewLocations(vector storage.VectorIndexOptions) *Locations {
+	return &Locations{
+		active:   make(map[string]*models.Location),
+		archived: make(map[string]*models.Location),
+		vector:   vector,
+	}
+}
+
+// Add добавляет локации в основной индекс (archived = false) или в архив, заменяя локации с теми же ID.
+func (l *Locations) Add(archived bool, locations ...*models.Location) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	target := l.active
+	if archived {
+		target = l.archived
+	}
+	for _, location := range locations {
+		target[location.ID] = clone(location)
+	}
+	l.generation.Add(1)
+}
+
+// Generation возвращает поколение данных — число, которое увеличивается после каждой записи.
+func (l *Locations) Generation() uint64 {
+	return l.generation.Load()
+}
+
+// GetLocation возвращает локацию основного индекса или storage.ErrLocationNotFound.
+func (l *Locations) GetLocation(ctx context.Context, id string) (*models.Location, error) {
+	return l.get(l.active, id)
+}
+
+// GetArchivedLocation возвращает локацию архива с признаком Archived или storage.ErrLocationNotFound.
+func (l *Locations) GetArchivedLocation(ctx context.Context, id string) (*models.Location, error) {
+	location, err := l.get(l.archived, id)
+	if err != nil {
+		return nil, err
+	}
+	location.Archived = true
+	return location, nil
+}
+
+func (l *Locations) get(index map[string]*models.Location, id string) (*models.Location, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	location, ok := index[id]
+	if !ok {
+		return nil, storage.ErrLocationNotFound
+	}
+	return clone(location), nil
+}
+
+// MultiGetLocations возвращает найденные локации по ID; при includeArchived отсутствующие
+// в основном индексе ищутся в архиве и возвращаются с признаком Archived.
+func (l *Locations) MultiGetLocations(ctx context.Context, ids []string, includeArchived bool) (map[string]*models.Location, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	found := make(map[string]*models.Location, len(ids))
+	for _, id := range ids {
+		if location, ok := l.active[id]; ok {
+			found[id] = clone(location)
+		} else if location, ok := l.archived[id]; ok && includeArchived {
+			found[id] = clone(location)
+			found[id].Archived = true
+		}
+	}
+	return found, nil
+}
+
+// LocationsExist сообщает, какие из локаций есть в основном индексе (при includeArchived — также в архиве).
+func (l *Locations) LocationsExist(ctx context.Context, ids []string, includeArchived bool) (map[string]bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	exists := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		_, active := l.active[id]
+		_, archived := l.archived[id]
+		exists[id] = active || (includeArchived && archived)
+	}
+	return exists, nil
+}
+
+// CountLocations возвращает количество локаций основного индекса, подходящих под фильтр.
+func (l *Locations) CountLocations(ctx context.Context, filter *models.LocationFilter) (int, error) {
+	return len(l.matching(filter)), nil
+}
+
+// ScanLocations обходит локации основного индекса, подходящие под фильтр, пачками размера batchSize
+// в порядке ID. Обход прекращается при первой ошибке, возвращенной fn.
+func (l *Locations) ScanLocations(ctx context.Context, filter *models.LocationFilter, batchSize int, fn func([]*models.Location) error) error {
+	locations := l.matching(filter)
+	if batchSize <= 0 {
+		batchSize = max(len(locations), 1)
+	}
+	for start := 0; start < len(locations); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+batchSize, len(locations))
+		if err := fn(locations[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListLocationsPage возвращает до size локаций основного индекса, подходящих под фильтр,
+// с ID больше afterID в порядке ID без embeddings.
+func (l *Locations) ListLocationsPage(ctx context.Context, filter *models.LocationFilter, afterID string, size int) ([]*models.Location, error) {
+	locations := l.matching(filter)
+	start := sort.Search(len(locations), func(i int) bool { return locations[i].ID > afterID })
+	page := locations[start:min(start+max(size, 0), len(locations))]
+	for _, location := range page {
+		location.Embedding = nil
+		location.DemographicEmbedding = nil
+	}
+	return page, nil
+}
+
+// SampleLocations возвращает size локаций, подходящих под фильтр, с наибольшим псевдослучайным
+// ключом, вычисленным из seed и ID локации. Ключ отличается от random_score Elasticsearch,
+// но выборка так же повторяется при тех же seed и данных. Embeddings не возвращаются.
+func (l *Locations) SampleLocations(ctx context.Context, filter *models.LocationFilter, seed int64, size int) ([]*models.Location, error) {
+	locations := l.matching(filter)
+	scores := make(map[string]uint64, len(locations))
+	for _, location := range locations {
+		hash := fnv.New64a()
+		fmt.Fprintf(hash, "%d:%s", seed, location.ID)
+		scores[location.ID] = hash.Sum64()
+		location.Embedding = nil
+		location.DemographicEmbedding = nil
+	}
+	// При совпадении ключей порядок определяется ID
+	sort.SliceStable(locations, func(i, j int) bool {
+		return scores[locations[i].ID] > scores[locations[j].ID]
+	})
+	return locations[:min(max(size, 0), len(locations))], nil
+}
+
+// CountLocationsBy возвращает количество локаций основного индекса, подходящих под фильтр,
+// по значениям поля field (имя поля JSON документа; у полей-массивов учитывается каждое значение).
+func (l *Locations) CountLocationsBy(ctx context.Context, filter *models.LocationFilter, field string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, location := range l.matching(filter) {
+		data, err := json.Marshal(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal location %s: %w", location.ID, err)
+		}
+		var document map[string]interface{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal location %s: %w", location.ID, err)
+		}
+		switch value := document[field].(type) {
+		case string:
+			counts[value]++
+		case []interface{}:
+			for _, item := range value {
+				if key, ok := item.(string); ok {
+					counts[key]++
+				}
+			}
+		}
+	}
+	return counts, nil
+}
+
+// matching возвращает копии локаций основного индекса, подходящих под фильтр, в порядке ID.
+func (l *Locations) matching(filter *models.LocationFilter) []*models.Location {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var locations []*models.Location
+	for _, location := range l.active {
+		if matches(location, filter) {
+			locations = append(locations, clone(location))
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+	return locations
+}
+
+// matches проверяет локацию по фильтру так же, как запрос Elasticsearch.
+func matches(location *models.Location, filter *models.LocationFilter) bool {
+	if filter.Region != "" && location.Region != filter.Region {
+		return false
+	}
+	if filter.City != "" && location.City != filter.City {
+		return false
+	}
+	if filter.BusinessType != "" && !contains(location.BusinessTypesSuitable, filter.BusinessType) {
+		return false
+	}
+	// Документы без embedding_version считаются версией 0
+	if filter.EmbeddingVersionBelow > 0 && location.EmbeddingVersion >= filter.EmbeddingVersionBelow {
+		return false
+	}
+	if filter.UpdatedBefore != nil && !location.UpdatedAt.Before(*filter.UpdatedBefore) {
+		return false
+	}
+	if filter.CreatedSince != nil && location.CreatedAt.Before(*filter.CreatedSince) {
+		return false
+	}
+	if filter.PublishedOnly && !location.Published() {
+		return false
+	}
+	return true
+}
+
+// GetMapping возвращает маппинг, который BuildLocationsMapping строит для параметров kNN индекса хранилища.
+func (l *Locations) GetMapping(ctx context.Context) (map[string]interface{}, error) {
+	mapping, err := storage.BuildLocationsMapping(l.vector)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(mapping), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+	return parsed.Mappings, nil
+}
+
+// GetVectorIndexOptions возвращает параметры kNN индекса, заданные при создании хранилища.
+func (l *Locations) GetVectorIndexOptions(ctx context.Context) (*storage.VectorIndexOptions, error) {
+	vector := l.vector
+	return &vector, nil
+}
+
+// BulkUpdateEmbeddings обновляет embeddings локаций основного индекса; отсутствующие локации
+// считаются неудачными обновлениями.
+func (l *Locations) BulkUpdateEmbeddings(ctx context.Context, embeddings map[string]storage.LocationVectors, version int) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	failed := 0
+	for id, vectors := range embeddings {
+		location, ok := l.active[id]
+		if !ok {
+			failed++
+			continue
+		}
+		location.Embedding = append([]float64(nil), vectors.Text...)
+		location.EmbeddingVersion = version
+		location.DemographicEmbedding = append([]float64(nil), vectors.Demographic...)
+	}
+	l.generation.Add(1)
+	return failed, nil
+}
+
+// SaveLocation создает или заменяет локацию основного индекса.
+func (l *Locations) SaveLocation(ctx context.Context, location *models.Location) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active[location.ID] = clone(location)
+	l.generation.Add(1)
+	return nil
+}
+
+// CreateLocation создает локацию или возвращает storage.ErrLocationExists.
+func (l *Locations) CreateLocation(ctx context.Context, location *models.Location) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.active[location.ID]; ok {
+		return storage.ErrLocationExists
+	}
+	l.active[location.ID] = clone(location)
+	l.generation.Add(1)
+	return nil
+}
+
+// UpdateLocation заменяет локацию, сохраняя время создания, состояние и историю демографии, как PostgresStorage,
+// или возвращает storage.ErrLocationNotFound.
+func (l *Locations) UpdateLocation(ctx context.Context, location *models.Location) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous, ok := l.active[location.ID]
+	if !ok {
+		return storage.ErrLocationNotFound
+	}
+	location.CreatedAt = previous.CreatedAt
+	location.Status = previous.Status
+	if len(location.DemographicsHistory) == 0 {
+		location.DemographicsHistory = append([]models.DemographicsSnapshot(nil), previous.DemographicsHistory...)
+	}
+	location.RecordDemographics(location.UpdatedAt)
+
+	l.active[location.ID] = clone(location)
+	l.generation.Add(1)
+	return nil
+}
+
+// DeleteLocation удаляет локацию основного индекса или возвращает storage.ErrLocationNotFound.
+func (l *Locations) DeleteLocation(ctx context.Context, id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.active[id]; !ok {
+		return storage.ErrLocationNotFound
+	}
+	delete(l.active, id)
+	l.generation.Add(1)
+	return nil
+}
+
+// GetStoredLocations возвращает локации основного индекса по ID.
+func (l *Locations) GetStoredLocations(ctx context.Context, ids []string) (map[string]*models.Location, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	locations := make(map[string]*models.Location, len(ids))
+	for _, id := range ids {
+		if location, ok := l.active[id]; ok {
+			locations[id] = clone(location)
+		}
+	}
+	return locations, nil
+}
+
+// TransitionLocationStatus переводит локацию основного индекса в состояние transition.To
+// и записывает переход в журнал, как PostgresStorage.
+func (l *Locations) TransitionLocationStatus(ctx context.Context, transition *models.LocationStatusTransition) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	location, ok := l.active[transition.LocationID]
+	if !ok {
+		return storage.ErrLocationNotFound
+	}
+	transition.From = location.CurrentStatus()
+	if !models.LocationStatusTransitionAllowed(transition.From, transition.To) {
+		return storage.ErrLocationStatusTransition
+	}
+
+	transition.ID = int64(len(l.transitions) + 1)
+	transition.CreatedAt = time.Now()
+	l.transitions = append(l.transitions, *transition)
+	location.Status = transition.To
+	location.UpdatedAt = transition.CreatedAt
+	l.generation.Add(1)
+	return nil
+}
+
+// ListLocationStatusTransitions возвращает журнал переходов локации в порядке выполнения.
+func (l *Locations) ListLocationStatusTransitions(ctx context.Context, locationID string) ([]models.LocationStatusTransition, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	transitions := []models.LocationStatusTransition{}
+	for _, transition := range l.transitions {
+		if transition.LocationID == locationID {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
+// clone возвращает независимую копию локации: изменения вызывающей стороны не затрагивают хранилище.
+func clone(location *models.Location) *models.Location {
+	data, err := json.Marshal(location)
+	if err != nil {
+		panic(fmt.Sprintf("memory: failed to marshal location %s: %v", location.ID, err))
+	}
+	var copied models.Location
+	if err := json.Unmarshal(data, &copied); err != nil {
+		panic(fmt.Sprintf("memory: failed to unmarshal location %s: %v", location.ID, err))
+	}
+	return &copied
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Проверка соответствия интерфейсам при компиляции
+var (
+	_ storage.LocationIndex          = (*Locations)(nil)
+	_ storage.RecommendationSearcher = (*Locations)(nil)
+	_ storage.LocationStore          = (*Locations)(nil)
+)
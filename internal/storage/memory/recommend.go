@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/geo"
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// RecommendLocations возвращает опубликованные локации, подходящие под регион, город, типы бизнеса,
+// парковку и безопасность запроса. Ранжирование function_score Elasticsearch не воспроизводится:
+// оценка локации равна traffic_score, а с точкой отсчета (origin, без него — центр города запроса)
+// к ней прибавляется 10 × гауссово затухание расстояния до точки, как у фактора расстояния индекса.
+// Порядок — порядок сортировки индекса (оценка, трафик, плотность конкурентов, ID). Следующая
+// страница запрашивается с req.SearchAfter = Next предыдущей.
+func (l *Locations) RecommendLocations(ctx context.Context, req *models.RecommendRequest) (*storage.RecommendResult, error) {
+	origin := req.Origin
+	if origin == nil {
+		origin = req.CityCenter
+	}
+
+	l.mu.RLock()
+	var locations []*models.Location
+	collect := func(index map[string]*models.Location, archived bool) {
+		for _, location := range index {
+			if location.Published() && recommendMatches(location, req) {
+				copied := clone(location)
+				copied.Archived = archived
+				copied.Score = copied.TrafficScore
+				if origin != nil {
+					copied.Score += 10 * distanceDecay(geo.DistanceMeters(location.Coordinates, *origin), req.RadiusMeters)
+				}
+				locations = append(locations, copied)
+			}
+		}
+	}
+	collect(l.active, false)
+	if req.IncludeArchived {
+		collect(l.archived, true)
+	}
+	l.mu.RUnlock()
+
+	sort.Slice(locations, func(i, j int) bool { return less(locations[i], locations[j]) })
+
+	start := 0
+	if len(req.SearchAfter) > 0 {
+		after, err := parseSortValues(req.SearchAfter)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(locations), func(i int) bool { return less(after, locations[i]) })
+	}
+	end := min(start+max(req.Limit, 0), len(locations))
+
+	page := &storage.RecommendResult{Locations: locations[start:end], Total: len(locations)}
+	if end > start && end-start == req.Limit && end < len(locations) {
+		last := locations[end-1]
+		page.Next = []interface{}{last.Score, last.TrafficScore, last.CompetitionDensity, last.ID}
+	}
+	return page, nil
+}
+
+// GetRegionCities возвращает города локаций основного индекса по регионам в порядке имен.
+func (l *Locations) GetRegionCities(ctx context.Context) (map[string][]string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	cities := make(map[string][]string)
+	for _, location := range l.active {
+		if location.Region != "" && location.City != "" && !contains(cities[location.Region], location.City) {
+			cities[location.Region] = append(cities[location.Region], location.City)
+		}
+	}
+	for _, names := range cities {
+		sort.Strings(names)
+	}
+	return cities, nil
+}
+
+// distanceDecay — гауссово затухание расстояния distance: 0.5 на половине радиуса поиска radius,
+// без радиуса — на 2 км.
+func distanceDecay(distance, radius float64) float64 {
+	scale := 2000.0
+	if radius > 0 {
+		scale = radius / 2
+	}
+	return math.Exp(-math.Ln2 * (distance / scale) * (distance / scale))
+}
+
+// recommendMatches проверяет локацию по фильтрам запроса рекомендаций, поддержанным хранилищем.
+func recommendMatches(location *models.Location, req *models.RecommendRequest) bool {
+	if req.Region != "" && location.Region != req.Region {
+		return false
+	}
+	if req.City != "" && location.City != req.City {
+		return false
+	}
+	if len(req.BusinessTypes) > 0 {
+		suitable := false
+		for _, businessType := range req.BusinessTypes {
+			suitable = suitable || contains(location.BusinessTypesSuitable, businessType)
+		}
+		if !suitable {
+			return false
+		}
+	} else if req.BusinessType != "" && !contains(location.BusinessTypesSuitable, req.BusinessType) {
+		return false
+	}
+	if req.HasParking && location.PaidLotsNearby < 1 && location.StreetParkingScore < storage.HasParkingMinStreetScore {
+		return false
+	}
+	if req.MinParkingScore > 0 && location.StreetParkingScore < req.MinParkingScore {
+		return false
+	}
+	if req.MinSafety > 0 && location.SafetyScore < req.MinSafety {
+		return false
+	}
+	return true
+}
+
+// less сравнивает локации в порядке сортировки выдачи рекомендаций.
+func less(a, b *models.Location) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.TrafficScore != b.TrafficScore {
+		return a.TrafficScore > b.TrafficScore
+	}
+	if a.CompetitionDensity != b.CompetitionDensity {
+		return a.CompetitionDensity < b.CompetitionDensity
+	}
+	return a.ID < b.ID
+}
+
+// parseSortValues разбирает значения search_after, возвращенные в Next, в ключ сортировки.
+// Числа принимаются в любом виде, который дает декодирование JSON.
+func parseSortValues(values []interface{}) (*models.Location, error) {
+	if len(values) != 4 {
+		return nil, fmt.Errorf("invalid search_after: expected 4 values, got %d", len(values))
+	}
+	var numbers [3]float64
+	for i := range numbers {
+		number, err := strconv.ParseFloat(fmt.Sprint(values[i]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search_after value %v: %w", values[i], err)
+		}
+		numbers[i] = number
+	}
+	id, ok := values[3].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid search_after id %v", values[3])
+	}
+	return &models.Location{Score: numbers[0], TrafficScore: numbers[1], CompetitionDensity: numbers[2], ID: id}, nil
+}
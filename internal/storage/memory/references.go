@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/storage"
+)
+
+// References хранит справочники типов бизнеса, регионов и городов в памяти. Реализует storage.ReferenceStore,
+// storage.CityStore и storage.RankingStore без настроек ранжирования: замещающих типов, весов, бустов
+// и переопределений нет, сезонный коэффициент равен 1. Типы бизнеса и регионы задаются при создании
+// и не изменяются, города изменяются через CityStore; запросы рекомендаций накапливаются в журнале (Queries).
+type References struct {
+	businessTypes []models.BusinessType
+	regions       []models.Region
+
+	mu      sync.Mutex
+	cities  []models.City
+	queries []models.QueryHistoryEntry
+}
+
+// NewReferences создает справочники из списков типов бизнеса, регионов и городов.
+func NewReferences(businessTypes []models.BusinessType, regions []models.Region, cities []models.City) *References {
+	return &References{
+		businessTypes: append([]models.BusinessType(nil), businessTypes...),
+		regions:       append([]models.Region(nil), regions...),
+		cities:        append([]models.City(nil), cities...),
+	}
+}
+
+// GetBusinessTypes возвращает копии типов бизнеса в порядке создания справочника.
+func (r *References) GetBusinessTypes(ctx context.Context) ([]*models.BusinessType, error) {
+	result := make([]*models.BusinessType, len(r.businessTypes))
+	for i := range r.businessTypes {
+		bt := r.businessTypes[i]
+		result[i] = &bt
+	}
+	return result, nil
+}
+
+// GetRegions возвращает копии регионов в порядке создания справочника.
+func (r *References) GetRegions(ctx context.Context) ([]*models.Region, error) {
+	result := make([]*models.Region, len(r.regions))
+	for i := range r.regions {
+		region := r.regions[i]
+		result[i] = &region
+	}
+	return result, nil
+}
+
+// GetCities возвращает копии городов в порядке создания справочника.
+func (r *References) GetCities(ctx context.Context) ([]*models.City, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*models.City, len(r.cities))
+	for i := range r.cities {
+		city := r.cities[i]
+		result[i] = &city
+	}
+	return result, nil
+}
+
+// GetCity возвращает копию города по ID или storage.ErrCityNotFound.
+func (r *References) GetCity(ctx context.Context, id int) (*models.City, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, city := range r.cities {
+		if city.ID == id {
+			return &city, nil
+		}
+	}
+	return nil, storage.ErrCityNotFound
+}
+
+// CreateCity добавляет город со следующим ID и записывает в city ID, название региона и время создания.
+func (r *References) CreateCity(ctx context.Context, city *models.City) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkCity(city); err != nil {
+		return err
+	}
+	city.ID = 1
+	for _, existing := range r.cities {
+		city.ID = max(city.ID, existing.ID+1)
+	}
+	city.CreatedAt = time.Now()
+	city.UpdatedAt = city.CreatedAt
+	r.cities = append(r.cities, *city)
+	return nil
+}
+
+// UpdateCity заменяет город city.ID, сохраняя время создания.
+func (r *References) UpdateCity(ctx context.Context, city *models.City) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.cities {
+		if r.cities[i].ID != city.ID {
+			continue
+		}
+		if err := r.checkCity(city); err != nil {
+			return err
+		}
+		city.CreatedAt = r.cities[i].CreatedAt
+		city.UpdatedAt = time.Now()
+		r.cities[i] = *city
+		return nil
+	}
+	return storage.ErrCityNotFound
+}
+
+// DeleteCity удаляет город или возвращает storage.ErrCityNotFound.
+func (r *References) DeleteCity(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.cities {
+		if r.cities[i].ID == id {
+			r.cities = append(r.cities[:i], r.cities[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrCityNotFound
+}
+
+// checkCity заполняет название региона города и проверяет, что регион есть в справочнике
+// и в нем нет другого города с таким именем, как ограничения таблицы cities.
+func (r *References) checkCity(city *models.City) error {
+	city.Region = ""
+	for _, region := range r.regions {
+		if region.ID == city.RegionID {
+			city.Region = region.Name
+		}
+	}
+	if city.Region == "" {
+		return storage.ErrCityRegionNotFound
+	}
+	for _, existing := range r.cities {
+		if existing.ID != city.ID && existing.RegionID == city.RegionID && existing.Name == city.Name {
+			return storage.ErrCityExists
+		}
+	}
+	return nil
+}
+
+// ExpandBusinessType возвращает тип бизнеса с его подтипами и категориями по parent_id справочника,
+// как таксономия PostgresStorage. Неизвестный тип возвращается как есть.
+func (r *References) ExpandBusinessType(ctx context.Context, name string) ([]string, error) {
+	byID := make(map[int]models.BusinessType, len(r.businessTypes))
+	var root *models.BusinessType
+	for i, bt := range r.businessTypes {
+		byID[bt.ID] = bt
+		if bt.Name == name {
+			root = &r.businessTypes[i]
+		}
+	}
+	if root == nil {
+		return []string{name}, nil
+	}
+
+	// isAncestor сообщает, является ли тип id предком типа bt
+	isAncestor := func(id int, bt models.BusinessType) bool {
+		for seen := 0; bt.ParentID != nil && seen < len(byID); seen++ {
+			if *bt.ParentID == id {
+				return true
+			}
+			bt = byID[*bt.ParentID]
+		}
+		return false
+	}
+	var names []string
+	for _, bt := range r.businessTypes {
+		if bt.ID == root.ID || isAncestor(root.ID, bt) || isAncestor(bt.ID, *root) {
+			names = append(names, bt.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ListBusinessTypeSubstitutes возвращает пустой список: замещающие типы не заданы.
+func (r *References) ListBusinessTypeSubstitutes(ctx context.Context, businessType string) ([]models.BusinessTypeSubstitute, error) {
+	return nil, nil
+}
+
+// GetVectorWeights возвращает nil: веса векторного поиска не заданы.
+func (r *References) GetVectorWeights(ctx context.Context, businessType string) (*models.VectorWeights, error) {
+	return nil, nil
+}
+
+// GetSeasonalCoefficients возвращает коэффициент 1 для всех городов.
+func (r *References) GetSeasonalCoefficients(ctx context.Context, businessType string, month int) (*models.SeasonalCoefficients, error) {
+	return &models.SeasonalCoefficients{Default: 1, ByCity: map[string]float64{}}, nil
+}
+
+// GetScoringBoosts возвращает пустой профиль ранжирования.
+func (r *References) GetScoringBoosts(ctx context.Context, businessType string) ([]models.ScoringBoost, error) {
+	return nil, nil
+}
+
+// GetRankingOverrides возвращает пустой список: ранжирование по умолчанию не переопределено.
+func (r *References) GetRankingOverrides(ctx context.Context, organization, subject string) ([]models.RankingOverride, error) {
+	return nil, nil
+}
+
+// RecordQuery добавляет запрос рекомендаций в журнал.
+func (r *References) RecordQuery(ctx context.Context, entry *models.QueryHistoryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = append(r.queries, *entry)
+	return nil
+}
+
+// Queries возвращает журнал запросов рекомендаций в порядке записи.
+func (r *References) Queries() []models.QueryHistoryEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]models.QueryHistoryEntry(nil), r.queries...)
+}
+
+// Проверка соответствия интерфейсам при компиляции
+var (
+	_ storage.ReferenceStore = (*References)(nil)
+	_ storage.CityStore      = (*References)(nil)
+	_ storage.RankingStore   = (*References)(nil)
+)
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// MultiGetLocations возвращает найденные локации по ID одним запросом _mget к основному индексу.
+// При includeArchived = true локации, не найденные в основном индексе, запрашиваются из архива.
+// Отсутствующие ID в результат не попадают.
+func (es *ElasticsearchStorage) MultiGetLocations(ctx context.Context, ids []string, includeArchived bool) (map[string]*models.Location, error) {
+	locations, err := es.mget(ctx, es.index, ids)
+	if err != nil {
+		return nil, err
+	}
+	if !includeArchived {
+		return locations, nil
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := locations[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	archived, err := es.mget(ctx, es.archive, missing)
+	if err != nil {
+		return nil, err
+	}
+	for id, loc := range archived {
+		loc.Archived = true
+		locations[id] = loc
+	}
+
+	return locations, nil
+}
+
+// mget получает документы индекса index по ID запросом _mget.
+func (es *ElasticsearchStorage) mget(ctx context.Context, index string, ids []string) (map[string]*models.Location, error) {
+	docs, err := es.mgetDocs(ctx, index, ids, true)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[string]*models.Location, len(docs))
+	for _, doc := range docs {
+		var loc models.Location
+		if err := json.Unmarshal(doc.Source, &loc); err != nil {
+			return nil, fmt.Errorf("failed to decode location %s: %w", doc.ID, err)
+		}
+		locations[doc.ID] = &loc
+	}
+
+	return locations, nil
+}
+
+// LocationsExist сообщает, какие из локаций ids есть в основном индексе (при includeArchived —
+// также в архиве). Тела документов не передаются.
+func (es *ElasticsearchStorage) LocationsExist(ctx context.Context, ids []string, includeArchived bool) (map[string]bool, error) {
+	exists := make(map[string]bool, len(ids))
+	indexes := []string{es.index}
+	if includeArchived {
+		indexes = append(indexes, es.archive)
+	}
+
+	remaining := ids
+	for _, index := range indexes {
+		docs, err := es.mgetDocs(ctx, index, remaining, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			exists[doc.ID] = true
+		}
+
+		var missing []string
+		for _, id := range remaining {
+			if !exists[id] {
+				missing = append(missing, id)
+			}
+		}
+		remaining = missing
+	}
+
+	return exists, nil
+}
+
+// mgetDoc — найденный документ ответа _mget.
+type mgetDoc struct {
+	ID     string          `json:"_id"`
+	Found  bool            `json:"found"`
+	Source json.RawMessage `json:"_source"`
+}
+
+// mgetDocs выполняет _mget к индексу index и возвращает найденные документы.
+// При source = false тела документов не запрашиваются.
+func (es *ElasticsearchStorage) mgetDocs(ctx context.Context, index string, ids []string, source bool) ([]mgetDoc, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"ids": ids}); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_mget?_source=%t", es.baseURL, index, source)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		// Индекса нет — документов тоже
+		return nil, nil
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error getting locations", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Docs []mgetDoc `json:"docs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	found := make([]mgetDoc, 0, len(result.Docs))
+	for _, doc := range result.Docs {
+		if doc.Found {
+			found = append(found, doc)
+		}
+	}
+
+	return found, nil
+}
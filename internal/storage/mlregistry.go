@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+var (
+	// ErrModelVersionNotFound возвращается, если версия модели отсутствует в реестре.
+	ErrModelVersionNotFound = errors.New("model version not found")
+	// ErrModelVersionExists возвращается при повторной регистрации версии модели.
+	ErrModelVersionExists = errors.New("model version already exists")
+)
+
+const modelVersionColumns = `kind, version, format, uri, metadata, active, created_at, activated_at`
+
+// CreateModelVersion регистрирует версию модели. Возвращает ErrModelVersionExists, если она уже есть.
+func (ps *PostgresStorage) CreateModelVersion(ctx context.Context, mv *models.ModelVersion) error {
+	metadata, err := json.Marshal(mv.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model metadata: %w", err)
+	}
+	if mv.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	query := `INSERT INTO model_versions (kind, version, format, uri, metadata)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, version) DO NOTHING
+		RETURNING created_at`
+
+	err = ps.db.QueryRowContext(ctx, query, mv.Kind, mv.Version, mv.Format, mv.URI, metadata).Scan(&mv.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrModelVersionExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert model version: %w", err)
+	}
+
+	return nil
+}
+
+// GetModelVersion возвращает версию модели или ErrModelVersionNotFound.
+func (ps *PostgresStorage) GetModelVersion(ctx context.Context, kind, version string) (*models.ModelVersion, error) {
+	query := `SELECT ` + modelVersionColumns + ` FROM model_versions WHERE kind = $1 AND version = $2`
+
+	mv, err := scanModelVersion(ps.db.QueryRowContext(ctx, query, kind, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrModelVersionNotFound
+	}
+	return mv, err
+}
+
+// ListModelVersions возвращает версии моделей вида kind (все при пустом kind), начиная с последних.
+func (ps *PostgresStorage) ListModelVersions(ctx context.Context, kind string) ([]models.ModelVersion, error) {
+	query := `SELECT ` + modelVersionColumns + ` FROM model_versions
+		WHERE $1 = '' OR kind = $1 ORDER BY kind, created_at DESC`
+
+	return ps.queryModelVersions(ctx, query, kind)
+}
+
+// ActiveModelVersions возвращает активные версии моделей всех видов.
+func (ps *PostgresStorage) ActiveModelVersions(ctx context.Context) ([]models.ModelVersion, error) {
+	query := `SELECT ` + modelVersionColumns + ` FROM model_versions WHERE active ORDER BY kind`
+
+	return ps.queryModelVersions(ctx, query)
+}
+
+// ActivateModelVersion делает версию модели активной для ее вида, снимая признак с предыдущей.
+func (ps *PostgresStorage) ActivateModelVersion(ctx context.Context, kind, version string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE model_versions SET active = FALSE WHERE kind = $1 AND active`, kind); err != nil {
+		return fmt.Errorf("failed to deactivate model version: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE model_versions SET active = TRUE, activated_at = CURRENT_TIMESTAMP WHERE kind = $1 AND version = $2`,
+		kind, version)
+	if err != nil {
+		return fmt.Errorf("failed to activate model version: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrModelVersionNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RecordPrediction сохраняет прогноз модели с ее версией.
+func (ps *PostgresStorage) RecordPrediction(ctx context.Context, prediction *models.ModelPrediction) error {
+	query := `INSERT INTO model_predictions (kind, version, location_id, business_type, value)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := ps.db.ExecContext(ctx, query,
+		prediction.Kind,
+		prediction.Version,
+		sql.NullString{String: prediction.LocationID, Valid: prediction.LocationID != ""},
+		prediction.BusinessType,
+		prediction.Value,
+	); err != nil {
+		return fmt.Errorf("failed to insert model prediction: %w", err)
+	}
+
+	return nil
+}
+
+func (ps *PostgresStorage) queryModelVersions(ctx context.Context, query string, args ...interface{}) ([]models.ModelVersion, error) {
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query model versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []models.ModelVersion{}
+	for rows.Next() {
+		mv, err := scanModelVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *mv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return versions, nil
+}
+
+func scanModelVersion(row rowScanner) (*models.ModelVersion, error) {
+	var (
+		mv          models.ModelVersion
+		metadata    []byte
+		activatedAt sql.NullTime
+	)
+	if err := row.Scan(&mv.Kind, &mv.Version, &mv.Format, &mv.URI, &metadata, &mv.Active, &mv.CreatedAt, &activatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan model version: %w", err)
+	}
+	if err := json.Unmarshal(metadata, &mv.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal model metadata: %w", err)
+	}
+	if activatedAt.Valid {
+		mv.ActivatedAt = &activatedAt.Time
+	}
+	return &mv, nil
+}
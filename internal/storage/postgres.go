@@ -1,14 +1,29 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/akozadaev/go_es_analytical_system/internal/models"
-	_ "github.com/lib/pq"
+	"github.com/akozadaev/go_es_analytical_system/internal/sqlhook"
+	"github.com/lib/pq"
 )
 
+// ErrQueryNotFound возвращается, если запрос отсутствует в истории.
+var ErrQueryNotFound = errors.New("query not found")
+
+// ErrLocationExists возвращается при создании локации с уже занятым ID.
+var ErrLocationExists = errors.New("location already exists")
+
+// ErrSnapshotNotFound возвращается, если снимок выдачи с указанным токеном отсутствует.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
 // PostgresStorage предоставляет методы для работы со справочниками в PostgreSQL.
 type PostgresStorage struct {
 	db *sql.DB // Подключение к базе данных PostgreSQL
@@ -17,10 +32,23 @@ type PostgresStorage struct {
 // NewPostgresStorage создает новый экземпляр PostgresStorage и устанавливает подключение к БД.
 // DSN должен быть в формате: "host=... port=... user=... password=... dbname=... sslmode=..."
 func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
-	db, err := sql.Open("postgres", dsn)
+	return NewPostgresStorageWithHooks(dsn, nil)
+}
+
+// SQLOpener открывает пул подключений к базе данных через коннектор драйвера.
+type SQLOpener func(connector driver.Connector) *sql.DB
+
+// NewPostgresStorageWithHooks создает PostgresStorage, вызывающий hooks для каждого запроса (метрики).
+// Пул подключений открывает open (например, с трассировкой запросов); nil — sql.OpenDB.
+func NewPostgresStorageWithHooks(dsn string, open SQLOpener, hooks ...sqlhook.Hook) (*PostgresStorage, error) {
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	if open == nil {
+		open = sql.OpenDB
+	}
+	db := open(sqlhook.Connector(connector, hooks...))
 
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
@@ -37,7 +65,7 @@ func (ps *PostgresStorage) Close() error {
 // GetBusinessTypes возвращает список всех типов бизнеса из справочника.
 // Результаты отсортированы по имени.
 func (ps *PostgresStorage) GetBusinessTypes(ctx context.Context) ([]*models.BusinessType, error) {
-	query := `SELECT id, name, description, created_at, updated_at FROM business_types ORDER BY name`
+	query := `SELECT id, name, description, benefits_from_events, parent_id, created_at, updated_at FROM business_types ORDER BY name`
 
 	rows, err := ps.db.QueryContext(ctx, query)
 	if err != nil {
@@ -48,15 +76,22 @@ func (ps *PostgresStorage) GetBusinessTypes(ctx context.Context) ([]*models.Busi
 	var businessTypes []*models.BusinessType
 	for rows.Next() {
 		var bt models.BusinessType
+		var parentID sql.NullInt64
 		if err := rows.Scan(
 			&bt.ID,
 			&bt.Name,
 			&bt.Description,
+			&bt.BenefitsFromEvents,
+			&parentID,
 			&bt.CreatedAt,
 			&bt.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan business type: %w", err)
 		}
+		if parentID.Valid {
+			parentIDInt := int(parentID.Int64)
+			bt.ParentID = &parentIDInt
+		}
 		businessTypes = append(businessTypes, &bt)
 	}
 
@@ -67,6 +102,42 @@ func (ps *PostgresStorage) GetBusinessTypes(ctx context.Context) ([]*models.Busi
 	return businessTypes, nil
 }
 
+// ExpandBusinessType возвращает тип бизнеса name вместе со всеми его подтипами и категориями,
+// в которые он входит, по иерархии business_types. Локация, пригодная для категории, подходит
+// для каждого ее подтипа, а запрос по категории должен находить локации всех подтипов.
+// Для типа, отсутствующего в справочнике, возвращается только name.
+func (ps *PostgresStorage) ExpandBusinessType(ctx context.Context, name string) ([]string, error) {
+	query := `
+		SELECT descendant FROM business_type_closure WHERE ancestor = $1
+		UNION
+		SELECT ancestor FROM business_type_closure WHERE descendant = $1
+		ORDER BY 1`
+
+	rows, err := ps.db.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query business type hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to scan business type: %w", err)
+		}
+		names = append(names, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	if len(names) == 0 {
+		return []string{name}, nil
+	}
+	return names, nil
+}
+
 // GetRegions возвращает список всех регионов из справочника.
 // Результаты отсортированы по имени. Поддерживает иерархическую структуру через ParentRegionID.
 func (ps *PostgresStorage) GetRegions(ctx context.Context) ([]*models.Region, error) {
@@ -104,3 +175,940 @@ func (ps *PostgresStorage) GetRegions(ctx context.Context) ([]*models.Region, er
 
 	return regions, nil
 }
+
+// RecordQuery сохраняет запрос рекомендаций и его результат в историю запросов,
+// а организацию клиента — в query_history_owners.
+func (ps *PostgresStorage) RecordQuery(ctx context.Context, entry *models.QueryHistoryEntry) error {
+	request, err := json.Marshal(entry.Request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resultIDs, err := json.Marshal(entry.ResultIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result ids: %w", err)
+	}
+
+	scores := entry.Scores
+	if scores == nil {
+		scores = []float64{}
+	}
+	resultScores, err := json.Marshal(scores)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result scores: %w", err)
+	}
+
+	versions := entry.ModelVersions
+	if versions == nil {
+		versions = map[string]string{}
+	}
+	modelVersions, err := json.Marshal(versions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model versions: %w", err)
+	}
+
+	query := `WITH entry AS (
+			INSERT INTO query_history (id, request, result_ids, result_scores, result_count, duration_ms, model_versions, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		)
+		INSERT INTO query_history_owners (query_id, organization)
+		SELECT id, $9 FROM entry WHERE $9 <> ''`
+
+	if _, err := ps.db.ExecContext(ctx, query,
+		entry.ID,
+		request,
+		resultIDs,
+		resultScores,
+		entry.ResultCount,
+		entry.DurationMs,
+		modelVersions,
+		entry.CreatedAt,
+		entry.Organization,
+	); err != nil {
+		return fmt.Errorf("failed to insert query history: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuery возвращает запись истории запросов по ID с организацией клиента или ErrQueryNotFound.
+func (ps *PostgresStorage) GetQuery(ctx context.Context, id string) (*models.QueryHistoryEntry, error) {
+	query := `SELECT h.id, h.request, h.result_ids, h.result_scores, h.result_count, h.duration_ms, h.created_at,
+			COALESCE(o.organization, '')
+		FROM query_history h
+		LEFT JOIN query_history_owners o ON o.query_id = h.id
+		WHERE h.id = $1`
+
+	var (
+		entry                            models.QueryHistoryEntry
+		request, resultIDs, resultScores []byte
+	)
+	err := ps.db.QueryRowContext(ctx, query, id).Scan(
+		&entry.ID,
+		&request,
+		&resultIDs,
+		&resultScores,
+		&entry.ResultCount,
+		&entry.DurationMs,
+		&entry.CreatedAt,
+		&entry.Organization,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQueryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query: %w", err)
+	}
+
+	if err := json.Unmarshal(request, &entry.Request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+	if err := json.Unmarshal(resultIDs, &entry.ResultIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result ids: %w", err)
+	}
+	if err := json.Unmarshal(resultScores, &entry.Scores); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result scores: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// ListQueries возвращает до limit записей истории запросов после позиции (afterTime, afterID)
+// в порядке (created_at, id). Используется для постраничной выгрузки истории.
+func (ps *PostgresStorage) ListQueries(ctx context.Context, afterTime time.Time, afterID string, limit int) ([]models.QueryHistoryEntry, error) {
+	query := `SELECT id, request, result_ids, result_scores, result_count, duration_ms, created_at
+		FROM query_history
+		WHERE (created_at, id) > ($1, $2)
+		ORDER BY created_at, id
+		LIMIT $3`
+
+	rows, err := ps.db.QueryContext(ctx, query, afterTime, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.QueryHistoryEntry
+	for rows.Next() {
+		var (
+			entry                            models.QueryHistoryEntry
+			request, resultIDs, resultScores []byte
+		)
+		if err := rows.Scan(&entry.ID, &request, &resultIDs, &resultScores, &entry.ResultCount, &entry.DurationMs, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan query history: %w", err)
+		}
+		if err := json.Unmarshal(request, &entry.Request); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+		}
+		if err := json.Unmarshal(resultIDs, &entry.ResultIDs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result ids: %w", err)
+		}
+		if err := json.Unmarshal(resultScores, &entry.Scores); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result scores: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// SaveLocation сохраняет локацию и в той же транзакции добавляет запись в outbox,
+// гарантируя, что изменение будет доставлено в Elasticsearch.
+func (ps *PostgresStorage) SaveLocation(ctx context.Context, location *models.Location) error {
+	data, err := json.Marshal(location)
+	if err != nil {
+		return fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO locations (id, data, created_at, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`
+	if _, err := tx.ExecContext(ctx, query, location.ID, data, location.CreatedAt, location.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to upsert location: %w", err)
+	}
+
+	return commitLocationWrite(ctx, tx, location.ID, data)
+}
+
+// CreateLocation сохраняет новую локацию и добавляет запись в outbox.
+// Возвращает ErrLocationExists, если локация с таким ID уже есть.
+func (ps *PostgresStorage) CreateLocation(ctx context.Context, location *models.Location) error {
+	data, err := json.Marshal(location)
+	if err != nil {
+		return fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO locations (id, data, created_at, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, location.ID, data, location.CreatedAt, location.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert location: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrLocationExists
+	}
+
+	return commitLocationWrite(ctx, tx, location.ID, data)
+}
+
+// UpdateLocation заменяет существующую локацию и добавляет запись в outbox.
+// Время создания, состояние жизненного цикла и поля, рассчитываемые конвейером обновления,
+// сохраняются прежними и записываются в location (состояние меняется только переходами,
+// см. TransitionLocationStatus), история демографии дополняется (см. models.Location.RecordDemographics).
+// Возвращает ErrLocationNotFound, если локация отсутствует.
+func (ps *PostgresStorage) UpdateLocation(ctx context.Context, location *models.Location) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var createdAt time.Time
+	var previousData []byte
+	err = tx.QueryRowContext(ctx, `SELECT created_at, data FROM locations WHERE id = $1 FOR UPDATE`, location.ID).Scan(&createdAt, &previousData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLocationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+	if err := preserveLocationState(location, createdAt, previousData); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(location)
+	if err != nil {
+		return fmt.Errorf("failed to marshal location: %w", err)
+	}
+
+	query := `UPDATE locations SET data = $2, updated_at = $3 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, location.ID, data, location.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+
+	return commitLocationWrite(ctx, tx, location.ID, data)
+}
+
+// preserveLocationState переносит в заменяющую версию локации location то, что не меняется
+// при замене данных: время создания createdAt, состояние жизненного цикла и производные поля
+// конвейера обновления (конкуренция, близость к мероприятиям и учебным заведениям, безопасность)
+// сохраненной версии previousData, а также ее историю демографии, если история не передана явно.
+// Изменившиеся демографические данные добавляются в историю с датой обновления.
+func preserveLocationState(location *models.Location, createdAt time.Time, previousData []byte) error {
+	var previous models.Location
+	if err := json.Unmarshal(previousData, &previous); err != nil {
+		return fmt.Errorf("failed to unmarshal location: %w", err)
+	}
+	location.CreatedAt = createdAt
+	location.Status = previous.Status
+	location.CompetitionDensity = previous.CompetitionDensity
+	location.EventExposure = previous.EventExposure
+	location.SchoolsNearby = previous.SchoolsNearby
+	location.UniversitiesNearby = previous.UniversitiesNearby
+	location.SafetyScore = previous.SafetyScore
+	if len(location.DemographicsHistory) == 0 {
+		location.DemographicsHistory = previous.DemographicsHistory
+	}
+	location.RecordDemographics(location.UpdatedAt)
+	return nil
+}
+
+// commitLocationWrite снимает отметку об удалении локации, добавляет запись upsert в outbox
+// и фиксирует транзакцию записи локации.
+func commitLocationWrite(ctx context.Context, tx *sql.Tx, id string, data []byte) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM location_tombstones WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete location tombstone: %w", err)
+	}
+
+	if err := insertOutbox(ctx, tx, id, models.OutboxUpsert, data); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLocation удаляет локацию и в той же транзакции добавляет запись об удалении в outbox
+// и отметку об удалении для ленты изменений.
+// Возвращает ErrLocationNotFound, если локация отсутствует.
+func (ps *PostgresStorage) DeleteLocation(ctx context.Context, id string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM locations WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete location: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrLocationNotFound
+	}
+
+	tombstone := `INSERT INTO location_tombstones (id, deleted_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at`
+	if _, err := tx.ExecContext(ctx, tombstone, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to insert location tombstone: %w", err)
+	}
+
+	if err := insertOutbox(ctx, tx, id, models.OutboxDelete, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertOutbox(ctx context.Context, tx *sql.Tx, locationID string, operation models.OutboxOperation, payload []byte) error {
+	query := `INSERT INTO location_outbox (location_id, operation, payload) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, locationID, string(operation), payload); err != nil {
+		return fmt.Errorf("failed to insert outbox entry: %w", err)
+	}
+	return nil
+}
+
+// ProcessOutbox выбирает до limit необработанных записей outbox (в порядке создания) и передает их в fn.
+// Записи блокируются на время обработки (FOR UPDATE SKIP LOCKED), поэтому несколько экземпляров
+// сервиса могут обрабатывать outbox параллельно. Успешно обработанные записи помечаются processed_at,
+// для неудачных увеличивается счетчик попыток и сохраняется ошибка — они будут повторены позже.
+// В той же транзакции обновляется состояние документов асинхронной индексации этих записей.
+// Записи одной локации применяются в порядке создания: если для локации запись не удалась,
+// последующие записи той же локации в пачке пропускаются, а записи локаций, у которых есть более
+// ранняя необработанная запись вне пачки (ее обрабатывает другой экземпляр), ждут следующей пачки.
+func (ps *PostgresStorage) ProcessOutbox(ctx context.Context, limit int, fn func(*models.OutboxEntry) error) (processed, failed int, err error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, location_id, operation, payload, attempts, created_at FROM location_outbox
+		WHERE processed_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query outbox: %w", err)
+	}
+
+	var entries []*models.OutboxEntry
+	for rows.Next() {
+		var entry models.OutboxEntry
+		var operation string
+		var payload []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.LocationID,
+			&operation,
+			&payload,
+			&entry.Attempts,
+			&entry.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		entry.Operation = models.OutboxOperation(operation)
+		if len(payload) > 0 {
+			entry.Payload = &models.Location{}
+			if err := json.Unmarshal(payload, entry.Payload); err != nil {
+				rows.Close()
+				return 0, 0, fmt.Errorf("failed to decode outbox payload: %w", err)
+			}
+		}
+		entries = append(entries, &entry)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	blocked, err := lockedEarlierEntries(ctx, tx, entries)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, entry := range entries {
+		if earliest, ok := blocked[entry.LocationID]; ok && earliest < entry.ID {
+			continue
+		}
+
+		if fnErr := fn(entry); fnErr != nil {
+			blocked[entry.LocationID] = entry.ID
+			failed++
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE location_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`,
+				entry.ID, fnErr.Error(),
+			); err != nil {
+				return 0, 0, fmt.Errorf("failed to update outbox entry: %w", err)
+			}
+			if err := updateAsyncIndexItems(ctx, tx, entry.ID, fnErr); err != nil {
+				return 0, 0, err
+			}
+			continue
+		}
+
+		processed++
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE location_outbox SET processed_at = CURRENT_TIMESTAMP, attempts = attempts + 1, last_error = NULL WHERE id = $1`,
+			entry.ID,
+		); err != nil {
+			return 0, 0, fmt.Errorf("failed to update outbox entry: %w", err)
+		}
+		if err := updateAsyncIndexItems(ctx, tx, entry.ID, nil); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return processed, failed, nil
+}
+
+// lockedEarlierEntries возвращает для локаций записей entries ID самой ранней необработанной записи,
+// не вошедшей в entries. Пачка выбирается в порядке ID, поэтому такая запись заблокирована другой
+// транзакцией, и более поздние записи локации нельзя применять раньше нее: иначе ее доставка
+// перезапишет в индексе более новый документ.
+func lockedEarlierEntries(ctx context.Context, tx *sql.Tx, entries []*models.OutboxEntry) (map[string]int64, error) {
+	blocked := make(map[string]int64)
+	if len(entries) == 0 {
+		return blocked, nil
+	}
+	ids := make([]int64, len(entries))
+	locationIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+		locationIDs[i] = entry.LocationID
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT location_id, MIN(id) FROM location_outbox
+		WHERE processed_at IS NULL AND location_id = ANY($1) AND id <> ALL($2)
+		GROUP BY location_id`, pq.Array(locationIDs), pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var locationID string
+		var id int64
+		if err := rows.Scan(&locationID, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan pending outbox entry: %w", err)
+		}
+		blocked[locationID] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return blocked, nil
+}
+
+// GetStoredLocations возвращает локации из PostgreSQL по ID, в том числе еще не доставленные
+// в Elasticsearch. Отсутствующие ID в результат не попадают.
+func (ps *PostgresStorage) GetStoredLocations(ctx context.Context, ids []string) (map[string]*models.Location, error) {
+	locations := make(map[string]*models.Location, len(ids))
+	if len(ids) == 0 {
+		return locations, nil
+	}
+	rows, err := ps.db.QueryContext(ctx, `SELECT id, data FROM locations WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   string
+			data []byte
+		)
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		var location models.Location
+		if err := json.Unmarshal(data, &location); err != nil {
+			return nil, fmt.Errorf("failed to decode location %s: %w", id, err)
+		}
+		locations[id] = &location
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return locations, nil
+}
+
+// ListLocationIDs возвращает ID всех локаций из PostgreSQL.
+func (ps *PostgresStorage) ListLocationIDs(ctx context.Context) ([]string, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT id FROM locations ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query location ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan location id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// PendingOutboxLocationIDs возвращает ID локаций, для которых в outbox есть необработанные изменения.
+func (ps *PostgresStorage) PendingOutboxLocationIDs(ctx context.Context) (map[string]bool, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT DISTINCT location_id FROM location_outbox WHERE processed_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending outbox: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan location id: %w", err)
+		}
+		ids[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// RecordOrphans сохраняет текущий список расхождений для стороны side ("es" — документ есть только
+// в Elasticsearch, "pg" — только в PostgreSQL) и возвращает время первого обнаружения каждого из них.
+// Расхождения, которых больше нет в списке, удаляются.
+func (ps *PostgresStorage) RecordOrphans(ctx context.Context, side string, ids []string) (map[string]time.Time, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM location_orphans WHERE side = $1 AND NOT (location_id = ANY($2))`,
+		side, pq.Array(ids),
+	); err != nil {
+		return nil, fmt.Errorf("failed to delete resolved orphans: %w", err)
+	}
+
+	firstSeen := make(map[string]time.Time, len(ids))
+	query := `INSERT INTO location_orphans (location_id, side) VALUES ($1, $2)
+		ON CONFLICT (location_id, side) DO UPDATE SET last_seen_at = CURRENT_TIMESTAMP
+		RETURNING first_seen_at`
+	for _, id := range ids {
+		var seen time.Time
+		if err := tx.QueryRowContext(ctx, query, id, side).Scan(&seen); err != nil {
+			return nil, fmt.Errorf("failed to record orphan: %w", err)
+		}
+		firstSeen[id] = seen
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return firstSeen, nil
+}
+
+// ResolveOrphan удаляет запись о расхождении после его исправления.
+func (ps *PostgresStorage) ResolveOrphan(ctx context.Context, side, id string) error {
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM location_orphans WHERE side = $1 AND location_id = $2`, side, id); err != nil {
+		return fmt.Errorf("failed to resolve orphan: %w", err)
+	}
+	return nil
+}
+
+// EnqueueLocationUpsert повторно ставит локацию из PostgreSQL в outbox для доставки в Elasticsearch.
+func (ps *PostgresStorage) EnqueueLocationUpsert(ctx context.Context, id string) error {
+	query := `INSERT INTO location_outbox (location_id, operation, payload)
+		SELECT id, 'upsert', data FROM locations WHERE id = $1`
+	res, err := ps.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue location: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrLocationNotFound
+	}
+	return nil
+}
+
+// UpdateLocationFields записывает в локации, сохраненные в PostgreSQL, значения полей updates
+// (ID → имя поля JSON → значение) и в той же транзакции ставит изменившиеся локации в outbox,
+// чтобы повторная доставка из outbox не возвращала прежние значения. Возвращает ID найденных
+// в PostgreSQL локаций и число изменившихся; остальные локации есть только в Elasticsearch.
+func (ps *PostgresStorage) UpdateLocationFields(ctx context.Context, updates map[string]map[string]interface{}) (map[string]bool, int, error) {
+	stored := make(map[string]bool, len(updates))
+	if len(updates) == 0 {
+		return stored, 0, nil
+	}
+	ids := make([]string, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, data FROM locations WHERE id = ANY($1) ORDER BY id FOR UPDATE`, pq.Array(ids))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query locations: %w", err)
+	}
+	var locations []*models.Location
+	for rows.Next() {
+		var data []byte
+		location := &models.Location{}
+		if err := rows.Scan(&location.ID, &data); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan location: %w", err)
+		}
+		if err := json.Unmarshal(data, location); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to decode location %s: %w", location.ID, err)
+		}
+		locations = append(locations, location)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	changed := 0
+	now := time.Now()
+	for _, location := range locations {
+		stored[location.ID] = true
+		before, err := json.Marshal(location)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal location: %w", err)
+		}
+		// Поля накладываются через JSON, чтобы значения по умолчанию опускались так же, как при записи локации
+		fields, err := json.Marshal(updates[location.ID])
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal fields: %w", err)
+		}
+		if err := json.Unmarshal(fields, location); err != nil {
+			return nil, 0, fmt.Errorf("failed to apply fields to location %s: %w", location.ID, err)
+		}
+		data, err := json.Marshal(location)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal location: %w", err)
+		}
+		if bytes.Equal(before, data) {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE locations SET data = $2, updated_at = $3 WHERE id = $1`, location.ID, data, now); err != nil {
+			return nil, 0, fmt.Errorf("failed to update location: %w", err)
+		}
+		if err := insertOutbox(ctx, tx, location.ID, models.OutboxUpsert, data); err != nil {
+			return nil, 0, err
+		}
+		changed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return stored, changed, nil
+}
+
+// RecommendedLocationIDsSince возвращает ID локаций, попадавших в выдачу рекомендаций начиная с since.
+// Используется архивацией, чтобы не переносить в архив востребованные локации.
+func (ps *PostgresStorage) RecommendedLocationIDsSince(ctx context.Context, since time.Time) (map[string]bool, error) {
+	query := `SELECT DISTINCT jsonb_array_elements_text(result_ids) FROM query_history WHERE created_at >= $1`
+	rows, err := ps.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recommended locations: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan location id: %w", err)
+		}
+		ids[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SaveSnapshot сохраняет снимок выдачи рекомендаций.
+func (ps *PostgresStorage) SaveSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	request, err := json.Marshal(snapshot.Request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	locations, err := json.Marshal(snapshot.Locations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal locations: %w", err)
+	}
+
+	query := `INSERT INTO recommendation_snapshots (token, query_id, request, locations, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if _, err := ps.db.ExecContext(ctx, query,
+		snapshot.Token,
+		snapshot.QueryID,
+		request,
+		locations,
+		snapshot.CreatedAt,
+		snapshot.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to insert snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetSnapshot возвращает снимок выдачи по токену или ErrSnapshotNotFound.
+// Срок действия снимка проверяет вызывающий код.
+func (ps *PostgresStorage) GetSnapshot(ctx context.Context, token string) (*models.Snapshot, error) {
+	query := `SELECT token, query_id, request, locations, created_at, expires_at
+		FROM recommendation_snapshots WHERE token = $1`
+
+	var (
+		snapshot           models.Snapshot
+		request, locations []byte
+	)
+	err := ps.db.QueryRowContext(ctx, query, token).Scan(
+		&snapshot.Token,
+		&snapshot.QueryID,
+		&request,
+		&locations,
+		&snapshot.CreatedAt,
+		&snapshot.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if err := json.Unmarshal(request, &snapshot.Request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+	}
+	if err := json.Unmarshal(locations, &snapshot.Locations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal locations: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// ErrNoteNotFound возвращается, если заметка отсутствует.
+var ErrNoteNotFound = errors.New("note not found")
+
+// ListNotes возвращает заметки организации о локации, отсортированные по времени создания.
+func (ps *PostgresStorage) ListNotes(ctx context.Context, locationID, organization string) ([]models.Note, error) {
+	query := `SELECT id, location_id, organization, author, text, rating, created_at, updated_at
+		FROM location_notes WHERE location_id = $1 AND organization = $2 ORDER BY created_at, id`
+
+	rows, err := ps.db.QueryContext(ctx, query, locationID, organization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []models.Note{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, *note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return notes, nil
+}
+
+// GetNote возвращает заметку по ID или ErrNoteNotFound.
+func (ps *PostgresStorage) GetNote(ctx context.Context, id int64) (*models.Note, error) {
+	query := `SELECT id, location_id, organization, author, text, rating, created_at, updated_at
+		FROM location_notes WHERE id = $1`
+
+	note, err := scanNote(ps.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoteNotFound
+	}
+	return note, err
+}
+
+// CreateNote сохраняет новую заметку и заполняет ее ID и временные метки.
+func (ps *PostgresStorage) CreateNote(ctx context.Context, note *models.Note) error {
+	query := `INSERT INTO location_notes (location_id, organization, author, text, rating)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
+
+	if err := ps.db.QueryRowContext(ctx, query,
+		note.LocationID,
+		note.Organization,
+		note.Author,
+		note.Text,
+		nullableInt(note.Rating),
+	).Scan(&note.ID, &note.CreatedAt, &note.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to insert note: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateNote изменяет текст и оценку заметки.
+func (ps *PostgresStorage) UpdateNote(ctx context.Context, note *models.Note) error {
+	query := `UPDATE location_notes SET text = $2, rating = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 RETURNING updated_at`
+
+	err := ps.db.QueryRowContext(ctx, query, note.ID, note.Text, nullableInt(note.Rating)).Scan(&note.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoteNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteNote удаляет заметку по ID.
+func (ps *PostgresStorage) DeleteNote(ctx context.Context, id int64) error {
+	res, err := ps.db.ExecContext(ctx, `DELETE FROM location_notes WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNoteNotFound
+	}
+	return nil
+}
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNote(row rowScanner) (*models.Note, error) {
+	var (
+		note   models.Note
+		rating sql.NullInt64
+	)
+	if err := row.Scan(
+		&note.ID,
+		&note.LocationID,
+		&note.Organization,
+		&note.Author,
+		&note.Text,
+		&rating,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan note: %w", err)
+	}
+	if rating.Valid {
+		value := int(rating.Int64)
+		note.Rating = &value
+	}
+	return &note, nil
+}
+
+func nullableInt(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// FrequentQueries возвращает наиболее частые запросы рекомендаций начиная с since.
+func (ps *PostgresStorage) FrequentQueries(ctx context.Context, since time.Time, limit int) ([]models.RecommendRequest, error) {
+	query := `SELECT request FROM query_history WHERE created_at >= $1
+		GROUP BY request ORDER BY COUNT(*) DESC LIMIT $2`
+
+	rows, err := ps.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query frequent queries: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.RecommendRequest
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan query: %w", err)
+		}
+		var req models.RecommendRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+var (
+	// ErrProjectNotFound возвращается, если проект отсутствует.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrCandidateNotFound возвращается, если локация не добавлена в проект.
+	ErrCandidateNotFound = errors.New("candidate not found")
+	// ErrCandidateExists возвращается при повторном добавлении локации в проект.
+	ErrCandidateExists = errors.New("candidate already exists")
+)
+
+const projectColumns = `id, organization, owner, name, business_type, region, budget, created_at, updated_at`
+
+// CreateProject сохраняет новый проект и заполняет его временные метки.
+func (ps *PostgresStorage) CreateProject(ctx context.Context, project *models.Project) error {
+	query := `INSERT INTO projects (id, organization, owner, name, business_type, region, budget)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at, updated_at`
+
+	if err := ps.db.QueryRowContext(ctx, query,
+		project.ID,
+		project.Organization,
+		project.Owner,
+		project.Name,
+		project.BusinessType,
+		project.Region,
+		nullableFloat(project.Budget),
+	).Scan(&project.CreatedAt, &project.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to insert project: %w", err)
+	}
+
+	return nil
+}
+
+// ListProjects возвращает проекты организации, начиная с последних измененных.
+func (ps *PostgresStorage) ListProjects(ctx context.Context, organization string) ([]models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE organization = $1 ORDER BY updated_at DESC`
+
+	rows, err := ps.db.QueryContext(ctx, query, organization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return projects, nil
+}
+
+// GetProject возвращает проект по ID или ErrProjectNotFound.
+func (ps *PostgresStorage) GetProject(ctx context.Context, id string) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	project, err := scanProject(ps.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProjectNotFound
+	}
+	return project, err
+}
+
+// UpdateProject изменяет параметры проекта.
+func (ps *PostgresStorage) UpdateProject(ctx context.Context, project *models.Project) error {
+	query := `UPDATE projects SET name = $2, business_type = $3, region = $4, budget = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 RETURNING updated_at`
+
+	err := ps.db.QueryRowContext(ctx, query,
+		project.ID,
+		project.Name,
+		project.BusinessType,
+		project.Region,
+		nullableFloat(project.Budget),
+	).Scan(&project.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteProject удаляет проект вместе с кандидатами.
+func (ps *PostgresStorage) DeleteProject(ctx context.Context, id string) error {
+	res, err := ps.db.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+// ListCandidates возвращает кандидатов проекта в порядке добавления.
+func (ps *PostgresStorage) ListCandidates(ctx context.Context, projectID string) ([]models.Candidate, error) {
+	query := `SELECT project_id, location_id, status, comment, added_by, created_at, updated_at
+		FROM project_candidates WHERE project_id = $1 ORDER BY created_at, location_id`
+
+	rows, err := ps.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := []models.Candidate{}
+	for rows.Next() {
+		var candidate models.Candidate
+		if err := rows.Scan(
+			&candidate.ProjectID,
+			&candidate.LocationID,
+			&candidate.Status,
+			&candidate.Comment,
+			&candidate.AddedBy,
+			&candidate.CreatedAt,
+			&candidate.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan candidate: %w", err)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// AddCandidate добавляет локацию в проект. Возвращает ErrCandidateExists, если она уже добавлена.
+func (ps *PostgresStorage) AddCandidate(ctx context.Context, candidate *models.Candidate) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO project_candidates (project_id, location_id, status, comment, added_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id, location_id) DO NOTHING
+		RETURNING created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		candidate.ProjectID,
+		candidate.LocationID,
+		candidate.Status,
+		candidate.Comment,
+		candidate.AddedBy,
+	).Scan(&candidate.CreatedAt, &candidate.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCandidateExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert candidate: %w", err)
+	}
+
+	if err := touchProject(ctx, tx, candidate.ProjectID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateCandidate изменяет статус и комментарий кандидата.
+func (ps *PostgresStorage) UpdateCandidate(ctx context.Context, candidate *models.Candidate) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE project_candidates SET status = $3, comment = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE project_id = $1 AND location_id = $2
+		RETURNING added_by, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		candidate.ProjectID,
+		candidate.LocationID,
+		candidate.Status,
+		candidate.Comment,
+	).Scan(&candidate.AddedBy, &candidate.CreatedAt, &candidate.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCandidateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update candidate: %w", err)
+	}
+
+	if err := touchProject(ctx, tx, candidate.ProjectID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveCandidate удаляет локацию из проекта.
+func (ps *PostgresStorage) RemoveCandidate(ctx context.Context, projectID, locationID string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM project_candidates WHERE project_id = $1 AND location_id = $2`,
+		projectID, locationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete candidate: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrCandidateNotFound
+	}
+
+	if err := touchProject(ctx, tx, projectID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// touchProject обновляет updated_at проекта при изменении списка кандидатов.
+func touchProject(ctx context.Context, tx *sql.Tx, projectID string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE projects SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, projectID); err != nil {
+		return fmt.Errorf("failed to touch project: %w", err)
+	}
+	return nil
+}
+
+func scanProject(row rowScanner) (*models.Project, error) {
+	var (
+		project models.Project
+		budget  sql.NullFloat64
+	)
+	if err := row.Scan(
+		&project.ID,
+		&project.Organization,
+		&project.Owner,
+		&project.Name,
+		&project.BusinessType,
+		&project.Region,
+		&budget,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+	if budget.Valid {
+		project.Budget = &budget.Float64
+	}
+	return &project, nil
+}
+
+func nullableFloat(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// AddQueryCost прибавляет показатели usage к дневной записи клиента usage.Subject за usage.Day
+// и возвращает суммарную стоимость за день с учетом добавленной.
+func (ps *PostgresStorage) AddQueryCost(ctx context.Context, usage *models.QueryCostUsage) (float64, error) {
+	query := `
+		INSERT INTO query_costs (subject, day, organization, requests, searches, took_ms, hits, buckets, cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (subject, day) DO UPDATE SET
+			organization = EXCLUDED.organization,
+			requests = query_costs.requests + EXCLUDED.requests,
+			searches = query_costs.searches + EXCLUDED.searches,
+			took_ms = query_costs.took_ms + EXCLUDED.took_ms,
+			hits = query_costs.hits + EXCLUDED.hits,
+			buckets = query_costs.buckets + EXCLUDED.buckets,
+			cost = query_costs.cost + EXCLUDED.cost
+		RETURNING cost
+	`
+
+	var total float64
+	err := ps.db.QueryRowContext(ctx, query,
+		usage.Subject, usage.Day, usage.Organization, usage.Requests, usage.Searches,
+		usage.TookMs, usage.Hits, usage.Buckets, usage.Cost,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add query cost: %w", err)
+	}
+
+	return total, nil
+}
+
+// MarkQueryCostAlerted отмечает, что по дневной записи клиента отправлен алерт о превышении бюджета.
+// Возвращает false, если отметка уже была поставлена (алерт отправлен ранее, в том числе другим экземпляром).
+func (ps *PostgresStorage) MarkQueryCostAlerted(ctx context.Context, subject, day string) (bool, error) {
+	query := `
+		UPDATE query_costs SET budget_alerted_at = NOW()
+		WHERE subject = $1 AND day = $2 AND budget_alerted_at IS NULL
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, subject, day)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark query cost alert: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark query cost alert: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// ListQueryCosts возвращает дневные записи стоимости запросов за период [from, to]
+// (даты YYYY-MM-DD), упорядоченные по дате и клиенту. Пустой subject — все клиенты.
+func (ps *PostgresStorage) ListQueryCosts(ctx context.Context, subject, from, to string) ([]models.QueryCostUsage, error) {
+	query := `
+		SELECT subject, organization, day, requests, searches, took_ms, hits, buckets, cost, budget_alerted_at
+		FROM query_costs
+		WHERE day BETWEEN $1 AND $2 AND ($3 = '' OR subject = $3)
+		ORDER BY day, subject
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, from, to, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query query costs: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []models.QueryCostUsage
+	for rows.Next() {
+		var usage models.QueryCostUsage
+		var day time.Time
+		var alertedAt sql.NullTime
+		if err := rows.Scan(&usage.Subject, &usage.Organization, &day, &usage.Requests, &usage.Searches,
+			&usage.TookMs, &usage.Hits, &usage.Buckets, &usage.Cost, &alertedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan query cost: %w", err)
+		}
+		usage.Day = day.Format("2006-01-02")
+		if alertedAt.Valid {
+			usage.BudgetAlertedAt = &alertedAt.Time
+		}
+		usages = append(usages, usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating query costs: %w", err)
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ErrRankingOverrideNotFound возвращается, если ранжирование по умолчанию не задано.
+var ErrRankingOverrideNotFound = errors.New("ranking override not found")
+
+// IsBoostableField сообщает, допустимо ли поле в профилях ранжирования и бустах клиентов.
+func IsBoostableField(field string) bool {
+	return boostableFields[field]
+}
+
+// GetRankingOverrides возвращает ранжирование по умолчанию организации и клиента subject:
+// сначала значения организации, затем клиента. Отсутствующие значения пропускаются.
+func (ps *PostgresStorage) GetRankingOverrides(ctx context.Context, organization, subject string) ([]models.RankingOverride, error) {
+	query := `SELECT organization, subject, weights, boosts, updated_at FROM ranking_overrides
+		WHERE organization = $1 AND subject IN ('', $2) ORDER BY subject = '' DESC`
+
+	rows, err := ps.db.QueryContext(ctx, query, organization, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ranking overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []models.RankingOverride
+	for rows.Next() {
+		override, err := scanRankingOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, *override)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ranking overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// GetRankingOverride возвращает ранжирование по умолчанию организации (пустой subject) или клиента.
+// Возвращает ErrRankingOverrideNotFound, если оно не задано.
+func (ps *PostgresStorage) GetRankingOverride(ctx context.Context, organization, subject string) (*models.RankingOverride, error) {
+	query := `SELECT organization, subject, weights, boosts, updated_at FROM ranking_overrides
+		WHERE organization = $1 AND subject = $2`
+
+	override, err := scanRankingOverride(ps.db.QueryRowContext(ctx, query, organization, subject))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRankingOverrideNotFound
+	}
+	return override, err
+}
+
+// PutRankingOverride сохраняет ранжирование по умолчанию, заменяя прежние значения,
+// и записывает время обновления в override.
+func (ps *PostgresStorage) PutRankingOverride(ctx context.Context, override *models.RankingOverride) error {
+	// Без весов в столбце NULL: запросы без weights используют веса по умолчанию
+	var weights interface{}
+	if override.Weights != nil {
+		data, err := json.Marshal(override.Weights)
+		if err != nil {
+			return fmt.Errorf("failed to marshal weights: %w", err)
+		}
+		weights = string(data)
+	}
+	if override.Boosts == nil {
+		override.Boosts = []models.ScoringBoost{}
+	}
+	boosts, err := json.Marshal(override.Boosts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal boosts: %w", err)
+	}
+
+	query := `INSERT INTO ranking_overrides (organization, subject, weights, boosts, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (organization, subject) DO UPDATE
+		SET weights = EXCLUDED.weights, boosts = EXCLUDED.boosts, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`
+
+	err = ps.db.QueryRowContext(ctx, query, override.Organization, override.Subject, weights, boosts).Scan(&override.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save ranking override: %w", err)
+	}
+	return nil
+}
+
+// DeleteRankingOverride удаляет ранжирование по умолчанию организации или клиента.
+func (ps *PostgresStorage) DeleteRankingOverride(ctx context.Context, organization, subject string) error {
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM ranking_overrides WHERE organization = $1 AND subject = $2`, organization, subject)
+	if err != nil {
+		return fmt.Errorf("failed to delete ranking override: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrRankingOverrideNotFound
+	}
+	return nil
+}
+
+// scanRankingOverride читает строку ranking_overrides.
+func scanRankingOverride(row rowScanner) (*models.RankingOverride, error) {
+	var override models.RankingOverride
+	var weights, boosts []byte
+	err := row.Scan(&override.Organization, &override.Subject, &weights, &boosts, &override.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan ranking override: %w", err)
+	}
+	if weights != nil {
+		if err := json.Unmarshal(weights, &override.Weights); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal weights: %w", err)
+		}
+	}
+	if err := json.Unmarshal(boosts, &override.Boosts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal boosts: %w", err)
+	}
+	return &override, nil
+}
@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/akozadaev/go_es_analytical_system/internal/refdata"
+	"github.com/lib/pq"
+)
+
+// ErrRegionHasCities возвращается, если удаляемый при загрузке справочников регион еще
+// используется городами.
+var ErrRegionHasCities = errors.New("region still has cities")
+
+// ImportReferenceData записывает справочники из переносимой выгрузки в одной транзакции:
+// типы бизнеса добавляются или обновляются по имени, регионы добавляются по пути, города
+// добавляются или обновляются по пути региона и имени. С prune записи, отсутствующие
+// в выгрузке, удаляются: города — раньше регионов. Выгрузка должна быть проверена заранее:
+// родители и регионы всех записей присутствуют в ней самой.
+//
+// Отличия от справочников окружения рассчитываются в той же транзакции после блокировки
+// таблиц справочников, поэтому параллельные изменения не попадают между расчетом и записью.
+// С dryRun изменения выполняются и откатываются: ответ совпадает с тем, что внесла бы загрузка,
+// включая ErrRegionHasCities.
+func (ps *PostgresStorage) ImportReferenceData(ctx context.Context, data *models.ReferenceData, prune, dryRun bool) (*models.ReferenceDiff, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// SHARE ROW EXCLUSIVE не мешает чтению справочников, но ждет завершения и блокирует их изменение
+	lock := `LOCK TABLE business_types, business_type_substitutes, regions, cities IN SHARE ROW EXCLUSIVE MODE`
+	if _, err := tx.ExecContext(ctx, lock); err != nil {
+		return nil, fmt.Errorf("failed to lock reference tables: %w", err)
+	}
+	current, err := queryReferenceData(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	diff := refdata.Diff(current, data)
+	diff.DryRun, diff.Prune = dryRun, prune
+	if prune {
+		if err := listReferenceCascade(ctx, tx, current, diff); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := importBusinessTypes(ctx, tx, data.BusinessTypes, prune); err != nil {
+		return nil, err
+	}
+	regionIDs, err := importRegions(ctx, tx, data.Regions)
+	if err != nil {
+		return nil, err
+	}
+	if err := importCities(ctx, tx, data.Cities, regionIDs, prune); err != nil {
+		return nil, err
+	}
+	if prune {
+		if err := pruneRegions(ctx, tx, data.Regions, regionIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return diff, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return diff, nil
+}
+
+// queryReferenceData читает справочники окружения в транзакции tx в переносимом формате.
+func queryReferenceData(ctx context.Context, tx *sql.Tx) (*models.ReferenceData, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, description, benefits_from_events, parent_id FROM business_types`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query business types: %w", err)
+	}
+	var businessTypes []models.BusinessType
+	for rows.Next() {
+		var bt models.BusinessType
+		var parentID sql.NullInt64
+		if err := rows.Scan(&bt.ID, &bt.Name, &bt.Description, &bt.BenefitsFromEvents, &parentID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan business type: %w", err)
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			bt.ParentID = &id
+		}
+		businessTypes = append(businessTypes, bt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	regions, err := queryRegions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `SELECT name, region_id, center_lat, center_lon, population FROM cities`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cities: %w", err)
+	}
+	var cities []models.City
+	for rows.Next() {
+		var city models.City
+		if err := rows.Scan(&city.Name, &city.RegionID, &city.Center.Lat, &city.Center.Lon, &city.Population); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan city: %w", err)
+		}
+		cities = append(cities, city)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cities: %w", err)
+	}
+
+	return refdata.FromModels(businessTypes, regions, cities), nil
+}
+
+// listReferenceCascade перечисляет в diff.Cascade записи, которые PostgreSQL изменит по внешним
+// ключам при удалении типов бизнеса diff.BusinessTypes.Removed: связи замещаемости удаляются
+// (ON DELETE CASCADE), а оставшиеся подтипы теряют категорию (ON DELETE SET NULL).
+func listReferenceCascade(ctx context.Context, tx *sql.Tx, current *models.ReferenceData, diff *models.ReferenceDiff) error {
+	if len(diff.BusinessTypes.Removed) == 0 {
+		return nil
+	}
+	removed := make(map[string]bool, len(diff.BusinessTypes.Removed))
+	for _, name := range diff.BusinessTypes.Removed {
+		removed[name] = true
+	}
+	for _, bt := range current.BusinessTypes {
+		if bt.Parent != "" && removed[bt.Parent] && !removed[bt.Name] {
+			diff.Cascade.DetachedBusinessTypes = append(diff.Cascade.DetachedBusinessTypes, bt.Name)
+		}
+	}
+
+	query := `SELECT business_type, substitute FROM business_type_substitutes
+		WHERE business_type = ANY($1) OR substitute = ANY($1)
+		ORDER BY business_type, substitute`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(diff.BusinessTypes.Removed))
+	if err != nil {
+		return fmt.Errorf("failed to query business type substitutes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var businessType, substitute string
+		if err := rows.Scan(&businessType, &substitute); err != nil {
+			return fmt.Errorf("failed to scan business type substitute: %w", err)
+		}
+		diff.Cascade.Substitutes = append(diff.Cascade.Substitutes, businessType+"/"+substitute)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	return nil
+}
+
+func importBusinessTypes(ctx context.Context, tx *sql.Tx, businessTypes []models.ReferenceBusinessType, prune bool) error {
+	upsert := `INSERT INTO business_types (name, description, benefits_from_events) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description,
+			benefits_from_events = EXCLUDED.benefits_from_events, updated_at = CURRENT_TIMESTAMP
+		WHERE business_types.description IS DISTINCT FROM EXCLUDED.description
+			OR business_types.benefits_from_events IS DISTINCT FROM EXCLUDED.benefits_from_events`
+	names := make([]string, len(businessTypes))
+	for i, bt := range businessTypes {
+		names[i] = bt.Name
+		if _, err := tx.ExecContext(ctx, upsert, bt.Name, bt.Description, bt.BenefitsFromEvents); err != nil {
+			return fmt.Errorf("failed to save business type %q: %w", bt.Name, err)
+		}
+	}
+
+	// Категории назначаются после добавления всех типов: категория может идти в выгрузке после подтипа
+	setParent := `UPDATE business_types SET parent_id = parent.id, updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT (SELECT id FROM business_types WHERE name = NULLIF($2, '')) AS id) parent
+		WHERE name = $1 AND parent_id IS DISTINCT FROM parent.id`
+	for _, bt := range businessTypes {
+		if _, err := tx.ExecContext(ctx, setParent, bt.Name, bt.Parent); err != nil {
+			return fmt.Errorf("failed to set parent of business type %q: %w", bt.Name, err)
+		}
+	}
+
+	if prune {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM business_types WHERE NOT (name = ANY($1))`, pq.Array(names)); err != nil {
+			return fmt.Errorf("failed to delete business types: %w", err)
+		}
+	}
+	return nil
+}
+
+// importRegions добавляет отсутствующие регионы и возвращает ID всех регионов окружения по путям.
+func importRegions(ctx context.Context, tx *sql.Tx, regions []models.ReferenceRegion) (map[string]int, error) {
+	existing, err := queryRegions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int, len(existing))
+	for id, path := range models.RegionPaths(existing) {
+		ids[path] = id
+	}
+
+	// Родительский регион добавляется раньше дочерних: регионы обрабатываются по глубине пути
+	ordered := append([]models.ReferenceRegion(nil), regions...)
+	sort.SliceStable(ordered, func(i, j int) bool { return regionDepth(ordered[i]) < regionDepth(ordered[j]) })
+	for _, region := range ordered {
+		path := region.Path()
+		if _, ok := ids[path]; ok {
+			continue
+		}
+		var parentID *int
+		if region.Parent != "" {
+			id, ok := ids[region.Parent]
+			if !ok {
+				return nil, fmt.Errorf("parent region %q of %q not found", region.Parent, path)
+			}
+			parentID = &id
+		}
+		var id int
+		query := `INSERT INTO regions (name, parent_region_id) VALUES ($1, $2) RETURNING id`
+		if err := tx.QueryRowContext(ctx, query, region.Name, parentID).Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to save region %q: %w", path, err)
+		}
+		ids[path] = id
+	}
+
+	return ids, nil
+}
+
+// queryRegions читает регионы окружения в транзакции tx.
+func queryRegions(ctx context.Context, tx *sql.Tx) ([]models.Region, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, parent_region_id FROM regions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query regions: %w", err)
+	}
+	defer rows.Close()
+
+	var regions []models.Region
+	for rows.Next() {
+		var region models.Region
+		var parentID sql.NullInt64
+		if err := rows.Scan(&region.ID, &region.Name, &parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan region: %w", err)
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			region.ParentRegionID = &id
+		}
+		regions = append(regions, region)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return regions, nil
+}
+
+// importCities добавляет и обновляет города по пути региона и имени, а с prune удаляет
+// отсутствующие в выгрузке. ids — ID регионов по путям после importRegions.
+func importCities(ctx context.Context, tx *sql.Tx, cities []models.ReferenceCity, ids map[string]int, prune bool) error {
+	upsert := `INSERT INTO cities (name, region_id, center_lat, center_lon, population) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name, region_id) DO UPDATE SET center_lat = EXCLUDED.center_lat, center_lon = EXCLUDED.center_lon,
+			population = EXCLUDED.population, updated_at = CURRENT_TIMESTAMP
+		WHERE (cities.center_lat, cities.center_lon, cities.population)
+			IS DISTINCT FROM (EXCLUDED.center_lat, EXCLUDED.center_lon, EXCLUDED.population)`
+	names := make([]string, len(cities))
+	regionIDs := make([]int64, len(cities))
+	for i, city := range cities {
+		regionID, ok := ids[city.Region]
+		if !ok {
+			return fmt.Errorf("region %q of city %q not found", city.Region, city.Name)
+		}
+		names[i], regionIDs[i] = city.Name, int64(regionID)
+		if _, err := tx.ExecContext(ctx, upsert, city.Name, regionID, city.Center.Lat, city.Center.Lon, city.Population); err != nil {
+			return fmt.Errorf("failed to save city %q: %w", city.Key(), err)
+		}
+	}
+
+	if prune {
+		query := `DELETE FROM cities c WHERE NOT EXISTS (
+			SELECT 1 FROM unnest($1::text[], $2::int[]) AS imported(name, region_id)
+			WHERE imported.name = c.name AND imported.region_id = c.region_id)`
+		if _, err := tx.ExecContext(ctx, query, pq.Array(names), pq.Array(regionIDs)); err != nil {
+			return fmt.Errorf("failed to delete cities: %w", err)
+		}
+	}
+	return nil
+}
+
+// pruneRegions удаляет регионы окружения, отсутствующие в выгрузке. Возвращает
+// ErrRegionHasCities, если у удаляемого региона остались города.
+func pruneRegions(ctx context.Context, tx *sql.Tx, regions []models.ReferenceRegion, ids map[string]int) error {
+	imported := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		imported[region.Path()] = true
+	}
+
+	// Дочерние регионы удаляются раньше родительских, чтобы не оставлять их без родителя
+	var removed []string
+	for path := range ids {
+		if !imported[path] {
+			removed = append(removed, path)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return strings.Count(removed[i], models.RegionPathSeparator) > strings.Count(removed[j], models.RegionPathSeparator)
+	})
+	for _, path := range removed {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM regions WHERE id = $1`, ids[path]); err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == pqForeignKeyViolation && pqErr.Table == "cities" {
+				return fmt.Errorf("%w: %s", ErrRegionHasCities, path)
+			}
+			return fmt.Errorf("failed to delete region %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// regionDepth возвращает глубину региона в иерархии (0 — корневой регион).
+func regionDepth(region models.ReferenceRegion) int {
+	if region.Parent == "" {
+		return 0
+	}
+	return strings.Count(region.Parent, models.RegionPathSeparator) + 1
+}
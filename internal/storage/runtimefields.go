@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+var (
+	// ErrInvalidRuntimeField возвращается, если кластер отклонил определение вычисляемого поля.
+	ErrInvalidRuntimeField = errors.New("invalid runtime field")
+	// ErrRuntimeFieldsUnsupported возвращается для кластера OpenSearch: в нем нет runtime fields.
+	ErrRuntimeFieldsUnsupported = errors.New("runtime fields are not supported by OpenSearch")
+)
+
+// ListRuntimeFields возвращает вычисляемые поля маппинга индекса локаций, упорядоченные по имени.
+func (es *ElasticsearchStorage) ListRuntimeFields(ctx context.Context) ([]models.RuntimeField, error) {
+	mapping, err := es.GetMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runtime, _ := mapping["runtime"].(map[string]interface{})
+	fields := make([]models.RuntimeField, 0, len(runtime))
+	for name, raw := range runtime {
+		definition, _ := raw.(map[string]interface{})
+		field := models.RuntimeField{Name: name}
+		field.Type, _ = definition["type"].(string)
+		// Скрипт хранится строкой или объектом {"source": ...}
+		switch script := definition["script"].(type) {
+		case string:
+			field.Script = script
+		case map[string]interface{}:
+			field.Script, _ = script["source"].(string)
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	return fields, nil
+}
+
+// PutRuntimeField добавляет вычисляемое поле в маппинг основного и архивного индексов локаций
+// или заменяет его определение. Возвращает ErrInvalidRuntimeField, если кластер не смог
+// скомпилировать скрипт, и ErrRuntimeFieldsUnsupported для OpenSearch.
+func (es *ElasticsearchStorage) PutRuntimeField(ctx context.Context, field *models.RuntimeField) error {
+	openSearch, err := es.isOpenSearch(ctx)
+	if err != nil {
+		return err
+	}
+	if openSearch {
+		return ErrRuntimeFieldsUnsupported
+	}
+	return es.putRuntimeMapping(ctx, map[string]interface{}{
+		field.Name: map[string]interface{}{
+			"type":   field.Type,
+			"script": map[string]interface{}{"source": field.Script},
+		},
+	})
+}
+
+// DeleteRuntimeField удаляет вычисляемое поле из маппинга основного и архивного индексов локаций.
+func (es *ElasticsearchStorage) DeleteRuntimeField(ctx context.Context, name string) error {
+	// Значение null удаляет поле из секции runtime
+	return es.putRuntimeMapping(ctx, map[string]interface{}{name: nil})
+}
+
+// putRuntimeMapping изменяет секцию runtime маппинга основного и архивного индексов локаций:
+// запросы с include_archived фильтруют по полю оба индекса. Отсутствующий архив пропускается.
+// Ответ 400 означает ошибку в определении поля и возвращается как ErrInvalidRuntimeField.
+func (es *ElasticsearchStorage) putRuntimeMapping(ctx context.Context, runtime map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"runtime": runtime}); err != nil {
+		return fmt.Errorf("failed to encode mapping: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s,%s/_mapping?ignore_unavailable=true", es.baseURL, es.index, es.archive)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("%w: %s", ErrInvalidRuntimeField, string(body))
+	}
+	if res.StatusCode >= 400 {
+		return newESError("error updating mapping", res.StatusCode, res.Body)
+	}
+
+	return nil
+}
+
+// RuntimeFieldStats рассчитывает статистику значений числового поля name по индексу локаций.
+// Ошибка выполнения скрипта на документах индекса возвращается как ErrInvalidRuntimeField.
+func (es *ElasticsearchStorage) RuntimeFieldStats(ctx context.Context, name string) (*models.RuntimeFieldStats, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"values": map[string]interface{}{"stats": map[string]interface{}{"field": name}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate runtime field: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRuntimeField, string(body))
+	}
+	if res.StatusCode >= 400 {
+		return nil, newESError("error aggregating runtime field", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Aggregations struct {
+			Values models.RuntimeFieldStats `json:"values"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result.Aggregations.Values, nil
+}
+
+// isOpenSearch сообщает, является ли кластер OpenSearch: его корневой эндпоинт возвращает
+// version.distribution = "opensearch".
+func (es *ElasticsearchStorage) isOpenSearch(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", es.baseURL+"/", nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to get cluster info: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return false, newESError("error getting cluster info", res.StatusCode, res.Body)
+	}
+
+	var info struct {
+		Version struct {
+			Distribution string `json:"distribution"`
+		} `json:"version"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return info.Version.Distribution == "opensearch", nil
+}
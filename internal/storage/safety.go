@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ReplaceDistrictSafety заменяет индексы безопасности районов в одной транзакции.
+func (ps *PostgresStorage) ReplaceDistrictSafety(ctx context.Context, districts []models.DistrictSafety) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM district_safety`); err != nil {
+		return fmt.Errorf("failed to clear district safety: %w", err)
+	}
+
+	query := `INSERT INTO district_safety (city, district, lat, lon, safety_score) VALUES ($1, $2, $3, $4, $5)`
+	for _, d := range districts {
+		if _, err := tx.ExecContext(ctx, query, d.City, d.District, d.Center.Lat, d.Center.Lon, d.SafetyScore); err != nil {
+			return fmt.Errorf("failed to insert district safety: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListDistrictSafety возвращает индексы безопасности всех районов.
+func (ps *PostgresStorage) ListDistrictSafety(ctx context.Context) ([]models.DistrictSafety, error) {
+	query := `SELECT city, district, lat, lon, safety_score FROM district_safety ORDER BY city, district`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query district safety: %w", err)
+	}
+	defer rows.Close()
+
+	var districts []models.DistrictSafety
+	for rows.Next() {
+		var d models.DistrictSafety
+		if err := rows.Scan(&d.City, &d.District, &d.Center.Lat, &d.Center.Lon, &d.SafetyScore); err != nil {
+			return nil, fmt.Errorf("failed to scan district safety: %w", err)
+		}
+		districts = append(districts, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating district safety: %w", err)
+	}
+
+	return districts, nil
+}
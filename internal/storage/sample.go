@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// SampleLocations возвращает size локаций, подходящих под фильтр, с наибольшим псевдослучайным
+// ключом random_score. Ключ вычисляется из seed и id документа, поэтому при тех же seed и данных
+// индекса выборка повторяется. Поля embedding и demographic_embedding не загружаются.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) SampleLocations(ctx context.Context, filter *models.LocationFilter, seed int64, size int) ([]*models.Location, error) {
+	query := map[string]interface{}{
+		"size": size,
+		"query": map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": buildFilterQuery(filter),
+				"functions": []map[string]interface{}{
+					{
+						"random_score": map[string]interface{}{
+							"seed":  seed,
+							"field": "id",
+						},
+					},
+				},
+				"boost_mode": "replace",
+			},
+		},
+		// При совпадении ключей порядок определяется id
+		"sort": []interface{}{
+			"_score",
+			map[string]interface{}{"id": map[string]interface{}{"order": "asc"}},
+		},
+		"_source": map[string]interface{}{"excludes": []string{"embedding", "demographic_embedding"}},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error searching", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Location `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	hits := result.Hits.Hits
+	locations := make([]*models.Location, 0, len(hits))
+	for i := range hits {
+		locations = append(locations, &hits[i].Source)
+	}
+
+	return locations, nil
+}
+
+// CountLocationsBy возвращает количество локаций, подходящих под фильтр, по значениям поля field.
+// Локация со списком значений (business_types_suitable) учитывается в каждом из них.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) CountLocationsBy(ctx context.Context, filter *models.LocationFilter, field string) (map[string]int, error) {
+	query := map[string]interface{}{
+		"size":  0,
+		"query": buildFilterQuery(filter),
+		"aggs": map[string]interface{}{
+			"values": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": field,
+					"size":  10000,
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.index)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count locations: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error counting locations", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Aggregations struct {
+			Values struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int    `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"values"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	counts := make(map[string]int, len(result.Aggregations.Values.Buckets))
+	for _, bucket := range result.Aggregations.Values.Buckets {
+		counts[bucket.Key] = bucket.DocCount
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+	"github.com/lib/pq"
+)
+
+// SavedSearchIndexSuffix добавляется к имени основного индекса для получения имени индекса
+// запросов сохраненных поисков.
+const SavedSearchIndexSuffix = "-saved-searches"
+
+// maxPercolateMatches ограничивает число сохраненных поисков, совпавших с одной локацией.
+const maxPercolateMatches = 10000
+
+// ErrSavedSearchNotFound возвращается, если сохраненный поиск отсутствует.
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+const savedSearchColumns = `id, organization, owner, name, request, webhook_url, created_at`
+
+// percolatorMapping добавляет к маппингу индекса локаций поле query типа percolator.
+// Остальные поля сохраняются: percolator разбирает фильтры и проверяемые локации по ним.
+func percolatorMapping(mappingJSON string) (string, error) {
+	var mapping map[string]interface{}
+	if err := json.Unmarshal([]byte(mappingJSON), &mapping); err != nil {
+		return "", fmt.Errorf("failed to parse mapping: %w", err)
+	}
+	mappings, _ := mapping["mappings"].(map[string]interface{})
+	properties, _ := mappings["properties"].(map[string]interface{})
+	if properties == nil {
+		return "", fmt.Errorf("mapping has no mappings.properties")
+	}
+	properties["query"] = map[string]interface{}{"type": "percolator"}
+
+	data, err := json.Marshal(mapping)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+	return string(data), nil
+}
+
+// RegisterSavedSearch сохраняет фильтры запроса req запросом percolator с ID id.
+// Повторная регистрация заменяет запрос.
+func (es *ElasticsearchStorage) RegisterSavedSearch(ctx context.Context, id string, req *models.RecommendRequest) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": recommendFilters(req)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal saved search query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_doc/%s?refresh=true", es.baseURL, es.percolator, id)
+	httpReq, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to register saved search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return newESError("error registering saved search", res.StatusCode, res.Body)
+	}
+
+	return nil
+}
+
+// UnregisterSavedSearch удаляет запрос percolator сохраненного поиска. Отсутствие запроса
+// не считается ошибкой.
+func (es *ElasticsearchStorage) UnregisterSavedSearch(ctx context.Context, id string) error {
+	url := fmt.Sprintf("%s/%s/_doc/%s?refresh=true", es.baseURL, es.percolator, id)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to unregister saved search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 && res.StatusCode != http.StatusNotFound {
+		return newESError("error unregistering saved search", res.StatusCode, res.Body)
+	}
+
+	return nil
+}
+
+// PercolateLocation возвращает ID сохраненных поисков, фильтрам которых соответствует локация.
+// Подбор выполняет кластер запросом percolate, без перебора сохраненных поисков в сервисе.
+func (es *ElasticsearchStorage) PercolateLocation(ctx context.Context, location *models.Location) ([]string, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"query": map[string]interface{}{
+			"percolate": map[string]interface{}{
+				"field":    "query",
+				"document": location,
+			},
+		},
+		"_source": false,
+		"size":    maxPercolateMatches,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode percolate query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", es.baseURL, es.percolator)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to percolate location: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error percolating location", res.StatusCode, res.Body)
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	ids := make([]string, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
+
+// CreateSavedSearch сохраняет сохраненный поиск и заполняет время создания.
+func (ps *PostgresStorage) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	request, err := json.Marshal(search.Request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saved search request: %w", err)
+	}
+
+	query := `INSERT INTO saved_searches (id, organization, owner, name, request, webhook_url)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+
+	if err := ps.db.QueryRowContext(ctx, query,
+		search.ID,
+		search.Organization,
+		search.Owner,
+		search.Name,
+		request,
+		search.WebhookURL,
+	).Scan(&search.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert saved search: %w", err)
+	}
+
+	return nil
+}
+
+// GetSavedSearch возвращает сохраненный поиск по ID или ErrSavedSearchNotFound.
+func (ps *PostgresStorage) GetSavedSearch(ctx context.Context, id string) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = $1`
+
+	search, err := scanSavedSearch(ps.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, err
+}
+
+// ListSavedSearches возвращает сохраненные поиски организации, начиная с последних.
+func (ps *PostgresStorage) ListSavedSearches(ctx context.Context, organization string) ([]models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE organization = $1 ORDER BY created_at DESC`
+
+	return ps.querySavedSearches(ctx, query, organization)
+}
+
+// GetSavedSearches возвращает сохраненные поиски с указанными ID; отсутствующие пропускаются.
+func (ps *PostgresStorage) GetSavedSearches(ctx context.Context, ids []string) ([]models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = ANY($1)`
+
+	return ps.querySavedSearches(ctx, query, pq.Array(ids))
+}
+
+// DeleteSavedSearch удаляет сохраненный поиск. Возвращает ErrSavedSearchNotFound, если его нет.
+func (ps *PostgresStorage) DeleteSavedSearch(ctx context.Context, id string) error {
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+func (ps *PostgresStorage) querySavedSearches(ctx context.Context, query string, args ...interface{}) ([]models.SavedSearch, error) {
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, *search)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return searches, nil
+}
+
+func scanSavedSearch(row rowScanner) (*models.SavedSearch, error) {
+	var (
+		search  models.SavedSearch
+		request []byte
+	)
+	if err := row.Scan(&search.ID, &search.Organization, &search.Owner, &search.Name, &request, &search.WebhookURL, &search.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan saved search: %w", err)
+	}
+	if err := json.Unmarshal(request, &search.Request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal saved search request: %w", err)
+	}
+	return &search, nil
+}
+
+// EnqueueSavedSearchNotifications ставит в очередь уведомления о локации для сохраненных поисков ids.
+// Поиски, удаленные после сопоставления, пропускаются. Возвращает число поставленных уведомлений.
+func (ps *PostgresStorage) EnqueueSavedSearchNotifications(ctx context.Context, ids []string, location *models.Location) (int64, error) {
+	data, err := json.Marshal(location)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal location: %w", err)
+	}
+	res, err := ps.db.ExecContext(ctx, `INSERT INTO saved_search_notifications (saved_search_id, location)
+		SELECT id, $2 FROM saved_searches WHERE id = ANY($1)`, pq.Array(ids), data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue saved search notifications: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ClaimSavedSearchNotifications выбирает до limit уведомлений, которые пора отправить, и откладывает
+// их следующую отправку на lease, чтобы другие экземпляры не отправили их одновременно.
+func (ps *PostgresStorage) ClaimSavedSearchNotifications(ctx context.Context, limit int, lease time.Duration) ([]models.SavedSearchNotification, error) {
+	query := `WITH claimed AS (
+			UPDATE saved_search_notifications SET next_attempt_at = CURRENT_TIMESTAMP + $2::float8 * INTERVAL '1 second'
+			WHERE id IN (
+				SELECT id FROM saved_search_notifications WHERE next_attempt_at <= CURRENT_TIMESTAMP
+				ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, saved_search_id, location, attempts)
+		SELECT c.id, c.location, c.attempts, s.id, s.organization, s.owner, s.name, s.webhook_url, s.created_at
+		FROM claimed c JOIN saved_searches s ON s.id = c.saved_search_id
+		ORDER BY c.id`
+	rows, err := ps.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim saved search notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []models.SavedSearchNotification
+	for rows.Next() {
+		var (
+			n        models.SavedSearchNotification
+			location []byte
+		)
+		if err := rows.Scan(&n.ID, &location, &n.Attempts, &n.Search.ID, &n.Search.Organization, &n.Search.Owner,
+			&n.Search.Name, &n.Search.WebhookURL, &n.Search.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search notification: %w", err)
+		}
+		if err := json.Unmarshal(location, &n.Location); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notification location: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating saved search notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// CompleteSavedSearchNotification фиксирует результат отправки уведомления: отправленное уведомление
+// удаляется, а при ошибке sendErr следующая попытка планируется через retryAfter; нулевой retryAfter
+// прекращает попытки и удаляет уведомление.
+func (ps *PostgresStorage) CompleteSavedSearchNotification(ctx context.Context, id int64, sendErr error, retryAfter time.Duration) error {
+	var err error
+	if sendErr == nil || retryAfter <= 0 {
+		_, err = ps.db.ExecContext(ctx, `DELETE FROM saved_search_notifications WHERE id = $1`, id)
+	} else {
+		_, err = ps.db.ExecContext(ctx, `UPDATE saved_search_notifications SET attempts = attempts + 1, last_error = $2,
+			next_attempt_at = CURRENT_TIMESTAMP + $3::float8 * INTERVAL '1 second' WHERE id = $1`,
+			id, sendErr.Error(), retryAfter.Seconds())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved search notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// schemaMigrations перечисляет по порядку таблицы, создаваемые миграциями migrations/NNN_*.sql:
+// наличие таблицы означает, что миграция с этим номером применена.
+// При добавлении миграции добавьте сюда ее таблицу.
+var schemaMigrations = []string{
+	"business_types",              // 001_init_schema
+	"query_history",               // 002_query_history
+	"location_outbox",             // 003_locations_outbox
+	"location_orphans",            // 004_location_orphans
+	"recommendation_snapshots",    // 005_recommendation_snapshots
+	"location_notes",              // 006_location_notes
+	"projects",                    // 007_projects
+	"idempotency_keys",            // 008_idempotency_keys
+	"seasonality_coefficients",    // 009_seasonality
+	"event_venues",                // 010_event_venues
+	"scoring_boosts",              // 011_education_scoring_profiles
+	"district_safety",             // 012_district_safety
+	"model_versions",              // 013_model_registry
+	"golden_queries",              // 014_golden_queries
+	"business_type_closure",       // 015_business_type_taxonomy
+	"business_type_substitutes",   // 016_business_type_substitutes
+	"location_tombstones",         // 017_location_tombstones
+	"analytics_segments",          // 018_analytics_segments
+	"query_costs",                 // 019_query_costs
+	"search_templates",            // 020_search_templates
+	"saved_searches",              // 021_saved_searches
+	"ranking_overrides",           // 022_ranking_overrides
+	"cluster_instances",           // 023_cluster_instances
+	"async_index_items",           // 024_async_index_items
+	"api_keys",                    // 025_api_keys
+	"scoring_vector_weights",      // 026_scoring_vector_weights
+	"location_status_transitions", // 027_location_status_transitions
+	"validation_profiles",         // 028_validation_profiles
+	"cities",                      // 029_cities
+	"saved_search_notifications",  // 030_saved_search_notifications
+	"query_history_owners",        // 031_query_history_owners
+}
+
+// ExpectedSchemaVersion возвращает номер последней миграции, известной приложению.
+func ExpectedSchemaVersion() int {
+	return len(schemaMigrations)
+}
+
+// SchemaVersion возвращает номер последней примененной миграции схемы PostgreSQL:
+// наибольший номер, для которого применены все миграции до него включительно.
+func (ps *PostgresStorage) SchemaVersion(ctx context.Context) (int, error) {
+	for i, table := range schemaMigrations {
+		var exists bool
+		if err := ps.db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("failed to check table %s: %w", table, err)
+		}
+		if !exists {
+			return i, nil
+		}
+	}
+	return len(schemaMigrations), nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// GetScoringBoosts возвращает профиль ранжирования типа бизнеса.
+func (ps *PostgresStorage) GetScoringBoosts(ctx context.Context, businessType string) ([]models.ScoringBoost, error) {
+	query := `SELECT business_type, field, min_value, boost FROM scoring_boosts WHERE business_type = $1 ORDER BY field`
+
+	rows, err := ps.db.QueryContext(ctx, query, businessType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scoring boosts: %w", err)
+	}
+	defer rows.Close()
+
+	var boosts []models.ScoringBoost
+	for rows.Next() {
+		var b models.ScoringBoost
+		if err := rows.Scan(&b.BusinessType, &b.Field, &b.Min, &b.Boost); err != nil {
+			return nil, fmt.Errorf("failed to scan scoring boost: %w", err)
+		}
+		boosts = append(boosts, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scoring boosts: %w", err)
+	}
+
+	return boosts, nil
+}
+
+// GetVectorWeights возвращает веса векторного поиска профиля ранжирования типа бизнеса
+// или nil, если они не заданы.
+func (ps *PostgresStorage) GetVectorWeights(ctx context.Context, businessType string) (*models.VectorWeights, error) {
+	query := `SELECT description_weight, demographics_weight FROM scoring_vector_weights WHERE business_type = $1`
+
+	var weights models.VectorWeights
+	err := ps.db.QueryRowContext(ctx, query, businessType).Scan(&weights.Description, &weights.Demographics)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vector weights: %w", err)
+	}
+	return &weights, nil
+}
+
+// ListRankingProfiles возвращает профили ранжирования всех типов бизнеса, для которых заданы
+// бусты или веса векторного поиска, в порядке имени типа.
+func (ps *PostgresStorage) ListRankingProfiles(ctx context.Context) ([]models.RankingProfile, error) {
+	query := `SELECT business_type, field, min_value, boost FROM scoring_boosts ORDER BY business_type, field`
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scoring boosts: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := make(map[string]*models.RankingProfile)
+	profile := func(businessType string) *models.RankingProfile {
+		p, ok := profiles[businessType]
+		if !ok {
+			p = &models.RankingProfile{BusinessType: businessType, Boosts: []models.ScoringBoost{}}
+			profiles[businessType] = p
+		}
+		return p
+	}
+	for rows.Next() {
+		var b models.ScoringBoost
+		if err := rows.Scan(&b.BusinessType, &b.Field, &b.Min, &b.Boost); err != nil {
+			return nil, fmt.Errorf("failed to scan scoring boost: %w", err)
+		}
+		p := profile(b.BusinessType)
+		p.Boosts = append(p.Boosts, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scoring boosts: %w", err)
+	}
+
+	query = `SELECT business_type, description_weight, demographics_weight FROM scoring_vector_weights`
+	weightRows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vector weights: %w", err)
+	}
+	defer weightRows.Close()
+	for weightRows.Next() {
+		var businessType string
+		var weights models.VectorWeights
+		if err := weightRows.Scan(&businessType, &weights.Description, &weights.Demographics); err != nil {
+			return nil, fmt.Errorf("failed to scan vector weights: %w", err)
+		}
+		profile(businessType).VectorWeights = &weights
+	}
+	if err := weightRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating vector weights: %w", err)
+	}
+
+	result := make([]models.RankingProfile, 0, len(profiles))
+	for _, p := range profiles {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].BusinessType < result[j].BusinessType })
+	return result, nil
+}
+
+// PutRankingProfile заменяет профиль ранжирования типа бизнеса в одной транзакции: бусты
+// профиля замещают прежние, а без весов векторного поиска прежние веса удаляются.
+func (ps *PostgresStorage) PutRankingProfile(ctx context.Context, profile *models.RankingProfile) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scoring_boosts WHERE business_type = $1`, profile.BusinessType); err != nil {
+		return fmt.Errorf("failed to delete scoring boosts: %w", err)
+	}
+	for _, b := range profile.Boosts {
+		query := `INSERT INTO scoring_boosts (business_type, field, min_value, boost) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.ExecContext(ctx, query, profile.BusinessType, b.Field, b.Min, b.Boost); err != nil {
+			return fmt.Errorf("failed to save scoring boost: %w", err)
+		}
+	}
+
+	if profile.VectorWeights == nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM scoring_vector_weights WHERE business_type = $1`, profile.BusinessType); err != nil {
+			return fmt.Errorf("failed to delete vector weights: %w", err)
+		}
+	} else {
+		query := `INSERT INTO scoring_vector_weights (business_type, description_weight, demographics_weight)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (business_type) DO UPDATE
+			SET description_weight = EXCLUDED.description_weight, demographics_weight = EXCLUDED.demographics_weight`
+		weights := profile.VectorWeights
+		if _, err := tx.ExecContext(ctx, query, profile.BusinessType, weights.Description, weights.Demographics); err != nil {
+			return fmt.Errorf("failed to save vector weights: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// RecommendTemplateName — имя шаблона поиска рекомендаций локаций.
+const RecommendTemplateName = "recommend_locations"
+
+// DefaultRecommendTemplate — шаблон mustache, повторяющий встроенный запрос рекомендаций.
+// Параметры шаблона формирует recommendTemplateParams.
+//
+//go:embed templates/recommend_locations.mustache
+var DefaultRecommendTemplate string
+
+var (
+	// ErrSearchTemplateNotFound возвращается, если версия шаблона поиска отсутствует.
+	ErrSearchTemplateNotFound = errors.New("search template not found")
+	// ErrInvalidSearchTemplate возвращается, если кластер отклонил шаблон поиска.
+	ErrInvalidSearchTemplate = errors.New("invalid search template")
+)
+
+const searchTemplateColumns = `name, version, source, description, active, created_at, activated_at`
+
+// SearchTemplateID возвращает ID версии шаблона поиска в кластере.
+func SearchTemplateID(name string, version int) string {
+	return fmt.Sprintf("%s-v%d", name, version)
+}
+
+// SetRecommendTemplate переключает поиск рекомендаций на шаблон с ID id, сохраненный в кластере;
+// пустой id возвращает встроенный запрос.
+func (es *ElasticsearchStorage) SetRecommendTemplate(id string) {
+	es.recommendTemplate.Store(&id)
+}
+
+// RecommendTemplate возвращает ID шаблона поиска рекомендаций или пустую строку для встроенного запроса.
+func (es *ElasticsearchStorage) RecommendTemplate() string {
+	if id := es.recommendTemplate.Load(); id != nil {
+		return *id
+	}
+	return ""
+}
+
+// recommendTemplateFor возвращает ID шаблона поиска для запроса req или пустую строку, если
+// запрос выполняется встроенным запросом. Векторный и сезонный режимы и текст намерения меняют
+// оценку поверх запроса, а расчет на дату (as_of) — скрипт фактора демографии; шаблоном они
+// не поддерживаются.
+func (es *ElasticsearchStorage) recommendTemplateFor(req *models.RecommendRequest) string {
+	id := es.recommendTemplate.Load()
+	if id == nil || req.UseEmbedding || req.Intent != "" || req.TargetMonth > 0 || req.AsOf != nil {
+		return ""
+	}
+	return *id
+}
+
+// recommendTemplateParams возвращает параметры шаблона поиска рекомендаций: фильтры и бусты
+// в виде готовых условий запроса (filters, boosts), веса факторов ранжирования после
+// нормализации (weights), размер страницы и значения search_after, а также исходные
+// параметры запроса для шаблонов, строящих условия самостоятельно.
+func recommendTemplateParams(req *models.RecommendRequest) map[string]interface{} {
+	weights := scaledWeights(req.Weights, distanceOrigin(req) != nil)
+	params := map[string]interface{}{
+		"region":         req.Region,
+		"city":           req.City,
+		"business_type":  req.BusinessType,
+		"business_types": req.BusinessTypes,
+		"filters":        searchFilters(req),
+		"boosts":         recommendBoosts(req),
+		"weights": map[string]interface{}{
+			"traffic":      weights.Traffic,
+			"competition":  weights.Competition,
+			"demographics": weights.Demographics,
+			"distance":     weights.Distance,
+		},
+		"competition_scale": competitionDecayScale,
+		"distance_scale":    distanceScale(req),
+		"population_pivot":  populationDensityPivot,
+		"size":              req.Limit,
+		"paged":             len(req.SearchAfter) > 0,
+		"search_after":      req.SearchAfter,
+	}
+	if origin := distanceOrigin(req); origin != nil {
+		params["origin"] = map[string]interface{}{"lat": origin.Lat, "lon": origin.Lon}
+	}
+	return params
+}
+
+// PutSearchTemplate сохраняет шаблон mustache source в кластере под ID id.
+// Возвращает ErrInvalidSearchTemplate, если кластер не смог разобрать шаблон.
+func (es *ElasticsearchStorage) PutSearchTemplate(ctx context.Context, id, source string) error {
+	body := map[string]interface{}{
+		"script": map[string]interface{}{"lang": "mustache", "source": source},
+	}
+	url := fmt.Sprintf("%s/_scripts/%s", es.baseURL, id)
+	if err := es.postTemplate(ctx, "PUT", url, body, nil); err != nil {
+		return fmt.Errorf("failed to store search template %s: %w", id, err)
+	}
+	return nil
+}
+
+// RenderSearchTemplate подставляет в шаблон source параметры запроса req и возвращает
+// получившийся запрос. Возвращает ErrInvalidSearchTemplate, если шаблон не удалось
+// отрисовать или результат не является JSON.
+func (es *ElasticsearchStorage) RenderSearchTemplate(ctx context.Context, source string, req *models.RecommendRequest) (json.RawMessage, error) {
+	if req.Limit == 0 {
+		req.Limit = 20 // Значение по умолчанию, как во встроенном запросе
+	}
+	body := map[string]interface{}{
+		"source": source,
+		"params": recommendTemplateParams(req),
+	}
+
+	var result struct {
+		Output json.RawMessage `json:"template_output"`
+	}
+	if err := es.postTemplate(ctx, "POST", es.baseURL+"/_render/template", body, &result); err != nil {
+		return nil, fmt.Errorf("failed to render search template: %w", err)
+	}
+	return result.Output, nil
+}
+
+// postTemplate отправляет запрос API шаблонов и декодирует ответ в target, если он задан.
+// Ответ 400 означает ошибку в шаблоне и возвращается как ErrInvalidSearchTemplate.
+func (es *ElasticsearchStorage) postTemplate(ctx context.Context, method, url string, body, target interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("%w: %s", ErrInvalidSearchTemplate, string(body))
+	}
+	if res.StatusCode >= 400 {
+		return newESError("error requesting "+url, res.StatusCode, res.Body)
+	}
+
+	if target == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// CreateSearchTemplate сохраняет новую версию шаблона поиска t.Name без активации.
+// Номер версии назначается следующим после последнего и записывается в t.Version.
+func (ps *PostgresStorage) CreateSearchTemplate(ctx context.Context, t *models.SearchTemplate) error {
+	query := `INSERT INTO search_templates (name, version, source, description)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3 FROM search_templates WHERE name = $1
+		RETURNING version, created_at`
+
+	if err := ps.db.QueryRowContext(ctx, query, t.Name, t.Source, t.Description).Scan(&t.Version, &t.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert search template: %w", err)
+	}
+	return nil
+}
+
+// GetSearchTemplate возвращает версию шаблона поиска или ErrSearchTemplateNotFound.
+func (ps *PostgresStorage) GetSearchTemplate(ctx context.Context, name string, version int) (*models.SearchTemplate, error) {
+	query := `SELECT ` + searchTemplateColumns + ` FROM search_templates WHERE name = $1 AND version = $2`
+
+	t, err := scanSearchTemplate(ps.db.QueryRowContext(ctx, query, name, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSearchTemplateNotFound
+	}
+	return t, err
+}
+
+// ActiveSearchTemplate возвращает активную версию шаблона поиска или ErrSearchTemplateNotFound.
+func (ps *PostgresStorage) ActiveSearchTemplate(ctx context.Context, name string) (*models.SearchTemplate, error) {
+	query := `SELECT ` + searchTemplateColumns + ` FROM search_templates WHERE name = $1 AND active`
+
+	t, err := scanSearchTemplate(ps.db.QueryRowContext(ctx, query, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSearchTemplateNotFound
+	}
+	return t, err
+}
+
+// ListSearchTemplates возвращает версии шаблона поиска, начиная с последней.
+func (ps *PostgresStorage) ListSearchTemplates(ctx context.Context, name string) ([]models.SearchTemplate, error) {
+	query := `SELECT ` + searchTemplateColumns + ` FROM search_templates WHERE name = $1 ORDER BY version DESC`
+
+	rows, err := ps.db.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.SearchTemplate{}
+	for rows.Next() {
+		t, err := scanSearchTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return templates, nil
+}
+
+// ActivateSearchTemplate делает версию шаблона поиска активной, снимая признак с предыдущей.
+// Нулевая версия снимает признак со всех версий: поиск возвращается к встроенному запросу.
+func (ps *PostgresStorage) ActivateSearchTemplate(ctx context.Context, name string, version int) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE search_templates SET active = FALSE WHERE name = $1 AND active`, name); err != nil {
+		return fmt.Errorf("failed to deactivate search template: %w", err)
+	}
+
+	if version > 0 {
+		result, err := tx.ExecContext(ctx,
+			`UPDATE search_templates SET active = TRUE, activated_at = CURRENT_TIMESTAMP WHERE name = $1 AND version = $2`,
+			name, version)
+		if err != nil {
+			return fmt.Errorf("failed to activate search template: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return ErrSearchTemplateNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func scanSearchTemplate(row rowScanner) (*models.SearchTemplate, error) {
+	var (
+		t           models.SearchTemplate
+		activatedAt sql.NullTime
+	)
+	if err := row.Scan(&t.Name, &t.Version, &t.Source, &t.Description, &t.Active, &t.CreatedAt, &activatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan search template: %w", err)
+	}
+	if activatedAt.Valid {
+		t.ActivatedAt = &activatedAt.Time
+	}
+	return &t, nil
+}
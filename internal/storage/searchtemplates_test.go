@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// TestDefaultRecommendTemplateMatchesBuiltInQuery отрисовывает встроенный шаблон mustache
+// с параметрами recommendTemplateParams и сравнивает результат со встроенным запросом
+// buildRecommendQuery: изменение одного из них без другого меняет выдачу после активации шаблона.
+func TestDefaultRecommendTemplateMatchesBuiltInQuery(t *testing.T) {
+	eventBoost := true
+	tests := []struct {
+		name string
+		req  models.RecommendRequest
+	}{
+		{"region and business type", models.RecommendRequest{Region: "Moscow", BusinessType: "cafe", Limit: 20}},
+		{"filters and boosts", models.RecommendRequest{
+			Region: "Moscow", City: "Moscow", BusinessTypes: []string{"cafe", "bakery"}, Limit: 10,
+			SafetyWeight: 2, EventBoost: &eventBoost,
+			Boosts: []models.ScoringBoost{{BusinessType: "cafe", Field: "traffic_score", Min: 7, Boost: 1.5}},
+		}},
+		{"origin and radius", models.RecommendRequest{
+			Region: "Moscow", BusinessType: "cafe", Limit: 5,
+			Origin: &models.GeoPoint{Lat: 55.75, Lon: 37.62}, RadiusMeters: 3000,
+			Weights: &models.ScoringWeights{Traffic: 1, Competition: 1, Demographics: 1, Distance: 2},
+		}},
+		{"city center", models.RecommendRequest{
+			Region: "Moscow", BusinessType: "cafe", Limit: 20, CityCenter: &models.GeoPoint{Lat: 55.75, Lon: 37.62},
+		}},
+		{"zero weight", models.RecommendRequest{
+			Region: "Moscow", BusinessType: "cafe", Limit: 20,
+			Weights: &models.ScoringWeights{Traffic: 3, Competition: 0, Demographics: 1},
+		}},
+		{"next page", models.RecommendRequest{
+			Region: "Moscow", BusinessType: "cafe", Limit: 20, SearchAfter: []interface{}{1.25, 7, 0.5, "loc-1"},
+		}},
+	}
+
+	es := &ElasticsearchStorage{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			rendered, err := renderMustache(DefaultRecommendTemplate, recommendTemplateParams(&req))
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			var fromTemplate map[string]interface{}
+			if err := json.Unmarshal([]byte(rendered), &fromTemplate); err != nil {
+				t.Fatalf("rendered template is not JSON: %v\n%s", err, rendered)
+			}
+			// Размер страницы встроенный запрос передает параметром URL
+			if size := fromTemplate["size"]; size != float64(req.Limit) {
+				t.Errorf("size %v, want %d", size, req.Limit)
+			}
+			delete(fromTemplate, "size")
+			// Функции с нулевым весом не влияют на оценку, встроенный запрос их не добавляет
+			functionScore := fromTemplate["query"].(map[string]interface{})["function_score"].(map[string]interface{})
+			var functions []interface{}
+			for _, f := range functionScore["functions"].([]interface{}) {
+				if f.(map[string]interface{})["weight"] != float64(0) {
+					functions = append(functions, f)
+				}
+			}
+			functionScore["functions"] = functions
+
+			builtIn, err := json.Marshal(es.buildRecommendQuery(&req))
+			if err != nil {
+				t.Fatalf("encode built-in query: %v", err)
+			}
+			var fromBuiltIn map[string]interface{}
+			if err := json.Unmarshal(builtIn, &fromBuiltIn); err != nil {
+				t.Fatalf("decode built-in query: %v", err)
+			}
+
+			got, want := normalizeScripts(fromTemplate), normalizeScripts(fromBuiltIn)
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(want, "", "  ")
+				t.Errorf("template query differs from built-in query\ntemplate: %s\nbuilt-in: %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// normalizeScripts схлопывает пробельные символы в строках: шаблон хранит скрипты Painless
+// в одну строку, встроенный запрос — с переносами.
+func normalizeScripts(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeScripts(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeScripts(value)
+		}
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	}
+	return v
+}
+
+// renderMustache отрисовывает подмножество mustache, которое использует шаблон рекомендаций:
+// переменные с точечными именами, секции по значениям и объектам и функцию toJson Elasticsearch.
+func renderMustache(source string, params map[string]interface{}) (string, error) {
+	return renderSection(source, []interface{}{params})
+}
+
+func renderSection(source string, stack []interface{}) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.Index(source, "{{")
+		if start < 0 {
+			out.WriteString(source)
+			return out.String(), nil
+		}
+		out.WriteString(source[:start])
+		end := strings.Index(source[start:], "}}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed tag at %d", start)
+		}
+		tag := strings.TrimSpace(source[start+2 : start+end])
+		source = source[start+end+2:]
+
+		if !strings.HasPrefix(tag, "#") {
+			out.WriteString(fmt.Sprint(lookupMustache(stack, tag)))
+			continue
+		}
+
+		name := tag[1:]
+		closing := "{{/" + name + "}}"
+		closeAt := strings.Index(source, closing)
+		if closeAt < 0 {
+			return "", fmt.Errorf("unclosed section %q", name)
+		}
+		inner := source[:closeAt]
+		source = source[closeAt+len(closing):]
+
+		if name == "toJson" {
+			data, err := json.Marshal(lookupMustache(stack, strings.TrimSpace(inner)))
+			if err != nil {
+				return "", err
+			}
+			out.Write(data)
+			continue
+		}
+		value := lookupMustache(stack, name)
+		switch v := value.(type) {
+		case nil, bool:
+			if v == true {
+				rendered, err := renderSection(inner, stack)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(rendered)
+			}
+		case map[string]interface{}:
+			rendered, err := renderSection(inner, append(stack, v))
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(rendered)
+		default:
+			return "", fmt.Errorf("unsupported section value %T for %q", value, name)
+		}
+	}
+}
+
+// lookupMustache ищет имя name (через точку — во вложенных объектах), начиная с внутренней секции.
+func lookupMustache(stack []interface{}, name string) interface{} {
+	parts := strings.Split(name, ".")
+	for i := len(stack) - 1; i >= 0; i-- {
+		scope, ok := stack[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := scope[parts[0]]
+		if !ok {
+			continue
+		}
+		for _, part := range parts[1:] {
+			nested, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = nested[part]
+		}
+		return value
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// UpsertSeasonalCoefficients сохраняет коэффициенты сезонности в одной транзакции,
+// заменяя существующие значения для тех же города, типа бизнеса и месяца.
+func (ps *PostgresStorage) UpsertSeasonalCoefficients(ctx context.Context, coefficients []models.SeasonalCoefficient) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO seasonality_coefficients (city, business_type, month, coefficient)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (business_type, month, city)
+		DO UPDATE SET coefficient = EXCLUDED.coefficient, updated_at = CURRENT_TIMESTAMP`
+
+	for _, c := range coefficients {
+		if _, err := tx.ExecContext(ctx, query, c.City, c.BusinessType, c.Month, c.Coefficient); err != nil {
+			return fmt.Errorf("failed to upsert seasonal coefficient: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetSeasonalCoefficients возвращает коэффициенты сезонности типа бизнеса на месяц.
+// Если общий коэффициент (пустой город) не задан, по умолчанию используется 1.
+func (ps *PostgresStorage) GetSeasonalCoefficients(ctx context.Context, businessType string, month int) (*models.SeasonalCoefficients, error) {
+	query := `SELECT city, coefficient FROM seasonality_coefficients WHERE business_type = $1 AND month = $2`
+
+	rows, err := ps.db.QueryContext(ctx, query, businessType, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query seasonal coefficients: %w", err)
+	}
+	defer rows.Close()
+
+	result := &models.SeasonalCoefficients{Default: 1, ByCity: make(map[string]float64)}
+	for rows.Next() {
+		var city string
+		var coefficient float64
+		if err := rows.Scan(&city, &coefficient); err != nil {
+			return nil, fmt.Errorf("failed to scan seasonal coefficient: %w", err)
+		}
+		if city == "" {
+			result.Default = coefficient
+			continue
+		}
+		result.ByCity[city] = coefficient
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating seasonal coefficients: %w", err)
+	}
+
+	return result, nil
+}
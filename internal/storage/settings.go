@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// tierPreferenceSetting — настройка предпочтительных уровней узлов данных индекса.
+const tierPreferenceSetting = "index.routing.allocation.include._tier_preference"
+
+// GetIndexSettings возвращает настройки размещения индекса локаций, с archived — архивного индекса.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) GetIndexSettings(ctx context.Context, archived bool) (*models.IndexSettings, error) {
+	index := es.settingsIndex(archived)
+	url := fmt.Sprintf("%s/%s/_settings?flat_settings=true&include_defaults=true", es.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, newESError("error getting index settings", res.StatusCode, res.Body)
+	}
+
+	var result map[string]indexSettingsEntry
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Ответ содержит один индекс; его имя может отличаться от запрошенного алиаса
+	for name, entry := range result {
+		settings := &models.IndexSettings{Index: name}
+		shards, _ := entry.value("index.number_of_shards")
+		settings.NumberOfShards, _ = strconv.Atoi(shards)
+		if replicas, ok := entry.value("index.number_of_replicas"); ok {
+			if n, err := strconv.Atoi(replicas); err == nil {
+				settings.NumberOfReplicas = &n
+			}
+		}
+		// В OpenSearch уровней узлов нет, настройка отсутствует
+		if tier, ok := entry.value(tierPreferenceSetting); ok {
+			settings.TierPreference = &tier
+		}
+		return settings, nil
+	}
+
+	return nil, fmt.Errorf("index %s not found in settings response", index)
+}
+
+// indexSettingsEntry — настройки одного индекса в ответе _settings?flat_settings=true.
+// Значения декодируются как произвольный JSON: среди настроек, особенно значений по умолчанию,
+// встречаются массивы и объекты, а используются только скалярные настройки.
+type indexSettingsEntry struct {
+	Settings map[string]interface{} `json:"settings"`
+	Defaults map[string]interface{} `json:"defaults"`
+}
+
+// value возвращает явно заданное, а без него — значение по умолчанию скалярной настройки key
+// в виде строки. ok = false, если настройки нет или она не скалярная.
+func (e indexSettingsEntry) value(key string) (string, bool) {
+	if v, ok := scalarSetting(e.Settings, key); ok {
+		return v, true
+	}
+	return scalarSetting(e.Defaults, key)
+}
+
+// scalarSetting возвращает скалярную настройку key из settings в виде строки.
+func scalarSetting(settings map[string]interface{}, key string) (string, bool) {
+	switch v := settings[key].(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// UpdateIndexSettings изменяет число реплик и предпочтительные уровни узлов индекса локаций,
+// с archived — архивного индекса. Пустой TierPreference сбрасывает настройку к значению
+// по умолчанию кластера. Кластер переносит шарды на узлы нужного уровня в фоне.
+func (es *ElasticsearchStorage) UpdateIndexSettings(ctx context.Context, archived bool, settings *models.IndexSettings) error {
+	body := map[string]interface{}{}
+	if settings.NumberOfReplicas != nil {
+		body["index.number_of_replicas"] = *settings.NumberOfReplicas
+	}
+	if settings.TierPreference != nil {
+		if *settings.TierPreference == "" {
+			body[tierPreferenceSetting] = nil
+		} else {
+			body[tierPreferenceSetting] = *settings.TierPreference
+		}
+	}
+
+	return es.putIndexSettings(ctx, es.settingsIndex(archived), body)
+}
+
+// putIndexSettings изменяет динамические настройки индекса index (ключи в плоском виде).
+func (es *ElasticsearchStorage) putIndexSettings(ctx context.Context, index string, settings map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(settings); err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_settings", es.baseURL, index)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return newESError("error updating index settings", res.StatusCode, res.Body)
+	}
+
+	return nil
+}
+
+func (es *ElasticsearchStorage) settingsIndex(archived bool) string {
+	if archived {
+		return es.archive
+	}
+	return es.index
+}
+
+// BulkLoadSettings — настройки индекса локаций, сохраненные перед массовой загрузкой.
+// nil означает, что настройка не была задана явно и действовало значение по умолчанию.
+type BulkLoadSettings struct {
+	RefreshInterval *string
+	Replicas        *string
+}
+
+// PrepareBulkLoad отключает периодическое обновление и реплики индекса локаций на время массовой
+// загрузки (refresh_interval=-1, number_of_replicas=0) и возвращает прежние настройки
+// для FinishBulkLoad. Без обновлений и репликации запись идет в несколько раз быстрее,
+// но загружаемые документы не видны поиску до завершения.
+func (es *ElasticsearchStorage) PrepareBulkLoad(ctx context.Context) (*BulkLoadSettings, error) {
+	var result map[string]indexSettingsEntry
+	url := fmt.Sprintf("%s/%s/_settings?flat_settings=true", es.baseURL, es.index)
+	if err := es.getJSON(ctx, url, &result); err != nil {
+		return nil, fmt.Errorf("failed to get index settings: %w", err)
+	}
+
+	saved := &BulkLoadSettings{}
+	for _, entry := range result {
+		if value, ok := scalarSetting(entry.Settings, "index.refresh_interval"); ok {
+			saved.RefreshInterval = &value
+		}
+		if value, ok := scalarSetting(entry.Settings, "index.number_of_replicas"); ok {
+			saved.Replicas = &value
+		}
+	}
+
+	err := es.putIndexSettings(ctx, es.index, map[string]interface{}{
+		"index.refresh_interval":   "-1",
+		"index.number_of_replicas": 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// FinishBulkLoad восстанавливает настройки, сохраненные PrepareBulkLoad, и принудительно
+// обновляет индекс, чтобы загруженные документы сразу стали доступны поиску.
+func (es *ElasticsearchStorage) FinishBulkLoad(ctx context.Context, saved *BulkLoadSettings) error {
+	// null возвращает настройке значение по умолчанию
+	restore := map[string]interface{}{
+		"index.refresh_interval":   nil,
+		"index.number_of_replicas": nil,
+	}
+	if saved.RefreshInterval != nil {
+		restore["index.refresh_interval"] = *saved.RefreshInterval
+	}
+	if saved.Replicas != nil {
+		restore["index.number_of_replicas"] = *saved.Replicas
+	}
+	if err := es.putIndexSettings(ctx, es.index, restore); err != nil {
+		return err
+	}
+
+	return es.Refresh(ctx)
+}
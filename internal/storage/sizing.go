@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// reindexPollInterval — интервал опроса задачи переиндексации.
+const reindexPollInterval = 5 * time.Second
+
+// IndexStats содержит объем первичных шардов индекса.
+type IndexStats struct {
+	Docs       int64 // Число документов
+	StoreBytes int64 // Размер на диске, байты
+}
+
+// GetIndexStats возвращает объем первичных шардов индекса локаций.
+// Использует прямой HTTP запрос для совместимости с OpenSearch.
+func (es *ElasticsearchStorage) GetIndexStats(ctx context.Context) (*IndexStats, error) {
+	var result struct {
+		All struct {
+			Primaries struct {
+				Docs struct {
+					Count int64 `json:"count"`
+				} `json:"docs"`
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	if err := es.getJSON(ctx, fmt.Sprintf("%s/%s/_stats/docs,store", es.baseURL, es.index), &result); err != nil {
+		return nil, fmt.Errorf("failed to get index stats: %w", err)
+	}
+
+	return &IndexStats{
+		Docs:       result.All.Primaries.Docs.Count,
+		StoreBytes: result.All.Primaries.Store.SizeInBytes,
+	}, nil
+}
+
+// DataNodeCount возвращает число узлов данных кластера.
+func (es *ElasticsearchStorage) DataNodeCount(ctx context.Context) (int, error) {
+	var result struct {
+		DataNodes int `json:"number_of_data_nodes"`
+	}
+	if err := es.getJSON(ctx, es.baseURL+"/_cluster/health", &result); err != nil {
+		return 0, fmt.Errorf("failed to get cluster health: %w", err)
+	}
+	return result.DataNodes, nil
+}
+
+// CreateIndexWithSettings создает индекс name с маппингом mappingJSON и настройками settings
+// (ключи в плоском виде, например "index.number_of_shards"). Существующий индекс считается ошибкой:
+// статические настройки, такие как число шардов, к нему уже не применить.
+func (es *ElasticsearchStorage) CreateIndexWithSettings(ctx context.Context, name, mappingJSON string, settings map[string]interface{}) error {
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(mappingJSON), &body); err != nil {
+		return fmt.Errorf("failed to parse mapping: %w", err)
+	}
+	body["settings"] = settings
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode index body: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s", es.baseURL, name)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create index %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return newESError("error creating index "+name, res.StatusCode, res.Body)
+	}
+
+	return nil
+}
+
+// SetIndexSettings изменяет динамические настройки индекса name, например refresh_interval
+// или число реплик (ключи в плоском виде).
+func (es *ElasticsearchStorage) SetIndexSettings(ctx context.Context, name string, settings map[string]interface{}) error {
+	return es.putIndexSettings(ctx, name, settings)
+}
+
+// ReindexTo копирует документы индекса локаций в индекс dest через Reindex API и ожидает
+// завершения задачи, сообщая прогресс в лог. Возвращает число скопированных документов.
+func (es *ElasticsearchStorage) ReindexTo(ctx context.Context, dest string) (int64, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"source": map[string]interface{}{"index": es.index},
+		"dest":   map[string]interface{}{"index": dest},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode reindex request: %w", err)
+	}
+
+	// Задача выполняется в кластере асинхронно: долгая переиндексация не упирается в таймауты HTTP
+	url := fmt.Sprintf("%s/_reindex?wait_for_completion=false", es.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start reindex: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return 0, newESError("error starting reindex", res.StatusCode, res.Body)
+	}
+
+	var started struct {
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&started); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	ticker := time.NewTicker(reindexPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+
+		var task struct {
+			Completed bool `json:"completed"`
+			Task      struct {
+				Status struct {
+					Total   int64 `json:"total"`
+					Created int64 `json:"created"`
+					Updated int64 `json:"updated"`
+				} `json:"status"`
+			} `json:"task"`
+			Error    json.RawMessage `json:"error"`
+			Response struct {
+				Failures []json.RawMessage `json:"failures"`
+			} `json:"response"`
+		}
+		if err := es.getJSON(ctx, fmt.Sprintf("%s/_tasks/%s", es.baseURL, started.Task), &task); err != nil {
+			return 0, fmt.Errorf("failed to get reindex task %s: %w", started.Task, err)
+		}
+
+		status := task.Task.Status
+		copied := status.Created + status.Updated
+		if !task.Completed {
+			slog.InfoContext(ctx, "Reindex in progress", slog.String("dest", dest), slog.Int64("copied", copied), slog.Int64("total", status.Total))
+			continue
+		}
+		if len(task.Error) > 0 {
+			return copied, fmt.Errorf("reindex task %s failed: %s", started.Task, string(task.Error))
+		}
+		if len(task.Response.Failures) > 0 {
+			return copied, fmt.Errorf("reindex task %s: %d documents failed, first: %s",
+				started.Task, len(task.Response.Failures), string(task.Response.Failures[0]))
+		}
+		return copied, nil
+	}
+}
+
+// getJSON выполняет GET запрос и декодирует JSON ответ в target.
+func (es *ElasticsearchStorage) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := es.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return newESError("error requesting "+url, res.StatusCode, res.Body)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ErrSubstituteNotFound возвращается, если связь замещаемости типов бизнеса отсутствует.
+var ErrSubstituteNotFound = errors.New("business type substitute not found")
+
+// UpsertBusinessTypeSubstitute создает связь замещаемости или обновляет долю существующей.
+func (ps *PostgresStorage) UpsertBusinessTypeSubstitute(ctx context.Context, sub *models.BusinessTypeSubstitute) error {
+	query := `INSERT INTO business_type_substitutes (business_type, substitute, weight)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (business_type, substitute) DO UPDATE SET weight = EXCLUDED.weight, updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at`
+
+	err := ps.db.QueryRowContext(ctx, query, sub.BusinessType, sub.Substitute, sub.Weight).
+		Scan(&sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert business type substitute: %w", err)
+	}
+
+	return nil
+}
+
+// ListBusinessTypeSubstitutes возвращает связи замещаемости типа бизнеса businessType
+// в порядке убывания доли, а при пустом businessType — все связи.
+func (ps *PostgresStorage) ListBusinessTypeSubstitutes(ctx context.Context, businessType string) ([]models.BusinessTypeSubstitute, error) {
+	query := `SELECT business_type, substitute, weight, created_at, updated_at FROM business_type_substitutes
+		WHERE $1 = '' OR business_type = $1
+		ORDER BY business_type, weight DESC, substitute`
+
+	rows, err := ps.db.QueryContext(ctx, query, businessType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query business type substitutes: %w", err)
+	}
+	defer rows.Close()
+
+	var substitutes []models.BusinessTypeSubstitute
+	for rows.Next() {
+		var sub models.BusinessTypeSubstitute
+		if err := rows.Scan(&sub.BusinessType, &sub.Substitute, &sub.Weight, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan business type substitute: %w", err)
+		}
+		substitutes = append(substitutes, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return substitutes, nil
+}
+
+// DeleteBusinessTypeSubstitute удаляет связь замещаемости. Возвращает ErrSubstituteNotFound, если ее нет.
+func (ps *PostgresStorage) DeleteBusinessTypeSubstitute(ctx context.Context, businessType, substitute string) error {
+	res, err := ps.db.ExecContext(ctx, `DELETE FROM business_type_substitutes WHERE business_type = $1 AND substitute = $2`,
+		businessType, substitute)
+	if err != nil {
+		return fmt.Errorf("failed to delete business type substitute: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrSubstituteNotFound
+	}
+	return nil
+}
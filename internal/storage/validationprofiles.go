@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ErrValidationProfileNotFound возвращается, если профиль проверки типа бизнеса не задан.
+var ErrValidationProfileNotFound = errors.New("validation profile not found")
+
+// ListValidationProfiles возвращает профили проверки локаций в порядке типов бизнеса.
+func (ps *PostgresStorage) ListValidationProfiles(ctx context.Context) ([]models.ValidationProfile, error) {
+	query := `SELECT business_type, required, ranges, created_at, updated_at FROM validation_profiles
+		ORDER BY business_type`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query validation profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []models.ValidationProfile
+	for rows.Next() {
+		profile, err := scanValidationProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating validation profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// GetValidationProfile возвращает профиль проверки типа бизнеса или ErrValidationProfileNotFound.
+func (ps *PostgresStorage) GetValidationProfile(ctx context.Context, businessType string) (*models.ValidationProfile, error) {
+	query := `SELECT business_type, required, ranges, created_at, updated_at FROM validation_profiles
+		WHERE business_type = $1`
+
+	profile, err := scanValidationProfile(ps.db.QueryRowContext(ctx, query, businessType))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrValidationProfileNotFound
+	}
+	return profile, err
+}
+
+// PutValidationProfile сохраняет профиль проверки типа бизнеса, заменяя прежний,
+// и записывает время создания и обновления в profile.
+func (ps *PostgresStorage) PutValidationProfile(ctx context.Context, profile *models.ValidationProfile) error {
+	if profile.Required == nil {
+		profile.Required = []string{}
+	}
+	if profile.Ranges == nil {
+		profile.Ranges = []models.FieldRange{}
+	}
+	required, err := json.Marshal(profile.Required)
+	if err != nil {
+		return fmt.Errorf("failed to marshal required fields: %w", err)
+	}
+	ranges, err := json.Marshal(profile.Ranges)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ranges: %w", err)
+	}
+
+	query := `INSERT INTO validation_profiles (business_type, required, ranges)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (business_type) DO UPDATE
+		SET required = EXCLUDED.required, ranges = EXCLUDED.ranges, updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at`
+
+	err = ps.db.QueryRowContext(ctx, query, profile.BusinessType, required, ranges).Scan(&profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save validation profile: %w", err)
+	}
+	return nil
+}
+
+// DeleteValidationProfile удаляет профиль проверки типа бизнеса.
+// Возвращает ErrValidationProfileNotFound, если его нет.
+func (ps *PostgresStorage) DeleteValidationProfile(ctx context.Context, businessType string) error {
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM validation_profiles WHERE business_type = $1`, businessType)
+	if err != nil {
+		return fmt.Errorf("failed to delete validation profile: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrValidationProfileNotFound
+	}
+	return nil
+}
+
+// scanValidationProfile читает строку validation_profiles.
+func scanValidationProfile(row rowScanner) (*models.ValidationProfile, error) {
+	var profile models.ValidationProfile
+	var required, ranges []byte
+	err := row.Scan(&profile.BusinessType, &required, &ranges, &profile.CreatedAt, &profile.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan validation profile: %w", err)
+	}
+	if err := json.Unmarshal(required, &profile.Required); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal required fields: %w", err)
+	}
+	if err := json.Unmarshal(ranges, &profile.Ranges); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ranges: %w", err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akozadaev/go_es_analytical_system/internal/models"
+)
+
+// ReplaceEventVenues заменяет справочник площадок мероприятий в одной транзакции.
+func (ps *PostgresStorage) ReplaceEventVenues(ctx context.Context, venues []models.EventVenue) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_venues`); err != nil {
+		return fmt.Errorf("failed to clear event venues: %w", err)
+	}
+
+	query := `INSERT INTO event_venues (name, kind, lat, lon, events_per_year, capacity) VALUES ($1, $2, $3, $4, $5, $6)`
+	for _, v := range venues {
+		if _, err := tx.ExecContext(ctx, query, v.Name, v.Kind, v.Coordinates.Lat, v.Coordinates.Lon, v.EventsPerYear, v.Capacity); err != nil {
+			return fmt.Errorf("failed to insert event venue: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListEventVenues возвращает все площадки мероприятий.
+func (ps *PostgresStorage) ListEventVenues(ctx context.Context) ([]models.EventVenue, error) {
+	query := `SELECT id, name, kind, lat, lon, events_per_year, capacity FROM event_venues ORDER BY id`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event venues: %w", err)
+	}
+	defer rows.Close()
+
+	var venues []models.EventVenue
+	for rows.Next() {
+		var v models.EventVenue
+		if err := rows.Scan(&v.ID, &v.Name, &v.Kind, &v.Coordinates.Lat, &v.Coordinates.Lon, &v.EventsPerYear, &v.Capacity); err != nil {
+			return nil, fmt.Errorf("failed to scan event venue: %w", err)
+		}
+		venues = append(venues, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event venues: %w", err)
+	}
+
+	return venues, nil
+}
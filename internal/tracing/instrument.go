@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+
+	"github.com/XSAM/otelsql"
+	"github.com/akozadaev/go_es_analytical_system/internal/metrics"
+	"github.com/akozadaev/go_es_analytical_system/internal/sqlhook"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler создает span каждого запроса с именем "метод шаблон_пути" и передает его в контексте
+// обработчикам, запросам к Elasticsearch и PostgreSQL. Идентификатор записываемой трассы
+// возвращается в заголовке X-Trace-Id. routeName возвращает шаблон пути запроса.
+func (t *Tracer) Handler(next http.Handler, routeName func(*http.Request) string) http.Handler {
+	withRoute := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(routeName(r)))
+		if traceID := TraceID(r.Context()); traceID != "" {
+			w.Header().Set("X-Trace-Id", traceID)
+		}
+		next.ServeHTTP(w, r)
+	})
+	return otelhttp.NewHandler(withRoute, "http.request",
+		otelhttp.WithTracerProvider(t.provider),
+		otelhttp.WithPropagators(propagator),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + routeName(r)
+		}),
+	)
+}
+
+// Transport возвращает http.RoundTripper, создающий span запросов к Elasticsearch внутри span
+// из контекста запроса и передающий traceparent. Запросы без span передаются next без изменений.
+func (t *Tracer) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(operationTransport{next: next},
+		otelhttp.WithTracerProvider(t.provider),
+		otelhttp.WithPropagators(propagator),
+		otelhttp.WithFilter(func(r *http.Request) bool { return traced(r.Context()) }),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "elasticsearch " + metrics.ESOperation(r.URL.Path)
+		}),
+		otelhttp.WithSpanOptions(trace.WithAttributes(semconv.DBSystemElasticsearch)),
+	)
+}
+
+// operationTransport добавляет к span запроса к Elasticsearch операцию (_search, _bulk...).
+type operationTransport struct {
+	next http.RoundTripper
+}
+
+func (t operationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace.SpanFromContext(req.Context()).SetAttributes(semconv.DBOperationName(metrics.ESOperation(req.URL.Path)))
+	return t.next.RoundTrip(req)
+}
+
+// OpenDB открывает пул подключений через connector, создающий span запросов к PostgreSQL
+// внутри span из контекста с текстом запроса в db.statement.
+func (t *Tracer) OpenDB(connector driver.Connector) *sql.DB {
+	return otelsql.OpenDB(connector,
+		otelsql.WithTracerProvider(t.provider),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanNameFormatter(func(_ context.Context, method otelsql.Method, query string) string {
+			if query == "" {
+				return "postgres " + string(method)
+			}
+			return "postgres " + sqlhook.Operation(query)
+		}),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return traced(ctx)
+			},
+		}),
+	)
+}
@@ -0,0 +1,131 @@
+// Package tracing трассирует обработку запросов API на OpenTelemetry SDK: span HTTP запроса
+// (otelhttp) и вложенные span запросов к Elasticsearch (otelhttp) и PostgreSQL (otelsql)
+// отправляются коллектору по OTLP/HTTP.
+//
+// Контекст трассировки передается заголовком W3C traceparent: span входящего запроса продолжает
+// трассу вызывающего сервиса, а запросы к Elasticsearch несут traceparent своего span. Span
+// создаются только внутри span запроса API: фоновые задания не трассируются.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName — имя инструментирования span, создаваемых приложением.
+const instrumentationName = "github.com/akozadaev/go_es_analytical_system"
+
+// shutdownTimeout ограничивает отправку накопленных span при остановке.
+const shutdownTimeout = 10 * time.Second
+
+// propagator передает контекст трассировки заголовком W3C traceparent.
+var propagator = propagation.TraceContext{}
+
+// Config содержит параметры трассировки.
+type Config struct {
+	Endpoint    string            // Адрес OTLP/HTTP коллектора, например http://otel-collector:4318
+	Headers     map[string]string // Заголовки запросов к коллектору (например, авторизация)
+	ServiceName string            // Значение service.name
+	Version     string            // Значение service.version
+	SampleRatio float64           // Доля трасс, начатых сервисом, которые записываются (0–1)
+}
+
+// ParseHeaders разбирает заголовки запросов к коллектору вида "ключ=значение"
+// (формат OTEL_EXPORTER_OTLP_HEADERS).
+func ParseHeaders(entries []string) (map[string]string, error) {
+	headers := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q: expected key=value", entry)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// Tracer — поставщик span OpenTelemetry SDK, отправляющий записанные трассы коллектору.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+}
+
+// New создает трассировщик и запускает отправку span коллектору cfg.Endpoint пачками в фоне.
+// Трассы, начатые сервисом, записываются с долей cfg.SampleRatio; трассы вызывающего сервиса
+// наследуют его решение о записи.
+func New(cfg Config) (*Tracer, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: expected http(s)://host:port", cfg.Endpoint)
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.Endpoint, "/")+"/v1/traces"),
+		otlptracehttp.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(cfg.Version),
+		)),
+	)
+	return &Tracer{provider: provider}, nil
+}
+
+// Close отправляет накопленные span и останавливает экспорт.
+func (t *Tracer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return t.provider.Shutdown(ctx)
+}
+
+// Start начинает вложенный span текущего span контекста. Если в ctx нет span (трассировка
+// отключена или операция выполняется вне запроса API), возвращает ctx и span, который ничего не записывает.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return ctx, parent
+	}
+	return parent.TracerProvider().Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// SetError отмечает операцию span как завершившуюся ошибкой err (nil не меняет статус).
+func SetError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceID возвращает идентификатор записываемой трассы контекста в шестнадцатеричном виде
+// или пустую строку, если трасса не записывается.
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsSampled() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// traced сообщает, выполняется ли операция внутри span запроса API.
+func traced(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
@@ -0,0 +1,170 @@
+// Package webhook защищает исходящие уведомления на адреса, заданные клиентами API
+// (callback_url асинхронной индексации, webhook_url сохраненных поисков), от SSRF:
+// адреса, которые разрешаются во внутреннюю сеть, loopback, link-local (в том числе
+// адреса метаданных облака) и другие служебные диапазоны, отклоняются как при сохранении
+// адреса, так и при каждом соединении во время доставки.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress возвращается для адреса уведомлений во внутренней или служебной сети.
+var ErrBlockedAddress = errors.New("address is not allowed for webhooks")
+
+// blockedPrefixes — служебные диапазоны, не покрытые методами netip.Addr.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "Этот" сегмент сети
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT, в том числе адреса метаданных некоторых облаков
+	netip.MustParsePrefix("192.0.0.0/24"),  // Назначения IETF
+	netip.MustParsePrefix("198.18.0.0/15"), // Тестирование производительности сетей
+	netip.MustParsePrefix("240.0.0.0/4"),   // Зарезервировано
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64: встроенный IPv4 адрес не проверить
+}
+
+// Policy задает, какие адреса допустимы для уведомлений. Нулевое значение запрещает
+// внутренние и служебные адреса.
+type Policy struct {
+	// AllowPrivate разрешает внутренние адреса (для локальной разработки и закрытых контуров)
+	AllowPrivate bool
+}
+
+// Allowed сообщает, допустимо ли соединение с адресом ip.
+func (p Policy) Allowed(ip netip.Addr) bool {
+	if p.AllowPrivate {
+		return true
+	}
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check проверяет адрес уведомлений rawURL: схема http или https и хост, все адреса которого
+// допустимы. Ошибка предназначена для ответа клиенту и не раскрывает разрешенные адреса.
+func (p Policy) Check(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("must be an http(s) URL")
+	}
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	if p.AllowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !p.Allowed(ip) {
+			return ErrBlockedAddress
+		}
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("cannot resolve host %q", host)
+	}
+	for _, ip := range ips {
+		if !p.Allowed(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+// Client возвращает HTTP клиент для доставки уведомлений с таймаутом timeout. Клиент
+// использует настройки прокси http.DefaultTransport и проверяет адрес каждого соединения,
+// кроме соединений с прокси, поэтому запрещенный адрес не пройдет и через перенаправление
+// или повторное разрешение имени хоста.
+func (p Policy) Client(timeout time.Duration) *http.Client {
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		base = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	transport := base.Clone()
+
+	// Адреса прокси, выбранные для запросов: соединения с ними не проверяются
+	var proxies sync.Map
+	if proxy := transport.Proxy; proxy != nil {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			u, err := proxy(req)
+			if u != nil {
+				proxies.Store(canonicalAddr(u), true)
+			}
+			return u, err
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	guarded := &net.Dialer{
+		Timeout:   dialer.Timeout,
+		KeepAlive: dialer.KeepAlive,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+			}
+			if !p.Allowed(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if _, ok := proxies.Load(address); ok {
+			return dialer.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("stopped after 5 redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// canonicalAddr возвращает адрес host:port прокси u в том виде, в каком его набирает транспорт.
+func canonicalAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,12 @@
+-- Создание таблицы истории запросов рекомендаций
+CREATE TABLE IF NOT EXISTS query_history (
+    id VARCHAR(64) PRIMARY KEY,
+    request JSONB NOT NULL,
+    result_ids JSONB NOT NULL DEFAULT '[]',
+    result_count INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание индексов для оптимизации запросов
+CREATE INDEX IF NOT EXISTS idx_query_history_created_at ON query_history(created_at);
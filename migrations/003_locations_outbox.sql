@@ -0,0 +1,23 @@
+-- Создание таблицы локаций (система-источник истины для записываемых через API локаций)
+CREATE TABLE IF NOT EXISTS locations (
+    id VARCHAR(255) PRIMARY KEY,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы outbox для надежной доставки изменений локаций в Elasticsearch
+CREATE TABLE IF NOT EXISTS location_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    location_id VARCHAR(255) NOT NULL,
+    operation VARCHAR(16) NOT NULL CHECK (operation IN ('upsert', 'delete')),
+    payload JSONB,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP
+);
+
+-- Создание индексов для оптимизации запросов
+CREATE INDEX IF NOT EXISTS idx_location_outbox_pending ON location_outbox(id) WHERE processed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_locations_updated_at ON locations(updated_at);
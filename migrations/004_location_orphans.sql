@@ -0,0 +1,10 @@
+-- Создание таблицы обнаруженных расхождений между PostgreSQL и Elasticsearch.
+-- Расхождение исправляется только если оно обнаружено повторно по истечении grace-периода
+-- (двухфазное удаление), чтобы не удалять документы, запись которых еще не доставлена.
+CREATE TABLE IF NOT EXISTS location_orphans (
+    location_id VARCHAR(255) NOT NULL,
+    side VARCHAR(8) NOT NULL CHECK (side IN ('es', 'pg')),
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (location_id, side)
+);
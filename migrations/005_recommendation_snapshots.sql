@@ -0,0 +1,16 @@
+-- Оценки результатов сохраняются в истории, чтобы выдачу можно было воспроизвести в снимке
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS result_scores JSONB NOT NULL DEFAULT '[]';
+
+-- Создание таблицы неизменяемых снимков выдачи рекомендаций, доступных по публичной ссылке
+CREATE TABLE IF NOT EXISTS recommendation_snapshots (
+    token VARCHAR(64) PRIMARY KEY,
+    query_id VARCHAR(64) NOT NULL REFERENCES query_history(id),
+    request JSONB NOT NULL,
+    locations JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Создание индексов для оптимизации запросов
+CREATE INDEX IF NOT EXISTS idx_recommendation_snapshots_query_id ON recommendation_snapshots(query_id);
+CREATE INDEX IF NOT EXISTS idx_recommendation_snapshots_expires_at ON recommendation_snapshots(expires_at);
@@ -0,0 +1,15 @@
+-- Создание таблицы заметок и оценок локаций пользователями.
+-- Заметки видны только внутри организации автора.
+CREATE TABLE IF NOT EXISTS location_notes (
+    id BIGSERIAL PRIMARY KEY,
+    location_id VARCHAR(255) NOT NULL,
+    organization VARCHAR(255) NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    rating SMALLINT CHECK (rating BETWEEN 1 AND 5),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание индексов для оптимизации запросов
+CREATE INDEX IF NOT EXISTS idx_location_notes_location_org ON location_notes(location_id, organization);
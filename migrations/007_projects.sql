@@ -0,0 +1,29 @@
+-- Создание таблицы проектов подбора локаций.
+-- Проект принадлежит организации и доступен всем ее пользователям.
+CREATE TABLE IF NOT EXISTS projects (
+    id VARCHAR(64) PRIMARY KEY,
+    organization VARCHAR(255) NOT NULL,
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    business_type VARCHAR(100) NOT NULL,
+    region VARCHAR(100) NOT NULL,
+    budget NUMERIC(15, 2),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы локаций-кандидатов проекта
+CREATE TABLE IF NOT EXISTS project_candidates (
+    project_id VARCHAR(64) NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    location_id VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'reviewing'
+        CHECK (status IN ('reviewing', 'visited', 'rejected', 'selected')),
+    comment TEXT NOT NULL DEFAULT '',
+    added_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, location_id)
+);
+
+-- Создание индексов для оптимизации запросов
+CREATE INDEX IF NOT EXISTS idx_projects_organization ON projects(organization);
@@ -0,0 +1,10 @@
+-- Создание таблицы месячных коэффициентов сезонности трафика по городу и типу бизнеса.
+-- Пустой город задает коэффициент для всех городов, для которых нет отдельной записи.
+CREATE TABLE IF NOT EXISTS seasonality_coefficients (
+    city VARCHAR(255) NOT NULL DEFAULT '',
+    business_type VARCHAR(255) NOT NULL,
+    month SMALLINT NOT NULL CHECK (month BETWEEN 1 AND 12),
+    coefficient DOUBLE PRECISION NOT NULL CHECK (coefficient >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (business_type, month, city)
+);
@@ -0,0 +1,17 @@
+-- Создание таблицы площадок мероприятий (стадионы, выставочные центры, концертные залы).
+-- Используется для расчета event_exposure локаций.
+CREATE TABLE IF NOT EXISTS event_venues (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(64) NOT NULL DEFAULT '',
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    events_per_year INTEGER NOT NULL DEFAULT 0 CHECK (events_per_year >= 0),
+    capacity INTEGER NOT NULL DEFAULT 0 CHECK (capacity >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Типы бизнеса, выигрывающие от потока посетителей мероприятий
+ALTER TABLE business_types ADD COLUMN IF NOT EXISTS benefits_from_events BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE business_types SET benefits_from_events = TRUE WHERE name IN ('cafe', 'restaurant');
@@ -0,0 +1,29 @@
+-- Создание таблицы учебных заведений (школы, колледжи, университеты).
+-- Используется для расчета schools_nearby и universities_nearby локаций.
+CREATE TABLE IF NOT EXISTS education_institutions (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('school', 'university')),
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы профилей ранжирования: бустинг числовых полей локации для типа бизнеса.
+-- Локация получает boost, если значение field не меньше min_value.
+CREATE TABLE IF NOT EXISTS scoring_boosts (
+    business_type VARCHAR(255) NOT NULL,
+    field VARCHAR(64) NOT NULL,
+    min_value DOUBLE PRECISION NOT NULL,
+    boost DOUBLE PRECISION NOT NULL CHECK (boost > 0),
+    PRIMARY KEY (business_type, field)
+);
+
+-- Бизнесы, ориентированные на молодежь, выигрывают от близости к учебным заведениям
+INSERT INTO scoring_boosts (business_type, field, min_value, boost) VALUES
+    ('cafe', 'universities_nearby', 1, 1.5),
+    ('barbershop', 'universities_nearby', 1, 1.3),
+    ('gym', 'universities_nearby', 1, 1.3),
+    ('repair_shop', 'universities_nearby', 1, 1.2),
+    ('grocery_store', 'schools_nearby', 2, 1.2)
+ON CONFLICT (business_type, field) DO NOTHING;
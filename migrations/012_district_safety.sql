@@ -0,0 +1,17 @@
+-- Создание таблицы индексов безопасности районов из внешнего датасета (уровень преступности).
+-- Локация относится к району с ближайшим центром в том же городе.
+CREATE TABLE IF NOT EXISTS district_safety (
+    city VARCHAR(255) NOT NULL,
+    district VARCHAR(255) NOT NULL,
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    safety_score DOUBLE PRECISION NOT NULL CHECK (safety_score >= 0 AND safety_score <= 10),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (city, district)
+);
+
+-- Розничные магазины и аптеки работают допоздна, поэтому безопасность района важна для них
+INSERT INTO scoring_boosts (business_type, field, min_value, boost) VALUES
+    ('pharmacy', 'safety_score', 7, 1.3),
+    ('grocery_store', 'safety_score', 7, 1.2)
+ON CONFLICT (business_type, field) DO NOTHING;
@@ -0,0 +1,32 @@
+-- Создание таблицы версий моделей (reranker, footfall, embedding) для реестра моделей.
+-- uri указывает на артефакт: локальный файл, объект в хранилище по HTTP(S) или адрес сервера моделей.
+CREATE TABLE IF NOT EXISTS model_versions (
+    kind VARCHAR(32) NOT NULL,
+    version VARCHAR(64) NOT NULL,
+    format VARCHAR(32) NOT NULL,
+    uri TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP,
+    PRIMARY KEY (kind, version)
+);
+
+-- Для каждого вида активна не более чем одна версия
+CREATE UNIQUE INDEX IF NOT EXISTS idx_model_versions_active ON model_versions(kind) WHERE active;
+
+-- Создание таблицы прогнозов моделей с версией, которая их построила
+CREATE TABLE IF NOT EXISTS model_predictions (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    version VARCHAR(64) NOT NULL,
+    location_id VARCHAR(255),
+    business_type VARCHAR(255) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_model_predictions_version ON model_predictions(kind, version);
+
+-- Версии моделей, участвовавших в ранжировании запроса
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS model_versions JSONB NOT NULL DEFAULT '{}';
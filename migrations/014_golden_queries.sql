@@ -0,0 +1,11 @@
+-- Создание таблицы эталонных запросов для регрессионной проверки релевантности:
+-- ожидаемые локации должны входить в первые top_k результатов выдачи.
+CREATE TABLE IF NOT EXISTS golden_queries (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    request JSONB NOT NULL,
+    expected_ids TEXT[] NOT NULL,
+    top_k INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
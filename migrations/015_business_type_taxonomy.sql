@@ -0,0 +1,44 @@
+-- Таксономия типов бизнеса: категория -> подтип. Локации могут быть пригодны для типа
+-- любого уровня, запрос по категории находит локации всех ее подтипов.
+ALTER TABLE business_types ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES business_types(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_business_types_parent_id ON business_types(parent_id);
+
+INSERT INTO business_types (name, description) VALUES
+    ('food_service', 'Общественное питание'),
+    ('beauty', 'Красота и уход'),
+    ('household_services', 'Бытовые услуги'),
+    ('retail', 'Розничная торговля'),
+    ('sport', 'Спорт и фитнес')
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE business_types child SET parent_id = parent.id
+FROM (VALUES
+    ('cafe', 'food_service'),
+    ('restaurant', 'food_service'),
+    ('beauty_salon', 'beauty'),
+    ('barbershop', 'beauty'),
+    ('repair_shop', 'household_services'),
+    ('tailoring', 'household_services'),
+    ('laundry', 'household_services'),
+    ('pharmacy', 'retail'),
+    ('grocery_store', 'retail'),
+    ('gym', 'sport')
+) AS mapping(child_name, parent_name)
+JOIN business_types parent ON parent.name = mapping.parent_name
+WHERE child.name = mapping.child_name AND child.parent_id IS NULL;
+
+-- Замыкание иерархии: пары (предок, потомок) любой глубины, включая сам тип (depth = 0).
+-- Категория, назначенная подтипу, не должна образовывать цикл: рекурсия ограничена глубиной.
+CREATE OR REPLACE VIEW business_type_closure AS
+WITH RECURSIVE closure (ancestor_id, descendant_id, depth) AS (
+    SELECT id, id, 0 FROM business_types
+    UNION ALL
+    SELECT c.ancestor_id, bt.id, c.depth + 1
+    FROM closure c
+    JOIN business_types bt ON bt.parent_id = c.descendant_id
+    WHERE c.depth < 16
+)
+SELECT a.name AS ancestor, d.name AS descendant, c.depth
+FROM closure c
+JOIN business_types a ON a.id = c.ancestor_id
+JOIN business_types d ON d.id = c.descendant_id;
@@ -0,0 +1,20 @@
+-- Создание таблицы замещаемости типов бизнеса: substitute частично конкурирует с business_type
+-- с долей weight (0–1]. Используется при расчете competition_density и для расширения поиска,
+-- когда для типа бизнеса не нашлось подходящих локаций. Связь направленная.
+CREATE TABLE IF NOT EXISTS business_type_substitutes (
+    business_type VARCHAR(255) NOT NULL REFERENCES business_types(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    substitute VARCHAR(255) NOT NULL REFERENCES business_types(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    weight DOUBLE PRECISION NOT NULL CHECK (weight > 0 AND weight <= 1),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (business_type, substitute),
+    CHECK (business_type <> substitute)
+);
+
+INSERT INTO business_type_substitutes (business_type, substitute, weight) VALUES
+    ('barbershop', 'beauty_salon', 0.5),
+    ('beauty_salon', 'barbershop', 0.3),
+    ('cafe', 'restaurant', 0.6),
+    ('restaurant', 'cafe', 0.4),
+    ('tailoring', 'laundry', 0.2)
+ON CONFLICT (business_type, substitute) DO NOTHING;
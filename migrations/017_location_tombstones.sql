@@ -0,0 +1,9 @@
+-- Создание таблицы удаленных локаций для ленты изменений: удаление фиксируется в одной
+-- транзакции с удалением из locations, повторное создание локации удаляет запись.
+CREATE TABLE IF NOT EXISTS location_tombstones (
+    id VARCHAR(255) PRIMARY KEY,
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_location_tombstones_deleted_at ON location_tombstones(deleted_at, id);
+CREATE INDEX IF NOT EXISTS idx_locations_updated_at_id ON locations(updated_at, id);
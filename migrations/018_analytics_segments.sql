@@ -0,0 +1,17 @@
+-- Создание таблицы материализованной аналитики: агрегаты локаций по региону и типу бизнеса,
+-- рассчитанные в Elasticsearch. Таблица полностью заменяется при каждом обновлении и служит
+-- источником /analytics, когда Elasticsearch перегружен или недоступен.
+CREATE TABLE IF NOT EXISTS analytics_segments (
+    region VARCHAR(255) NOT NULL,
+    business_type VARCHAR(255) NOT NULL,
+    location_count INTEGER NOT NULL,
+    avg_traffic_score DOUBLE PRECISION NOT NULL,
+    p50_traffic_score DOUBLE PRECISION NOT NULL,
+    p90_traffic_score DOUBLE PRECISION NOT NULL,
+    avg_competition_density DOUBLE PRECISION NOT NULL,
+    avg_income DOUBLE PRECISION NOT NULL,
+    refreshed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (region, business_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_segments_business_type ON analytics_segments(business_type);
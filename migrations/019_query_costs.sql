@@ -0,0 +1,17 @@
+-- Создание таблицы учета стоимости поисковых запросов: суммарные показатели запросов
+-- к Elasticsearch по клиентам API за день и отметка об отправленном алерте превышения бюджета.
+CREATE TABLE IF NOT EXISTS query_costs (
+    subject VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    organization VARCHAR(255) NOT NULL DEFAULT '',
+    requests BIGINT NOT NULL DEFAULT 0,
+    searches BIGINT NOT NULL DEFAULT 0,
+    took_ms BIGINT NOT NULL DEFAULT 0,
+    hits BIGINT NOT NULL DEFAULT 0,
+    buckets BIGINT NOT NULL DEFAULT 0,
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+    budget_alerted_at TIMESTAMP,
+    PRIMARY KEY (subject, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_costs_day ON query_costs(day);
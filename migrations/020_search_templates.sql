@@ -0,0 +1,15 @@
+-- Создание таблицы версий шаблонов поиска (mustache), хранимых в кластере как stored scripts.
+-- Версия шаблона сохраняется в кластере под ID "<name>-v<version>" при активации.
+CREATE TABLE IF NOT EXISTS search_templates (
+    name VARCHAR(64) NOT NULL,
+    version INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP,
+    PRIMARY KEY (name, version)
+);
+
+-- Для каждого шаблона активна не более чем одна версия
+CREATE UNIQUE INDEX IF NOT EXISTS idx_search_templates_active ON search_templates(name) WHERE active;
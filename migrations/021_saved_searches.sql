@@ -0,0 +1,14 @@
+-- Создание таблицы сохраненных поисков: при появлении или изменении локации, подходящей
+-- под фильтры поиска, владелец получает уведомление. Фильтры поиска также регистрируются
+-- запросами percolator в индексе сохраненных поисков Elasticsearch.
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id VARCHAR(64) PRIMARY KEY,
+    organization VARCHAR(255) NOT NULL,
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    request JSONB NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_organization ON saved_searches(organization);
@@ -0,0 +1,11 @@
+-- Создание таблицы ранжирования по умолчанию организаций и API ключей: веса факторов
+-- для запросов без weights и бусты полей поверх профиля ранжирования типа бизнеса.
+-- Пустой subject задает значения для всей организации, иначе — для клиента (API ключа).
+CREATE TABLE IF NOT EXISTS ranking_overrides (
+    organization VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    weights JSONB,
+    boosts JSONB NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization, subject)
+);
@@ -0,0 +1,12 @@
+-- Создание таблицы экземпляров сервиса для отображения ролей в кластере.
+-- Экземпляры с включенным выбором лидера периодически обновляют heartbeat_at;
+-- записи без обновления дольше нескольких интервалов считаются остановленными.
+CREATE TABLE IF NOT EXISTS cluster_instances (
+    id VARCHAR(255) PRIMARY KEY,
+    role VARCHAR(20) NOT NULL,
+    version VARCHAR(100) NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL,
+    heartbeat_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_instances_heartbeat ON cluster_instances(heartbeat_at);
@@ -0,0 +1,25 @@
+-- Создание таблицы документов, принятых на асинхронную индексацию (POST /locations/async).
+-- Документ доставляется в Elasticsearch записью outbox outbox_id; relay обновляет его состояние
+-- в той же транзакции. next_callback_at задает время отправки уведомления на callback_url
+-- (NULL — уведомление не ожидается).
+CREATE TABLE IF NOT EXISTS async_index_items (
+    tracking_id VARCHAR(64) PRIMARY KEY,
+    batch_id VARCHAR(64) NOT NULL,
+    location_id VARCHAR(255) NOT NULL,
+    outbox_id BIGINT,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('pending', 'indexed', 'failed', 'rejected')),
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    callback_url TEXT NOT NULL DEFAULT '',
+    callback_attempts INTEGER NOT NULL DEFAULT 0,
+    callback_error TEXT,
+    callback_delivered_at TIMESTAMP,
+    next_callback_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_async_index_items_batch ON async_index_items(batch_id);
+CREATE INDEX IF NOT EXISTS idx_async_index_items_outbox ON async_index_items(outbox_id) WHERE outbox_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_async_index_items_callbacks ON async_index_items(next_callback_at) WHERE next_callback_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_async_index_items_completed ON async_index_items(completed_at);
@@ -0,0 +1,23 @@
+-- Создание таблицы API ключей внешних клиентов (партнеров), выпускаемых через /admin/api-keys.
+-- Хранится только SHA-256 хеш ключа; prefix — начало ключа для опознания в списке.
+-- daily_quota ограничивает число запросов ключа за сутки UTC (0 — без ограничения).
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(64) PRIMARY KEY,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    prefix VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    organization VARCHAR(255) NOT NULL DEFAULT '',
+    subject VARCHAR(255) NOT NULL,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    daily_quota INTEGER NOT NULL DEFAULT 0 CHECK (daily_quota >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Число запросов ключа по дням (UTC), когда квоты учитываются в PostgreSQL.
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id VARCHAR(64) NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
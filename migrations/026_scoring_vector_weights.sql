@@ -0,0 +1,15 @@
+-- Создание таблицы весов векторного поиска в профиле ранжирования типа бизнеса: вклад сходства
+-- embedding описания и вектора демографического профиля района, если запрос задает оба вектора.
+-- Типы бизнеса без строки используют равные веса.
+CREATE TABLE IF NOT EXISTS scoring_vector_weights (
+    business_type VARCHAR(255) PRIMARY KEY,
+    description_weight DOUBLE PRECISION NOT NULL CHECK (description_weight >= 0),
+    demographics_weight DOUBLE PRECISION NOT NULL CHECK (demographics_weight >= 0),
+    CHECK (description_weight + demographics_weight > 0)
+);
+
+-- Магазины у дома зависят от жителей района больше, чем от описания помещения
+INSERT INTO scoring_vector_weights (business_type, description_weight, demographics_weight) VALUES
+    ('grocery_store', 0.3, 0.7),
+    ('pharmacy', 0.4, 0.6)
+ON CONFLICT (business_type) DO NOTHING;
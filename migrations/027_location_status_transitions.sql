@@ -0,0 +1,15 @@
+-- Создание журнала переходов локаций между состояниями жизненного цикла (draft, published,
+-- retired). Текущее состояние хранится в документе локации (поле status); журнал пишется
+-- в одной транзакции с изменением документа и не удаляется вместе с локацией.
+CREATE TABLE IF NOT EXISTS location_status_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    location_id VARCHAR(255) NOT NULL,
+    from_status VARCHAR(32) NOT NULL,
+    to_status VARCHAR(32) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    organization VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_location_status_transitions_location ON location_status_transitions(location_id, created_at);
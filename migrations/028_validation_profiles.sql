@@ -0,0 +1,11 @@
+-- Создание таблицы профилей проверки локаций по типам бизнеса: обязательные поля (required —
+-- массив имен полей) и допустимые диапазоны числовых полей (ranges — массив
+-- {"field": ..., "gte": ..., "lte": ...}). Профиль применяется при записи и импорте локаций,
+-- у которых тип бизнеса входит в business_types_suitable.
+CREATE TABLE IF NOT EXISTS validation_profiles (
+    business_type VARCHAR(255) PRIMARY KEY,
+    required JSONB NOT NULL DEFAULT '[]',
+    ranges JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- Создание справочника городов: город относится к региону, имеет координаты центра
+-- и численность населения. Центр города служит точкой отсчета фактора расстояния в запросах
+-- рекомендаций без origin; город локации сверяется со справочником при записи и импорте.
+-- Регион с городами не удаляется: сначала нужно удалить или перенести его города.
+CREATE TABLE IF NOT EXISTS cities (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    region_id INTEGER NOT NULL REFERENCES regions(id),
+    center_lat DOUBLE PRECISION NOT NULL,
+    center_lon DOUBLE PRECISION NOT NULL,
+    population INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(name, region_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cities_region ON cities(region_id);
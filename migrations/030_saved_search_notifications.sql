@@ -0,0 +1,14 @@
+-- Создание очереди уведомлений сохраненных поисков. Relay outbox ставит в очередь по записи на каждый
+-- поиск, совпавший с проиндексированной локацией, а отдельный воркер отправляет их на webhook_url поиска.
+-- Запись удаляется после отправки или исчерпания попыток; next_attempt_at задает время следующей попытки.
+CREATE TABLE IF NOT EXISTS saved_search_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    saved_search_id VARCHAR(64) NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    location JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_search_notifications_next ON saved_search_notifications(next_attempt_at);
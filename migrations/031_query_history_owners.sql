@@ -0,0 +1,7 @@
+-- Создание таблицы организаций, выполнивших запросы рекомендаций. Снимок выдачи по query_id может
+-- создать только организация запроса; записи истории без строки здесь (до миграции или без
+-- аутентификации) относятся к пустой организации.
+CREATE TABLE IF NOT EXISTS query_history_owners (
+    query_id VARCHAR(64) PRIMARY KEY REFERENCES query_history(id) ON DELETE CASCADE,
+    organization VARCHAR(255) NOT NULL
+);